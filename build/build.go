@@ -0,0 +1,467 @@
+// Package build runs generators over a whole project of schemas, for embedding yema builds in CI systems
+// and task runners without shelling out to the CLI. yema build uses it as well.
+package build
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/aep/yema"
+	"github.com/aep/yema/cue"
+	"github.com/aep/yema/example"
+	"github.com/aep/yema/golang"
+	"github.com/aep/yema/internal/ident"
+	"github.com/aep/yema/jsonschema"
+	"github.com/aep/yema/parser"
+	"github.com/aep/yema/rust"
+	"github.com/aep/yema/transform"
+	"github.com/aep/yema/typescript"
+	"github.com/aep/yema/vet"
+	"github.com/aep/yema/wire"
+	"gopkg.in/yaml.v3"
+)
+
+// Config describes a project build, typically loaded from a yaml file
+type Config struct {
+	// Dir is the directory relative paths are resolved against, defaults to the working directory.
+	// LoadConfig sets it to the directory of the configuration file.
+	Dir string `yaml:"-"`
+	// Schemas are the schema files to build, as paths or glob patterns
+	Schemas []string `yaml:"schemas"`
+	// Targets are the generators run for every schema
+	Targets []Target `yaml:"targets"`
+	// AllowAnyFieldName is passed on to the parser, see parser.Options
+	AllowAnyFieldName bool `yaml:"allowAnyFieldName"`
+	// SkipVet disables the semantic checks of package vet before generating
+	SkipVet bool `yaml:"skipVet"`
+	// Concurrency is the number of schemas built in parallel, defaults to the number of CPUs
+	Concurrency int `yaml:"concurrency"`
+}
+
+// Target is a generator along with its options
+type Target struct {
+	// Generator is one of cue, jsonschema, golang, typescript, zod, rust, wire or example
+	Generator string `yaml:"generator"`
+	// Out is the directory the generated files are written to, one per schema named after it
+	Out string `yaml:"out"`
+	// Package is the package name of generated Go code
+	Package string `yaml:"package"`
+	// Module is the module name of generated Rust code
+	Module string `yaml:"module"`
+	// Namespace is the namespace of generated TypeScript code
+	Namespace string `yaml:"namespace"`
+	// FileStyle is the kind of TypeScript file, declaration files are written with a .d.ts extension,
+	// see typescript.Options
+	FileStyle string `yaml:"fileStyle"`
+	// StructStyle declares the structs of TypeScript code as interfaces or aliases, see typescript.Options
+	StructStyle string `yaml:"structStyle"`
+	// StructStyles overrides StructStyle by the name of a struct, see typescript.Options
+	StructStyles map[string]typescript.StructStyle `yaml:"structStyles"`
+	// Closed rejects the fields structs of CUE definitions do not declare, see cue.Options
+	Closed bool `yaml:"closed"`
+	// Validators generates validation code (golang, typescript, rust)
+	Validators bool `yaml:"validators"`
+	// Constructors generates constructors with functional options for Go structs, see golang.Options
+	Constructors bool `yaml:"constructors"`
+	// Enums generates types for the string enums of Go and Rust code, see golang.Options and rust.Options
+	Enums bool `yaml:"enums"`
+	// Getters generates protobuf-style getters for the fields of Go code, see golang.Options
+	Getters bool `yaml:"getters"`
+	// Clones generates Clone methods deep copying the structs of Go code, see golang.Options
+	Clones bool `yaml:"clones"`
+	// Parsers generates functions decoding JSON into the root type of Go code, see golang.Options
+	Parsers bool `yaml:"parsers"`
+	// Strict makes generated Go parsers fail for fields the schema does not declare
+	Strict bool `yaml:"strict"`
+	// Proto shapes Go structs like those of protoc-gen-go for the protobuf JSON mapping, see golang.Options
+	Proto bool `yaml:"proto"`
+	// TimeTypes maps timestamps and durations of Go code to package time, see golang.Options
+	TimeTypes bool `yaml:"timeTypes"`
+	// TypeOverrides replaces the types of fields of Go or Rust code by path, see golang.Options and rust.Options
+	TypeOverrides map[string]string `yaml:"typeOverrides"`
+	// BTreeMaps generates BTreeMap instead of HashMap for the maps of Rust code, see rust.Options
+	BTreeMaps bool `yaml:"btreeMaps"`
+	// TimeCrate is the crate timestamps and dates of Rust code are mapped to, chrono or time, see rust.Options
+	TimeCrate string `yaml:"timeCrate"`
+	// EncodedBytes writes the bytes of Rust code as strings in the encoding of their format, and converts those
+	// of TypeScript code from and to them, see rust.Options and typescript.Options
+	EncodedBytes bool `yaml:"encodedBytes"`
+	// NoStd generates Rust code for no_std crates with alloc, see rust.Options
+	NoStd bool `yaml:"noStd"`
+	// ValidatorCrate derives Validate of the validator crate for the structs of Rust code, see rust.Options
+	ValidatorCrate bool `yaml:"validatorCrate"`
+	// URLCrate maps the uri strings of Rust code to url::Url, see rust.Options
+	URLCrate bool `yaml:"urlCrate"`
+	// DefaultCollections generates the optional lists and maps of Rust code as empty if missing, see rust.Options
+	DefaultCollections bool `yaml:"defaultCollections"`
+	// DefaultStrings generates the optional strings of Rust code as empty if missing, see rust.Options
+	DefaultStrings bool `yaml:"defaultStrings"`
+	// TypeDerives adds derives to the generated types of Rust code by name, see rust.Options
+	TypeDerives map[string][]string `yaml:"typeDerives"`
+	// TypeAttributes adds attributes to the generated types of Rust code by name, see rust.Options
+	TypeAttributes map[string][]string `yaml:"typeAttributes"`
+	// Transliterate derives identifiers from ASCII transliterations of non-ASCII field names (golang, rust, typescript, cue)
+	Transliterate bool `yaml:"transliterate"`
+	// ProtoJSON follows the protobuf JSON mapping (golang, typescript)
+	ProtoJSON bool `yaml:"protojson"`
+	// Guards generates type guards for the types of TypeScript code, see typescript.Options
+	Guards bool `yaml:"guards"`
+	// EnumStyle generates a type for every enum of TypeScript code, see typescript.Options
+	EnumStyle string `yaml:"enumStyle"`
+	// UseMaps generates the maps of TypeScript code as a Map, see typescript.Options
+	UseMaps bool `yaml:"useMaps"`
+	// Brands generates branded types for the uuid strings of TypeScript code, see typescript.Options
+	Brands bool `yaml:"brands"`
+	// BrandNames renames the brands of TypeScript code by the name of their field, see typescript.Options
+	BrandNames map[string]string `yaml:"brandNames"`
+	// Dates types the timestamps of TypeScript code as Date, see typescript.Options
+	Dates bool `yaml:"dates"`
+	// CamelCase names the properties of TypeScript code in camelCase, see typescript.Options
+	CamelCase bool `yaml:"camelCase"`
+	// NullableOptionals types the optional fields of TypeScript code as T | null as well, see typescript.Options
+	NullableOptionals bool `yaml:"nullableOptionals"`
+	// ExactOptionals types the optional fields of TypeScript code as T | undefined as well, see typescript.Options
+	ExactOptionals bool `yaml:"exactOptionals"`
+	// Tags are the struct tags of generated Go code, such as json or yaml:snake, see golang.ParseTag
+	Tags []string `yaml:"tags"`
+	// TagTemplates are extra struct tags of generated Go code by name, see golang.Options
+	TagTemplates map[string]string `yaml:"tagTemplates"`
+	// Direction generates the request or response variant of every schema, see package transform
+	Direction string `yaml:"direction"`
+}
+
+// extensions are the file extensions of the output of each generator
+var extensions = map[string]string{
+	"cue":        ".cue",
+	"jsonschema": ".schema.json",
+	"golang":     ".go",
+	"typescript": ".ts",
+	"zod":        ".zod.ts",
+	"rust":       ".rs",
+	"wire":       ".wire.json",
+	"example":    ".example.json",
+}
+
+// Report is the machine-readable result of a build
+type Report struct {
+	Schemas []SchemaReport `json:"schemas"`
+}
+
+// Failed returns true if any schema failed to build
+func (r *Report) Failed() bool {
+	for _, s := range r.Schemas {
+		if s.Error != "" || len(s.Problems) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// SchemaReport is the result of building a single schema
+type SchemaReport struct {
+	// Path is the schema file
+	Path string `json:"path"`
+	// Outputs are the files written for the schema
+	Outputs []Output `json:"outputs,omitempty"`
+	// Problems are the findings of vet, nothing is generated for a schema with problems
+	Problems []string `json:"problems,omitempty"`
+	// Error is the reason the schema failed to build, if any
+	Error string `json:"error,omitempty"`
+}
+
+// Output is a generated file
+type Output struct {
+	Generator string `json:"generator"`
+	Path      string `json:"path"`
+}
+
+// LoadConfig reads a build configuration file
+func LoadConfig(path string) (Config, error) {
+	var cfg Config
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed parsing build config: %w", err)
+	}
+	cfg.Dir = filepath.Dir(path)
+	return cfg, nil
+}
+
+// Build builds every schema of the project concurrently and reports the result of each.
+// Failures of single schemas are recorded in the report, the error is only set for an invalid
+// configuration or when ctx is done, in which case schemas not yet built are missing from the report.
+func Build(ctx context.Context, cfg Config) (Report, error) {
+	var report Report
+
+	for _, target := range cfg.Targets {
+		if _, ok := extensions[target.Generator]; !ok {
+			return report, fmt.Errorf("unsupported generator %q", target.Generator)
+		}
+		if target.Out == "" {
+			return report, fmt.Errorf("no output directory for generator %s", target.Generator)
+		}
+		if _, err := transform.Direction(&yema.Type{}, target.Direction); err != nil {
+			return report, fmt.Errorf("generator %s: %w", target.Generator, err)
+		}
+		if _, err := goTags(target.Tags); err != nil {
+			return report, fmt.Errorf("generator %s: %w", target.Generator, err)
+		}
+	}
+
+	paths, err := expand(cfg)
+	if err != nil {
+		return report, err
+	}
+
+	concurrency := cfg.Concurrency
+	if concurrency <= 0 {
+		concurrency = runtime.NumCPU()
+	}
+
+	results := make([]*SchemaReport, len(paths))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = buildSchema(ctx, cfg, paths[i])
+			}
+		}()
+	}
+
+feed:
+	for i := range paths {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, r := range results {
+		if r != nil {
+			report.Schemas = append(report.Schemas, *r)
+		}
+	}
+
+	return report, ctx.Err()
+}
+
+// expand resolves the schema patterns to a sorted list of unique files
+func expand(cfg Config) ([]string, error) {
+	seen := make(map[string]bool)
+	var paths []string
+	for _, pattern := range cfg.Schemas {
+		matches, err := filepath.Glob(resolve(cfg, pattern))
+		if err != nil {
+			return nil, fmt.Errorf("invalid schema pattern %q: %w", pattern, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no schemas match %q", pattern)
+		}
+		for _, path := range matches {
+			if !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+	sort.Strings(paths)
+
+	// outputs are named after the schema, so two schemas of the same name would overwrite each other
+	names := make(map[string]string)
+	for _, path := range paths {
+		name := schemaName(path)
+		if other, ok := names[name]; ok {
+			return nil, fmt.Errorf("schemas %s and %s generate the same files", other, path)
+		}
+		names[name] = path
+	}
+
+	return paths, nil
+}
+
+func resolve(cfg Config, path string) string {
+	if cfg.Dir == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(cfg.Dir, path)
+}
+
+// schemaName is the name of the files generated for a schema
+func schemaName(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
+
+func buildSchema(ctx context.Context, cfg Config, path string) *SchemaReport {
+	r := &SchemaReport{Path: path}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+
+	t, err := parser.FromYAMLWithOptions(data, parser.Options{AllowAnyFieldName: cfg.AllowAnyFieldName})
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+
+	if !cfg.SkipVet {
+		for _, p := range append(vet.Vet(t), vet.Defs(data)...) {
+			r.Problems = append(r.Problems, p.String())
+		}
+		if len(r.Problems) != 0 {
+			return r
+		}
+	}
+
+	name := schemaName(path)
+	for _, target := range cfg.Targets {
+		if ctx.Err() != nil {
+			r.Error = ctx.Err().Error()
+			return r
+		}
+
+		out, err := Generate(t, target, ident.Camel(name))
+		if err != nil {
+			r.Error = fmt.Sprintf("%s: %v", target.Generator, err)
+			return r
+		}
+
+		dir := resolve(cfg, target.Out)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			r.Error = err.Error()
+			return r
+		}
+		ext := extensions[target.Generator]
+		if target.Generator == "typescript" && target.FileStyle == string(typescript.Declarations) {
+			ext = ".d.ts"
+		}
+		outPath := filepath.Join(dir, name+ext)
+		if err := os.WriteFile(outPath, out, 0o644); err != nil {
+			r.Error = err.Error()
+			return r
+		}
+		r.Outputs = append(r.Outputs, Output{Generator: target.Generator, Path: outPath})
+	}
+
+	return r
+}
+
+// Generate runs the generator of target on a single schema, typeName is the name of the root type in generated code
+func Generate(t *yema.Type, target Target, typeName string) ([]byte, error) {
+	t, err := transform.Direction(t, target.Direction)
+	if err != nil {
+		return nil, err
+	}
+
+	switch target.Generator {
+	case "cue":
+		value, err := cue.ToCue(cuecontext.New(), t, cue.Options{RootType: typeName, Closed: target.Closed, Transliterate: target.Transliterate})
+		if err != nil {
+			return nil, err
+		}
+		return cue.Format(value)
+	case "jsonschema":
+		return jsonschema.ToJSONSchema(t)
+	case "golang":
+		tags, err := goTags(target.Tags)
+		if err != nil {
+			return nil, err
+		}
+		return golang.ToGolang(t, golang.Options{
+			Package:           target.Package,
+			RootType:          typeName,
+			Transliterate:     target.Transliterate,
+			ProtoJSON:         target.ProtoJSON,
+			Tags:              tags,
+			TagTemplates:      target.TagTemplates,
+			Validators:        target.Validators,
+			Constructors:      target.Constructors,
+			Enums:             target.Enums,
+			Getters:           target.Getters,
+			Clones:            target.Clones,
+			Parsers:           target.Parsers,
+			DenyUnknownFields: target.Strict,
+			TimeTypes:         target.TimeTypes,
+			Proto:             target.Proto,
+			TypeOverrides:     target.TypeOverrides,
+		})
+	case "typescript", "zod":
+		return typescript.ToTypeScript(t, typescript.Options{
+			Namespace:         target.Namespace,
+			Style:             typescript.FileStyle(target.FileStyle),
+			RootType:          typeName,
+			Structs:           typescript.StructStyle(target.StructStyle),
+			StructStyles:      target.StructStyles,
+			ExportAll:         true,
+			Validators:        target.Validators,
+			Transliterate:     target.Transliterate,
+			ProtoJSON:         target.ProtoJSON,
+			Zod:               target.Generator == "zod",
+			Guards:            target.Guards,
+			Enums:             typescript.EnumStyle(target.EnumStyle),
+			UseMaps:           target.UseMaps,
+			Brands:            target.Brands,
+			BrandNames:        target.BrandNames,
+			Dates:             target.Dates,
+			CamelCase:         target.CamelCase,
+			EncodedBytes:      target.EncodedBytes,
+			NullableOptionals: target.NullableOptionals,
+			ExactOptionals:    target.ExactOptionals,
+		})
+	case "rust":
+		return rust.ToRust(t, rust.Options{
+			Module:             target.Module,
+			RootType:           typeName,
+			UseSerdeRename:     true,
+			Validators:         target.Validators,
+			Transliterate:      target.Transliterate,
+			Enums:              target.Enums,
+			BTreeMaps:          target.BTreeMaps,
+			TimeCrate:          rust.TimeCrate(target.TimeCrate),
+			EncodedBytes:       target.EncodedBytes,
+			NoStd:              target.NoStd,
+			ValidatorCrate:     target.ValidatorCrate,
+			URLCrate:           target.URLCrate,
+			DefaultCollections: target.DefaultCollections,
+			DefaultStrings:     target.DefaultStrings,
+			TypeOverrides:      target.TypeOverrides,
+			TypeDerives:        target.TypeDerives,
+			TypeAttributes:     target.TypeAttributes,
+		})
+	case "wire":
+		return wire.Encode(t)
+	case "example":
+		return example.ToJSON(t)
+	}
+	return nil, fmt.Errorf("unsupported generator %q", target.Generator)
+}
+
+// goTags parses the struct tags of a target
+func goTags(specs []string) ([]golang.Tag, error) {
+	var tags []golang.Tag
+	for _, spec := range specs {
+		tag, err := golang.ParseTag(spec)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
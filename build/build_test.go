@@ -0,0 +1,96 @@
+package build
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuild(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "schemas"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("schemas/person.yaml", "name: string\nage?: int\n")
+	write("schemas/order.yaml", "id: int64\nitems: [string]\n")
+	write("schemas/broken.yaml", "id: nope\n")
+	write("yema-build.yaml", `
+schemas: ["schemas/*.yaml"]
+targets:
+  - generator: golang
+    out: gen/go
+    package: models
+  - generator: jsonschema
+    out: gen/schema
+`)
+	cfg, err := LoadConfig(filepath.Join(dir, "yema-build.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := Build(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Schemas) != 3 || !report.Failed() {
+		t.Fatalf("unexpected report %+v", report)
+	}
+
+	broken, order, person := report.Schemas[0], report.Schemas[1], report.Schemas[2]
+	if broken.Error == "" || len(broken.Outputs) != 0 {
+		t.Errorf("expected broken schema to fail, got %+v", broken)
+	}
+	if order.Error != "" || len(order.Outputs) != 2 {
+		t.Errorf("expected order schema to build, got %+v", order)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "gen", "go", "person.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "package models") || !strings.Contains(string(data), "type Person struct") {
+		t.Errorf("unexpected generated code:\n%s", data)
+	}
+	if person.Outputs[1].Path != filepath.Join(dir, "gen", "schema", "person.schema.json") {
+		t.Errorf("unexpected output %+v", person.Outputs[1])
+	}
+}
+
+func TestBuildConfigErrors(t *testing.T) {
+	tests := []Config{
+		{Schemas: []string{"*.yaml"}, Targets: []Target{{Generator: "cobol", Out: "gen"}}},
+		{Schemas: []string{"*.yaml"}, Targets: []Target{{Generator: "golang"}}},
+		{Dir: t.TempDir(), Schemas: []string{"*.yaml"}},
+	}
+
+	for _, cfg := range tests {
+		if _, err := Build(context.Background(), cfg); err == nil {
+			t.Errorf("expected error for config %+v", cfg)
+		}
+	}
+}
+
+func TestBuildCancelled(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.yaml"), []byte("a: int\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := Build(ctx, Config{Dir: dir, Schemas: []string{"*.yaml"}, Targets: []Target{{Generator: "golang", Out: "gen"}}})
+	if err != context.Canceled {
+		t.Errorf("got error %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,330 @@
+// Package bundle combines a schema and the schemas it references into a single self-contained document,
+// for publishing schemas to consumers that cannot fetch their dependencies.
+//
+// A reference to another document names a file relative to the referencing document, or a URL:
+//
+//	home:
+//	  $ref: address.yaml
+//	work:
+//	  $ref: https://example.com/schemas/common.yaml#/$defs/address
+//
+// Referenced types are hoisted into $defs of the bundled schema, once per distinct target,
+// and every reference is rewritten to point there.
+package bundle
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/aep/yema/internal/ident"
+	"github.com/aep/yema/parser"
+	"gopkg.in/yaml.v3"
+)
+
+// Options holds configuration options for bundling
+type Options struct {
+	// Fetch loads the document at a http or https URL, defaults to a GET request with http.DefaultClient
+	Fetch func(url string) ([]byte, error)
+}
+
+// Bundle loads the schema at entry, a file path or URL, and resolves all references to other documents.
+// The root of the schema must be a mapping to hold $defs.
+func Bundle(entry string, opts Options) (*yaml.Node, error) {
+	if opts.Fetch == nil {
+		opts.Fetch = httpGet
+	}
+
+	b := &bundler{
+		opts:  opts,
+		docs:  make(map[string]*yaml.Node),
+		names: make(map[string]string),
+		used:  make(map[string]bool),
+	}
+
+	location, err := absLocation(entry)
+	if err != nil {
+		return nil, err
+	}
+	b.entry = location
+
+	doc, err := b.load(location)
+	if err != nil {
+		return nil, err
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s: references can only be bundled into a schema with a mapping at the root", entry)
+	}
+
+	b.defs = mappingValue(root, parser.DefsKey)
+	if b.defs == nil {
+		b.defs = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		root.Content = append(root.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: parser.DefsKey},
+			b.defs,
+		)
+	}
+	for i := 0; i+1 < len(b.defs.Content); i += 2 {
+		name := b.defs.Content[i].Value
+		b.used[name] = true
+		b.names[location+"#"+strings.TrimPrefix(parser.RefPrefix, "#")+name] = name
+	}
+
+	if err := b.rewrite(root, location); err != nil {
+		return nil, err
+	}
+
+	if len(b.defs.Content) == 0 {
+		removeKey(root, parser.DefsKey)
+	}
+
+	return doc, nil
+}
+
+type bundler struct {
+	opts  Options
+	entry string
+	// docs are the loaded documents by location
+	docs map[string]*yaml.Node
+	// names are the names of hoisted types by location and fragment
+	names map[string]string
+	// used are the names taken in defs
+	used map[string]bool
+	defs *yaml.Node
+}
+
+// rewrite replaces the references in the type declared by node, which was loaded from location
+func (b *bundler) rewrite(node *yaml.Node, location string) error {
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, child := range node.Content {
+			if err := b.rewrite(child, location); err != nil {
+				return err
+			}
+		}
+
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i].Value, node.Content[i+1]
+			switch key {
+			case parser.TestsKey, parser.ExampleKey:
+				// Data, not types
+			case parser.RefKey:
+				if value.Kind != yaml.ScalarNode {
+					return fmt.Errorf("%s:%d: %s must be a string", location, value.Line, parser.RefKey)
+				}
+				name, err := b.hoist(value.Value, location)
+				if err != nil {
+					return fmt.Errorf("%s:%d: %w", location, value.Line, err)
+				}
+				if name != "" {
+					value.Value = parser.RefPrefix + name
+				}
+			default:
+				if err := b.rewrite(value, location); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// hoist adds the type a reference points to to defs, unless already done, and returns its name.
+// It returns an empty name for references that stay as they are.
+func (b *bundler) hoist(ref, location string) (string, error) {
+	target, fragment, _ := strings.Cut(ref, "#")
+	if target == "" {
+		if location == b.entry {
+			return "", nil
+		}
+		target = location
+	} else {
+		var err error
+		target, err = resolveLocation(location, target)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	key := target + "#" + fragment
+	if name, ok := b.names[key]; ok {
+		return name, nil
+	}
+
+	doc, err := b.load(target)
+	if err != nil {
+		return "", err
+	}
+
+	var decl *yaml.Node
+	var name string
+	switch {
+	case fragment == "":
+		decl = rootType(doc.Content[0])
+		name = baseName(target)
+	case strings.HasPrefix("#"+fragment, parser.RefPrefix):
+		name = strings.TrimPrefix("#"+fragment, parser.RefPrefix)
+		if defs := mappingValue(doc.Content[0], parser.DefsKey); defs != nil {
+			if def := mappingValue(defs, name); def != nil {
+				decl = copyNode(def)
+			}
+		}
+		if decl == nil {
+			return "", fmt.Errorf("%s declares no type %q", target, name)
+		}
+	default:
+		return "", fmt.Errorf("unsupported reference %q, expected a document or #/$defs/name", ref)
+	}
+
+	name = b.uniqueName(name)
+	b.names[key] = name
+	b.defs.Content = append(b.defs.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}, decl)
+
+	// Set the name before rewriting, so references back to the type do not hoist it again
+	if err := b.rewrite(decl, target); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+func (b *bundler) uniqueName(name string) string {
+	unique := name
+	for i := 2; b.used[unique]; i++ {
+		unique = name + "_" + strconv.Itoa(i)
+	}
+	b.used[unique] = true
+	return unique
+}
+
+// load reads and decodes the document at location, once
+func (b *bundler) load(location string) (*yaml.Node, error) {
+	if doc, ok := b.docs[location]; ok {
+		return doc, nil
+	}
+
+	var data []byte
+	var err error
+	if isURL(location) {
+		data, err = b.opts.Fetch(location)
+	} else {
+		data, err = os.ReadFile(location)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", location, err)
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) != 1 {
+		return nil, fmt.Errorf("%s: empty schema", location)
+	}
+
+	b.docs[location] = &doc
+	return &doc, nil
+}
+
+// rootType returns a copy of the root type of a document, without the root keys that are not fields
+func rootType(root *yaml.Node) *yaml.Node {
+	decl := copyNode(root)
+	removeKey(decl, parser.DefsKey)
+	removeKey(decl, parser.TestsKey)
+	return decl
+}
+
+// baseName derives the name of a hoisted document from its file name
+func baseName(location string) string {
+	base := location
+	if u, err := url.Parse(location); err == nil && isURL(location) {
+		base = u.Path
+	}
+	base = filepath.Base(base)
+	return ident.Snake(ident.ASCII(strings.TrimSuffix(base, filepath.Ext(base))))
+}
+
+func absLocation(location string) (string, error) {
+	if isURL(location) {
+		return location, nil
+	}
+	return filepath.Abs(location)
+}
+
+// resolveLocation resolves a reference relative to the document at base
+func resolveLocation(base, ref string) (string, error) {
+	if isURL(ref) {
+		return ref, nil
+	}
+	if isURL(base) {
+		u, err := url.Parse(base)
+		if err != nil {
+			return "", err
+		}
+		r, err := url.Parse(ref)
+		if err != nil {
+			return "", err
+		}
+		return u.ResolveReference(r).String(), nil
+	}
+	if filepath.IsAbs(ref) {
+		return ref, nil
+	}
+	return filepath.Join(filepath.Dir(base), ref), nil
+}
+
+func isURL(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+}
+
+func httpGet(location string) ([]byte, error) {
+	resp, err := http.Get(location)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: %s", location, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func removeKey(node *yaml.Node, key string) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+func copyNode(node *yaml.Node) *yaml.Node {
+	c := *node
+	c.Content = make([]*yaml.Node, len(node.Content))
+	for i, child := range node.Content {
+		c.Content[i] = copyNode(child)
+	}
+	return &c
+}
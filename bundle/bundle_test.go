@@ -0,0 +1,105 @@
+package bundle
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aep/yema/parser"
+	"gopkg.in/yaml.v3"
+)
+
+func TestBundle(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"entry.yaml": `
+name: string
+home:
+  $ref: common/address.yaml
+work?:
+  $ref: common/address.yaml
+tags:
+  - $ref: https://example.com/types.yaml#/$defs/tag
+`,
+		"common/address.yaml": `
+street: string
+geo:
+  $ref: "#/$defs/geo"
+$defs:
+  geo:
+    lat: float64
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var fetched []string
+	doc, err := Bundle(filepath.Join(dir, "entry.yaml"), Options{
+		Fetch: func(url string) ([]byte, error) {
+			fetched = append(fetched, url)
+			return []byte("$defs:\n  tag:\n    key: string\n"), nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(fetched) != 1 || fetched[0] != "https://example.com/types.yaml" {
+		t.Errorf("fetched %v", fetched)
+	}
+
+	out, err := yaml.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"$defs:", "address:", "geo:", "tag:"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("missing %q in bundled schema:\n%s", want, out)
+		}
+	}
+	if strings.Contains(string(out), ".yaml") {
+		t.Errorf("bundled schema still references other documents:\n%s", out)
+	}
+
+	schema, err := parser.FromNode(doc, parser.Options{})
+	if err != nil {
+		t.Fatalf("bundled schema is invalid: %v\n%s", err, out)
+	}
+	home := (*schema.Struct)["home"]
+	if got := strings.Join(home.FieldNames(), ","); got != "street,geo" {
+		t.Errorf("home fields = %s", got)
+	}
+}
+
+func TestBundleNameCollision(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "a"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "entry.yaml"), []byte("$defs:\n  address: string\nx:\n  $ref: a/address.yaml\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a", "address.yaml"), []byte("street: string\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := Bundle(filepath.Join(dir, "entry.yaml"), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema, err := parser.FromNode(doc, parser.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x := (*schema.Struct)["x"]; x.Struct == nil {
+		t.Errorf("expected x to reference the hoisted struct, got %+v", x)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+
+	"github.com/aep/yema/build"
+	"github.com/spf13/cobra"
+)
+
+var (
+	buildConfig string
+	buildJSON   bool
+)
+
+var buildCmd = &cobra.Command{
+	Use:   "build",
+	Short: "Generate code for every schema of a project",
+	Long: `Run generators for every schema of a project, as configured in a yaml file:
+  schemas: [schemas/*.yaml]
+  targets:
+    - generator: golang
+      out: gen/go
+      package: models
+    - generator: typescript
+      out: gen/ts
+
+Paths are relative to the configuration file. Each schema generates one file per target,
+named after the schema, with a root type named after it as well.
+
+Example:
+  yema build --config yema-build.yaml --json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := build.LoadConfig(buildConfig)
+		if err != nil {
+			log.Fatalf("Error loading build config: %v", err)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		report, err := build.Build(ctx, cfg)
+		if err != nil {
+			log.Fatalf("Build failed: %v", err)
+		}
+
+		if buildJSON {
+			out, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				log.Fatalf("Error encoding report: %v", err)
+			}
+			fmt.Println(string(out))
+		} else {
+			for _, s := range report.Schemas {
+				for _, p := range s.Problems {
+					fmt.Printf("%s: %s\n", s.Path, p)
+				}
+				if s.Error != "" {
+					fmt.Printf("%s: %s\n", s.Path, s.Error)
+				}
+				for _, o := range s.Outputs {
+					fmt.Printf("%s -> %s\n", s.Path, o.Path)
+				}
+			}
+		}
+
+		if report.Failed() {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	buildCmd.Flags().StringVar(&buildConfig, "config", "yema-build.yaml", "Build configuration file")
+	buildCmd.Flags().BoolVar(&buildJSON, "json", false, "Print the build report as json")
+	rootCmd.AddCommand(buildCmd)
+}
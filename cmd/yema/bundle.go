@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"os"
+
+	"github.com/aep/yema/bundle"
+	"github.com/aep/yema/parser"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var bundleOutput string
+
+var bundleCmd = &cobra.Command{
+	Use:   "bundle [schema]",
+	Short: "Combine a schema and the schemas it references into a single file",
+	Long: `Resolve every $ref to another file or URL and hoist the referenced types into $defs,
+producing one self-contained schema that can be published to consumers.
+
+Example:
+  yema bundle entry.yaml -o bundled.yaml`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		doc, err := bundle.Bundle(args[0], bundle.Options{})
+		if err != nil {
+			log.Fatalf("Error bundling schema: %v", err)
+		}
+
+		if _, err := parser.FromNode(doc, parserOptions()); err != nil {
+			log.Fatalf("Bundled schema is invalid: %v", err)
+		}
+
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(doc); err != nil {
+			log.Fatalf("Error encoding bundled schema: %v", err)
+		}
+
+		if bundleOutput == "" {
+			os.Stdout.Write(buf.Bytes())
+			return
+		}
+		if err := os.WriteFile(bundleOutput, buf.Bytes(), 0o644); err != nil {
+			log.Fatalf("Error writing bundled schema: %v", err)
+		}
+	},
+}
+
+func init() {
+	bundleCmd.Flags().StringVarP(&bundleOutput, "output", "o", "", "File to write the bundled schema to, defaults to stdout")
+	rootCmd.AddCommand(bundleCmd)
+}
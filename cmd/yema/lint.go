@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/aep/yema/lint"
+	"github.com/aep/yema/parser"
+	"github.com/spf13/cobra"
+)
+
+var (
+	lintConfig  string
+	lintTargets []string
+)
+
+var lintCmd = &cobra.Command{
+	Use:   "lint [schema...]",
+	Short: "Report schema constructs that generate poor code for selected targets",
+	Long: `Report schema constructs that generate poor or invalid code for the selected targets,
+such as field names that are Rust keywords or 64-bit integers used from TypeScript.
+
+Rules can be configured per project in a yaml file:
+  targets: [golang, typescript]
+  disable: [identifier-collision]
+  maxNesting: 3
+
+Example:
+  yema lint schema.yaml --config yema-lint.yaml`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var cfg lint.Config
+		if lintConfig != "" {
+			var err error
+			cfg, err = lint.LoadConfig(lintConfig)
+			if err != nil {
+				log.Fatalf("Error loading lint config: %v", err)
+			}
+		}
+		if len(lintTargets) != 0 {
+			cfg.Targets = lintTargets
+		}
+
+		found := 0
+		for _, path := range args {
+			schemaData, err := os.ReadFile(path)
+			if err != nil {
+				log.Fatalf("Error reading schema file: %v", err)
+			}
+
+			schema, err := parser.FromYAMLWithOptions(schemaData, parserOptions())
+			if err != nil {
+				log.Fatalf("Error parsing schema %s: %v", path, err)
+			}
+
+			for _, f := range lint.Lint(schema, cfg) {
+				found++
+				fmt.Printf("%s: %s\n", path, f)
+			}
+		}
+
+		if found != 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	lintCmd.Flags().StringVar(&lintConfig, "config", "", "Lint configuration file")
+	lintCmd.Flags().StringSliceVar(&lintTargets, "target", nil, "Targets to check for, overrides the config (golang, rust, typescript, sql)")
+	rootCmd.AddCommand(lintCmd)
+}
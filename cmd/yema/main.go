@@ -5,19 +5,22 @@ import (
 	"io"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/aep/yema/cue"
+	"github.com/aep/yema/example"
 	"github.com/aep/yema/golang"
 	"github.com/aep/yema/jsonschema"
 	"github.com/aep/yema/parser"
 	"github.com/aep/yema/rust"
+	"github.com/aep/yema/transform"
 	"github.com/aep/yema/typescript"
+	"github.com/aep/yema/vet"
+	"github.com/aep/yema/wire"
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v3"
 
 	"cuelang.org/go/cue/cuecontext"
-	"cuelang.org/go/cue/format"
 )
 
 var (
@@ -25,11 +28,51 @@ var (
 	codePackage      string
 	codeModuleName   string
 	codeTypeName     string
+	cueClosed        bool
 	tsNamespace      string
+	tsFileStyle      string
 	tsUseInterfaces  bool
+	tsStructStyles   []string
 	tsExportAll      bool
+	tsGuards         bool
+	tsEnumStyle      string
+	tsUseMaps        bool
+	tsBrands         bool
+	tsBrandNames     []string
+	tsDates          bool
+	tsCamelCase      bool
+	tsNullables      bool
+	tsExactOptionals bool
 	rustDeriveTraits string
 	rustUseRename    bool
+	rustBTreeMaps    bool
+	rustTimeCrate    string
+	encodedBytes     bool
+	rustNoStd        bool
+	rustValidator    bool
+	rustURLCrate     bool
+	rustDefaultColls bool
+	rustDefaultStrs  bool
+	rustTypeDerives  []string
+	rustTypeAttrs    []string
+	allowAnyNames    bool
+	genValidators    bool
+	genConstructors  bool
+	genEnums         bool
+	genGetters       bool
+	genClones        bool
+	genParsers       bool
+	genStrict        bool
+	genTimeTypes     bool
+	genProto         bool
+	runVet           bool
+	transliterate    bool
+	direction        string
+	protoJSON        bool
+	goTags           []string
+	goTagTemplates   []string
+	typeOverrides    []string
+	outDir           string
 )
 
 var rootCmd = &cobra.Command{
@@ -50,26 +93,43 @@ It can convert Yema schemas to various formats and validate data against schemas
 			input = file
 		}
 
-		var ys map[string]interface{}
-		err := yaml.NewDecoder(input).Decode(&ys)
+		data, err := io.ReadAll(input)
 		if err != nil {
-			log.Fatalf("Error parsing YAML: %v", err)
+			log.Fatalf("Error reading schema: %v", err)
 		}
 
-		yy, err := parser.From(ys)
+		yy, err := parser.FromYAMLWithOptions(data, parserOptions())
 		if err != nil {
 			log.Fatalf("Error parsing schema: %v", err)
 		}
 
+		if runVet {
+			if problems := append(vet.Vet(yy), vet.Defs(data)...); len(problems) != 0 {
+				for _, p := range problems {
+					fmt.Fprintln(os.Stderr, p)
+				}
+				log.Fatalf("Schema has %d problems, see yema vet", len(problems))
+			}
+		}
+
+		yy, err = transform.Direction(yy, direction)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+
 		switch outputFormat {
 		case "cue":
-			value, err := cue.ToCue(cuecontext.New(), yy)
+			// Documents are vetted against #Root unless the definition is named
+			cueOpts := cue.Options{Closed: cueClosed, Transliterate: transliterate}
+			if cmd.Flags().Changed("type") {
+				cueOpts.RootType = codeTypeName
+			}
+			value, err := cue.ToCue(cuecontext.New(), yy, cueOpts)
 			if err != nil {
 				log.Fatalf("Error parsing schema: %v", err)
 			}
 
-			node := value.Syntax()
-			bytes, err := format.Node(node)
+			bytes, err := cue.Format(value)
 			if err != nil {
 				log.Fatalf("Error formatting CUE: %v", err)
 			}
@@ -82,21 +142,110 @@ It can convert Yema schemas to various formats and validate data against schemas
 			}
 			fmt.Println(string(jsonBytes))
 		case "golang":
-			goBytes, err := golang.ToGolang(yy, golang.Options{
-				Package:  codePackage,
-				RootType: codeTypeName,
-			})
+			var tags []golang.Tag
+			for _, spec := range goTags {
+				tag, err := golang.ParseTag(spec)
+				if err != nil {
+					log.Fatalf("Error: %v", err)
+				}
+				tags = append(tags, tag)
+			}
+			// Templates may hold commas, so each is given in a flag of its own
+			templates := make(map[string]string)
+			for _, spec := range goTagTemplates {
+				name, tmpl, ok := strings.Cut(spec, "=")
+				if !ok {
+					log.Fatalf("Error: tag template %q is not of the form name=template", spec)
+				}
+				templates[name] = tmpl
+			}
+			goOpts := golang.Options{
+				Package:           codePackage,
+				RootType:          codeTypeName,
+				Transliterate:     transliterate,
+				ProtoJSON:         protoJSON,
+				Tags:              tags,
+				TagTemplates:      templates,
+				Validators:        genValidators,
+				Constructors:      genConstructors,
+				Enums:             genEnums,
+				Getters:           genGetters,
+				Clones:            genClones,
+				Parsers:           genParsers,
+				DenyUnknownFields: genStrict,
+				TimeTypes:         genTimeTypes,
+				Proto:             genProto,
+				TypeOverrides:     parseTypeOverrides(),
+			}
+			if outDir != "" {
+				files, err := golang.ToGolangFiles(yy, goOpts)
+				if err != nil {
+					log.Fatalf("Error generating Go structs: %v", err)
+				}
+				if err := writeFiles(outDir, files); err != nil {
+					log.Fatalf("Error: %v", err)
+				}
+				return
+			}
+			goBytes, err := golang.ToGolang(yy, goOpts)
 			if err != nil {
 				log.Fatalf("Error generating Go structs: %v", err)
 			}
 			fmt.Println(string(goBytes))
-		case "typescript":
-			tsBytes, err := typescript.ToTypeScript(yy, typescript.Options{
-				Namespace:     tsNamespace,
-				RootType:      codeTypeName,
-				UseInterfaces: tsUseInterfaces,
-				ExportAll:     tsExportAll,
-			})
+		case "typescript", "zod":
+			structs := typescript.Interfaces
+			if !tsUseInterfaces {
+				structs = typescript.TypeAliases
+			}
+			structStyles := make(map[string]typescript.StructStyle)
+			for _, spec := range tsStructStyles {
+				name, style, ok := strings.Cut(spec, "=")
+				if !ok {
+					log.Fatalf("Error: struct style %q is not of the form type=style", spec)
+				}
+				structStyles[name] = typescript.StructStyle(style)
+			}
+			brandNames := make(map[string]string)
+			for _, spec := range tsBrandNames {
+				name, renamed, ok := strings.Cut(spec, "=")
+				if !ok {
+					log.Fatalf("Error: brand name %q is not of the form field=brand", spec)
+				}
+				brandNames[name] = renamed
+			}
+			tsOpts := typescript.Options{
+				Namespace:         tsNamespace,
+				Style:             typescript.FileStyle(tsFileStyle),
+				RootType:          codeTypeName,
+				Structs:           structs,
+				StructStyles:      structStyles,
+				ExportAll:         tsExportAll,
+				Validators:        genValidators,
+				Transliterate:     transliterate,
+				ProtoJSON:         protoJSON,
+				Zod:               outputFormat == "zod",
+				Guards:            tsGuards,
+				Enums:             typescript.EnumStyle(tsEnumStyle),
+				UseMaps:           tsUseMaps,
+				Brands:            tsBrands,
+				BrandNames:        brandNames,
+				Dates:             tsDates,
+				CamelCase:         tsCamelCase,
+				EncodedBytes:      encodedBytes,
+				NullableOptionals: tsNullables,
+				ExactOptionals:    tsExactOptionals,
+			}
+			if outDir != "" {
+				files, err := typescript.ToTypeScriptFiles(yy, tsOpts)
+				if err != nil {
+					log.Fatalf("Error generating TypeScript definitions: %v", err)
+				}
+				if err := writeFiles(outDir, files); err != nil {
+					log.Fatalf("Error: %v", err)
+				}
+				return
+			}
+			tsBytes, err := typescript.ToTypeScript(yy, tsOpts)
 			if err != nil {
 				log.Fatalf("Error generating TypeScript definitions: %v", err)
 			}
@@ -110,17 +259,73 @@ It can convert Yema schemas to various formats and validate data against schemas
 					deriveTraits[i] = strings.TrimSpace(deriveTraits[i])
 				}
 			}
+			typeDerives := make(map[string][]string)
+			for _, spec := range rustTypeDerives {
+				name, traits, ok := strings.Cut(spec, "=")
+				if !ok {
+					log.Fatalf("Error: type derive %q is not of the form type=traits", spec)
+				}
+				for _, trait := range strings.Split(traits, ",") {
+					typeDerives[name] = append(typeDerives[name], strings.TrimSpace(trait))
+				}
+			}
+			// Attributes may hold commas, so each is given in a flag of its own
+			typeAttrs := make(map[string][]string)
+			for _, spec := range rustTypeAttrs {
+				name, attr, ok := strings.Cut(spec, "=")
+				if !ok {
+					log.Fatalf("Error: type attribute %q is not of the form type=attribute", spec)
+				}
+				typeAttrs[name] = append(typeAttrs[name], attr)
+			}
 
-			rustBytes, err := rust.ToRust(yy, rust.Options{
-				Module:         codeModuleName,
-				RootType:       codeTypeName,
-				DeriveTraits:   deriveTraits,
-				UseSerdeRename: rustUseRename,
-			})
+			rustOpts := rust.Options{
+				Module:             codeModuleName,
+				RootType:           codeTypeName,
+				DeriveTraits:       deriveTraits,
+				UseSerdeRename:     rustUseRename,
+				Validators:         genValidators,
+				Transliterate:      transliterate,
+				Enums:              genEnums,
+				BTreeMaps:          rustBTreeMaps,
+				TimeCrate:          rust.TimeCrate(rustTimeCrate),
+				EncodedBytes:       encodedBytes,
+				NoStd:              rustNoStd,
+				ValidatorCrate:     rustValidator,
+				URLCrate:           rustURLCrate,
+				DefaultCollections: rustDefaultColls,
+				DefaultStrings:     rustDefaultStrs,
+				TypeOverrides:      parseTypeOverrides(),
+				TypeDerives:        typeDerives,
+				TypeAttributes:     typeAttrs,
+			}
+			if outDir != "" {
+				files, err := rust.ToRustCrate(yy, rustOpts)
+				if err != nil {
+					log.Fatalf("Error generating Rust crate: %v", err)
+				}
+				if err := writeFiles(outDir, files); err != nil {
+					log.Fatalf("Error: %v", err)
+				}
+				return
+			}
+			rustBytes, err := rust.ToRust(yy, rustOpts)
 			if err != nil {
 				log.Fatalf("Error generating Rust structs: %v", err)
 			}
 			fmt.Println(string(rustBytes))
+		case "example":
+			exampleBytes, err := example.ToJSON(yy)
+			if err != nil {
+				log.Fatalf("Error generating example: %v", err)
+			}
+			fmt.Println(string(exampleBytes))
+		case "wire":
+			wireBytes, err := wire.Encode(yy)
+			if err != nil {
+				log.Fatalf("Error encoding schema: %v", err)
+			}
+			fmt.Println(string(wireBytes))
 		default:
 			log.Fatalf("Unsupported output format: %s", outputFormat)
 		}
@@ -134,14 +339,88 @@ func main() {
 	}
 }
 
+// parseTypeOverrides parses the type overrides of the flags, given as path=type
+func parseTypeOverrides() map[string]string {
+	overrides := make(map[string]string)
+	for _, spec := range typeOverrides {
+		path, typ, ok := strings.Cut(spec, "=")
+		if !ok {
+			log.Fatalf("Error: type override %q is not of the form path=type", spec)
+		}
+		overrides[path] = typ
+	}
+	return overrides
+}
+
+// writeFiles writes generated files to dir, named by their slash-separated path in it, creating directories if needed
+func writeFiles(dir string, files map[string][]byte) error {
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, content, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parserOptions returns the schema parser options selected by the global flags
+func parserOptions() parser.Options {
+	return parser.Options{
+		AllowAnyFieldName: allowAnyNames,
+	}
+}
+
 func init() {
-	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "cue", "Output format (cue, jsonschema, golang, typescript, rust)")
+	rootCmd.Flags().StringVarP(&outputFormat, "output", "o", "cue", "Output format (cue, jsonschema, golang, typescript, zod, rust, wire, example)")
 	rootCmd.PersistentFlags().StringVar(&codePackage, "package", "generated", "Package name for generated code (golang)")
 	rootCmd.PersistentFlags().StringVar(&codeModuleName, "module", "generated", "Module name for generated code (rust)")
-	rootCmd.PersistentFlags().StringVar(&codeTypeName, "type", "Type", "Root type name for generated code")
+	rootCmd.PersistentFlags().StringVar(&codeTypeName, "type", "Type", "Root type name for generated code, names the element type if the root is an array, Root by default (cue)")
+	rootCmd.Flags().BoolVar(&cueClosed, "closed", false, "Reject fields structs do not declare instead of leaving them open (cue)")
 	rootCmd.PersistentFlags().StringVar(&tsNamespace, "namespace", "", "Namespace for TypeScript code (typescript)")
+	rootCmd.Flags().StringVar(&tsFileStyle, "file-style", "", "Kind of file: module, declaration for a .d.ts, or namespace, the default with --namespace (typescript)")
 	rootCmd.PersistentFlags().BoolVar(&tsUseInterfaces, "interfaces", true, "Use interfaces instead of type aliases (typescript)")
+	rootCmd.Flags().StringArrayVar(&tsStructStyles, "struct-style", nil, "Declare a struct as an interface or alias by its type name, such as RootAddress=alias, repeatable (typescript, zod)")
 	rootCmd.PersistentFlags().BoolVar(&tsExportAll, "export-all", true, "Export all TypeScript types (typescript)")
+	rootCmd.Flags().BoolVar(&tsGuards, "guards", false, "Generate isType guards checking the kinds of fields and items at runtime (typescript, zod)")
+	rootCmd.Flags().BoolVar(&tsBrands, "brands", false, "Generate branded types for uuid strings, named after their field (typescript, zod)")
+	rootCmd.Flags().StringArrayVar(&tsBrandNames, "brand-name", nil, "Rename the brand of a field, such as OrderUserId=UserId, repeatable (typescript, zod)")
+	rootCmd.Flags().BoolVar(&tsDates, "dates", false, "Type timestamps as Date, with functions converting them from and to JSON (typescript, zod)")
+	rootCmd.Flags().BoolVar(&tsNullables, "nullable-optionals", false, "Type optional fields as T | null as well, as JSON may hold null for them (typescript, zod)")
+	rootCmd.Flags().BoolVar(&tsExactOptionals, "exact-optionals", false, "Type fields that may be missing as T | undefined as well, for exactOptionalPropertyTypes (typescript, zod)")
+	rootCmd.Flags().BoolVar(&tsCamelCase, "camel-case", false, "Name properties in camelCase, with functions translating the names of JSON documents (typescript, zod)")
+	rootCmd.Flags().BoolVar(&tsUseMaps, "use-maps", false, "Generate maps as Map instead of Record (typescript, zod)")
+	rootCmd.Flags().StringVar(&tsEnumStyle, "enum-style", "", "Generate a type for every enum as a union of literals, const object or TypeScript enum: union, const or enum (typescript, zod)")
 	rootCmd.PersistentFlags().StringVar(&rustDeriveTraits, "derive", "Debug,Clone,Serialize,Deserialize", "Comma-separated list of traits to derive (rust)")
-	rootCmd.PersistentFlags().BoolVar(&rustUseRename, "serde-rename", true, "Use serde rename attributes for JSON field names (rust)")
+	rootCmd.PersistentFlags().BoolVar(&allowAnyNames, "allow-any-field-name", false, "Allow field names that are not identifiers, such as x-request-id")
+	rootCmd.PersistentFlags().BoolVar(&genValidators, "validators", false, "Generate validation code for the root type (golang, typescript, rust)")
+	rootCmd.PersistentFlags().BoolVar(&transliterate, "transliterate", false, "Derive identifiers from ASCII transliterations of non-ASCII field names (golang, rust, typescript, cue)")
+	rootCmd.Flags().StringVar(&direction, "direction", "", "Generate the request or response variant, leaving out read-only or write-only fields")
+	rootCmd.PersistentFlags().BoolVar(&protoJSON, "protojson", false, "Follow the protobuf JSON mapping, with 64-bit integers as strings and default values omitted (golang, typescript, validate)")
+	rootCmd.Flags().BoolVar(&runVet, "vet", true, "Check the schema for semantic problems before generating")
+	rootCmd.Flags().StringSliceVar(&goTags, "tags", nil, "Comma-separated struct tags of generated fields, json by default, with an optional naming strategy such as yaml:snake (golang)")
+	rootCmd.Flags().BoolVar(&genConstructors, "constructors", false, "Generate NewType constructors taking the required fields, with functional options for the others (golang)")
+	rootCmd.Flags().BoolVar(&genEnums, "enums", false, "Generate a type with constants for every string enum, rejecting other values in JSON (golang, rust)")
+	rootCmd.Flags().BoolVar(&genGetters, "getters", false, "Generate GetField methods returning zero values for nil structs and optional fields (golang)")
+	rootCmd.Flags().BoolVar(&genClones, "clones", false, "Generate Clone methods returning deep copies of structs and unions (golang)")
+	rootCmd.Flags().BoolVar(&genParsers, "parsers", false, "Generate a ParseType function decoding JSON into the root type and validating it (golang)")
+	rootCmd.Flags().BoolVar(&genStrict, "strict", false, "Make generated parsers fail for fields not defined in the schema (golang)")
+	rootCmd.Flags().BoolVar(&genProto, "proto", false, "Shape structs like protoc-gen-go, with protobuf tags numbering fields and json keys of the protobuf JSON mapping (golang)")
+	rootCmd.Flags().BoolVar(&genTimeTypes, "time-types", false, "Map date-time and duration formats to time.Time and types holding a time.Duration (golang)")
+	rootCmd.Flags().StringArrayVar(&goTagTemplates, "tag-template", nil, "Extra struct tag of generated fields as name=template, such as validate={{if .Required}}required{{end}} (golang)")
+	rootCmd.Flags().StringVar(&outDir, "out-dir", "", "Directory to write a file for every top-level type (golang, typescript) or a crate that builds as it is (rust) to instead of stdout")
+	rootCmd.Flags().StringArrayVar(&typeOverrides, "type-override", nil, "Type of a field as path=type, such as price=github.com/shopspring/decimal.Decimal (golang) or price=rust_decimal::Decimal (rust)")
+	rootCmd.PersistentFlags().BoolVar(&rustUseRename, "serde-rename", true, "Use serde rename attributes for JSON field names, a rename_all of the struct for the convention most fields follow (rust)")
+	rootCmd.Flags().BoolVar(&rustBTreeMaps, "btree-maps", false, "Generate BTreeMap instead of HashMap for maps, serializing keys in order (rust)")
+	rootCmd.Flags().StringVar(&rustTimeCrate, "time-crate", "", "Map timestamps and dates to the types of the chrono or time crate, and durations to std::time::Duration (rust)")
+	rootCmd.Flags().BoolVar(&encodedBytes, "encoded-bytes", false, "Write bytes as strings in the encoding of their format, base64 by default, instead of arrays of numbers (rust), or converted from and to a Uint8Array by decode and encode functions (typescript)")
+	rootCmd.Flags().BoolVar(&rustNoStd, "no-std", false, "Generate code for no_std crates with alloc, deriving serde behind the serde feature (rust)")
+	rootCmd.Flags().BoolVar(&rustValidator, "validator-crate", false, "Derive Validate of the validator crate, validating uri strings and nested structs (rust)")
+	rootCmd.Flags().BoolVar(&rustURLCrate, "url-crate", false, "Map uri strings to url::Url of the url crate, next to uuid strings mapped to uuid::Uuid (rust)")
+	rootCmd.Flags().BoolVar(&rustDefaultColls, "default-collections", false, "Generate optional lists and maps as collections that are empty if missing instead of an Option (rust)")
+	rootCmd.Flags().BoolVar(&rustDefaultStrs, "default-strings", false, "Generate optional strings as strings that are empty if missing instead of an Option (rust)")
+	rootCmd.Flags().StringArrayVar(&rustTypeDerives, "type-derive", nil, "Extra derives of a generated type as type=traits, such as RootAddress=PartialEq,Eq (rust)")
+	rootCmd.Flags().StringArrayVar(&rustTypeAttrs, "type-attribute", nil, "Extra attribute of a generated type as type=attribute, such as Root=serde(deny_unknown_fields) (rust)")
 }
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/aep/yema/parser"
+	"github.com/spf13/cobra"
+)
+
+var metaSchemaCmd = &cobra.Command{
+	Use:   "meta-schema",
+	Short: "Print the JSON Schema describing Yema schema files",
+	Long: `Print the JSON Schema describing what a valid Yema schema file looks like,
+for use with editors and other tooling.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Print(string(parser.MetaSchema))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(metaSchemaCmd)
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/aep/yema/playground"
+	"github.com/spf13/cobra"
+)
+
+var playgroundListen string
+
+var playgroundCmd = &cobra.Command{
+	Use:   "playground",
+	Short: "Serve a local web UI to try Yema schemas",
+	Long: `Serve a web UI where a schema and a document are edited side by side,
+showing validation errors and the generated code of every target as you type.
+
+Example:
+  yema playground --listen localhost:8080`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		log.Printf("Serving the playground on http://%s", playgroundListen)
+		log.Fatal(http.ListenAndServe(playgroundListen, playground.Handler()))
+	},
+}
+
+func init() {
+	playgroundCmd.Flags().StringVar(&playgroundListen, "listen", "localhost:8080", "Address to listen on")
+	rootCmd.AddCommand(playgroundCmd)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/aep/yema/proxy"
+	"github.com/spf13/cobra"
+)
+
+var (
+	proxyManifest string
+	proxyUpstream string
+	proxyListen   string
+	proxyReject   bool
+)
+
+var proxyCmd = &cobra.Command{
+	Use:   "proxy",
+	Short: "Validate live traffic against Yema schemas",
+	Long: `Run a reverse proxy that validates request and response bodies
+against the schemas declared for each route in a manifest.
+Violations are logged, or rejected when --reject is set.
+Requests to routes not listed in the manifest are forwarded unchecked.
+
+Example manifest:
+  routes:
+    - method: POST
+      path: /users
+      request: schemas/create-user.yaml
+      response: schemas/user.yaml
+
+Example:
+  yema proxy --manifest routes.yaml --upstream http://svc`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		manifest, err := proxy.LoadManifest(proxyManifest)
+		if err != nil {
+			log.Fatalf("Error loading manifest: %v", err)
+		}
+
+		upstream, err := url.Parse(proxyUpstream)
+		if err != nil {
+			log.Fatalf("Error parsing upstream URL: %v", err)
+		}
+
+		handler, err := proxy.New(manifest, proxy.Options{
+			Upstream: upstream,
+			Reject:   proxyReject,
+		})
+		if err != nil {
+			log.Fatalf("Error creating proxy: %v", err)
+		}
+
+		log.Printf("Proxying %s to %s", proxyListen, upstream)
+		log.Fatal(http.ListenAndServe(proxyListen, handler))
+	},
+}
+
+func init() {
+	proxyCmd.Flags().StringVar(&proxyManifest, "manifest", "", "Route manifest file")
+	proxyCmd.Flags().StringVar(&proxyUpstream, "upstream", "", "URL of the upstream service")
+	proxyCmd.Flags().StringVar(&proxyListen, "listen", ":8080", "Address to listen on")
+	proxyCmd.Flags().BoolVar(&proxyReject, "reject", false, "Reject requests and responses that violate their schema")
+	proxyCmd.MarkFlagRequired("manifest")
+	proxyCmd.MarkFlagRequired("upstream")
+	rootCmd.AddCommand(proxyCmd)
+}
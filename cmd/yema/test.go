@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/aep/yema/parser"
+	"github.com/aep/yema/schematest"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var testCmd = &cobra.Command{
+	Use:   "test [schema...]",
+	Short: "Run test cases embedded in Yema schemas",
+	Long: `Run the documents listed under $tests in each schema file
+and check that they pass or fail validation as declared.
+
+Example:
+  yema test user.yaml order.yaml`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		failed := 0
+
+		for _, path := range args {
+			schemaData, err := os.ReadFile(path)
+			if err != nil {
+				log.Fatalf("Error reading schema file: %v", err)
+			}
+
+			var schemaDoc interface{}
+			err = yaml.Unmarshal(schemaData, &schemaDoc)
+			if err != nil {
+				log.Fatalf("Error parsing schema file %s: %v", path, err)
+			}
+
+			schema, err := parser.FromYAMLWithOptions(schemaData, parserOptions())
+			if err != nil {
+				log.Fatalf("Error parsing schema %s: %v", path, err)
+			}
+
+			cases, err := schematest.FromSchema(schemaDoc)
+			if err != nil {
+				log.Fatalf("Error reading tests in %s: %v", path, err)
+			}
+
+			for _, r := range schematest.Run(cases, schema) {
+				if r.Passed() {
+					fmt.Printf("ok   %s: %s\n", path, r.Case.Name)
+					continue
+				}
+
+				failed++
+				fmt.Printf("FAIL %s: %s: %s\n", path, r.Case.Name, r.Failure)
+				for _, e := range r.Errors {
+					fmt.Printf("  %s\n", e)
+				}
+			}
+		}
+
+		if failed != 0 {
+			fmt.Printf("%d test(s) failed\n", failed)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(testCmd)
+}
@@ -1,23 +1,38 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/aep/yema"
 	"github.com/aep/yema/parser"
 	"github.com/aep/yema/validator"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
 )
 
+var (
+	validateStrict    bool
+	validateMaxErrors int
+	validateFailFast  bool
+	validateStream    bool
+	validateFormat    string
+)
+
 var validateCmd = &cobra.Command{
 	Use:   "validate [schema] [subject]",
 	Short: "Validate data against a Yema schema",
 	Long: `Validate JSON or YAML data against a Yema schema.
 This command checks if the provided data conforms to the specified schema.
-Unknown fields not defined in the schema are ignored during validation.
+Unknown fields not defined in the schema are ignored during validation,
+unless --strict is set.
 
 Example:
   yema validate data.json --schema schema.yaml`,
@@ -29,14 +44,8 @@ Example:
 			log.Fatalf("Error reading schema file: %v", err)
 		}
 
-		var schemaMap map[string]interface{}
-		err = yaml.Unmarshal(schemaData, &schemaMap)
-		if err != nil {
-			log.Fatalf("Error parsing schema file: %v", err)
-		}
-
 		// Convert schema to yema.Type
-		schema, err := parser.From(schemaMap)
+		schema, err := parser.FromYAMLWithOptions(schemaData, parserOptions())
 		if err != nil {
 			log.Fatalf("Error parsing schema: %v", err)
 		}
@@ -52,23 +61,40 @@ Example:
 			input = file
 		}
 
-		// Read all data from input
-		inputData, err := io.ReadAll(input)
+		if validateFormat != "text" && validateFormat != "json" {
+			log.Fatalf("Unknown output format %q, expected text or json", validateFormat)
+		}
+
+		opts := validator.Options{
+			DenyUnknownFields: validateStrict,
+			MaxErrors:         validateMaxErrors,
+			FailFast:          validateFailFast,
+			ProtoJSON:         protoJSON,
+		}
+
+		if validateStream {
+			validateRecords(input, schema, opts)
+			return
+		}
+
+		data, err := io.ReadAll(input)
 		if err != nil {
 			log.Fatalf("Error reading input data: %v", err)
 		}
+		isJSON := len(args) > 1 && strings.EqualFold(filepath.Ext(args[1]), ".json")
+		errs := validateDocument(data, isJSON, schema, opts)
 
-		// Parse input data based on extension or try both formats
-		var dataMap map[string]interface{}
-		err = yaml.Unmarshal(inputData, &dataMap)
-		if err != nil {
-			log.Fatalf("Error parsing input data: %v", err)
+		if validateFormat == "json" {
+			printJSON(validationResult{Valid: len(errs) == 0, Errors: reportErrors(errs)})
+			if len(errs) != 0 {
+				os.Exit(1)
+			}
+			return
 		}
 
-		// Validate the data against the schema
-		if err := validator.Validate(dataMap, schema); len(err) != 0 {
+		if len(errs) != 0 {
 			fmt.Println("Validation failed")
-			for _, e := range err {
+			for _, e := range errs {
 				fmt.Printf("  %s\n", e)
 			}
 			os.Exit(1)
@@ -78,8 +104,123 @@ Example:
 	},
 }
 
+// validateRecords validates a stream of JSON records one at a time, printing the errors of every invalid record
+func validateRecords(input io.Reader, schema *yema.Type, opts validator.Options) {
+	failed := 0
+	records, err := validator.NewStreamValidatorWithOptions(schema, opts).Validate(input, func(errs []*validator.RecordError) {
+		failed++
+		if validateFormat == "json" {
+			// One line per invalid record, so the output is newline-delimited JSON like the input
+			recordErrs := make([]error, len(errs))
+			for i, e := range errs {
+				recordErrs[i] = e
+			}
+			printJSON(validationResult{Record: &errs[0].Record, Errors: reportErrors(recordErrs)})
+			return
+		}
+		for _, e := range errs {
+			fmt.Printf("  %s\n", e)
+		}
+	})
+	if err != nil {
+		log.Fatalf("Error reading input data: %v", err)
+	}
+
+	if validateFormat == "json" {
+		if failed != 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if failed != 0 {
+		fmt.Printf("Validation failed for %d of %d records\n", failed, records)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Validation successful for %d records! ✓\n", records)
+}
+
+// validateDocument validates a JSON or YAML document. JSON is decoded directly, so integers keep their exact value,
+// as is data from stdin holding a JSON object or array. Errors in YAML are reported with the line of the offending value.
+func validateDocument(data []byte, isJSON bool, schema *yema.Type, opts validator.Options) []error {
+	if isJSON || looksLikeJSON(data) {
+		return validator.ValidateJSONWithOptions(bytes.NewReader(data), schema, opts)
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return []error{&validator.Error{Code: validator.CodeSyntax, Message: "syntax.yaml", Err: err}}
+	}
+	return validator.ValidateYAMLNodeWithOptions(&node, schema, opts)
+}
+
+// looksLikeJSON reports whether data is a JSON object or array. YAML flow mappings such as {a: 1} start alike,
+// so data must be valid JSON as well.
+func looksLikeJSON(data []byte) bool {
+	trimmed := bytes.TrimLeft(data, " \t\r\n")
+	return len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid(trimmed)
+}
+
+// validationResult is the outcome of a validation in --format json
+type validationResult struct {
+	Valid bool `json:"valid"`
+	// Record is the index of an invalid record of a stream, nil for single documents
+	Record *int              `json:"record,omitempty"`
+	Errors []validationError `json:"errors"`
+}
+
+type validationError struct {
+	Path string         `json:"path"`
+	Code validator.Code `json:"code,omitempty"`
+	// Line and Column locate the error in YAML input, 0 if unknown
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// reportErrors converts errors to their JSON form, the errors of records are reported without the record prefix
+func reportErrors(errs []error) []validationError {
+	out := make([]validationError, 0, len(errs))
+	for _, err := range errs {
+		var recordErr *validator.RecordError
+		if errors.As(err, &recordErr) {
+			err = recordErr.Err
+		}
+		var e validationError
+		var posErr *validator.PositionError
+		if errors.As(err, &posErr) {
+			// The position is reported in its own fields rather than in the message
+			e.Line, e.Column = posErr.Line, posErr.Column
+			err = posErr.Err
+		}
+		e.Code, e.Message = validator.CodeOf(err), err.Error()
+		var verr *validator.Error
+		var unionErr *validator.UnionError
+		if errors.As(err, &unionErr) {
+			e.Path = unionErr.Path
+		} else if errors.As(err, &verr) {
+			e.Path = verr.Path
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+func printJSON(v interface{}) {
+	out, err := json.Marshal(v)
+	if err != nil {
+		log.Fatalf("Error encoding result: %v", err)
+	}
+	fmt.Println(string(out))
+}
+
 func init() {
+	validateCmd.Flags().BoolVar(&validateStrict, "strict", false, "Report fields not defined in the schema")
+	validateCmd.Flags().IntVar(&validateMaxErrors, "max-errors", 0, "Stop after reporting this many errors, 0 reports all")
+	validateCmd.Flags().BoolVar(&validateFailFast, "fail-fast", false, "Stop at the first error")
+	validateCmd.Flags().BoolVar(&validateStream, "stream", false, "Validate every record of newline-delimited JSON or a top-level JSON array")
+	validateCmd.Flags().StringVar(&validateFormat, "format", "text", "Output format (text, json), json reports a stable code for every error")
 	validateCmd.MarkFlagRequired("schema")
 	rootCmd.AddCommand(validateCmd)
 }
-
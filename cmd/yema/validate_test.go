@@ -0,0 +1,40 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aep/yema/parser"
+	"github.com/aep/yema/validator"
+)
+
+func TestValidateDocument(t *testing.T) {
+	schema, err := parser.FromYAML([]byte("name: string\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		data string
+		// json is set for data decoded as JSON, whose errors are not located by line
+		json bool
+	}{
+		{"json object on stdin", "\n  {\"name\": 1}\n", true},
+		{"yaml", "name: 1\n", false},
+		{"yaml flow mapping", "{name: 1}\n", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := validateDocument([]byte(tt.data), false, schema, validator.Options{})
+			if len(errs) != 1 || validator.CodeOf(errs[0]) != validator.CodeTypeMismatch {
+				t.Fatalf("expected a type mismatch, got %v", errs)
+			}
+			var posErr *validator.PositionError
+			if located := errors.As(errs[0], &posErr); located == tt.json {
+				t.Errorf("decoded as JSON: %v, want %v (%v)", !located, tt.json, errs[0])
+			}
+		})
+	}
+}
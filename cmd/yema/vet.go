@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/aep/yema/parser"
+	"github.com/aep/yema/vet"
+	"github.com/spf13/cobra"
+)
+
+var vetCmd = &cobra.Command{
+	Use:   "vet [schema...]",
+	Short: "Report semantic problems in schemas",
+	Long: `Report declarations that are contradictory or cannot be satisfied,
+such as structs without fields or examples that are not valid for their type.
+Style and target-language issues are reported by yema lint instead.
+
+The same checks run before generating code, unless --vet=false is set.
+
+Example:
+  yema vet schema.yaml`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		found := 0
+		for _, path := range args {
+			schemaData, err := os.ReadFile(path)
+			if err != nil {
+				log.Fatalf("Error reading schema file: %v", err)
+			}
+
+			schema, err := parser.FromYAMLWithOptions(schemaData, parserOptions())
+			if err != nil {
+				log.Fatalf("Error parsing schema %s: %v", path, err)
+			}
+
+			for _, p := range append(vet.Vet(schema), vet.Defs(schemaData)...) {
+				found++
+				fmt.Printf("%s: %s\n", path, p)
+			}
+		}
+
+		if found != 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(vetCmd)
+}
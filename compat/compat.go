@@ -0,0 +1,377 @@
+// Package compat lets a server keep serving clients generated from older versions of a schema.
+//
+// Clients report the fingerprint of the schema they were generated from. The server looks it up
+// in a Registry of the schema versions it has published and negotiates a Plan, which removes
+// everything the client does not know about from responses before they are sent.
+package compat
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/aep/yema"
+	"github.com/aep/yema/internal/fieldpath"
+	"github.com/aep/yema/wire"
+)
+
+// Fingerprint identifies the structure of a schema. Attributes that do not affect the data,
+// such as examples and code names, and the order of fields do not change the fingerprint.
+func Fingerprint(t *yema.Type) (string, error) {
+	if t == nil {
+		return "", fmt.Errorf("nil type provided")
+	}
+
+	data, err := wire.Encode(structure(t))
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:16]), nil
+}
+
+// structure returns a copy of t without the attributes ignored by Fingerprint
+func structure(t *yema.Type) *yema.Type {
+	s := &yema.Type{Kind: t.Kind, Optional: t.Optional, KeyPattern: t.KeyPattern, Enum: t.Enum,
+		Format: t.Format, FormatArg: t.FormatArg, ReadOnly: t.ReadOnly, WriteOnly: t.WriteOnly,
+		Requires: t.Requires, Conflicts: t.Conflicts, Unique: t.Unique, Length: t.Length}
+	if t.If != nil {
+		s.If = &yema.Condition{Equals: t.If.Equals}
+		if t.If.Then != nil {
+			s.If.Then = structure(t.If.Then)
+		}
+		if t.If.Else != nil {
+			s.If.Else = structure(t.If.Else)
+		}
+	}
+	if t.Array != nil {
+		s.Array = structure(t.Array)
+	}
+	if t.Map != nil {
+		s.Map = structure(t.Map)
+	}
+	for i := range t.Union {
+		s.Union = append(s.Union, *structure(&t.Union[i]))
+	}
+	if t.Struct != nil {
+		fields := make(map[string]yema.Type, len(*t.Struct))
+		for name, fieldType := range *t.Struct {
+			fields[name] = *structure(&fieldType)
+		}
+		s.Struct = &fields
+	}
+	return s
+}
+
+// Registry holds the published versions of a schema by fingerprint. It is safe for concurrent use.
+type Registry struct {
+	mu      sync.RWMutex
+	schemas map[string]*yema.Type
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{schemas: make(map[string]*yema.Type)}
+}
+
+// Register adds a version of the schema and returns its fingerprint
+func (r *Registry) Register(t *yema.Type) (string, error) {
+	fp, err := Fingerprint(t)
+	if err != nil {
+		return "", err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.schemas[fp] = t
+	return fp, nil
+}
+
+// Lookup returns the schema version with the given fingerprint
+func (r *Registry) Lookup(fingerprint string) (*yema.Type, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.schemas[fingerprint]
+	return t, ok
+}
+
+// Negotiate looks up the schema version a client was generated from and plans how to serve it
+// documents of the current schema
+func (r *Registry) Negotiate(fingerprint string, current *yema.Type) (*Plan, error) {
+	client, ok := r.Lookup(fingerprint)
+	if !ok {
+		return nil, fmt.Errorf("unknown schema fingerprint %q", fingerprint)
+	}
+	return Negotiate(client, current)
+}
+
+// Plan down-converts documents of the server's schema to the schema a client understands
+type Plan struct {
+	// Schema is the server schema restricted to what the client knows about, responses converted by
+	// Transform are valid against it
+	Schema *yema.Type
+	// Dropped are the paths of fields the client does not know about, e.g. "addresses[].geo"
+	Dropped []string
+	// Restricted are the paths of optional fields that may hold enum values the client does not know,
+	// Transform drops them from documents holding such a value
+	Restricted []string
+}
+
+// Negotiate computes the plan for serving a client generated from the client schema with documents of the server schema.
+// Fields only the server knows about are dropped, as are optional fields holding enum values only the server knows.
+// It returns an error if the schemas are incompatible, because a field changed its kind or format or the client
+// requires a field the server may not send, such as one that may hold an enum value the client does not know.
+func Negotiate(client, server *yema.Type) (*Plan, error) {
+	if client == nil || server == nil {
+		return nil, fmt.Errorf("nil type provided")
+	}
+
+	p := &Plan{}
+	schema, err := p.negotiate(client, server, "", false)
+	if err != nil {
+		return nil, err
+	}
+	p.Schema = schema
+	return p, nil
+}
+
+// negotiate plans serving values of the server type as values of the client type at path. optional is whether
+// the value is a field the client may go without, which is dropped rather than sent with an unknown enum value.
+func (p *Plan) negotiate(client, server *yema.Type, path string, optional bool) (*yema.Type, error) {
+	if client.Kind != server.Kind {
+		return nil, fmt.Errorf("%s changed from %v to %v", fieldpath.Display(path), client.Kind, server.Kind)
+	}
+
+	// Clients reject enum values they do not know
+	enum := server.Enum
+	if client.Enum != nil {
+		var unknown []interface{}
+		for _, value := range server.Enum {
+			if !containsValue(client.Enum, value) {
+				unknown = append(unknown, value)
+			}
+		}
+		if len(unknown) > 0 || server.Enum == nil {
+			if !optional {
+				if server.Enum == nil {
+					return nil, fmt.Errorf("%s is no longer restricted to the values the client knows", fieldpath.Display(path))
+				}
+				return nil, fmt.Errorf("%s allows %v, which the client does not know", fieldpath.Display(path), unknown[0])
+			}
+			// The field is dropped from documents holding any other value
+			enum = nil
+			for _, value := range client.Enum {
+				if server.Enum == nil || containsValue(server.Enum, value) {
+					enum = append(enum, value)
+				}
+			}
+			p.Restricted = append(p.Restricted, path)
+		}
+	}
+
+	// Clients validate values against the format they were generated with
+	if client.Format != server.Format {
+		return nil, fmt.Errorf("%s changed its format from %q to %q", fieldpath.Display(path), client.Format, server.Format)
+	}
+	if !reflect.DeepEqual(client.FormatArg, server.FormatArg) {
+		return nil, fmt.Errorf("%s changed the argument of its format %q from %v to %v", fieldpath.Display(path), server.Format, client.FormatArg, server.FormatArg)
+	}
+
+	// Clients may check the length of bytes as well
+	if !reflect.DeepEqual(client.Length, server.Length) {
+		return nil, fmt.Errorf("%s changed the length of its bytes", fieldpath.Display(path))
+	}
+
+	// Clients reject duplicate items if they were generated from a schema requiring unique items
+	if client.Unique && !server.Unique {
+		return nil, fmt.Errorf("%s no longer requires unique items", fieldpath.Display(path))
+	}
+
+	t := *server
+	t.Enum = enum
+	switch server.Kind {
+	case yema.Array:
+		if client.Array == nil || server.Array == nil {
+			return nil, fmt.Errorf("array type with nil Array field at %s", fieldpath.Display(path))
+		}
+		items, err := p.negotiate(client.Array, server.Array, path+"[]", false)
+		if err != nil {
+			return nil, err
+		}
+		t.Array = items
+
+	case yema.Union:
+		// Variants are tried in order, so they must match one by one
+		if len(client.Union) != len(server.Union) {
+			return nil, fmt.Errorf("%s changed from %d to %d variants", fieldpath.Display(path), len(client.Union), len(server.Union))
+		}
+		t.Union = make([]yema.Type, len(server.Union))
+		for i := range server.Union {
+			variant, err := p.negotiate(&client.Union[i], &server.Union[i], path, false)
+			if err != nil {
+				return nil, err
+			}
+			t.Union[i] = *variant
+		}
+
+	case yema.Map:
+		if client.Map == nil || server.Map == nil {
+			return nil, fmt.Errorf("map type with nil Map field at %s", fieldpath.Display(path))
+		}
+		if client.KeyPattern != server.KeyPattern {
+			return nil, fmt.Errorf("%s changed its key pattern from %q to %q", fieldpath.Display(path), client.KeyPattern, server.KeyPattern)
+		}
+		values, err := p.negotiate(client.Map, server.Map, path+"[]", false)
+		if err != nil {
+			return nil, err
+		}
+		t.Map = values
+
+	case yema.Struct:
+		if client.Struct == nil || server.Struct == nil {
+			return nil, fmt.Errorf("struct type with nil Struct field at %s", fieldpath.Display(path))
+		}
+
+		fields := make(map[string]yema.Type, len(*client.Struct))
+		var order []string
+		for _, name := range server.FieldNames() {
+			fieldPath := fieldpath.Join(path, name)
+			serverField := (*server.Struct)[name]
+			clientField, ok := (*client.Struct)[name]
+			if !ok {
+				p.Dropped = append(p.Dropped, fieldPath)
+				continue
+			}
+			if serverField.Optional && !clientField.Optional {
+				return nil, fmt.Errorf("%s is optional but required by the client", fieldPath)
+			}
+
+			field, err := p.negotiate(&clientField, &serverField, fieldPath, clientField.Optional)
+			if err != nil {
+				return nil, err
+			}
+			fields[name] = *field
+			order = append(order, name)
+		}
+
+		for _, name := range client.FieldNames() {
+			if _, ok := (*server.Struct)[name]; !ok && !(*client.Struct)[name].Optional {
+				return nil, fmt.Errorf("%s is required by the client but no longer exists", fieldpath.Join(path, name))
+			}
+		}
+
+		// The client never sees dropped fields, rules and conditions referring to them do not apply
+		for name, field := range fields {
+			field.Requires = known(field.Requires, fields)
+			field.Conflicts = known(field.Conflicts, fields)
+			if field.If != nil && !knowsAll(field.If.Equals, fields) {
+				field.If = nil
+			}
+			fields[name] = field
+		}
+
+		t.Struct = &fields
+		t.Order = order
+	}
+
+	return &t, nil
+}
+
+// Transform returns a copy of data without the fields dropped by the plan, and without the optional fields holding
+// enum values the client does not know. data is a decoded json document of the server schema, of any type matching its root.
+func (p *Plan) Transform(data interface{}) interface{} {
+	return transform(data, p.Schema)
+}
+
+func transform(value interface{}, t *yema.Type) interface{} {
+	if t.Kind == yema.Union {
+		// Fields are dropped following the first variant of the shape of the value
+		for i := range t.Union {
+			if sameShape(value, t.Union[i].Kind) {
+				return transform(value, &t.Union[i])
+			}
+		}
+		return value
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if t.Kind == yema.Map && t.Map != nil {
+			out := make(map[string]interface{}, len(v))
+			for key, item := range v {
+				out[key] = transform(item, t.Map)
+			}
+			return out
+		}
+		if t.Kind != yema.Struct || t.Struct == nil {
+			return v
+		}
+		out := make(map[string]interface{}, len(v))
+		for name, fieldValue := range v {
+			fieldType, ok := (*t.Struct)[name]
+			if !ok {
+				continue
+			}
+			if fieldType.Optional && fieldType.Enum != nil && !containsValue(fieldType.Enum, fieldValue) {
+				continue
+			}
+			out[name] = transform(fieldValue, &fieldType)
+		}
+		return out
+	case []interface{}:
+		if t.Kind != yema.Array || t.Array == nil {
+			return v
+		}
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = transform(item, t.Array)
+		}
+		return out
+	}
+	return value
+}
+
+// sameShape reports whether a decoded json value can be of the kind
+func sameShape(value interface{}, kind yema.Kind) bool {
+	switch value.(type) {
+	case map[string]interface{}:
+		return kind == yema.Struct || kind == yema.Map
+	case []interface{}:
+		return kind == yema.Array
+	}
+	return false
+}
+
+// containsValue reports whether an enum lists value
+func containsValue(enum []interface{}, value interface{}) bool {
+	for _, allowed := range enum {
+		if fmt.Sprint(allowed) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// known returns the names that are fields, nil if there are none
+func known(names []string, fields map[string]yema.Type) []string {
+	var kept []string
+	for _, name := range names {
+		if _, ok := fields[name]; ok {
+			kept = append(kept, name)
+		}
+	}
+	return kept
+}
+
+// knowsAll reports whether every field a condition compares is a field
+func knowsAll(equals map[string]interface{}, fields map[string]yema.Type) bool {
+	for name := range equals {
+		if _, ok := fields[name]; !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,208 @@
+package compat
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aep/yema/parser"
+)
+
+func TestFingerprint(t *testing.T) {
+	a, err := parser.FromYAML([]byte("name: string\nage?: int\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := parser.FromYAML([]byte("age?:\n  $type: int\n  $example: 3\nname: string\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := parser.FromYAML([]byte("name: string\nage: int\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fa, _ := Fingerprint(a)
+	fb, _ := Fingerprint(b)
+	fc, _ := Fingerprint(c)
+	if fa != fb {
+		t.Errorf("field order and examples must not change the fingerprint, got %s and %s", fa, fb)
+	}
+	if fa == fc {
+		t.Errorf("optionality must change the fingerprint")
+	}
+
+	var maps []string
+	for _, src := range []string{"$map: string\n", "$map: int\n", "$map: string\n$keys: \"^[a-z]+$\"\n"} {
+		m, err := parser.FromYAML([]byte(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		fm, err := Fingerprint(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, other := range maps {
+			if fm == other {
+				t.Errorf("map values and key patterns must change the fingerprint, %q collides", src)
+			}
+		}
+		maps = append(maps, fm)
+	}
+
+	var ids []string
+	for _, src := range []string{
+		"id: string\n",
+		"id:\n  $type: string\n  $format: uuid\n",
+		"id:\n  $type: string\n  $format: {date-time: \"2006-01-02\"}\n",
+		"id:\n  $type: string\n  $format: {date-time: \"15:04\"}\n",
+		"id:\n  $type: string\n  $readonly: true\n",
+		"id:\n  $type: string\n  $writeonly: true\n",
+	} {
+		s, err := parser.FromYAML([]byte(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		fs, err := Fingerprint(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, other := range ids {
+			if fs == other {
+				t.Errorf("formats and access modes must change the fingerprint, %q collides", src)
+			}
+		}
+		ids = append(ids, fs)
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	v1, err := parser.FromYAML([]byte("name: string\naddresses: [{city: string}]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v2, err := parser.FromYAML([]byte("name: string\nnickname?: string\naddresses: [{city: string, \"geo?\": {lat: float64}}]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewRegistry()
+	fp, err := r.Register(v1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := r.Negotiate(fp, v2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"nickname", "addresses[].geo"}; !reflect.DeepEqual(plan.Dropped, want) {
+		t.Errorf("dropped %v, want %v", plan.Dropped, want)
+	}
+	if got, _ := Fingerprint(plan.Schema); got != fp {
+		t.Errorf("down-converted schema does not match the client schema")
+	}
+
+	got := plan.Transform(map[string]interface{}{
+		"name":      "Bob",
+		"nickname":  "B",
+		"addresses": []interface{}{map[string]interface{}{"city": "X", "geo": map[string]interface{}{"lat": 1.0}}},
+	})
+	want := map[string]interface{}{
+		"name":      "Bob",
+		"addresses": []interface{}{map[string]interface{}{"city": "X"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Transform = %v, want %v", got, want)
+	}
+
+	if _, err := r.Negotiate("unknown", v2); err == nil {
+		t.Error("expected error for an unknown fingerprint")
+	}
+}
+
+func TestNegotiateIncompatible(t *testing.T) {
+	tests := []struct {
+		client, server string
+	}{
+		{"id: int\n", "id: string\n"},
+		{"id: int\n", "id?: int\n"},
+		{"id: int\nname: string\n", "id: int\n"},
+		{"env:\n  $type: string\n  $enum: [dev]\n", "env:\n  $type: string\n  $enum: [dev, prod]\n"},
+		{"env:\n  $type: string\n  $enum: [dev]\n", "env: string\n"},
+		{"tags:\n  $type: [string]\n  $unique: true\n", "tags: [string]\n"},
+		{"id:\n  $type: string\n  $format: uuid\n", "id: string\n"},
+		{"id: string\n", "id:\n  $type: string\n  $format: uuid\n"},
+		{"day:\n  $type: string\n  $format: {date-time: \"2006-01-02\"}\n", "day:\n  $type: string\n  $format: {date-time: \"15:04\"}\n"},
+	}
+
+	for _, tt := range tests {
+		client, err := parser.FromYAML([]byte(tt.client))
+		if err != nil {
+			t.Fatal(err)
+		}
+		server, err := parser.FromYAML([]byte(tt.server))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Negotiate(client, server); err == nil {
+			t.Errorf("expected error negotiating %q with %q", tt.client, tt.server)
+		}
+	}
+}
+
+func TestNegotiateEnums(t *testing.T) {
+	client, err := parser.FromYAML([]byte("env?:\n  $type: string\n  $enum: [dev, test]\nlevel?:\n  $type: int\n  $enum: [1, 2]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := parser.FromYAML([]byte("env?:\n  $type: string\n  $enum: [dev, test, prod]\nlevel?: int\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := Negotiate(client, server)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"env", "level"}; !reflect.DeepEqual(plan.Restricted, want) {
+		t.Errorf("restricted %v, want %v", plan.Restricted, want)
+	}
+	fp, _ := Fingerprint(client)
+	if got, _ := Fingerprint(plan.Schema); got != fp {
+		t.Errorf("down-converted schema does not match the client schema")
+	}
+
+	// Optional fields holding values the client does not know are dropped
+	got := plan.Transform(map[string]interface{}{"env": "prod", "level": 2.0})
+	want := map[string]interface{}{"level": 2.0}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Transform = %v, want %v", got, want)
+	}
+	got = plan.Transform(map[string]interface{}{"env": "dev", "level": 3.0})
+	want = map[string]interface{}{"env": "dev"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Transform = %v, want %v", got, want)
+	}
+}
+
+func TestTransformArrayRoot(t *testing.T) {
+	client, err := parser.FromYAML([]byte("- id: int\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := parser.FromYAML([]byte("- id: int\n  name?: string\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := Negotiate(client, server)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := plan.Transform([]interface{}{map[string]interface{}{"id": 1, "name": "x"}})
+	want := []interface{}{map[string]interface{}{"id": 1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Transform = %v, want %v", got, want)
+	}
+}
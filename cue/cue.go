@@ -2,33 +2,48 @@ package cue
 
 import (
 	"fmt"
+	"strings"
 
 	"cuelang.org/go/cue"
 	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/format"
 	"cuelang.org/go/cue/token"
 	"github.com/aep/yema"
+	"github.com/aep/yema/internal/ident"
 )
 
-// TypeToCue converts an abstract Type to a CUE value
-func ToCue(ctx *cue.Context, t *yema.Type) (cue.Value, error) {
+// Options holds configuration options for CUE generation
+type Options struct {
+	// RootType is the name of the definition of the root, #Root by default, which documents are vetted against,
+	// e.g. with cue vet -d '#Root'
+	RootType string
+	// Closed rejects fields a struct does not declare, as definitions do in CUE. Structs are left open
+	// with ... otherwise, accepting documents with unexpected fields.
+	Closed bool
+	// Transliterate derives the names of nested definitions from ASCII transliterations of non-ASCII field names
+	Transliterate bool
+}
+
+// TypeToCue converts an abstract Type to CUE definitions, the root may be of any kind. The root is the
+// definition RootType, the structs of its fields are definitions named after their parent and field, such
+// as #RootAddress, and the items of arrays and values of maps after their field. The struct items of a root
+// array are named RootType + "Item" and the struct values of a root map RootType + "Value". Union variants
+// are declared in place. Types referenced with $ref are a single definition named after their entry of $defs,
+// such as #Address, shared by every reference.
+func ToCue(ctx *cue.Context, t *yema.Type, opts Options) (cue.Value, error) {
 	if t == nil {
 		return cue.Value{}, fmt.Errorf("nil type provided")
 	}
-
-	file := &ast.File{}
-
-	if t.Kind != yema.Struct {
-		return cue.Value{}, fmt.Errorf("expected root type to be Struct, got %v", t.Kind)
+	if opts.RootType == "" {
+		opts.RootType = "Root"
 	}
 
-	structExpr, err := typeToAstExpr(t, "")
-	if err != nil {
+	g := &generator{opts: opts, origins: map[string]string{opts.RootType: "the root type"}, refs: make(map[string]bool)}
+	if err := g.define(definition{opts.RootType, t, "the root type", ""}); err != nil {
 		return cue.Value{}, err
 	}
 
-	file.Decls = append(file.Decls, &ast.EmbedDecl{Expr: structExpr})
-
-	value := ctx.BuildFile(file)
+	value := ctx.BuildFile(&ast.File{Decls: g.decls})
 	if value.Err() != nil {
 		return cue.Value{}, fmt.Errorf("failed to build CUE value: %w", value.Err())
 	}
@@ -36,7 +51,80 @@ func ToCue(ctx *cue.Context, t *yema.Type) (cue.Value, error) {
 	return value, nil
 }
 
-func typeToAstExpr(t *yema.Type, fieldName string) (ast.Expr, error) {
+// Format formats the definitions of value as a CUE file
+func Format(value cue.Value) ([]byte, error) {
+	node := value.Syntax()
+	// The definitions are the declarations of the file rather than the fields of a struct
+	if lit, ok := node.(*ast.StructLit); ok {
+		node = &ast.File{Decls: lit.Elts}
+	}
+	return format.Node(node)
+}
+
+// generator collects the definitions of a schema
+type generator struct {
+	opts  Options
+	decls []ast.Decl
+	// origins maps the names of definitions to the field they are generated for, to report collisions
+	origins map[string]string
+	// refs holds the entries of $defs that are declared already
+	refs map[string]bool
+}
+
+// definition is a named type that still needs to be declared
+type definition struct {
+	name string
+	t    *yema.Type
+	// origin describes the field the definition is generated for in errors
+	origin string
+	// ref is the entry of $defs the definition declares, which is declared once however often it is referenced
+	ref string
+}
+
+// define declares the definition d, followed by the nested definitions of its fields in their order
+func (g *generator) define(d definition) error {
+	// The definition declares the type itself rather than referencing it
+	t := *d.t
+	t.Ref = ""
+
+	var nested []definition
+	expr, err := g.typeToAstExpr(&t, d.name, "", &nested)
+	if err != nil {
+		return err
+	}
+	g.decls = append(g.decls, &ast.Field{
+		Label: ast.NewIdent("#" + d.name),
+		Value: expr,
+	})
+
+	for _, n := range nested {
+		if n.ref != "" {
+			if g.refs[n.ref] {
+				continue
+			}
+			g.refs[n.ref] = true
+		}
+		if other, ok := g.origins[n.name]; ok {
+			return fmt.Errorf("%s and %s both map to the definition #%s, declare a $codename for one of them", other, n.origin, n.name)
+		}
+		g.origins[n.name] = n.origin
+		if err := g.define(n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// typeToAstExpr converts t to a CUE expression. Structs are referenced by the definition named after the
+// parent and typeIdent and added to nested, except for the struct of the definition itself. Types expanded
+// from $defs are referenced by the definition of their entry.
+func (g *generator) typeToAstExpr(t *yema.Type, parentName, typeIdent string, nested *[]definition) (ast.Expr, error) {
+	if t.Ref != "" {
+		name := ident.Camel(ident.Source(t.Ref, "", g.opts.Transliterate))
+		*nested = append(*nested, definition{name, t, fmt.Sprintf("$defs.%s", t.Ref), t.Ref})
+		return ast.NewIdent("#" + name), nil
+	}
+
 	switch t.Kind {
 	case yema.Bool:
 		return ast.NewIdent("bool"), nil
@@ -79,8 +167,13 @@ func typeToAstExpr(t *yema.Type, fieldName string) (ast.Expr, error) {
 			}, nil
 		}
 
+		// The struct items of a root array are named like a field Item
+		if parentName == g.opts.RootType && typeIdent == "" {
+			typeIdent = "Item"
+		}
+
 		// Get type of array elements
-		elemExpr, err := typeToAstExpr(t.Array, fieldName)
+		elemExpr, err := g.typeToAstExpr(t.Array, parentName, typeIdent, nested)
 		if err != nil {
 			return nil, err
 		}
@@ -96,16 +189,35 @@ func typeToAstExpr(t *yema.Type, fieldName string) (ast.Expr, error) {
 			return nil, fmt.Errorf("struct type with nil Struct field")
 		}
 
+		// Structs other than the one of the definition itself are definitions of their own
+		if typeIdent != "" {
+			name := parentName + typeIdent
+			*nested = append(*nested, definition{name, t, fmt.Sprintf("the elements of %s", parentName), ""})
+			return ast.NewIdent("#" + name), nil
+		}
+
 		structLit := &ast.StructLit{
 			Elts: []ast.Decl{},
 		}
 
-		for k, fieldType := range *t.Struct {
-			label := ast.NewIdent(k)
-			fieldExpr, err := typeToAstExpr(&fieldType, k)
+		for _, k := range t.FieldNames() {
+			fieldType := (*t.Struct)[k]
+			var label ast.Label = ast.NewIdent(k)
+			if !ast.IsValidIdent(k) || strings.HasPrefix(k, "_") || strings.HasPrefix(k, "#") {
+				// Identifiers starting with _ or # are hidden fields or definitions in CUE
+				label = ast.NewString(k)
+			}
+			fieldIdent := ident.Camel(ident.Source(k, fieldType.CodeName, g.opts.Transliterate))
+			start := len(*nested)
+			fieldExpr, err := g.typeToAstExpr(&fieldType, parentName, fieldIdent, nested)
 			if err != nil {
 				return nil, err
 			}
+			for i := start; i < len(*nested); i++ {
+				if (*nested)[i].ref == "" {
+					(*nested)[i].origin = fmt.Sprintf("field %q of %s", k, parentName)
+				}
+			}
 
 			field := &ast.Field{
 				Label:    label,
@@ -123,9 +235,65 @@ func typeToAstExpr(t *yema.Type, fieldName string) (ast.Expr, error) {
 			structLit.Elts = append(structLit.Elts, field)
 		}
 
+		// Definitions are closed, unless they allow any other field
+		if !g.opts.Closed {
+			structLit.Elts = append(structLit.Elts, &ast.Ellipsis{})
+		}
+
 		return structLit, nil
 
+	case yema.Union:
+		if len(t.Union) == 0 {
+			return nil, fmt.Errorf("union type without variants")
+		}
+		variants := make([]ast.Expr, 0, len(t.Union))
+		for i := range t.Union {
+			// Variants are declared in place, as they have no name
+			variantExpr, err := g.typeToAstExpr(&t.Union[i], parentName, "", nested)
+			if err != nil {
+				return nil, err
+			}
+			variants = append(variants, variantExpr)
+		}
+		return ast.NewBinExpr(token.OR, variants...), nil
+
+	case yema.Map:
+		if t.Map == nil {
+			return nil, fmt.Errorf("map type with nil Map field")
+		}
+
+		// The struct values of a root map are named like a field Value
+		if parentName == g.opts.RootType && typeIdent == "" {
+			typeIdent = "Value"
+		}
+
+		valueExpr, err := g.typeToAstExpr(t.Map, parentName, typeIdent, nested)
+		if err != nil {
+			return nil, err
+		}
+
+		// A pattern constraint applies to every field of the struct
+		if t.KeyPattern == "" {
+			return &ast.StructLit{Elts: []ast.Decl{patternField(ast.NewIdent("string"), valueExpr)}}, nil
+		}
+
+		// Keys not matching the pattern are rejected by constraining their values to bottom
+		return &ast.StructLit{
+			Elts: []ast.Decl{
+				patternField(&ast.UnaryExpr{Op: token.MAT, X: ast.NewString(t.KeyPattern)}, valueExpr),
+				patternField(&ast.UnaryExpr{Op: token.NMAT, X: ast.NewString(t.KeyPattern)}, &ast.BottomLit{}),
+			},
+		}, nil
+
 	default:
-		return nil, fmt.Errorf("unexpected type kind: %v for field %s", t.Kind, fieldName)
+		return nil, fmt.Errorf("unexpected type kind: %v in %s", t.Kind, parentName+typeIdent)
+	}
+}
+
+// patternField declares the value of every field whose name matches key
+func patternField(key, value ast.Expr) *ast.Field {
+	return &ast.Field{
+		Label: &ast.ListLit{Elts: []ast.Expr{key}},
+		Value: value,
 	}
 }
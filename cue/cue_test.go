@@ -0,0 +1,108 @@
+package cue
+
+import (
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/aep/yema/parser"
+)
+
+func TestToCue(t *testing.T) {
+	schema, err := parser.FromYAML([]byte(`name: string
+address:
+  street: string
+  geo?: {lat: float64}
+tags?: [{label: string}]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := cuecontext.New()
+	value, err := ToCue(ctx, schema, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := Format(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"#Root: {\n",
+		"\taddress!: #RootAddress\n",
+		"\ttags?: [...#RootTags]\n",
+		"#RootAddress: {\n",
+		"\tgeo?:    #RootAddressGeo\n",
+		"#RootAddressGeo: {\n",
+		"#RootTags: {\n",
+		"\t...\n}",
+	} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	if strings.HasPrefix(string(out), "{") {
+		t.Errorf("expected the definitions at the top level of the file:\n%s", out)
+	}
+
+	// Open structs accept unexpected fields, closed ones reject them like cue vet does
+	doc := ctx.CompileString(`{name: "a", address: {street: "b", geo: {lat: 1.5, lng: 2.5}}, extra: true}`)
+	if err := value.LookupPath(cue.ParsePath("#Root")).Unify(doc).Validate(cue.Concrete(true)); err != nil {
+		t.Errorf("open definitions reject the document: %v", err)
+	}
+	closed, err := ToCue(ctx, schema, Options{Closed: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = closed.LookupPath(cue.ParsePath("#Root")).Unify(doc).Validate(cue.Concrete(true))
+	if err == nil || !strings.Contains(err.Error(), "field not allowed") {
+		t.Errorf("expected unexpected fields to be rejected, got %v", err)
+	}
+
+	// The fields fooBar and foo.bar both name a definition RootFooBar
+	clash, err := parser.FromYAML([]byte(`fooBar: {a: int}
+foo: {bar: {b: int}}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ToCue(ctx, clash, Options{})
+	if err == nil || !strings.Contains(err.Error(), "both map to the definition #RootFooBar") {
+		t.Errorf("expected a collision of #RootFooBar, got %v", err)
+	}
+
+	// References to $defs share the definition of their entry
+	refs, err := parser.FromYAML([]byte(`$defs:
+  address:
+    street: string
+    geo?: {lat: float64}
+home: {$ref: "#/$defs/address"}
+work?: {$ref: "#/$defs/address"}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, err = ToCue(ctx, refs, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err = Format(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"\thome!: #Address\n",
+		"\twork?: #Address\n",
+		"#Address: {\n",
+		"\tgeo?:    #AddressGeo\n",
+	} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(string(out), "#RootHome") || strings.Count(string(out), "#Address: {") != 1 {
+		t.Errorf("expected a single shared #Address:\n%s", out)
+	}
+}
@@ -0,0 +1,213 @@
+// Package example generates example documents from a schema, for fixtures and documentation.
+// Declared examples, or else the first value of an enum, are used where present,
+// every other value is a placeholder for its kind.
+package example
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aep/yema"
+	"github.com/aep/yema/internal/fieldpath"
+	"github.com/aep/yema/validator"
+)
+
+// ToJSON generates an indented example JSON document for a schema, with fields in declaration order
+func ToJSON(t *yema.Type) ([]byte, error) {
+	if t == nil {
+		return nil, fmt.Errorf("nil type provided")
+	}
+
+	var buf bytes.Buffer
+	if err := writeValue(&buf, t, ""); err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, buf.Bytes(), "", "  "); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func writeValue(buf *bytes.Buffer, t *yema.Type, path string) error {
+	example := t.Example
+	if example == nil && len(t.Enum) != 0 {
+		example = t.Enum[0]
+	}
+	if example != nil {
+		data, err := json.Marshal(example)
+		if err != nil {
+			return fmt.Errorf("example of %s cannot be encoded as json: %w", fieldpath.Display(path), err)
+		}
+		buf.Write(data)
+		return nil
+	}
+
+	switch t.Kind {
+	case yema.Bool:
+		buf.WriteString("false")
+	case yema.Int, yema.Int8, yema.Int16, yema.Int32, yema.Int64,
+		yema.Uint, yema.Uint8, yema.Uint16, yema.Uint32, yema.Uint64:
+		buf.WriteString("0")
+	case yema.Float32, yema.Float64:
+		buf.WriteString("0.0")
+	case yema.String:
+		if t.Format == validator.DateTimeFormat {
+			// The reference time of package time is a timestamp in any layout
+			layout, ok := t.FormatArg.(string)
+			if !ok {
+				layout = time.RFC3339
+			}
+			data, err := json.Marshal(time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC).Format(layout))
+			if err != nil {
+				return err
+			}
+			buf.Write(data)
+			break
+		}
+		if t.Format == validator.UUIDFormat {
+			// The version digit follows the third group, the variant of RFC 9562 the fourth
+			version := 4
+			if versions, _ := validator.UUIDVersions(t.FormatArg); versions != nil {
+				version = versions[0]
+			}
+			fmt.Fprintf(buf, `"123e4567-e89b-%d2d3-a456-426614174000"`, version)
+			break
+		}
+		if t.Format == validator.URIFormat {
+			scheme := "https"
+			if schemes, _ := validator.URISchemes(t.FormatArg); schemes != nil {
+				scheme = schemes[0]
+			}
+			fmt.Fprintf(buf, `"%s://example.com/"`, scheme)
+			break
+		}
+		if address, ok := ipExamples[t.Format]; ok {
+			fmt.Fprintf(buf, "%q", address[t.FormatArg == validator.PrivateRange])
+			break
+		}
+		if t.Format == validator.SemverFormat {
+			fmt.Fprintf(buf, "%q", validator.SemverExample(t.FormatArg))
+			break
+		}
+		if t.Format == validator.DurationFormat {
+			if t.FormatArg == validator.GoDuration {
+				buf.WriteString(`"1h30m0s"`)
+			} else {
+				buf.WriteString(`"PT1H30M"`)
+			}
+			break
+		}
+		buf.WriteString(`"string"`)
+	case yema.Bytes:
+		buf.WriteString(`""`)
+	case yema.Array:
+		if t.Array == nil {
+			return fmt.Errorf("array type with nil Array field at %s", fieldpath.Display(path))
+		}
+		buf.WriteByte('[')
+		if err := writeValue(buf, t.Array, path+"[]"); err != nil {
+			return err
+		}
+		buf.WriteByte(']')
+	case yema.Union:
+		if len(t.Union) == 0 {
+			return fmt.Errorf("union type without variants at %s", fieldpath.Display(path))
+		}
+		if err := writeValue(buf, &t.Union[0], path); err != nil {
+			return err
+		}
+	case yema.Map:
+		if t.Map == nil {
+			return fmt.Errorf("map type with nil Map field at %s", fieldpath.Display(path))
+		}
+		// Keys constrained by a pattern cannot be made up, so such maps are left empty
+		if t.KeyPattern != "" {
+			buf.WriteString("{}")
+			break
+		}
+		buf.WriteString(`{"key":`)
+		if err := writeValue(buf, t.Map, path+"[]"); err != nil {
+			return err
+		}
+		buf.WriteByte('}')
+	case yema.Struct:
+		if t.Struct == nil {
+			return fmt.Errorf("struct type with nil Struct field at %s", fieldpath.Display(path))
+		}
+		buf.WriteByte('{')
+		written := make(map[string]bool, len(*t.Struct))
+		for _, name := range t.FieldNames() {
+			fieldType := (*t.Struct)[name]
+			if fieldType.Optional && !compatible(t, name, written) {
+				continue
+			}
+			if len(written) > 0 {
+				buf.WriteByte(',')
+			}
+			written[name] = true
+			key, err := json.Marshal(name)
+			if err != nil {
+				return err
+			}
+			buf.Write(key)
+			buf.WriteByte(':')
+
+			if err := writeValue(buf, &fieldType, fieldpath.Join(path, name)); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	default:
+		return fmt.Errorf("unexpected type kind: %v at %s", t.Kind, fieldpath.Display(path))
+	}
+
+	return nil
+}
+
+// compatible reports whether a field can be added to the written fields of a struct without breaking
+// its requires and conflicts rules, so examples leave out optional fields that conflict with earlier ones
+func compatible(t *yema.Type, name string, written map[string]bool) bool {
+	field := (*t.Struct)[name]
+	for _, other := range field.Conflicts {
+		if written[other] {
+			return false
+		}
+	}
+	for other := range written {
+		for _, conflict := range (*t.Struct)[other].Conflicts {
+			if conflict == name {
+				return false
+			}
+		}
+	}
+	for _, other := range field.Requires {
+		if _, declared := (*t.Struct)[other]; declared && !written[other] && precedes(t, other, name) {
+			return false
+		}
+	}
+	return true
+}
+
+// precedes reports whether field a is declared before field b
+func precedes(t *yema.Type, a, b string) bool {
+	for _, name := range t.FieldNames() {
+		switch name {
+		case a:
+			return true
+		case b:
+			return false
+		}
+	}
+	return false
+}
+
+// ipExamples are the example addresses of the IP formats, by whether the format requires a private address
+var ipExamples = map[string]map[bool]string{
+	validator.IPv4Format: {false: "192.0.2.1", true: "10.0.0.1"},
+	validator.IPv6Format: {false: "2001:db8::1", true: "fd00::1"},
+	validator.CIDRFormat: {false: "192.0.2.0/24", true: "10.0.0.0/8"},
+}
@@ -0,0 +1,94 @@
+package example
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aep/yema/parser"
+	"github.com/aep/yema/validator"
+)
+
+func TestToJSON(t *testing.T) {
+	schema, err := parser.FromYAML([]byte(`
+name:
+  $type: string
+  $example: bob
+age?: int
+tags:
+  $type: [string]
+  $example: [admin, ops]
+address:
+  street: {$type: string, $example: Main St}
+  zip: uint16
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := ToJSON(schema)
+	if err != nil {
+		t.Fatalf("ToJSON failed: %v", err)
+	}
+
+	want := `{
+  "name": "bob",
+  "age": 0,
+  "tags": [
+    "admin",
+    "ops"
+  ],
+  "address": {
+    "street": "Main St",
+    "zip": 0
+  }
+}`
+	if string(out) != want {
+		t.Errorf("got\n%s\nwant\n%s", out, want)
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(out, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if errs := validator.Validate(doc, schema); len(errs) != 0 {
+		t.Errorf("example document does not validate: %v", errs)
+	}
+}
+
+func TestToJSONRules(t *testing.T) {
+	schema, err := parser.FromYAML([]byte(`
+token?:
+  $type: string
+  $conflicts: [password]
+password?: string
+cert?:
+  $type: string
+  $requires: [key]
+key?: string
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := ToJSON(schema)
+	if err != nil {
+		t.Fatalf("ToJSON failed: %v", err)
+	}
+
+	want := `{
+  "token": "string",
+  "cert": "string",
+  "key": "string"
+}`
+	if string(out) != want {
+		t.Errorf("got\n%s\nwant\n%s", out, want)
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(out, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if errs := validator.Validate(doc, schema); len(errs) != 0 {
+		t.Errorf("example document does not validate: %v", errs)
+	}
+}
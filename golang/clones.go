@@ -0,0 +1,181 @@
+package golang
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/aep/yema"
+)
+
+// writeClone writes a Clone method returning a deep copy of a struct, see Options.Clones
+func writeClone(buf *bytes.Buffer, structName string, fields []structField, opts Options) error {
+	for _, field := range fields {
+		if field.ident == "Clone" {
+			return fmt.Errorf("struct %s: field Clone has the name of the method Clone", structName)
+		}
+	}
+
+	fmt.Fprintf(buf, "// Clone returns a deep copy of t, sharing no pointers, slices or maps with it\n")
+	fmt.Fprintf(buf, "func (t *%s) Clone() *%s {\n", structName, structName)
+	buf.WriteString("\tif t == nil {\n\t\treturn nil\n\t}\n\tc := *t\n")
+	e := &cloneEmitter{buf: buf, opts: opts}
+	for _, field := range fields {
+		e.value("c."+field.ident, &field.schema, field.path, 1)
+	}
+	buf.WriteString("\treturn &c\n}\n\n")
+	return nil
+}
+
+// writeUnionClone writes a Clone method returning a deep copy of a union, whose variants are named names
+func writeUnionClone(buf *bytes.Buffer, name string, names []string, variants []yema.Type, path string, opts Options) {
+	fmt.Fprintf(buf, "// Clone returns a deep copy of u, sharing no pointers, slices or maps with it\n")
+	fmt.Fprintf(buf, "func (u *%s) Clone() *%s {\n", name, name)
+	buf.WriteString("\tif u == nil {\n\t\treturn nil\n\t}\n\tc := *u\n")
+
+	// Variants without pointers or slices are copied by copying the union
+	var cases bytes.Buffer
+	e := &cloneEmitter{buf: &cases, opts: opts}
+	for i := range variants {
+		variant := variants[i]
+		variant.Optional = false
+		if !e.needsClone(&variant, path) {
+			continue
+		}
+		fmt.Fprintf(&cases, "\tcase %s:\n", names[i])
+		e.value("v", &variant, path, 2)
+		cases.WriteString("\t\tc.Value = v\n")
+	}
+	if cases.Len() > 0 {
+		buf.WriteString("\tswitch v := u.Value.(type) {\n")
+		buf.Write(cases.Bytes())
+		buf.WriteString("\t}\n")
+	}
+	buf.WriteString("\treturn &c\n}\n\n")
+}
+
+// cloneEmitter writes the Go statements deep copying values
+type cloneEmitter struct {
+	buf  *bytes.Buffer
+	opts Options
+	vars int
+}
+
+func (e *cloneEmitter) line(depth int, format string, args ...interface{}) {
+	e.buf.WriteString(strings.Repeat("\t", depth))
+	fmt.Fprintf(e.buf, format, args...)
+	e.buf.WriteString("\n")
+}
+
+// needsClone reports whether values of t at path hold pointers, slices or maps. Overridden types are copied as they are.
+func (e *cloneEmitter) needsClone(t *yema.Type, path string) bool {
+	if _, ok := e.opts.overrides[path]; ok && path != "" {
+		return false
+	}
+	if t.Optional && t.Kind != yema.Array && t.Kind != yema.Bytes && t.Kind != yema.Map {
+		return true
+	}
+	switch t.Kind {
+	case yema.Array, yema.Bytes, yema.Map:
+		return true
+	case yema.Struct:
+		for name, field := range *t.Struct {
+			fieldPath := name
+			if path != "" {
+				fieldPath = path + "." + name
+			}
+			if e.needsClone(&field, fieldPath) {
+				return true
+			}
+		}
+	case yema.Union:
+		for i := range t.Union {
+			if e.needsClone(&t.Union[i], path) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// value emits the statements replacing expr, a shallow copy of a value of t at path, with a deep copy
+func (e *cloneEmitter) value(expr string, t *yema.Type, path string, depth int) {
+	if !e.needsClone(t, path) {
+		return
+	}
+
+	switch {
+	case t.Kind == yema.Struct || t.Kind == yema.Union:
+		// Clone returns nil for nil pointers
+		if t.Optional {
+			e.line(depth, "%s = %s.Clone()", expr, expr)
+		} else {
+			e.line(depth, "%s = *%s.Clone()", expr, expr)
+		}
+	case t.Kind == yema.Bytes:
+		e.line(depth, "%s = slices.Clone(%s)", expr, expr)
+	case t.Kind == yema.Array:
+		// slices.Clone keeps nil slices nil, required lists that are missing stay told apart from empty ones
+		e.line(depth, "%s = slices.Clone(%s)", expr, expr)
+		if e.needsClone(t.Array, path+"[]") {
+			e.vars++
+			i := fmt.Sprintf("i%d", e.vars)
+			e.line(depth, "for %s := range %s {", i, expr)
+			e.value(expr+"["+i+"]", t.Array, path+"[]", depth+1)
+			e.line(depth, "}")
+		}
+	case t.Kind == yema.Map:
+		// Values of maps are not addressable, they are copied and stored again
+		e.line(depth, "%s = maps.Clone(%s)", expr, expr)
+		if e.needsClone(t.Map, path+"[]") {
+			e.vars++
+			k, v := fmt.Sprintf("k%d", e.vars), fmt.Sprintf("v%d", e.vars)
+			e.line(depth, "for %s, %s := range %s {", k, v, expr)
+			e.value(v, t.Map, path+"[]", depth+1)
+			e.line(depth+1, "%s[%s] = %s", expr, k, v)
+			e.line(depth, "}")
+		}
+	default:
+		e.vars++
+		v := fmt.Sprintf("v%d", e.vars)
+		e.line(depth, "if %s != nil {", expr)
+		e.line(depth+1, "%s := *%s", v, expr)
+		e.line(depth+1, "%s = &%s", expr, v)
+		e.line(depth, "}")
+	}
+}
+
+// holds reports whether t at path holds a value of one of kinds anywhere, such as slices copied with package
+// slices or maps copied with package maps
+func (e *cloneEmitter) holds(t *yema.Type, path string, kinds ...yema.Kind) bool {
+	if _, ok := e.opts.overrides[path]; ok && path != "" {
+		return false
+	}
+	if slices.Contains(kinds, t.Kind) {
+		return true
+	}
+	switch t.Kind {
+	case yema.Array:
+		return t.Array != nil && e.holds(t.Array, path+"[]", kinds...)
+	case yema.Map:
+		return t.Map != nil && e.holds(t.Map, path+"[]", kinds...)
+	case yema.Union:
+		for i := range t.Union {
+			if e.holds(&t.Union[i], path, kinds...) {
+				return true
+			}
+		}
+	case yema.Struct:
+		for name, field := range *t.Struct {
+			fieldPath := name
+			if path != "" {
+				fieldPath = path + "." + name
+			}
+			if e.holds(&field, fieldPath, kinds...) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package golang
+
+import (
+	"bytes"
+	"fmt"
+	"go/token"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// writeConstructor writes NewType taking the required fields of a struct in declaration order.
+// Optional fields are left empty unless set by functional options, TypeOption, written by WithTypeField.
+func writeConstructor(buf *bytes.Buffer, structName string, fields []structField) {
+	// Parameters must not shadow the variables of the constructor
+	params := map[string]string{}
+	used := map[string]bool{"t": true, "opt": true, "opts": true}
+	for _, field := range fields {
+		name := paramName(field.ident)
+		for used[name] {
+			name += "_"
+		}
+		used[name] = true
+		params[field.ident] = name
+	}
+
+	var required, optional []structField
+	for _, field := range fields {
+		if field.optional {
+			optional = append(optional, field)
+		} else {
+			required = append(required, field)
+		}
+	}
+
+	var args []string
+	for _, field := range required {
+		args = append(args, params[field.ident]+" "+field.goType)
+	}
+	if len(optional) > 0 {
+		fmt.Fprintf(buf, "// %sOption sets an optional field of %s, see New%s\n", structName, structName, structName)
+		fmt.Fprintf(buf, "type %sOption func(*%s)\n\n", structName, structName)
+		args = append(args, "opts ..."+structName+"Option")
+	}
+
+	fmt.Fprintf(buf, "// New%s returns a %s with the required fields given\n", structName, structName)
+	fmt.Fprintf(buf, "func New%s(%s) *%s {\n", structName, strings.Join(args, ", "), structName)
+	fmt.Fprintf(buf, "\tt := &%s{", structName)
+	if len(required) > 0 {
+		buf.WriteString("\n")
+		for _, field := range required {
+			fmt.Fprintf(buf, "\t\t%s: %s,\n", field.ident, params[field.ident])
+		}
+		buf.WriteString("\t")
+	}
+	buf.WriteString("}\n")
+	if len(optional) > 0 {
+		buf.WriteString("\tfor _, opt := range opts {\n\t\topt(t)\n\t}\n")
+	}
+	buf.WriteString("\treturn t\n}\n\n")
+
+	for _, field := range optional {
+		param := params[field.ident]
+		// Optional slices are nil when unset rather than pointers
+		valueType, value := field.goType, param
+		if strings.HasPrefix(valueType, "*") {
+			valueType, value = valueType[1:], "&"+param
+		}
+		fmt.Fprintf(buf, "// With%s%s sets %s of a %s\n", structName, field.ident, field.ident, structName)
+		fmt.Fprintf(buf, "func With%s%s(%s %s) %sOption {\n", structName, field.ident, param, valueType, structName)
+		fmt.Fprintf(buf, "\treturn func(t *%s) {\n\t\tt.%s = %s\n\t}\n}\n\n", structName, field.ident, value)
+	}
+}
+
+// paramName returns the name of the parameter for a field, its identifier starting in lower case
+func paramName(fieldIdent string) string {
+	first, size := utf8.DecodeRuneInString(fieldIdent)
+	name := string(unicode.ToLower(first)) + fieldIdent[size:]
+	if token.IsKeyword(name) {
+		name += "_"
+	}
+	return name
+}
@@ -0,0 +1,72 @@
+package golang
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aep/yema"
+	"github.com/aep/yema/internal/ident"
+	"github.com/aep/yema/validator"
+)
+
+// isStringEnum reports whether t is a string restricted to an enum, which is generated as a type of its own
+// if Options.Enums is set. UUIDs keep their type.
+func isStringEnum(t *yema.Type) bool {
+	if t.Kind != yema.String || len(t.Enum) == 0 || t.Format == validator.UUIDFormat {
+		return false
+	}
+	for _, value := range t.Enum {
+		if _, ok := value.(string); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// writeEnum writes the type of a string enum along with a constant for every value, named after the type
+// and the value. Values other than those of the enum are rejected when marshaling and unmarshaling JSON.
+func writeEnum(buf *bytes.Buffer, name string, t *yema.Type, transliterate bool) error {
+	consts := make([]string, len(t.Enum))
+	byConst := make(map[string]string, len(t.Enum))
+	for i, value := range t.Enum {
+		s := value.(string)
+		consts[i] = name + ident.Camel(ident.Source(s, "", transliterate))
+		if other, ok := byConst[consts[i]]; ok {
+			return fmt.Errorf("values %q and %q of %s both map to the constant %s", other, s, name, consts[i])
+		}
+		byConst[consts[i]] = s
+	}
+
+	writeComment(buf, "", t.Description, fmt.Sprintf("%s is one of the values of an enum", name))
+	fmt.Fprintf(buf, "type %s string\n\n", name)
+
+	fmt.Fprintf(buf, "// Values of %s\n", name)
+	buf.WriteString("const (\n")
+	for i, value := range t.Enum {
+		fmt.Fprintf(buf, "\t%s %s = %s\n", consts[i], name, strconv.Quote(value.(string)))
+	}
+	buf.WriteString(")\n\n")
+
+	fmt.Fprintf(buf, "// IsValid reports whether v is one of the values of %s\n", name)
+	fmt.Fprintf(buf, "func (v %s) IsValid() bool {\n", name)
+	fmt.Fprintf(buf, "\tswitch v {\n\tcase %s:\n\t\treturn true\n\t}\n", strings.Join(consts, ", "))
+	buf.WriteString("\treturn false\n}\n\n")
+
+	fmt.Fprintf(buf, "// MarshalJSON encodes v as a string, failing for values other than those of %s\n", name)
+	fmt.Fprintf(buf, "func (v %s) MarshalJSON() ([]byte, error) {\n", name)
+	buf.WriteString("\tif !v.IsValid() {\n")
+	fmt.Fprintf(buf, "\t\treturn nil, fmt.Errorf(\"invalid %s %%q\", string(v))\n", name)
+	buf.WriteString("\t}\n\treturn json.Marshal(string(v))\n}\n\n")
+
+	fmt.Fprintf(buf, "// UnmarshalJSON decodes a string into v, failing for values other than those of %s\n", name)
+	fmt.Fprintf(buf, "func (v *%s) UnmarshalJSON(data []byte) error {\n", name)
+	buf.WriteString("\tvar s string\n")
+	buf.WriteString("\tif err := json.Unmarshal(data, &s); err != nil {\n\t\treturn err\n\t}\n")
+	fmt.Fprintf(buf, "\tif !%s(s).IsValid() {\n", name)
+	fmt.Fprintf(buf, "\t\treturn fmt.Errorf(\"invalid %s %%q\", s)\n", name)
+	fmt.Fprintf(buf, "\t}\n\t*v = %s(s)\n\treturn nil\n}\n\n", name)
+
+	return nil
+}
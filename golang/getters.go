@@ -0,0 +1,37 @@
+package golang
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// writeGetters writes a GetField method for every field of a struct, see Options.Getters
+func writeGetters(buf *bytes.Buffer, structName string, fields []structField) error {
+	idents := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		idents[field.ident] = true
+	}
+
+	for _, field := range fields {
+		getter := "Get" + field.ident
+		if idents[getter] {
+			return fmt.Errorf("struct %s: field %s has the name of the getter of %s", structName, getter, field.ident)
+		}
+
+		// Nested structs are returned as pointers, optional fields other than those dereferenced
+		resultType, value, cond := field.goType, "t."+field.ident, "t != nil"
+		switch {
+		case field.nested && !field.pointer:
+			resultType, value = "*"+field.goType, "&t."+field.ident
+		case field.pointer && !field.nested:
+			resultType, value = strings.TrimPrefix(field.goType, "*"), "*t."+field.ident
+			cond += " && t." + field.ident + " != nil"
+		}
+
+		fmt.Fprintf(buf, "// %s returns %s, or its zero value if it is not set\n", getter, field.ident)
+		fmt.Fprintf(buf, "func (t *%s) %s() (v %s) {\n", structName, getter, resultType)
+		fmt.Fprintf(buf, "\tif %s {\n\t\tv = %s\n\t}\n\treturn v\n}\n\n", cond, value)
+	}
+	return nil
+}
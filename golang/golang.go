@@ -3,27 +3,156 @@ package golang
 import (
 	"bytes"
 	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"path"
+	"sort"
+	"strings"
 	"unicode"
 
 	"github.com/aep/yema"
+	"github.com/aep/yema/internal/fieldpath"
+	"github.com/aep/yema/internal/ident"
+	"github.com/aep/yema/validator"
 )
 
 // Options holds configuration options for Go code generation
 type Options struct {
 	// Package is the name of the Go package to generate
 	Package string
-	// RootType is the name of the root struct type
+	// RootType is the name of the root type. If the root is an array, it names the element type
+	// and the root itself is named RootType + "List".
 	RootType string
+	// Transliterate derives identifiers from ASCII transliterations of non-ASCII field names,
+	// without it a name such as "名前" generates an unexported field
+	Transliterate bool
+	// ProtoJSON follows the protobuf JSON mapping: 64-bit integer fields are encoded as strings and fields
+	// that are not structs are omitted when empty, as proto3 omits default values.
+	// Encoding/json cannot quote the elements of slices, so lists of 64-bit integers stay numbers.
+	ProtoJSON bool
+	// Proto shapes structs like those of protoc-gen-go, so their JSON follows the protobuf JSON mapping of a message
+	// declaring the same fields: ProtoJSON and Enums are implied, json keys are the json_name of the fields and
+	// a protobuf tag numbers them in declaration order, so fields must only be added at the end. Unions are rejected,
+	// as the variants of oneofs are named fields of their message, and so are lists of lists.
+	Proto bool
+	// Tags are the struct tags of generated fields, such as yaml or toml for schemas of config files, json if none are given.
+	// Optional fields are omitted when empty in every tag, 64-bit integers are only quoted by json.
+	Tags []Tag
+	// TagTemplates are extra struct tags of generated fields, such as validate or db, by tag name.
+	// Each is a text/template executed with the TagField of a field, tags rendering empty are left out.
+	TagTemplates map[string]string
+	// Constructors generates a NewType function for every struct taking its required fields, optional fields
+	// are set by functional options such as WithTypeField
+	Constructors bool
+	// Clones generates a Clone method for every struct and union returning a deep copy, which shares no pointers,
+	// slices or maps with the original. Fields of overridden types are copied as they are.
+	Clones bool
+	// Getters generates a GetField method for every field as protobuf does, returning the zero value of the field
+	// if the struct or the pointer of an optional field is nil, and a pointer to nested structs so calls can be chained
+	Getters bool
+	// Enums generates a type of its own for every string restricted to an $enum, named like nested structs,
+	// with a constant for every value and JSON methods rejecting other values
+	Enums bool
+	// TimeTypes maps timestamps in RFC 3339 to time.Time, and other timestamps and durations to types of their own
+	// holding a time.Time or time.Duration that are written in the layout or dialect of their format.
+	// Strings are kept without it, for teams that handle times themselves.
+	TimeTypes bool
+	// Validators generates a Validate method on the root struct, or on the element of a root array,
+	// checking the rules of the schema the Go types do not enforce, such as required lists and enums
+	Validators bool
+	// Parsers generates ParseType decoding a single JSON value into the root type, calling Validate if Validators
+	// is set and the root is a struct. Root arrays are not validated, as Validate checks a single element.
+	Parsers bool
+	// DenyUnknownFields makes parsers fail for fields the schema does not declare, as the option of package validator
+	DenyUnknownFields bool
+	// TypeOverrides replaces the Go types generated for fields of the root struct by path, such as address.zip
+	// or tags[] for the items of an array and the values of a map, with Go types qualified by the import path
+	// of their package, such as github.com/shopspring/decimal.Decimal. Optional fields hold pointers to them
+	// unless they are pointers, slices or maps. The imports are added to the generated file.
+	TypeOverrides map[string]string
+
+	// overrides are the parsed TypeOverrides
+	overrides map[string]override
+	// types collects the top-level types in the order they are generated, see ToGolangFiles
+	types *[]typeStart
 }
 
 // ToGolangWithOptions converts a yema.Type to Go struct definitions with custom options
 func ToGolang(t *yema.Type, opts Options) ([]byte, error) {
-	if t == nil {
-		return nil, fmt.Errorf("nil type provided")
+	g, err := generate(t, opts)
+	if err != nil {
+		return nil, err
 	}
+	decls := append(append(g.decls, g.methods...), g.helpers...)
+	return formatFile(g.pkg, g.imports, decls)
+}
 
-	if t.Kind != yema.Struct {
-		return nil, fmt.Errorf("expected root type to be Struct, got %v", t.Kind)
+// ToGolangFiles converts a yema.Type to Go code like ToGolang, split into a file for every top-level type
+// named after it in lower case, such as rootaddress.go, with the imports it uses. Validators and the helpers
+// of generated code, validators and parsers go into the file of the root type.
+func ToGolangFiles(t *yema.Type, opts Options) (map[string][]byte, error) {
+	g, err := generate(t, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte, len(g.types))
+	names := make(map[string]string, len(g.types))
+	for i, start := range g.types {
+		// File names without underscores never carry build constraints, as root_linux.go would
+		name := strings.ToLower(start.name) + ".go"
+		if other, ok := names[name]; ok {
+			return nil, fmt.Errorf("types %s and %s both map to the file %s", other, start.name, name)
+		}
+		names[name] = start.name
+
+		end := len(g.decls)
+		if i+1 < len(g.types) {
+			end = g.types[i+1].offset
+		}
+		decls := g.decls[start.offset:end:end]
+		if i == 0 {
+			decls = append(append(decls, g.methods...), g.helpers...)
+		}
+		imports, err := usedImports(g.imports, g.importNames, decls)
+		if err != nil {
+			return nil, fmt.Errorf("generated code of %s: %w", start.name, err)
+		}
+		if files[name], err = formatFile(g.pkg, imports, decls); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// generated is the Go code generated for a schema before it is written to files
+type generated struct {
+	pkg     string
+	imports []string
+	// importNames are the names of the packages of imports, by import path
+	importNames map[string]string
+	// decls are the declarations of the types, the root type first
+	decls []byte
+	// types are the top-level types in the order of decls, each declared up to the start of the next
+	types []typeStart
+	// methods, the validators and parsers, and helpers are the declarations following the types
+	methods, helpers []byte
+}
+
+// typeStart is the offset in the generated declarations a top-level type starts at
+type typeStart struct {
+	name   string
+	offset int
+}
+
+// generate generates the Go code of a schema, see ToGolang. Types expanded from an entry of $defs are generated
+// once, named after the entry, and shared by all its uses. Schemas have no parameterized definitions, so no
+// generic types are generated.
+func generate(t *yema.Type, opts Options) (*generated, error) {
+	if t == nil {
+		return nil, fmt.Errorf("nil type provided")
 	}
 
 	// Use default values if not provided
@@ -33,78 +162,369 @@ func ToGolang(t *yema.Type, opts Options) ([]byte, error) {
 	if opts.RootType == "" {
 		opts.RootType = "Root"
 	}
+	if len(opts.Tags) == 0 {
+		opts.Tags = defaultTags
+	}
+	for _, tag := range opts.Tags {
+		if err := tag.check(); err != nil {
+			return nil, err
+		}
+	}
+	templates, err := parseTagTemplates(opts.TagTemplates, opts.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	// A root array is a list of its element type, which is named RootType
+	elem, depth := t, 0
+	for elem.Kind == yema.Array && elem.Array != nil {
+		elem = elem.Array
+		depth++
+	}
 
+	if len(opts.TypeOverrides) > 0 && elem.Kind != yema.Struct {
+		return nil, fmt.Errorf("type overrides require a root struct, got %v", elem.Kind)
+	}
+	opts.overrides, err = parseOverrides(elem, opts.TypeOverrides)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Proto {
+		opts.ProtoJSON, opts.Enums = true, true
+		if err := checkProto(elem, "", opts); err != nil {
+			return nil, err
+		}
+		_, taken := opts.TagTemplates["protobuf"]
+		for _, tag := range opts.Tags {
+			taken = taken || tag.Name == "protobuf"
+		}
+		if taken {
+			return nil, fmt.Errorf("tag protobuf is written by the proto mode and cannot be given")
+		}
+	}
+
+	g := &generated{pkg: opts.Package, importNames: make(map[string]string)}
+	generatedStructs := ident.Types{opts.RootType: ""}
+	opts.types = &g.types
 	var buf bytes.Buffer
-	buf.WriteString(fmt.Sprintf("package %s\n\n", opts.Package))
+	var imports []string
+	for _, o := range opts.overrides {
+		if o.importPath != "" {
+			imports = append(imports, o.importPath)
+			g.importNames[o.importPath] = o.packageName()
+		}
+	}
+	reprs := stringReprs(elem, "", opts, make(map[goString]bool))
+	if reprs[uuidString] {
+		imports = append(imports, "github.com/google/uuid")
+	}
+	if reprs[enumString] {
+		imports = append(imports, "encoding/json", "fmt")
+	}
+	if reprs[timeString] || reprs[layoutString] || reprs[goDurationString] {
+		imports = append(imports, "time")
+	}
+	isoDurations := reprs[isoDurationString]
+	if isoDurations {
+		imports = append(imports, isoDurationImports...)
+	}
+	unions := hasUnion(elem, "", opts)
+	if unions {
+		imports = append(imports, unionImports...)
+	}
+	// Clones copy slices and maps, there are none on types other than structs and unions
+	if opts.Clones && (elem.Kind == yema.Struct || elem.Kind == yema.Union) {
+		cloner := &cloneEmitter{opts: opts}
+		if cloner.holds(elem, "", yema.Array, yema.Bytes) {
+			imports = append(imports, "slices")
+		}
+		if cloner.holds(elem, "", yema.Map) {
+			imports = append(imports, "maps")
+		}
+	}
 
-	// Process the root struct
-	err := generateStructs(t, opts.RootType, &buf, make(map[string]bool))
+	g.types = append(g.types, typeStart{opts.RootType, 0})
+	if depth > 0 {
+		fmt.Fprintf(&buf, "// %sList is a list of %s\n", opts.RootType, opts.RootType)
+		fmt.Fprintf(&buf, "type %sList %s%s\n\n", opts.RootType, strings.Repeat("[]", depth), opts.RootType)
+	}
+
+	switch {
+	case stringRepr(elem, opts).named():
+		if err := writeStringType(&buf, opts.RootType, elem, opts); err != nil {
+			return nil, err
+		}
+	case elem.Kind == yema.Union:
+		if err := generateUnion(elem, opts.RootType, "", &buf, generatedStructs, opts, templates); err != nil {
+			return nil, err
+		}
+	case elem.Kind != yema.Struct:
+		scalar := *elem
+		scalar.Optional = false
+		// The values of a root map are named like a field Value
+		valuesIdent := ""
+		if elem.Kind == yema.Map {
+			valuesIdent = "Value"
+		}
+		goType, nestedName, err := typeToGoType(&scalar, opts.RootType, valuesIdent, "", opts)
+		if err != nil {
+			return nil, err
+		}
+		writeComment(&buf, "", elem.Description, fmt.Sprintf("%s represents a generated type", opts.RootType))
+		// A type defined as time.Time would lose its methods
+		if stringRepr(elem, opts) == timeString {
+			fmt.Fprintf(&buf, "type %s = %s\n\n", opts.RootType, goType)
+		} else {
+			fmt.Fprintf(&buf, "type %s %s\n\n", opts.RootType, goType)
+		}
+		if nestedName != "" {
+			nested, valuePath := nestedElem(&scalar, "")
+			if err := generateNested(nestedType{nestedName, valuePath, nested}, &buf, generatedStructs, opts, templates); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		// Process the root struct
+		err = generateStructs(elem, opts.RootType, "", &buf, generatedStructs, opts, templates)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	g.decls = buf.Bytes()
+
+	// Validators check a single element of root arrays, like those of the other generators
+	var methods bytes.Buffer
+	validate := opts.Validators && elem.Kind == yema.Struct
+	if validate {
+		if err := generateValidator(elem, opts.RootType, &methods, opts); err != nil {
+			return nil, err
+		}
+		imports = append(imports, "errors")
+		if bytes.Contains(methods.Bytes(), []byte("fmt.Errorf(")) {
+			imports = append(imports, "fmt")
+		}
+		for _, importPath := range validatorImports {
+			if bytes.Contains(methods.Bytes(), []byte(path.Base(importPath)+".")) {
+				imports = append(imports, importPath)
+			}
+		}
+		// Validators of durations without a type of their own parse them like the types do
+		if bytes.Contains(methods.Bytes(), []byte("parseISODuration(")) && !isoDurations {
+			isoDurations = true
+			imports = append(imports, isoDurationImports...)
+		}
+	}
+	if opts.Parsers {
+		rootType := opts.RootType
+		if depth > 0 {
+			rootType += "List"
+		}
+		writeParser(&methods, rootType, validate && depth == 0, opts)
+		imports = append(imports, parserImports...)
+	}
+	g.methods = methods.Bytes()
+
+	if isoDurations {
+		g.helpers = append(g.helpers, isoDurationHelpers...)
+	}
+	if unions {
+		g.helpers = append(g.helpers, unmarshalStrictHelper...)
+	}
+
+	g.imports = imports
+	return g, nil
+}
+
+// formatFile returns a formatted file of the package holding decls, which must use all imports
+func formatFile(pkg string, imports []string, decls []byte) ([]byte, error) {
+	// Formatting fails only if the generated code does not parse, which is a bug of the generator
+	formatted, err := format.Source(withHeader(pkg, imports, decls))
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w", err)
+	}
+	return formatted, nil
+}
+
+// usedImports returns the imports whose package is referenced by decls. Packages are named after the last element
+// of their import path unless names holds another name.
+func usedImports(imports []string, names map[string]string, decls []byte) ([]string, error) {
+	f, err := parser.ParseFile(token.NewFileSet(), "", append([]byte("package p\n\n"), decls...), 0)
 	if err != nil {
 		return nil, err
 	}
+	// Identifiers not declared in the file, such as the x of x.Y, refer to packages
+	referenced := make(map[string]bool)
+	ast.Inspect(f, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if x, ok := sel.X.(*ast.Ident); ok && x.Obj == nil {
+				referenced[x.Name] = true
+			}
+		}
+		return true
+	})
 
-	return buf.Bytes(), nil
+	var used []string
+	for _, importPath := range imports {
+		name, ok := names[importPath]
+		if !ok {
+			name = path.Base(importPath)
+		}
+		if referenced[name] {
+			used = append(used, importPath)
+		}
+	}
+	return used, nil
 }
 
-// generateStructs recursively generates Go struct definitions
-func generateStructs(t *yema.Type, structName string, buf *bytes.Buffer, generatedStructs map[string]bool) error {
-	if t.Kind != yema.Struct {
-		return fmt.Errorf("expected Struct type, got %v", t.Kind)
+// withHeader prepends the package clause and the imports, which may repeat, to the declarations of a file.
+// Imports of the standard library are grouped before the others, as goimports does.
+func withHeader(pkg string, imports []string, decls []byte) []byte {
+	var std, other []string
+	seen := make(map[string]bool)
+	for _, path := range imports {
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		if strings.Contains(path, ".") {
+			other = append(other, path)
+		} else {
+			std = append(std, path)
+		}
 	}
+	sort.Strings(std)
+	sort.Strings(other)
 
-	// Don't regenerate structs we've already processed
-	if generatedStructs[structName] {
-		return nil
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "package %s\n\n", pkg)
+	switch {
+	case len(seen) == 1:
+		fmt.Fprintf(&buf, "import %q\n\n", imports[0])
+	case len(seen) > 1:
+		buf.WriteString("import (\n")
+		for _, path := range std {
+			fmt.Fprintf(&buf, "\t%q\n", path)
+		}
+		if len(std) > 0 && len(other) > 0 {
+			buf.WriteString("\n")
+		}
+		for _, path := range other {
+			fmt.Fprintf(&buf, "\t%q\n", path)
+		}
+		buf.WriteString(")\n\n")
 	}
+	buf.Write(decls)
+	return buf.Bytes()
+}
 
-	// Mark this struct as generated
-	generatedStructs[structName] = true
+// nestedType is a nested struct or enum type that still needs to be generated
+type nestedType struct {
+	name string
+	// path is the path of the type in the schema, see Options.TypeOverrides
+	path string
+	t    *yema.Type
+}
+
+// structField is a field of a generated struct
+type structField struct {
+	// ident is the identifier of the field
+	ident string
+	// goType is the type of the field, a pointer for optional fields other than slices
+	goType   string
+	optional bool
+	// pointer is set if the field is a pointer because it is optional
+	pointer bool
+	// nested is set if the field holds a generated struct, directly or through such a pointer
+	nested bool
+	// schema is the type of the field and path its path in the schema, see Options.TypeOverrides
+	schema yema.Type
+	path   string
+}
+
+// generateStructs recursively generates Go struct definitions. Fields are written in declaration order,
+// or sorted by name for schemas without one, and nested structs follow their parent in the order of its fields,
+// so the output is the same on every run. The path of the struct is empty for the root. The caller claims the
+// name of the struct in generatedStructs.
+func generateStructs(t *yema.Type, structName, path string, buf *bytes.Buffer, generatedStructs ident.Types, opts Options, templates []tagTemplate) error {
+	if t.Kind != yema.Struct {
+		return fmt.Errorf("expected Struct type, got %v", t.Kind)
+	}
 
 	// Start struct definition
-	fmt.Fprintf(buf, "// %s represents a generated struct\n", structName)
+	writeComment(buf, "", t.Description, fmt.Sprintf("%s represents a generated struct", structName))
 	fmt.Fprintf(buf, "type %s struct {\n", structName)
 
+	goFieldNames, err := ident.Fields(t, ident.Camel, opts.Transliterate)
+	if err != nil {
+		return fmt.Errorf("struct %s: %w", structName, err)
+	}
+
 	// Track any nested structs we need to generate
-	nestedStructs := make(map[string]*yema.Type)
+	var nestedStructs []nestedType
+	var fields []structField
 
 	// Process all fields in the struct
-	for fieldName, fieldType := range *t.Struct {
-		goFieldName := toCamelCase(fieldName)
-		goFieldType, nestedName, err := typeToGoType(&fieldType, structName, fieldName)
+	for i, fieldName := range t.FieldNames() {
+		fieldType := (*t.Struct)[fieldName]
+
+		goFieldName := goFieldNames[fieldName]
+		fieldPath := fieldName
+		if path != "" {
+			fieldPath = path + "." + fieldName
+		}
+		goFieldType, nestedName, err := typeToGoType(&fieldType, structName, goFieldName, fieldPath, opts)
 		if err != nil {
 			return err
 		}
 
-		// Check if this field requires a nested struct to be generated
-		if nestedName != "" && fieldType.Kind == yema.Struct {
-			nestedStructs[nestedName] = &yema.Type{
-				Kind:   yema.Struct,
-				Struct: fieldType.Struct,
-			}
-		} else if nestedName != "" && fieldType.Kind == yema.Array && fieldType.Array.Kind == yema.Struct {
-			nestedStructs[nestedName] = &yema.Type{
-				Kind:   yema.Struct,
-				Struct: fieldType.Array.Struct,
-			}
+		// Check if this field requires a nested struct or enum to be generated, possibly as the item of nested
+		// arrays or the value of maps
+		if nestedName != "" {
+			nested, elemPath := nestedElem(&fieldType, fieldPath)
+			nestedStructs = append(nestedStructs, nestedType{nestedName, elemPath, nested})
 		}
 
-		// Add json tag
-		jsonTag := fieldName
-		if fieldType.Optional {
-			jsonTag += ",omitempty"
+		tags, err := fieldTags(fieldName, goFieldName, fieldPath, i+1, fieldType, opts, templates)
+		if err != nil {
+			return err
 		}
 
-		// Write field definition
-		fmt.Fprintf(buf, "\t%s %s `json:\"%s\"`\n", goFieldName, goFieldType, jsonTag)
+		// Write field definition, annotated with the direction it is restricted to
+		writeComment(buf, "\t", fieldType.Description, "")
+		fmt.Fprintf(buf, "\t%s %s `%s`%s\n", goFieldName, goFieldType, tags, accessComment(fieldType))
+		_, overridden := opts.overrides[fieldPath]
+		fields = append(fields, structField{
+			ident:    goFieldName,
+			goType:   goFieldType,
+			optional: fieldType.Optional,
+			pointer:  fieldType.Optional && !overridden && fieldType.Kind != yema.Array && fieldType.Kind != yema.Bytes && fieldType.Kind != yema.Map,
+			nested:   !overridden && fieldType.Kind == yema.Struct,
+			schema:   fieldType,
+			path:     fieldPath,
+		})
 	}
 
 	// Close struct definition
 	fmt.Fprintf(buf, "}\n\n")
 
-	// Generate any nested struct definitions
-	for nestedName, nestedStruct := range nestedStructs {
-		err := generateStructs(nestedStruct, nestedName, buf, generatedStructs)
-		if err != nil {
+	if opts.Constructors {
+		writeConstructor(buf, structName, fields)
+	}
+	if opts.Getters {
+		if err := writeGetters(buf, structName, fields); err != nil {
+			return err
+		}
+	}
+	if opts.Clones {
+		if err := writeClone(buf, structName, fields, opts); err != nil {
+			return err
+		}
+	}
+
+	// Generate any nested struct, enum and union definitions
+	for _, nested := range nestedStructs {
+		if err := generateNested(nested, buf, generatedStructs, opts, templates); err != nil {
 			return err
 		}
 	}
@@ -112,11 +532,173 @@ func generateStructs(t *yema.Type, structName string, buf *bytes.Buffer, generat
 	return nil
 }
 
-// typeToGoType converts a yema.Type to a Go type string
-func typeToGoType(t *yema.Type, parentName, fieldName string) (string, string, error) {
+// nestedElem returns the type nested in a field of type t at path, the item of arrays and the value of maps,
+// and its path. Types expanded from $defs are at the path of their entry, as all uses share them.
+// Structs keep only their fields, description and reference.
+func nestedElem(t *yema.Type, path string) (*yema.Type, string) {
+	for t.Kind == yema.Array || t.Kind == yema.Map {
+		if t.Kind == yema.Array {
+			t = t.Array
+		} else {
+			t = t.Map
+		}
+		path += "[]"
+	}
+	if t.Ref != "" {
+		path = defsPath(t.Ref)
+	}
+	if t.Kind == yema.Struct {
+		return &yema.Type{
+			Kind:        yema.Struct,
+			Struct:      t.Struct,
+			Order:       t.Order,
+			Description: t.Description,
+			Ref:         t.Ref,
+		}, path
+	}
+	return t, path
+}
+
+// defsPath returns the path of an entry of $defs
+func defsPath(ref string) string {
+	return "$defs." + ref
+}
+
+// nestedTypeName returns the name of a nested type, named after its entry of $defs if it was expanded from one,
+// otherwise after its parent and the field identifier
+func nestedTypeName(t *yema.Type, parentName, fieldIdent string, opts Options) string {
+	if t.Ref != "" {
+		return ident.Camel(ident.Source(t.Ref, "", opts.Transliterate))
+	}
+	return parentName + fieldIdent
+}
+
+// generateNested generates a nested type unless it was generated already. Types of other paths named alike,
+// such as those of the fields a.bC and ab.c, are reported.
+func generateNested(nested nestedType, buf *bytes.Buffer, generatedStructs ident.Types, opts Options, templates []tagTemplate) error {
+	if fresh, err := generatedStructs.Claim(nested.name, nested.path); !fresh {
+		return err
+	}
+	*opts.types = append(*opts.types, typeStart{nested.name, buf.Len()})
+	if nested.t.Kind == yema.Struct {
+		return generateStructs(nested.t, nested.name, nested.path, buf, generatedStructs, opts, templates)
+	}
+	if nested.t.Kind == yema.Union {
+		return generateUnion(nested.t, nested.name, nested.path, buf, generatedStructs, opts, templates)
+	}
+	return writeStringType(buf, nested.name, nested.t, opts)
+}
+
+// fieldTags returns the struct tags of a field, the number is its position in the struct starting at 1
+func fieldTags(fieldName, goFieldName, fieldPath string, number int, fieldType yema.Type, opts Options, templates []tagTemplate) (string, error) {
+	var tags []string
+	for _, tag := range opts.Tags {
+		key := tag.key(fieldName)
+		if tag.Name == "json" && opts.Proto {
+			_, key = protoNames(fieldName)
+		}
+		if !isValidTagName(key) {
+			return "", fmt.Errorf("field name %q cannot be used in a Go %s tag", key, tag.Name)
+		}
+		if fieldType.Optional || opts.ProtoJSON && fieldType.Kind != yema.Struct {
+			key += ",omitempty"
+		}
+		if tag.Name == "json" && opts.ProtoJSON && is64Bit(fieldType.Kind) {
+			key += ",string"
+		}
+		tags = append(tags, fmt.Sprintf("%s:%q", tag.Name, key))
+	}
+	if opts.Proto {
+		_, overridden := opts.overrides[fieldPath]
+		tags = append(tags, fmt.Sprintf("protobuf:%q", protobufTag(fieldName, number, fieldType, overridden)))
+		// Map entries are messages of a key and a value
+		if fieldType.Kind == yema.Map && !overridden {
+			tags = append(tags, `protobuf_key:"bytes,1,opt,name=key,proto3"`,
+				fmt.Sprintf("protobuf_val:\"%s,2,opt,name=value,proto3\"", protobufWire(fieldType.Map, false)))
+		}
+	}
+
+	field := newTagField(fieldName, goFieldName, fieldType)
+	for _, tmpl := range templates {
+		value, err := tmpl.render(field)
+		if err != nil {
+			return "", err
+		}
+		if value != "" {
+			tags = append(tags, fmt.Sprintf("%s:%q", tmpl.name, value))
+		}
+	}
+	return strings.Join(tags, " "), nil
+}
+
+// commentWidth is the width doc comments are wrapped at, not counting indentation
+const commentWidth = 80
+
+// writeComment writes a description as a doc comment wrapped at commentWidth, or fallback if there is none.
+// Paragraphs separated by blank lines are kept apart.
+func writeComment(buf *bytes.Buffer, indent, description, fallback string) {
+	if strings.TrimSpace(description) == "" {
+		if fallback != "" {
+			fmt.Fprintf(buf, "%s// %s\n", indent, fallback)
+		}
+		return
+	}
+
+	for i, paragraph := range strings.Split(strings.TrimSpace(description), "\n\n") {
+		if i > 0 {
+			fmt.Fprintf(buf, "%s//\n", indent)
+		}
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			if line != "" && len("// ")+len(line)+1+len(word) > commentWidth {
+				fmt.Fprintf(buf, "%s// %s\n", indent, line)
+				line = ""
+			}
+			if line != "" {
+				line += " "
+			}
+			line += word
+		}
+		if line != "" {
+			fmt.Fprintf(buf, "%s// %s\n", indent, line)
+		}
+	}
+}
+
+// is64Bit reports whether kind is an integer protojson encodes as a string
+func is64Bit(kind yema.Kind) bool {
+	switch kind {
+	case yema.Int, yema.Int64, yema.Uint, yema.Uint64:
+		return true
+	}
+	return false
+}
+
+// accessComment returns the trailing comment of a field restricted to responses or requests
+func accessComment(t yema.Type) string {
+	switch {
+	case t.ReadOnly:
+		return " // read-only"
+	case t.WriteOnly:
+		return " // write-only"
+	}
+	return ""
+}
+
+// typeToGoType converts a yema.Type to a Go type string, nested structs are named by nestedTypeName,
+// as are strings with a type of their own, see goString. Types overridden for the path are used as they are.
+func typeToGoType(t *yema.Type, parentName, fieldIdent, path string, opts Options) (string, string, error) {
 	var goType string
 	var nestedStructName string
 
+	if o, ok := opts.overrides[path]; ok && path != "" {
+		goType = o.goType
+		if t.Optional && !strings.HasPrefix(goType, "*") && !strings.HasPrefix(goType, "[]") {
+			goType = "*" + goType
+		}
+		return goType, "", nil
+	}
+
 	switch t.Kind {
 	case yema.Bool:
 		goType = "bool"
@@ -145,30 +727,54 @@ func typeToGoType(t *yema.Type, parentName, fieldName string) (string, string, e
 	case yema.Float64:
 		goType = "float64"
 	case yema.String:
-		goType = "string"
+		switch repr := stringRepr(t, opts); {
+		case repr == uuidString:
+			goType = "uuid.UUID"
+		case repr == timeString:
+			goType = "time.Time"
+		case repr.named():
+			nestedStructName = nestedTypeName(t, parentName, fieldIdent, opts)
+			goType = nestedStructName
+		default:
+			goType = "string"
+		}
 	case yema.Bytes:
+		// encoding/json reads and writes []byte in base64 only, as do protojson bytes whatever their format
+		if !opts.ProtoJSON && (t.Format == validator.HexEncoding || t.Format == validator.Base64URLEncoding) {
+			return "", "", fmt.Errorf("bytes in %s at %s have no Go representation, []byte is read from base64", t.Format, fieldpath.Display(path))
+		}
 		goType = "[]byte"
 	case yema.Array:
 		if t.Array == nil {
 			return "", "", fmt.Errorf("array type with nil Array field")
 		}
-		elemType, elemNestedName, err := typeToGoType(t.Array, parentName, fieldName)
+		elemType, elemNestedName, err := typeToGoType(t.Array, parentName, fieldIdent, path+"[]", opts)
 		if err != nil {
 			return "", "", err
 		}
 		goType = "[]" + elemType
 		nestedStructName = elemNestedName
-	case yema.Struct:
-		// Create a name for the nested struct
-		nestedStructName = parentName + toCamelCase(fieldName)
+	case yema.Map:
+		if t.Map == nil {
+			return "", "", fmt.Errorf("map type with nil Map field")
+		}
+		valueType, valueNestedName, err := typeToGoType(t.Map, parentName, fieldIdent, path+"[]", opts)
+		if err != nil {
+			return "", "", err
+		}
+		goType = "map[string]" + valueType
+		nestedStructName = valueNestedName
+	case yema.Struct, yema.Union:
+		// Create a name for the nested struct or union
+		nestedStructName = nestedTypeName(t, parentName, fieldIdent, opts)
 		goType = nestedStructName
 	default:
 		return "", "", fmt.Errorf("unexpected type kind: %v", t.Kind)
 	}
 
 	if t.Optional {
-		// For optional fields (except slices which are already nullable)
-		if t.Kind != yema.Array && t.Kind != yema.Bytes {
+		// For optional fields (except slices and maps which are already nullable)
+		if t.Kind != yema.Array && t.Kind != yema.Bytes && t.Kind != yema.Map {
 			goType = "*" + goType
 		}
 	}
@@ -176,25 +782,18 @@ func typeToGoType(t *yema.Type, parentName, fieldName string) (string, string, e
 	return goType, nestedStructName, nil
 }
 
-// toCamelCase converts a string to CamelCase
-func toCamelCase(s string) string {
-	var result string
-	nextUpper := true
-
-	for _, char := range s {
-		if char == '_' || char == '-' || char == ' ' {
-			nextUpper = true
-			continue
-		}
-
-		if nextUpper {
-			result += string(unicode.ToUpper(char))
-			nextUpper = false
-		} else {
-			result += string(char)
+// isValidTagName reports whether encoding/json accepts name in a struct tag, following its own rules
+func isValidTagName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		switch {
+		case strings.ContainsRune("!#$%&()*+-./:;<=>?@[]^_{|}~ ", c):
+			// Backslash and quote chars are reserved, but otherwise any punctuation chars are allowed
+		case !unicode.IsLetter(c) && !unicode.IsDigit(c):
+			return false
 		}
 	}
-
-	return result
+	return true
 }
-
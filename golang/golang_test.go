@@ -1,9 +1,18 @@
 package golang
 
 import (
+	"go/format"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/aep/yema"
+	"github.com/aep/yema/parser"
 )
 
 func TestToGolang(t *testing.T) {
@@ -45,7 +54,7 @@ func TestToGolang(t *testing.T) {
 	}
 
 	// Generate Go struct
-	result, err := ToGolang(testStruct)
+	result, err := ToGolang(testStruct, Options{})
 	if err != nil {
 		t.Fatalf("Error generating Go struct: %v", err)
 	}
@@ -56,4 +65,1143 @@ func TestToGolang(t *testing.T) {
 	}
 
 	t.Logf("Generated Go struct:\n%s", string(result))
-}
\ No newline at end of file
+}
+func TestToGolangFieldOrder(t *testing.T) {
+	schema, err := parser.FromYAML([]byte("zeta: string\nalpha:\n  second: int\n  first: int\nmid: [{b: bool, a: bool}]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 10; i++ {
+		result, err := ToGolang(schema, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out := string(result)
+		assertOrder(t, out, "Zeta ", "Alpha ", "Mid ", "type RootAlpha", "Second ", "First ", "type RootMid", "B ", "A ")
+	}
+}
+
+// spaces are the runs of spaces gofmt aligns fields and comments with
+var spaces = regexp.MustCompile(` {2,}`)
+
+// unaligned returns generated code with the alignment of gofmt collapsed to single spaces
+func unaligned(s string) string {
+	return spaces.ReplaceAllString(s, " ")
+}
+
+// assertOrder checks that each of the substrings appears in s after the previous one, ignoring alignment
+func assertOrder(t *testing.T, s string, substrings ...string) {
+	t.Helper()
+	s = unaligned(s)
+	pos := 0
+	for _, sub := range substrings {
+		i := strings.Index(s[pos:], sub)
+		if i < 0 {
+			t.Fatalf("%q not found in order in:\n%s", sub, s)
+		}
+		pos += i + len(sub)
+	}
+}
+
+func TestToGolangFieldNames(t *testing.T) {
+	schema, err := parser.FromYAMLWithOptions([]byte("x-request-id: string\napp.kubernetes.io/name?: string\n"), parser.Options{AllowAnyFieldName: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := ToGolang(schema, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := string(result)
+	assertOrder(t, out, "XRequestId string `json:\"x-request-id\"`", "AppKubernetesIoName *string `json:\"app.kubernetes.io/name,omitempty\"`")
+
+	bad := &yema.Type{Kind: yema.Struct, Struct: &map[string]yema.Type{`a"b`: {Kind: yema.String}}}
+	if _, err := ToGolang(bad, Options{}); err == nil {
+		t.Errorf("expected error for field name that cannot be used in a json tag")
+	}
+}
+
+func TestToGolangTransliterate(t *testing.T) {
+	schema, err := parser.FromYAML([]byte("größe: int\n名前:\n  $type: string\n  $codename: name\nadresse:\n  straße: string\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := ToGolang(schema, Options{Transliterate: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := string(result)
+	assertOrder(t, out,
+		"Grosse int `json:\"größe\"`",
+		"Name string `json:\"名前\"`",
+		"Adresse RootAdresse `json:\"adresse\"`",
+		"Strasse string `json:\"straße\"`",
+	)
+
+	collision, err := parser.FromYAML([]byte("größe: int\ngrosse: int\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ToGolang(collision, Options{Transliterate: true}); err == nil {
+		t.Error("expected error for fields mapping to the same Go identifier")
+	}
+}
+
+func TestToGolangTypeNames(t *testing.T) {
+	// The fields fooBar and foo.bar both name a struct RootFooBar
+	schema, err := parser.FromYAML([]byte("fooBar:\n  a: int\nfoo:\n  bar:\n    b: string\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ToGolang(schema, Options{})
+	if err == nil || !strings.Contains(err.Error(), "type name RootFooBar generated for both fooBar and foo.bar") {
+		t.Errorf("expected a collision of RootFooBar, got %v", err)
+	}
+}
+
+func TestToGolangRefs(t *testing.T) {
+	schema, err := parser.FromYAML([]byte(`$defs:
+  address:
+    street: string
+    status: {$ref: "#/$defs/status"}
+  status: {$type: string, $enum: [active, moved]}
+home: {$ref: "#/$defs/address"}
+work?: {$ref: "#/$defs/address"}
+previous: [{$ref: "#/$defs/address"}]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := ToGolang(schema, Options{Enums: true, Validators: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(result)
+	assertOrder(t, out,
+		"type Root struct {",
+		"Home Address `json:\"home\"`",
+		"Work *Address `json:\"work,omitempty\"`",
+		"Previous []Address `json:\"previous\"`",
+		"type Address struct {",
+		"Status Status `json:\"status\"`",
+		"type Status string",
+	)
+	if n := strings.Count(out, "type Address struct {"); n != 1 {
+		t.Errorf("expected a single Address struct, got %d:\n%s", n, out)
+	}
+	if strings.Contains(out, "RootHome") || strings.Contains(out, "RootWork") || strings.Contains(out, "RootPrevious") {
+		t.Errorf("expected uses of $defs to share their type:\n%s", out)
+	}
+	vetGenerated(t, result)
+
+	// Overrides beneath a use would change the shared type for all uses
+	_, err = ToGolang(schema, Options{TypeOverrides: map[string]string{"work.street": "[]byte"}})
+	if err == nil || !strings.Contains(err.Error(), "work.street ($defs.address)") {
+		t.Errorf("expected an override of a shared type to be rejected, got %v", err)
+	}
+	if _, err := ToGolang(schema, Options{TypeOverrides: map[string]string{"work": "[]byte"}}); err != nil {
+		t.Errorf("expected an override of a use to be accepted, got %v", err)
+	}
+
+	// Entries of $defs are named like other types
+	clash, err := parser.FromYAML([]byte("$defs:\n  rootHome: {a: int}\nhome: {b: int}\nother: {$ref: \"#/$defs/rootHome\"}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ToGolang(clash, Options{})
+	if err == nil || !strings.Contains(err.Error(), "type name RootHome generated for both home and $defs.rootHome") {
+		t.Errorf("expected a collision of RootHome, got %v", err)
+	}
+}
+
+func TestToGolangRoots(t *testing.T) {
+	list, err := parser.FromYAML([]byte("- - name: string\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := ToGolang(list, Options{RootType: "User"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertOrder(t, string(result), "type UserList [][]User", "type User struct {", "Name string")
+
+	scalar := &yema.Type{Kind: yema.Int64}
+	result, err = ToGolang(scalar, Options{RootType: "ID"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertOrder(t, string(result), "type ID int64")
+}
+
+func TestToGolangAccess(t *testing.T) {
+	schema, err := parser.FromYAML([]byte("id:\n  $type: string\n  $readonly: true\npassword:\n  $type: string\n  $writeonly: true\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := ToGolang(schema, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := string(result)
+	for _, want := range []string{
+		"Id string `json:\"id\"` // read-only\n",
+		"Password string `json:\"password\"` // write-only\n",
+	} {
+		if !strings.Contains(unaligned(out), want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestToGolangProtoJSON(t *testing.T) {
+	schema, err := parser.FromYAML([]byte("id: int64\ncount: int32\nname?: string\ntags: [uint64]\nowner:\n  name: string\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := ToGolang(schema, Options{ProtoJSON: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := string(result)
+	for _, want := range []string{
+		"Id int64 `json:\"id,omitempty,string\"`",
+		"Count int32 `json:\"count,omitempty\"`",
+		"Name *string `json:\"name,omitempty\"`",
+		"Tags []uint64 `json:\"tags,omitempty\"`",
+		"Owner RootOwner `json:\"owner\"`",
+	} {
+		if !strings.Contains(unaligned(out), want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestToGolangUUID(t *testing.T) {
+	schema, err := parser.FromYAML([]byte("id:\n  $type: string\n  $format: {uuid: [4, 7]}\nparents: [{$type: string, $format: uuid}]\nname: string\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := ToGolang(schema, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertOrder(t, string(result), "import \"github.com/google/uuid\"", "Id uuid.UUID ", "Parents []uuid.UUID ", "Name string ")
+
+	// Schemas without UUIDs import nothing
+	plain, err := ToGolang(&yema.Type{Kind: yema.String}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(plain), "import") {
+		t.Errorf("unexpected import in:\n%s", plain)
+	}
+}
+
+func TestToGolangTags(t *testing.T) {
+	schema, err := parser.FromYAML([]byte("listenAddr: string\nmaxConns?: int64\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		opts Options
+		want []string
+	}{
+		{"default", Options{}, []string{"ListenAddr string `json:\"listenAddr\"`", "MaxConns *int64 `json:\"maxConns,omitempty\"`"}},
+		{"yaml and toml", Options{Tags: []Tag{{Name: "yaml"}, {Name: "toml", Naming: SnakeCase}}}, []string{
+			"ListenAddr string `yaml:\"listenAddr\" toml:\"listen_addr\"`",
+			"MaxConns *int64 `yaml:\"maxConns,omitempty\" toml:\"max_conns,omitempty\"`",
+		}},
+		{"kebab", Options{Tags: []Tag{{Name: "json"}, {Name: "yaml", Naming: KebabCase}}}, []string{
+			"ListenAddr string `json:\"listenAddr\" yaml:\"listen-addr\"`",
+		}},
+		{"protojson only quotes json", Options{ProtoJSON: true, Tags: []Tag{{Name: "json"}, {Name: "yaml"}}}, []string{
+			"MaxConns *int64 `json:\"maxConns,omitempty,string\" yaml:\"maxConns,omitempty\"`",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ToGolang(schema, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(unaligned(string(result)), want) {
+					t.Errorf("missing %q in:\n%s", want, result)
+				}
+			}
+		})
+	}
+}
+
+func TestParseTag(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    Tag
+		wantErr bool
+	}{
+		{"json", Tag{Name: "json"}, false},
+		{"yaml:snake", Tag{Name: "yaml", Naming: SnakeCase}, false},
+		{"toml:camel", Tag{Name: "toml", Naming: CamelCase}, false},
+		{"yaml:upper", Tag{}, true},
+		{"", Tag{}, true},
+		{"my tag", Tag{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseTag(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseTag(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("ParseTag(%q) = %+v, want %+v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestToGolangTagTemplates(t *testing.T) {
+	schema, err := parser.FromYAML([]byte("userName: string\nage?: int\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := ToGolang(schema, Options{TagTemplates: map[string]string{
+		"validate": `{{if .Required}}required{{end}}`,
+		"db":       `{{.Snake}}`,
+		"bson":     `{{.Name}},omitempty`,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := string(result)
+	for _, want := range []string{
+		"UserName string `json:\"userName\" bson:\"userName,omitempty\" db:\"user_name\" validate:\"required\"`",
+		"Age *int `json:\"age,omitempty\" bson:\"age,omitempty\" db:\"age\"`",
+	} {
+		if !strings.Contains(unaligned(out), want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+
+	for _, templates := range []map[string]string{
+		{"validate": "{{.Missing}}"},
+		{"validate": "{{if}}"},
+		{"json": "{{.Name}}"},
+		{"sql": "`{{.Name}}`"},
+	} {
+		if _, err := ToGolang(schema, Options{TagTemplates: templates}); err == nil {
+			t.Errorf("expected error for templates %v", templates)
+		}
+	}
+}
+
+func TestToGolangDeterministic(t *testing.T) {
+	// Schemas built in code have no declaration order, their fields are sorted by name
+	schema := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"zeta": {Kind: yema.String},
+			"beta": {Kind: yema.Struct, Struct: &map[string]yema.Type{
+				"y": {Kind: yema.Int},
+				"x": {Kind: yema.Struct, Struct: &map[string]yema.Type{"deep": {Kind: yema.Bool}}},
+			}},
+			"alpha": {Kind: yema.Array, Array: &yema.Type{Kind: yema.Array, Array: &yema.Type{
+				Kind: yema.Struct, Struct: &map[string]yema.Type{"cell": {Kind: yema.Int}},
+			}}},
+		},
+	}
+
+	first, err := ToGolang(schema, Options{TagTemplates: map[string]string{"db": "{{.Snake}}", "bson": "{{.Name}}"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertOrder(t, string(first), "Alpha [][]RootAlpha ", "Beta RootBeta ", "Zeta ",
+		"type RootAlpha struct", "Cell ", "type RootBeta struct", "X RootBetaX ", "Y ", "type RootBetaX struct", "Deep ")
+
+	for i := 0; i < 20; i++ {
+		result, err := ToGolang(schema, Options{TagTemplates: map[string]string{"db": "{{.Snake}}", "bson": "{{.Name}}"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(result) != string(first) {
+			t.Fatalf("output differs between runs:\n%s\n---\n%s", first, result)
+		}
+	}
+}
+
+func TestToGolangDescriptions(t *testing.T) {
+	schema, err := parser.FromYAML([]byte(`name:
+  $type: string
+  $description: The name of the person as it appears on official documents, used for display and for sorting lists.
+address:
+  $type:
+    street: string
+  $description: |
+    Where the person lives.
+
+    Used for shipping.
+age: int
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := ToGolang(schema, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := string(result)
+	for _, want := range []string{
+		"// Root represents a generated struct\ntype Root struct {",
+		"\t// The name of the person as it appears on official documents, used for display\n\t// and for sorting lists.\n\tName string",
+		"\t// Where the person lives.\n\t//\n\t// Used for shipping.\n\tAddress RootAddress",
+		"\tAge int `json:\"age\"`",
+		"// Where the person lives.\n//\n// Used for shipping.\ntype RootAddress struct {",
+	} {
+		if !strings.Contains(unaligned(out), want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(strings.TrimLeft(line, "\t"), "//") && len(strings.TrimLeft(line, "\t")) > 80 {
+			t.Errorf("comment line longer than 80 columns: %q", line)
+		}
+	}
+}
+
+func TestToGolangValidators(t *testing.T) {
+	schema, err := parser.FromYAML([]byte(`name: string
+color:
+  $type: string
+  $enum: [red, "50%"]
+level?:
+  $type: int8
+  $enum: [1, 2]
+notes?: [string]
+owner:
+  $type: string
+  $format: uuid
+  $enum: [123E4567-E89B-42D3-A456-426614174000]
+items:
+  - sku: string
+    kinds: [{$type: string, $enum: [a, b]}]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := ToGolang(schema, Options{Validators: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(result)
+	assertOrder(t, out,
+		"import (\n\t\"errors\"\n\t\"fmt\"\n\n\t\"github.com/google/uuid\"\n)",
+		"func (t *Root) Validate() error {",
+		"switch t.Color {\n\tcase \"red\", \"50%\":",
+		`errors.New("field 'color' must be one of \"red\", \"50%\"")`,
+		"if v1 := t.Level; v1 != nil {\n\t\tswitch *v1 {\n\t\tcase 1, 2:",
+		"switch t.Owner.String() {\n\tcase \"123e4567-e89b-42d3-a456-426614174000\":",
+		"if t.Items == nil {",
+		"for i2, v3 := range t.Items {",
+		"if v3.Kinds == nil {",
+		`fmt.Errorf("field 'items[%d].kinds[%d]' must be one of \"a\", \"b\"", i2, i4)`,
+		"return errors.Join(errs...)",
+	)
+
+	// Optional lists may be nil and the range of int8 is guaranteed by the Go type
+	for _, unexpected := range []string{"t.Notes", "127", "t.Name"} {
+		if strings.Contains(out, unexpected) {
+			t.Errorf("unexpected %q in:\n%s", unexpected, out)
+		}
+	}
+
+	// Schemas without index paths do not import fmt
+	plain, err := ToGolang(&yema.Type{Kind: yema.Struct, Struct: &map[string]yema.Type{"tags": {Kind: yema.Array, Array: &yema.Type{Kind: yema.String}}}}, Options{Validators: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertOrder(t, string(plain), "import \"errors\"", "errors.New(\"required field 'tags' is missing\")")
+}
+
+func TestToGolangValidatorRules(t *testing.T) {
+	schema, err := parser.FromYAML([]byte(`labels: {$map: string, $keys: "^[a-z]+$"}
+tags: {$type: [string], $unique: true}
+cert?: {$type: string, $requires: [key]}
+key?: string
+token?: {$type: string, $conflicts: [password]}
+password?: string
+checksum: {$type: bytes, $format: base64, $length: {min: 16, max: 64}}
+homepage?: {$type: string, $format: {uri: [https]}}
+servers:
+  - addr: {$type: string, $format: {ipv4: private}}
+    net?: {$type: string, $format: cidr}
+    since: {$type: string, $format: date-time}
+    timeout: {$type: string, $format: duration}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := ToGolang(schema, Options{Validators: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(result)
+	assertOrder(t, out,
+		"func (t *Root) Validate() error {",
+		"if t.Cert != nil && t.Key == nil {",
+		`errors.New("field 'cert' requires field 'key'")`,
+		"if t.Token != nil && t.Password != nil {",
+		"for k1 := range t.Labels {\n\t\tif !keyPatternRoot1.MatchString(k1) {",
+		`fmt.Errorf("key of field 'labels.%s' must match ^[a-z]+$", k1)`,
+		"if reflect.DeepEqual(t.Tags[i2], t.Tags[j3]) {",
+		`fmt.Errorf("field 'tags[%d]' duplicates 'tags[%d]', items must be unique", i2, j3)`,
+		"if len(t.Checksum) < 16 {",
+		`errors.New("field 'checksum' must hold at least 16 bytes")`,
+		"if len(t.Checksum) > 64 {",
+		`if u5, err := url.Parse(*v4); err != nil || u5.Scheme == "" {`,
+		`} else if u5.Scheme != "https" {`,
+		`} else if !a8.Unmap().IsPrivate() {`,
+		`if p10, err := netip.ParsePrefix(*v9); err != nil || p10.Masked() != p10 {`,
+		`if _, err := time.Parse(time.RFC3339, v7.Since); err != nil {`,
+		`if _, err := parseISODuration(v7.Timeout); err != nil {`,
+		"var keyPatternRoot1 = regexp.MustCompile(\"^[a-z]+$\")",
+		"func parseISODuration(s string) (time.Duration, error) {",
+	)
+	vetGenerated(t, result)
+
+	// Durations and timestamps of the time types are checked when they are decoded
+	typed, err := ToGolang(schema, Options{Validators: true, TimeTypes: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(typed), "time.Parse(") || strings.Contains(string(typed), "parseISODuration(v") {
+		t.Errorf("unexpected checks of time types in:\n%s", typed)
+	}
+
+	// Rules generated code cannot check are reported rather than skipped
+	for _, src := range []string{
+		"id: {$type: string, $format: ulid}\n",
+		"net: {$type: string, $format: {cidr: public}}\n",
+		"a: string\nb:\n  $type: string\n  $if: {a: x}\n  $then: int\n",
+		"sum: {$type: bytes, $length: {min: 4, max: 4}}\n",
+	} {
+		schema, err := parser.FromYAML([]byte(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ToGolang(schema, Options{Validators: true}); err == nil || !strings.Contains(err.Error(), "cannot be checked by the generated Validate method") {
+			t.Errorf("expected an error for %q, got %v", src, err)
+		}
+	}
+}
+
+func TestToGolangEncodedBytes(t *testing.T) {
+	schema, err := parser.FromYAML([]byte("sum: {$type: bytes, $format: hex, $length: {min: 4, max: 4}}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// encoding/json reads []byte from base64, which would take the hex deadbeef for 6 bytes
+	if _, err := ToGolang(schema, Options{}); err == nil || !strings.Contains(err.Error(), "bytes in hex at sum have no Go representation") {
+		t.Errorf("expected an error for hex bytes, got %v", err)
+	}
+
+	// protojson writes bytes in base64 whatever their format
+	result, err := ToGolang(schema, Options{ProtoJSON: true, Validators: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertOrder(t, string(result), "Sum []byte", "if len(t.Sum) < 4 {", "if len(t.Sum) > 4 {")
+	vetGenerated(t, result)
+}
+
+func TestToGolangConstructors(t *testing.T) {
+	schema, err := parser.FromYAML([]byte("name: string\ntype: string\nnickname?: string\ntags?: [string]\naddress:\n  street: string\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := ToGolang(schema, Options{Constructors: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertOrder(t, string(result),
+		"type RootOption func(*Root)",
+		// Keywords are not valid parameter names
+		"func NewRoot(name string, type_ string, address RootAddress, opts ...RootOption) *Root {",
+		"\t\tName: name,\n\t\tType: type_,\n\t\tAddress: address,\n\t}",
+		"for _, opt := range opts {",
+		"func WithRootNickname(nickname string) RootOption {",
+		"t.Nickname = &nickname",
+		"func WithRootTags(tags []string) RootOption {",
+		"t.Tags = tags",
+		// Structs without optional fields take no options
+		"func NewRootAddress(street string) *RootAddress {",
+	)
+	if strings.Contains(string(result), "RootAddressOption") {
+		t.Errorf("unexpected options of RootAddress in:\n%s", result)
+	}
+}
+
+func TestToGolangEnums(t *testing.T) {
+	schema, err := parser.FromYAML([]byte(`status: {$type: string, $enum: [active, in-active]}
+roles?: [{$type: string, $enum: [admin, user]}]
+level: {$type: int8, $enum: [1, 2]}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := ToGolang(schema, Options{Enums: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(result)
+	assertOrder(t, out,
+		"import (\n\t\"encoding/json\"\n\t\"fmt\"\n)",
+		"Status RootStatus `json:\"status\"`",
+		"Roles []RootRoles `json:\"roles,omitempty\"`",
+		// Numbers keep their type
+		"Level int8 `json:\"level\"`",
+		"type RootStatus string",
+		"RootStatusActive RootStatus = \"active\"\n\tRootStatusInActive RootStatus = \"in-active\"",
+		"func (v RootStatus) IsValid() bool {\n\tswitch v {\n\tcase RootStatusActive, RootStatusInActive:",
+		"func (v RootStatus) MarshalJSON() ([]byte, error) {",
+		"func (v *RootStatus) UnmarshalJSON(data []byte) error {",
+		"type RootRoles string",
+	)
+
+	// A root enum is the root type itself
+	root, err := ToGolang(&yema.Type{Kind: yema.String, Enum: []interface{}{"a", "b"}}, Options{Enums: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertOrder(t, string(root), "type Root string", "RootA Root = \"a\"")
+
+	// Values must map to distinct constants
+	_, err = ToGolang(&yema.Type{Kind: yema.String, Enum: []interface{}{"a-b", "a_b"}}, Options{Enums: true})
+	if err == nil || !strings.Contains(err.Error(), "both map to the constant RootAB") {
+		t.Errorf("expected an error for colliding constants, got %v", err)
+	}
+}
+
+func TestToGolangTimeTypes(t *testing.T) {
+	schema, err := parser.FromYAML([]byte(`created: {$type: string, $format: date-time}
+birthday?: {$type: string, $format: {date-time: "2006-01-02"}}
+timeout: {$type: string, $format: {duration: go}}
+ttl: {$type: string, $format: duration}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := ToGolang(schema, Options{TimeTypes: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertOrder(t, string(result),
+		"import (\n\t\"fmt\"\n\t\"strconv\"\n\t\"strings\"\n\t\"time\"\n)",
+		"Created time.Time `json:\"created\"`",
+		"Birthday *RootBirthday `json:\"birthday,omitempty\"`",
+		"Timeout RootTimeout `json:\"timeout\"`",
+		"Ttl RootTtl `json:\"ttl\"`",
+		"type RootBirthday time.Time",
+		"time.Time(t).Format(\"2006-01-02\")",
+		"time.Parse(\"2006-01-02\", string(text))",
+		"type RootTimeout time.Duration",
+		"time.ParseDuration(string(text))",
+		"type RootTtl time.Duration",
+		"parseISODuration(string(text))",
+		"func parseISODuration(s string) (time.Duration, error) {",
+		"func formatISODuration(d time.Duration) string {",
+	)
+
+	// Strings are kept by default
+	plain, err := ToGolang(schema, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(plain), "time.") {
+		t.Errorf("unexpected time types in:\n%s", plain)
+	}
+
+	// A root timestamp keeps the methods of time.Time
+	root, err := ToGolang(&yema.Type{Kind: yema.String, Format: "date-time"}, Options{TimeTypes: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertOrder(t, string(root), "import \"time\"", "type Root = time.Time")
+}
+
+func TestToGolangTypeOverrides(t *testing.T) {
+	schema, err := parser.FromYAML([]byte(`price: float64
+discount?: float64
+status: {$type: string, $enum: [a, b]}
+tags: [string]
+address:
+  zip: string
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := ToGolang(schema, Options{Enums: true, Validators: true, TypeOverrides: map[string]string{
+		"price":       "github.com/shopspring/decimal.Decimal",
+		"discount":    "github.com/shopspring/decimal.Decimal",
+		"status":      "string",
+		"tags[]":      "encoding/json.RawMessage",
+		"address.zip": "*gopkg.in/yaml.v3.Node",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(result)
+	assertOrder(t, out,
+		"import (\n\t\"encoding/json\"\n\t\"errors\"\n\n\t\"github.com/shopspring/decimal\"\n\t\"gopkg.in/yaml.v3\"\n)",
+		"Price decimal.Decimal `json:\"price\"`",
+		"Discount *decimal.Decimal `json:\"discount,omitempty\"`",
+		"Status string `json:\"status\"`",
+		"Tags []json.RawMessage `json:\"tags\"`",
+		"Zip *yaml.Node `json:\"zip\"`",
+	)
+	// Overridden enums are neither generated nor checked
+	if strings.Contains(out, "RootStatus") || strings.Contains(out, "t.Status") {
+		t.Errorf("unexpected enum of an overridden field in:\n%s", out)
+	}
+
+	for spec, want := range map[string]string{
+		"github.com/foo/bar/v2.Baz": "bar.Baz",
+		"github.com/mattn/go-x.T":   "x.T",
+		"[]time.Duration":           "[]time.Duration",
+		"int64":                     "int64",
+	} {
+		o, err := parseOverride(spec)
+		if err != nil || o.goType != want {
+			t.Errorf("parseOverride(%q) = %q, %v, want %q", spec, o.goType, err, want)
+		}
+	}
+
+	for _, overrides := range []map[string]string{
+		{"missing": "string"},
+		{"address": "string", "address.zip": "string"},
+		{"price": "decimal."},
+		{"price": "[x]int"},
+	} {
+		if _, err := ToGolang(schema, Options{TypeOverrides: overrides}); err == nil {
+			t.Errorf("expected an error for %v", overrides)
+		}
+	}
+}
+
+func TestToGolangGetters(t *testing.T) {
+	schema, err := parser.FromYAML([]byte("name: string\nlevel?: int8\ntags?: [string]\naddress:\n  zip?: string\nhome?:\n  city: string\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := ToGolang(schema, Options{Getters: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertOrder(t, string(result),
+		"func (t *Root) GetName() (v string) {\n\tif t != nil {\n\t\tv = t.Name\n\t}\n\treturn v\n}",
+		"func (t *Root) GetLevel() (v int8) {\n\tif t != nil && t.Level != nil {\n\t\tv = *t.Level\n\t}",
+		"func (t *Root) GetTags() (v []string) {\n\tif t != nil {\n\t\tv = t.Tags\n\t}",
+		// Nested structs are returned as pointers
+		"func (t *Root) GetAddress() (v *RootAddress) {\n\tif t != nil {\n\t\tv = &t.Address\n\t}",
+		"func (t *Root) GetHome() (v *RootHome) {\n\tif t != nil {\n\t\tv = t.Home\n\t}",
+		"func (t *RootAddress) GetZip() (v string) {",
+		"func (t *RootHome) GetCity() (v string) {",
+	)
+
+	// Getters must not collide with fields
+	collision, err := parser.FromYAML([]byte("name: string\ngetName: string\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ToGolang(collision, Options{Getters: true}); err == nil || !strings.Contains(err.Error(), "getter") {
+		t.Errorf("expected an error for a field named like a getter, got %v", err)
+	}
+}
+
+func TestToGolangClones(t *testing.T) {
+	schema, err := parser.FromYAML([]byte(`name?: string
+server:
+  hosts: [string]
+flat:
+  a: string
+servers?:
+  - port?: int32
+target?:
+  $union: [bytes, int64]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := ToGolang(schema, Options{Clones: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertOrder(t, string(result),
+		"\t\"fmt\"\n\t\"slices\"\n)",
+		"func (t *Root) Clone() *Root {\n\tif t == nil {\n\t\treturn nil\n\t}\n\tc := *t\n",
+		"\tif c.Name != nil {\n\t\tv1 := *c.Name\n\t\tc.Name = &v1\n\t}\n",
+		"\tc.Server = *c.Server.Clone()\n",
+		// Slices of structs are copied item by item, structs without pointers or slices as they are
+		"\tc.Servers = slices.Clone(c.Servers)\n\tfor i2 := range c.Servers {\n\t\tc.Servers[i2] = *c.Servers[i2].Clone()\n\t}\n",
+		"\tc.Target = c.Target.Clone()\n\treturn &c\n}",
+		"func (t *RootServer) Clone() *RootServer {",
+		"\tc.Hosts = slices.Clone(c.Hosts)\n",
+		"func (u *RootTarget) Clone() *RootTarget {",
+		"\tswitch v := u.Value.(type) {\n\tcase RootTargetBytes:\n\t\tv = slices.Clone(v)\n\t\tc.Value = v\n\t}\n",
+	)
+	if strings.Contains(string(result), "c.Flat =") {
+		t.Error("struct without pointers or slices is cloned")
+	}
+
+	collision, err := parser.FromYAML([]byte("clone: string\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ToGolang(collision, Options{Clones: true}); err == nil || !strings.Contains(err.Error(), "field Clone") {
+		t.Errorf("expected an error for a field named Clone, got %v", err)
+	}
+}
+
+func TestToGolangParsers(t *testing.T) {
+	schema, err := parser.FromYAML([]byte("name: string\ntags: [string]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := ToGolang(schema, Options{Parsers: true, Validators: true, DenyUnknownFields: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertOrder(t, string(result),
+		"import (\n\t\"bytes\"\n\t\"encoding/json\"\n\t\"errors\"\n\t\"io\"\n)",
+		"func (t *Root) Validate() error {",
+		"// ParseRoot decodes a Root from JSON, failing for fields the schema does not declare, and validates it\n",
+		"func ParseRoot(data []byte) (*Root, error) {\n\tdec := json.NewDecoder(bytes.NewReader(data))\n\tdec.UseNumber()\n\tdec.DisallowUnknownFields()\n",
+		"\tif _, err := dec.Token(); err != io.EOF {\n",
+		"\tif err := v.Validate(); err != nil {\n\t\treturn nil, err\n\t}\n\treturn &v, nil\n}",
+	)
+
+	// Root arrays are parsed as lists without validation, unknown fields are ignored unless denied
+	list, err := parser.FromYAML([]byte("- name: string\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err = ToGolang(list, Options{Parsers: true, Validators: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertOrder(t, string(result), "func ParseRootList(data []byte) (*RootList, error) {")
+	for _, unwanted := range []string{"DisallowUnknownFields", "v.Validate()"} {
+		if strings.Contains(string(result), unwanted) {
+			t.Errorf("unexpected %s in:\n%s", unwanted, result)
+		}
+	}
+}
+
+func TestToGolangProto(t *testing.T) {
+	schema, err := parser.FromYAML([]byte(`user_id: int64
+displayName?: string
+status: {$type: string, $enum: [active]}
+scores: [float32]
+address?:
+  zip: string
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := ToGolang(schema, Options{Proto: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertOrder(t, unaligned(string(result)),
+		"UserId int64 `json:\"userId,omitempty,string\" protobuf:\"varint,1,opt,name=user_id,json=userId,proto3\"`",
+		"DisplayName *string `json:\"displayName,omitempty\" protobuf:\"bytes,2,opt,name=display_name,json=displayName,proto3,oneof\"`",
+		"Status RootStatus `json:\"status,omitempty\" protobuf:\"bytes,3,opt,name=status,proto3\"`",
+		"Scores []float32 `json:\"scores,omitempty\" protobuf:\"fixed32,4,rep,packed,name=scores,proto3\"`",
+		"Address *RootAddress `json:\"address,omitempty\" protobuf:\"bytes,5,opt,name=address,proto3\"`",
+		"RootStatusActive RootStatus = \"active\"",
+	)
+
+	for yaml, want := range map[string]string{
+		"id:\n  $union: [string, int64]\n": "union at id",
+		"matrix: [[int8]]\n":               "list of lists at matrix",
+	} {
+		schema, err := parser.FromYAML([]byte(yaml))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ToGolang(schema, Options{Proto: true}); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected an error about the %s, got %v", want, err)
+		}
+	}
+	if _, err := ToGolang(schema, Options{Proto: true, TagTemplates: map[string]string{"protobuf": "x"}}); err == nil {
+		t.Error("expected an error for a protobuf tag template")
+	}
+}
+
+func TestToGolangUnions(t *testing.T) {
+	schema, err := parser.FromYAML([]byte(`id:
+  $union: [string, int64]
+target?:
+  $union:
+    - street: string
+    - lat: float64
+    - {$type: string, $format: uuid}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := ToGolang(schema, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertOrder(t, string(result),
+		"import (\n\t\"bytes\"\n\t\"encoding/json\"\n\t\"fmt\"\n\n\t\"github.com/google/uuid\"\n)",
+		"Id RootId `json:\"id\"`",
+		"Target *RootTarget `json:\"target,omitempty\"`",
+		"type RootId struct {\n\t// Value is one of RootIdString or RootIdInt64, nil if unset\n\tValue RootIdVariant\n}",
+		"type RootIdVariant interface {\n\tisRootIdVariant()\n}",
+		"type RootIdString string",
+		"func (RootIdString) isRootIdVariant() {}",
+		"type RootIdInt64 int64",
+		"func (u RootId) MarshalJSON() ([]byte, error) {",
+		"func (u *RootId) UnmarshalJSON(data []byte) error {",
+		// Variants of the same kind are numbered, types of other packages are embedded
+		"func (RootTargetStruct1) isRootTargetVariant() {}",
+		"func (RootTargetStruct2) isRootTargetVariant() {}",
+		"type RootTargetString struct {\n\tuuid.UUID\n}",
+		"var v1 RootTargetStruct1\n\tif err := unmarshalStrict(data, &v1); err == nil {",
+		"type RootTargetStruct1 struct {",
+		"type RootTargetStruct2 struct {",
+		"func unmarshalStrict(data []byte, v interface{}) error {",
+	)
+}
+
+func TestToGolangFormatted(t *testing.T) {
+	schema, err := parser.FromYAML([]byte(`name: string
+level?: int8
+status: {$type: string, $enum: [a, b]}
+ttl: {$type: string, $format: duration}
+id:
+  $union: [string, int64]
+address:
+  zip?: string
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := ToGolang(schema, Options{Constructors: true, Getters: true, Enums: true, TimeTypes: true, Validators: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	formatted, err := format.Source(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(formatted) != string(result) {
+		t.Errorf("output is not formatted:\n%s", result)
+	}
+}
+
+func TestToGolangFiles(t *testing.T) {
+	schema, err := parser.FromYAML([]byte(`id: {$type: string, $format: uuid}
+status: {$type: string, $enum: [active, inactive]}
+linux:
+  kernel: string
+  tags?: [string]
+target?:
+  $union:
+    - street: string
+    - name: string
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := Options{Enums: true, Validators: true}
+	files, err := ToGolangFiles(schema, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	want := []string{"root.go", "rootlinux.go", "rootstatus.go", "roottarget.go", "roottargetstruct1.go", "roottargetstruct2.go"}
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("files %v, want %v", names, want)
+	}
+
+	// Every file imports what it uses, the root file holds validators and helpers
+	assertOrder(t, string(files["root.go"]),
+		"package generated\n\nimport (\n\t\"bytes\"\n\t\"encoding/json\"\n\t\"errors\"\n\n\t\"github.com/google/uuid\"\n)",
+		"type Root struct {",
+		"func (t *Root) Validate() error {",
+		"func unmarshalStrict(data []byte, v interface{}) error {",
+	)
+	assertOrder(t, string(files["rootstatus.go"]),
+		"package generated\n\nimport (\n\t\"encoding/json\"\n\t\"fmt\"\n)",
+		"type RootStatus string",
+	)
+	assertOrder(t, string(files["roottarget.go"]),
+		"import (\n\t\"encoding/json\"\n\t\"fmt\"\n)",
+		"type RootTarget struct {",
+		"func (RootTargetStruct1) isRootTargetVariant() {}",
+		"func (u *RootTarget) UnmarshalJSON(data []byte) error {",
+	)
+	if linux := string(files["rootlinux.go"]); strings.Contains(linux, "import") || !strings.Contains(linux, "type RootLinux struct {") {
+		t.Errorf("unexpected rootlinux.go:\n%s", linux)
+	}
+
+	if !strings.Contains(string(files["roottargetstruct2.go"]), "type RootTargetStruct2 struct {\n\tName string") {
+		t.Errorf("unexpected roottargetstruct2.go:\n%s", files["roottargetstruct2.go"])
+	}
+
+	schema, err = parser.FromYAML([]byte("ab: {x: string}\na_b: {y: string}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ToGolangFiles(schema, Options{}); err == nil || !strings.Contains(err.Error(), "both map to the file rootab.go") {
+		t.Errorf("expected a file collision, got %v", err)
+	}
+}
+
+// vetGenerated runs go vet on the generated code of a package without dependencies
+func vetGenerated(t *testing.T, code []byte) {
+	t.Helper()
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go is not installed")
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module generated\n\ngo 1.24\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "generated.go"), code, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(goBin, "vet", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOWORK=off", "GOFLAGS=-mod=mod")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("go vet: %v\n%s\n%s", err, out, code)
+	}
+}
+
+func TestValidatorsProtoJSONCompile(t *testing.T) {
+	schema, err := parser.FromYAML([]byte(`status: {$type: string, $enum: [active, inactive]}
+size: {$type: int32, $enum: [1, 2]}
+note?: {$type: string, $enum: [a, b]}
+tags: [{$type: string, $enum: [x, y]}]
+owner:
+  role: {$type: string, $enum: [admin, user]}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, opts := range map[string]Options{
+		"protojson":       {ProtoJSON: true, Validators: true},
+		"protojson enums": {ProtoJSON: true, Validators: true, Enums: true},
+		"proto":           {Proto: true, Validators: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			code, err := ToGolang(schema, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			vetGenerated(t, code)
+		})
+	}
+}
+
+func TestToGolangMaps(t *testing.T) {
+	schema, err := parser.FromYAML([]byte(`labels: {$map: string}
+hosts?:
+  $map:
+    addr: string
+    port?: int32
+    tags?: [string]
+levels: {$map: {$type: string, $enum: [low, high]}}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := ToGolang(schema, Options{Clones: true, Validators: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(result)
+	for _, want := range []string{
+		"Labels map[string]string `json:\"labels\"`",
+		"Hosts map[string]RootHosts `json:\"hosts,omitempty\"`",
+		"Levels map[string]string `json:\"levels\"`",
+		"type RootHosts struct {",
+		"\"maps\"",
+		"c.Hosts = maps.Clone(c.Hosts)\n\tfor k1, v1 := range c.Hosts {\n\t\tv1 = *v1.Clone()\n\t\tc.Hosts[k1] = v1\n\t}",
+		"if t.Labels == nil {",
+		"errs = append(errs, fmt.Errorf(\"field 'levels.%s' must be one of",
+	} {
+		if !strings.Contains(unaligned(out), want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	vetGenerated(t, result)
+
+	// The struct values of a root map are named like a field Value
+	root, err := parser.FromYAML([]byte("$map: {name: string}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err = ToGolang(root, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"type Root map[string]RootValue", "type RootValue struct {"} {
+		if !strings.Contains(string(result), want) {
+			t.Errorf("missing %q in:\n%s", want, result)
+		}
+	}
+
+	// Maps are map fields of entries in protobuf
+	result, err = ToGolang(schema, Options{Proto: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "protobuf:\"bytes,1,rep,name=labels,proto3\" protobuf_key:\"bytes,1,opt,name=key,proto3\" protobuf_val:\"bytes,2,opt,name=value,proto3\""
+	if !strings.Contains(string(result), want) {
+		t.Errorf("missing %q in:\n%s", want, result)
+	}
+	vetGenerated(t, result)
+}
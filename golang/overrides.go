@@ -0,0 +1,145 @@
+package golang
+
+import (
+	"fmt"
+	"go/token"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/aep/yema"
+)
+
+// override is a Go type replacing the one generated for a path of the schema, see Options.TypeOverrides
+type override struct {
+	// goType is the type as written in generated code, such as *decimal.Decimal
+	goType string
+	// importPath is the package the type is declared in, empty for predeclared types
+	importPath string
+}
+
+// packageName returns the name the type is qualified with, empty for predeclared types
+func (o override) packageName() string {
+	name, _, ok := strings.Cut(strings.TrimLeft(o.goType, "*[]"), ".")
+	if !ok {
+		return ""
+	}
+	return name
+}
+
+// majorVersion matches the last element of import paths of major versions such as v2,
+// versionSuffix the suffix of gopkg.in paths such as yaml.v3
+var (
+	majorVersion  = regexp.MustCompile(`^v[0-9]+$`)
+	versionSuffix = regexp.MustCompile(`\.v[0-9]+$`)
+)
+
+// parseOverride parses a Go type qualified by the import path of its package, such as
+// github.com/shopspring/decimal.Decimal, optionally preceded by * or [] as in *time.Location
+func parseOverride(spec string) (override, error) {
+	name := strings.TrimLeft(spec, "*[]")
+	prefix := spec[:len(spec)-len(name)]
+	if strings.ReplaceAll(strings.ReplaceAll(prefix, "[]", ""), "*", "") != "" {
+		return override{}, fmt.Errorf("invalid Go type %q", spec)
+	}
+
+	i := strings.LastIndex(name, ".")
+	if i < 0 {
+		if !token.IsIdentifier(name) {
+			return override{}, fmt.Errorf("invalid Go type %q", spec)
+		}
+		return override{goType: spec}, nil
+	}
+
+	importPath, typeName := name[:i], name[i+1:]
+	if importPath == "" || !token.IsIdentifier(typeName) {
+		return override{}, fmt.Errorf("invalid Go type %q, expected the import path of its package followed by a dot and its name", spec)
+	}
+	// The package is named after the last element of its path that is not a major version, as goimports assumes
+	elems := strings.Split(importPath, "/")
+	pkg := elems[len(elems)-1]
+	if len(elems) > 1 && majorVersion.MatchString(pkg) {
+		pkg = elems[len(elems)-2]
+	}
+	pkg = strings.TrimPrefix(versionSuffix.ReplaceAllString(pkg, ""), "go-")
+	if !token.IsIdentifier(pkg) {
+		return override{}, fmt.Errorf("cannot derive the package name of the Go type %q", spec)
+	}
+	return override{goType: prefix + pkg + "." + typeName, importPath: importPath}, nil
+}
+
+// parseOverrides parses the type overrides of the options, each path must name a field of the root struct t
+// or the items of one. Paths beneath another override are reported as well, as their type is never generated,
+// and so are paths beneath a use of $defs, as its type is shared by all uses.
+func parseOverrides(t *yema.Type, specs map[string]string) (map[string]override, error) {
+	if len(specs) == 0 {
+		return nil, nil
+	}
+
+	overrides := make(map[string]override, len(specs))
+	for path, spec := range specs {
+		o, err := parseOverride(spec)
+		if err != nil {
+			return nil, fmt.Errorf("type override of %s: %w", path, err)
+		}
+		overrides[path] = o
+	}
+
+	// matched maps the paths of overrides to the entry of $defs they are beneath, empty if none
+	matched := make(map[string]string, len(overrides))
+	matchOverrides(t, "", "", overrides, matched)
+
+	var unmatched, shared []string
+	for path := range overrides {
+		ref, ok := matched[path]
+		switch {
+		case !ok:
+			unmatched = append(unmatched, path)
+		case ref != "":
+			shared = append(shared, fmt.Sprintf("%s (%s)", path, defsPath(ref)))
+		}
+	}
+	if len(shared) > 0 {
+		sort.Strings(shared)
+		return nil, fmt.Errorf("type overrides of %s apply to a type of $defs shared by all its uses, override the field holding it instead", strings.Join(shared, ", "))
+	}
+	if len(unmatched) > 0 {
+		sort.Strings(unmatched)
+		return nil, fmt.Errorf("type overrides of %s match no field, paths look like address.zip or tags[]", strings.Join(unmatched, ", "))
+	}
+	return overrides, nil
+}
+
+// matchOverrides marks the overrides of paths in t with the entry of $defs ref they are beneath,
+// without descending into the types they replace
+func matchOverrides(t *yema.Type, path, ref string, overrides map[string]override, matched map[string]string) {
+	if path != "" {
+		if _, ok := overrides[path]; ok {
+			matched[path] = ref
+			return
+		}
+		// The root type is generated on its own even if it was expanded from $defs
+		if t.Ref != "" {
+			ref = t.Ref
+		}
+	}
+
+	switch {
+	case t.Kind == yema.Array && t.Array != nil && path != "":
+		matchOverrides(t.Array, path+"[]", ref, overrides, matched)
+	case t.Kind == yema.Map && t.Map != nil && path != "":
+		matchOverrides(t.Map, path+"[]", ref, overrides, matched)
+	case t.Kind == yema.Union:
+		for i := range t.Union {
+			matchOverrides(&t.Union[i], path, ref, overrides, matched)
+		}
+	case t.Kind == yema.Struct && t.Struct != nil:
+		for name, field := range *t.Struct {
+			fieldPath := name
+			if path != "" {
+				fieldPath = path + "." + name
+			}
+			matchOverrides(&field, fieldPath, ref, overrides, matched)
+		}
+	}
+}
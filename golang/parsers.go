@@ -0,0 +1,34 @@
+package golang
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// parserImports are the imports of the functions written by writeParser
+var parserImports = []string{"bytes", "encoding/json", "errors", "io"}
+
+// writeParser writes ParseType decoding a single JSON value into the root type, see Options.Parsers.
+// Numbers decoded into interfaces, as by overridden types, are kept as json.Number so large integers stay exact.
+func writeParser(buf *bytes.Buffer, typeName string, validate bool, opts Options) {
+	summary := "decodes a " + typeName + " from JSON"
+	if opts.DenyUnknownFields {
+		summary += ", failing for fields the schema does not declare"
+	}
+	if validate {
+		summary += ", and validates it"
+	}
+	fmt.Fprintf(buf, "// Parse%s %s\n", typeName, summary)
+	fmt.Fprintf(buf, "func Parse%s(data []byte) (*%s, error) {\n", typeName, typeName)
+	buf.WriteString("\tdec := json.NewDecoder(bytes.NewReader(data))\n\tdec.UseNumber()\n")
+	if opts.DenyUnknownFields {
+		buf.WriteString("\tdec.DisallowUnknownFields()\n")
+	}
+	fmt.Fprintf(buf, "\tvar v %s\n", typeName)
+	buf.WriteString("\tif err := dec.Decode(&v); err != nil {\n\t\treturn nil, err\n\t}\n")
+	buf.WriteString("\tif _, err := dec.Token(); err != io.EOF {\n\t\treturn nil, errors.New(\"unexpected data after the JSON value\")\n\t}\n")
+	if validate {
+		buf.WriteString("\tif err := v.Validate(); err != nil {\n\t\treturn nil, err\n\t}\n")
+	}
+	buf.WriteString("\treturn &v, nil\n}\n\n")
+}
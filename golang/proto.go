@@ -0,0 +1,97 @@
+package golang
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aep/yema"
+	"github.com/aep/yema/internal/fieldpath"
+	"github.com/aep/yema/internal/ident"
+)
+
+// collectionNames name the kinds of collections in errors
+var collectionNames = map[yema.Kind]string{yema.Array: "list", yema.Map: "map"}
+
+// checkProto reports the types of t at path that have no protobuf representation, see Options.Proto
+func checkProto(t *yema.Type, path string, opts Options) error {
+	if _, ok := opts.overrides[path]; ok && path != "" {
+		return nil
+	}
+	switch t.Kind {
+	case yema.Union:
+		return fmt.Errorf("union at %s has no protobuf representation, the variants of a oneof are named fields", fieldpath.Display(path))
+	case yema.Array:
+		if t.Array.Kind == yema.Array || t.Array.Kind == yema.Map {
+			return fmt.Errorf("list of %ss at %s has no protobuf representation", collectionNames[t.Array.Kind], fieldpath.Display(path))
+		}
+		return checkProto(t.Array, path+"[]", opts)
+	case yema.Map:
+		if t.Map.Kind == yema.Array || t.Map.Kind == yema.Map {
+			return fmt.Errorf("map of %ss at %s has no protobuf representation", collectionNames[t.Map.Kind], fieldpath.Display(path))
+		}
+		return checkProto(t.Map, path+"[]", opts)
+	case yema.Struct:
+		for _, name := range t.FieldNames() {
+			fieldPath := name
+			if path != "" {
+				fieldPath = path + "." + name
+			}
+			field := (*t.Struct)[name]
+			if err := checkProto(&field, fieldPath, opts); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// protoNames returns the name of a field in protobuf, in snake_case, and its json_name in lowerCamelCase
+func protoNames(fieldName string) (string, string) {
+	name := ident.Snake(fieldName)
+	return name, Tag{Naming: CamelCase}.key(name)
+}
+
+// protobufTag returns the protobuf struct tag of a field numbered number, as protoc-gen-go writes it
+func protobufTag(fieldName string, number int, t yema.Type, overridden bool) string {
+	elem, label := &t, "opt"
+	switch t.Kind {
+	case yema.Array:
+		elem, label = t.Array, "rep"
+	case yema.Map:
+		label = "rep"
+	}
+
+	wire := protobufWire(elem, overridden)
+	parts := []string{wire, strconv.Itoa(number), label}
+	// Repeated scalars are packed in proto3
+	if label == "rep" && wire != "bytes" {
+		parts = append(parts, "packed")
+	}
+	name, jsonName := protoNames(fieldName)
+	parts = append(parts, "name="+name)
+	if jsonName != name {
+		parts = append(parts, "json="+jsonName)
+	}
+	parts = append(parts, "proto3")
+	// Optional fields other than messages, lists and maps have presence through a synthetic oneof
+	if t.Optional && t.Kind != yema.Struct && t.Kind != yema.Array && t.Kind != yema.Map {
+		parts = append(parts, "oneof")
+	}
+	return strings.Join(parts, ",")
+}
+
+// protobufWire returns the wire type of values of t in a protobuf tag, messages, maps and overridden types
+// are length-delimited bytes
+func protobufWire(t *yema.Type, overridden bool) string {
+	switch {
+	case overridden:
+	case t.Kind == yema.Bool || t.Kind >= yema.Int && t.Kind <= yema.Uint64:
+		return "varint"
+	case t.Kind == yema.Float32:
+		return "fixed32"
+	case t.Kind == yema.Float64:
+		return "fixed64"
+	}
+	return "bytes"
+}
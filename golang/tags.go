@@ -0,0 +1,160 @@
+package golang
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/aep/yema"
+	"github.com/aep/yema/internal/ident"
+)
+
+// Tag is a struct tag written on every generated field, such as json, yaml or toml
+type Tag struct {
+	// Name of the tag
+	Name string
+	// Naming derives the key of a field in the tag from its name in the schema, KeepNames if empty
+	Naming Naming
+}
+
+// Naming is a strategy to derive the keys of fields in a struct tag from their names in the schema
+type Naming string
+
+// Naming strategies of struct tags
+const (
+	// KeepNames uses the names of the schema as they are, so documents valid for the schema decode
+	KeepNames Naming = ""
+	// SnakeCase converts names to snake_case
+	SnakeCase Naming = "snake"
+	// CamelCase converts names to camelCase
+	CamelCase Naming = "camel"
+	// KebabCase converts names to kebab-case
+	KebabCase Naming = "kebab"
+)
+
+// defaultTags are the tags of generated fields if the options list none
+var defaultTags = []Tag{{Name: "json"}}
+
+// ParseTag parses a tag given as its name, optionally followed by a colon and a naming strategy, such as yaml:snake
+func ParseTag(s string) (Tag, error) {
+	name, naming, _ := strings.Cut(s, ":")
+	tag := Tag{Name: name, Naming: Naming(naming)}
+	if err := tag.check(); err != nil {
+		return Tag{}, err
+	}
+	return tag, nil
+}
+
+// check reports a tag that cannot be written or has an unknown naming strategy
+func (t Tag) check() error {
+	if t.Name == "" {
+		return fmt.Errorf("tag has no name")
+	}
+	// The name of a tag is any non-control character except space, quote and colon, see reflect.StructTag
+	for _, c := range t.Name {
+		if c <= ' ' || c == '"' || c == ':' || c == 0x7f || c == '`' {
+			return fmt.Errorf("invalid tag name %q", t.Name)
+		}
+	}
+	switch t.Naming {
+	case KeepNames, SnakeCase, CamelCase, KebabCase:
+		return nil
+	}
+	return fmt.Errorf("tag %s has an unknown naming strategy %q, expected snake, camel or kebab", t.Name, t.Naming)
+}
+
+// key returns the key of a field in the tag
+func (t Tag) key(name string) string {
+	switch t.Naming {
+	case SnakeCase:
+		return ident.Snake(name)
+	case CamelCase:
+		camel := ident.Camel(name)
+		first, size := utf8.DecodeRuneInString(camel)
+		return string(unicode.ToLower(first)) + camel[size:]
+	case KebabCase:
+		return strings.ReplaceAll(ident.Snake(name), "_", "-")
+	}
+	return name
+}
+
+// TagField is the data the templates of extra struct tags are executed with, see Options.TagTemplates
+type TagField struct {
+	// Name is the name of the field in the schema
+	Name string
+	// Ident is the identifier of the field in Go
+	Ident string
+	// Snake, Camel and Kebab are the name of the field in the naming strategies of the same name
+	Snake, Camel, Kebab string
+	// Required is set unless the field is optional
+	Required bool
+	// Type is the kind of the field in the schema, such as string or int64
+	Type string
+	// Format is the format the field declares, if any
+	Format string
+}
+
+// newTagField returns the data of the tag templates for a field
+func newTagField(name, goName string, t yema.Type) TagField {
+	return TagField{
+		Name:     name,
+		Ident:    goName,
+		Snake:    Tag{Naming: SnakeCase}.key(name),
+		Camel:    Tag{Naming: CamelCase}.key(name),
+		Kebab:    Tag{Naming: KebabCase}.key(name),
+		Required: !t.Optional,
+		Type:     t.Kind.String(),
+		Format:   t.Format,
+	}
+}
+
+// tagTemplate is a parsed template of an extra struct tag
+type tagTemplate struct {
+	name string
+	tmpl *template.Template
+}
+
+// parseTagTemplates parses the templates of extra struct tags, ordered by tag name so the output is stable.
+// The tags must differ from those derived from the field names.
+func parseTagTemplates(templates map[string]string, tags []Tag) ([]tagTemplate, error) {
+	names := make([]string, 0, len(templates))
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parsed := make([]tagTemplate, 0, len(names))
+	for _, name := range names {
+		if err := (Tag{Name: name}).check(); err != nil {
+			return nil, err
+		}
+		for _, tag := range tags {
+			if tag.Name == name {
+				return nil, fmt.Errorf("tag %s has a template and is derived from field names", name)
+			}
+		}
+		tmpl, err := template.New(name).Parse(templates[name])
+		if err != nil {
+			return nil, fmt.Errorf("template of tag %s: %w", name, err)
+		}
+		parsed = append(parsed, tagTemplate{name: name, tmpl: tmpl})
+	}
+	return parsed, nil
+}
+
+// render executes the template for a field, the result must fit in a struct tag
+func (t tagTemplate) render(field TagField) (string, error) {
+	var b strings.Builder
+	if err := t.tmpl.Execute(&b, field); err != nil {
+		return "", fmt.Errorf("template of tag %s: %w", t.name, err)
+	}
+	value := strings.TrimSpace(b.String())
+	// Tags are written in a raw string literal, any other character is quoted
+	if strings.ContainsRune(value, '`') {
+		return "", fmt.Errorf("tag %s of field %s holds a backquote: %s", t.name, field.Name, value)
+	}
+	return value, nil
+}
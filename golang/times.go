@@ -0,0 +1,209 @@
+package golang
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aep/yema"
+	"github.com/aep/yema/validator"
+)
+
+// goString is the representation of a string of the schema in Go
+type goString int
+
+const (
+	// plainString is a string
+	plainString goString = iota
+	// uuidString is a uuid.UUID
+	uuidString
+	// enumString is a type of its own with a constant for every value, see Options.Enums
+	enumString
+	// timeString is a time.Time, whose JSON encoding is RFC 3339
+	timeString
+	// layoutString is a type of its own holding a time.Time in the layout of its date-time format
+	layoutString
+	// goDurationString is a type of its own holding a time.Duration written like 1h30m
+	goDurationString
+	// isoDurationString is a type of its own holding a time.Duration written in ISO 8601, such as PT1H30M
+	isoDurationString
+)
+
+// stringRepr returns the representation of a string in Go
+func stringRepr(t *yema.Type, opts Options) goString {
+	switch {
+	case t.Kind != yema.String:
+		return plainString
+	case t.Format == validator.UUIDFormat:
+		return uuidString
+	case opts.Enums && isStringEnum(t):
+		return enumString
+	case opts.TimeTypes && t.Format == validator.DateTimeFormat:
+		if t.FormatArg == nil || t.FormatArg == time.RFC3339 {
+			return timeString
+		}
+		return layoutString
+	case opts.TimeTypes && t.Format == validator.DurationFormat:
+		if t.FormatArg == validator.GoDuration {
+			return goDurationString
+		}
+		return isoDurationString
+	}
+	return plainString
+}
+
+// named reports whether strings of the representation have a type of their own, named like nested structs
+func (r goString) named() bool {
+	switch r {
+	case enumString, layoutString, goDurationString, isoDurationString:
+		return true
+	}
+	return false
+}
+
+// writeStringType writes the type of its own of a string
+func writeStringType(buf *bytes.Buffer, name string, t *yema.Type, opts Options) error {
+	repr := stringRepr(t, opts)
+	if repr == enumString {
+		return writeEnum(buf, name, t, opts.Transliterate)
+	}
+	writeTimeType(buf, name, t, repr)
+	return nil
+}
+
+// stringReprs returns the representations of all strings in t at path, leaving out overridden types
+func stringReprs(t *yema.Type, path string, opts Options, reprs map[goString]bool) map[goString]bool {
+	if _, ok := opts.overrides[path]; ok && path != "" {
+		return reprs
+	}
+	switch {
+	case t.Kind == yema.String:
+		reprs[stringRepr(t, opts)] = true
+	case t.Array != nil:
+		stringReprs(t.Array, path+"[]", opts, reprs)
+	case t.Map != nil:
+		stringReprs(t.Map, path+"[]", opts, reprs)
+	case t.Kind == yema.Union:
+		// Variants share the path of their union
+		for i := range t.Union {
+			stringReprs(&t.Union[i], path, opts, reprs)
+		}
+	case t.Struct != nil:
+		for name, field := range *t.Struct {
+			fieldPath := name
+			if path != "" {
+				fieldPath = path + "." + name
+			}
+			stringReprs(&field, fieldPath, opts, reprs)
+		}
+	}
+	return reprs
+}
+
+// writeTimeType writes the type of a timestamp with a layout other than RFC 3339 or of a duration, as represented by repr.
+// The types implement encoding.TextMarshaler and encoding.TextUnmarshaler, used by json and most other encodings.
+func writeTimeType(buf *bytes.Buffer, name string, t *yema.Type, repr goString) {
+	if repr == layoutString {
+		layout := strconv.Quote(t.FormatArg.(string))
+		writeComment(buf, "", t.Description, fmt.Sprintf("%s is a timestamp in the layout %s", name, t.FormatArg))
+		fmt.Fprintf(buf, "type %s time.Time\n\n", name)
+
+		fmt.Fprintf(buf, "// MarshalText formats the timestamp in its layout\n")
+		fmt.Fprintf(buf, "func (t %s) MarshalText() ([]byte, error) {\n", name)
+		fmt.Fprintf(buf, "\treturn []byte(time.Time(t).Format(%s)), nil\n}\n\n", layout)
+
+		fmt.Fprintf(buf, "// UnmarshalText parses a timestamp in its layout\n")
+		fmt.Fprintf(buf, "func (t *%s) UnmarshalText(text []byte) error {\n", name)
+		fmt.Fprintf(buf, "\tv, err := time.Parse(%s, string(text))\n", layout)
+		fmt.Fprintf(buf, "\tif err != nil {\n\t\treturn err\n\t}\n\t*t = %s(v)\n\treturn nil\n}\n\n", name)
+		return
+	}
+
+	format, parse, example := "time.Duration(d).String()", "time.ParseDuration", "1h30m"
+	if repr == isoDurationString {
+		format, parse, example = "formatISODuration(time.Duration(d))", "parseISODuration", "PT1H30M"
+	}
+	writeComment(buf, "", t.Description, fmt.Sprintf("%s is a duration written like %s", name, example))
+	fmt.Fprintf(buf, "type %s time.Duration\n\n", name)
+
+	fmt.Fprintf(buf, "// MarshalText formats the duration like %s\n", example)
+	fmt.Fprintf(buf, "func (d %s) MarshalText() ([]byte, error) {\n", name)
+	if repr == isoDurationString {
+		buf.WriteString("\tif d < 0 {\n\t\treturn nil, fmt.Errorf(\"negative duration %v cannot be written in ISO 8601\", time.Duration(d))\n\t}\n")
+	}
+	fmt.Fprintf(buf, "\treturn []byte(%s), nil\n}\n\n", format)
+
+	fmt.Fprintf(buf, "// UnmarshalText parses a duration like %s\n", example)
+	fmt.Fprintf(buf, "func (d *%s) UnmarshalText(text []byte) error {\n", name)
+	fmt.Fprintf(buf, "\tv, err := %s(string(text))\n", parse)
+	fmt.Fprintf(buf, "\tif err != nil {\n\t\treturn err\n\t}\n\t*d = %s(v)\n\treturn nil\n}\n\n", name)
+}
+
+// isoDurationImports are the imports of isoDurationHelpers
+var isoDurationImports = []string{"fmt", "strconv", "strings", "time"}
+
+// isoDurationHelpers parse and format ISO 8601 durations in generated code, accepting the same durations
+// as the duration format of package validator
+const isoDurationHelpers = `// parseISODuration parses an ISO 8601 duration such as P1DT2H30M, days and weeks are 24 hours and 7 days long
+func parseISODuration(s string) (time.Duration, error) {
+	rest, ok := strings.CutPrefix(s, "P")
+	if !ok || rest == "" || strings.HasSuffix(rest, "T") {
+		return 0, fmt.Errorf("invalid ISO 8601 duration %q", s)
+	}
+
+	const units = "WDTHMS"
+	sizes := [...]time.Duration{7 * 24 * time.Hour, 24 * time.Hour, 0, time.Hour, time.Minute, time.Second}
+	var d time.Duration
+	// next is the index in units of the first unit that may still follow
+	next := 0
+	for rest != "" {
+		if rest[0] == 'T' {
+			if next > 2 {
+				return 0, fmt.Errorf("invalid ISO 8601 duration %q", s)
+			}
+			next, rest = 3, rest[1:]
+			continue
+		}
+
+		end := strings.IndexAny(rest, units)
+		if end <= 0 || strings.Trim(rest[:end], "0123456789.") != "" {
+			return 0, fmt.Errorf("invalid ISO 8601 duration %q", s)
+		}
+		unit := strings.IndexByte(units[next:], rest[end]) + next
+		// Hours, minutes and seconds follow the T, years and months are not supported
+		if unit < next || unit == 2 || next < 3 && unit > 2 || unit != 5 && strings.Contains(rest[:end], ".") {
+			return 0, fmt.Errorf("invalid ISO 8601 duration %q", s)
+		}
+		n, err := strconv.ParseFloat(rest[:end], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid ISO 8601 duration %q", s)
+		}
+		d += time.Duration(n * float64(sizes[unit]))
+		next, rest = unit+1, rest[end+1:]
+	}
+	return d, nil
+}
+
+// formatISODuration formats a duration that is not negative in ISO 8601, such as PT1H30M
+func formatISODuration(d time.Duration) string {
+	if d == 0 {
+		return "PT0S"
+	}
+	var b strings.Builder
+	b.WriteString("PT")
+	if h := d / time.Hour; h > 0 {
+		fmt.Fprintf(&b, "%dH", h)
+		d -= h * time.Hour
+	}
+	if m := d / time.Minute; m > 0 {
+		fmt.Fprintf(&b, "%dM", m)
+		d -= m * time.Minute
+	}
+	if d > 0 {
+		b.WriteString(strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "S")
+	}
+	return b.String()
+}
+
+`
@@ -0,0 +1,127 @@
+package golang
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/aep/yema"
+	"github.com/aep/yema/internal/ident"
+)
+
+// unionImports are the imports of the JSON methods of unions and unmarshalStrictHelper
+var unionImports = []string{"bytes", "encoding/json", "fmt"}
+
+// unmarshalStrictHelper decodes the variants of unions in generated code
+const unmarshalStrictHelper = `// unmarshalStrict decodes JSON into v, failing for fields v does not declare
+func unmarshalStrict(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+`
+
+// generateUnion generates a union as a struct holding a sealed interface, implemented by a type for every variant.
+// The variants are named after the union and their kind, numbered by position if several have the same kind.
+// Unions have no discriminator, so JSON is decoded into the first variant it matches in the order of the schema.
+func generateUnion(t *yema.Type, name, path string, buf *bytes.Buffer, generatedStructs ident.Types, opts Options, templates []tagTemplate) error {
+	kinds := make(map[yema.Kind]int, len(t.Union))
+	for _, variant := range t.Union {
+		kinds[variant.Kind]++
+	}
+
+	iface := name + "Variant"
+	names := make([]string, len(t.Union))
+	var defs bytes.Buffer
+	var nested []nestedType
+	for i := range t.Union {
+		variant := t.Union[i]
+		variant.Optional = false
+		names[i] = name + ident.Camel(variant.Kind.String())
+		if kinds[variant.Kind] > 1 {
+			names[i] += fmt.Sprint(i + 1)
+		}
+
+		// Structs and strings with a type of their own are the variant, others are defined on their Go type
+		if variant.Kind == yema.Struct || variant.Kind == yema.Union || stringRepr(&variant, opts).named() {
+			nested = append(nested, nestedType{names[i], path, &variant})
+		} else {
+			goType, nestedName, err := typeToGoType(&variant, names[i], "Item", path, opts)
+			if err != nil {
+				return err
+			}
+			if nestedName != "" {
+				elem, _ := nestedElem(&variant, path)
+				nested = append(nested, nestedType{nestedName, path, elem})
+			}
+			fmt.Fprintf(&defs, "// %s is a variant of %s\n", names[i], name)
+			// Types of other packages are embedded to keep their methods, such as those encoding JSON
+			if strings.Contains(goType, ".") && !strings.HasPrefix(goType, "[]") {
+				fmt.Fprintf(&defs, "type %s struct {\n\t%s\n}\n\n", names[i], goType)
+			} else {
+				fmt.Fprintf(&defs, "type %s %s\n\n", names[i], goType)
+			}
+		}
+		fmt.Fprintf(&defs, "func (%s) is%s() {}\n\n", names[i], iface)
+	}
+
+	writeComment(buf, "", t.Description, fmt.Sprintf("%s holds a value of one of the variants of a union", name))
+	fmt.Fprintf(buf, "type %s struct {\n", name)
+	fmt.Fprintf(buf, "\t// Value is one of %s or %s, nil if unset\n", strings.Join(names[:len(names)-1], ", "), names[len(names)-1])
+	fmt.Fprintf(buf, "\tValue %s\n}\n\n", iface)
+
+	fmt.Fprintf(buf, "// %s is implemented by the variants of %s\n", iface, name)
+	fmt.Fprintf(buf, "type %s interface {\n\tis%s()\n}\n\n", iface, iface)
+	buf.Write(defs.Bytes())
+
+	fmt.Fprintf(buf, "// MarshalJSON encodes the value of the variant, null if unset\n")
+	fmt.Fprintf(buf, "func (u %s) MarshalJSON() ([]byte, error) {\n", name)
+	buf.WriteString("\tif u.Value == nil {\n\t\treturn []byte(\"null\"), nil\n\t}\n\treturn json.Marshal(u.Value)\n}\n\n")
+
+	fmt.Fprintf(buf, "// UnmarshalJSON decodes the first variant matching the JSON in the order of the schema, rejecting unknown fields\n")
+	fmt.Fprintf(buf, "func (u *%s) UnmarshalJSON(data []byte) error {\n", name)
+	buf.WriteString("\tif string(data) == \"null\" {\n\t\tu.Value = nil\n\t\treturn nil\n\t}\n")
+	for i, variantName := range names {
+		fmt.Fprintf(buf, "\tvar v%d %s\n", i+1, variantName)
+		fmt.Fprintf(buf, "\tif err := unmarshalStrict(data, &v%d); err == nil {\n\t\tu.Value = v%d\n\t\treturn nil\n\t}\n", i+1, i+1)
+	}
+	fmt.Fprintf(buf, "\treturn fmt.Errorf(\"%s matches no variant of %s\", data)\n}\n\n", "%s", name)
+
+	if opts.Clones {
+		writeUnionClone(buf, name, names, t.Union, path, opts)
+	}
+
+	for _, n := range nested {
+		if err := generateNested(n, buf, generatedStructs, opts, templates); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasUnion reports whether t at path holds a union anywhere, leaving out overridden types
+func hasUnion(t *yema.Type, path string, opts Options) bool {
+	if _, ok := opts.overrides[path]; ok && path != "" {
+		return false
+	}
+	switch {
+	case t.Kind == yema.Union:
+		return true
+	case t.Array != nil:
+		return hasUnion(t.Array, path+"[]", opts)
+	case t.Map != nil:
+		return hasUnion(t.Map, path+"[]", opts)
+	case t.Struct != nil:
+		for name, field := range *t.Struct {
+			fieldPath := name
+			if path != "" {
+				fieldPath = path + "." + name
+			}
+			if hasUnion(&field, fieldPath, opts) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,506 @@
+package golang
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aep/yema"
+	"github.com/aep/yema/internal/checks"
+	"github.com/aep/yema/internal/fieldpath"
+	"github.com/aep/yema/internal/ident"
+	"github.com/aep/yema/validator"
+)
+
+// generateValidator generates a Validate method for the root struct from the shared validation IR.
+// Checks already guaranteed by the Go types, such as kinds and the range of sized integers, are skipped.
+// Required fields can only be told missing if their zero value is nil, which holds for slices and maps,
+// and fields are present for $requires and $conflicts unless they are optional and nil.
+// Unions are not checked, their JSON only decodes into a variant it matches. Rules the method cannot check,
+// such as $if conditions and custom formats, are reported as an error rather than skipped.
+func generateValidator(t *yema.Type, structName string, buf *bytes.Buffer, opts Options) error {
+	root, err := checks.BuildWithOptions(t, checks.Options{ProtoJSON: opts.ProtoJSON})
+	if err != nil {
+		return err
+	}
+
+	var method bytes.Buffer
+	fmt.Fprintf(&method, "// Validate checks the rules of the schema not enforced by the Go types and returns all violations found\n")
+	fmt.Fprintf(&method, "func (t *%s) Validate() error {\n", structName)
+	fmt.Fprintf(&method, "\tvar errs []error\n")
+
+	e := &validatorEmitter{buf: &method, structName: structName, opts: opts}
+	e.node(root, "t", "", nil, 1)
+	if e.err != nil {
+		return e.err
+	}
+
+	fmt.Fprintf(&method, "\treturn errors.Join(errs...)\n")
+	fmt.Fprintf(&method, "}\n\n")
+
+	// The patterns are compiled once, when the package is initialized
+	for i, pattern := range e.patterns {
+		fmt.Fprintf(&method, "// %s is the pattern of the keys of %s\n", patternVar(structName, i), pattern.path)
+		fmt.Fprintf(&method, "var %s = regexp.MustCompile(%s)\n\n", patternVar(structName, i), strconv.Quote(pattern.expr))
+	}
+	buf.Write(method.Bytes())
+	return nil
+}
+
+// validatorImports are the imports of the packages generated validators may call, the formats parsing
+// ISO 8601 durations call parseISODuration of isoDurationHelpers
+var validatorImports = []string{"net/netip", "net/url", "reflect", "regexp", "time"}
+
+// validatorEmitter writes the Go statements checking a node of the validation IR
+type validatorEmitter struct {
+	buf        *bytes.Buffer
+	vars       int
+	structName string
+	opts       Options
+	// patterns are the patterns of map keys, declared as variables after the method
+	patterns []keyPattern
+	// err is the first rule found that the method cannot check
+	err error
+}
+
+// keyPattern is the pattern of the keys of the map at a schema path
+type keyPattern struct {
+	path string
+	expr string
+}
+
+// patternVar returns the name of the variable holding the i-th key pattern of the validator of a struct
+func patternVar(structName string, i int) string {
+	return "keyPattern" + structName + strconv.Itoa(i+1)
+}
+
+// fail records that the rule of the schema at path cannot be checked
+func (e *validatorEmitter) fail(path, format string, args ...interface{}) {
+	if e.err == nil {
+		e.err = fmt.Errorf("%s at %s cannot be checked by the generated Validate method", fmt.Sprintf(format, args...), fieldpath.Display(path))
+	}
+}
+
+func (e *validatorEmitter) newVar(prefix string) string {
+	e.vars++
+	return prefix + strconv.Itoa(e.vars)
+}
+
+func (e *validatorEmitter) line(depth int, format string, args ...interface{}) {
+	e.buf.WriteString(strings.Repeat("\t", depth))
+	fmt.Fprintf(e.buf, format, args...)
+	e.buf.WriteString("\n")
+}
+
+// report emits the statement recording a violation, the message is a format string of the array indices
+// and map keys of the value, the variables of which are in indices
+func (e *validatorEmitter) report(depth int, indices []string, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	if len(indices) == 0 {
+		e.line(depth, "errs = append(errs, errors.New(%s))", strconv.Quote(strings.ReplaceAll(msg, "%%", "%")))
+		return
+	}
+	e.line(depth, "errs = append(errs, fmt.Errorf(%s, %s))", strconv.Quote(msg), strings.Join(indices, ", "))
+}
+
+// field emits the checks for a field of the struct expression owner
+func (e *validatorEmitter) field(f *checks.Field, owner, pathFmt string, indices []string, depth int) {
+	nilable := f.Node.Kind == yema.Array || f.Node.Kind == yema.Bytes || f.Node.Kind == yema.Map
+	if e.overridden(f.Node) || !e.needsChecks(f.Node) && !(f.Required && nilable) {
+		return
+	}
+
+	fieldPath := escapeFormat(f.Name)
+	if pathFmt != "" {
+		fieldPath = pathFmt + "." + fieldPath
+	}
+
+	expr := e.fieldExpr(f, owner)
+	switch {
+	case nilable:
+		// Slices and maps are not pointers when optional, a missing required one is nil
+		if f.Required {
+			e.line(depth, "if %s == nil {", expr)
+			e.report(depth+1, indices, "required field '%s' is missing", fieldPath)
+			e.line(depth, "}")
+		}
+		e.node(f.Node, expr, fieldPath, indices, depth)
+	case f.Optional:
+		// Optional fields are pointers, other fields protojson may omit hold their zero value
+		v := e.newVar("v")
+		e.line(depth, "if %s := %s; %s != nil {", v, expr, v)
+		if f.Node.Kind != yema.Struct {
+			v = "*" + v
+		}
+		e.node(f.Node, v, fieldPath, indices, depth+1)
+		e.line(depth, "}")
+	default:
+		e.node(f.Node, expr, fieldPath, indices, depth)
+	}
+}
+
+// node emits the checks for the value of expr
+func (e *validatorEmitter) node(n *checks.Node, expr, pathFmt string, indices []string, depth int) {
+	for _, c := range n.Checks {
+		if impliedByType(n.Kind, c) {
+			continue
+		}
+		// []byte holds the bytes decoded from base64, while the validator counts the bytes of strings
+		// that declare no encoding as they are
+		if (c.Op == checks.OpMinLength || c.Op == checks.OpMaxLength) && n.Encoding != validator.Base64Encoding {
+			e.fail(n.Path, "the length of bytes not in base64")
+			return
+		}
+
+		switch c.Op {
+		case checks.OpMin:
+			e.line(depth, "if %s < %d {", expr, c.Bound)
+			e.report(depth+1, indices, "field '%s' must be at least %d", pathFmt, c.Bound)
+			e.line(depth, "}")
+		case checks.OpMax:
+			e.line(depth, "if %s > %d {", expr, c.Bound)
+			e.report(depth+1, indices, "field '%s' must be at most %d", pathFmt, c.Bound)
+			e.line(depth, "}")
+		case checks.OpMinLength:
+			e.line(depth, "if len(%s) < %d {", expr, c.Bound)
+			e.report(depth+1, indices, "field '%s' must hold at least %d bytes", pathFmt, c.Bound)
+			e.line(depth, "}")
+		case checks.OpMaxLength:
+			e.line(depth, "if len(%s) > %d {", expr, c.Bound)
+			e.report(depth+1, indices, "field '%s' must hold at most %d bytes", pathFmt, c.Bound)
+			e.line(depth, "}")
+		}
+	}
+
+	if e.checksFormat(n) {
+		e.format(n, expr, pathFmt, indices, depth)
+	}
+
+	if len(n.Enum) != 0 {
+		// UUIDs are compared in their canonical form, lower case, as are the values allowed for them
+		value := expr
+		if n.Format == validator.UUIDFormat {
+			value += ".String()"
+		}
+		values := make([]string, len(n.Enum))
+		for i, allowed := range n.Enum {
+			if s, ok := allowed.(string); ok && n.Format == validator.UUIDFormat {
+				allowed = strings.ToLower(s)
+			}
+			values[i] = goLiteral(allowed)
+		}
+		e.line(depth, "switch %s {", value)
+		e.line(depth, "case %s:", strings.Join(values, ", "))
+		e.line(depth, "default:")
+		e.report(depth+1, indices, "field '%s' must be one of %s", pathFmt, escapeFormat(strings.Join(values, ", ")))
+		e.line(depth, "}")
+	}
+
+	e.rules(n, expr, pathFmt, indices, depth)
+	for _, f := range n.Fields {
+		e.field(f, expr, pathFmt, indices, depth)
+	}
+
+	if n.Unique {
+		e.unique(expr, pathFmt, indices, depth)
+	}
+
+	if n.Items != nil && !e.overridden(n.Items) && e.needsChecks(n.Items) {
+		i := e.newVar("i")
+		v := e.newVar("v")
+		e.line(depth, "for %s, %s := range %s {", i, v, expr)
+		e.node(n.Items, v, pathFmt+"[%d]", append(indices[:len(indices):len(indices)], i), depth+1)
+		e.line(depth, "}")
+	}
+
+	// Values of maps are at the path of their key, as the validator reports them
+	checkValues := n.Values != nil && !e.overridden(n.Values) && e.needsChecks(n.Values)
+	if checkValues || n.KeyPattern != "" {
+		k := e.newVar("k")
+		keyIndices := append(indices[:len(indices):len(indices)], k)
+		if checkValues {
+			v := e.newVar("v")
+			e.line(depth, "for %s, %s := range %s {", k, v, expr)
+			e.keyPattern(n, k, pathFmt, keyIndices, depth+1)
+			e.node(n.Values, v, pathFmt+".%s", keyIndices, depth+1)
+		} else {
+			e.line(depth, "for %s := range %s {", k, expr)
+			e.keyPattern(n, k, pathFmt, keyIndices, depth+1)
+		}
+		e.line(depth, "}")
+	}
+}
+
+// fieldExpr returns the expression of a field of the struct expression owner
+func (e *validatorEmitter) fieldExpr(f *checks.Field, owner string) string {
+	return owner + "." + ident.Camel(ident.Source(f.Name, f.CodeName, e.opts.Transliterate))
+}
+
+// present returns the condition of a field of the struct expression owner being present, empty if it always is.
+// Optional fields are pointers, slices or maps, which are nil if the field is absent or null.
+func (e *validatorEmitter) present(f *checks.Field, owner string) string {
+	if !f.Optional {
+		return ""
+	}
+	return e.fieldExpr(f, owner) + " != nil"
+}
+
+// rules emits the checks of the fields of the struct expression owner requiring or conflicting with their siblings
+func (e *validatorEmitter) rules(n *checks.Node, owner, pathFmt string, indices []string, depth int) {
+	fields := make(map[string]*checks.Field, len(n.Fields))
+	for _, f := range n.Fields {
+		fields[f.Name] = f
+	}
+	fieldPath := func(name string) string {
+		if pathFmt == "" {
+			return escapeFormat(name)
+		}
+		return pathFmt + "." + escapeFormat(name)
+	}
+
+	for _, f := range n.Fields {
+		if f.If != nil {
+			e.fail(f.Node.Path, "the $if condition")
+		}
+		for _, name := range f.Requires {
+			if e.present(fields[name], owner) == "" {
+				continue
+			}
+			e.line(depth, "if %s {", joinConditions(e.present(f, owner), e.fieldExpr(fields[name], owner)+" == nil"))
+			e.report(depth+1, indices, "field '%s' requires field '%s'", fieldPath(f.Name), fieldPath(name))
+			e.line(depth, "}")
+		}
+		for _, name := range f.Conflicts {
+			cond := joinConditions(e.present(f, owner), e.present(fields[name], owner))
+			if cond == "" {
+				e.report(depth, indices, "field '%s' conflicts with field '%s'", fieldPath(f.Name), fieldPath(name))
+				continue
+			}
+			e.line(depth, "if %s {", cond)
+			e.report(depth+1, indices, "field '%s' conflicts with field '%s'", fieldPath(f.Name), fieldPath(name))
+			e.line(depth, "}")
+		}
+	}
+}
+
+// joinConditions joins the non-empty conditions with &&
+func joinConditions(conds ...string) string {
+	var kept []string
+	for _, cond := range conds {
+		if cond != "" {
+			kept = append(kept, cond)
+		}
+	}
+	return strings.Join(kept, " && ")
+}
+
+// unique emits the check of the items of the slice expr differing from each other, reporting each item equal to
+// an earlier one like the validator
+func (e *validatorEmitter) unique(expr, pathFmt string, indices []string, depth int) {
+	i := e.newVar("i")
+	j := e.newVar("j")
+	e.line(depth, "for %s := range %s {", i, expr)
+	e.line(depth+1, "for %s := 0; %s < %s; %s++ {", j, j, i, j)
+	e.line(depth+2, "if reflect.DeepEqual(%s[%s], %s[%s]) {", expr, i, expr, j)
+	args := append(append(append(indices[:len(indices):len(indices)], i), indices...), j)
+	e.report(depth+3, args, "field '%s[%%d]' duplicates '%s[%%d]', items must be unique", pathFmt, pathFmt)
+	e.line(depth+3, "break")
+	e.line(depth+2, "}")
+	e.line(depth+1, "}")
+	e.line(depth, "}")
+}
+
+// keyPattern emits the check of the key k of the map at pathFmt matching its pattern, if it has one
+func (e *validatorEmitter) keyPattern(n *checks.Node, k, pathFmt string, indices []string, depth int) {
+	if n.KeyPattern == "" {
+		return
+	}
+	e.patterns = append(e.patterns, keyPattern{path: fieldpath.Display(n.Path), expr: n.KeyPattern})
+	e.line(depth, "if !%s.MatchString(%s) {", patternVar(e.structName, len(e.patterns)-1), k)
+	e.report(depth+1, indices, "key of field '%s' must match %s", pathFmt+".%s", escapeFormat(n.KeyPattern))
+	e.line(depth, "}")
+}
+
+// checksFormat reports whether the format of the string n needs a check, which its Go type does not guarantee.
+// UUIDs and the time types only decode from valid values, and enums only hold values of their format.
+func (e *validatorEmitter) checksFormat(n *checks.Node) bool {
+	if n.Kind != yema.String || n.Format == "" || len(n.Enum) != 0 {
+		return false
+	}
+	switch stringRepr(&yema.Type{Kind: n.Kind, Format: n.Format, FormatArg: n.FormatArg}, e.opts) {
+	case plainString:
+		return true
+	case uuidString:
+		return n.FormatArg != nil
+	}
+	return false
+}
+
+// format emits the check of the string expr against its format, see checksFormat
+func (e *validatorEmitter) format(n *checks.Node, expr, pathFmt string, indices []string, depth int) {
+	switch n.Format {
+	case validator.UUIDFormat:
+		versions, _ := validator.UUIDVersions(n.FormatArg)
+		conds := make([]string, len(versions))
+		names := make([]string, len(versions))
+		for i, version := range versions {
+			conds[i] = fmt.Sprintf("%s.Version() != %d", expr, version)
+			names[i] = strconv.Itoa(version)
+		}
+		e.line(depth, "if %s.Variant() != uuid.RFC4122 || %s {", expr, strings.Join(conds, " && "))
+		e.report(depth+1, indices, "field '%s' must be a UUID of version %s", pathFmt, strings.Join(names, " or "))
+
+	case validator.DateTimeFormat:
+		layout, layoutExpr := time.RFC3339, "time.RFC3339"
+		if arg, ok := n.FormatArg.(string); ok && arg != time.RFC3339 {
+			layout, layoutExpr = arg, strconv.Quote(arg)
+		}
+		e.line(depth, "if _, err := time.Parse(%s, %s); err != nil {", layoutExpr, expr)
+		e.report(depth+1, indices, "field '%s' must be a timestamp in the layout %s", pathFmt, escapeFormat(strconv.Quote(layout)))
+
+	case validator.DurationFormat:
+		parse, example := "parseISODuration", "PT1H30M"
+		if n.FormatArg == validator.GoDuration {
+			parse, example = "time.ParseDuration", "1h30m"
+		}
+		e.line(depth, "if _, err := %s(%s); err != nil {", parse, expr)
+		e.report(depth+1, indices, "field '%s' must be a duration such as %s", pathFmt, example)
+
+	case validator.URIFormat:
+		u := e.newVar("u")
+		e.line(depth, "if %s, err := url.Parse(%s); err != nil || %s.Scheme == \"\" {", u, expr, u)
+		e.report(depth+1, indices, "field '%s' must be an absolute URI", pathFmt)
+		if schemes, _ := validator.URISchemes(n.FormatArg); schemes != nil {
+			conds := make([]string, len(schemes))
+			for i, scheme := range schemes {
+				conds[i] = fmt.Sprintf("%s.Scheme != %s", u, strconv.Quote(scheme))
+			}
+			e.line(depth, "} else if %s {", strings.Join(conds, " && "))
+			e.report(depth+1, indices, "field '%s' must be a URI with scheme %s", pathFmt, escapeFormat(strings.Join(schemes, " or ")))
+		}
+
+	case validator.IPv4Format, validator.IPv6Format:
+		a := e.newVar("a")
+		version, check := "IPv4", "!"+a+".Is4()"
+		if n.Format == validator.IPv6Format {
+			version, check = "IPv6", "!"+a+".Is6() || "+a+".Zone() != \"\""
+		}
+		e.line(depth, "if %s, err := netip.ParseAddr(%s); err != nil || %s {", a, expr, check)
+		e.report(depth+1, indices, "field '%s' must be an %s address", pathFmt, version)
+		// IPv4 addresses mapped to IPv6 are in the range of the IPv4 address
+		switch n.FormatArg {
+		case validator.PrivateRange:
+			e.line(depth, "} else if !%s.Unmap().IsPrivate() {", a)
+			e.report(depth+1, indices, "field '%s' must be a private address", pathFmt)
+		case validator.PublicRange:
+			e.line(depth, "} else if !%s.Unmap().IsGlobalUnicast() || %s.Unmap().IsPrivate() {", a, a)
+			e.report(depth+1, indices, "field '%s' must be a public address", pathFmt)
+		}
+
+	case validator.CIDRFormat:
+		if n.FormatArg != nil {
+			e.fail(n.Path, "the range of the format %s", n.Format)
+			return
+		}
+		p := e.newVar("p")
+		e.line(depth, "if %s, err := netip.ParsePrefix(%s); err != nil || %s.Masked() != %s {", p, expr, p, p)
+		e.report(depth+1, indices, "field '%s' must be a CIDR prefix such as 10.0.0.0/8", pathFmt)
+
+	default:
+		// Custom formats are only registered with the validator
+		e.fail(n.Path, "the format %s", n.Format)
+		return
+	}
+	e.line(depth, "}")
+}
+
+// goLiteral returns the Go literal of an enum value
+func goLiteral(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return strconv.Quote(s)
+	}
+	return fmt.Sprint(value)
+}
+
+// overridden reports whether the type of n is overridden, see Options.TypeOverrides
+func (e *validatorEmitter) overridden(n *checks.Node) bool {
+	_, ok := e.opts.overrides[n.Path]
+	return ok && n.Path != ""
+}
+
+// needsChecks reports whether any check in the subtree of n is not implied by the Go types or overridden
+func (e *validatorEmitter) needsChecks(n *checks.Node) bool {
+	if e.overridden(n) {
+		return false
+	}
+	if len(n.Enum) != 0 || e.checksFormat(n) || n.KeyPattern != "" || n.Unique {
+		return true
+	}
+	for _, c := range n.Checks {
+		if !impliedByType(n.Kind, c) {
+			return true
+		}
+	}
+	for _, f := range n.Fields {
+		nilable := f.Node.Kind == yema.Array || f.Node.Kind == yema.Bytes || f.Node.Kind == yema.Map
+		if !e.overridden(f.Node) && f.Required && nilable || e.needsChecks(f.Node) {
+			return true
+		}
+		if f.If != nil || len(f.Requires) != 0 || len(f.Conflicts) != 0 {
+			return true
+		}
+	}
+	return n.Items != nil && e.needsChecks(n.Items) || n.Values != nil && e.needsChecks(n.Values)
+}
+
+// impliedByType reports whether the Go type generated for kind already guarantees the check
+func impliedByType(kind yema.Kind, c checks.Check) bool {
+	if c.Op == checks.OpType {
+		return true
+	}
+
+	min, max, ok := goIntRange(kind)
+	if !ok {
+		return false
+	}
+
+	switch c.Op {
+	case checks.OpMin:
+		return min >= c.Bound
+	case checks.OpMax:
+		// The range of uint64 exceeds int64, so no bound is implied by it
+		return kind != yema.Uint64 && kind != yema.Uint && max <= c.Bound
+	}
+	return false
+}
+
+// goIntRange returns the range of the Go integer type generated for kind, clamped to int64
+func goIntRange(kind yema.Kind) (int64, int64, bool) {
+	switch kind {
+	case yema.Int, yema.Int64:
+		return math.MinInt64, math.MaxInt64, true
+	case yema.Int8:
+		return math.MinInt8, math.MaxInt8, true
+	case yema.Int16:
+		return math.MinInt16, math.MaxInt16, true
+	case yema.Int32:
+		return math.MinInt32, math.MaxInt32, true
+	case yema.Uint, yema.Uint64:
+		return 0, math.MaxInt64, true
+	case yema.Uint8:
+		return 0, math.MaxUint8, true
+	case yema.Uint16:
+		return 0, math.MaxUint16, true
+	case yema.Uint32:
+		return 0, math.MaxUint32, true
+	}
+	return 0, 0, false
+}
+
+// escapeFormat escapes a string for use inside a Go format string
+func escapeFormat(s string) string {
+	return strings.ReplaceAll(s, "%", "%%")
+}
@@ -0,0 +1,245 @@
+// Package checks builds the validation IR shared by all generators that emit validation code.
+//
+// The IR describes the rules a JSON document must follow to match a schema, as a tree of nodes
+// mirroring the schema with a list of checks per node. It is built once per schema, so every
+// target language enforces identical rules. Emitters may skip checks that the type system of
+// their target language already guarantees, e.g. kind checks on statically typed fields, and fail
+// rather than skip the checks their generated types cannot make.
+package checks
+
+import (
+	"fmt"
+
+	"github.com/aep/yema"
+	"github.com/aep/yema/internal/fieldpath"
+	"github.com/aep/yema/validator"
+)
+
+// JSONType is a type in the JSON data model
+type JSONType int
+
+const (
+	Boolean JSONType = iota + 1
+	Integer
+	Number
+	String
+	Array
+	Object
+)
+
+func (t JSONType) String() string {
+	switch t {
+	case Boolean:
+		return "boolean"
+	case Integer:
+		return "integer"
+	case Number:
+		return "number"
+	case String:
+		return "string"
+	case Array:
+		return "array"
+	case Object:
+		return "object"
+	}
+	return "unknown"
+}
+
+// Op is the operation of a single check
+type Op int
+
+const (
+	// OpType checks that the value is of Check.Type
+	OpType Op = iota + 1
+	// OpMin checks that a numeric value is at least Check.Bound
+	OpMin
+	// OpMax checks that a numeric value is at most Check.Bound
+	OpMax
+	// OpMinLength checks that bytes number at least Check.Bound once decoded from Node.Encoding
+	OpMinLength
+	// OpMaxLength checks that bytes number at most Check.Bound once decoded from Node.Encoding
+	OpMaxLength
+)
+
+// Check is a single rule applied to a value
+type Check struct {
+	Op    Op
+	Type  JSONType
+	Bound int64
+}
+
+// Node holds the checks for the value at one location in the document
+type Node struct {
+	// Path is the location of the value for display, e.g. "addresses[].street"
+	Path string
+	// Kind is the schema kind of the value
+	Kind yema.Kind
+	// Checks are applied to the value in order, the first check is always OpType
+	Checks []Check
+	// Enum lists the values allowed for a string or number, nil if any value of the kind is allowed
+	Enum []interface{}
+	// Format is the format a string declares, which may change its type in generated code, such as uuid,
+	// and FormatArg its argument, nil if none was declared
+	Format    string
+	FormatArg interface{}
+	// Encoding is the encoding of the string holding bytes, such as base64 with ProtoJSON, empty if the bytes
+	// of the string are counted as they are
+	Encoding string
+	// KeyPattern is a regular expression every key of a map must match, empty if keys are unconstrained
+	KeyPattern string
+	// Unique requires the items of an array to differ from each other, compared by value
+	Unique bool
+	// Fields are the nodes for the fields of an object, in declaration order
+	Fields []*Field
+	// Items is the node for every element of an array
+	Items *Node
+	// Values is the node for every value of a map
+	Values *Node
+	// Variants are the nodes of the variants of a union, the value must match one of them
+	Variants []*Node
+}
+
+// Field is a named field of an object
+type Field struct {
+	Name string
+	// CodeName is the declared source of identifiers for the field in generated code, if any
+	CodeName string
+	Required bool
+	// Optional is whether the schema declares the field optional, fields that are not may still not be
+	// Required with ProtoJSON
+	Optional bool
+	// Requires and Conflicts list the sibling fields that must be present or absent whenever the field is
+	Requires  []string
+	Conflicts []string
+	// If makes the declaration of the field depend on the values of its sibling fields, nil if it does not
+	If   *yema.Condition
+	Node *Node
+}
+
+// Options holds configuration options for building the validation IR
+type Options struct {
+	// ProtoJSON follows the protobuf JSON mapping: 64-bit integers are strings, and only struct fields are
+	// required, as proto3 omits fields holding their default value
+	ProtoJSON bool
+}
+
+// Build produces the validation IR for a schema
+func Build(t *yema.Type) (*Node, error) {
+	return BuildWithOptions(t, Options{})
+}
+
+// BuildWithOptions is like Build with custom options
+func BuildWithOptions(t *yema.Type, opts Options) (*Node, error) {
+	if t == nil {
+		return nil, fmt.Errorf("nil type provided")
+	}
+	return build(t, "", opts)
+}
+
+func build(t *yema.Type, path string, opts Options) (*Node, error) {
+	n := &Node{Path: path, Kind: t.Kind, Enum: t.Enum, Format: t.Format, FormatArg: t.FormatArg, KeyPattern: t.KeyPattern, Unique: t.Unique}
+
+	switch t.Kind {
+	case yema.Int, yema.Int64, yema.Uint, yema.Uint64:
+		if opts.ProtoJSON {
+			// protojson encodes 64-bit integers as strings
+			n.Checks = append(n.Checks, Check{Op: OpType, Type: String})
+			return n, nil
+		}
+	}
+
+	switch t.Kind {
+	case yema.Bool:
+		n.Checks = append(n.Checks, Check{Op: OpType, Type: Boolean})
+	case yema.Int, yema.Int64, yema.Uint64:
+		n.Checks = append(n.Checks, Check{Op: OpType, Type: Integer})
+	case yema.Uint:
+		n.Checks = append(n.Checks, Check{Op: OpType, Type: Integer}, Check{Op: OpMin, Bound: 0})
+	case yema.Int8:
+		n.Checks = append(n.Checks, Check{Op: OpType, Type: Integer}, Check{Op: OpMin, Bound: -128}, Check{Op: OpMax, Bound: 127})
+	case yema.Int16:
+		n.Checks = append(n.Checks, Check{Op: OpType, Type: Integer}, Check{Op: OpMin, Bound: -32768}, Check{Op: OpMax, Bound: 32767})
+	case yema.Int32:
+		n.Checks = append(n.Checks, Check{Op: OpType, Type: Integer}, Check{Op: OpMin, Bound: -2147483648}, Check{Op: OpMax, Bound: 2147483647})
+	case yema.Uint8:
+		n.Checks = append(n.Checks, Check{Op: OpType, Type: Integer}, Check{Op: OpMin, Bound: 0}, Check{Op: OpMax, Bound: 255})
+	case yema.Uint16:
+		n.Checks = append(n.Checks, Check{Op: OpType, Type: Integer}, Check{Op: OpMin, Bound: 0}, Check{Op: OpMax, Bound: 65535})
+	case yema.Uint32:
+		n.Checks = append(n.Checks, Check{Op: OpType, Type: Integer}, Check{Op: OpMin, Bound: 0}, Check{Op: OpMax, Bound: 4294967295})
+	case yema.Float32, yema.Float64:
+		n.Checks = append(n.Checks, Check{Op: OpType, Type: Number})
+	case yema.String:
+		n.Checks = append(n.Checks, Check{Op: OpType, Type: String})
+	case yema.Bytes:
+		n.Checks = append(n.Checks, Check{Op: OpType, Type: String})
+		switch {
+		case opts.ProtoJSON:
+			n.Encoding = validator.Base64Encoding
+		case t.Format == validator.Base64Encoding, t.Format == validator.Base64URLEncoding, t.Format == validator.HexEncoding:
+			n.Encoding = t.Format
+		}
+		if t.Length != nil && t.Length.Min > 0 {
+			n.Checks = append(n.Checks, Check{Op: OpMinLength, Bound: int64(t.Length.Min)})
+		}
+		if t.Length != nil && t.Length.Max >= 0 {
+			n.Checks = append(n.Checks, Check{Op: OpMaxLength, Bound: int64(t.Length.Max)})
+		}
+	case yema.Array:
+		if t.Array == nil {
+			return nil, fmt.Errorf("array type with nil Array field at %s", fieldpath.Display(path))
+		}
+		n.Checks = append(n.Checks, Check{Op: OpType, Type: Array})
+		items, err := build(t.Array, path+"[]", opts)
+		if err != nil {
+			return nil, err
+		}
+		n.Items = items
+	case yema.Map:
+		if t.Map == nil {
+			return nil, fmt.Errorf("map type with nil Map field at %s", fieldpath.Display(path))
+		}
+		n.Checks = append(n.Checks, Check{Op: OpType, Type: Object})
+		values, err := build(t.Map, path+"[]", opts)
+		if err != nil {
+			return nil, err
+		}
+		n.Values = values
+	case yema.Struct:
+		if t.Struct == nil {
+			return nil, fmt.Errorf("struct type with nil Struct field at %s", fieldpath.Display(path))
+		}
+		n.Checks = append(n.Checks, Check{Op: OpType, Type: Object})
+		for _, name := range t.FieldNames() {
+			fieldType := (*t.Struct)[name]
+			child, err := build(&fieldType, fieldpath.Join(path, name), opts)
+			if err != nil {
+				return nil, err
+			}
+			required := !fieldType.Optional && (!opts.ProtoJSON || fieldType.Kind == yema.Struct)
+			n.Fields = append(n.Fields, &Field{
+				Name:      name,
+				CodeName:  fieldType.CodeName,
+				Required:  required,
+				Optional:  fieldType.Optional,
+				Requires:  fieldType.Requires,
+				Conflicts: fieldType.Conflicts,
+				If:        fieldType.If,
+				Node:      child,
+			})
+		}
+	case yema.Union:
+		// Variants share the path of their union
+		for i := range t.Union {
+			variant, err := build(&t.Union[i], path, opts)
+			if err != nil {
+				return nil, err
+			}
+			n.Variants = append(n.Variants, variant)
+		}
+	default:
+		return nil, fmt.Errorf("unexpected type kind: %v at %s", t.Kind, fieldpath.Display(path))
+	}
+
+	return n, nil
+}
@@ -0,0 +1,149 @@
+package checks
+
+import (
+	"testing"
+
+	"github.com/aep/yema"
+)
+
+func TestBuild(t *testing.T) {
+	schema := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"name": {Kind: yema.String, Enum: []interface{}{"alice", "bob"}},
+			"age":  {Kind: yema.Uint8, Optional: true},
+			"tags": {Kind: yema.Array, Array: &yema.Type{Kind: yema.Int16}},
+		},
+		Order: []string{"name", "age", "tags"},
+	}
+
+	root, err := Build(schema)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	if root.Checks[0].Type != Object || len(root.Fields) != 3 {
+		t.Fatalf("unexpected root node %+v", root)
+	}
+
+	if enum := root.Fields[0].Node.Enum; len(enum) != 2 || enum[0] != "alice" {
+		t.Errorf("unexpected enum for name: %v", enum)
+	}
+
+	age := root.Fields[1]
+	if age.Name != "age" || age.Required {
+		t.Errorf("unexpected field %+v", age)
+	}
+	if got := age.Node.Checks; len(got) != 3 || got[1] != (Check{Op: OpMin, Bound: 0}) || got[2] != (Check{Op: OpMax, Bound: 255}) {
+		t.Errorf("unexpected checks for age: %+v", got)
+	}
+
+	items := root.Fields[2].Node.Items
+	if items == nil || items.Path != "tags[]" || items.Checks[0].Type != Integer {
+		t.Errorf("unexpected items node %+v", items)
+	}
+
+	if _, err := Build(&yema.Type{Kind: yema.Array}); err == nil {
+		t.Errorf("expected error for array without item type")
+	}
+}
+
+func TestBuildProtoJSON(t *testing.T) {
+	schema := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"id":    {Kind: yema.Uint64},
+			"count": {Kind: yema.Int32},
+			"owner": {Kind: yema.Struct, Struct: &map[string]yema.Type{}},
+		},
+		Order: []string{"id", "count", "owner"},
+	}
+
+	root, err := BuildWithOptions(schema, Options{ProtoJSON: true})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	id, count, owner := root.Fields[0], root.Fields[1], root.Fields[2]
+	if id.Required || len(id.Node.Checks) != 1 || id.Node.Checks[0].Type != String {
+		t.Errorf("unexpected 64-bit field %+v %+v", id, id.Node.Checks)
+	}
+	if count.Required || count.Node.Checks[0].Type != Integer {
+		t.Errorf("unexpected 32-bit field %+v %+v", count, count.Node.Checks)
+	}
+	if !owner.Required {
+		t.Errorf("struct fields stay required: %+v", owner)
+	}
+}
+
+func TestBuildBytes(t *testing.T) {
+	schema := &yema.Type{Kind: yema.Bytes, Format: "hex", Length: &yema.Length{Min: 4, Max: -1}}
+	n, err := Build(schema)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if len(n.Checks) != 2 || n.Checks[1] != (Check{Op: OpMinLength, Bound: 4}) || n.Encoding != "hex" {
+		t.Errorf("unexpected node %+v", n)
+	}
+
+	// protojson writes bytes in base64 whatever their format
+	n, err = BuildWithOptions(schema, Options{ProtoJSON: true})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if n.Encoding != "base64" {
+		t.Errorf("expected base64 with protojson, got %q", n.Encoding)
+	}
+
+	n, err = Build(&yema.Type{Kind: yema.Bytes, Format: "checksum"})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if n.Encoding != "" || len(n.Checks) != 1 {
+		t.Errorf("unexpected node for bytes without an encoding %+v", n)
+	}
+}
+
+func TestBuildUnion(t *testing.T) {
+	schema := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"id": {Kind: yema.Union, Union: []yema.Type{{Kind: yema.String}, {Kind: yema.Int8}}},
+		},
+	}
+
+	root, err := Build(schema)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	id := root.Fields[0].Node
+	if len(id.Checks) != 0 || len(id.Variants) != 2 {
+		t.Fatalf("unexpected union node %+v", id)
+	}
+	if v := id.Variants[1]; v.Path != "id" || len(v.Checks) != 3 || v.Checks[0].Type != Integer {
+		t.Errorf("unexpected variant %+v", v)
+	}
+}
+
+func TestBuildMap(t *testing.T) {
+	schema := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"labels": {Kind: yema.Map, Map: &yema.Type{Kind: yema.Uint8}},
+		},
+	}
+
+	root, err := Build(schema)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	labels := root.Fields[0].Node
+	if labels.Checks[0].Type != Object || labels.Values == nil {
+		t.Fatalf("unexpected map node %+v", labels)
+	}
+	if v := labels.Values; v.Path != "labels[]" || v.Checks[0].Type != Integer {
+		t.Errorf("unexpected values %+v", v)
+	}
+}
@@ -0,0 +1,64 @@
+// Package fieldpath builds the dotted paths of fields, such as settings.limits.maxItems, that problems and
+// errors about a schema or document are reported at.
+package fieldpath
+
+// Join returns the path of the field name of the struct at path
+func Join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// Display returns path as it is shown in messages, the root for the empty path
+func Display(path string) string {
+	if path == "" {
+		return "root"
+	}
+	return path
+}
+
+// Split returns the segments of a path: the names of fields, and the indices of array items and the keys of
+// map values in brackets, such as ["labels", "[app.kubernetes.io/name]", "value"] for
+// labels[app.kubernetes.io/name].value. Keys are written verbatim, so a bracket only ends one if the path ends
+// or another segment starts right after it.
+func Split(path string) []string {
+	var segments []string
+	for i := 0; i < len(path); {
+		switch path[i] {
+		case '.':
+			i++
+		case '[':
+			end := len(path)
+			for j := i + 1; j < len(path); j++ {
+				if path[j] == ']' && (j+1 == len(path) || path[j+1] == '.' || path[j+1] == '[') {
+					end = j + 1
+					break
+				}
+			}
+			segments = append(segments, path[i:end])
+			i = end
+		default:
+			end := i
+			for end < len(path) && path[end] != '.' && path[end] != '[' {
+				end++
+			}
+			segments = append(segments, path[i:end])
+			i = end
+		}
+	}
+	return segments
+}
+
+// Parent returns the path of the value holding the value at path, empty for the fields of the root
+func Parent(path string) string {
+	segments := Split(path)
+	if len(segments) == 0 {
+		return ""
+	}
+	parent := path[:len(path)-len(segments[len(segments)-1])]
+	if len(parent) > 0 && parent[len(parent)-1] == '.' {
+		parent = parent[:len(parent)-1]
+	}
+	return parent
+}
@@ -0,0 +1,94 @@
+// Package ident turns arbitrary schema field names into identifiers that are valid in generated code.
+//
+// Field names may contain characters such as '-', '.' or '/' when the parser is configured to allow them,
+// e.g. "x-request-id" or "app.kubernetes.io/name". Generators keep the original name for the wire format
+// and use the functions in this package to derive the identifier in the target language.
+package ident
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Camel converts a field name to CamelCase, treating every character that is not a letter or digit as a word separator.
+// The case of letters within a word is preserved, so "isActive" becomes "IsActive".
+// Names starting with a digit are prefixed with "X".
+func Camel(s string) string {
+	var b strings.Builder
+	nextUpper := true
+
+	for _, char := range s {
+		if !isWordChar(char) {
+			nextUpper = true
+			continue
+		}
+
+		if b.Len() == 0 && unicode.IsDigit(char) {
+			b.WriteByte('X')
+		}
+
+		if nextUpper {
+			b.WriteRune(unicode.ToUpper(char))
+			nextUpper = false
+		} else {
+			b.WriteRune(char)
+		}
+	}
+
+	if b.Len() == 0 {
+		return "X"
+	}
+
+	return b.String()
+}
+
+// Snake converts a field name to snake_case.
+// Upper case letters start a new word and every character that is not a letter or digit becomes a single '_'.
+// Names starting with a digit are prefixed with '_'.
+func Snake(s string) string {
+	var b strings.Builder
+	separate := false
+
+	for _, char := range s {
+		if !isWordChar(char) {
+			separate = b.Len() > 0
+			continue
+		}
+
+		if unicode.IsUpper(char) && b.Len() > 0 {
+			separate = true
+		}
+		if separate {
+			b.WriteByte('_')
+			separate = false
+		}
+
+		if b.Len() == 0 && unicode.IsDigit(char) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToLower(char))
+	}
+
+	if b.Len() == 0 {
+		return "_"
+	}
+
+	return b.String()
+}
+
+// IsIdentifier reports whether s is usable as is as an identifier in common target languages
+func IsIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, char := range s {
+		if !(isWordChar(char) || char == '_') || (i == 0 && unicode.IsDigit(char)) {
+			return false
+		}
+	}
+	return true
+}
+
+func isWordChar(char rune) bool {
+	return unicode.IsLetter(char) || unicode.IsDigit(char)
+}
@@ -0,0 +1,85 @@
+package ident
+
+import (
+	"testing"
+
+	"github.com/aep/yema"
+)
+
+func TestNames(t *testing.T) {
+	tests := []struct {
+		in, camel, snake string
+		ident            bool
+	}{
+		{"name", "Name", "name", true},
+		{"isActive", "IsActive", "is_active", true},
+		{"is_active", "IsActive", "is_active", true},
+		{"x-request-id", "XRequestId", "x_request_id", false},
+		{"app.kubernetes.io/name", "AppKubernetesIoName", "app_kubernetes_io_name", false},
+		{"1st", "X1st", "_1st", false},
+		{"größe", "Größe", "größe", true},
+		{"--", "X", "_", false},
+	}
+
+	for _, tt := range tests {
+		if got := Camel(tt.in); got != tt.camel {
+			t.Errorf("Camel(%q) = %q, want %q", tt.in, got, tt.camel)
+		}
+		if got := Snake(tt.in); got != tt.snake {
+			t.Errorf("Snake(%q) = %q, want %q", tt.in, got, tt.snake)
+		}
+		if got := IsIdentifier(tt.in); got != tt.ident {
+			t.Errorf("IsIdentifier(%q) = %v, want %v", tt.in, got, tt.ident)
+		}
+	}
+}
+
+func TestASCII(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"name", "name"},
+		{"größe", "grosse"},
+		{"Übersetzung", "Ubersetzung"},
+		{"café", "cafe"},
+		{"Straße", "Strasse"},
+		{"имя", "imya"},
+		{"Щука", "Shchuka"},
+		{"όνομα", "onoma"},
+		{"名前", "_u540d__u524d_"},
+		{"x-request-id", "x-request-id"},
+	}
+
+	for _, tt := range tests {
+		if got := ASCII(tt.in); got != tt.want {
+			t.Errorf("ASCII(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	if got := Camel(ASCII("名前")); got != "U540dU524d" {
+		t.Errorf("Camel(ASCII(名前)) = %q", got)
+	}
+}
+
+func TestFields(t *testing.T) {
+	st := &yema.Type{Kind: yema.Struct, Struct: &map[string]yema.Type{
+		"größe": {Kind: yema.Int},
+		"名前":    {Kind: yema.String, CodeName: "name"},
+	}}
+
+	idents, err := Fields(st, Camel, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idents["größe"] != "Grosse" || idents["名前"] != "Name" {
+		t.Errorf("unexpected identifiers %v", idents)
+	}
+
+	(*st.Struct)["grosse"] = yema.Type{Kind: yema.Int}
+	if _, err := Fields(st, Camel, true); err == nil {
+		t.Error("expected an error for fields mapping to the same identifier")
+	}
+	if _, err := Fields(st, Camel, false); err != nil {
+		t.Errorf("fields only collide when transliterated, got %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+package ident
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/aep/yema"
+	"github.com/aep/yema/internal/fieldpath"
+	"golang.org/x/text/unicode/norm"
+)
+
+// romanized spells lower case letters that do not decompose to ASCII
+var romanized = map[rune]string{
+	// Latin
+	'ß': "ss", 'æ': "ae", 'œ': "oe", 'ø': "o", 'đ': "d", 'ð': "d", 'ł': "l", 'þ': "th", 'ı': "i",
+	// Greek
+	'α': "a", 'β': "v", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i", 'θ': "th", 'ι': "i", 'κ': "k",
+	'λ': "l", 'μ': "m", 'ν': "n", 'ξ': "x", 'ο': "o", 'π': "p", 'ρ': "r", 'σ': "s", 'ς': "s", 'τ': "t",
+	'υ': "y", 'φ': "f", 'χ': "ch", 'ψ': "ps", 'ω': "o",
+	// Cyrillic
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh", 'з': "z", 'и': "i",
+	'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t",
+	'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "",
+	'э': "e", 'ю': "yu", 'я': "ya", 'і': "i", 'ї': "yi", 'є': "ye", 'ґ': "g",
+}
+
+// ASCII transliterates a field name to ASCII, so identifiers derived from it are valid and exported in every target.
+// Diacritics are dropped, Latin ligatures, Greek and Cyrillic are romanized, and any other letter or digit is spelled
+// as its code point in a word of its own, e.g. "größe" becomes "grosse" and "名前" becomes "_u540d__u524d_".
+func ASCII(s string) string {
+	var b strings.Builder
+	for _, char := range s {
+		if char <= unicode.MaxASCII {
+			b.WriteRune(char)
+			continue
+		}
+		if !isWordChar(char) {
+			b.WriteByte('_')
+			continue
+		}
+
+		lower := unicode.ToLower(char)
+		spelled, ok := romanized[lower]
+		if !ok {
+			spelled, ok = decompose(lower)
+		}
+		if !ok {
+			b.WriteString("_u" + strconv.FormatInt(int64(char), 16) + "_")
+			continue
+		}
+
+		if lower != char && spelled != "" {
+			spelled = strings.ToUpper(spelled[:1]) + spelled[1:]
+		}
+		b.WriteString(spelled)
+	}
+	return b.String()
+}
+
+// decompose spells a letter with diacritics as its base letters, if those are ASCII or romanized
+func decompose(char rune) (string, bool) {
+	var b strings.Builder
+	for _, c := range norm.NFD.String(string(char)) {
+		switch {
+		case unicode.Is(unicode.Mn, c):
+		case c <= unicode.MaxASCII:
+			b.WriteRune(c)
+		case romanized[c] != "":
+			b.WriteString(romanized[c])
+		default:
+			return "", false
+		}
+	}
+	return b.String(), b.Len() > 0
+}
+
+// Source returns the name the identifiers of a field are derived from in generated code:
+// its code name if one was declared, otherwise the field name, transliterated if requested
+func Source(name, codeName string, transliterate bool) string {
+	if codeName != "" {
+		return codeName
+	}
+	if transliterate {
+		return ASCII(name)
+	}
+	return name
+}
+
+// Fields maps the field names of a struct to identifiers, applying conv to the Source of every field.
+// It returns an error if two fields map to the same identifier.
+func Fields(t *yema.Type, conv func(string) string, transliterate bool) (map[string]string, error) {
+	idents := make(map[string]string, len(*t.Struct))
+	byIdent := make(map[string]string, len(*t.Struct))
+
+	for _, name := range t.FieldNames() {
+		fieldType := (*t.Struct)[name]
+		id := conv(Source(name, fieldType.CodeName, transliterate))
+		if other, ok := byIdent[id]; ok {
+			return nil, fmt.Errorf("fields %q and %q both map to the identifier %s, declare a $codename for one of them", other, name, id)
+		}
+		byIdent[id] = name
+		idents[name] = id
+	}
+
+	return idents, nil
+}
+
+// Types records the path in the schema each name of a generated type was taken for. Generators name nested
+// types after their parent and field, so the fields a.bC and ab.c both name a type RootABC.
+type Types map[string]string
+
+// Claim takes name for the type at path. It returns false if the name was already taken for path, so the type is
+// generated once, and an error if it was taken for another path, whose type may have a different shape.
+func (t Types) Claim(name, path string) (bool, error) {
+	if other, ok := t[name]; ok {
+		if other != path {
+			return false, fmt.Errorf("type name %s generated for both %s and %s, declare a $codename for one of them", name, fieldpath.Display(other), fieldpath.Display(path))
+		}
+		return false, nil
+	}
+	t[name] = path
+	return true, nil
+}
@@ -1,9 +1,17 @@
 package jsonschema
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/aep/yema"
+	"github.com/aep/yema/validator"
 )
 
 // SchemaVersion is the JSON Schema version to use
@@ -15,8 +23,90 @@ type JSONSchema struct {
 	Type        string                 `json:"type,omitempty"`
 	Properties  map[string]*JSONSchema `json:"properties,omitempty"`
 	Items       *JSONSchema            `json:"items,omitempty"`
+	UniqueItems bool                   `json:"uniqueItems,omitempty"`
 	Required    []string               `json:"required,omitempty"`
 	Description string                 `json:"description,omitempty"`
+	Format      string                 `json:"format,omitempty"`
+	Enum        []interface{}          `json:"enum,omitempty"`
+	Examples    []interface{}          `json:"examples,omitempty"`
+	ReadOnly    bool                   `json:"readOnly,omitempty"`
+	WriteOnly   bool                   `json:"writeOnly,omitempty"`
+
+	// AnyOf lists the schemas of the variants of a union
+	AnyOf []*JSONSchema `json:"anyOf,omitempty"`
+	// AdditionalProperties is the schema of the values of a map
+	AdditionalProperties *JSONSchema `json:"additionalProperties,omitempty"`
+	// PropertyNames restricts the keys of a map, Pattern is the regular expression a string must match
+	PropertyNames *JSONSchema `json:"propertyNames,omitempty"`
+	Pattern       string      `json:"pattern,omitempty"`
+	// Dependencies maps a property to the properties it requires, or to a schema the object must match
+	// if the property is present, used for the requires and conflicts rules of struct fields
+	Dependencies map[string]interface{} `json:"dependencies,omitempty"`
+	// Not is a schema the value must not match
+	Not *JSONSchema `json:"not,omitempty"`
+	// AllOf lists schemas the value must match as well, used for the conditions of struct fields
+	AllOf []*JSONSchema `json:"allOf,omitempty"`
+	// If selects whether the value must match Then or Else, Const is the only value a schema allows
+	If    *JSONSchema     `json:"if,omitempty"`
+	Then  *JSONSchema     `json:"then,omitempty"`
+	Else  *JSONSchema     `json:"else,omitempty"`
+	Const json.RawMessage `json:"const,omitempty"`
+
+	// order lists the keys of Properties in declaration order
+	order []string
+}
+
+// MarshalJSON encodes the schema with properties in declaration order
+func (s *JSONSchema) MarshalJSON() ([]byte, error) {
+	type plain JSONSchema
+	var props *orderedProperties
+	if len(s.Properties) != 0 {
+		props = &orderedProperties{s}
+	}
+
+	// The outer Properties field shadows the embedded one
+	return json.Marshal(struct {
+		*plain
+		Properties *orderedProperties `json:"properties,omitempty"`
+	}{(*plain)(s), props})
+}
+
+// orderedProperties encodes the properties of a schema in declaration order
+type orderedProperties struct {
+	s *JSONSchema
+}
+
+func (p *orderedProperties) MarshalJSON() ([]byte, error) {
+	names := p.s.order
+	if len(names) != len(p.s.Properties) {
+		names = make([]string, 0, len(p.s.Properties))
+		for name := range p.s.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(p.s.Properties[name])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
 }
 
 // ToJSONSchema converts an abstract Type to a JSON Schema document
@@ -25,10 +115,6 @@ func ToJSONSchema(t *yema.Type) ([]byte, error) {
 		return nil, fmt.Errorf("nil type provided")
 	}
 
-	if t.Kind != yema.Struct {
-		return nil, fmt.Errorf("expected root type to be Struct, got %v", t.Kind)
-	}
-
 	schema := &JSONSchema{
 		Schema: SchemaVersion,
 	}
@@ -42,6 +128,15 @@ func ToJSONSchema(t *yema.Type) ([]byte, error) {
 }
 
 func typeToJSONSchema(t *yema.Type, schema *JSONSchema) error {
+	if t.Example != nil {
+		schema.Examples = []interface{}{t.Example}
+	}
+	schema.Description = t.Description
+	schema.Format = jsonSchemaFormat(t)
+	schema.Enum = t.Enum
+	schema.ReadOnly = t.ReadOnly
+	schema.WriteOnly = t.WriteOnly
+
 	switch t.Kind {
 	case yema.Bool:
 		schema.Type = "boolean"
@@ -52,6 +147,30 @@ func typeToJSONSchema(t *yema.Type, schema *JSONSchema) error {
 		schema.Type = "number"
 	case yema.String, yema.Bytes:
 		schema.Type = "string"
+		if t.Format == validator.UUIDFormat {
+			pattern, err := uuidPattern(t.FormatArg)
+			if err != nil {
+				return err
+			}
+			schema.Pattern = pattern
+		}
+		if t.Format == validator.SemverFormat {
+			// JSON Schema has no format for versions, nor a way to express their ranges
+			schema.Pattern = semverPattern
+		}
+		if t.Format == validator.URIFormat {
+			schemes, err := validator.URISchemes(t.FormatArg)
+			if err != nil {
+				return err
+			}
+			if schemes != nil {
+				// Schemes may hold + and ., which are special in patterns
+				for i, scheme := range schemes {
+					schemes[i] = regexp.QuoteMeta(scheme)
+				}
+				schema.Pattern = "^(" + strings.Join(schemes, "|") + "):"
+			}
+		}
 	case yema.Array:
 		schema.Type = "array"
 		if t.Array != nil {
@@ -62,6 +181,28 @@ func typeToJSONSchema(t *yema.Type, schema *JSONSchema) error {
 			}
 			schema.Items = itemSchema
 		}
+		schema.UniqueItems = t.Unique
+	case yema.Map:
+		schema.Type = "object"
+		if t.Map == nil {
+			return fmt.Errorf("map type with nil Map field")
+		}
+		valueSchema := &JSONSchema{}
+		if err := typeToJSONSchema(t.Map, valueSchema); err != nil {
+			return err
+		}
+		schema.AdditionalProperties = valueSchema
+		if t.KeyPattern != "" {
+			schema.PropertyNames = &JSONSchema{Pattern: t.KeyPattern}
+		}
+	case yema.Union:
+		for i := range t.Union {
+			variantSchema := &JSONSchema{}
+			if err := typeToJSONSchema(&t.Union[i], variantSchema); err != nil {
+				return err
+			}
+			schema.AnyOf = append(schema.AnyOf, variantSchema)
+		}
 	case yema.Struct:
 		schema.Type = "object"
 		if t.Struct == nil {
@@ -71,7 +212,28 @@ func typeToJSONSchema(t *yema.Type, schema *JSONSchema) error {
 		schema.Properties = make(map[string]*JSONSchema)
 		schema.Required = []string{}
 
-		for fieldName, fieldType := range *t.Struct {
+		for _, fieldName := range t.FieldNames() {
+			fieldType := (*t.Struct)[fieldName]
+			// Rules apply whichever declaration a conditional field takes
+			if dependency := rules(&fieldType); dependency != nil {
+				if schema.Dependencies == nil {
+					schema.Dependencies = make(map[string]interface{})
+				}
+				schema.Dependencies[fieldName] = dependency
+			}
+
+			if fieldType.If != nil {
+				// The declaration of a conditional field depends on its siblings, so it is given by the condition alone
+				cond, err := conditionSchema(fieldName, &fieldType)
+				if err != nil {
+					return err
+				}
+				schema.AllOf = append(schema.AllOf, cond)
+				schema.Properties[fieldName] = &JSONSchema{}
+				schema.order = append(schema.order, fieldName)
+				continue
+			}
+
 			propSchema := &JSONSchema{}
 			err := typeToJSONSchema(&fieldType, propSchema)
 			if err != nil {
@@ -79,6 +241,7 @@ func typeToJSONSchema(t *yema.Type, schema *JSONSchema) error {
 			}
 
 			schema.Properties[fieldName] = propSchema
+			schema.order = append(schema.order, fieldName)
 
 			// Add to required list if not optional
 			if !fieldType.Optional {
@@ -98,3 +261,103 @@ func typeToJSONSchema(t *yema.Type, schema *JSONSchema) error {
 	return nil
 }
 
+// rules returns the dependency of a struct field with requires or conflicts rules, the list of required
+// properties if it has no conflicts, nil if it has no rules
+func rules(t *yema.Type) interface{} {
+	if len(t.Conflicts) == 0 {
+		if len(t.Requires) == 0 {
+			return nil
+		}
+		return t.Requires
+	}
+
+	dependency := &JSONSchema{Required: t.Requires, Not: &JSONSchema{}}
+	for _, other := range t.Conflicts {
+		dependency.Not.AnyOf = append(dependency.Not.AnyOf, &JSONSchema{Required: []string{other}})
+	}
+	return dependency
+}
+
+// conditionSchema returns the if/then/else schema of a struct field declared with a condition
+func conditionSchema(name string, t *yema.Type) (*JSONSchema, error) {
+	cond := &JSONSchema{If: &JSONSchema{Properties: make(map[string]*JSONSchema)}}
+	for other, value := range t.If.Equals {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("condition on field '%s' cannot be encoded as json: %w", other, err)
+		}
+		cond.If.Properties[other] = &JSONSchema{Const: data}
+		cond.If.Required = append(cond.If.Required, other)
+	}
+	sort.Strings(cond.If.Required)
+
+	base := *t
+	base.If = nil
+	var err error
+	if cond.Then, err = branchSchema(name, t.If.Then, &base); err != nil {
+		return nil, err
+	}
+	if cond.Else, err = branchSchema(name, t.If.Else, &base); err != nil {
+		return nil, err
+	}
+	return cond, nil
+}
+
+// branchSchema returns the schema of an object whose field name is declared by a branch of a condition,
+// base if the branch is nil
+func branchSchema(name string, branch, base *yema.Type) (*JSONSchema, error) {
+	if branch == nil {
+		branch = base
+	}
+	field := &JSONSchema{}
+	if err := typeToJSONSchema(branch, field); err != nil {
+		return nil, err
+	}
+	schema := &JSONSchema{Properties: map[string]*JSONSchema{name: field}}
+	if !branch.Optional {
+		schema.Required = []string{name}
+	}
+	return schema, nil
+}
+
+// semverPattern matches semantic versions, as given by the specification at semver.org
+const semverPattern = `^(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)` +
+	`(?:-((?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?` +
+	`(?:\+([0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?$`
+
+// uuidPattern returns the pattern of UUIDs restricted to versions, empty if any version is allowed
+func uuidPattern(arg interface{}) (string, error) {
+	versions, err := validator.UUIDVersions(arg)
+	if err != nil || versions == nil {
+		return "", err
+	}
+	digits := ""
+	for _, v := range versions {
+		digits += strconv.Itoa(v)
+	}
+	const hex = "[0-9a-fA-F]"
+	return "^" + hex + "{8}-" + hex + "{4}-[" + digits + "]" + hex + "{3}-[89abAB]" + hex + "{3}-" + hex + "{12}$", nil
+}
+
+// jsonSchemaFormat returns the format of a type in JSON Schema. Timestamps in other layouts than RFC 3339
+// have no format unless the layout matches the JSON Schema date or time, durations only in ISO 8601.
+func jsonSchemaFormat(t *yema.Type) string {
+	if t.Format == validator.DurationFormat {
+		if t.FormatArg == nil || t.FormatArg == validator.ISO8601Duration {
+			return "duration"
+		}
+		return ""
+	}
+	if t.Format != validator.DateTimeFormat || t.FormatArg == nil {
+		return t.Format
+	}
+	switch t.FormatArg {
+	case time.RFC3339:
+		return "date-time"
+	case time.DateOnly:
+		return "date"
+	case time.TimeOnly:
+		return "time"
+	}
+	return ""
+}
@@ -0,0 +1,85 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aep/yema/parser"
+)
+
+// generate returns the JSON Schema of a schema in YAML, decoded into generic values
+func generate(t *testing.T, src string) (map[string]interface{}, string) {
+	t.Helper()
+	schema, err := parser.FromYAML([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := ToJSONSchema(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	return doc, string(out)
+}
+
+// assertJSON compares a decoded value with the JSON want
+func assertJSON(t *testing.T, name string, got interface{}, want string) {
+	t.Helper()
+	var expected interface{}
+	if err := json.Unmarshal([]byte(want), &expected); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, expected) {
+		data, _ := json.Marshal(got)
+		t.Errorf("%s: expected %s, got %s", name, want, data)
+	}
+}
+
+func TestToJSONSchemaRules(t *testing.T) {
+	doc, out := generate(t, `cert?: {$type: string, $requires: [key]}
+key?: string
+token?: {$type: string, $requires: [user], $conflicts: [password, cert]}
+user?: string
+password?: string
+`)
+
+	assertJSON(t, "dependencies", doc["dependencies"], `{
+		"cert": ["key"],
+		"token": {"required": ["user"], "not": {"anyOf": [{"required": ["password"]}, {"required": ["cert"]}]}}
+	}`)
+	if _, ok := doc["required"]; ok {
+		t.Errorf("unexpected required fields in:\n%s", out)
+	}
+
+	// Properties keep the order of their declaration
+	if strings.Index(out, `"token"`) > strings.Index(out, `"password"`) {
+		t.Errorf("expected properties in declaration order:\n%s", out)
+	}
+}
+
+func TestToJSONSchemaConditions(t *testing.T) {
+	doc, _ := generate(t, `mode: string
+port:
+  $type: string
+  $if: {mode: server}
+  $then: int
+  $else: bool
+  $requires: [mode]
+`)
+
+	assertJSON(t, "required", doc["required"], `["mode"]`)
+	assertJSON(t, "properties", doc["properties"], `{"mode": {"type": "string"}, "port": {}}`)
+	assertJSON(t, "allOf", doc["allOf"], `[{
+		"if": {"properties": {"mode": {"const": "server"}}, "required": ["mode"]},
+		"then": {"properties": {"port": {"type": "integer"}}, "required": ["port"]},
+		"else": {"properties": {"port": {"type": "boolean"}}, "required": ["port"]}
+	}]`)
+
+	// The rules of a conditional field apply whichever declaration it takes
+	assertJSON(t, "dependencies", doc["dependencies"], `{"port": ["mode"]}`)
+}
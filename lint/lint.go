@@ -0,0 +1,257 @@
+// Package lint flags schema constructs that generate poor or invalid code for selected targets,
+// so problems surface when reviewing the schema rather than when building the generated code.
+package lint
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/aep/yema"
+	"github.com/aep/yema/internal/fieldpath"
+	"github.com/aep/yema/internal/ident"
+	"gopkg.in/yaml.v3"
+)
+
+// Config selects the rules to run, typically loaded per project from a yaml file
+type Config struct {
+	// Targets are the generators the schema is used with, e.g. golang, rust, typescript or sql.
+	// Rules specific to other targets are skipped. Empty runs the rules for all targets.
+	Targets []string `yaml:"targets"`
+	// Disable lists the names of rules not to run
+	Disable []string `yaml:"disable"`
+	// MaxNesting is the depth of nested anonymous structs reported by the nesting rule, defaults to 3
+	MaxNesting int `yaml:"maxNesting"`
+}
+
+// LoadConfig reads a lint configuration file
+func LoadConfig(path string) (Config, error) {
+	var cfg Config
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed parsing lint config: %w", err)
+	}
+	return cfg, nil
+}
+
+// Finding is a single problem reported by a rule
+type Finding struct {
+	// Rule is the name of the rule reporting the problem
+	Rule string
+	// Path is the location of the offending field, e.g. "addresses[].type"
+	Path string
+	// Message describes the problem
+	Message string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s (%s)", fieldpath.Display(f.Path), f.Message, f.Rule)
+}
+
+// Rule is a single lint check
+type Rule struct {
+	// Name identifies the rule in findings and in Config.Disable
+	Name string
+	// Targets are the generators the rule is relevant for, empty means all
+	Targets []string
+	// Check inspects a single node of the schema
+	Check func(n *Node, cfg Config) []Finding
+}
+
+// Node is a type in the schema along with its location
+type Node struct {
+	// Path is the location of the type, e.g. "settings.limits"
+	Path string
+	// Name is the field name the type is declared with, empty for the root, array items, map values and union variants
+	Name string
+	// Depth is the number of structs enclosing the type
+	Depth int
+	Type  *yema.Type
+}
+
+// Rules are all available rules
+var Rules = []Rule{
+	{Name: "rust-keyword", Targets: []string{"rust"}, Check: checkRustKeyword},
+	{Name: "typescript-int64", Targets: []string{"typescript"}, Check: checkTypeScriptInt64},
+	{Name: "identifier-collision", Targets: []string{"golang", "rust", "typescript"}, Check: checkIdentifierCollision},
+	{Name: "nesting", Targets: []string{"sql"}, Check: checkNesting},
+	{Name: "conditional-type", Targets: []string{"golang", "rust", "typescript"}, Check: checkConditionalType},
+}
+
+// Lint runs the rules selected by cfg on every type in the schema
+func Lint(t *yema.Type, cfg Config) []Finding {
+	if cfg.MaxNesting <= 0 {
+		cfg.MaxNesting = 3
+	}
+
+	var rules []Rule
+	for _, r := range Rules {
+		if enabled(r, cfg) {
+			rules = append(rules, r)
+		}
+	}
+
+	var findings []Finding
+	walk(&Node{Type: t}, func(n *Node) {
+		for _, r := range rules {
+			findings = append(findings, r.Check(n, cfg)...)
+		}
+	})
+
+	return findings
+}
+
+func enabled(r Rule, cfg Config) bool {
+	for _, name := range cfg.Disable {
+		if name == r.Name {
+			return false
+		}
+	}
+	if len(cfg.Targets) == 0 || len(r.Targets) == 0 {
+		return true
+	}
+	for _, target := range cfg.Targets {
+		for _, rt := range r.Targets {
+			if target == rt {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// walk calls fn for n and every type nested in it, in declaration order
+func walk(n *Node, fn func(*Node)) {
+	fn(n)
+
+	t := n.Type
+	switch t.Kind {
+	case yema.Array:
+		if t.Array != nil {
+			walk(&Node{Path: n.Path + "[]", Depth: n.Depth, Type: t.Array}, fn)
+		}
+	case yema.Union:
+		for i := range t.Union {
+			walk(&Node{Path: n.Path, Depth: n.Depth, Type: &t.Union[i]}, fn)
+		}
+	case yema.Map:
+		if t.Map != nil {
+			walk(&Node{Path: n.Path + "[]", Depth: n.Depth, Type: t.Map}, fn)
+		}
+	case yema.Struct:
+		if t.Struct == nil {
+			return
+		}
+		for _, name := range t.FieldNames() {
+			fieldType := (*t.Struct)[name]
+			walk(&Node{Path: fieldpath.Join(n.Path, name), Name: name, Depth: n.Depth + 1, Type: &fieldType}, fn)
+		}
+	}
+}
+
+// rustKeywords are the strict and reserved keywords of Rust that cannot be used as field names
+var rustKeywords = map[string]bool{
+	"as": true, "break": true, "const": true, "continue": true, "crate": true, "else": true, "enum": true,
+	"extern": true, "false": true, "fn": true, "for": true, "if": true, "impl": true, "in": true, "let": true,
+	"loop": true, "match": true, "mod": true, "move": true, "mut": true, "pub": true, "ref": true, "return": true,
+	"self": true, "Self": true, "static": true, "struct": true, "super": true, "trait": true, "true": true,
+	"type": true, "unsafe": true, "use": true, "where": true, "while": true, "async": true, "await": true,
+	"dyn": true, "abstract": true, "become": true, "box": true, "do": true, "final": true, "macro": true,
+	"override": true, "priv": true, "typeof": true, "unsized": true, "virtual": true, "yield": true, "try": true,
+}
+
+func checkRustKeyword(n *Node, cfg Config) []Finding {
+	if n.Name == "" {
+		return nil
+	}
+	id := ident.Snake(ident.Source(n.Name, n.Type.CodeName, false))
+	if !rustKeywords[id] {
+		return nil
+	}
+	return []Finding{{
+		Rule:    "rust-keyword",
+		Path:    n.Path,
+		Message: fmt.Sprintf("field name %q is a Rust keyword", id),
+	}}
+}
+
+func checkTypeScriptInt64(n *Node, cfg Config) []Finding {
+	switch n.Type.Kind {
+	case yema.Int64, yema.Uint64:
+	default:
+		return nil
+	}
+	return []Finding{{
+		Rule:    "typescript-int64",
+		Path:    n.Path,
+		Message: "64-bit integers lose precision above 2^53 in a TypeScript number",
+	}}
+}
+
+func checkIdentifierCollision(n *Node, cfg Config) []Finding {
+	t := n.Type
+	if t.Kind != yema.Struct || t.Struct == nil {
+		return nil
+	}
+
+	byIdent := make(map[string][]string)
+	for _, name := range t.FieldNames() {
+		id := ident.Camel(ident.Source(name, (*t.Struct)[name].CodeName, false))
+		byIdent[id] = append(byIdent[id], name)
+	}
+
+	ids := make([]string, 0, len(byIdent))
+	for id := range byIdent {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var findings []Finding
+	for _, id := range ids {
+		names := byIdent[id]
+		if len(names) < 2 {
+			continue
+		}
+		findings = append(findings, Finding{
+			Rule:    "identifier-collision",
+			Path:    n.Path,
+			Message: fmt.Sprintf("fields %q all map to the identifier %s", names, id),
+		})
+	}
+
+	return findings
+}
+
+func checkNesting(n *Node, cfg Config) []Finding {
+	if n.Type.Kind != yema.Struct || n.Depth != cfg.MaxNesting+1 {
+		return nil
+	}
+	return []Finding{{
+		Rule:    "nesting",
+		Path:    n.Path,
+		Message: fmt.Sprintf("anonymous struct nested deeper than %d levels", cfg.MaxNesting),
+	}}
+}
+
+func checkConditionalType(n *Node, cfg Config) []Finding {
+	cond := n.Type.If
+	if cond == nil {
+		return nil
+	}
+
+	var findings []Finding
+	for _, branch := range []*yema.Type{cond.Then, cond.Else} {
+		if branch == nil || branch.Kind == n.Type.Kind {
+			continue
+		}
+		findings = append(findings, Finding{
+			Rule:    "conditional-type",
+			Path:    n.Path,
+			Message: fmt.Sprintf("field may hold a %v depending on its siblings, generated code declares it as %v", branch.Kind, n.Type.Kind),
+		})
+	}
+	return findings
+}
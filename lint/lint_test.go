@@ -0,0 +1,53 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/aep/yema/parser"
+)
+
+func TestLint(t *testing.T) {
+	schema, err := parser.FromYAMLWithOptions([]byte(`
+type: string
+id: int64
+a-b: string
+a_b: string
+l1:
+  l2:
+    l3:
+      l4:
+        x: int
+port:
+  $type: uint16
+  $if:   {type: unix}
+  $then: string
+`), parser.Options{AllowAnyFieldName: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		cfg  Config
+		want []string
+	}{
+		{"all targets", Config{}, []string{"identifier-collision ", "rust-keyword type", "typescript-int64 id", "nesting l1.l2.l3.l4", "conditional-type port"}},
+		{"rust only", Config{Targets: []string{"rust"}}, []string{"identifier-collision ", "rust-keyword type", "conditional-type port"}},
+		{"disabled rule", Config{Targets: []string{"rust"}, Disable: []string{"rust-keyword"}}, []string{"identifier-collision ", "conditional-type port"}},
+		{"deeper nesting allowed", Config{Targets: []string{"sql"}, MaxNesting: 4}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := Lint(schema, tt.cfg)
+			if len(findings) != len(tt.want) {
+				t.Fatalf("got findings %v, want %v", findings, tt.want)
+			}
+			for i, f := range findings {
+				if got := f.Rule + " " + f.Path; got != tt.want[i] {
+					t.Errorf("finding %d = %q, want %q", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
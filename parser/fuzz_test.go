@@ -0,0 +1,29 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aep/yema/validator"
+)
+
+// FuzzFromYAML checks that no schema, however malformed, makes the parser or the validator panic
+func FuzzFromYAML(f *testing.F) {
+	f.Add("name: string\nage: int32?\n", "name: a\nage: 1\n")
+	f.Add("items: [string]\n", "items: [a, 1]\n")
+	f.Add("$defs:\n  node:\n    next: $ref:node?\nroot: $ref:node\n", "root: {next: {next: {}}}\n")
+	f.Add("labels: {$map: string, $keys: \"^[a-z]+$\"}\n", "labels: {A: 1}\n")
+	f.Add("id: {$union: [string, {x: int}]}\n", "id: {x: a}\n")
+	f.Add("env: {$type: string, $enum: [dev, prod]}\n", "env: prd\n")
+	f.Add("a: &a {b: *a}\n", "a: &x [*x]\n")
+	f.Add("- string\n", "- 1\n")
+
+	f.Fuzz(func(t *testing.T, schema, document string) {
+		typ, err := FromYAML([]byte(schema))
+		if err != nil {
+			return
+		}
+		validator.ValidateYAML(strings.NewReader(document), typ)
+		validator.ValidateAt(map[string]interface{}{}, typ, document)
+	})
+}
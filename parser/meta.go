@@ -0,0 +1,329 @@
+package parser
+
+import (
+	_ "embed"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/aep/yema/internal/fieldpath"
+)
+
+// MetaSchema is a JSON Schema describing what a valid yema schema file looks like.
+// It is meant for editors and external tooling, the parser itself enforces the same rules in Check.
+// The meta-schema only describes the default field name rules, see Options.AllowAnyFieldName.
+//
+//go:embed meta.schema.json
+var MetaSchema []byte
+
+// Check validates a raw schema document against the meta-schema before interpretation.
+// It reports every violation found, each with the path of the offending value.
+func Check(schema map[string]interface{}, opts Options) []error {
+	var errs []error
+	opts.defs, _ = schema[DefsKey].(map[string]interface{})
+	checkFields(schema, "", true, opts, &errs)
+	return errs
+}
+
+// CheckValue is like Check for a schema document of any root type, such as an array
+func CheckValue(schema interface{}, opts Options) []error {
+	if m, ok := schema.(map[string]interface{}); ok && !isDeclaration(m) {
+		return Check(m, opts)
+	}
+
+	var errs []error
+	checkValue(schema, "", opts, &errs)
+	return errs
+}
+
+func checkFields(fields map[string]interface{}, path string, root bool, opts Options, errs *[]error) {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if root && key == TestsKey {
+			continue
+		}
+		if root && key == DefsKey {
+			checkDefs(fields[key], opts, errs)
+			continue
+		}
+
+		fieldName := strings.TrimSuffix(key, "?")
+		fieldPath := fieldpath.Join(path, fieldName)
+		if !opts.validFieldName(fieldName) {
+			*errs = append(*errs, fmt.Errorf("invalid field name %q at %s", fieldName, fieldpath.Display(path)))
+			continue
+		}
+
+		checkValue(fields[key], fieldPath, opts, errs)
+	}
+}
+
+func checkValue(value interface{}, path string, opts Options, errs *[]error) {
+	if err := opts.enter(fieldpath.Display(path)); err != nil {
+		*errs = append(*errs, err)
+		return
+	}
+
+	switch v := value.(type) {
+	case string:
+		if _, ok := kindNames[v]; !ok {
+			*errs = append(*errs, fmt.Errorf("unknown type %q at %s", v, fieldpath.Display(path)))
+		}
+	case []interface{}:
+		if len(v) != 1 {
+			*errs = append(*errs, fmt.Errorf("expected exactly one array item type at %s, got %d", fieldpath.Display(path), len(v)))
+			return
+		}
+		checkValue(v[0], path+"[]", opts, errs)
+	case map[string]interface{}:
+		if _, ok := v[TypeKey]; ok {
+			checkLongForm(v, path, opts, errs)
+			return
+		}
+		if _, ok := v[RefKey]; ok {
+			checkRef(v, path, opts, errs)
+			return
+		}
+		if _, ok := v[MapKey]; ok {
+			checkMap(v, path, opts, errs)
+			return
+		}
+		if _, ok := v[UnionKey]; ok {
+			checkUnion(v, path, opts, errs)
+			return
+		}
+		checkFields(v, path, false, opts, errs)
+	default:
+		*errs = append(*errs, fmt.Errorf("expected type string, list or mapping at %s, got %s", fieldpath.Display(path), describe(value)))
+	}
+}
+
+func checkDefs(value interface{}, opts Options, errs *[]error) {
+	defs, ok := value.(map[string]interface{})
+	if !ok {
+		*errs = append(*errs, fmt.Errorf("expected %s to be a mapping, got %s", DefsKey, describe(value)))
+		return
+	}
+
+	names := make([]string, 0, len(defs))
+	for name := range defs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !isCodeName(name) {
+			*errs = append(*errs, fmt.Errorf("invalid type name %q in %s", name, DefsKey))
+			continue
+		}
+		checkValue(defs[name], DefsKey+"."+name, opts, errs)
+	}
+}
+
+func checkRef(v map[string]interface{}, path string, opts Options, errs *[]error) {
+	for key := range v {
+		if key != RefKey {
+			*errs = append(*errs, fmt.Errorf("unexpected key %q next to %s at %s", key, RefKey, fieldpath.Display(path)))
+		}
+	}
+
+	ref, ok := v[RefKey].(string)
+	if !ok {
+		*errs = append(*errs, fmt.Errorf("expected %s to be a string at %s, got %s", RefKey, fieldpath.Display(path), describe(v[RefKey])))
+		return
+	}
+	if !strings.HasPrefix(ref, RefPrefix) {
+		*errs = append(*errs, fmt.Errorf("reference %q at %s is not local, bundle the schema first", ref, fieldpath.Display(path)))
+		return
+	}
+	if _, ok := opts.defs[strings.TrimPrefix(ref, RefPrefix)]; !ok {
+		*errs = append(*errs, fmt.Errorf("unknown reference %q at %s", ref, fieldpath.Display(path)))
+	}
+}
+
+// UnusedDefs returns the sorted names of the entries of $defs no reference reaches from the types of the
+// schema document, directly or through other entries
+func UnusedDefs(schema interface{}) []string {
+	root, ok := schema.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	defs, _ := root[DefsKey].(map[string]interface{})
+	if len(defs) == 0 {
+		return nil
+	}
+
+	used := make(map[string]bool)
+	for key, value := range root {
+		if key != DefsKey && key != TestsKey {
+			markRefs(value, defs, used)
+		}
+	}
+
+	var unused []string
+	for name := range defs {
+		if !used[name] {
+			unused = append(unused, name)
+		}
+	}
+	sort.Strings(unused)
+	return unused
+}
+
+// markRefs marks the entries of defs referenced by value and, once, those referenced by the entries themselves.
+// Examples are values rather than types and are skipped.
+func markRefs(value interface{}, defs map[string]interface{}, used map[string]bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if ref, ok := v[RefKey].(string); ok && strings.HasPrefix(ref, RefPrefix) {
+			name := strings.TrimPrefix(ref, RefPrefix)
+			if def, ok := defs[name]; ok && !used[name] {
+				used[name] = true
+				markRefs(def, defs, used)
+			}
+		}
+		for key, child := range v {
+			if key != ExampleKey {
+				markRefs(child, defs, used)
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			markRefs(child, defs, used)
+		}
+	}
+}
+
+func checkMap(v map[string]interface{}, path string, opts Options, errs *[]error) {
+	keys := make([]string, 0, len(v))
+	for key := range v {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		switch key {
+		case MapKey:
+			checkValue(v[key], path+"[]", opts, errs)
+		case KeysKey:
+			pattern, ok := v[key].(string)
+			if !ok {
+				*errs = append(*errs, fmt.Errorf("expected %s to be a string at %s, got %s", KeysKey, fieldpath.Display(path), describe(v[key])))
+			} else if _, err := regexp.Compile(pattern); err != nil {
+				*errs = append(*errs, fmt.Errorf("invalid %s pattern at %s: %v", KeysKey, fieldpath.Display(path), err))
+			}
+		default:
+			*errs = append(*errs, fmt.Errorf("unknown key %q in map declaration at %s", key, fieldpath.Display(path)))
+		}
+	}
+}
+
+func checkUnion(v map[string]interface{}, path string, opts Options, errs *[]error) {
+	for key := range v {
+		if key != UnionKey {
+			*errs = append(*errs, fmt.Errorf("unexpected key %q next to %s at %s", key, UnionKey, fieldpath.Display(path)))
+		}
+	}
+
+	variants, ok := v[UnionKey].([]interface{})
+	if !ok || len(variants) < 2 {
+		*errs = append(*errs, fmt.Errorf("expected %s to list at least two types at %s, got %s", UnionKey, fieldpath.Display(path), describe(v[UnionKey])))
+		return
+	}
+	for _, variant := range variants {
+		checkValue(variant, path, opts, errs)
+	}
+}
+
+func checkLongForm(v map[string]interface{}, path string, opts Options, errs *[]error) {
+	keys := make([]string, 0, len(v))
+	for key := range v {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		switch key {
+		case TypeKey:
+			checkValue(v[key], path, opts, errs)
+		case ExampleKey:
+			// Examples are checked against the type while parsing
+		case DescriptionKey:
+			if _, ok := v[key].(string); !ok {
+				*errs = append(*errs, fmt.Errorf("expected %s to be a string at %s, got %s", DescriptionKey, fieldpath.Display(path), describe(v[key])))
+			}
+		case CodeNameKey:
+			if codeName, ok := v[key].(string); !ok {
+				*errs = append(*errs, fmt.Errorf("expected %s to be a string at %s, got %s", CodeNameKey, fieldpath.Display(path), describe(v[key])))
+			} else if !isCodeName(codeName) {
+				*errs = append(*errs, fmt.Errorf("%s %q is not an ASCII identifier at %s", CodeNameKey, codeName, fieldpath.Display(path)))
+			}
+		case FormatKey:
+			// Arguments are checked against the format while parsing
+			if _, _, ok := formatName(v[key]); !ok {
+				*errs = append(*errs, fmt.Errorf("expected %s to be a name or a mapping of a name to its argument at %s, got %s", FormatKey, fieldpath.Display(path), describe(v[key])))
+			}
+		case LengthKey:
+			// The kind of the type is checked while parsing
+			if _, ok := parseLength(v[key]); !ok {
+				*errs = append(*errs, fmt.Errorf("expected %s to be a positive number or a mapping of min, max or both to bounds at %s, got %s", LengthKey, fieldpath.Display(path), describe(v[key])))
+			}
+		case EnumKey:
+			// Enum values are checked against the type while parsing
+			if enum, ok := v[key].([]interface{}); !ok || len(enum) == 0 {
+				*errs = append(*errs, fmt.Errorf("expected %s to be a non-empty list at %s, got %s", EnumKey, fieldpath.Display(path), describe(v[key])))
+			}
+		case RequiresKey, ConflictsKey:
+			// The listed fields are checked against their struct while parsing
+			if _, ok := fieldNames(v[key]); !ok {
+				*errs = append(*errs, fmt.Errorf("expected %s to be a non-empty list of field names at %s, got %s", key, fieldpath.Display(path), describe(v[key])))
+			}
+		case UniqueKey:
+			if _, ok := v[key].(bool); !ok {
+				*errs = append(*errs, fmt.Errorf("expected %s to be a boolean at %s, got %s", UniqueKey, fieldpath.Display(path), describe(v[key])))
+			}
+		case IfKey:
+			// The compared fields and values are checked against their struct while parsing
+			if equals, ok := v[key].(map[string]interface{}); !ok || len(equals) == 0 {
+				*errs = append(*errs, fmt.Errorf("expected %s to be a non-empty mapping of field names to values at %s, got %s", IfKey, fieldpath.Display(path), describe(v[key])))
+			}
+		case ThenKey, ElseKey:
+			if _, ok := v[IfKey]; !ok {
+				*errs = append(*errs, fmt.Errorf("%s without %s at %s", key, IfKey, fieldpath.Display(path)))
+			}
+			if v[key] != RequiredBranch && v[key] != OptionalBranch {
+				checkValue(v[key], path, opts, errs)
+			}
+		case ReadOnlyKey, WriteOnlyKey:
+			if _, ok := v[key].(bool); !ok {
+				*errs = append(*errs, fmt.Errorf("expected %s to be a boolean at %s, got %s", key, fieldpath.Display(path), describe(v[key])))
+			}
+		default:
+			*errs = append(*errs, fmt.Errorf("unknown key %q in type declaration at %s", key, fieldpath.Display(path)))
+		}
+	}
+
+	if v[ReadOnlyKey] == true && v[WriteOnlyKey] == true {
+		*errs = append(*errs, fmt.Errorf("type at %s cannot be both %s and %s", fieldpath.Display(path), ReadOnlyKey, WriteOnlyKey))
+	}
+}
+
+// describe names the kind of a raw yaml or json value for error messages
+func describe(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case int, int64, uint64, float64:
+		return "number"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
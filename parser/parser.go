@@ -1,13 +1,191 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
 	"github.com/aep/yema"
+	"github.com/aep/yema/validator"
+	"math"
+	"regexp"
+	"sort"
 	"strings"
 	"unicode"
 	"unicode/utf8"
 )
 
+// kindNames maps the type names usable in a schema to their kind
+var kindNames = map[string]yema.Kind{
+	"bool":    yema.Bool,
+	"int":     yema.Int,
+	"int8":    yema.Int8,
+	"int16":   yema.Int16,
+	"int32":   yema.Int32,
+	"int64":   yema.Int64,
+	"uint":    yema.Uint,
+	"uint8":   yema.Uint8,
+	"uint16":  yema.Uint16,
+	"uint32":  yema.Uint32,
+	"uint64":  yema.Uint64,
+	"float32": yema.Float32,
+	"float64": yema.Float64,
+	"string":  yema.String,
+	"bytes":   yema.Bytes,
+}
+
+// TestsKey is the root key holding test cases embedded in a schema file, see package schematest
+const TestsKey = "$tests"
+
+// Keys of the long-form syntax. A mapping with a $type key declares a type along with its attributes
+// instead of a struct:
+//
+//	age:
+//	  $type: int
+//	  $example: 42
+//
+// $description documents the type in generated code and JSON Schema.
+// $codename declares the name generators derive identifiers from instead of the field name,
+// for field names that do not map to good identifiers, e.g. non-ASCII names.
+// $readonly and $writeonly restrict a field to responses or requests, see package transform.
+// $format names a custom validator of the value, see validator.RegisterFormat,
+// or the encoding of bytes given as strings: base64, base64url or hex.
+// Built-in formats take an argument by mapping their name to it, such as the layout of timestamps:
+// {date-time: "2006-01-02"}.
+// $length bounds the number of bytes of bytes, counted once decoded from their encoding. It is either the exact
+// number, such as 32 for a SHA-256 checksum, or a mapping of min, max or both to the inclusive bounds.
+// $enum lists the values allowed for a string or number.
+// $requires and $conflicts list sibling fields that must, or must not, be present whenever the field is,
+// e.g. a certificate requiring its key or two mutually exclusive ways to authenticate.
+// $unique requires the items of an array to differ from each other, compared by value.
+// $if makes the declaration of a field depend on the values of sibling fields: while all of them hold the
+// given values the field is declared by $then, otherwise by $else. A branch is either a type or one of
+// required and optional, which keep the type of the field and only change whether it may be missing:
+//
+//	port?:
+//	  $type: uint16
+//	  $if:   {protocol: tcp}
+//	  $then: required
+const (
+	TypeKey        = "$type"
+	ExampleKey     = "$example"
+	DescriptionKey = "$description"
+	CodeNameKey    = "$codename"
+	ReadOnlyKey    = "$readonly"
+	WriteOnlyKey   = "$writeonly"
+	FormatKey      = "$format"
+	LengthKey      = "$length"
+	EnumKey        = "$enum"
+	RequiresKey    = "$requires"
+	ConflictsKey   = "$conflicts"
+	UniqueKey      = "$unique"
+	IfKey          = "$if"
+	ThenKey        = "$then"
+	ElseKey        = "$else"
+)
+
+// Branches of $then and $else that keep the type of the field
+const (
+	RequiredBranch = "required"
+	OptionalBranch = "optional"
+)
+
+// Keys of a map declaration. A mapping with a $map key declares a map with string keys and values of the given type,
+// $keys optionally restricts the keys to those matching a regular expression:
+//
+//	labels:
+//	  $map: string
+//	  $keys: "^[a-z]+$"
+const (
+	MapKey  = "$map"
+	KeysKey = "$keys"
+)
+
+// UnionKey declares a union of at least two types, a value matches the union if it matches any of them.
+// The variants are tried in order:
+//
+//	id:
+//	  $union: [string, int64]
+const UnionKey = "$union"
+
+// DefsKey is the root key declaring named types. A mapping holding only RefKey uses a named type
+// wherever a type is expected, references are expanded in place and cannot be recursive:
+//
+//	$defs:
+//	  address:
+//	    street: string
+//	home:
+//	  $ref: "#/$defs/address"
+const (
+	DefsKey = "$defs"
+	RefKey  = "$ref"
+	// RefPrefix is the prefix of references to named types declared in the same schema
+	RefPrefix = "#/$defs/"
+)
+
+// Options holds configuration options for parsing schemas
+type Options struct {
+	// AllowAnyFieldName permits any non-empty field name, such as "x-request-id" or "app.kubernetes.io/name",
+	// instead of only identifiers. Names starting with $ stay reserved for schema directives.
+	// Generators derive safe identifiers from such names where the target language requires it.
+	AllowAnyFieldName bool
+	// MaxDepth bounds the nesting of types in a schema, 0 uses DefaultMaxDepth
+	MaxDepth int
+	// MaxTypes bounds the number of types a schema expands to, guarding against references that
+	// multiply with every level, 0 uses DefaultMaxTypes
+	MaxTypes int
+
+	// depth is the nesting of the type being parsed or checked
+	depth int
+	// types counts the types parsed so far
+	types *int
+	// defs are the named types of the schema being parsed
+	defs map[string]interface{}
+	// expanding are the names of the references being expanded, to reject recursion
+	expanding []string
+}
+
+// Default resource limits of the parser, see Options
+const (
+	DefaultMaxDepth = 1000
+	DefaultMaxTypes = 100000
+)
+
+// enter descends one level into a schema, failing once MaxDepth is exceeded
+func (o *Options) enter(path string) error {
+	o.depth++
+	limit := o.MaxDepth
+	if limit <= 0 {
+		limit = DefaultMaxDepth
+	}
+	if o.depth > limit {
+		return fmt.Errorf("%w: schema nests deeper than %d levels at %s", yema.ErrLimitExceeded, limit, path)
+	}
+	return nil
+}
+
+// count records a parsed type, failing once MaxTypes is exceeded
+func (o *Options) count(fieldName string) error {
+	if o.types == nil {
+		o.types = new(int)
+	}
+	*o.types++
+	limit := o.MaxTypes
+	if limit <= 0 {
+		limit = DefaultMaxTypes
+	}
+	if *o.types > limit {
+		return fmt.Errorf("%w: schema expands to more than %d types at field '%s'", yema.ErrLimitExceeded, limit, fieldName)
+	}
+	return nil
+}
+
+// validFieldName reports whether name is permitted as a field name under these options
+func (o Options) validFieldName(name string) bool {
+	if o.AllowAnyFieldName {
+		return name != "" && !strings.HasPrefix(name, "$")
+	}
+	return isValidFieldName(name)
+}
+
 func isValidFieldName(name string) bool {
 	if name == "" {
 		return false
@@ -29,10 +207,29 @@ func isValidFieldName(name string) bool {
 	return true
 }
 
+// From interprets a raw schema document, as decoded from yaml or json, into a yema.Type.
+// The document is checked against the meta-schema first, see Check.
+// Maps hold no order, so Type.Order is left empty and fields are in the order of their names,
+// see FromYAML to keep the declared order.
 func From(schema map[string]interface{}) (*yema.Type, error) {
+	return FromWithOptions(schema, Options{})
+}
+
+// FromWithOptions is like From with custom options
+func FromWithOptions(schema map[string]interface{}, opts Options) (*yema.Type, error) {
+	if errs := Check(schema, opts); len(errs) != 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	opts.defs, _ = schema[DefsKey].(map[string]interface{})
+	opts.types = new(int)
 	structType := make(map[string]yema.Type)
 
 	for key, value := range schema {
+		if key == TestsKey || key == DefsKey {
+			continue
+		}
+
 		isOptional := false
 		fieldName := key
 		if strings.HasSuffix(key, "?") {
@@ -40,11 +237,11 @@ func From(schema map[string]interface{}) (*yema.Type, error) {
 			fieldName = key[:len(key)-1]
 		}
 
-		if !isValidFieldName(fieldName) {
+		if !opts.validFieldName(fieldName) {
 			return nil, fmt.Errorf("invalid field name: %q", fieldName)
 		}
 
-		fieldType, err := parseValueToType(fieldName, value, isOptional)
+		fieldType, err := parseValueToType(fieldName, value, isOptional, opts)
 		if err != nil {
 			return nil, err
 		}
@@ -52,48 +249,58 @@ func From(schema map[string]interface{}) (*yema.Type, error) {
 		structType[fieldName] = fieldType
 	}
 
+	if err := checkRules(structType); err != nil {
+		return nil, err
+	}
+
 	return &yema.Type{
 		Kind:   yema.Struct,
 		Struct: &structType,
 	}, nil
 }
 
-func parseValueToType(fieldName string, value interface{}, isOptional bool) (yema.Type, error) {
+// FromValue interprets a raw schema document of any root type into a yema.Type.
+// A mapping that is not a long-form declaration is a struct as with From, anything else declares
+// the root type itself, e.g. [string] or a list of structs. Like From, it leaves Type.Order empty.
+func FromValue(schema interface{}) (*yema.Type, error) {
+	return FromValueWithOptions(schema, Options{})
+}
+
+// FromValueWithOptions is like FromValue with custom options
+func FromValueWithOptions(schema interface{}, opts Options) (*yema.Type, error) {
+	switch v := schema.(type) {
+	case nil:
+		return FromWithOptions(nil, opts)
+	case map[string]interface{}:
+		if !isDeclaration(v) {
+			return FromWithOptions(v, opts)
+		}
+	}
+
+	if errs := CheckValue(schema, opts); len(errs) != 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	opts.types = new(int)
+	t, err := parseValueToType("root", schema, false, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func parseValueToType(fieldName string, value interface{}, isOptional bool, opts Options) (yema.Type, error) {
+	if err := opts.enter("field '" + fieldName + "'"); err != nil {
+		return yema.Type{}, err
+	}
+	if err := opts.count(fieldName); err != nil {
+		return yema.Type{}, err
+	}
+
 	switch v := value.(type) {
 	case string:
-		var kind yema.Kind
-		switch v {
-		case "bool":
-			kind = yema.Bool
-		case "int":
-			kind = yema.Int
-		case "int8":
-			kind = yema.Int8
-		case "int16":
-			kind = yema.Int16
-		case "int32":
-			kind = yema.Int32
-		case "int64":
-			kind = yema.Int64
-		case "uint":
-			kind = yema.Uint
-		case "uint8":
-			kind = yema.Uint8
-		case "uint16":
-			kind = yema.Uint16
-		case "uint32":
-			kind = yema.Uint32
-		case "uint64":
-			kind = yema.Uint64
-		case "float32":
-			kind = yema.Float32
-		case "float64":
-			kind = yema.Float64
-		case "string":
-			kind = yema.String
-		case "bytes":
-			kind = yema.Bytes
-		default:
+		kind, ok := kindNames[v]
+		if !ok {
 			return yema.Type{}, fmt.Errorf("failed parsing field '%s', expected type, not: %s", fieldName, v)
 		}
 		return yema.Type{
@@ -111,7 +318,7 @@ func parseValueToType(fieldName string, value interface{}, isOptional bool) (yem
 		}
 
 		// Parse the array item type
-		itemType, err := parseValueToType(fieldName, v[0], false)
+		itemType, err := parseValueToType(fieldName, v[0], false, opts)
 		if err != nil {
 			return yema.Type{}, err
 		}
@@ -123,6 +330,19 @@ func parseValueToType(fieldName string, value interface{}, isOptional bool) (yem
 		}, nil
 
 	case map[string]interface{}:
+		if _, ok := v[TypeKey]; ok {
+			return parseLongForm(fieldName, v, isOptional, opts)
+		}
+		if ref, ok := v[RefKey]; ok {
+			return parseRef(fieldName, ref, isOptional, opts)
+		}
+		if _, ok := v[MapKey]; ok {
+			return parseMap(fieldName, v, isOptional, opts)
+		}
+		if _, ok := v[UnionKey]; ok {
+			return parseUnion(fieldName, v, isOptional, opts)
+		}
+
 		nestedStruct := make(map[string]yema.Type)
 
 		for k, val := range v {
@@ -133,11 +353,11 @@ func parseValueToType(fieldName string, value interface{}, isOptional bool) (yem
 				nestedFieldName = k[:len(k)-1]
 			}
 
-			if !isValidFieldName(nestedFieldName) {
+			if !opts.validFieldName(nestedFieldName) {
 				return yema.Type{}, fmt.Errorf("invalid field name: %q", nestedFieldName)
 			}
 
-			nestedType, err := parseValueToType(nestedFieldName, val, nestedIsOptional)
+			nestedType, err := parseValueToType(nestedFieldName, val, nestedIsOptional, opts)
 			if err != nil {
 				return yema.Type{}, err
 			}
@@ -145,6 +365,10 @@ func parseValueToType(fieldName string, value interface{}, isOptional bool) (yem
 			nestedStruct[nestedFieldName] = nestedType
 		}
 
+		if err := checkRules(nestedStruct); err != nil {
+			return yema.Type{}, err
+		}
+
 		return yema.Type{
 			Kind:     yema.Struct,
 			Optional: isOptional,
@@ -154,3 +378,455 @@ func parseValueToType(fieldName string, value interface{}, isOptional bool) (yem
 		return yema.Type{}, fmt.Errorf("failed parsing field '%s', expected type, not: %s", fieldName, v)
 	}
 }
+
+// parseRef expands a reference to a named type, recording its name in Ref
+func parseRef(fieldName string, ref interface{}, isOptional bool, opts Options) (yema.Type, error) {
+	refString, _ := ref.(string)
+	name := strings.TrimPrefix(refString, RefPrefix)
+	def, ok := opts.defs[name]
+	if !ok || !strings.HasPrefix(refString, RefPrefix) {
+		return yema.Type{}, fmt.Errorf("failed parsing field '%s', unknown reference %v", fieldName, ref)
+	}
+
+	for _, expanding := range opts.expanding {
+		if expanding == name {
+			return yema.Type{}, fmt.Errorf("failed parsing field '%s', recursive reference %q", fieldName, refString)
+		}
+	}
+	opts.expanding = append(opts.expanding[:len(opts.expanding):len(opts.expanding)], name)
+
+	t, err := parseValueToType(fieldName, def, isOptional, opts)
+	if err != nil {
+		return yema.Type{}, err
+	}
+	t.Ref = name
+	return t, nil
+}
+
+// parseMap parses a map declaration
+func parseMap(fieldName string, v map[string]interface{}, isOptional bool, opts Options) (yema.Type, error) {
+	values, err := parseValueToType(fieldName, v[MapKey], false, opts)
+	if err != nil {
+		return yema.Type{}, err
+	}
+
+	t := yema.Type{
+		Kind:     yema.Map,
+		Optional: isOptional,
+		Map:      &values,
+	}
+
+	for key, value := range v {
+		switch key {
+		case MapKey:
+		case KeysKey:
+			pattern, ok := value.(string)
+			if !ok {
+				return yema.Type{}, fmt.Errorf("failed parsing field '%s', %s must be a string", fieldName, KeysKey)
+			}
+			if _, err := regexp.Compile(pattern); err != nil {
+				return yema.Type{}, fmt.Errorf("failed parsing field '%s', invalid %s: %w", fieldName, KeysKey, err)
+			}
+			t.KeyPattern = pattern
+		default:
+			return yema.Type{}, fmt.Errorf("failed parsing field '%s', unknown key %q", fieldName, key)
+		}
+	}
+
+	return t, nil
+}
+
+// parseUnion parses a union declaration
+func parseUnion(fieldName string, v map[string]interface{}, isOptional bool, opts Options) (yema.Type, error) {
+	for key := range v {
+		if key != UnionKey {
+			return yema.Type{}, fmt.Errorf("failed parsing field '%s', unexpected key %q next to %s", fieldName, key, UnionKey)
+		}
+	}
+
+	variants, ok := v[UnionKey].([]interface{})
+	if !ok || len(variants) < 2 {
+		return yema.Type{}, fmt.Errorf("failed parsing field '%s', %s must list at least two types", fieldName, UnionKey)
+	}
+
+	t := yema.Type{
+		Kind:     yema.Union,
+		Optional: isOptional,
+		Union:    make([]yema.Type, 0, len(variants)),
+	}
+	for _, variant := range variants {
+		variantType, err := parseValueToType(fieldName, variant, false, opts)
+		if err != nil {
+			return yema.Type{}, err
+		}
+		t.Union = append(t.Union, variantType)
+	}
+
+	return t, nil
+}
+
+// isDeclaration reports whether a mapping declares a type other than a struct
+func isDeclaration(v map[string]interface{}) bool {
+	_, isLongForm := v[TypeKey]
+	_, isMap := v[MapKey]
+	_, isUnion := v[UnionKey]
+	return isLongForm || isMap || isUnion
+}
+
+// parseLongForm parses a type declared with the long-form syntax
+func parseLongForm(fieldName string, v map[string]interface{}, isOptional bool, opts Options) (yema.Type, error) {
+	t, err := parseValueToType(fieldName, v[TypeKey], isOptional, opts)
+	if err != nil {
+		return yema.Type{}, err
+	}
+
+	for key, value := range v {
+		switch key {
+		case TypeKey:
+		case ExampleKey:
+			t.Example = value
+		case DescriptionKey:
+			description, ok := value.(string)
+			if !ok {
+				return yema.Type{}, fmt.Errorf("failed parsing field '%s', %s must be a string", fieldName, DescriptionKey)
+			}
+			t.Description = description
+		case CodeNameKey:
+			codeName, ok := value.(string)
+			if !ok || !isCodeName(codeName) {
+				return yema.Type{}, fmt.Errorf("failed parsing field '%s', %s must be an ASCII identifier", fieldName, CodeNameKey)
+			}
+			t.CodeName = codeName
+		case FormatKey:
+			format, arg, ok := formatName(value)
+			if !ok {
+				return yema.Type{}, fmt.Errorf("failed parsing field '%s', %s must be a name or a mapping of a name to its argument", fieldName, FormatKey)
+			}
+			t.Format, t.FormatArg = format, arg
+		case LengthKey:
+			length, ok := parseLength(value)
+			if !ok {
+				return yema.Type{}, fmt.Errorf("failed parsing field '%s', %s must be a positive number or a mapping of min, max or both to bounds", fieldName, LengthKey)
+			}
+			if t.Kind != yema.Bytes {
+				return yema.Type{}, fmt.Errorf("failed parsing field '%s', %s is only supported for bytes", fieldName, LengthKey)
+			}
+			t.Length = length
+		case EnumKey:
+			enum, ok := value.([]interface{})
+			if !ok || len(enum) == 0 {
+				return yema.Type{}, fmt.Errorf("failed parsing field '%s', %s must be a non-empty list", fieldName, EnumKey)
+			}
+			t.Enum = enum
+		case RequiresKey, ConflictsKey:
+			names, ok := fieldNames(value)
+			if !ok {
+				return yema.Type{}, fmt.Errorf("failed parsing field '%s', %s must be a non-empty list of field names", fieldName, key)
+			}
+			if key == RequiresKey {
+				t.Requires = names
+			} else {
+				t.Conflicts = names
+			}
+		case UniqueKey:
+			unique, ok := value.(bool)
+			if !ok {
+				return yema.Type{}, fmt.Errorf("failed parsing field '%s', %s must be a boolean", fieldName, UniqueKey)
+			}
+			if unique && t.Kind != yema.Array {
+				return yema.Type{}, fmt.Errorf("failed parsing field '%s', %s is only supported for arrays", fieldName, UniqueKey)
+			}
+			t.Unique = unique
+		case IfKey, ThenKey, ElseKey:
+			// Branches replace the complete declaration, so they are parsed once it is known
+		case ReadOnlyKey, WriteOnlyKey:
+			flag, ok := value.(bool)
+			if !ok {
+				return yema.Type{}, fmt.Errorf("failed parsing field '%s', %s must be a boolean", fieldName, key)
+			}
+			if key == ReadOnlyKey {
+				t.ReadOnly = flag
+			} else {
+				t.WriteOnly = flag
+			}
+		default:
+			return yema.Type{}, fmt.Errorf("failed parsing field '%s', unknown key %q", fieldName, key)
+		}
+	}
+
+	if t.ReadOnly && t.WriteOnly {
+		return yema.Type{}, fmt.Errorf("failed parsing field '%s', cannot be both %s and %s", fieldName, ReadOnlyKey, WriteOnlyKey)
+	}
+
+	if t.Format != "" {
+		if err := validator.CheckFormat(t.Format, t.FormatArg, t.Kind); err != nil {
+			return yema.Type{}, fmt.Errorf("failed parsing field '%s', %w", fieldName, err)
+		}
+	}
+
+	if t.Enum != nil {
+		if err := checkEnum(fieldName, t); err != nil {
+			return yema.Type{}, err
+		}
+	}
+
+	if t.Example != nil {
+		if err := checkExample(fieldName, t); err != nil {
+			return yema.Type{}, err
+		}
+	}
+
+	if err := parseCondition(fieldName, v, &t, opts); err != nil {
+		return yema.Type{}, err
+	}
+
+	return t, nil
+}
+
+// parseCondition parses the $if, $then and $else keys of a long-form declaration into t
+func parseCondition(fieldName string, v map[string]interface{}, t *yema.Type, opts Options) error {
+	thenValue, hasThen := v[ThenKey]
+	elseValue, hasElse := v[ElseKey]
+	ifValue, hasIf := v[IfKey]
+	if !hasIf {
+		if hasThen || hasElse {
+			return fmt.Errorf("failed parsing field '%s', %s and %s require %s", fieldName, ThenKey, ElseKey, IfKey)
+		}
+		return nil
+	}
+
+	equals, ok := ifValue.(map[string]interface{})
+	if !ok || len(equals) == 0 {
+		return fmt.Errorf("failed parsing field '%s', %s must be a non-empty mapping of field names to values", fieldName, IfKey)
+	}
+	if !hasThen && !hasElse {
+		return fmt.Errorf("failed parsing field '%s', %s requires %s or %s", fieldName, IfKey, ThenKey, ElseKey)
+	}
+
+	cond := &yema.Condition{Equals: equals}
+	var err error
+	if hasThen {
+		if cond.Then, err = parseBranch(fieldName, ThenKey, thenValue, *t, opts); err != nil {
+			return err
+		}
+	}
+	if hasElse {
+		if cond.Else, err = parseBranch(fieldName, ElseKey, elseValue, *t, opts); err != nil {
+			return err
+		}
+	}
+	t.If = cond
+	return nil
+}
+
+// parseBranch parses the declaration of a field given by $then or $else
+func parseBranch(fieldName, key string, value interface{}, field yema.Type, opts Options) (*yema.Type, error) {
+	switch value {
+	case RequiredBranch:
+		field.Optional = false
+		return &field, nil
+	case OptionalBranch:
+		field.Optional = true
+		return &field, nil
+	}
+
+	branch, err := parseValueToType(fieldName, value, field.Optional, opts)
+	if err != nil {
+		return nil, err
+	}
+	if branch.If != nil || branch.Requires != nil || branch.Conflicts != nil || branch.CodeName != "" || branch.ReadOnly || branch.WriteOnly {
+		return nil, fmt.Errorf("failed parsing field '%s', %s declares a type, attributes of the field belong next to %s", fieldName, key, IfKey)
+	}
+	return &branch, nil
+}
+
+// formatName returns the name of the format declared by $format and its argument, either given as a name
+// or as a mapping of the name to the argument such as {date-time: "2006-01-02"}, false if value is neither
+func formatName(value interface{}) (string, interface{}, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, nil, v != ""
+	case map[string]interface{}:
+		if len(v) != 1 {
+			return "", nil, false
+		}
+		for name, arg := range v {
+			return name, arg, name != "" && arg != nil
+		}
+	}
+	return "", nil, false
+}
+
+// parseLength returns the bounds declared by $length, false if value is neither a positive number
+// nor a mapping of min, max or both to bounds with min not above max
+func parseLength(value interface{}) (*yema.Length, bool) {
+	if n, ok := lengthBound(value); ok {
+		return &yema.Length{Min: n, Max: n}, n > 0
+	}
+
+	bounds, ok := value.(map[string]interface{})
+	if !ok || len(bounds) == 0 {
+		return nil, false
+	}
+	length := &yema.Length{Max: -1}
+	for key, bound := range bounds {
+		n, ok := lengthBound(bound)
+		if !ok {
+			return nil, false
+		}
+		switch key {
+		case "min":
+			length.Min = n
+		case "max":
+			length.Max = n
+		default:
+			return nil, false
+		}
+	}
+	return length, length.Max < 0 || length.Min <= length.Max
+}
+
+// lengthBound returns a bound of $length, false if value is not a non-negative integer
+func lengthBound(value interface{}) (int, bool) {
+	switch n := value.(type) {
+	case int:
+		return n, n >= 0 && n <= math.MaxInt32
+	case int64:
+		return int(n), n >= 0 && n <= math.MaxInt32
+	case uint64:
+		return int(n), n <= math.MaxInt32
+	case float64:
+		return int(n), n >= 0 && n <= math.MaxInt32 && n == math.Trunc(n)
+	}
+	return 0, false
+}
+
+// fieldNames returns the field names listed by $requires or $conflicts, false if value is not a non-empty list of them
+func fieldNames(value interface{}) ([]string, bool) {
+	list, ok := value.([]interface{})
+	if !ok || len(list) == 0 {
+		return nil, false
+	}
+	names := make([]string, len(list))
+	for i, item := range list {
+		name, ok := item.(string)
+		if !ok || name == "" {
+			return nil, false
+		}
+		names[i] = name
+	}
+	return names, true
+}
+
+// checkRules checks that the fields of a struct only require, conflict with or depend on other fields of the same struct
+func checkRules(fields map[string]yema.Type) error {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		field := fields[name]
+		for _, rule := range []struct {
+			key    string
+			others []string
+		}{{RequiresKey, field.Requires}, {ConflictsKey, field.Conflicts}} {
+			for _, other := range rule.others {
+				if other == name {
+					return fmt.Errorf("failed parsing field '%s', %s cannot refer to the field itself", name, rule.key)
+				}
+				if _, ok := fields[other]; !ok {
+					return fmt.Errorf("failed parsing field '%s', %s refers to unknown field '%s'", name, rule.key, other)
+				}
+			}
+		}
+		if field.If != nil {
+			if err := checkCondition(fields, name, field.If); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkCondition checks that a condition compares sibling strings, numbers or booleans with values valid for them
+func checkCondition(fields map[string]yema.Type, name string, cond *yema.Condition) error {
+	others := make([]string, 0, len(cond.Equals))
+	for other := range cond.Equals {
+		others = append(others, other)
+	}
+	sort.Strings(others)
+
+	for _, other := range others {
+		if other == name {
+			return fmt.Errorf("failed parsing field '%s', %s cannot refer to the field itself", name, IfKey)
+		}
+		otherType, ok := fields[other]
+		if !ok {
+			return fmt.Errorf("failed parsing field '%s', %s refers to unknown field '%s'", name, IfKey, other)
+		}
+		switch otherType.Kind {
+		case yema.Bool, yema.String,
+			yema.Int, yema.Int8, yema.Int16, yema.Int32, yema.Int64,
+			yema.Uint, yema.Uint8, yema.Uint16, yema.Uint32, yema.Uint64,
+			yema.Float32, yema.Float64:
+		default:
+			return fmt.Errorf("failed parsing field '%s', %s can only compare booleans, strings and numbers, field '%s' is a %s", name, IfKey, other, otherType.Kind)
+		}
+		valueType := yema.Type{Kind: otherType.Kind, Enum: otherType.Enum}
+		if errs := validator.Validate(cond.Equals[other], &valueType); len(errs) != 0 {
+			return fmt.Errorf("failed parsing field '%s', invalid %s value of field '%s': %w", name, IfKey, other, errors.Join(errs...))
+		}
+	}
+	return nil
+}
+
+// isCodeName reports whether name is an ASCII identifier, usable as is in every target language
+func isCodeName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '_' || i > 0 && r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// checkEnum validates the allowed values of a type against the type itself, only strings and numbers can be enums
+func checkEnum(fieldName string, t yema.Type) error {
+	switch t.Kind {
+	case yema.String,
+		yema.Int, yema.Int8, yema.Int16, yema.Int32, yema.Int64,
+		yema.Uint, yema.Uint8, yema.Uint16, yema.Uint32, yema.Uint64,
+		yema.Float32, yema.Float64:
+	default:
+		return fmt.Errorf("failed parsing field '%s', %s is only supported for strings and numbers", fieldName, EnumKey)
+	}
+
+	valueType := yema.Type{Kind: t.Kind}
+	for i, value := range t.Enum {
+		if errs := validator.Validate(value, &valueType); len(errs) != 0 {
+			return fmt.Errorf("failed parsing field '%s', invalid %s value %d: %w", fieldName, EnumKey, i, errors.Join(errs...))
+		}
+	}
+
+	return nil
+}
+
+// checkExample validates the example of a type against the type itself
+func checkExample(fieldName string, t yema.Type) error {
+	t.Optional = false
+	schema := &yema.Type{
+		Kind:   yema.Struct,
+		Struct: &map[string]yema.Type{fieldName: t},
+	}
+
+	if errs := validator.Validate(map[string]interface{}{fieldName: t.Example}, schema); len(errs) != 0 {
+		return fmt.Errorf("invalid example: %w", errors.Join(errs...))
+	}
+
+	return nil
+}
@@ -0,0 +1,574 @@
+package parser
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/aep/yema"
+	"gopkg.in/yaml.v3"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		wantErr []string
+	}{
+		{
+			name:   "valid schema",
+			source: "name: string\ntags?: [string]\naddress: {street: string}\n",
+		},
+		{
+			name:    "null type",
+			source:  "foo:\n  bar:\n",
+			wantErr: []string{"expected type string, list or mapping at foo.bar, got null"},
+		},
+		{
+			name:    "unknown type in array",
+			source:  "foo: [strin]\n",
+			wantErr: []string{`unknown type "strin" at foo[]`},
+		},
+		{
+			name:    "multiple errors",
+			source:  "a: 1\nb: [int, int]\n\"c-d\": string\n",
+			wantErr: []string{"at a, got number", "exactly one array item type at b", `invalid field name "c-d" at root`},
+		},
+		{
+			name:    "invalid length",
+			source:  "a:\n  $type: bytes\n  $length: {min: x}\n",
+			wantErr: []string{"expected $length to be a positive number or a mapping of min, max or both to bounds at a, got map"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var schema map[string]interface{}
+			if err := yaml.Unmarshal([]byte(tt.source), &schema); err != nil {
+				t.Fatal(err)
+			}
+
+			errs := Check(schema, Options{})
+			if len(errs) != len(tt.wantErr) {
+				t.Fatalf("got errors %v, want %v", errs, tt.wantErr)
+			}
+			for i, want := range tt.wantErr {
+				if !strings.Contains(errs[i].Error(), want) {
+					t.Errorf("error %q does not contain %q", errs[i], want)
+				}
+			}
+
+			if _, err := From(schema); (err != nil) != (len(tt.wantErr) != 0) {
+				t.Errorf("From() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestMetaSchemaScalars(t *testing.T) {
+	var meta struct {
+		Definitions struct {
+			Scalar struct {
+				Enum []string `json:"enum"`
+			} `json:"scalar"`
+		} `json:"definitions"`
+	}
+	if err := json.Unmarshal(MetaSchema, &meta); err != nil {
+		t.Fatalf("meta-schema is not valid json: %v", err)
+	}
+
+	var names []string
+	for name := range kindNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	got := append([]string(nil), meta.Definitions.Scalar.Enum...)
+	sort.Strings(got)
+
+	if strings.Join(got, ",") != strings.Join(names, ",") {
+		t.Errorf("meta-schema scalars %v do not match parser type names %v", got, names)
+	}
+}
+
+func TestMetaSchemaLength(t *testing.T) {
+	var meta struct {
+		Definitions struct {
+			Declaration struct {
+				Properties map[string]json.RawMessage `json:"properties"`
+			} `json:"declaration"`
+		} `json:"definitions"`
+	}
+	if err := json.Unmarshal(MetaSchema, &meta); err != nil {
+		t.Fatalf("meta-schema is not valid json: %v", err)
+	}
+	if _, ok := meta.Definitions.Declaration.Properties[LengthKey]; !ok {
+		t.Errorf("meta-schema does not declare %s", LengthKey)
+	}
+}
+
+func TestFromYAMLOrder(t *testing.T) {
+	schema, err := FromYAML([]byte("zeta: string\nalpha?:\n  second: int\n  first: int\nitems: [{b: bool, a: bool}]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.Join(schema.FieldNames(), ","); got != "zeta,alpha,items" {
+		t.Errorf("root order = %s", got)
+	}
+
+	alpha := (*schema.Struct)["alpha"]
+	if got := strings.Join(alpha.FieldNames(), ","); got != "second,first" {
+		t.Errorf("nested order = %s", got)
+	}
+
+	items := (*schema.Struct)["items"]
+	if got := strings.Join(items.Array.FieldNames(), ","); got != "b,a" {
+		t.Errorf("array item order = %s", got)
+	}
+
+	// The declarations of a conditional field keep their order as well
+	schema, err = FromYAML([]byte(`mode: string
+server:
+  $type: {z: int, y: int}
+  $if: {mode: a}
+  $then: {d: int, c: int}
+  $else: optional
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := (*schema.Struct)["server"]
+	if got := strings.Join(server.If.Then.FieldNames(), ","); got != "d,c" {
+		t.Errorf("then order = %s", got)
+	}
+	if got := strings.Join(server.If.Else.FieldNames(), ","); got != "z,y" {
+		t.Errorf("else order = %s", got)
+	}
+	if got := strings.Join(server.FieldNames(), ","); got != "z,y" {
+		t.Errorf("conditional field order = %s", got)
+	}
+}
+
+func TestAllowAnyFieldName(t *testing.T) {
+	source := []byte("x-request-id: string\nmetadata:\n  app.kubernetes.io/name?: string\n")
+
+	if _, err := FromYAML(source); err == nil {
+		t.Errorf("expected error for non-identifier field names")
+	}
+
+	schema, err := FromYAMLWithOptions(source, Options{AllowAnyFieldName: true})
+	if err != nil {
+		t.Fatalf("FromYAMLWithOptions failed: %v", err)
+	}
+
+	metadata := (*schema.Struct)["metadata"]
+	if !(*metadata.Struct)["app.kubernetes.io/name"].Optional {
+		t.Errorf("expected optional field app.kubernetes.io/name")
+	}
+
+	if _, err := FromYAMLWithOptions([]byte("$foo: string\n"), Options{AllowAnyFieldName: true}); err == nil {
+		t.Errorf("expected error for reserved field name")
+	}
+}
+
+func TestLongForm(t *testing.T) {
+	schema, err := FromYAML([]byte("age?:\n  $type: int\n  $example: 42\nuser:\n  $type:\n    b: string\n    a: string\n"))
+	if err != nil {
+		t.Fatalf("FromYAML failed: %v", err)
+	}
+
+	age := (*schema.Struct)["age"]
+	if age.Kind != yema.Int || !age.Optional || age.Example != 42 {
+		t.Errorf("unexpected type for age: %+v", age)
+	}
+
+	user := (*schema.Struct)["user"]
+	if got := strings.Join(user.FieldNames(), ","); got != "b,a" {
+		t.Errorf("long-form struct order = %s", got)
+	}
+
+	schema, err = FromYAML([]byte("名前:\n  $type: string\n  $codename: name\n"))
+	if err != nil {
+		t.Fatalf("FromYAML failed: %v", err)
+	}
+	if got := (*schema.Struct)["名前"].CodeName; got != "name" {
+		t.Errorf("code name = %q, want name", got)
+	}
+
+	schema, err = FromYAML([]byte("id:\n  $type: string\n  $readonly: true\npassword:\n  $type: string\n  $writeonly: true\n"))
+	if err != nil {
+		t.Fatalf("FromYAML failed: %v", err)
+	}
+	if id, password := (*schema.Struct)["id"], (*schema.Struct)["password"]; !id.ReadOnly || id.WriteOnly || password.ReadOnly || !password.WriteOnly {
+		t.Errorf("unexpected access modifiers id=%+v password=%+v", id, password)
+	}
+
+	schema, err = FromYAML([]byte("id:\n  $type: string\n  $format: ulid\n"))
+	if err != nil {
+		t.Fatalf("FromYAML failed: %v", err)
+	}
+	if got := (*schema.Struct)["id"].Format; got != "ulid" {
+		t.Errorf("format = %q, want ulid", got)
+	}
+
+	schema, err = FromYAML([]byte("day:\n  $type: string\n  $format: {date-time: \"2006-01-02\"}\n"))
+	if err != nil {
+		t.Fatalf("FromYAML failed: %v", err)
+	}
+	if day := (*schema.Struct)["day"]; day.Format != "date-time" || day.FormatArg != "2006-01-02" {
+		t.Errorf("format = %q %v, want date-time 2006-01-02", day.Format, day.FormatArg)
+	}
+
+	schema, err = FromYAML([]byte("age:\n  $type: int\n  $description: Age in years\n"))
+	if err != nil {
+		t.Fatalf("FromYAML failed: %v", err)
+	}
+	if got := (*schema.Struct)["age"].Description; got != "Age in years" {
+		t.Errorf("description = %q, want Age in years", got)
+	}
+
+	schema, err = FromYAML([]byte("timeout:\n  $type: string\n  $format: {duration: go}\n"))
+	if err != nil {
+		t.Fatalf("FromYAML failed: %v", err)
+	}
+	if timeout := (*schema.Struct)["timeout"]; timeout.Format != "duration" || timeout.FormatArg != "go" {
+		t.Errorf("format = %q %v, want duration go", timeout.Format, timeout.FormatArg)
+	}
+
+	invalid := []string{
+		"age:\n  $type: int\n  $example: old\n",
+		"age:\n  $type: int\n  $codename: größe\n",
+		"age:\n  $type: int\n  $codename: 1\n",
+		"age:\n  $type: int\n  $description: [years]\n",
+		"age:\n  $type: int\n  $bogus: 1\n",
+		"age:\n  $type: int\n  $readonly: yes please\n",
+		"age:\n  $type: int\n  $format: \"\"\n",
+		"age:\n  $type: int\n  $format: date-time\n",
+		"age:\n  $type: string\n  $format: {date-time: 2006-01-02}\n",
+		"age:\n  $type: string\n  $format: {ulid: 1}\n",
+		"age:\n  $type: string\n  $format: {duration: bogus}\n",
+		"age:\n  $type: string\n  $format: {uuid: [9]}\n",
+		"age:\n  $type: string\n  $format: {uri: [\"https://\"]}\n",
+		"age:\n  $type: bytes\n  $format: uuid\n",
+		"age:\n  $type: string\n  $format: {date-time: \"2006-01-02\", ulid: 1}\n",
+		"age:\n  $type: int\n  $readonly: true\n  $writeonly: true\n",
+		"age:\n  $type: int\n  other: int\n",
+	}
+	for _, src := range invalid {
+		if _, err := FromYAML([]byte(src)); err == nil {
+			t.Errorf("expected error for %q", src)
+		}
+	}
+}
+
+func TestFromYAMLRoots(t *testing.T) {
+	list, err := FromYAML([]byte("- b: string\n  a?: int\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list.Kind != yema.Array || list.Array.Kind != yema.Struct {
+		t.Fatalf("unexpected array root %+v", list)
+	}
+	if got := strings.Join(list.Array.FieldNames(), ","); got != "b,a" {
+		t.Errorf("array root item order = %s", got)
+	}
+
+	scalar, err := FromYAML([]byte("string\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scalar.Kind != yema.String {
+		t.Errorf("unexpected scalar root %+v", scalar)
+	}
+
+	long, err := FromYAML([]byte("$type: [int]\n$example: [1, 2]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if long.Kind != yema.Array || long.Example == nil {
+		t.Errorf("unexpected long-form root %+v", long)
+	}
+
+	for _, src := range []string{"[string, int]\n", "strin\n", "42\n"} {
+		if _, err := FromYAML([]byte(src)); err == nil {
+			t.Errorf("expected error for %q", src)
+		}
+	}
+}
+
+func TestRefs(t *testing.T) {
+	schema, err := FromYAML([]byte(`
+$defs:
+  address:
+    street: string
+    city: string
+  addresses: [{$ref: "#/$defs/address"}]
+home:
+  $ref: "#/$defs/address"
+others?:
+  $ref: "#/$defs/addresses"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.Join(schema.FieldNames(), ","); got != "home,others" {
+		t.Errorf("root fields = %s", got)
+	}
+	home := (*schema.Struct)["home"]
+	if got := strings.Join(home.FieldNames(), ","); home.Kind != yema.Struct || got != "street,city" {
+		t.Errorf("unexpected referenced type %+v", home)
+	}
+	others := (*schema.Struct)["others"]
+	if !others.Optional || others.Kind != yema.Array || others.Array.Kind != yema.Struct {
+		t.Errorf("unexpected referenced array %+v", others)
+	}
+
+	invalid := []string{
+		"a:\n  $ref: \"#/$defs/missing\"\n",
+		"a:\n  $ref: other.yaml\n",
+		"$defs:\n  node:\n    next?:\n      $ref: \"#/$defs/node\"\na:\n  $ref: \"#/$defs/node\"\n",
+		"$defs:\n  x: string\na:\n  $ref: \"#/$defs/x\"\n  $example: 1\n",
+	}
+	for _, src := range invalid {
+		if _, err := FromYAML([]byte(src)); err == nil {
+			t.Errorf("expected error for %q", src)
+		}
+	}
+}
+
+func TestLimits(t *testing.T) {
+	deep := strings.Repeat("[", 20) + "string" + strings.Repeat("]", 20)
+	if _, err := FromYAMLWithOptions([]byte("a: "+deep+"\n"), Options{MaxDepth: 10}); !errors.Is(err, yema.ErrLimitExceeded) {
+		t.Errorf("expected depth limit error, got %v", err)
+	}
+	if _, err := FromYAMLWithOptions([]byte("a: "+deep+"\n"), Options{}); err != nil {
+		t.Errorf("unexpected error within the default limits: %v", err)
+	}
+
+	// Every definition uses the previous one twice, doubling the expanded types with every level
+	var b strings.Builder
+	b.WriteString("$defs:\n  t0: string\n")
+	for i := 1; i <= 20; i++ {
+		fmt.Fprintf(&b, "  t%d: {a: {$ref: \"#/$defs/t%d\"}, b: {$ref: \"#/$defs/t%d\"}}\n", i, i-1, i-1)
+	}
+	b.WriteString("root: {$ref: \"#/$defs/t20\"}\n")
+	if _, err := FromYAML([]byte(b.String())); !errors.Is(err, yema.ErrLimitExceeded) {
+		t.Errorf("expected type limit error, got %v", err)
+	}
+}
+
+func TestMaps(t *testing.T) {
+	schema, err := FromYAML([]byte("labels:\n  $map: string\n  $keys: \"^[a-z]+$\"\nscores?:\n  $map: {b: int, a: int}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	labels := (*schema.Struct)["labels"]
+	if labels.Kind != yema.Map || labels.Map.Kind != yema.String || labels.KeyPattern != "^[a-z]+$" {
+		t.Errorf("unexpected labels type %+v", labels)
+	}
+	scores := (*schema.Struct)["scores"]
+	if scores.Kind != yema.Map || !scores.Optional || scores.Map.Kind != yema.Struct {
+		t.Fatalf("unexpected scores type %+v", scores)
+	}
+	if got := strings.Join(scores.Map.FieldNames(), ","); got != "b,a" {
+		t.Errorf("map value field order = %s", got)
+	}
+
+	root, err := FromYAML([]byte("$map: [int]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root.Kind != yema.Map || root.Map.Kind != yema.Array {
+		t.Errorf("unexpected map root %+v", root)
+	}
+
+	long, err := FromYAML([]byte("tags:\n  $type: {$map: string}\n  $example: {a: b}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tags := (*long.Struct)["tags"]; tags.Kind != yema.Map || tags.Example == nil {
+		t.Errorf("unexpected long-form map %+v", tags)
+	}
+
+	for _, src := range []string{
+		"a:\n  $map: strin\n",
+		"a:\n  $map: string\n  $keys: \"(\"\n",
+		"a:\n  $map: string\n  $keys: 1\n",
+		"a:\n  $map: string\n  $other: 1\n",
+		"a:\n  $type: {$map: string}\n  $example: {a: 1}\n",
+	} {
+		if _, err := FromYAML([]byte(src)); err == nil {
+			t.Errorf("expected error for %q", src)
+		}
+	}
+}
+
+func TestEnum(t *testing.T) {
+	schema, err := FromYAML([]byte("env:\n  $type: string\n  $enum: [dev, prod]\n  $example: dev\nlevel:\n  $type: uint8\n  $enum: [1, 2]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env := (*schema.Struct)["env"]; len(env.Enum) != 2 || env.Enum[1] != "prod" {
+		t.Errorf("unexpected env type %+v", env)
+	}
+
+	for _, src := range []string{
+		"a:\n  $type: string\n  $enum: []\n",
+		"a:\n  $type: string\n  $enum: dev\n",
+		"a:\n  $type: string\n  $enum: [dev, 1]\n",
+		"a:\n  $type: uint8\n  $enum: [1, 300]\n",
+		"a:\n  $type: [string]\n  $enum: [a]\n",
+		"a:\n  $type: string\n  $enum: [dev, prod]\n  $example: test\n",
+	} {
+		if _, err := FromYAML([]byte(src)); err == nil {
+			t.Errorf("expected error for %q", src)
+		}
+	}
+}
+
+func TestRules(t *testing.T) {
+	schema, err := FromYAML([]byte("cert?:\n  $type: string\n  $requires: [key]\nkey?: string\ntls:\n  token?:\n    $type: string\n    $conflicts: [password]\n  password?: string\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert := (*schema.Struct)["cert"]; !reflect.DeepEqual(cert.Requires, []string{"key"}) {
+		t.Errorf("unexpected cert type %+v", cert)
+	}
+	if token := (*(*schema.Struct)["tls"].Struct)["token"]; !reflect.DeepEqual(token.Conflicts, []string{"password"}) {
+		t.Errorf("unexpected token type %+v", token)
+	}
+
+	for _, src := range []string{
+		"a:\n  $type: string\n  $requires: []\n",
+		"a:\n  $type: string\n  $requires: b\n",
+		"a:\n  $type: string\n  $requires: [1]\n",
+		"a:\n  $type: string\n  $requires: [b]\n",
+		"a:\n  $type: string\n  $conflicts: [a]\n",
+		"a:\n  b:\n    $type: string\n    $conflicts: [a]\n",
+	} {
+		if _, err := FromYAML([]byte(src)); err == nil {
+			t.Errorf("expected error for %q", src)
+		}
+	}
+}
+
+func TestUnique(t *testing.T) {
+	schema, err := FromYAML([]byte("tags:\n  $type: [string]\n  $unique: true\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tags := (*schema.Struct)["tags"]; !tags.Unique {
+		t.Errorf("unexpected tags type %+v", tags)
+	}
+
+	for _, src := range []string{
+		"a:\n  $type: [string]\n  $unique: yes please\n",
+		"a:\n  $type: string\n  $unique: true\n",
+	} {
+		if _, err := FromYAML([]byte(src)); err == nil {
+			t.Errorf("expected error for %q", src)
+		}
+	}
+}
+
+func TestLength(t *testing.T) {
+	schema, err := FromYAML([]byte("sum:\n  $type: bytes\n  $length: 32\nnonce:\n  $type: bytes\n  $length: {min: 12}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum := (*schema.Struct)["sum"]; !reflect.DeepEqual(sum.Length, &yema.Length{Min: 32, Max: 32}) {
+		t.Errorf("unexpected sum type %+v", sum)
+	}
+	if nonce := (*schema.Struct)["nonce"]; !reflect.DeepEqual(nonce.Length, &yema.Length{Min: 12, Max: -1}) {
+		t.Errorf("unexpected nonce type %+v", nonce)
+	}
+
+	for _, src := range []string{
+		"a:\n  $type: bytes\n  $length: 0\n",
+		"a:\n  $type: bytes\n  $length: -1\n",
+		"a:\n  $type: bytes\n  $length: {min: 4, max: 2}\n",
+		"a:\n  $type: bytes\n  $length: {exactly: 4}\n",
+		"a:\n  $type: string\n  $length: 4\n",
+	} {
+		if _, err := FromYAML([]byte(src)); err == nil {
+			t.Errorf("expected error for %q", src)
+		}
+	}
+}
+
+func TestConditions(t *testing.T) {
+	schema, err := FromYAML([]byte(`
+protocol:
+  $type: string
+  $enum: [tcp, udp, unix]
+port?:
+  $type:  uint16
+  $if:    {protocol: tcp}
+  $then:  required
+path?:
+  $type: string
+  $if:   {protocol: unix}
+  $else: {$type: bytes, $format: hex}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	port := (*schema.Struct)["port"]
+	if port.If == nil || port.If.Equals["protocol"] != "tcp" || port.If.Then == nil || port.If.Then.Optional || port.If.Then.Kind != yema.Uint16 || port.If.Else != nil {
+		t.Errorf("unexpected port type %+v", port)
+	}
+	path := (*schema.Struct)["path"]
+	if path.If == nil || path.If.Then != nil || path.If.Else == nil || path.If.Else.Kind != yema.Bytes || !path.If.Else.Optional || path.If.Else.Format != "hex" {
+		t.Errorf("unexpected path type %+v", path)
+	}
+
+	for _, src := range []string{
+		"a: string\nb:\n  $type: string\n  $then: required\n",
+		"a: string\nb:\n  $type: string\n  $if: {a: x}\n",
+		"a: string\nb:\n  $type: string\n  $if: {}\n  $then: required\n",
+		"a: string\nb:\n  $type: string\n  $if: {c: x}\n  $then: required\n",
+		"a: string\nb:\n  $type: string\n  $if: {b: x}\n  $then: required\n",
+		"a: string\nb:\n  $type: string\n  $if: {a: 1}\n  $then: required\n",
+		"a: [string]\nb:\n  $type: string\n  $if: {a: x}\n  $then: required\n",
+		"a: string\nb:\n  $type: string\n  $if: {a: x}\n  $then: {$type: int, $readonly: true}\n",
+		"a: string\nb:\n  $type: string\n  $if: {a: x}\n  $then: nothing\n",
+	} {
+		if _, err := FromYAML([]byte(src)); err == nil {
+			t.Errorf("expected error for %q", src)
+		}
+	}
+}
+
+func TestUnions(t *testing.T) {
+	schema, err := FromYAML([]byte("id:\n  $union: [string, {b: int, a: int}]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id := (*schema.Struct)["id"]
+	if id.Kind != yema.Union || len(id.Union) != 2 || id.Union[0].Kind != yema.String {
+		t.Fatalf("unexpected union type %+v", id)
+	}
+	if got := strings.Join(id.Union[1].FieldNames(), ","); got != "b,a" {
+		t.Errorf("union variant field order = %s", got)
+	}
+
+	for _, src := range []string{
+		"a:\n  $union: [string]\n",
+		"a:\n  $union: string\n",
+		"a:\n  $union: [string, strin]\n",
+		"a:\n  $union: [string, int]\n  $example: 1\n",
+	} {
+		if _, err := FromYAML([]byte(src)); err == nil {
+			t.Errorf("expected error for %q", src)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aep/yema"
+	"gopkg.in/yaml.v3"
+)
+
+// FromYAML parses a yaml or json schema document of any root type into a yema.Type.
+// Unlike From, it records the declared order of fields in Type.Order.
+func FromYAML(data []byte) (*yema.Type, error) {
+	return FromYAMLWithOptions(data, Options{})
+}
+
+// FromYAMLWithOptions is like FromYAML with custom options
+func FromYAMLWithOptions(data []byte, opts Options) (*yema.Type, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	return FromNode(&node, opts)
+}
+
+// FromNode parses a decoded yaml document into a yema.Type, recording the declared order of fields.
+// The root may be of any type, see FromValue.
+func FromNode(node *yaml.Node, opts Options) (*yema.Type, error) {
+	var schema interface{}
+	if err := node.Decode(&schema); err != nil {
+		return nil, fmt.Errorf("failed decoding schema: %w", err)
+	}
+
+	t, err := FromValueWithOptions(schema, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	applyOrder(node, t, defsNode(node))
+	return t, nil
+}
+
+// defsNode returns the node declaring the named types of a schema, nil if there is none
+func defsNode(node *yaml.Node) *yaml.Node {
+	node = resolve(node)
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == DefsKey {
+			return resolve(node.Content[i+1])
+		}
+	}
+	return nil
+}
+
+// applyOrder walks a yaml node alongside the type parsed from it and sets the field order of every struct
+func applyOrder(node *yaml.Node, t *yema.Type, defs *yaml.Node) {
+	if t.If != nil {
+		applyBranchOrder(node, t.If, defs)
+	}
+	node = typeNode(node, defs)
+
+	switch t.Kind {
+	case yema.Struct:
+		if node.Kind != yaml.MappingNode || t.Struct == nil {
+			return
+		}
+
+		order := make([]string, 0, len(*t.Struct))
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			name := strings.TrimSuffix(node.Content[i].Value, "?")
+			fieldType, ok := (*t.Struct)[name]
+			if !ok {
+				continue
+			}
+
+			applyOrder(node.Content[i+1], &fieldType, defs)
+			(*t.Struct)[name] = fieldType
+			order = append(order, name)
+		}
+		t.Order = order
+
+	case yema.Array:
+		if node.Kind != yaml.SequenceNode || len(node.Content) != 1 || t.Array == nil {
+			return
+		}
+		applyOrder(node.Content[0], t.Array, defs)
+
+	case yema.Union:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			variants := resolve(node.Content[i+1])
+			if node.Content[i].Value != UnionKey || variants.Kind != yaml.SequenceNode || len(variants.Content) != len(t.Union) {
+				continue
+			}
+			for j := range t.Union {
+				applyOrder(variants.Content[j], &t.Union[j], defs)
+			}
+		}
+
+	case yema.Map:
+		if node.Kind != yaml.MappingNode || t.Map == nil {
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == MapKey {
+				applyOrder(node.Content[i+1], t.Map, defs)
+			}
+		}
+	}
+}
+
+// applyBranchOrder sets the field order of the declarations the condition of a field gives it,
+// node is the declaration of the field holding $then and $else
+func applyBranchOrder(node *yaml.Node, cond *yema.Condition, defs *yaml.Node) {
+	node = resolve(node)
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		var branch *yema.Type
+		switch node.Content[i].Value {
+		case ThenKey:
+			branch = cond.Then
+		case ElseKey:
+			branch = cond.Else
+		}
+		if branch == nil {
+			continue
+		}
+		declared := resolve(node.Content[i+1])
+		if declared.Kind == yaml.ScalarNode && (declared.Value == RequiredBranch || declared.Value == OptionalBranch) {
+			// The branch only changes whether the field is optional, its type is declared next to $if
+			declared = node
+		}
+		applyOrder(declared, branch, defs)
+	}
+}
+
+// typeNode returns the node declaring the type itself, skipping over long-form declarations and references
+func typeNode(node *yaml.Node, defs *yaml.Node) *yaml.Node {
+	node = resolve(node)
+	// The parser rejects recursive references, the bound only guards against looping forever
+	for hops := 0; node.Kind == yaml.MappingNode && hops < 1000; hops++ {
+		var declared *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			switch node.Content[i].Value {
+			case TypeKey:
+				declared = node.Content[i+1]
+			case RefKey:
+				declared = defNode(defs, strings.TrimPrefix(node.Content[i+1].Value, RefPrefix))
+			}
+		}
+		if declared == nil {
+			break
+		}
+		node = resolve(declared)
+	}
+	return node
+}
+
+// defNode returns the node declaring the named type, nil if there is none
+func defNode(defs *yaml.Node, name string) *yaml.Node {
+	if defs == nil || defs.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(defs.Content); i += 2 {
+		if defs.Content[i].Value == name {
+			return defs.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// resolve unwraps document and alias nodes
+func resolve(node *yaml.Node) *yaml.Node {
+	for {
+		switch {
+		case node.Kind == yaml.DocumentNode && len(node.Content) == 1:
+			node = node.Content[0]
+		case node.Kind == yaml.AliasNode && node.Alias != nil:
+			node = node.Alias
+		default:
+			return node
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Package playground serves a local web UI to try schemas, validating documents and generating code as they are edited
+package playground
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/aep/yema/build"
+	"github.com/aep/yema/parser"
+	"github.com/aep/yema/validator"
+	"github.com/aep/yema/vet"
+)
+
+//go:embed index.html
+var index []byte
+
+// maxRequestSize bounds the schema and document submitted in a single request
+const maxRequestSize = 1 << 20
+
+// generators are the targets code is generated for, in the order they are shown
+var generators = []string{"golang", "typescript", "rust", "jsonschema", "cue", "example", "wire"}
+
+// Request is a schema and a document to check against it, both in yaml or json
+type Request struct {
+	Schema   string `json:"schema"`
+	Document string `json:"document"`
+}
+
+// Response reports the result of checking a Request
+type Response struct {
+	// SchemaError is set if the schema cannot be parsed, nothing else is reported then
+	SchemaError string `json:"schemaError,omitempty"`
+	// Problems are the findings of vet on the schema
+	Problems []string `json:"problems"`
+	// Errors are the violations of the document, empty if it is valid or no document was given
+	Errors []string `json:"errors"`
+	// Outputs are the generated code of each target
+	Outputs []Output `json:"outputs"`
+}
+
+// Output is the code generated for a single target
+type Output struct {
+	Generator string `json:"generator"`
+	Code      string `json:"code,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Handler returns the handler serving the web UI on / and checking requests posted to /api/check
+func Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(index)
+	})
+	mux.HandleFunc("POST /api/check", func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize)).Decode(&req); err != nil {
+			http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(Check(req))
+	})
+	return mux
+}
+
+// Check parses and vets the schema of req, validates its document and generates code for every target
+func Check(req Request) Response {
+	resp := Response{Problems: []string{}, Errors: []string{}, Outputs: []Output{}}
+
+	t, err := parser.FromYAML([]byte(req.Schema))
+	if err != nil {
+		resp.SchemaError = err.Error()
+		return resp
+	}
+
+	for _, p := range append(vet.Vet(t), vet.Defs([]byte(req.Schema))...) {
+		resp.Problems = append(resp.Problems, p.String())
+	}
+
+	if strings.TrimSpace(req.Document) != "" {
+		for _, e := range validator.ValidateYAML(strings.NewReader(req.Document), t) {
+			resp.Errors = append(resp.Errors, e.Error())
+		}
+	}
+
+	for _, g := range generators {
+		out := Output{Generator: g}
+		code, err := build.Generate(t, build.Target{Generator: g, Validators: true}, "Root")
+		if err != nil {
+			out.Error = err.Error()
+		} else {
+			out.Code = string(code)
+		}
+		resp.Outputs = append(resp.Outputs, out)
+	}
+
+	return resp
+}
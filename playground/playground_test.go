@@ -0,0 +1,58 @@
+package playground
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	srv := httptest.NewServer(Handler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		t.Errorf("GET / = %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	body := `{"schema": "name: string\nage: uint8\n", "document": "name: x\nage: 300\n"}`
+	resp, err = http.Post(srv.URL+"/api/check", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var r Response
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Errors) != 1 || r.Errors[0] != "field 'age' value out of range for uint8" {
+		t.Errorf("unexpected errors %v", r.Errors)
+	}
+	if len(r.Outputs) != len(generators) {
+		t.Fatalf("expected an output per generator, got %+v", r.Outputs)
+	}
+	for _, o := range r.Outputs {
+		if o.Error != "" || o.Code == "" {
+			t.Errorf("unexpected output for %s: %+v", o.Generator, o)
+		}
+	}
+}
+
+func TestCheckInvalidSchema(t *testing.T) {
+	r := Check(Request{Schema: "name: strin\n", Document: "name: x\n"})
+	if r.SchemaError == "" || len(r.Outputs) != 0 {
+		t.Errorf("expected a schema error only, got %+v", r)
+	}
+
+	r = Check(Request{Schema: "empty: {}\n"})
+	if len(r.Problems) == 0 {
+		t.Errorf("expected vet problems, got %+v", r)
+	}
+}
@@ -0,0 +1,299 @@
+// Package proxy implements a reverse proxy that validates requests and responses against yema schemas
+package proxy
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aep/yema"
+	"github.com/aep/yema/parser"
+	"github.com/aep/yema/transform"
+	"github.com/aep/yema/validator"
+	"gopkg.in/yaml.v3"
+)
+
+// Route pairs an HTTP endpoint with the schemas of its request and response bodies
+type Route struct {
+	// Method is the HTTP method to match, empty matches any method
+	Method string `yaml:"method"`
+	// Path is the URL path pattern to match, see net/http.ServeMux for the syntax
+	Path string `yaml:"path"`
+	// Request is the path to the schema file for the request body, read-only fields are left out
+	Request string `yaml:"request"`
+	// Response is the path to the schema file for the response body, write-only fields are left out
+	Response string `yaml:"response"`
+
+	requestType  *yema.Type
+	responseType *yema.Type
+}
+
+// Manifest is the list of routes enforced by the proxy
+type Manifest struct {
+	Routes []Route `yaml:"routes"`
+}
+
+// LoadManifest reads a manifest file and parses all schemas it refers to.
+// Schema paths are resolved relative to the manifest file.
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var m Manifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed parsing manifest: %w", err)
+	}
+
+	dir := filepath.Dir(path)
+	for i := range m.Routes {
+		route := &m.Routes[i]
+		if route.Path == "" {
+			return nil, fmt.Errorf("route %d has no path", i)
+		}
+		if route.Request != "" {
+			route.requestType, err = loadSchema(filepath.Join(dir, route.Request))
+			if err != nil {
+				return nil, err
+			}
+			route.requestType = transform.Request(route.requestType)
+		}
+		if route.Response != "" {
+			route.responseType, err = loadSchema(filepath.Join(dir, route.Response))
+			if err != nil {
+				return nil, err
+			}
+			route.responseType = transform.Response(route.responseType)
+		}
+	}
+
+	return &m, nil
+}
+
+// loadSchema reads and parses a single yema schema file
+func loadSchema(path string) (*yema.Type, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := parser.FromYAML(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing schema %s: %w", path, err)
+	}
+
+	return t, nil
+}
+
+// Options holds configuration options for the proxy
+type Options struct {
+	// Upstream is the base URL of the service requests are forwarded to
+	Upstream *url.URL
+	// Reject determines whether violations are rejected (true) or only logged (false)
+	Reject bool
+	// Logger receives violation reports, defaults to log.Default()
+	Logger *log.Logger
+}
+
+// New returns a handler that forwards all requests to the upstream service,
+// validating bodies of requests matching a route in the manifest.
+// Requests that do not match any route are forwarded unchecked.
+func New(m *Manifest, opts Options) (http.Handler, error) {
+	if opts.Upstream == nil {
+		return nil, fmt.Errorf("no upstream provided")
+	}
+	if opts.Logger == nil {
+		opts.Logger = log.Default()
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/", httputil.NewSingleHostReverseProxy(opts.Upstream))
+
+	for i := range m.Routes {
+		route := &m.Routes[i]
+		pattern := route.Path
+		if route.Method != "" {
+			pattern = route.Method + " " + route.Path
+		}
+
+		h := &routeHandler{
+			route: route,
+			opts:  opts,
+			proxy: httputil.NewSingleHostReverseProxy(opts.Upstream),
+		}
+		h.proxy.ModifyResponse = h.checkResponse
+
+		if err := registerRoute(mux, pattern, h); err != nil {
+			return nil, err
+		}
+	}
+
+	return mux, nil
+}
+
+// registerRoute adds a route to the mux, turning its panic on invalid or conflicting patterns into an error
+func registerRoute(mux *http.ServeMux, pattern string, h http.Handler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("invalid route %q: %v", pattern, r)
+		}
+	}()
+	mux.Handle(pattern, h)
+	return nil
+}
+
+type routeHandler struct {
+	route *Route
+	opts  Options
+	proxy *httputil.ReverseProxy
+}
+
+func (h *routeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.route.requestType != nil && r.Body != nil {
+		body, err := io.ReadAll(r.Body)
+		r.Body.Close()
+		if err != nil {
+			http.Error(w, "failed reading request body", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		if errs := h.check("request", r, r.Header, body, h.route.requestType); len(errs) != 0 {
+			h.report("request", r, errs)
+			if h.opts.Reject {
+				writeViolations(w, http.StatusBadRequest, errs)
+				return
+			}
+		}
+	}
+
+	h.proxy.ServeHTTP(w, r)
+}
+
+// checkResponse validates successful upstream responses against the response schema
+func (h *routeHandler) checkResponse(resp *http.Response) error {
+	if h.route.responseType == nil || resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	errs := h.check("response", resp.Request, resp.Header, body, h.route.responseType)
+	if len(errs) == 0 {
+		return nil
+	}
+
+	h.report("response", resp.Request, errs)
+	if !h.opts.Reject {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	encodeViolations(&buf, errs)
+	resp.StatusCode = http.StatusBadGateway
+	resp.Status = fmt.Sprintf("%d %s", http.StatusBadGateway, http.StatusText(http.StatusBadGateway))
+	resp.Header.Set("Content-Type", "application/json")
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = int64(buf.Len())
+	resp.Body = io.NopCloser(&buf)
+
+	return nil
+}
+
+// check validates the body of a request or response with the header against the schema.
+// Empty bodies and bodies of other content types than JSON are not checked.
+func (h *routeHandler) check(direction string, r *http.Request, header http.Header, body []byte, schema *yema.Type) []error {
+	if len(body) == 0 || !isJSON(header.Get("Content-Type")) {
+		return nil
+	}
+
+	data, err := decodeBody(header.Get("Content-Encoding"), body)
+	if errors.Is(err, errUnsupportedEncoding) {
+		h.opts.Logger.Printf("%s %s: %s not checked: %s", r.Method, r.URL.Path, direction, err)
+		return nil
+	}
+	if err != nil {
+		return []error{err}
+	}
+
+	return validateBody(data, schema)
+}
+
+func (h *routeHandler) report(direction string, r *http.Request, errs []error) {
+	for _, e := range errs {
+		h.opts.Logger.Printf("%s %s: %s violation: %s", r.Method, r.URL.Path, direction, e)
+	}
+}
+
+// isJSON reports whether a Content-Type is JSON, such as application/json or application/problem+json.
+// Bodies without a Content-Type are taken to be JSON.
+func isJSON(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+var errUnsupportedEncoding = errors.New("unsupported content encoding")
+
+// decodeBody returns a body with the Content-Encoding decoded. Only gzip is supported.
+func decodeBody(encoding string, body []byte) ([]byte, error) {
+	switch strings.ToLower(encoding) {
+	case "", "identity":
+		return body, nil
+	case "gzip", "x-gzip":
+		zr, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("failed decoding gzip body: %w", err)
+		}
+		defer zr.Close()
+		data, err := io.ReadAll(zr)
+		if err != nil {
+			return nil, fmt.Errorf("failed decoding gzip body: %w", err)
+		}
+		return data, nil
+	default:
+		return nil, fmt.Errorf("%w %q", errUnsupportedEncoding, encoding)
+	}
+}
+
+// validateBody decodes a JSON body and validates it against the schema
+func validateBody(body []byte, schema *yema.Type) []error {
+	return validator.ValidateJSON(bytes.NewReader(body), schema)
+}
+
+func writeViolations(w http.ResponseWriter, status int, errs []error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encodeViolations(w, errs)
+}
+
+func encodeViolations(w io.Writer, errs []error) {
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.Error()
+	}
+	json.NewEncoder(w).Encode(map[string][]string{"errors": msgs})
+}
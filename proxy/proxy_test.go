@@ -0,0 +1,136 @@
+package proxy
+
+import (
+	"compress/gzip"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProxy(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "create.yaml", "name: string\nage?: int\n")
+	writeFile(t, dir, "user.yaml", "id: int\nname: string\n")
+	writeFile(t, dir, "routes.yaml", `
+routes:
+  - method: POST
+    path: /users
+    request: create.yaml
+    response: user.yaml
+`)
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		switch {
+		case strings.Contains(string(body), "empty"):
+			w.WriteHeader(http.StatusNoContent)
+			return
+		case strings.Contains(string(body), "text"):
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte("not json"))
+			return
+		}
+		response := `{"id": 1, "name": "bob"}`
+		if strings.Contains(string(body), "broken") {
+			response = `{"name": "broken"}`
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if strings.Contains(string(body), "gzip") {
+			w.Header().Set("Content-Encoding", "gzip")
+			zw := gzip.NewWriter(w)
+			zw.Write([]byte(response))
+			zw.Close()
+			return
+		}
+		w.Write([]byte(response))
+	}))
+	defer upstream.Close()
+
+	m, err := LoadManifest(filepath.Join(dir, "routes.yaml"))
+	if err != nil {
+		t.Fatalf("LoadManifest failed: %v", err)
+	}
+
+	u, _ := url.Parse(upstream.URL)
+
+	tests := []struct {
+		name       string
+		reject     bool
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{"valid request", true, "/users", `{"name": "bob"}`, http.StatusOK},
+		{"invalid request rejected", true, "/users", `{"age": 3}`, http.StatusBadRequest},
+		{"invalid request logged", false, "/users", `{"age": 3}`, http.StatusOK},
+		{"invalid response rejected", true, "/users", `{"name": "broken"}`, http.StatusBadGateway},
+		{"invalid response logged", false, "/users", `{"name": "broken"}`, http.StatusOK},
+		{"unmatched route", true, "/other", `{}`, http.StatusOK},
+		{"empty response", true, "/users", `{"name": "empty"}`, http.StatusNoContent},
+		{"text response", true, "/users", `{"name": "text"}`, http.StatusOK},
+		{"gzip response", true, "/users", `{"name": "gzip"}`, http.StatusOK},
+		{"invalid gzip response rejected", true, "/users", `{"name": "broken gzip"}`, http.StatusBadGateway},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := New(m, Options{Upstream: u, Reject: tt.reject, Logger: log.New(io.Discard, "", 0)})
+			if err != nil {
+				t.Fatalf("New failed: %v", err)
+			}
+
+			srv := httptest.NewServer(h)
+			defer srv.Close()
+
+			resp, err := http.Post(srv.URL+tt.path, "application/json", strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("got status %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestCheckResponseStatus(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "user.yaml", "id: int\n")
+	writeFile(t, dir, "routes.yaml", "routes:\n  - path: /users\n    response: user.yaml\n")
+
+	m, err := LoadManifest(filepath.Join(dir, "routes.yaml"))
+	if err != nil {
+		t.Fatalf("LoadManifest failed: %v", err)
+	}
+	h := &routeHandler{route: &m.Routes[0], opts: Options{Reject: true, Logger: log.New(io.Discard, "", 0)}}
+
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Status:     "200 OK",
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(`{}`)),
+		Request:    req,
+	}
+	if err := h.checkResponse(resp); err != nil {
+		t.Fatalf("checkResponse failed: %v", err)
+	}
+	if resp.Status != "502 Bad Gateway" {
+		t.Errorf("got status %q, want %q", resp.Status, "502 Bad Gateway")
+	}
+}
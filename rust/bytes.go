@@ -0,0 +1,157 @@
+package rust
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/aep/yema"
+	"github.com/aep/yema/validator"
+)
+
+// bytesEncoding returns the path of the serde module writing bytes as strings in the encoding of their format,
+// or "" if bytes stay a Vec<u8>, see Options.EncodedBytes. Formats other than encodings are written in base64.
+func bytesEncoding(t *yema.Type, opts Options) string {
+	if !opts.EncodedBytes || t.Kind != yema.Bytes {
+		return ""
+	}
+	switch t.Format {
+	case validator.Base64URLEncoding:
+		return "encodings::base64url"
+	case validator.HexEncoding:
+		return "encodings::hex"
+	}
+	return "encodings::base64"
+}
+
+// writeBytesType writes the newtype holding bytes written in the encoding of their format
+func writeBytesType(buf *bytes.Buffer, name string, t *yema.Type, opts Options, indent string) {
+	with := bytesEncoding(t, opts)
+	encoding := "base64"
+	switch with {
+	case "encodings::base64url":
+		encoding = "URL-safe base64"
+	case "encodings::hex":
+		encoding = "hex"
+	}
+
+	writeDerive(buf, name, opts.DeriveTraits, opts, indent)
+	writeDoc(buf, indent, t, fmt.Sprintf("%s holds bytes written as a string in %s", name, encoding))
+	if usesSerde(opts) {
+		fmt.Fprintf(buf, "%spub struct %s(%s pub Vec<u8>);\n\n", indent, name, serdeAttr(fmt.Sprintf("with = %q", with), opts))
+	} else {
+		fmt.Fprintf(buf, "%spub struct %s(pub Vec<u8>);\n\n", indent, name)
+	}
+}
+
+// writeEncodingModules writes the encodings module with the serde modules of the encodings of bytes in mods,
+// reading base64 padded or not like package validator
+func writeEncodingModules(buf *bytes.Buffer, mods map[string]bool, opts Options) {
+	base64 := mods["encodings::base64"] || mods["encodings::base64url"]
+	if !base64 && !mods["encodings::hex"] {
+		return
+	}
+	openHelperModule(buf, "encodings", "reads and writes bytes as strings in the encodings of bytes formats", opts)
+	if base64 {
+		buf.WriteString(base64Helpers)
+	}
+	for _, name := range []string{"base64", "base64url", "hex"} {
+		if mods["encodings::"+name] {
+			buf.WriteString(helperSubmodule(encodingModules[name], opts))
+		}
+	}
+	buf.WriteString("    }\n")
+}
+
+// base64Helpers encode and decode base64 in the alphabet of the base64 and base64url modules
+const base64Helpers = `        /// encode writes b in padded base64 with the alphabet
+        fn encode(b: &[u8], alphabet: &[u8; 64]) -> String {
+            let mut s = String::with_capacity((b.len() + 2) / 3 * 4);
+            for chunk in b.chunks(3) {
+                let n = chunk.iter().enumerate().fold(0u32, |n, (i, &c)| n | u32::from(c) << (16 - 8 * i));
+                for i in 0..4 {
+                    if i <= chunk.len() {
+                        s.push(alphabet[(n >> (18 - 6 * i) & 63) as usize] as char);
+                    } else {
+                        s.push('=');
+                    }
+                }
+            }
+            s
+        }
+
+        /// decode reads base64 with the alphabet, padded or not
+        fn decode(s: &str, alphabet: &[u8; 64]) -> Option<Vec<u8>> {
+            let mut s = s.as_bytes();
+            if s.len() % 4 == 0 {
+                s = s.strip_suffix(b"==").or_else(|| s.strip_suffix(b"=")).unwrap_or(s);
+            }
+            if s.len() % 4 == 1 {
+                return None;
+            }
+            let mut b = Vec::with_capacity(s.len() * 3 / 4);
+            for chunk in s.chunks(4) {
+                let mut n = 0u32;
+                for (i, c) in chunk.iter().enumerate() {
+                    n |= (alphabet.iter().position(|a| a == c)? as u32) << (18 - 6 * i);
+                }
+                b.extend_from_slice(&n.to_be_bytes()[1..chunk.len()]);
+            }
+            Some(b)
+        }
+`
+
+// encodingModules are the serde modules of the encodings of bytes, by the name of their module
+var encodingModules = map[string]string{
+	"base64": `
+        /// base64 reads and writes bytes in base64, such as aGVsbG8=
+        pub mod base64 {
+            use serde::{de::Error, Deserialize, Deserializer, Serializer};
+
+            const ALPHABET: &[u8; 64] = b"ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/";
+
+            pub fn serialize<S: Serializer>(b: &[u8], serializer: S) -> Result<S::Ok, S::Error> {
+                serializer.serialize_str(&super::encode(b, ALPHABET))
+            }
+
+            pub fn deserialize<'de, D: Deserializer<'de>>(deserializer: D) -> Result<Vec<u8>, D::Error> {
+                let s = String::deserialize(deserializer)?;
+                super::decode(&s, ALPHABET).ok_or_else(|| D::Error::custom("invalid base64"))
+            }
+        }
+`,
+	"base64url": `
+        /// base64url reads and writes bytes in URL-safe base64, such as -_8=
+        pub mod base64url {
+            use serde::{de::Error, Deserialize, Deserializer, Serializer};
+
+            const ALPHABET: &[u8; 64] = b"ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_";
+
+            pub fn serialize<S: Serializer>(b: &[u8], serializer: S) -> Result<S::Ok, S::Error> {
+                serializer.serialize_str(&super::encode(b, ALPHABET))
+            }
+
+            pub fn deserialize<'de, D: Deserializer<'de>>(deserializer: D) -> Result<Vec<u8>, D::Error> {
+                let s = String::deserialize(deserializer)?;
+                super::decode(&s, ALPHABET).ok_or_else(|| D::Error::custom("invalid URL-safe base64"))
+            }
+        }
+`,
+	"hex": `
+        /// hex reads and writes bytes in hex, such as 68656c6c6f
+        pub mod hex {
+            use serde::{de::Error, Deserialize, Deserializer, Serializer};
+
+            pub fn serialize<S: Serializer>(b: &[u8], serializer: S) -> Result<S::Ok, S::Error> {
+                serializer.serialize_str(&b.iter().map(|c| format!("{c:02x}")).collect::<String>())
+            }
+
+            pub fn deserialize<'de, D: Deserializer<'de>>(deserializer: D) -> Result<Vec<u8>, D::Error> {
+                let s = String::deserialize(deserializer)?;
+                if s.len() % 2 != 0 || !s.bytes().all(|c| c.is_ascii_hexdigit()) {
+                    return Err(D::Error::custom("invalid hex"));
+                }
+                Ok((0..s.len()).step_by(2).map(|i| u8::from_str_radix(&s[i..i + 2], 16).unwrap()).collect())
+            }
+        }
+`,
+}
@@ -0,0 +1,117 @@
+package rust
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aep/yema"
+	"github.com/aep/yema/validator"
+)
+
+// ToRustCrate generates a crate that builds as it is, holding the code of ToRust in src/lib.rs and a Cargo.toml
+// depending on the crates the code uses. The package is named after Module, the files by their path in the crate.
+func ToRustCrate(t *yema.Type, opts Options) (map[string][]byte, error) {
+	code, err := ToRust(t, opts)
+	if err != nil {
+		return nil, err
+	}
+	opts = withDefaults(opts)
+	// ToRust reported invalid overrides already
+	elem, _ := rootElem(t)
+	opts.overrides, opts.imports, _ = parseOverrides(elem, opts.TypeOverrides)
+
+	lib := code
+	if opts.NoStd {
+		lib = append([]byte("#![no_std]\n\nextern crate alloc;\n\n"), code...)
+	}
+	return map[string][]byte{
+		"Cargo.toml": cargoManifest(opts.Module, usedCrates(t, opts), opts),
+		"src/lib.rs": lib,
+	}, nil
+}
+
+// usedCrates returns the crates of the types in the code of t
+func usedCrates(t *yema.Type, opts Options) map[string]bool {
+	crates := make(map[string]bool)
+	elem, _ := rootElem(t)
+	walkTypes(elem, "", opts, func(t *yema.Type) {
+		if t.Kind == yema.String && t.Format == validator.UUIDFormat {
+			crates["uuid"] = true
+		} else if rustType, _ := timeType(t, opts); strings.HasPrefix(rustType, "chrono::") {
+			crates["chrono"] = true
+		} else if strings.HasPrefix(rustType, "time::") {
+			crates["time"] = true
+		} else if isURL(t, opts) {
+			crates["url"] = true
+		}
+	}, make(map[*map[string]yema.Type]bool))
+	return crates
+}
+
+// cargoManifest writes the Cargo.toml of a crate named name using crates. No_std crates leave out the std features
+// of their dependencies and only depend on serde with their serde feature, which enables it for the others.
+func cargoManifest(name string, crates map[string]bool, opts Options) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[package]\nname = %q\nversion = \"0.1.0\"\nedition = \"2021\"\n\n[dependencies]\n", name)
+
+	serde := usesSerde(opts)
+	// serdeFeatures are the features of the dependencies reading and writing their types with serde
+	serdeFeatures := map[string][]string{
+		"uuid":   {"serde"},
+		"chrono": {"serde"},
+		"time":   {"serde-well-known", "serde-human-readable"},
+		"url":    {"serde"},
+	}
+	versions := []struct{ crate, version string }{{"chrono", "0.4"}, {"time", "0.3"}, {"url", "2"}, {"uuid", "1"}}
+
+	if serde && opts.NoStd {
+		b.WriteString("serde = { version = \"1\", default-features = false, features = [\"derive\", \"alloc\"], optional = true }\n")
+	} else if serde {
+		b.WriteString("serde = { version = \"1\", features = [\"derive\"] }\n")
+	}
+	var enables []string
+	for _, v := range versions {
+		if !crates[v.crate] {
+			continue
+		}
+		var features []string
+		switch {
+		case opts.NoStd:
+			for _, feature := range serdeFeatures[v.crate] {
+				enables = append(enables, v.crate+"/"+feature)
+			}
+			if v.crate == "chrono" {
+				features = []string{"alloc"}
+			}
+		case serde:
+			features = serdeFeatures[v.crate]
+		}
+
+		fmt.Fprintf(&b, "%s = { version = %q", v.crate, v.version)
+		if opts.NoStd {
+			b.WriteString(", default-features = false")
+		}
+		if len(features) > 0 {
+			fmt.Fprintf(&b, ", features = [%s]", quoteList(features))
+		}
+		b.WriteString(" }\n")
+	}
+
+	if opts.ValidatorCrate {
+		b.WriteString("validator = { version = \"0.20\", features = [\"derive\"] }\n")
+	}
+
+	if serde && opts.NoStd {
+		fmt.Fprintf(&b, "\n[features]\nserde = [%s]\n", quoteList(append([]string{"dep:serde"}, enables...)))
+	}
+	return []byte(b.String())
+}
+
+// quoteList writes strings as the items of a TOML array
+func quoteList(items []string) string {
+	quoted := make([]string, len(items))
+	for i, item := range items {
+		quoted[i] = fmt.Sprintf("%q", item)
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,53 @@
+package rust
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aep/yema"
+)
+
+// docWidth is the width doc comments are wrapped at, not counting indentation
+const docWidth = 80
+
+// writeDoc writes the description of t as a doc comment wrapped at docWidth, or fallback if there is none,
+// followed by the example of t in JSON if it declares one
+func writeDoc(buf *bytes.Buffer, indent string, t *yema.Type, fallback string) {
+	description := strings.TrimSpace(t.Description)
+	if description == "" {
+		description = fallback
+	}
+
+	var paragraphs []string
+	if description != "" {
+		paragraphs = strings.Split(description, "\n\n")
+	}
+	if t.Example != nil {
+		// Examples that have no JSON encoding are left out, the example generator reports them
+		if data, err := json.Marshal(t.Example); err == nil {
+			paragraphs = append(paragraphs, "Example: `"+string(data)+"`")
+		}
+	}
+
+	for i, paragraph := range paragraphs {
+		if i > 0 {
+			fmt.Fprintf(buf, "%s///\n", indent)
+		}
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			if line != "" && len("/// ")+len(line)+1+len(word) > docWidth {
+				fmt.Fprintf(buf, "%s/// %s\n", indent, line)
+				line = ""
+			}
+			if line != "" {
+				line += " "
+			}
+			line += word
+		}
+		if line != "" {
+			fmt.Fprintf(buf, "%s/// %s\n", indent, line)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package rust
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/aep/yema"
+	"github.com/aep/yema/internal/ident"
+	"github.com/aep/yema/validator"
+)
+
+// isStringEnum reports whether t is a string restricted to an enum, which is generated as a Rust enum
+// if Options.Enums is set. UUIDs keep their type.
+func isStringEnum(t *yema.Type) bool {
+	if t.Kind != yema.String || len(t.Enum) == 0 || t.Format == validator.UUIDFormat {
+		return false
+	}
+	for _, value := range t.Enum {
+		if _, ok := value.(string); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// renameRule is a value of serde's rename_all with the conversion serde applies to identifiers
+type renameRule struct {
+	name    string
+	convert func(string) string
+}
+
+// renameRules are the values of serde's rename_all in the order they are preferred, with the conversion
+// serde applies to the PascalCase identifiers of variants
+var renameRules = []renameRule{
+	{"PascalCase", func(s string) string { return s }},
+	{"snake_case", func(s string) string { return serdeSnake(s, '_') }},
+	{"kebab-case", func(s string) string { return serdeSnake(s, '-') }},
+	{"SCREAMING_SNAKE_CASE", func(s string) string { return asciiUpper(serdeSnake(s, '_')) }},
+	{"SCREAMING-KEBAB-CASE", func(s string) string { return asciiUpper(serdeSnake(s, '-')) }},
+	{"camelCase", func(s string) string {
+		if s == "" {
+			return s
+		}
+		return asciiLower(s[:1]) + s[1:]
+	}},
+	{"lowercase", asciiLower},
+	{"UPPERCASE", asciiUpper},
+}
+
+// serdeSnake converts a PascalCase identifier like serde does, separating every upper case letter
+func serdeSnake(s string, sep rune) string {
+	var b strings.Builder
+	for i, c := range s {
+		if i > 0 && unicode.IsUpper(c) {
+			b.WriteRune(sep)
+		}
+		b.WriteString(asciiLower(string(c)))
+	}
+	return b.String()
+}
+
+// asciiLower and asciiUpper change the case of ASCII letters only, as serde does
+func asciiLower(s string) string {
+	return strings.Map(func(c rune) rune {
+		if c <= unicode.MaxASCII {
+			return unicode.ToLower(c)
+		}
+		return c
+	}, s)
+}
+
+func asciiUpper(s string) string {
+	return strings.Map(func(c rune) rune {
+		if c <= unicode.MaxASCII {
+			return unicode.ToUpper(c)
+		}
+		return c
+	}, s)
+}
+
+// enumDerives returns the derived traits of enums, Default cannot be derived for enums without a default variant
+func enumDerives(traits []string) []string {
+	var derives []string
+	for _, trait := range traits {
+		if trait != "Default" {
+			derives = append(derives, trait)
+		}
+	}
+	return derives
+}
+
+// usesSerde reports whether the derived traits allow serde attributes
+func usesSerde(opts Options) bool {
+	return containsTrait(opts.DeriveTraits, "Serialize") || containsTrait(opts.DeriveTraits, "Deserialize")
+}
+
+// writeEnum writes a string enum as a Rust enum with a variant for every value, named after the value.
+// The values are mapped by the rename_all rule of serde matching most of them, the others are renamed one by one.
+func writeEnum(buf *bytes.Buffer, name string, t *yema.Type, opts Options, indent string) error {
+	variants := make([]string, len(t.Enum))
+	byVariant := make(map[string]string, len(t.Enum))
+	for i, value := range t.Enum {
+		s := value.(string)
+		variants[i] = ident.Camel(ident.Source(s, "", opts.Transliterate))
+		// Self is a keyword in every case
+		if variants[i] == "Self" {
+			variants[i] += "_"
+		}
+		if other, ok := byVariant[variants[i]]; ok {
+			return fmt.Errorf("values %q and %q of %s both map to the variant %s", other, s, name, variants[i])
+		}
+		byVariant[variants[i]] = s
+	}
+
+	rule, best := renameRules[0], -1
+	for _, r := range renameRules {
+		matches := 0
+		for i, value := range t.Enum {
+			if r.convert(variants[i]) == value.(string) {
+				matches++
+			}
+		}
+		if matches > best {
+			rule, best = r, matches
+		}
+	}
+
+	writeDerive(buf, name, enumDerives(opts.DeriveTraits), opts, indent)
+	writeDoc(buf, indent, t, name+" is one of the values of an enum")
+	serde := usesSerde(opts)
+	if serde && rule.name != "PascalCase" {
+		fmt.Fprintf(buf, "%s%s\n", indent, serdeAttr(fmt.Sprintf("rename_all = %q", rule.name), opts))
+	}
+	fmt.Fprintf(buf, "%spub enum %s {\n", indent, name)
+	for i, value := range t.Enum {
+		if serde && rule.convert(variants[i]) != value.(string) {
+			fmt.Fprintf(buf, "%s    %s\n", indent, serdeAttr("rename = "+strconv.Quote(value.(string)), opts))
+		}
+		fmt.Fprintf(buf, "%s    %s,\n", indent, variants[i])
+	}
+	fmt.Fprintf(buf, "%s}\n\n", indent)
+	return nil
+}
+
+// generateUnion generates a union as an untagged enum with a variant for every variant of the union, named
+// after its kind and numbered by position if several have the same kind. Unions have no discriminator,
+// serde decodes the first variant matching the data in the order of the schema. Serde also decodes structs
+// from arrays of their fields, so arrays may match a struct variant listed before the variant of the array.
+// The variants share the path of the union.
+func generateUnion(t *yema.Type, name, path string, buf *bytes.Buffer, generatedStructs ident.Types, opts Options, indentLevel int) error {
+	kinds := make(map[yema.Kind]int, len(t.Union))
+	for _, variant := range t.Union {
+		kinds[variant.Kind]++
+	}
+
+	indent := strings.Repeat("    ", indentLevel)
+	writeDerive(buf, name, enumDerives(opts.DeriveTraits), opts, indent)
+	writeDoc(buf, indent, t, name+" holds a value of one of the variants of a union")
+	if usesSerde(opts) {
+		fmt.Fprintf(buf, "%s%s\n", indent, serdeAttr("untagged", opts))
+	}
+	fmt.Fprintf(buf, "%spub enum %s {\n", indent, name)
+
+	var nested []nestedType
+	for i := range t.Union {
+		variant := t.Union[i]
+		variant.Optional = false
+		variantName := ident.Camel(variant.Kind.String())
+		if kinds[variant.Kind] > 1 {
+			variantName += strconv.Itoa(i + 1)
+		}
+		rustType, nestedName, err := typeToRustType(&variant, name, variantName, path, opts)
+		if err != nil {
+			return err
+		}
+		rustType = boxRecursive(&variant, rustType, opts)
+		if nestedName != "" {
+			elem, elemPath := nestedElem(&variant, path)
+			nested = append(nested, nestedType{nestedName, elemPath, elem})
+		}
+		fmt.Fprintf(buf, "%s    %s(%s),\n", indent, variantName, rustType)
+	}
+	fmt.Fprintf(buf, "%s}\n\n", indent)
+
+	for _, n := range nested {
+		if err := generateNested(n, buf, generatedStructs, opts, indentLevel); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package rust
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// isSerdeTrait reports whether trait is derived by serde, which no_std code derives behind the serde feature
+func isSerdeTrait(trait string) bool {
+	return trait == "Serialize" || trait == "Deserialize"
+}
+
+// writeDerive writes the derive attribute of traits and those of Options.TypeDerives for the type name, followed by
+// its attributes of Options.TypeAttributes. No_std code derives the serde traits behind the serde feature,
+// so crates can leave serde out, see Options.NoStd.
+func writeDerive(buf *bytes.Buffer, name string, traits []string, opts Options, indent string) {
+	for _, trait := range opts.TypeDerives[name] {
+		if !slices.Contains(traits, trait) {
+			traits = append(slices.Clip(traits), trait)
+		}
+	}
+
+	var plain, serde []string
+	for _, trait := range traits {
+		if opts.NoStd && isSerdeTrait(trait) {
+			serde = append(serde, trait)
+		} else {
+			plain = append(plain, trait)
+		}
+	}
+	if len(plain) > 0 {
+		fmt.Fprintf(buf, "%s#[derive(%s)]\n", indent, strings.Join(plain, ", "))
+	}
+	if len(serde) > 0 {
+		fmt.Fprintf(buf, "%s#[cfg_attr(feature = \"serde\", derive(%s))]\n", indent, strings.Join(serde, ", "))
+	}
+	for _, attr := range opts.TypeAttributes[name] {
+		fmt.Fprintf(buf, "%s#[%s]\n", indent, attr)
+	}
+}
+
+// serdeAttr returns the serde attribute with args, behind the serde feature in no_std code
+func serdeAttr(args string, opts Options) string {
+	if opts.NoStd {
+		return "#[cfg_attr(feature = \"serde\", serde(" + args + "))]"
+	}
+	return "#[serde(" + args + ")]"
+}
+
+// allocImports imports the types and macros of alloc that the std prelude holds, for no_std code
+const allocImports = "use alloc::{format, string::{String, ToString}, vec::Vec};"
+
+// writeAllocImports writes allocImports, which not every module uses all of
+func writeAllocImports(buf *bytes.Buffer, indent string) {
+	fmt.Fprintf(buf, "%s#[allow(unused_imports)]\n%s%s\n", indent, indent, allocImports)
+}
+
+// openHelperModule starts a module of serde helpers, only compiled with the serde feature in no_std code
+func openHelperModule(buf *bytes.Buffer, name, summary string, opts Options) {
+	fmt.Fprintf(buf, "    /// %s %s\n", name, summary)
+	if opts.NoStd {
+		buf.WriteString("    #[cfg(feature = \"serde\")]\n")
+	}
+	fmt.Fprintf(buf, "    mod %s {\n", name)
+	if opts.NoStd {
+		writeAllocImports(buf, "        ")
+		buf.WriteString("\n")
+	}
+}
+
+// helperSubmodule returns the code of a submodule of serde helpers, importing alloc after serde in no_std code
+func helperSubmodule(code string, opts Options) string {
+	if !opts.NoStd {
+		return code
+	}
+	const serdeImports = "use serde::{de::Error, Deserialize, Deserializer, Serializer};\n"
+	var buf bytes.Buffer
+	writeAllocImports(&buf, "            ")
+	return strings.Replace(code, serdeImports, serdeImports+buf.String(), 1)
+}
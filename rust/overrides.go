@@ -0,0 +1,149 @@
+package rust
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/aep/yema"
+	"github.com/aep/yema/internal/fieldpath"
+	"github.com/aep/yema/internal/ident"
+)
+
+// rustTypePath matches a Rust type given as an override, a path such as rust_decimal::Decimal
+// optionally followed by generic arguments, which are written as they are
+var rustTypePath = regexp.MustCompile(`^(::)?[A-Za-z_][A-Za-z0-9_]*(::[A-Za-z_][A-Za-z0-9_]*)*(<.+>)?$`)
+
+// preludeNames are the names in scope of every generated module, types of other paths with these names
+// are written with their path instead of being imported
+var preludeNames = map[string]bool{
+	"Box": true, "Option": true, "Result": true, "String": true, "ToString": true, "Vec": true,
+	"Serialize": true, "Deserialize": true, "Validate": true,
+}
+
+// parseOverrides parses the type overrides of the options, each path must name a field of the root struct t or
+// the items or values of one. Types are written by their name and imported with a use declaration, unless their
+// path starts with ::, several share the name or it is in scope already. It returns the types as written by path
+// and the paths to import.
+func parseOverrides(t *yema.Type, specs map[string]string) (map[string]string, []string, error) {
+	if len(specs) == 0 {
+		return nil, nil, nil
+	}
+
+	// paths are the paths of the types named by their name
+	paths := make(map[string]map[string]bool)
+	for path, spec := range specs {
+		if !rustTypePath.MatchString(spec) {
+			return nil, nil, fmt.Errorf("type override of %s: invalid Rust type %q", path, spec)
+		}
+		typePath, _, _ := strings.Cut(spec, "<")
+		if i := strings.LastIndex(typePath, "::"); i >= 0 && !strings.HasPrefix(typePath, "::") {
+			name := typePath[i+2:]
+			if paths[name] == nil {
+				paths[name] = make(map[string]bool)
+			}
+			paths[name][typePath] = true
+		}
+	}
+
+	overrides := make(map[string]string, len(specs))
+	var imports []string
+	for path, spec := range specs {
+		typePath, args, _ := strings.Cut(spec, "<")
+		i := strings.LastIndex(typePath, "::")
+		if i < 0 || strings.HasPrefix(typePath, "::") || len(paths[typePath[i+2:]]) > 1 || preludeNames[typePath[i+2:]] {
+			overrides[path] = spec
+			continue
+		}
+		overrides[path] = strings.TrimSuffix(typePath[i+2:]+"<"+args, "<")
+		if !slices.Contains(imports, typePath) {
+			imports = append(imports, typePath)
+		}
+	}
+	sort.Strings(imports)
+
+	matched := make(map[string]bool, len(overrides))
+	matchOverrides(t, "", overrides, matched, make(map[*map[string]yema.Type]bool))
+
+	var unmatched []string
+	for path := range overrides {
+		if !matched[path] {
+			unmatched = append(unmatched, path)
+		}
+	}
+	if len(unmatched) > 0 {
+		sort.Strings(unmatched)
+		return nil, nil, fmt.Errorf("type overrides of %s match no field, paths look like address.zip or tags[]", strings.Join(unmatched, ", "))
+	}
+	return overrides, imports, nil
+}
+
+// matchOverrides marks the overrides of paths in t, without descending into the types they replace.
+// Ancestors are the structs t is in, structs containing themselves are not descended into again.
+func matchOverrides(t *yema.Type, path string, overrides map[string]string, matched map[string]bool, ancestors map[*map[string]yema.Type]bool) {
+	if overridden(path, overrides) {
+		matched[path] = true
+		return
+	}
+
+	switch {
+	case t.Kind == yema.Array && t.Array != nil && path != "":
+		matchOverrides(t.Array, path+"[]", overrides, matched, ancestors)
+	case t.Kind == yema.Map && t.Map != nil && path != "":
+		matchOverrides(t.Map, path+"[]", overrides, matched, ancestors)
+	case t.Kind == yema.Union:
+		for i := range t.Union {
+			matchOverrides(&t.Union[i], path, overrides, matched, ancestors)
+		}
+	case t.Kind == yema.Struct && t.Struct != nil && !ancestors[t.Struct]:
+		ancestors[t.Struct] = true
+		defer delete(ancestors, t.Struct)
+		for name, field := range *t.Struct {
+			matchOverrides(&field, fieldpath.Join(path, name), overrides, matched, ancestors)
+		}
+	}
+}
+
+// overridden reports whether the type at path is overridden, see Options.TypeOverrides
+func overridden(path string, overrides map[string]string) bool {
+	_, ok := overrides[path]
+	return ok && path != ""
+}
+
+// checkTypeNames reports derives and attributes given for types that are not generated, and imported types
+// named like a generated type
+func checkTypeNames(generated ident.Types, opts Options) error {
+	var unmatched []string
+	for name := range opts.TypeDerives {
+		if _, ok := generated[name]; !ok {
+			unmatched = append(unmatched, name)
+		}
+	}
+	for name := range opts.TypeAttributes {
+		if _, ok := generated[name]; !ok && !slices.Contains(unmatched, name) {
+			unmatched = append(unmatched, name)
+		}
+	}
+	if len(unmatched) > 0 {
+		sort.Strings(unmatched)
+		return fmt.Errorf("derives and attributes of %s match no generated struct or enum", strings.Join(unmatched, ", "))
+	}
+
+	for _, path := range opts.imports {
+		name := path[strings.LastIndex(path, "::")+2:]
+		if _, ok := generated[name]; ok {
+			return fmt.Errorf("type override %s is named like the generated type %s, give its full path as ::%s", path, name, path)
+		}
+	}
+	return nil
+}
+
+// writeImports writes the use declarations of the types of overrides
+func writeImports(buf *bytes.Buffer, opts Options) {
+	for _, path := range opts.imports {
+		fmt.Fprintf(buf, "    use %s;\n", path)
+	}
+}
@@ -3,22 +3,74 @@ package rust
 import (
 	"bytes"
 	"fmt"
+	"slices"
 	"strings"
-	"unicode"
+	"unicode/utf8"
 
 	"github.com/aep/yema"
+	"github.com/aep/yema/internal/fieldpath"
+	"github.com/aep/yema/internal/ident"
+	"github.com/aep/yema/validator"
 )
 
 // Options holds configuration options for Rust code generation
 type Options struct {
 	// Module is the name of the Rust module to generate
 	Module string
-	// RootType is the name of the root struct type
+	// RootType is the name of the root type. If the root is an array, it names the element type
+	// and the root itself is named RootType + "List".
 	RootType string
 	// DeriveTraits specifies which traits to automatically derive for structs
 	DeriveTraits []string
 	// UseSerdeRename determines whether to use serde rename attributes for JSON field names
 	UseSerdeRename bool
+	// Validators determines whether to generate a validate method on the root struct
+	Validators bool
+	// Transliterate derives identifiers from ASCII transliterations of non-ASCII field names
+	Transliterate bool
+	// BTreeMaps generates a BTreeMap for every map instead of a HashMap, which serializes its keys in order
+	BTreeMaps bool
+	// Enums generates an enum for every string restricted to an $enum, named like nested structs,
+	// with a variant for every value instead of a String
+	Enums bool
+	// EncodedBytes generates a newtype for every bytes, named like nested structs and written as a string in the
+	// encoding of its format, base64 if it declares none, instead of a Vec<u8> written as an array of numbers
+	EncodedBytes bool
+	// TimeCrate maps timestamps in RFC 3339 and dates in the layout 2006-01-02 to the types of the crate, and
+	// durations to std::time::Duration, instead of a String. Timestamps in other layouts stay a String.
+	TimeCrate TimeCrate
+	// NoStd generates code for no_std crates declaring extern crate alloc. Types of std become those
+	// of alloc and core, maps are BTreeMaps as alloc has no HashMap, and serde is only derived with the serde
+	// feature of the crate. Strings and lists stay on the heap, the schema bounds no lengths for heapless types.
+	NoStd bool
+	// ValidatorCrate derives Validate of the validator crate for structs, validating uri strings as URLs and
+	// the structs in fields. Integer bounds are guaranteed by the Rust types, the schema has no lengths or patterns.
+	ValidatorCrate bool
+	// URLCrate generates a url::Url of the url crate for every uri string, which is parsed when read, instead of
+	// a String. Strings in the uuid format are a uuid::Uuid of the uuid crate regardless.
+	URLCrate bool
+	// DefaultCollections generates optional lists and maps as collections that are empty if the field is missing,
+	// instead of an Option, for APIs treating missing and empty alike. Empty collections are left out when written.
+	DefaultCollections bool
+	// DefaultStrings generates optional strings held by a String as one that is empty if the field is missing,
+	// like DefaultCollections
+	DefaultStrings bool
+	// TypeOverrides replaces the Rust types generated for fields of the root struct by path, such as address.zip
+	// or tags[] for the items of a list or the values of a map, with Rust types such as rust_decimal::Decimal,
+	// which are imported into the module. Optional fields hold an Option of them. Their crates are not added to
+	// the Cargo.toml of ToRustCrate.
+	TypeOverrides map[string]string
+	// TypeDerives adds traits to the derive attribute of generated structs and enums by their name
+	TypeDerives map[string][]string
+	// TypeAttributes adds attributes to generated structs and enums by their name, such as serde(deny_unknown_fields)
+	TypeAttributes map[string][]string
+
+	// overrides are the types of TypeOverrides as written in the code, imports the paths imported for them
+	overrides map[string]string
+	imports   []string
+	// structs are the names of the structs being generated by their fields. Types built in Go may contain
+	// a struct within itself, which the parser rejects, and refer to it by name.
+	structs map[*map[string]yema.Type]string
 }
 
 // ToRustWithOptions converts a yema.Type to Rust struct definitions with custom options
@@ -26,20 +78,31 @@ func ToRust(t *yema.Type, opts Options) ([]byte, error) {
 	if t == nil {
 		return nil, fmt.Errorf("nil type provided")
 	}
-
-	if t.Kind != yema.Struct {
-		return nil, fmt.Errorf("expected root type to be Struct, got %v", t.Kind)
+	if err := checkTimeCrate(opts); err != nil {
+		return nil, err
 	}
 
-	// Use default values if not provided
-	if opts.Module == "" {
-		opts.Module = "generated"
+	opts = withDefaults(opts)
+	if opts.ValidatorCrate && opts.NoStd {
+		return nil, fmt.Errorf("the validator crate requires std")
 	}
-	if opts.RootType == "" {
-		opts.RootType = "Root"
+	if opts.URLCrate && opts.NoStd {
+		return nil, fmt.Errorf("the url crate requires std")
 	}
-	if len(opts.DeriveTraits) == 0 {
-		opts.DeriveTraits = []string{"Debug", "Clone", "Serialize", "Deserialize"}
+	if opts.Validators && isRecursive(t, make(map[*map[string]yema.Type]bool)) {
+		return nil, fmt.Errorf("validators do not support structs containing themselves")
+	}
+	opts.structs = make(map[*map[string]yema.Type]string)
+
+	// A root array is a list of its element type, which is named RootType
+	elem, depth := rootElem(t)
+	if len(opts.TypeOverrides) > 0 && elem.Kind != yema.Struct {
+		return nil, fmt.Errorf("type overrides require a root struct, got %v", elem.Kind)
+	}
+	var err error
+	opts.overrides, opts.imports, err = parseOverrides(elem, opts.TypeOverrides)
+	if err != nil {
+		return nil, err
 	}
 
 	var buf bytes.Buffer
@@ -47,87 +110,295 @@ func ToRust(t *yema.Type, opts Options) ([]byte, error) {
 	// Add module declaration
 	if opts.Module != "" {
 		buf.WriteString(fmt.Sprintf("pub mod %s {\n", opts.Module))
+		if opts.NoStd {
+			writeAllocImports(&buf, "    ")
+		}
 		// Add serde import if we're using it
-		if containsTrait(opts.DeriveTraits, "Serialize") || containsTrait(opts.DeriveTraits, "Deserialize") {
-			buf.WriteString("    use serde::{Serialize, Deserialize};\n\n")
+		if usesSerde(opts) {
+			if opts.NoStd {
+				buf.WriteString("    #[cfg(feature = \"serde\")]\n")
+			}
+			buf.WriteString("    use serde::{Serialize, Deserialize};\n")
+		}
+		if opts.ValidatorCrate {
+			buf.WriteString("    use validator::Validate;\n")
+		}
+		writeImports(&buf, opts)
+		if opts.NoStd || usesSerde(opts) || opts.ValidatorCrate || len(opts.imports) > 0 {
+			buf.WriteString("\n")
 		}
 	}
 
-	// Process the root struct
-	err := generateStructs(t, opts.RootType, &buf, make(map[string]bool), opts, 1)
-	if err != nil {
+	if depth > 0 {
+		fmt.Fprintf(&buf, "    /// %sList is a list of %s\n", opts.RootType, opts.RootType)
+		fmt.Fprintf(&buf, "    pub type %sList = %s%s%s;\n\n", opts.RootType, strings.Repeat("Vec<", depth), opts.RootType, strings.Repeat(">", depth))
+	}
+
+	generated := make(ident.Types)
+	switch {
+	case elem.Kind == yema.Union || opts.Enums && isStringEnum(elem) || isTimeNewtype(elem, opts) || bytesEncoding(elem, opts) != "":
+		root := *elem
+		root.Optional = false
+		if err := generateNested(nestedType{opts.RootType, "", &root}, &buf, generated, opts, 1); err != nil {
+			return nil, err
+		}
+	case elem.Kind == yema.Struct:
+		// Process the root struct
+		err := generateStructs(elem, opts.RootType, "", &buf, generated, opts, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		// Validators check a single element of root arrays, Rust does not allow methods on Vec
+		if opts.Validators {
+			if err := generateValidator(elem, opts.RootType, &buf, opts, 1); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		scalar := *elem
+		scalar.Optional = false
+		rustType, _, err := typeToRustType(&scalar, opts.RootType, "", "", opts)
+		if err != nil {
+			return nil, err
+		}
+		writeDoc(&buf, "    ", elem, opts.RootType+" represents a generated type")
+		fmt.Fprintf(&buf, "    pub type %s = %s;\n\n", opts.RootType, rustType)
+	}
+	if err := checkTypeNames(generated, opts); err != nil {
 		return nil, err
 	}
 
 	// Close module if needed
 	if opts.Module != "" {
+		if usesSerde(opts) {
+			mods := serdeModules(t, opts)
+			writeDurationModules(&buf, mods, opts)
+			writeEncodingModules(&buf, mods, opts)
+		}
 		buf.WriteString("}\n")
 	}
 
 	return buf.Bytes(), nil
 }
 
-// generateStructs recursively generates Rust struct definitions
-func generateStructs(t *yema.Type, structName string, buf *bytes.Buffer, generatedStructs map[string]bool, opts Options, indentLevel int) error {
-	if t.Kind != yema.Struct {
-		return fmt.Errorf("expected Struct type, got %v", t.Kind)
+// withDefaults returns opts with default values for the options not provided
+func withDefaults(opts Options) Options {
+	if opts.Module == "" {
+		opts.Module = "generated"
 	}
+	if opts.RootType == "" {
+		opts.RootType = "Root"
+	}
+	if len(opts.DeriveTraits) == 0 {
+		opts.DeriveTraits = []string{"Debug", "Clone", "Serialize", "Deserialize"}
+	}
+	return opts
+}
+
+// rootElem returns the element of a root array and the depth of its arrays, or t itself if it is no array
+func rootElem(t *yema.Type) (*yema.Type, int) {
+	depth := 0
+	for t.Kind == yema.Array && t.Array != nil {
+		t = t.Array
+		depth++
+	}
+	return t, depth
+}
+
+// nestedType is a nested struct, enum or union that still needs to be generated
+type nestedType struct {
+	name string
+	// path is the path of the type in the schema, see Options.TypeOverrides
+	path string
+	t    *yema.Type
+}
 
-	// Don't regenerate structs we've already processed
-	if generatedStructs[structName] {
+// nestedElem returns the type to generate for a value of t at path, the item of arrays and the value of maps,
+// without being optional, and its path
+func nestedElem(t *yema.Type, path string) (*yema.Type, string) {
+	for t.Kind == yema.Array || t.Kind == yema.Map {
+		if t.Kind == yema.Array {
+			t = t.Array
+		} else {
+			t = t.Map
+		}
+		path += "[]"
+	}
+	if t.Kind == yema.Struct {
+		return &yema.Type{
+			Kind:        yema.Struct,
+			Struct:      t.Struct,
+			Order:       t.Order,
+			Description: t.Description,
+			Example:     t.Example,
+		}, path
+	}
+	elem := *t
+	elem.Optional = false
+	return &elem, path
+}
+
+// walkTypes calls visit for t at path and every type in it in order, visiting every struct once.
+// Overridden types are not visited, as no code is generated for them.
+func walkTypes(t *yema.Type, path string, opts Options, visit func(*yema.Type), seen map[*map[string]yema.Type]bool) {
+	if overridden(path, opts.overrides) {
+		return
+	}
+	visit(t)
+	switch t.Kind {
+	case yema.Array:
+		walkTypes(t.Array, path+"[]", opts, visit, seen)
+	case yema.Map:
+		walkTypes(t.Map, path+"[]", opts, visit, seen)
+	case yema.Union:
+		for i := range t.Union {
+			walkTypes(&t.Union[i], path, opts, visit, seen)
+		}
+	case yema.Struct:
+		if seen[t.Struct] {
+			return
+		}
+		seen[t.Struct] = true
+		for _, name := range t.FieldNames() {
+			field := (*t.Struct)[name]
+			walkTypes(&field, fieldpath.Join(path, name), opts, visit, seen)
+		}
+	}
+}
+
+// serdeModules returns the paths of the serde modules generated with the code that values in t are read and written by
+func serdeModules(t *yema.Type, opts Options) map[string]bool {
+	mods := make(map[string]bool)
+	elem, _ := rootElem(t)
+	walkTypes(elem, "", opts, func(t *yema.Type) {
+		if _, with := timeType(t, opts); strings.HasPrefix(with, "durations::") {
+			mods[with] = true
+		}
+		if with := bytesEncoding(t, opts); with != "" {
+			mods[with] = true
+		}
+	}, make(map[*map[string]yema.Type]bool))
+	return mods
+}
+
+// generateNested generates a nested type unless it was generated already. Types of other paths named alike,
+// such as those of the fields a.bC and ab.c, are reported.
+func generateNested(nested nestedType, buf *bytes.Buffer, generatedStructs ident.Types, opts Options, indentLevel int) error {
+	if nested.t.Kind == yema.Struct {
+		return generateStructs(nested.t, nested.name, nested.path, buf, generatedStructs, opts, indentLevel)
+	}
+	if fresh, err := generatedStructs.Claim(nested.name, nested.path); !fresh {
+		return err
+	}
+	if nested.t.Kind == yema.Union {
+		return generateUnion(nested.t, nested.name, nested.path, buf, generatedStructs, opts, indentLevel)
+	}
+	if isTimeNewtype(nested.t, opts) {
+		writeTimeType(buf, nested.name, nested.t, opts, strings.Repeat("    ", indentLevel))
 		return nil
 	}
+	if nested.t.Kind == yema.Bytes {
+		writeBytesType(buf, nested.name, nested.t, opts, strings.Repeat("    ", indentLevel))
+		return nil
+	}
+	return writeEnum(buf, nested.name, nested.t, opts, strings.Repeat("    ", indentLevel))
+}
 
-	// Mark this struct as generated
-	generatedStructs[structName] = true
+// generateStructs recursively generates Rust struct definitions for the struct at path
+func generateStructs(t *yema.Type, structName, path string, buf *bytes.Buffer, generatedStructs ident.Types, opts Options, indentLevel int) error {
+	if t.Kind != yema.Struct {
+		return fmt.Errorf("expected Struct type, got %v", t.Kind)
+	}
+
+	// Don't regenerate structs we've already processed, or structs of other paths named alike
+	if fresh, err := generatedStructs.Claim(structName, path); !fresh {
+		return err
+	}
+	// Structs nested in it may contain it
+	opts.structs[t.Struct] = structName
+	defer delete(opts.structs, t.Struct)
 
 	indent := strings.Repeat("    ", indentLevel)
 
 	// Add derive attributes if any provided
-	if len(opts.DeriveTraits) > 0 {
-		fmt.Fprintf(buf, "%s#[derive(%s)]\n", indent, strings.Join(opts.DeriveTraits, ", "))
+	derives := opts.DeriveTraits
+	if opts.ValidatorCrate {
+		derives = append(slices.Clip(derives), "Validate")
+	}
+	writeDerive(buf, structName, derives, opts, indent)
+
+	rustFieldNames, err := ident.Fields(t, ident.Snake, opts.Transliterate)
+	if err != nil {
+		return fmt.Errorf("struct %s: %w", structName, err)
 	}
+	rule := fieldRenameRule(rustFieldNames)
 
 	// Start struct definition
-	fmt.Fprintf(buf, "%s/// %s represents a generated struct\n", indent, structName)
+	writeDoc(buf, indent, t, structName+" represents a generated struct")
+	if opts.UseSerdeRename && rule.name != "" {
+		fmt.Fprintf(buf, "%s%s\n", indent, serdeAttr(fmt.Sprintf("rename_all = %q", rule.name), opts))
+	}
 	fmt.Fprintf(buf, "%spub struct %s {\n", indent, structName)
 
 	// Track any nested structs we need to generate
-	nestedStructs := make(map[string]*yema.Type)
+	var nestedStructs []nestedType
 
 	// Process all fields in the struct
-	for fieldName, fieldType := range *t.Struct {
-		rustFieldName := toSnakeCase(fieldName)
-		rustFieldType, nestedName, err := typeToRustType(&fieldType, structName, fieldName)
+	for _, fieldName := range t.FieldNames() {
+		fieldType := (*t.Struct)[fieldName]
+		rustFieldName := rustFieldNames[fieldName]
+		typeIdent := ident.Camel(ident.Source(fieldName, fieldType.CodeName, opts.Transliterate))
+		fieldPath := fieldpath.Join(path, fieldName)
+		defaulted := defaultsEmpty(&fieldType, fieldPath, opts)
+		if defaulted {
+			fieldType.Optional = false
+		}
+		rustFieldType, nestedName, err := typeToRustType(&fieldType, structName, typeIdent, fieldPath, opts)
 		if err != nil {
 			return err
 		}
+		if !overridden(fieldPath, opts.overrides) {
+			rustFieldType = boxRecursive(&fieldType, rustFieldType, opts)
+		}
 
-		// Check if this field requires a nested struct to be generated
-		if nestedName != "" && fieldType.Kind == yema.Struct {
-			nestedStructs[nestedName] = &yema.Type{
-				Kind:   yema.Struct,
-				Struct: fieldType.Struct,
-			}
-		} else if nestedName != "" && fieldType.Kind == yema.Array && fieldType.Array.Kind == yema.Struct {
-			nestedStructs[nestedName] = &yema.Type{
-				Kind:   yema.Struct,
-				Struct: fieldType.Array.Struct,
-			}
+		// Check if this field requires a nested struct, enum or union to be generated, possibly as the item of arrays
+		// or the value of maps
+		if nestedName != "" {
+			elem, elemPath := nestedElem(&fieldType, fieldPath)
+			nestedStructs = append(nestedStructs, nestedType{nestedName, elemPath, elem})
 		}
 
 		// Add field documentation
-		fmt.Fprintf(buf, "%s    /// %s field\n", indent, fieldName)
-
-		// Add serde rename attribute if the field name is different from JSON field
-		if opts.UseSerdeRename && rustFieldName != fieldName {
-			if fieldType.Optional {
-				fmt.Fprintf(buf, "%s    #[serde(rename = \"%s\", skip_serializing_if = \"Option::is_none\")]\n", indent, fieldName)
-			} else {
-				fmt.Fprintf(buf, "%s    #[serde(rename = \"%s\")]\n", indent, fieldName)
-			}
-		} else if opts.UseSerdeRename && fieldType.Optional {
-			fmt.Fprintf(buf, "%s    #[serde(skip_serializing_if = \"Option::is_none\")]\n", indent)
+		writeDoc(buf, indent+"    ", &fieldType, fieldName+" field")
+		if fieldType.ReadOnly {
+			fmt.Fprintf(buf, "%s    ///\n%s    /// Read-only, never accepted in requests\n", indent, indent)
+		} else if fieldType.WriteOnly {
+			fmt.Fprintf(buf, "%s    ///\n%s    /// Write-only, never sent in responses\n", indent, indent)
+		}
+
+		// Add serde rename attribute if the rule of the struct does not map the field name to the JSON field
+		var serdeArgs []string
+		if opts.UseSerdeRename && rule.convert(rustFieldName) != fieldName {
+			serdeArgs = append(serdeArgs, fmt.Sprintf("rename = \"%s\"", fieldName))
+		}
+		if defaulted && usesSerde(opts) {
+			serdeArgs = append(serdeArgs, "default")
+		}
+		switch {
+		case opts.UseSerdeRename && fieldType.Optional:
+			serdeArgs = append(serdeArgs, "skip_serializing_if = \"Option::is_none\"")
+		case opts.UseSerdeRename && defaulted:
+			// Vec, String and the maps all have an is_empty method
+			collection, _, _ := strings.Cut(rustFieldType, "<")
+			serdeArgs = append(serdeArgs, fmt.Sprintf("skip_serializing_if = \"%s::is_empty\"", collection))
+		}
+		if len(serdeArgs) > 0 {
+			fmt.Fprintf(buf, "%s    %s\n", indent, serdeAttr(strings.Join(serdeArgs, ", "), opts))
+		}
+
+		if args := validateArgs(&fieldType, fieldPath, opts); args != "" {
+			fmt.Fprintf(buf, "%s    #[validate(%s)]\n", indent, args)
 		}
 
 		// Write field definition
@@ -137,10 +408,9 @@ func generateStructs(t *yema.Type, structName string, buf *bytes.Buffer, generat
 	// Close struct definition
 	fmt.Fprintf(buf, "%s}\n\n", indent)
 
-	// Generate any nested struct definitions
-	for nestedName, nestedStruct := range nestedStructs {
-		err := generateStructs(nestedStruct, nestedName, buf, generatedStructs, opts, indentLevel)
-		if err != nil {
+	// Generate any nested struct, enum and union definitions
+	for _, nested := range nestedStructs {
+		if err := generateNested(nested, buf, generatedStructs, opts, indentLevel); err != nil {
 			return err
 		}
 	}
@@ -148,11 +418,73 @@ func generateStructs(t *yema.Type, structName string, buf *bytes.Buffer, generat
 	return nil
 }
 
-// typeToRustType converts a yema.Type to a Rust type string
-func typeToRustType(t *yema.Type, parentName, fieldName string) (string, string, error) {
+// fieldRenameRules are the values of serde's rename_all for structs in the order they are preferred, with the
+// conversion serde applies to the snake_case identifiers of fields. The first leaves the identifiers as they are.
+var fieldRenameRules = []renameRule{
+	{"", func(s string) string { return s }},
+	{"camelCase", func(s string) string {
+		s = serdePascal(s)
+		if s == "" || s[0] >= utf8.RuneSelf {
+			return s
+		}
+		return asciiLower(s[:1]) + s[1:]
+	}},
+	{"PascalCase", serdePascal},
+	{"kebab-case", func(s string) string { return strings.ReplaceAll(s, "_", "-") }},
+	{"SCREAMING_SNAKE_CASE", asciiUpper},
+	{"SCREAMING-KEBAB-CASE", func(s string) string { return asciiUpper(strings.ReplaceAll(s, "_", "-")) }},
+}
+
+// serdePascal converts a snake_case identifier like serde does, capitalizing the letter after every '_'
+func serdePascal(s string) string {
+	var b strings.Builder
+	capitalize := true
+	for _, c := range s {
+		switch {
+		case c == '_':
+			capitalize = true
+		case capitalize:
+			b.WriteString(asciiUpper(string(c)))
+			capitalize = false
+		default:
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// fieldRenameRule returns the rename rule mapping most identifiers of rustFieldNames to the names of their fields,
+// so only the other fields are renamed one by one
+func fieldRenameRule(rustFieldNames map[string]string) renameRule {
+	rule, best := fieldRenameRules[0], -1
+	for _, r := range fieldRenameRules {
+		matches := 0
+		for fieldName, rustFieldName := range rustFieldNames {
+			if r.convert(rustFieldName) == fieldName {
+				matches++
+			}
+		}
+		if matches > best {
+			rule, best = r, matches
+		}
+	}
+	return rule
+}
+
+// typeToRustType converts a yema.Type to a Rust type string, nested structs, enums and unions are named
+// after the parent and typeIdent. Types overridden for the path are used as they are.
+func typeToRustType(t *yema.Type, parentName, typeIdent, path string, opts Options) (string, string, error) {
 	var rustType string
 	var nestedStructName string
 
+	if overridden(path, opts.overrides) {
+		rustType = opts.overrides[path]
+		if t.Optional {
+			rustType = "Option<" + rustType + ">"
+		}
+		return rustType, "", nil
+	}
+
 	switch t.Kind {
 	case yema.Bool:
 		rustType = "bool"
@@ -182,21 +514,57 @@ func typeToRustType(t *yema.Type, parentName, fieldName string) (string, string,
 		rustType = "f64"
 	case yema.String:
 		rustType = "String"
+		if t.Format == validator.UUIDFormat {
+			// Requires the uuid crate with its serde feature
+			rustType = "uuid::Uuid"
+		} else if opts.Enums && isStringEnum(t) || isTimeNewtype(t, opts) {
+			nestedStructName = parentName + typeIdent
+			rustType = nestedStructName
+		} else if mapped, _ := timeType(t, opts); mapped != "" {
+			rustType = mapped
+		} else if isURL(t, opts) {
+			// Requires the url crate with its serde feature
+			rustType = "url::Url"
+		}
 	case yema.Bytes:
 		rustType = "Vec<u8>"
+		if bytesEncoding(t, opts) != "" {
+			nestedStructName = parentName + typeIdent
+			rustType = nestedStructName
+		}
 	case yema.Array:
 		if t.Array == nil {
 			return "", "", fmt.Errorf("array type with nil Array field")
 		}
-		elemType, elemNestedName, err := typeToRustType(t.Array, parentName, fieldName)
+		elemType, elemNestedName, err := typeToRustType(t.Array, parentName, typeIdent, path+"[]", opts)
 		if err != nil {
 			return "", "", err
 		}
 		rustType = "Vec<" + elemType + ">"
 		nestedStructName = elemNestedName
-	case yema.Struct:
-		// Create a name for the nested struct
-		nestedStructName = parentName + toCamelCase(fieldName)
+	case yema.Map:
+		if t.Map == nil {
+			return "", "", fmt.Errorf("map type with nil Map field")
+		}
+		valueType, valueNestedName, err := typeToRustType(t.Map, parentName, typeIdent, path+"[]", opts)
+		if err != nil {
+			return "", "", err
+		}
+		mapType := "std::collections::HashMap"
+		if opts.NoStd {
+			mapType = "alloc::collections::BTreeMap"
+		} else if opts.BTreeMaps {
+			mapType = "std::collections::BTreeMap"
+		}
+		rustType = mapType + "<String, " + valueType + ">"
+		nestedStructName = valueNestedName
+	case yema.Struct, yema.Union:
+		if name, ok := opts.structs[t.Struct]; ok && t.Kind == yema.Struct {
+			rustType = name
+			break
+		}
+		// Create a name for the nested struct or union
+		nestedStructName = parentName + typeIdent
 		rustType = nestedStructName
 	default:
 		return "", "", fmt.Errorf("unexpected type kind: %v", t.Kind)
@@ -209,42 +577,94 @@ func typeToRustType(t *yema.Type, parentName, fieldName string) (string, string,
 	return rustType, nestedStructName, nil
 }
 
-// toCamelCase converts a string to CamelCase
-func toCamelCase(s string) string {
-	var result string
-	nextUpper := true
+// boxRecursive boxes rustType, the type of a value of t held by a struct or union, if t is a struct containing it.
+// Lists and maps already hold their values on the heap.
+func boxRecursive(t *yema.Type, rustType string, opts Options) string {
+	name, ok := opts.structs[t.Struct]
+	if !ok || t.Kind != yema.Struct {
+		return rustType
+	}
+	if t.Optional {
+		return "Option<Box<" + name + ">>"
+	}
+	return "Box<" + name + ">"
+}
 
-	for _, char := range s {
-		if char == '_' || char == '-' || char == ' ' {
-			nextUpper = true
-			continue
-		}
+// isURL reports whether t is a uri string generated as a url::Url, see Options.URLCrate. Enums take precedence.
+func isURL(t *yema.Type, opts Options) bool {
+	return opts.URLCrate && t.Kind == yema.String && t.Format == validator.URIFormat && !(opts.Enums && isStringEnum(t))
+}
 
-		if nextUpper {
-			result += string(unicode.ToUpper(char))
-			nextUpper = false
-		} else {
-			result += string(char)
-		}
+// defaultsEmpty reports whether the optional field of t at path is generated as a value that is empty if it is
+// missing, see Options.DefaultCollections and Options.DefaultStrings
+func defaultsEmpty(t *yema.Type, path string, opts Options) bool {
+	if !t.Optional || overridden(path, opts.overrides) {
+		return false
 	}
+	switch t.Kind {
+	case yema.Array, yema.Map:
+		return opts.DefaultCollections
+	case yema.String:
+		mapped, _ := timeType(t, opts)
+		return opts.DefaultStrings && t.Format != validator.UUIDFormat && !(opts.Enums && isStringEnum(t)) && mapped == "" && !isURL(t, opts)
+	}
+	return false
+}
 
-	return result
+// validateArgs returns the arguments of the validate attribute of a struct field of t at path, see
+// Options.ValidatorCrate. Fields holding structs validate them, unless they are boxed structs containing the field.
+// Overridden types are not validated.
+func validateArgs(t *yema.Type, path string, opts Options) string {
+	if !opts.ValidatorCrate || overridden(path, opts.overrides) {
+		return ""
+	}
+	// A url::Url is a URL already, see Options.URLCrate
+	if t.Kind == yema.String && t.Format == validator.URIFormat && !(opts.Enums && isStringEnum(t)) && !opts.URLCrate {
+		return "url"
+	}
+	if _, boxed := opts.structs[t.Struct]; boxed && t.Kind == yema.Struct {
+		return ""
+	}
+	elem, elemPath := nestedElem(t, path)
+	// Items and values may be overridden by types of their own
+	for p := elemPath; p != path; p = strings.TrimSuffix(p, "[]") {
+		if overridden(p, opts.overrides) {
+			return ""
+		}
+	}
+	if elem.Kind == yema.Struct {
+		return "nested"
+	}
+	return ""
 }
 
-// toSnakeCase converts a string to snake_case
-func toSnakeCase(s string) string {
-	var result string
-	for i, char := range s {
-		if unicode.IsUpper(char) {
-			if i > 0 {
-				result += "_"
+// isRecursive reports whether a struct in t contains itself, ancestors are the structs t is in
+func isRecursive(t *yema.Type, ancestors map[*map[string]yema.Type]bool) bool {
+	switch t.Kind {
+	case yema.Array:
+		return isRecursive(t.Array, ancestors)
+	case yema.Map:
+		return isRecursive(t.Map, ancestors)
+	case yema.Union:
+		for i := range t.Union {
+			if isRecursive(&t.Union[i], ancestors) {
+				return true
+			}
+		}
+	case yema.Struct:
+		if ancestors[t.Struct] {
+			return true
+		}
+		ancestors[t.Struct] = true
+		defer delete(ancestors, t.Struct)
+		for _, name := range t.FieldNames() {
+			field := (*t.Struct)[name]
+			if isRecursive(&field, ancestors) {
+				return true
 			}
-			result += string(unicode.ToLower(char))
-		} else {
-			result += string(char)
 		}
 	}
-	return result
+	return false
 }
 
 // containsTrait checks if a trait is in the derive list
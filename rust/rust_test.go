@@ -1,9 +1,11 @@
 package rust
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/aep/yema"
+	"github.com/aep/yema/validator"
 )
 
 func TestToRustSimple(t *testing.T) {
@@ -32,7 +34,7 @@ func TestToRustSimple(t *testing.T) {
 	}
 
 	// Convert to Rust
-	result, err := ToRust(yemaType)
+	result, err := ToRust(yemaType, Options{})
 	if err != nil {
 		t.Fatalf("ToRust failed: %v", err)
 	}
@@ -88,11 +90,749 @@ func TestToRustNested(t *testing.T) {
 		DeriveTraits: []string{"Debug", "Clone", "Serialize", "Deserialize", "PartialEq"},
 	}
 
-	result, err := ToRustWithOptions(yemaType, options)
+	result, err := ToRust(yemaType, options)
 	if err != nil {
-		t.Fatalf("ToRustWithOptions failed: %v", err)
+		t.Fatalf("ToRust failed: %v", err)
 	}
 
 	// Print the result for inspection
 	t.Logf("Generated Rust code with options:\n%s", string(result))
-}
\ No newline at end of file
+}
+
+func TestToRustTypeNames(t *testing.T) {
+	// The fields fooBar and foo.bar both name a struct RootFooBar
+	schema := &yema.Type{Kind: yema.Struct, Order: []string{"fooBar", "foo"}, Struct: &map[string]yema.Type{
+		"fooBar": {Kind: yema.Struct, Struct: &map[string]yema.Type{"a": {Kind: yema.Int}}},
+		"foo": {Kind: yema.Struct, Struct: &map[string]yema.Type{
+			"bar": {Kind: yema.Struct, Struct: &map[string]yema.Type{"b": {Kind: yema.String}}},
+		}},
+	}}
+	_, err := ToRust(schema, Options{})
+	if err == nil || !strings.Contains(err.Error(), "type name RootFooBar generated for both fooBar and foo.bar") {
+		t.Errorf("expected a collision of RootFooBar, got %v", err)
+	}
+}
+
+func TestToRustValidators(t *testing.T) {
+	yemaType := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"name": {Kind: yema.String},
+			"age":  {Kind: yema.Int8, Optional: true},
+		},
+	}
+
+	result, err := ToRust(yemaType, Options{RootType: "Person", Validators: true})
+	if err != nil {
+		t.Fatalf("ToRust failed: %v", err)
+	}
+
+	out := string(result)
+	if !strings.Contains(out, "impl Person {") || !strings.Contains(out, "pub fn validate(&self) -> Result<(), Vec<String>> {") {
+		t.Errorf("missing validate method:\n%s", out)
+	}
+
+	// The range of int8 is guaranteed by i8, so no field is bound to be checked
+	if strings.Contains(out, "127") || strings.Contains(out, "let v") || strings.Contains(out, "if let Some") {
+		t.Errorf("unexpected range check implied by the Rust type:\n%s", out)
+	}
+}
+
+func TestToRustRoots(t *testing.T) {
+	list := &yema.Type{Kind: yema.Array, Array: &yema.Type{Kind: yema.String}}
+	result, err := ToRust(list, Options{RootType: "Tag"})
+	if err != nil {
+		t.Fatalf("ToRust failed: %v", err)
+	}
+
+	out := string(result)
+	for _, want := range []string{"pub type TagList = Vec<Tag>;", "pub type Tag = String;"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestToRustUUID(t *testing.T) {
+	yemaType := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"id":     {Kind: yema.String, Format: "uuid"},
+			"parent": {Kind: yema.String, Format: "uuid", Optional: true},
+		},
+	}
+
+	result, err := ToRust(yemaType, Options{})
+	if err != nil {
+		t.Fatalf("ToRust failed: %v", err)
+	}
+
+	out := string(result)
+	for _, want := range []string{"pub id: uuid::Uuid,", "pub parent: Option<uuid::Uuid>,"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestToRustEnums(t *testing.T) {
+	yemaType := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"status": {Kind: yema.String, Enum: []interface{}{"in_progress", "done", "self"}},
+			"levels": {Kind: yema.Array, Optional: true, Array: &yema.Type{Kind: yema.String, Enum: []interface{}{"Low", "High"}}},
+			"id":     {Kind: yema.String, Format: "uuid", Enum: []interface{}{"0b5e1d5c-57c5-4d5c-9a65-2d1f0f7c3a10"}},
+		},
+	}
+
+	result, err := ToRust(yemaType, Options{Enums: true})
+	if err != nil {
+		t.Fatalf("ToRust failed: %v", err)
+	}
+
+	out := string(result)
+	for _, want := range []string{
+		"pub status: RootStatus,",
+		"pub levels: Option<Vec<RootLevels>>,",
+		"pub id: uuid::Uuid,",
+		"    #[serde(rename_all = \"snake_case\")]\n    pub enum RootStatus {\n        InProgress,\n        Done,\n        #[serde(rename = \"self\")]\n        Self_,\n    }",
+		"    /// RootLevels is one of the values of an enum\n    pub enum RootLevels {\n        Low,\n        High,\n    }",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+
+	// Enums are strings unless enabled
+	result, err = ToRust(yemaType, Options{})
+	if err != nil {
+		t.Fatalf("ToRust failed: %v", err)
+	}
+	if !strings.Contains(string(result), "pub status: String,") {
+		t.Errorf("expected a String without Enums:\n%s", result)
+	}
+
+	collision := &yema.Type{Kind: yema.String, Enum: []interface{}{"a-b", "a_b"}}
+	if _, err := ToRust(collision, Options{Enums: true}); err == nil || !strings.Contains(err.Error(), "both map to the variant AB") {
+		t.Errorf("expected a variant collision, got %v", err)
+	}
+}
+
+func TestToRustUnions(t *testing.T) {
+	yemaType := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"id": {Kind: yema.Union, Union: []yema.Type{{Kind: yema.String}, {Kind: yema.Int64}}},
+			"target": {Kind: yema.Union, Optional: true, Union: []yema.Type{
+				{Kind: yema.Struct, Struct: &map[string]yema.Type{"street": {Kind: yema.String}}},
+				{Kind: yema.Struct, Struct: &map[string]yema.Type{"lat": {Kind: yema.Float64}}},
+			}},
+		},
+	}
+
+	result, err := ToRust(yemaType, Options{})
+	if err != nil {
+		t.Fatalf("ToRust failed: %v", err)
+	}
+
+	out := string(result)
+	for _, want := range []string{
+		"pub id: RootId,",
+		"pub target: Option<RootTarget>,",
+		"    #[serde(untagged)]\n    pub enum RootId {\n        String(String),\n        Int64(i64),\n    }",
+		"    pub enum RootTarget {\n        Struct1(RootTargetStruct1),\n        Struct2(RootTargetStruct2),\n    }",
+		"pub struct RootTargetStruct1 {",
+		"pub struct RootTargetStruct2 {",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestToRustMaps(t *testing.T) {
+	yemaType := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"labels": {Kind: yema.Map, Map: &yema.Type{Kind: yema.String}},
+			"hosts": {Kind: yema.Map, Optional: true, Map: &yema.Type{
+				Kind:   yema.Struct,
+				Struct: &map[string]yema.Type{"port": {Kind: yema.Int32}},
+			}},
+		},
+	}
+
+	result, err := ToRust(yemaType, Options{Validators: true})
+	if err != nil {
+		t.Fatalf("ToRust failed: %v", err)
+	}
+	out := string(result)
+	for _, want := range []string{
+		"pub labels: std::collections::HashMap<String, String>,",
+		"pub hosts: Option<std::collections::HashMap<String, RootHosts>>,",
+		"pub struct RootHosts {",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+
+	result, err = ToRust(yemaType, Options{BTreeMaps: true})
+	if err != nil {
+		t.Fatalf("ToRust failed: %v", err)
+	}
+	if !strings.Contains(string(result), "pub labels: std::collections::BTreeMap<String, String>,") {
+		t.Errorf("expected a BTreeMap:\n%s", result)
+	}
+}
+
+func TestToRustTimeCrates(t *testing.T) {
+	yemaType := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"created": {Kind: yema.String, Format: validator.DateTimeFormat},
+			"day":     {Kind: yema.String, Format: validator.DateTimeFormat, FormatArg: "2006-01-02", Optional: true},
+			"clock":   {Kind: yema.String, Format: validator.DateTimeFormat, FormatArg: "15:04"},
+			"timeout": {Kind: yema.String, Format: validator.DurationFormat},
+			"steps": {Kind: yema.Array, Array: &yema.Type{
+				Kind: yema.String, Format: validator.DurationFormat, FormatArg: validator.GoDuration,
+			}},
+		},
+	}
+
+	tests := []struct {
+		crate TimeCrate
+		want  []string
+	}{
+		{"", []string{"pub created: String,", "pub day: Option<String>,", "pub timeout: String,"}},
+		{Chrono, []string{
+			"pub created: chrono::DateTime<chrono::Utc>,",
+			"pub day: Option<chrono::NaiveDate>,",
+			"pub clock: String,",
+			"pub timeout: RootTimeout,",
+			"pub struct RootTimeout(#[serde(with = \"durations::iso8601\")] pub std::time::Duration);",
+			"pub steps: Vec<RootSteps>,",
+			"pub struct RootSteps(#[serde(with = \"durations::go\")] pub std::time::Duration);",
+			"pub mod iso8601 {",
+			"pub mod go {",
+		}},
+		{Time, []string{
+			"pub created: RootCreated,",
+			"pub struct RootCreated(#[serde(with = \"time::serde::rfc3339\")] pub time::OffsetDateTime);",
+			"pub day: Option<time::Date>,",
+		}},
+	}
+	for _, tt := range tests {
+		result, err := ToRust(yemaType, Options{TimeCrate: tt.crate})
+		if err != nil {
+			t.Fatalf("ToRust(%q) failed: %v", tt.crate, err)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(string(result), want) {
+				t.Errorf("crate %q: missing %q in:\n%s", tt.crate, want, result)
+			}
+		}
+		if tt.crate == "" && strings.Contains(string(result), "mod durations") {
+			t.Errorf("unexpected durations module:\n%s", result)
+		}
+	}
+
+	if _, err := ToRust(yemaType, Options{TimeCrate: "jiff"}); err == nil {
+		t.Error("expected an error for an unsupported crate")
+	}
+}
+
+func TestToRustEncodedBytes(t *testing.T) {
+	yemaType := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"data":   {Kind: yema.Bytes},
+			"digest": {Kind: yema.Bytes, Format: validator.HexEncoding, Optional: true},
+			"chunks": {Kind: yema.Array, Array: &yema.Type{Kind: yema.Bytes, Format: validator.Base64URLEncoding}},
+		},
+	}
+
+	result, err := ToRust(yemaType, Options{})
+	if err != nil {
+		t.Fatalf("ToRust failed: %v", err)
+	}
+	if !strings.Contains(string(result), "pub data: Vec<u8>,") || strings.Contains(string(result), "mod encodings") {
+		t.Errorf("expected bytes to stay a Vec<u8>:\n%s", result)
+	}
+
+	result, err = ToRust(yemaType, Options{EncodedBytes: true})
+	if err != nil {
+		t.Fatalf("ToRust failed: %v", err)
+	}
+	out := string(result)
+	for _, want := range []string{
+		"pub data: RootData,",
+		"pub struct RootData(#[serde(with = \"encodings::base64\")] pub Vec<u8>);",
+		"pub digest: Option<RootDigest>,",
+		"pub struct RootDigest(#[serde(with = \"encodings::hex\")] pub Vec<u8>);",
+		"pub chunks: Vec<RootChunks>,",
+		"pub struct RootChunks(#[serde(with = \"encodings::base64url\")] pub Vec<u8>);",
+		"pub mod base64 {",
+		"pub mod base64url {",
+		"pub mod hex {",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestToRustBytesLength(t *testing.T) {
+	yemaType := &yema.Type{
+		Kind:  yema.Struct,
+		Order: []string{"digest", "chunks"},
+		Struct: &map[string]yema.Type{
+			"digest": {Kind: yema.Bytes, Format: validator.HexEncoding, Optional: true, Length: &yema.Length{Min: 4, Max: 4}},
+			"chunks": {Kind: yema.Array, Array: &yema.Type{Kind: yema.Bytes, Length: &yema.Length{Min: 0, Max: 16}}},
+		},
+	}
+
+	result, err := ToRust(yemaType, Options{Validators: true})
+	if err != nil {
+		t.Fatalf("ToRust failed: %v", err)
+	}
+	out := string(result)
+	for _, want := range []string{
+		"if let Some(v1) = &self.digest {",
+		"if v1.len() < 4 {",
+		"errors.push(format!(\"field 'digest' must hold at least 4 bytes\"));",
+		"if v1.len() > 4 {",
+		"if v4.len() > 16 {",
+		"errors.push(format!(\"field 'chunks[{i3}]' must hold at most 16 bytes\"));",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+
+	// The newtypes of encoded bytes hold the decoded bytes, but read bytes without an encoding from base64
+	_, err = ToRust(yemaType, Options{Validators: true, EncodedBytes: true})
+	if err == nil || !strings.Contains(err.Error(), "the length of bytes not in an encoding at chunks[] cannot be checked") {
+		t.Errorf("expected an error for the length of bytes without an encoding, got %v", err)
+	}
+	yemaType.Order = []string{"digest"}
+	delete(*yemaType.Struct, "chunks")
+	result, err = ToRust(yemaType, Options{Validators: true, EncodedBytes: true})
+	if err != nil {
+		t.Fatalf("ToRust failed: %v", err)
+	}
+	if !strings.Contains(string(result), "if v1.0.len() < 4 {") {
+		t.Errorf("expected the length of the newtype to be checked:\n%s", result)
+	}
+}
+
+func TestToRustNoStd(t *testing.T) {
+	yemaType := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"userName": {Kind: yema.String},
+			"labels":   {Kind: yema.Map, Map: &yema.Type{Kind: yema.String}},
+			"timeout":  {Kind: yema.String, Format: validator.DurationFormat},
+		},
+	}
+
+	result, err := ToRust(yemaType, Options{UseSerdeRename: true, NoStd: true, TimeCrate: Chrono})
+	if err != nil {
+		t.Fatalf("ToRust failed: %v", err)
+	}
+	out := string(result)
+	for _, want := range []string{
+		"    use alloc::{format, string::{String, ToString}, vec::Vec};\n    #[cfg(feature = \"serde\")]\n    use serde::{Serialize, Deserialize};",
+		"#[derive(Debug, Clone)]\n    #[cfg_attr(feature = \"serde\", derive(Serialize, Deserialize))]\n    /// Root represents",
+		"#[cfg_attr(feature = \"serde\", serde(rename_all = \"camelCase\"))]\n    pub struct Root {",
+		"pub labels: alloc::collections::BTreeMap<String, String>,",
+		"pub struct RootTimeout(#[cfg_attr(feature = \"serde\", serde(with = \"durations::iso8601\"))] pub core::time::Duration);",
+		"    #[cfg(feature = \"serde\")]\n    mod durations {",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "std::") {
+		t.Errorf("unexpected std type:\n%s", out)
+	}
+}
+
+func TestToRustRecursive(t *testing.T) {
+	// The parser rejects recursive references, types built in Go may contain themselves
+	node := map[string]yema.Type{"name": {Kind: yema.String}}
+	meta := map[string]yema.Type{}
+	root := &yema.Type{Kind: yema.Struct, Struct: &node}
+	node["children"] = yema.Type{Kind: yema.Array, Array: root}
+	node["parent"] = yema.Type{Kind: yema.Struct, Struct: &node, Optional: true}
+	node["meta"] = yema.Type{Kind: yema.Struct, Struct: &meta}
+	meta["owner"] = yema.Type{Kind: yema.Struct, Struct: &node}
+	node["either"] = yema.Type{Kind: yema.Union, Union: []yema.Type{{Kind: yema.String}, {Kind: yema.Struct, Struct: &node}}}
+
+	result, err := ToRust(root, Options{})
+	if err != nil {
+		t.Fatalf("ToRust failed: %v", err)
+	}
+	out := string(result)
+	for _, want := range []string{
+		"pub children: Vec<Root>,",
+		"pub parent: Option<Box<Root>>,",
+		"pub meta: RootMeta,",
+		"pub owner: Box<Root>,",
+		"Struct(Box<Root>),",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+
+	if _, err := ToRust(root, Options{Validators: true}); err == nil {
+		t.Error("expected an error for validators of a recursive type")
+	}
+}
+
+func TestToRustCrate(t *testing.T) {
+	yemaType := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"id":      {Kind: yema.String, Format: validator.UUIDFormat},
+			"created": {Kind: yema.String, Format: validator.DateTimeFormat},
+		},
+	}
+
+	files, err := ToRustCrate(yemaType, Options{Module: "api", TimeCrate: Chrono})
+	if err != nil {
+		t.Fatalf("ToRustCrate failed: %v", err)
+	}
+	manifest := string(files["Cargo.toml"])
+	for _, want := range []string{
+		"name = \"api\"",
+		"serde = { version = \"1\", features = [\"derive\"] }",
+		"chrono = { version = \"0.4\", features = [\"serde\"] }",
+		"uuid = { version = \"1\", features = [\"serde\"] }",
+	} {
+		if !strings.Contains(manifest, want) {
+			t.Errorf("missing %q in:\n%s", want, manifest)
+		}
+	}
+	if !strings.HasPrefix(string(files["src/lib.rs"]), "pub mod api {") {
+		t.Errorf("unexpected src/lib.rs:\n%s", files["src/lib.rs"])
+	}
+
+	files, err = ToRustCrate(yemaType, Options{NoStd: true})
+	if err != nil {
+		t.Fatalf("ToRustCrate failed: %v", err)
+	}
+	manifest = string(files["Cargo.toml"])
+	for _, want := range []string{
+		"optional = true",
+		"uuid = { version = \"1\", default-features = false }",
+		"serde = [\"dep:serde\", \"uuid/serde\"]",
+	} {
+		if !strings.Contains(manifest, want) {
+			t.Errorf("missing %q in:\n%s", want, manifest)
+		}
+	}
+	if strings.Contains(manifest, "chrono") {
+		t.Errorf("unexpected chrono dependency without a time crate:\n%s", manifest)
+	}
+	if !strings.HasPrefix(string(files["src/lib.rs"]), "#![no_std]\n\nextern crate alloc;\n") {
+		t.Errorf("unexpected src/lib.rs:\n%s", files["src/lib.rs"])
+	}
+
+	if _, err := ToRustCrate(yemaType, Options{NoStd: true, TimeCrate: Time}); err == nil {
+		t.Error("expected an error for the time crate in no_std code")
+	}
+}
+
+func TestToRustValidatorCrate(t *testing.T) {
+	yemaType := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"homepage": {Kind: yema.String, Format: validator.URIFormat, Optional: true},
+			"name":     {Kind: yema.String},
+			"address": {Kind: yema.Struct, Struct: &map[string]yema.Type{
+				"street": {Kind: yema.String},
+			}},
+			"contacts": {Kind: yema.Array, Array: &yema.Type{Kind: yema.Struct, Struct: &map[string]yema.Type{
+				"site": {Kind: yema.String, Format: validator.URIFormat},
+			}}},
+		},
+	}
+
+	result, err := ToRust(yemaType, Options{ValidatorCrate: true})
+	if err != nil {
+		t.Fatalf("ToRust failed: %v", err)
+	}
+	out := string(result)
+	for _, want := range []string{
+		"    use validator::Validate;\n",
+		"#[derive(Debug, Clone, Serialize, Deserialize, Validate)]\n    /// Root represents",
+		"#[derive(Debug, Clone, Serialize, Deserialize, Validate)]\n    /// RootAddress represents",
+		"#[validate(url)]\n        pub homepage: Option<String>,",
+		"#[validate(nested)]\n        pub address: RootAddress,",
+		"#[validate(nested)]\n        pub contacts: Vec<RootContacts>,",
+		"#[validate(url)]\n        pub site: String,",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	if strings.Count(out, "#[validate(") != 4 {
+		t.Errorf("expected 4 validate attributes:\n%s", out)
+	}
+}
+
+func TestToRustDocs(t *testing.T) {
+	yemaType := &yema.Type{
+		Kind:        yema.Struct,
+		Description: "Server configures a listener.\n\nIt is reloaded on SIGHUP.",
+		Struct: &map[string]yema.Type{
+			"port": {Kind: yema.Uint16, Description: "Port to listen on", Example: 8080},
+			"host": {Kind: yema.String},
+			"tls": {Kind: yema.Struct, Description: "TLS settings of the listener", Struct: &map[string]yema.Type{
+				"cert": {Kind: yema.String, Example: "/etc/cert.pem"},
+			}},
+		},
+	}
+
+	result, err := ToRust(yemaType, Options{})
+	if err != nil {
+		t.Fatalf("ToRust failed: %v", err)
+	}
+	out := string(result)
+	for _, want := range []string{
+		"    /// Server configures a listener.\n    ///\n    /// It is reloaded on SIGHUP.\n    pub struct Root {",
+		"        /// Port to listen on\n        ///\n        /// Example: `8080`\n        pub port: u16,",
+		"        /// host field\n        pub host: String,",
+		"    /// TLS settings of the listener\n    pub struct RootTls {",
+		"        /// cert field\n        ///\n        /// Example: `\"/etc/cert.pem\"`\n        pub cert: String,",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestToRustDeterministic(t *testing.T) {
+	// Schemas built in code have no declaration order, their fields are sorted by name
+	schema := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"zeta": {Kind: yema.String, Format: validator.DurationFormat, FormatArg: validator.GoDuration},
+			"beta": {Kind: yema.Struct, Struct: &map[string]yema.Type{
+				"yAxis": {Kind: yema.Int, Optional: true},
+				"x":     {Kind: yema.Struct, Struct: &map[string]yema.Type{"deep": {Kind: yema.Bytes, Format: validator.HexEncoding}}},
+			}},
+			"alpha": {Kind: yema.Array, Array: &yema.Type{Kind: yema.Map, Map: &yema.Type{
+				Kind: yema.Struct, Struct: &map[string]yema.Type{"cell": {Kind: yema.Int8}, "data": {Kind: yema.Bytes}},
+			}}},
+			"mode": {Kind: yema.String, Enum: []interface{}{"on", "off"}},
+		},
+	}
+	opts := Options{UseSerdeRename: true, Validators: true, Enums: true, TimeCrate: Chrono, EncodedBytes: true}
+
+	first, err := ToRust(schema, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(first)
+	pos := 0
+	for _, want := range []string{
+		"pub alpha: Vec<std::collections::HashMap<String, RootAlpha>>,", "pub beta: RootBeta,", "pub mode: RootMode,", "pub zeta: RootZeta,",
+		"pub struct RootAlpha {", "pub cell: i8,", "pub data: RootAlphaData,", "pub struct RootAlphaData(",
+		"pub struct RootBeta {", "pub x: RootBetaX,", "pub y_axis: Option<i32>,", "pub struct RootBetaX {", "pub struct RootBetaXDeep(",
+		"pub enum RootMode {", "pub struct RootZeta(", "mod durations {", "mod encodings {",
+	} {
+		i := strings.Index(out[pos:], want)
+		if i < 0 {
+			t.Fatalf("%q not found in order in:\n%s", want, out)
+		}
+		pos += i + len(want)
+	}
+
+	for i := 0; i < 20; i++ {
+		result, err := ToRust(schema, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(result) != out {
+			t.Fatalf("output differs between runs:\n%s\n---\n%s", out, result)
+		}
+	}
+}
+
+func TestToRustTypeOverrides(t *testing.T) {
+	yemaType := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"price":    {Kind: yema.String},
+			"discount": {Kind: yema.String, Optional: true},
+			"rates":    {Kind: yema.Map, Map: &yema.Type{Kind: yema.String}},
+			"owner":    {Kind: yema.Struct, Struct: &map[string]yema.Type{"name": {Kind: yema.String}}},
+			"tags":     {Kind: yema.Array, Array: &yema.Type{Kind: yema.Int}},
+			"address": {Kind: yema.Struct, Struct: &map[string]yema.Type{
+				"zip": {Kind: yema.Struct, Struct: &map[string]yema.Type{"code": {Kind: yema.String}}},
+			}},
+		},
+	}
+
+	result, err := ToRust(yemaType, Options{
+		TypeOverrides: map[string]string{
+			"price":       "rust_decimal::Decimal",
+			"discount":    "rust_decimal::Decimal",
+			"rates[]":     "rust_decimal::Decimal",
+			"owner":       "serde_json::Value",
+			"tags[]":      "::core::num::NonZeroI64",
+			"address.zip": "std::collections::BTreeSet<String>",
+		},
+		TypeDerives:    map[string][]string{"RootAddress": {"PartialEq", "Debug"}},
+		TypeAttributes: map[string][]string{"Root": {"serde(deny_unknown_fields)"}},
+	})
+	if err != nil {
+		t.Fatalf("ToRust failed: %v", err)
+	}
+	out := string(result)
+	for _, want := range []string{
+		"    use serde::{Serialize, Deserialize};\n    use rust_decimal::Decimal;\n    use serde_json::Value;\n    use std::collections::BTreeSet;\n\n",
+		"#[serde(deny_unknown_fields)]\n    /// Root represents",
+		"#[derive(Debug, Clone, Serialize, Deserialize, PartialEq)]\n    /// RootAddress represents",
+		"pub price: Decimal,",
+		"pub discount: Option<Decimal>,",
+		"pub rates: std::collections::HashMap<String, Decimal>,",
+		"pub owner: Value,",
+		"pub tags: Vec<::core::num::NonZeroI64>,",
+		"pub zip: BTreeSet<String>,",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	// The types replaced by overrides are not generated
+	for _, unwanted := range []string{"RootOwner", "RootAddressZip"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("unexpected %q in:\n%s", unwanted, out)
+		}
+	}
+
+	for _, opts := range []Options{
+		{TypeOverrides: map[string]string{"missing": "u8"}},
+		{TypeOverrides: map[string]string{"price": "not a type"}},
+		{TypeOverrides: map[string]string{"owner": "crate::RootAddress"}},
+		{TypeDerives: map[string][]string{"Missing": {"Eq"}}},
+		{TypeAttributes: map[string][]string{"Missing": {"non_exhaustive"}}},
+	} {
+		if _, err := ToRust(yemaType, opts); err == nil {
+			t.Errorf("expected an error for %+v", opts)
+		}
+	}
+}
+
+func TestToRustRenameAll(t *testing.T) {
+	yemaType := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"userName":     {Kind: yema.String},
+			"createdAt":    {Kind: yema.String, Optional: true},
+			"id":           {Kind: yema.String},
+			"x-request-id": {Kind: yema.String},
+			"settings": {Kind: yema.Struct, Struct: &map[string]yema.Type{
+				"max_retries": {Kind: yema.Int},
+				"timeout":     {Kind: yema.Int},
+			}},
+		},
+	}
+
+	result, err := ToRust(yemaType, Options{UseSerdeRename: true})
+	if err != nil {
+		t.Fatalf("ToRust failed: %v", err)
+	}
+	out := string(result)
+	for _, want := range []string{
+		"    #[serde(rename_all = \"camelCase\")]\n    pub struct Root {",
+		"        #[serde(skip_serializing_if = \"Option::is_none\")]\n        pub created_at: Option<String>,",
+		"        pub user_name: String,",
+		"        #[serde(rename = \"x-request-id\")]\n        pub x_request_id: String,",
+		"    pub struct RootSettings {\n        /// max_retries field\n        pub max_retries: i32,",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	if strings.Count(out, "rename") != 2 {
+		t.Errorf("expected a single rename_all and rename:\n%s", out)
+	}
+}
+
+func TestToRustDefaultCollections(t *testing.T) {
+	yemaType := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"tags":     {Kind: yema.Array, Array: &yema.Type{Kind: yema.String}, Optional: true},
+			"labels":   {Kind: yema.Map, Map: &yema.Type{Kind: yema.String}, Optional: true},
+			"nickname": {Kind: yema.String, Optional: true},
+			"id":       {Kind: yema.String, Format: validator.UUIDFormat, Optional: true},
+			"age":      {Kind: yema.Int, Optional: true},
+		},
+	}
+
+	result, err := ToRust(yemaType, Options{UseSerdeRename: true, DefaultCollections: true, DefaultStrings: true})
+	if err != nil {
+		t.Fatalf("ToRust failed: %v", err)
+	}
+	out := string(result)
+	for _, want := range []string{
+		"#[serde(default, skip_serializing_if = \"Vec::is_empty\")]\n        pub tags: Vec<String>,",
+		"#[serde(default, skip_serializing_if = \"std::collections::HashMap::is_empty\")]\n        pub labels: std::collections::HashMap<String, String>,",
+		"#[serde(default, skip_serializing_if = \"String::is_empty\")]\n        pub nickname: String,",
+		"#[serde(skip_serializing_if = \"Option::is_none\")]\n        pub id: Option<uuid::Uuid>,",
+		"#[serde(skip_serializing_if = \"Option::is_none\")]\n        pub age: Option<i32>,",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+
+	result, err = ToRust(yemaType, Options{UseSerdeRename: true})
+	if err != nil {
+		t.Fatalf("ToRust failed: %v", err)
+	}
+	if out := string(result); !strings.Contains(out, "pub tags: Option<Vec<String>>,") || !strings.Contains(out, "pub nickname: Option<String>,") {
+		t.Errorf("expected options by default:\n%s", out)
+	}
+}
+
+func TestToRustURLCrate(t *testing.T) {
+	yemaType := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"homepage": {Kind: yema.String, Format: validator.URIFormat, Optional: true},
+			"id":       {Kind: yema.String, Format: validator.UUIDFormat},
+		},
+	}
+
+	files, err := ToRustCrate(yemaType, Options{URLCrate: true, ValidatorCrate: true})
+	if err != nil {
+		t.Fatalf("ToRustCrate failed: %v", err)
+	}
+	lib, manifest := string(files["src/lib.rs"]), string(files["Cargo.toml"])
+	if !strings.Contains(lib, "pub homepage: Option<url::Url>,") || !strings.Contains(lib, "pub id: uuid::Uuid,") {
+		t.Errorf("missing url::Url and uuid::Uuid in:\n%s", lib)
+	}
+	// The type guarantees the URL, the validator crate does not validate url::Url
+	if strings.Contains(lib, "#[validate(url)]") {
+		t.Errorf("unexpected url validation in:\n%s", lib)
+	}
+	for _, want := range []string{
+		"url = { version = \"2\", features = [\"serde\"] }",
+		"uuid = { version = \"1\", features = [\"serde\"] }",
+	} {
+		if !strings.Contains(manifest, want) {
+			t.Errorf("missing %q in:\n%s", want, manifest)
+		}
+	}
+
+	if _, err := ToRust(yemaType, Options{URLCrate: true, NoStd: true}); err == nil {
+		t.Error("expected an error for the url crate in no_std code")
+	}
+}
@@ -0,0 +1,276 @@
+package rust
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/aep/yema"
+	"github.com/aep/yema/validator"
+)
+
+// TimeCrate is a crate timestamps and dates are generated with, see Options.TimeCrate
+type TimeCrate string
+
+const (
+	// Chrono generates chrono::DateTime<chrono::Utc> and chrono::NaiveDate, requiring the serde feature of chrono
+	Chrono TimeCrate = "chrono"
+	// Time generates time::OffsetDateTime and time::Date, requiring the serde-well-known and
+	// serde-human-readable features of time
+	Time TimeCrate = "time"
+)
+
+// dateLayout is the layout of date-time formats holding a date only
+const dateLayout = "2006-01-02"
+
+// timeType returns the Rust type of a timestamp, date or duration, or "" for other strings, see Options.TimeCrate.
+// Types read and written by a module other than their own serde implementation are held by a newtype named
+// like nested structs, so the module applies wherever they are, and with is the path of the module.
+// Enums take precedence.
+func timeType(t *yema.Type, opts Options) (rustType, with string) {
+	if opts.TimeCrate == "" || t.Kind != yema.String || opts.Enums && isStringEnum(t) {
+		return "", ""
+	}
+
+	switch t.Format {
+	case validator.DateTimeFormat:
+		switch {
+		case (t.FormatArg == nil || t.FormatArg == time.RFC3339) && opts.TimeCrate == Chrono:
+			return "chrono::DateTime<chrono::Utc>", ""
+		case t.FormatArg == nil || t.FormatArg == time.RFC3339:
+			return "time::OffsetDateTime", "time::serde::rfc3339"
+		case t.FormatArg == dateLayout && opts.TimeCrate == Chrono:
+			return "chrono::NaiveDate", ""
+		case t.FormatArg == dateLayout:
+			return "time::Date", ""
+		}
+	case validator.DurationFormat:
+		if t.FormatArg == validator.GoDuration {
+			return durationType(opts), "durations::go"
+		}
+		return durationType(opts), "durations::iso8601"
+	}
+	// Other layouts have no equivalent in the crates
+	return "", ""
+}
+
+// durationType is the Rust type of durations, core::time::Duration in no_std code
+func durationType(opts Options) string {
+	if opts.NoStd {
+		return "core::time::Duration"
+	}
+	return "std::time::Duration"
+}
+
+// isTimeNewtype reports whether t is held by a newtype read and written by a module, see timeType
+func isTimeNewtype(t *yema.Type, opts Options) bool {
+	_, with := timeType(t, opts)
+	return with != ""
+}
+
+// checkTimeCrate reports a crate that is not supported, the serde formats of time require std
+func checkTimeCrate(opts Options) error {
+	switch opts.TimeCrate {
+	case "", Chrono:
+		return nil
+	case Time:
+		if opts.NoStd {
+			return fmt.Errorf("the time crate formats timestamps only with std, use %s for no_std code", Chrono)
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported time crate %q, expected %s or %s", opts.TimeCrate, Chrono, Time)
+}
+
+// writeTimeType writes the newtype holding a timestamp or duration read and written by the module with
+func writeTimeType(buf *bytes.Buffer, name string, t *yema.Type, opts Options, indent string) {
+	rustType, with := timeType(t, opts)
+	summary := "a timestamp in RFC 3339"
+	switch with {
+	case "durations::go":
+		summary = "a duration written like 1h30m"
+	case "durations::iso8601":
+		summary = "a duration written in ISO 8601, such as PT1H30M"
+	}
+
+	writeDerive(buf, name, enumDerives(opts.DeriveTraits), opts, indent)
+	writeDoc(buf, indent, t, name+" is "+summary)
+	if usesSerde(opts) {
+		fmt.Fprintf(buf, "%spub struct %s(%s pub %s);\n\n", indent, name, serdeAttr(fmt.Sprintf("with = %q", with), opts), rustType)
+	} else {
+		fmt.Fprintf(buf, "%spub struct %s(pub %s);\n\n", indent, name, rustType)
+	}
+}
+
+// writeDurationModules writes the durations module with the serde modules of the dialects of durations in mods,
+// accepting the same durations as the duration format of package validator. Durations are never negative in Rust.
+func writeDurationModules(buf *bytes.Buffer, mods map[string]bool, opts Options) {
+	if !mods["durations::go"] && !mods["durations::iso8601"] {
+		return
+	}
+	openHelperModule(buf, "durations", "reads and writes durations as the strings of duration formats", opts)
+	buf.WriteString(durationHelpers)
+	for _, name := range []string{"go", "iso8601"} {
+		if mods["durations::"+name] {
+			buf.WriteString(helperSubmodule(durationDialects[name], opts))
+		}
+	}
+	buf.WriteString("    }\n")
+}
+
+// durationHelpers are the functions of the durations module shared by the serde modules of the dialects
+const durationHelpers = `        /// decimal writes int followed by the fraction frac of digits digits, leaving out trailing zeros
+        fn decimal(int: u64, frac: u64, digits: usize) -> String {
+            let frac = format!("{frac:0digits$}");
+            let frac = frac.trim_end_matches('0');
+            if frac.is_empty() {
+                int.to_string()
+            } else {
+                format!("{int}.{frac}")
+            }
+        }
+
+        /// number splits a number such as 1.5 into its integer part and its fraction, which may be left out but not both
+        fn number(s: &str) -> Option<(u64, &str)> {
+            let (int, frac) = s.split_once('.').unwrap_or((s, ""));
+            let digits = |s: &str| s.bytes().all(|c| c.is_ascii_digit());
+            if int.is_empty() && frac.is_empty() || !digits(int) || !digits(frac) {
+                return None;
+            }
+            Some((if int.is_empty() { 0 } else { int.parse().ok()? }, frac))
+        }
+
+        /// fraction returns the nanoseconds of the fraction frac of a unit of unit nanoseconds
+        fn fraction(frac: &str, unit: u64) -> Option<u64> {
+            let frac = &frac[..frac.len().min(20)];
+            if frac.is_empty() {
+                return Some(0);
+            }
+            let n: u128 = frac.parse().ok()?;
+            u64::try_from(n * unit as u128 / 10u128.pow(frac.len() as u32)).ok()
+        }
+`
+
+// durationDialects are the serde modules of the dialects of durations, by the name of their module
+var durationDialects = map[string]string{
+	"iso8601": `
+        /// iso8601 reads and writes durations such as P1DT2H30M, days and weeks are 24 hours and 7 days long
+        pub mod iso8601 {
+            use serde::{de::Error, Deserialize, Deserializer, Serializer};
+            use core::time::Duration;
+
+            pub fn serialize<S: Serializer>(d: &Duration, serializer: S) -> Result<S::Ok, S::Error> {
+                let secs = d.as_secs();
+                let mut s = String::from("PT");
+                if secs >= 3600 {
+                    s += &format!("{}H", secs / 3600);
+                }
+                if secs % 3600 >= 60 {
+                    s += &format!("{}M", secs % 3600 / 60);
+                }
+                if secs % 60 > 0 || d.subsec_nanos() > 0 || s.len() == 2 {
+                    s += &format!("{}S", super::decimal(secs % 60, d.subsec_nanos().into(), 9));
+                }
+                serializer.serialize_str(&s)
+            }
+
+            pub fn deserialize<'de, D: Deserializer<'de>>(deserializer: D) -> Result<Duration, D::Error> {
+                let s = String::deserialize(deserializer)?;
+                parse(&s).ok_or_else(|| D::Error::custom(format!("invalid ISO 8601 duration {s:?}")))
+            }
+
+            fn parse(s: &str) -> Option<Duration> {
+                let mut rest = s.strip_prefix('P')?;
+                if rest.is_empty() || rest.ends_with('T') {
+                    return None;
+                }
+
+                const UNITS: &str = "WDTHMS";
+                const SIZES: [u64; 6] = [7 * 86400, 86400, 0, 3600, 60, 1];
+                let mut d = Duration::ZERO;
+                // next is the index in UNITS of the first unit that may still follow
+                let mut next = 0;
+                while !rest.is_empty() {
+                    if let Some(after) = rest.strip_prefix('T') {
+                        if next > 2 {
+                            return None;
+                        }
+                        next = 3;
+                        rest = after;
+                        continue;
+                    }
+
+                    let end = rest.find(|c: char| !c.is_ascii_digit() && c != '.')?;
+                    let unit = next + UNITS[next..].find(rest[end..].chars().next()?)?;
+                    // Hours, minutes and seconds follow the T, years and months are not supported
+                    if end == 0 || unit == 2 || next < 3 && unit > 2 || unit != 5 && rest[..end].contains('.') {
+                        return None;
+                    }
+                    let (int, frac) = super::number(&rest[..end])?;
+                    d = d.checked_add(Duration::from_secs(int.checked_mul(SIZES[unit])?))?;
+                    d = d.checked_add(Duration::from_nanos(super::fraction(frac, 1_000_000_000)?))?;
+                    next = unit + 1;
+                    rest = &rest[end + 1..];
+                }
+                Some(d)
+            }
+        }
+`,
+	"go": `
+        /// go reads and writes durations such as 1h30m like the time package of Go
+        pub mod go {
+            use serde::{de::Error, Deserialize, Deserializer, Serializer};
+            use core::time::Duration;
+
+            pub fn serialize<S: Serializer>(d: &Duration, serializer: S) -> Result<S::Ok, S::Error> {
+                let (secs, nanos) = (d.as_secs(), u64::from(d.subsec_nanos()));
+                let s = match (secs, nanos) {
+                    (0, 0) => "0s".to_string(),
+                    // Durations below a second are written in the largest unit they have a whole one of
+                    (0, 1_000_000..) => format!("{}ms", super::decimal(nanos / 1_000_000, nanos % 1_000_000, 6)),
+                    (0, 1_000..) => format!("{}µs", super::decimal(nanos / 1_000, nanos % 1_000, 3)),
+                    (0, _) => format!("{nanos}ns"),
+                    (0..=59, _) => format!("{}s", super::decimal(secs, nanos, 9)),
+                    (60..=3599, _) => format!("{}m{}s", secs / 60, super::decimal(secs % 60, nanos, 9)),
+                    _ => format!("{}h{}m{}s", secs / 3600, secs % 3600 / 60, super::decimal(secs % 60, nanos, 9)),
+                };
+                serializer.serialize_str(&s)
+            }
+
+            pub fn deserialize<'de, D: Deserializer<'de>>(deserializer: D) -> Result<Duration, D::Error> {
+                let s = String::deserialize(deserializer)?;
+                parse(&s).ok_or_else(|| D::Error::custom(format!("invalid duration {s:?}")))
+            }
+
+            fn parse(s: &str) -> Option<Duration> {
+                let mut rest = s.strip_prefix('+').unwrap_or(s);
+                if rest == "0" {
+                    return Some(Duration::ZERO);
+                }
+                if rest.is_empty() {
+                    return None;
+                }
+
+                let mut d = Duration::ZERO;
+                while !rest.is_empty() {
+                    let end = rest.find(|c: char| !c.is_ascii_digit() && c != '.')?;
+                    let unit_end = rest[end..].find(|c: char| c.is_ascii_digit() || c == '.').map_or(rest.len(), |i| end + i);
+                    let unit: u64 = match &rest[end..unit_end] {
+                        "ns" => 1,
+                        "us" | "µs" | "μs" => 1_000,
+                        "ms" => 1_000_000,
+                        "s" => 1_000_000_000,
+                        "m" => 60_000_000_000,
+                        "h" => 3_600_000_000_000,
+                        _ => return None,
+                    };
+                    let (int, frac) = super::number(&rest[..end])?;
+                    let nanos = int.checked_mul(unit)?.checked_add(super::fraction(frac, unit)?)?;
+                    d = d.checked_add(Duration::from_nanos(nanos))?;
+                    rest = &rest[unit_end..];
+                }
+                Some(d)
+            }
+        }
+`,
+}
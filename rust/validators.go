@@ -0,0 +1,250 @@
+package rust
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/aep/yema"
+	"github.com/aep/yema/internal/checks"
+	"github.com/aep/yema/internal/fieldpath"
+	"github.com/aep/yema/internal/ident"
+)
+
+// generateValidator generates a validate method for the root struct from the shared validation IR.
+// Checks already guaranteed by the Rust types, such as kinds and the range of sized integers, are skipped.
+func generateValidator(t *yema.Type, structName string, buf *bytes.Buffer, opts Options, indentLevel int) error {
+	root, err := checks.Build(t)
+	if err != nil {
+		return err
+	}
+
+	indent := strings.Repeat("    ", indentLevel)
+	fmt.Fprintf(buf, "%simpl %s {\n", indent, structName)
+	fmt.Fprintf(buf, "%s    /// validate checks the rules of the schema not enforced by the type system and returns all violations found\n", indent)
+	fmt.Fprintf(buf, "%s    pub fn validate(&self) -> Result<(), Vec<String>> {\n", indent)
+	fmt.Fprintf(buf, "%s        #[allow(unused_mut)]\n", indent)
+	fmt.Fprintf(buf, "%s        let mut errors: Vec<String> = Vec::new();\n", indent)
+
+	e := &validatorEmitter{buf: buf, transliterate: opts.Transliterate, overrides: opts.overrides, defaultCollections: opts.DefaultCollections, encodedBytes: opts.EncodedBytes}
+	for _, f := range root.Fields {
+		e.field(f, "self", "", indentLevel+2)
+	}
+	if e.err != nil {
+		return e.err
+	}
+
+	fmt.Fprintf(buf, "%s        if errors.is_empty() {\n", indent)
+	fmt.Fprintf(buf, "%s            Ok(())\n", indent)
+	fmt.Fprintf(buf, "%s        } else {\n", indent)
+	fmt.Fprintf(buf, "%s            Err(errors)\n", indent)
+	fmt.Fprintf(buf, "%s        }\n", indent)
+	fmt.Fprintf(buf, "%s    }\n", indent)
+	fmt.Fprintf(buf, "%s}\n\n", indent)
+
+	return nil
+}
+
+// validatorEmitter writes the Rust statements checking a node of the validation IR
+type validatorEmitter struct {
+	buf           *bytes.Buffer
+	vars          int
+	transliterate bool
+	// overrides are the types overridden by path, which are not checked
+	overrides map[string]string
+	// defaultCollections holds optional lists and maps in collections that are empty if missing, not in an Option.
+	// Strings have no checks the Rust types do not imply, see impliedByType.
+	defaultCollections bool
+	// encodedBytes holds bytes in newtypes read from the encoding of their format, base64 if they declare none
+	encodedBytes bool
+	// err is the first rule of the schema found that cannot be checked
+	err error
+}
+
+func (e *validatorEmitter) newVar(prefix string) string {
+	e.vars++
+	return prefix + strconv.Itoa(e.vars)
+}
+
+func (e *validatorEmitter) line(depth int, format string, args ...interface{}) {
+	e.buf.WriteString(strings.Repeat("    ", depth))
+	fmt.Fprintf(e.buf, format, args...)
+	e.buf.WriteString("\n")
+}
+
+// field emits the checks for a field of the struct expression owner.
+// pathFmt is the content of a format string literal for the path of the owner, capturing array indices inline.
+func (e *validatorEmitter) field(f *checks.Field, owner, pathFmt string, depth int) {
+	if !e.needsChecks(f.Node) {
+		return
+	}
+
+	fieldPath := escapeFormat(f.Name)
+	if pathFmt != "" {
+		fieldPath = pathFmt + "." + fieldPath
+	}
+
+	expr := "&" + owner + "." + ident.Snake(ident.Source(f.Name, f.CodeName, e.transliterate))
+	collection := f.Node.Kind == yema.Array || f.Node.Kind == yema.Map
+	v := e.newVar("v")
+	if !f.Required && !(e.defaultCollections && collection) {
+		if body := e.capture(func() { e.node(f.Node, v, fieldPath, depth+1) }); len(body) != 0 {
+			e.line(depth, "if let Some(%s) = %s {", v, expr)
+			e.buf.Write(body)
+			e.line(depth, "}")
+		}
+		return
+	}
+
+	if body := e.capture(func() { e.node(f.Node, v, fieldPath, depth) }); len(body) != 0 {
+		e.line(depth, "let %s = %s;", v, expr)
+		e.buf.Write(body)
+	}
+}
+
+// capture returns the statements emit writes, so the binding of a value nothing checks can be left out
+func (e *validatorEmitter) capture(emit func()) []byte {
+	outer := e.buf
+	e.buf = new(bytes.Buffer)
+	emit()
+	body := e.buf.Bytes()
+	e.buf = outer
+	return body
+}
+
+// node emits the checks for the value behind reference expr
+func (e *validatorEmitter) node(n *checks.Node, expr, pathFmt string, depth int) {
+	for _, c := range n.Checks {
+		if impliedByType(n.Kind, c) {
+			continue
+		}
+
+		var cond, msg string
+		switch c.Op {
+		case checks.OpMin:
+			cond = fmt.Sprintf("(*%s as i128) < %d", expr, c.Bound)
+			msg = fmt.Sprintf("must be at least %d", c.Bound)
+		case checks.OpMax:
+			cond = fmt.Sprintf("(*%s as i128) > %d", expr, c.Bound)
+			msg = fmt.Sprintf("must be at most %d", c.Bound)
+		case checks.OpMinLength:
+			cond = fmt.Sprintf("%s < %d", e.byteLength(n, expr), c.Bound)
+			msg = fmt.Sprintf("must hold at least %d bytes", c.Bound)
+		case checks.OpMaxLength:
+			cond = fmt.Sprintf("%s > %d", e.byteLength(n, expr), c.Bound)
+			msg = fmt.Sprintf("must hold at most %d bytes", c.Bound)
+		default:
+			continue
+		}
+
+		e.line(depth, "if %s {", cond)
+		e.line(depth+1, "errors.push(format!(\"field '%s' %s\"));", pathFmt, msg)
+		e.line(depth, "}")
+	}
+
+	for _, f := range n.Fields {
+		e.field(f, expr, pathFmt, depth)
+	}
+
+	if n.Items != nil && e.needsChecks(n.Items) {
+		i := e.newVar("i")
+		v := e.newVar("v")
+		if body := e.capture(func() { e.node(n.Items, v, pathFmt+"[{"+i+"}]", depth+1) }); len(body) != 0 {
+			e.line(depth, "for (%s, %s) in %s.iter().enumerate() {", i, v, expr)
+			e.buf.Write(body)
+			e.line(depth, "}")
+		}
+	}
+
+	// Values of maps are at the path of their key, as the validator reports them
+	if n.Values != nil && e.needsChecks(n.Values) {
+		k := e.newVar("k")
+		v := e.newVar("v")
+		if body := e.capture(func() { e.node(n.Values, v, pathFmt+".{"+k+"}", depth+1) }); len(body) != 0 {
+			e.line(depth, "for (%s, %s) in %s.iter() {", k, v, expr)
+			e.buf.Write(body)
+			e.line(depth, "}")
+		}
+	}
+}
+
+// byteLength returns the number of bytes held by the bytes behind reference expr
+func (e *validatorEmitter) byteLength(n *checks.Node, expr string) string {
+	if !e.encodedBytes {
+		return expr + ".len()"
+	}
+	// The newtypes read strings declaring no encoding as base64, while the validator counts their bytes as they are
+	if n.Encoding == "" && e.err == nil {
+		e.err = fmt.Errorf("the length of bytes not in an encoding at %s cannot be checked by the generated validate method", fieldpath.Display(n.Path))
+	}
+	return expr + ".0.len()"
+}
+
+// needsChecks reports whether any check in the subtree of n is not implied by the Rust types or overridden
+func (e *validatorEmitter) needsChecks(n *checks.Node) bool {
+	if overridden(n.Path, e.overrides) {
+		return false
+	}
+	for _, c := range n.Checks {
+		if !impliedByType(n.Kind, c) {
+			return true
+		}
+	}
+	for _, f := range n.Fields {
+		if e.needsChecks(f.Node) {
+			return true
+		}
+	}
+	return n.Items != nil && e.needsChecks(n.Items) || n.Values != nil && e.needsChecks(n.Values)
+}
+
+// impliedByType reports whether the Rust type generated for kind already guarantees the check
+func impliedByType(kind yema.Kind, c checks.Check) bool {
+	if c.Op == checks.OpType {
+		return true
+	}
+
+	min, max, ok := rustIntRange(kind)
+	if !ok {
+		return false
+	}
+
+	switch c.Op {
+	case checks.OpMin:
+		return min >= c.Bound
+	case checks.OpMax:
+		// The range of u64 exceeds int64, so no bound is implied by it
+		return kind != yema.Uint64 && max <= c.Bound
+	}
+	return false
+}
+
+// rustIntRange returns the range of the Rust integer type generated for kind, clamped to int64
+func rustIntRange(kind yema.Kind) (int64, int64, bool) {
+	switch kind {
+	case yema.Int, yema.Int32:
+		return math.MinInt32, math.MaxInt32, true
+	case yema.Int8:
+		return math.MinInt8, math.MaxInt8, true
+	case yema.Int16:
+		return math.MinInt16, math.MaxInt16, true
+	case yema.Int64:
+		return math.MinInt64, math.MaxInt64, true
+	case yema.Uint, yema.Uint32:
+		return 0, math.MaxUint32, true
+	case yema.Uint8:
+		return 0, math.MaxUint8, true
+	case yema.Uint16:
+		return 0, math.MaxUint16, true
+	case yema.Uint64:
+		return 0, math.MaxInt64, true
+	}
+	return 0, 0, false
+}
+
+// escapeFormat escapes a string for use inside a Rust format string literal
+func escapeFormat(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "{", "{{", "}", "}}").Replace(s)
+}
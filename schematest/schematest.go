@@ -0,0 +1,256 @@
+// Package schematest runs test cases embedded in yema schema files.
+//
+// A schema file may contain a root level $tests list next to its fields:
+//
+//	name: string
+//	$tests:
+//	  - name: minimal
+//	    data: {name: bob}
+//	    valid: true
+//	  - name: missing name
+//	    data: {}
+//	    valid: false
+//	    errors: [required_missing]
+//	  - name: wrong type
+//	    data: {name: 42}
+//	    valid: false
+//	    errors: [{code: type_mismatch, path: name}]
+//
+// Each entry in errors must match at least one validation error. It names the code of the error, such as
+// required_missing or E_REQUIRED_MISSING for validator.CodeRequiredMissing, or the key of its message in a
+// validator.Catalog, such as required or type.string. Entries naming neither are looked for in the messages of
+// the errors, which change more often than codes. A mapping also gives the path of the error, as it is
+// shown in messages.
+package schematest
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aep/yema"
+	"github.com/aep/yema/internal/fieldpath"
+	"github.com/aep/yema/parser"
+	"github.com/aep/yema/validator"
+)
+
+// Case is a single document expected to pass or fail validation
+type Case struct {
+	// Name describes the case in reports
+	Name string
+	// Data is the document to validate, of any type matching the schema root
+	Data interface{}
+	// Valid is whether the document is expected to pass validation
+	Valid bool
+	// Errors are expected among the validation errors of an invalid document
+	Errors []Expected
+}
+
+// Expected is an error a case expects
+type Expected struct {
+	// Code is the code of the error, the key of its message or, failing both, a substring of its message.
+	// Empty matches any error.
+	Code string
+	// Path is the location of the error, empty for the document itself, nil for any location
+	Path *string
+}
+
+// String describes the expected error in reports
+func (e Expected) String() string {
+	s := fmt.Sprintf("%q", e.Code)
+	if e.Code == "" {
+		s = "any error"
+	}
+	if e.Path != nil {
+		s += " at " + fieldpath.Display(*e.Path)
+	}
+	return s
+}
+
+// Result is the outcome of running a single Case
+type Result struct {
+	Case *Case
+	// Errors are the validation errors reported for the document
+	Errors []error
+	// Failure describes why the case did not behave as expected, empty if it passed
+	Failure string
+}
+
+// Passed reports whether the case behaved as expected
+func (r Result) Passed() bool {
+	return r.Failure == ""
+}
+
+// FromSchema extracts the test cases from a raw schema document.
+// Only a mapping at the root can hold test cases, other documents have none.
+func FromSchema(schema interface{}) ([]Case, error) {
+	root, ok := schema.(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	raw, ok := root[parser.TestsKey]
+	if !ok {
+		return nil, nil
+	}
+
+	list, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s must be a list", parser.TestsKey)
+	}
+
+	cases := make([]Case, 0, len(list))
+	for i, item := range list {
+		entry, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s[%d] must be a mapping", parser.TestsKey, i)
+		}
+
+		c := Case{Name: fmt.Sprintf("case %d", i)}
+		hasData := false
+		for key, value := range entry {
+			switch key {
+			case "name":
+				name, ok := value.(string)
+				if !ok {
+					return nil, fmt.Errorf("%s[%d].name must be a string", parser.TestsKey, i)
+				}
+				c.Name = name
+			case "data":
+				c.Data = value
+				hasData = true
+			case "valid":
+				valid, ok := value.(bool)
+				if !ok {
+					return nil, fmt.Errorf("%s[%d].valid must be a boolean", parser.TestsKey, i)
+				}
+				c.Valid = valid
+			case "errors":
+				errs, ok := value.([]interface{})
+				if !ok {
+					return nil, fmt.Errorf("%s[%d].errors must be a list", parser.TestsKey, i)
+				}
+				for j, e := range errs {
+					expected, err := parseExpected(e)
+					if err != nil {
+						return nil, fmt.Errorf("%s[%d].errors[%d] %w", parser.TestsKey, i, j, err)
+					}
+					c.Errors = append(c.Errors, expected)
+				}
+			default:
+				return nil, fmt.Errorf("%s[%d] has unknown key %q", parser.TestsKey, i, key)
+			}
+		}
+
+		if !hasData {
+			return nil, fmt.Errorf("%s[%d] has no data", parser.TestsKey, i)
+		}
+		if c.Valid && len(c.Errors) != 0 {
+			return nil, fmt.Errorf("%s[%d] is valid but expects errors", parser.TestsKey, i)
+		}
+
+		cases = append(cases, c)
+	}
+
+	return cases, nil
+}
+
+// parseExpected reads an entry of the errors of a case, a code or a mapping of its code and path
+func parseExpected(entry interface{}) (Expected, error) {
+	switch e := entry.(type) {
+	case string:
+		return Expected{Code: e}, nil
+	case map[string]interface{}:
+		var expected Expected
+		for key, value := range e {
+			s, ok := value.(string)
+			if !ok {
+				return Expected{}, fmt.Errorf("has a %s that is not a string", key)
+			}
+			switch key {
+			case "code":
+				expected.Code = s
+			case "path":
+				expected.Path = &s
+			default:
+				return Expected{}, fmt.Errorf("has unknown key %q", key)
+			}
+		}
+		return expected, nil
+	}
+	return Expected{}, fmt.Errorf("must be a string or a mapping")
+}
+
+// Run validates every case against the schema and compares the outcome with the expectation
+func Run(cases []Case, schema *yema.Type) []Result {
+	results := make([]Result, len(cases))
+	for i := range cases {
+		results[i] = run(&cases[i], schema)
+	}
+	return results
+}
+
+func run(c *Case, schema *yema.Type) Result {
+	r := Result{
+		Case:   c,
+		Errors: validator.Validate(c.Data, schema),
+	}
+
+	if c.Valid {
+		if len(r.Errors) != 0 {
+			r.Failure = "expected document to be valid"
+		}
+		return r
+	}
+
+	if len(r.Errors) == 0 {
+		r.Failure = "expected document to be invalid"
+		return r
+	}
+
+	for _, want := range c.Errors {
+		if !containsError(r.Errors, want) {
+			r.Failure = fmt.Sprintf("expected an error %s", want)
+			return r
+		}
+	}
+
+	return r
+}
+
+func containsError(errs []error, want Expected) bool {
+	for _, e := range errs {
+		if matches(e, want) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether err is the expected error, by its code or the key of its message when the
+// expectation names either, by its message otherwise
+func matches(err error, want Expected) bool {
+	var path, message string
+	var verr *validator.Error
+	var unionErr *validator.UnionError
+	switch {
+	case errors.As(err, &unionErr):
+		path = unionErr.Path
+	case errors.As(err, &verr):
+		path, message = verr.Path, verr.Message
+	}
+	if want.Path != nil && *want.Path != path {
+		return false
+	}
+
+	if want.Code == "" {
+		return true
+	}
+	code := validator.CodeOf(err)
+	if code != "" && (strings.EqualFold(want.Code, string(code)) || strings.EqualFold(want.Code, strings.TrimPrefix(string(code), "E_"))) {
+		return true
+	}
+	if message != "" && want.Code == message {
+		return true
+	}
+	return strings.Contains(err.Error(), want.Code)
+}
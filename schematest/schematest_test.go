@@ -0,0 +1,108 @@
+package schematest
+
+import (
+	"testing"
+
+	"github.com/aep/yema/parser"
+	"gopkg.in/yaml.v3"
+)
+
+const schemaSource = `
+name: string
+age?: int
+$tests:
+  - name: minimal
+    data: {name: bob}
+    valid: true
+  - name: missing name
+    data: {age: 3}
+    valid: false
+    errors: ["'name' is missing"]
+  - name: wrong expectation
+    data: {name: bob}
+    valid: false
+  - name: wrong error
+    data: {}
+    valid: false
+    errors: ["must be a string"]
+  - name: not a mapping
+    data: [bob]
+    valid: false
+  - name: code
+    data: {}
+    valid: false
+    errors: [required_missing, E_REQUIRED_MISSING, required]
+  - name: code at path
+    data: {name: 42}
+    valid: false
+    errors: [{code: type_mismatch, path: name}, {path: name}]
+  - name: code at other path
+    data: {name: 42}
+    valid: false
+    errors: [{code: type_mismatch, path: age}]
+  - name: other code
+    data: {name: 42}
+    valid: false
+    errors: [range]
+`
+
+func TestRun(t *testing.T) {
+	var schemaMap map[string]interface{}
+	if err := yaml.Unmarshal([]byte(schemaSource), &schemaMap); err != nil {
+		t.Fatal(err)
+	}
+
+	schema, err := parser.From(schemaMap)
+	if err != nil {
+		t.Fatalf("parser.From failed: %v", err)
+	}
+	if _, ok := (*schema.Struct)["$tests"]; ok {
+		t.Fatalf("$tests must not be parsed as a field")
+	}
+
+	cases, err := FromSchema(schemaMap)
+	if err != nil {
+		t.Fatalf("FromSchema failed: %v", err)
+	}
+
+	want := []bool{true, true, false, false, true, true, true, false, false}
+	results := Run(cases, schema)
+	if len(results) != len(want) {
+		t.Fatalf("got %d results, want %d", len(results), len(want))
+	}
+
+	for i, r := range results {
+		if r.Passed() != want[i] {
+			t.Errorf("%s: passed = %v, want %v (%s)", r.Case.Name, r.Passed(), want[i], r.Failure)
+		}
+	}
+}
+
+func TestFromSchemaInvalid(t *testing.T) {
+	tests := []string{
+		`$tests: {}`,
+		`$tests: [{valid: true}]`,
+		`$tests: [{data: {}, valid: true, errors: ["x"]}]`,
+		`$tests: [{data: {}, bogus: 1}]`,
+		`$tests: [{data: {}, errors: [1]}]`,
+		`$tests: [{data: {}, errors: [{code: required, bogus: x}]}]`,
+		`$tests: [{data: {}, errors: [{path: [name]}]}]`,
+	}
+
+	for _, src := range tests {
+		var schemaMap map[string]interface{}
+		if err := yaml.Unmarshal([]byte(src), &schemaMap); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := FromSchema(schemaMap); err == nil {
+			t.Errorf("expected error for %s", src)
+		}
+	}
+}
+
+func TestFromSchemaNonMapping(t *testing.T) {
+	cases, err := FromSchema([]interface{}{"string"})
+	if err != nil || cases != nil {
+		t.Errorf("FromSchema = %v, %v, want no cases", cases, err)
+	}
+}
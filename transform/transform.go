@@ -0,0 +1,107 @@
+// Package transform derives variants of a schema, such as the request and response bodies of a shared resource schema
+package transform
+
+import (
+	"fmt"
+
+	"github.com/aep/yema"
+)
+
+// Direction returns the variant of t for the direction named "request" or "response",
+// an empty direction returns t itself
+func Direction(t *yema.Type, direction string) (*yema.Type, error) {
+	switch direction {
+	case "":
+		return t, nil
+	case "request":
+		return Request(t), nil
+	case "response":
+		return Response(t), nil
+	}
+	return nil, fmt.Errorf("unknown direction %q, expected request or response", direction)
+}
+
+// Request returns a copy of t for request bodies, without fields marked read-only
+func Request(t *yema.Type) *yema.Type {
+	return without(t, func(field yema.Type) bool { return field.ReadOnly })
+}
+
+// Response returns a copy of t for response bodies, without fields marked write-only
+func Response(t *yema.Type) *yema.Type {
+	return without(t, func(field yema.Type) bool { return field.WriteOnly })
+}
+
+// without returns a deep copy of t, leaving out the struct fields for which drop returns true
+func without(t *yema.Type, drop func(field yema.Type) bool) *yema.Type {
+	if t == nil {
+		return nil
+	}
+
+	c := *t
+	if t.Array != nil {
+		c.Array = without(t.Array, drop)
+	}
+	if t.Map != nil {
+		c.Map = without(t.Map, drop)
+	}
+	if t.If != nil {
+		c.If = &yema.Condition{Equals: t.If.Equals, Then: without(t.If.Then, drop), Else: without(t.If.Else, drop)}
+	}
+	if t.Union != nil {
+		c.Union = make([]yema.Type, len(t.Union))
+		for i := range t.Union {
+			c.Union[i] = *without(&t.Union[i], drop)
+		}
+	}
+
+	if t.Struct != nil {
+		fields := make(map[string]yema.Type, len(*t.Struct))
+		for name, fieldType := range *t.Struct {
+			if !drop(fieldType) {
+				fields[name] = *without(&fieldType, drop)
+			}
+		}
+		// Rules and conditions on dropped fields no longer apply, requiring one could never be met
+		for name, fieldType := range fields {
+			fieldType.Requires = present(fieldType.Requires, fields)
+			fieldType.Conflicts = present(fieldType.Conflicts, fields)
+			if fieldType.If != nil && !allFields(fieldType.If.Equals, fields) {
+				fieldType.If = nil
+			}
+			fields[name] = fieldType
+		}
+		c.Struct = &fields
+
+		if t.Order != nil {
+			c.Order = make([]string, 0, len(fields))
+			for _, name := range t.Order {
+				if _, ok := fields[name]; ok {
+					c.Order = append(c.Order, name)
+				}
+			}
+		}
+	}
+
+	return &c
+}
+
+// present returns the names that are fields, nil if there are none
+func present(names []string, fields map[string]yema.Type) []string {
+	var kept []string
+	for _, name := range names {
+		if _, ok := fields[name]; ok {
+			kept = append(kept, name)
+		}
+	}
+	return kept
+}
+
+// allFields reports whether every field a condition compares is one of fields
+func allFields(equals map[string]interface{}, fields map[string]yema.Type) bool {
+	for name := range equals {
+		if _, ok := fields[name]; !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,81 @@
+package transform
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aep/yema"
+	"github.com/aep/yema/parser"
+)
+
+func TestDirections(t *testing.T) {
+	schema, err := parser.FromYAML([]byte(`
+id:
+  $type: string
+  $readonly: true
+name: string
+password:
+  $type: string
+  $writeonly: true
+members:
+  - id:
+      $type: int
+      $readonly: true
+    role: string
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		variant *yema.Type
+		fields  string
+		members string
+	}{
+		{"request", Request(schema), "name,password,members", "role"},
+		{"response", Response(schema), "id,name,members", "id,role"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := strings.Join(tt.variant.FieldNames(), ","); got != tt.fields {
+				t.Errorf("fields = %s, want %s", got, tt.fields)
+			}
+			members := (*tt.variant.Struct)["members"].Array
+			if got := strings.Join(members.FieldNames(), ","); got != tt.members {
+				t.Errorf("member fields = %s, want %s", got, tt.members)
+			}
+		})
+	}
+
+	// The original schema is left untouched
+	if got := strings.Join(schema.FieldNames(), ","); got != "id,name,password,members" {
+		t.Errorf("schema was modified, fields = %s", got)
+	}
+}
+
+func TestRulesOnDroppedFields(t *testing.T) {
+	schema, err := parser.FromYAML([]byte(`
+password?:
+  $type: string
+  $writeonly: true
+  $requires: [username]
+username?: string
+token?:
+  $type: string
+  $conflicts: [password, username]
+  $requires: [password]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token := (*Response(schema).Struct)["token"]
+	if token.Requires != nil || strings.Join(token.Conflicts, ",") != "username" {
+		t.Errorf("response token rules = requires %v, conflicts %v", token.Requires, token.Conflicts)
+	}
+	if token := (*schema.Struct)["token"]; len(token.Requires) != 1 || len(token.Conflicts) != 2 {
+		t.Errorf("schema was modified, token = %+v", token)
+	}
+}
@@ -0,0 +1,46 @@
+package typescript
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aep/yema"
+	"github.com/aep/yema/validator"
+)
+
+// isBrand reports whether t is a string generated as a branded type, see Options.Brands. Enums keep their type.
+func isBrand(t *yema.Type, opts Options) bool {
+	return opts.Brands && t.Kind == yema.String && t.Format == validator.UUIDFormat && !isEnum(t, opts)
+}
+
+// brandName returns the name of the brand named name after its field, renamed by Options.BrandNames
+func brandName(name string, opts Options) string {
+	if renamed, ok := opts.BrandNames[name]; ok {
+		opts.renamedBrands[name] = true
+		return renamed
+	}
+	return name
+}
+
+// checkBrandNames reports renamed brands that are not generated
+func checkBrandNames(opts Options) error {
+	var unmatched []string
+	for name := range opts.BrandNames {
+		if !opts.renamedBrands[name] {
+			unmatched = append(unmatched, name)
+		}
+	}
+	if len(unmatched) > 0 {
+		sort.Strings(unmatched)
+		return fmt.Errorf("brand names of %s match no branded field, brands are named like nested types such as RootUserId", strings.Join(unmatched, ", "))
+	}
+	return nil
+}
+
+// writeBrand writes the branded type name of the string t
+func writeBrand(buf *bytes.Buffer, name string, t *yema.Type, exportKeyword string) {
+	writeDoc(buf, t, fmt.Sprintf("%s is a %s string, branded so it is not mixed up with other strings", name, t.Format))
+	fmt.Fprintf(buf, "%stype %s = string & { readonly __brand: %q };\n\n", exportKeyword, name, name)
+}
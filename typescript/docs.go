@@ -0,0 +1,113 @@
+package typescript
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aep/yema"
+)
+
+// docWidth is the width TSDoc comments are wrapped at, not counting indentation
+const docWidth = 80
+
+// deprecatedPrefix starts a paragraph of a description that deprecates the type, as in Go doc comments
+const deprecatedPrefix = "Deprecated:"
+
+// docLines returns the lines of the TSDoc comment of t: its description wrapped at docWidth, or fallback if
+// there is none, a paragraph starting with "Deprecated:" as the @deprecated tag, and the example of t in JSON
+// as the @example tag
+func docLines(t *yema.Type, fallback string) []string {
+	description := strings.TrimSpace(t.Description)
+	if description == "" {
+		description = fallback
+	}
+
+	// The end of the comment cannot appear in it
+	description = strings.ReplaceAll(description, "*/", "*\\/")
+
+	var lines, tags []string
+	if description != "" {
+		for _, paragraph := range strings.Split(description, "\n\n") {
+			paragraph = strings.TrimSpace(paragraph)
+			if rest, ok := strings.CutPrefix(paragraph, deprecatedPrefix); ok {
+				tags = append(tags, wrap("@deprecated "+strings.TrimSpace(rest))...)
+				continue
+			}
+			if len(lines) > 0 {
+				lines = append(lines, "")
+			}
+			lines = append(lines, wrap(paragraph)...)
+		}
+	}
+	if t.Example != nil {
+		// Examples that have no JSON encoding are left out, the example generator reports them
+		if data, err := json.Marshal(t.Example); err == nil {
+			tags = append(tags, "@example", "```json", strings.ReplaceAll(string(data), "*/", "*\\/"), "```")
+		}
+	}
+
+	if len(lines) > 0 && len(tags) > 0 {
+		lines = append(lines, "")
+	}
+	return append(lines, tags...)
+}
+
+// wrap splits paragraph into lines of at most docWidth, unless a single word is longer
+func wrap(paragraph string) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(paragraph) {
+		if line != "" && len(" * ")+len(line)+1+len(word) > docWidth {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// writeDoc writes the TSDoc comment of the type t, see docLines
+func writeDoc(buf *bytes.Buffer, t *yema.Type, fallback string) {
+	writeLines(buf, "", docLines(t, fallback))
+}
+
+// writeFieldDoc writes the TSDoc comment of a field of type t indented by indent, on a single line if it fits,
+// with a note for write-only fields. Fields without documentation get no comment.
+func writeFieldDoc(buf *bytes.Buffer, indent string, t *yema.Type) {
+	if t.WriteOnly {
+		documented := *t
+		documented.Description = strings.TrimSpace(t.Description + "\n\nWrite-only, never sent in responses")
+		t = &documented
+	}
+
+	lines := docLines(t, "")
+	if len(lines) == 1 {
+		fmt.Fprintf(buf, "%s/** %s */\n", indent, lines[0])
+		return
+	}
+	writeLines(buf, indent, lines)
+}
+
+// writeLines writes lines as a TSDoc comment indented by indent
+func writeLines(buf *bytes.Buffer, indent string, lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Fprintf(buf, "%s/**\n", indent)
+	for _, line := range lines {
+		if line == "" {
+			fmt.Fprintf(buf, "%s *\n", indent)
+		} else {
+			fmt.Fprintf(buf, "%s * %s\n", indent, line)
+		}
+	}
+	fmt.Fprintf(buf, "%s */\n", indent)
+}
@@ -0,0 +1,101 @@
+package typescript
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/aep/yema"
+	"github.com/aep/yema/internal/ident"
+)
+
+// EnumStyle is the way enums are generated, see Options.Enums
+type EnumStyle string
+
+const (
+	// LiteralUnions generates a type that is the union of the literals of the values, such as "a" | "b"
+	LiteralUnions EnumStyle = "union"
+	// ConstObjects generates an object mapping a key for every value to it as const, and the type of its values
+	ConstObjects EnumStyle = "const"
+	// TSEnums generates a TypeScript enum with a member for every value
+	TSEnums EnumStyle = "enum"
+)
+
+// checkEnumStyle reports a style that is not supported
+func checkEnumStyle(style EnumStyle) error {
+	switch style {
+	case "", LiteralUnions, ConstObjects, TSEnums:
+		return nil
+	}
+	return fmt.Errorf("unsupported enum style %q, expected %s, %s or %s", style, LiteralUnions, ConstObjects, TSEnums)
+}
+
+// isEnum reports whether t is a string or number restricted to an enum, which is generated as a type of its own
+// if Options.Enums is set. Integers written as strings in protojson keep their type.
+func isEnum(t *yema.Type, opts Options) bool {
+	if opts.Enums == "" || len(t.Enum) == 0 {
+		return false
+	}
+
+	tsType, _, err := typeToTypeScriptType(&yema.Type{Kind: t.Kind}, "", "", Options{ProtoJSON: opts.ProtoJSON})
+	if err != nil {
+		return false
+	}
+	for _, value := range t.Enum {
+		switch value.(type) {
+		case string:
+			if tsType != "string" {
+				return false
+			}
+		case int, int64, uint64, float64:
+			if tsType != "number" {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// writeEnum writes the enum t as a type named name in the style of the options. The keys of const objects and
+// the members of TypeScript enums are named after the values.
+func writeEnum(buf *bytes.Buffer, name string, t *yema.Type, exportKeyword string, opts Options) error {
+	literals := make([]string, len(t.Enum))
+	for i, value := range t.Enum {
+		literals[i] = tsLiteral(value)
+	}
+
+	writeDoc(buf, t, fmt.Sprintf("%s is one of the values of an enum", name))
+	if opts.Enums == LiteralUnions {
+		fmt.Fprintf(buf, "%stype %s = %s;\n\n", exportKeyword, name, strings.Join(literals, " | "))
+		return nil
+	}
+
+	keys := make([]string, len(t.Enum))
+	byKey := make(map[string]string, len(t.Enum))
+	for i, value := range t.Enum {
+		keys[i] = ident.Camel(ident.Source(fmt.Sprint(value), "", opts.Transliterate))
+		if other, ok := byKey[keys[i]]; ok {
+			return fmt.Errorf("values %s and %s of %s both map to the key %s", other, literals[i], name, keys[i])
+		}
+		byKey[keys[i]] = literals[i]
+	}
+
+	if opts.Enums == TSEnums {
+		fmt.Fprintf(buf, "%senum %s {\n", exportKeyword, name)
+		for i := range keys {
+			fmt.Fprintf(buf, "  %s = %s,\n", keys[i], literals[i])
+		}
+		buf.WriteString("}\n\n")
+		return nil
+	}
+
+	fmt.Fprintf(buf, "%sconst %s = {\n", exportKeyword, name)
+	for i := range keys {
+		fmt.Fprintf(buf, "  %s: %s,\n", keys[i], literals[i])
+	}
+	buf.WriteString("} as const;\n")
+	fmt.Fprintf(buf, "%stype %s = (typeof %s)[keyof typeof %s];\n\n", exportKeyword, name, name, name)
+	return nil
+}
@@ -0,0 +1,229 @@
+package typescript
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/aep/yema"
+)
+
+// FileStyle is the kind of file generated, see Options.Style
+type FileStyle string
+
+const (
+	// ESModules generates an ES module exporting the types with export statements
+	ESModules FileStyle = "module"
+	// Declarations generates an ambient declaration file, a .d.ts declaring the types globally or in the
+	// namespace of Options.Namespace. It holds no runtime code, such as guards, validators, the mappers of
+	// dates and camelCase properties or enum objects.
+	Declarations FileStyle = "declaration"
+	// Namespaces generates a script declaring the types in the namespace of Options.Namespace
+	Namespaces FileStyle = "namespace"
+)
+
+// resolveFileStyle defaults the style of opts to Namespaces if a namespace is given and ESModules otherwise,
+// and reports options the style cannot be generated with
+func resolveFileStyle(opts *Options) error {
+	if opts.Style == "" {
+		opts.Style = ESModules
+		if opts.Namespace != "" {
+			opts.Style = Namespaces
+		}
+	}
+
+	switch opts.Style {
+	case ESModules:
+		if opts.Namespace != "" {
+			return fmt.Errorf("namespace %s cannot be declared in an ES module, use the %s or %s style", opts.Namespace, Namespaces, Declarations)
+		}
+	case Namespaces:
+		if opts.Namespace == "" {
+			return fmt.Errorf("the %s style requires a namespace", Namespaces)
+		}
+	case Declarations:
+		if opts.Guards || opts.Validators || opts.Dates || opts.CamelCase || opts.EncodedBytes || opts.Enums == ConstObjects || opts.Enums == TSEnums {
+			return fmt.Errorf("declaration files hold no runtime code, generate guards, validators, dates, camelCase properties, encoded bytes and enums of style %s or %s in a module instead", ConstObjects, TSEnums)
+		}
+	default:
+		return fmt.Errorf("unsupported file style %q, expected %s, %s or %s", opts.Style, ESModules, Declarations, Namespaces)
+	}
+
+	if opts.Zod && opts.Style != ESModules {
+		return fmt.Errorf("zod schemas are only generated in ES modules, the file imports zod")
+	}
+	return nil
+}
+
+// exportIf returns the keyword exporting a declaration if exported, global declarations of declaration files
+// are never exported, as an export would make the file a module
+func exportIf(exported bool, opts Options) string {
+	if !exported || opts.Style == Declarations && opts.Namespace == "" {
+		return ""
+	}
+	return "export "
+}
+
+// ToTypeScriptFiles converts a yema.Type to TypeScript code like ToTypeScript, split into an ES module for every
+// top-level type, with its guard and mappers, named like rootaddress.ts. Modules import the types and functions
+// of others they use by relative paths, the root module holds the root list, zod schemas and validators, and
+// index.ts re-exports every module. All types are exported, as other modules import them.
+func ToTypeScriptFiles(t *yema.Type, opts Options) (map[string][]byte, error) {
+	if opts.Namespace != "" || opts.Style != "" && opts.Style != ESModules {
+		return nil, fmt.Errorf("files import each other, they are generated as ES modules only")
+	}
+	if opts.RootType == "" {
+		opts.RootType = "Root"
+	}
+	types := []typeStart{{opts.RootType, 0}}
+	opts.types = &types
+	opts.ExportAll = true
+
+	code, err := ToTypeScript(t, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// The code of a type is the concatenation of its sections, the root type has one before and after the others
+	var names []string
+	sections := make(map[string][]byte)
+	for i, start := range types {
+		end := len(code)
+		if i+1 < len(types) {
+			end = types[i+1].offset
+		}
+		if _, ok := sections[start.name]; !ok {
+			names = append(names, start.name)
+		}
+		sections[start.name] = append(sections[start.name], code[start.offset:end]...)
+	}
+
+	modules := make(map[string]string, len(names))
+	fileNames := map[string]string{"index.ts": "the index"}
+	declared := make(map[string]map[string]bool, len(names))
+	for _, name := range names {
+		module := strings.ToLower(name)
+		if other, ok := fileNames[module+".ts"]; ok {
+			return nil, fmt.Errorf("%s and type %s both map to the file %s.ts", other, name, module)
+		}
+		fileNames[module+".ts"] = "type " + name
+		modules[name] = module
+		declared[name] = declarations(sections[name])
+	}
+
+	files := make(map[string][]byte, len(names)+1)
+	var index bytes.Buffer
+	for _, name := range names {
+		used := identifiers(sections[name])
+		var buf bytes.Buffer
+		for _, other := range names {
+			if other == name {
+				continue
+			}
+			var imported []string
+			typesOnly := true
+			for _, decl := range slices.Sorted(maps.Keys(declared[other])) {
+				if !used[decl] {
+					continue
+				}
+				if declared[other][decl] {
+					typesOnly = false
+					imported = append(imported, decl)
+				} else {
+					imported = append(imported, "type "+decl)
+				}
+			}
+			if len(imported) == 0 {
+				continue
+			}
+			if typesOnly {
+				for i := range imported {
+					imported[i] = strings.TrimPrefix(imported[i], "type ")
+				}
+				fmt.Fprintf(&buf, "import type { %s } from \"./%s\";\n", strings.Join(imported, ", "), modules[other])
+			} else {
+				fmt.Fprintf(&buf, "import { %s } from \"./%s\";\n", strings.Join(imported, ", "), modules[other])
+			}
+		}
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+		buf.Write(sections[name])
+		files[modules[name]+".ts"] = buf.Bytes()
+		fmt.Fprintf(&index, "export * from \"./%s\";\n", modules[name])
+	}
+	files["index.ts"] = index.Bytes()
+	return files, nil
+}
+
+// typeStart is the offset in the generated code a top-level type starts at
+type typeStart struct {
+	name   string
+	offset int
+}
+
+// startType records that the code of the type name starts at the end of buf, when generating files
+func startType(buf *bytes.Buffer, name string, opts Options) {
+	if opts.types != nil {
+		*opts.types = append(*opts.types, typeStart{name, buf.Len()})
+	}
+}
+
+// declarationPattern matches the top-level declarations of generated code
+var declarationPattern = regexp.MustCompile(`(?m)^(?:export )?(interface|type|enum|const|function) ([A-Za-z_$][\w$]*)`)
+
+// declarations returns the names declared by code, true for values, such as functions, enums and enum
+// objects, and false for types only
+func declarations(code []byte) map[string]bool {
+	names := make(map[string]bool)
+	for _, match := range declarationPattern.FindAllSubmatch(code, -1) {
+		kind, name := string(match[1]), string(match[2])
+		names[name] = names[name] || kind != "interface" && kind != "type"
+	}
+	return names
+}
+
+// identifiers returns the identifiers code uses outside of comments and string literals
+func identifiers(code []byte) map[string]bool {
+	used := make(map[string]bool)
+	isIdent := func(c byte) bool {
+		return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+	}
+	for i := 0; i < len(code); {
+		switch c := code[i]; {
+		case bytes.HasPrefix(code[i:], []byte("//")):
+			end := bytes.IndexByte(code[i:], '\n')
+			if end < 0 {
+				return used
+			}
+			i += end
+		case bytes.HasPrefix(code[i:], []byte("/*")):
+			end := bytes.Index(code[i+2:], []byte("*/"))
+			if end < 0 {
+				return used
+			}
+			i += end + 4
+		case c == '"' || c == '\'' || c == '`':
+			i++
+			for i < len(code) && code[i] != c {
+				if code[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			i++
+		case isIdent(c):
+			start := i
+			for i < len(code) && isIdent(code[i]) {
+				i++
+			}
+			used[string(code[start:i])] = true
+		default:
+			i++
+		}
+	}
+	return used
+}
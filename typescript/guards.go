@@ -0,0 +1,127 @@
+package typescript
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aep/yema"
+	"github.com/aep/yema/internal/ident"
+)
+
+// writeStructGuard writes the type guard of the interface typeName of struct t, checking the kind of every field.
+// Optional fields may be missing, nested types are checked by their own guards.
+func writeStructGuard(buf *bytes.Buffer, t *yema.Type, typeName, exportKeyword string, opts Options) error {
+	fmt.Fprintf(buf, "/**\n * is%s reports whether v is a %s\n */\n", typeName, typeName)
+	fmt.Fprintf(buf, "%sfunction is%s(v: unknown): v is %s {\n", exportKeyword, typeName, typeName)
+	buf.WriteString("  if (typeof v !== \"object\" || v === null || Array.isArray(v)) {\n    return false;\n  }\n")
+	buf.WriteString("  const o = v as Record<string, unknown>;\n")
+
+	propNames, err := propertyNames(t, opts)
+	if err != nil {
+		return err
+	}
+
+	vars := 0
+	for _, fieldName := range t.FieldNames() {
+		fieldType := (*t.Struct)[fieldName]
+		typeIdent := ident.Camel(ident.Source(fieldName, fieldType.CodeName, opts.Transliterate))
+		expr := "o[" + strconv.Quote(propNames[fieldName]) + "]"
+		cond, err := guardCondition(&fieldType, expr, typeName, typeIdent, opts, &vars)
+		if err != nil {
+			return err
+		}
+		// protojson omits fields holding their default value, which only structs do not have
+		if fieldType.Optional || opts.ProtoJSON && fieldType.Kind != yema.Struct {
+			cond = expr + " === undefined || " + cond
+		}
+		if fieldType.Optional && opts.NullableOptionals {
+			cond = expr + " === null || " + cond
+		}
+		fmt.Fprintf(buf, "  if (!(%s)) {\n    return false;\n  }\n", cond)
+	}
+
+	buf.WriteString("  return true;\n}\n\n")
+	return nil
+}
+
+// writeGuard writes the type guard of typeName, a root list or scalar of t
+func writeGuard(buf *bytes.Buffer, t *yema.Type, typeName, elemName string, opts Options) error {
+	vars := 0
+	cond, err := guardCondition(t, "v", elemName, "", opts, &vars)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(buf, "/**\n * is%s reports whether v is a %s\n */\n", typeName, typeName)
+	fmt.Fprintf(buf, "export function is%s(v: unknown): v is %s {\n", typeName, typeName)
+	fmt.Fprintf(buf, "  return %s;\n}\n\n", cond)
+	return nil
+}
+
+// guardCondition returns the condition that expr holds a value of the TypeScript type of t, see
+// typeToTypeScriptType. Nested types are named after the parent and typeIdent, vars counts the variables
+// of the items of arrays. Enums are checked for their values.
+func guardCondition(t *yema.Type, expr, parentName, typeIdent string, opts Options, vars *int) (string, error) {
+	protoJSON := opts.ProtoJSON
+	if isEnum(t, opts) {
+		values := make([]string, len(t.Enum))
+		for i, value := range t.Enum {
+			values[i] = expr + " === " + tsLiteral(value)
+		}
+		return strings.Join(values, " || "), nil
+	}
+
+	switch t.Kind {
+	case yema.Bool:
+		return fmt.Sprintf("typeof %s === \"boolean\"", expr), nil
+	case yema.Int, yema.Int64, yema.Uint, yema.Uint64:
+		if protoJSON {
+			return fmt.Sprintf("typeof %s === \"string\"", expr), nil
+		}
+		return fmt.Sprintf("typeof %s === \"number\" && Number.isInteger(%s)", expr, expr), nil
+	case yema.Int8, yema.Int16, yema.Int32, yema.Uint8, yema.Uint16, yema.Uint32:
+		return fmt.Sprintf("typeof %s === \"number\" && Number.isInteger(%s)", expr, expr), nil
+	case yema.Float32, yema.Float64:
+		return fmt.Sprintf("typeof %s === \"number\"", expr), nil
+	case yema.String:
+		if isDate(t, opts) {
+			return fmt.Sprintf("%s instanceof Date", expr), nil
+		}
+		return fmt.Sprintf("typeof %s === \"string\"", expr), nil
+	case yema.Bytes:
+		if protoJSON {
+			return fmt.Sprintf("typeof %s === \"string\"", expr), nil
+		}
+		return fmt.Sprintf("%s instanceof Uint8Array", expr), nil
+	case yema.Array:
+		if t.Array == nil {
+			return "", fmt.Errorf("array type with nil Array field")
+		}
+		*vars++
+		item := "e" + strconv.Itoa(*vars)
+		itemCond, err := guardCondition(t.Array, item, parentName, typeIdent, opts, vars)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Array.isArray(%s) && %s.every((%s) => %s)", expr, expr, item, itemCond), nil
+	case yema.Map:
+		if t.Map == nil {
+			return "", fmt.Errorf("map type with nil Map field")
+		}
+		*vars++
+		value := "e" + strconv.Itoa(*vars)
+		valueCond, err := guardCondition(t.Map, value, parentName, typeIdent, opts, vars)
+		if err != nil {
+			return "", err
+		}
+		if opts.UseMaps {
+			return fmt.Sprintf("%s instanceof Map && Array.from(%s.values()).every((%s) => %s)", expr, expr, value, valueCond), nil
+		}
+		return fmt.Sprintf("typeof %s === \"object\" && %s !== null && !Array.isArray(%s) && Object.values(%s).every((%s) => %s)",
+			expr, expr, expr, expr, value, valueCond), nil
+	case yema.Struct, yema.Union:
+		return fmt.Sprintf("is%s%s(%s)", parentName, typeIdent, expr), nil
+	}
+	return "", fmt.Errorf("unexpected type kind: %v", t.Kind)
+}
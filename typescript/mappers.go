@@ -0,0 +1,279 @@
+package typescript
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aep/yema"
+	"github.com/aep/yema/internal/ident"
+	"github.com/aep/yema/validator"
+)
+
+// dateLayout is the layout of dates without a time, which are a Date at midnight UTC
+const dateLayout = "2006-01-02"
+
+// isDate reports whether t is a timestamp generated as a Date, see Options.Dates. Timestamps in other layouts
+// than RFC 3339 and dateLayout stay strings, as Date cannot parse them.
+func isDate(t *yema.Type, opts Options) bool {
+	if !opts.Dates || t.Kind != yema.String || t.Format != validator.DateTimeFormat || isEnum(t, opts) {
+		return false
+	}
+	return t.FormatArg == nil || t.FormatArg == time.RFC3339 || t.FormatArg == dateLayout
+}
+
+// bytesEncoding returns the encoding of bytes t is converted from and to, or "" if they are not converted, see
+// Options.EncodedBytes. Formats other than encodings are base64, protojson bytes stay strings.
+func bytesEncoding(t *yema.Type, opts Options) string {
+	if !opts.EncodedBytes || opts.ProtoJSON || t.Kind != yema.Bytes {
+		return ""
+	}
+	switch t.Format {
+	case validator.Base64URLEncoding:
+		return "Base64URL"
+	case validator.HexEncoding:
+		return "Hex"
+	}
+	return "Base64"
+}
+
+// needsMapping reports whether values of t differ from their JSON decoding, holding Dates, Maps, encoded bytes
+// or properties named other than their fields
+func needsMapping(t *yema.Type, opts Options) bool {
+	if !opts.Dates && !opts.CamelCase && !opts.EncodedBytes {
+		return false
+	}
+	switch t.Kind {
+	case yema.String:
+		return isDate(t, opts)
+	case yema.Bytes:
+		return bytesEncoding(t, opts) != ""
+	case yema.Array:
+		return needsMapping(t.Array, opts)
+	case yema.Map:
+		return opts.UseMaps || needsMapping(t.Map, opts)
+	case yema.Struct:
+		if renamesFields(t, opts) {
+			return true
+		}
+		for _, field := range *t.Struct {
+			if needsMapping(&field, opts) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// mapping returns the expression converting expr of type t from its JSON decoding if decode, or to the value
+// JSON.stringify encodes otherwise, "" if it needs no conversion. Nested types are named after the parent and
+// typeIdent, vars counts the variables of items.
+func mapping(t *yema.Type, expr string, decode bool, parentName, typeIdent string, opts Options, vars *int) string {
+	if !needsMapping(t, opts) {
+		return ""
+	}
+
+	switch t.Kind {
+	case yema.String:
+		switch {
+		case decode:
+			return fmt.Sprintf("new Date(%s)", expr)
+		case t.FormatArg == dateLayout:
+			return fmt.Sprintf("%s.toISOString().slice(0, 10)", expr)
+		}
+		return fmt.Sprintf("%s.toISOString()", expr)
+	case yema.Bytes:
+		if decode {
+			return fmt.Sprintf("decode%s(%s)", bytesEncoding(t, opts), expr)
+		}
+		return fmt.Sprintf("encode%s(%s)", bytesEncoding(t, opts), expr)
+	case yema.Array:
+		*vars++
+		item := "e" + strconv.Itoa(*vars)
+		itemMapping := mapping(t.Array, item, decode, parentName, typeIdent, opts, vars)
+		if decode {
+			return fmt.Sprintf("%s.map((%s: any) => %s)", expr, item, itemMapping)
+		}
+		return fmt.Sprintf("%s.map((%s) => %s)", expr, item, itemMapping)
+	case yema.Map:
+		*vars++
+		key, value := "k"+strconv.Itoa(*vars), "e"+strconv.Itoa(*vars)
+		valueMapping := mapping(t.Map, value, decode, parentName, typeIdent, opts, vars)
+		if valueMapping == "" {
+			valueMapping = value
+		}
+		switch {
+		case decode && opts.UseMaps:
+			return fmt.Sprintf("new Map(Object.entries(%s).map(([%s, %s]: [string, any]) => [%s, %s] as const))", expr, key, value, key, valueMapping)
+		case decode:
+			return fmt.Sprintf("Object.fromEntries(Object.entries(%s).map(([%s, %s]: [string, any]) => [%s, %s] as const))", expr, key, value, key, valueMapping)
+		case opts.UseMaps:
+			return fmt.Sprintf("Object.fromEntries(Array.from(%s, ([%s, %s]) => [%s, %s] as const))", expr, key, value, key, valueMapping)
+		}
+		return fmt.Sprintf("Object.fromEntries(Object.entries(%s).map(([%s, %s]) => [%s, %s] as const))", expr, key, value, key, valueMapping)
+	case yema.Struct:
+		if decode {
+			return fmt.Sprintf("decode%s%s(%s)", parentName, typeIdent, expr)
+		}
+		return fmt.Sprintf("encode%s%s(%s)", parentName, typeIdent, expr)
+	}
+	return ""
+}
+
+// writeStructMappers writes the functions decoding and encoding the interface typeName of struct t,
+// converting the fields that need it and keeping the others. With CamelCase every field is renamed,
+// leaving out unknown fields.
+func writeStructMappers(buf *bytes.Buffer, t *yema.Type, typeName, exportKeyword string, opts Options) error {
+	propNames, err := propertyNames(t, opts)
+	if err != nil {
+		return err
+	}
+
+	for _, decode := range []bool{true, false} {
+		writeMapperDoc(buf, typeName, decode)
+		if decode {
+			fmt.Fprintf(buf, "%sfunction decode%s(v: any): %s {\n", exportKeyword, typeName, typeName)
+		} else {
+			fmt.Fprintf(buf, "%sfunction encode%s(v: %s): any {\n", exportKeyword, typeName, typeName)
+		}
+		buf.WriteString("  return {\n")
+		if !opts.CamelCase {
+			buf.WriteString("    ...v,\n")
+		}
+
+		vars := 0
+		for _, fieldName := range t.FieldNames() {
+			fieldType := (*t.Struct)[fieldName]
+			typeIdent := ident.Camel(ident.Source(fieldName, fieldType.CodeName, opts.Transliterate))
+			// Decoding reads fields and writes properties, encoding the other way round
+			from, to := fieldName, propNames[fieldName]
+			if !decode {
+				from, to = to, from
+			}
+			expr := "v[" + strconv.Quote(from) + "]"
+			conv := mapping(&fieldType, expr, decode, typeName, typeIdent, opts, &vars)
+			if conv == "" {
+				if !opts.CamelCase {
+					continue
+				}
+				fmt.Fprintf(buf, "    %s: %s,\n", propertyKey(to), expr)
+				continue
+			}
+			// Optional fields stay missing, the validators accept null for them as well, which is kept if
+			// the type allows it
+			if fieldType.Optional || opts.ProtoJSON && fieldType.Kind != yema.Struct {
+				missing := "undefined"
+				if fieldType.Optional && opts.NullableOptionals {
+					missing = expr
+				}
+				conv = fmt.Sprintf("%s == null ? %s : %s", expr, missing, conv)
+			}
+			fmt.Fprintf(buf, "    %s: %s,\n", propertyKey(to), conv)
+		}
+		buf.WriteString("  };\n}\n\n")
+	}
+	return nil
+}
+
+// writeMappers writes the functions decoding and encoding typeName, a root list or scalar of t
+func writeMappers(buf *bytes.Buffer, t *yema.Type, typeName, elemName string, opts Options) {
+	for _, decode := range []bool{true, false} {
+		vars := 0
+		conv := mapping(t, "v", decode, elemName, "", opts, &vars)
+		writeMapperDoc(buf, typeName, decode)
+		if decode {
+			fmt.Fprintf(buf, "export function decode%s(v: any): %s {\n", typeName, typeName)
+		} else {
+			fmt.Fprintf(buf, "export function encode%s(v: %s): any {\n", typeName, typeName)
+		}
+		fmt.Fprintf(buf, "  return %s;\n}\n\n", conv)
+	}
+}
+
+// writeMapperDoc writes the doc comment of the function decoding or encoding typeName
+func writeMapperDoc(buf *bytes.Buffer, typeName string, decode bool) {
+	if decode {
+		fmt.Fprintf(buf, "/**\n * decode%s converts the JSON.parse result of a %s to its type\n */\n", typeName, typeName)
+		return
+	}
+	fmt.Fprintf(buf, "/**\n * encode%s converts a %s to the value JSON.stringify encodes\n */\n", typeName, typeName)
+}
+
+// encodingFuncs are the functions converting bytes from and to the strings of an encoding, by bytesEncoding.
+// Decoding accepts base64 with or without padding, like package validator.
+var encodingFuncs = map[string]string{
+	"Base64": `/**
+ * decodeBase64 converts a base64 string to its bytes
+ */
+%[1]sfunction decodeBase64(s: string): Uint8Array {
+  return Uint8Array.from(atob(s), (c) => c.charCodeAt(0));
+}
+
+/**
+ * encodeBase64 converts bytes to a padded base64 string
+ */
+%[1]sfunction encodeBase64(b: Uint8Array): string {
+  return btoa(Array.from(b, (byte) => String.fromCharCode(byte)).join(""));
+}
+
+`,
+	"Base64URL": `/**
+ * decodeBase64URL converts a URL-safe base64 string to its bytes
+ */
+%[1]sfunction decodeBase64URL(s: string): Uint8Array {
+  return Uint8Array.from(atob(s.replace(/-/g, "+").replace(/_/g, "/")), (c) => c.charCodeAt(0));
+}
+
+/**
+ * encodeBase64URL converts bytes to a padded URL-safe base64 string
+ */
+%[1]sfunction encodeBase64URL(b: Uint8Array): string {
+  return btoa(Array.from(b, (byte) => String.fromCharCode(byte)).join("")).replace(/\+/g, "-").replace(/\//g, "_");
+}
+
+`,
+	"Hex": `/**
+ * decodeHex converts a hex string to its bytes
+ */
+%[1]sfunction decodeHex(s: string): Uint8Array {
+  return Uint8Array.from(s.match(/../g) ?? [], (byte) => parseInt(byte, 16));
+}
+
+/**
+ * encodeHex converts bytes to a lower-case hex string
+ */
+%[1]sfunction encodeHex(b: Uint8Array): string {
+  return Array.from(b, (byte) => byte.toString(16).padStart(2, "0")).join("");
+}
+
+`,
+}
+
+// encodings collects the encodings of the bytes t holds in used, see bytesEncoding
+func encodings(t *yema.Type, opts Options, used map[string]bool) {
+	switch t.Kind {
+	case yema.Bytes:
+		if encoding := bytesEncoding(t, opts); encoding != "" {
+			used[encoding] = true
+		}
+	case yema.Array:
+		encodings(t.Array, opts, used)
+	case yema.Map:
+		encodings(t.Map, opts, used)
+	case yema.Struct:
+		for _, field := range *t.Struct {
+			encodings(&field, opts, used)
+		}
+	}
+}
+
+// writeEncodings writes the functions converting bytes from and to the strings of the used encodings,
+// exported with Options.ExportAll
+func writeEncodings(buf *bytes.Buffer, used map[string]bool, opts Options) {
+	for _, encoding := range []string{"Base64", "Base64URL", "Hex"} {
+		if used[encoding] {
+			fmt.Fprintf(buf, encodingFuncs[encoding], exportIf(opts.ExportAll, opts))
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package typescript
+
+import (
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/aep/yema"
+	"github.com/aep/yema/internal/ident"
+)
+
+// propertyNames returns the property names of the fields of struct t, the field names themselves unless
+// Options.CamelCase converts them
+func propertyNames(t *yema.Type, opts Options) (map[string]string, error) {
+	if !opts.CamelCase {
+		names := make(map[string]string, len(*t.Struct))
+		for name := range *t.Struct {
+			names[name] = name
+		}
+		return names, nil
+	}
+	return ident.Fields(t, lowerCamel, opts.Transliterate)
+}
+
+// renamesFields reports whether a property name of struct t differs from its field name
+func renamesFields(t *yema.Type, opts Options) bool {
+	names, err := propertyNames(t, opts)
+	if err != nil {
+		// Reported when generating the interface
+		return true
+	}
+	for fieldName, name := range names {
+		if name != fieldName {
+			return true
+		}
+	}
+	return false
+}
+
+// lowerCamel converts s to camelCase, such as created_at to createdAt
+func lowerCamel(s string) string {
+	camel := ident.Camel(s)
+	first, size := utf8.DecodeRuneInString(camel)
+	return string(unicode.ToLower(first)) + camel[size:]
+}
+
+// propertyKey returns name as the key of a property, quoted if it is not a valid identifier
+func propertyKey(name string) string {
+	if !ident.IsIdentifier(name) {
+		return strconv.Quote(name)
+	}
+	return name
+}
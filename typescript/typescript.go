@@ -3,21 +3,136 @@ package typescript
 import (
 	"bytes"
 	"fmt"
-	"unicode"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
 
 	"github.com/aep/yema"
+	"github.com/aep/yema/internal/ident"
 )
 
 // Options holds configuration options for TypeScript code generation
 type Options struct {
 	// Namespace is the TypeScript namespace to use (if any)
 	Namespace string
-	// RootType is the name of the root interface type
+	// Style is the kind of file, ESModules unless a Namespace is given, which implies Namespaces.
+	// Namespaces are deprecated practice in modern TypeScript projects, which use modules.
+	Style FileStyle
+	// RootType is the name of the root type. If the root is an array, it names the element type
+	// and the root itself is named RootType + "List".
 	RootType string
-	// UseInterfaces determines whether to generate interfaces (true) or types (false)
-	UseInterfaces bool
+	// Structs is the way structs are declared, as Interfaces unless TypeAliases are chosen. Other types, such as
+	// root lists and maps, are always type aliases, as interfaces cannot declare them.
+	Structs StructStyle
+	// StructStyles overrides the declaration of structs by the name of their type, such as RootAddress
+	StructStyles map[string]StructStyle
 	// ExportAll determines whether to export all types (true) or just the root type (false)
 	ExportAll bool
+	// Validators determines whether to generate a runtime validation function for the root type
+	Validators bool
+	// Transliterate derives the names of nested types from ASCII transliterations of non-ASCII field names.
+	// Property names keep the field name unless CamelCase converts them.
+	Transliterate bool
+	// ProtoJSON follows the protobuf JSON mapping: 64-bit integers and bytes are strings,
+	// and fields that are not structs may be omitted, as proto3 omits default values
+	ProtoJSON bool
+	// Zod generates zod schemas of the root type next to the types, parsing the same JSON documents as the
+	// validators at runtime. The file imports zod, so it must be one of ESModules.
+	Zod bool
+	// Guards generates a type guard isType for every generated type, checking the kinds of fields and items
+	// at runtime without depending on a validation library
+	Guards bool
+	// UseMaps generates maps as a Map instead of a Record. JSON.parse returns plain objects, which the
+	// caller converts, e.g. with new Map(Object.entries(o)).
+	UseMaps bool
+	// Enums generates a type of its own for every string or number restricted to an $enum, named like nested
+	// types, in one of the styles LiteralUnions, ConstObjects or TSEnums, instead of a string or number
+	Enums EnumStyle
+	// Brands generates a branded type for every string of the uuid format, named after its field like nested
+	// types, such as type UserId = string & { readonly __brand: "UserId" }, so ids of different entities
+	// cannot be mixed up
+	Brands bool
+	// BrandNames renames brands by the name of their field, such as OrderUserId to UserId,
+	// so fields holding ids of the same entity share a brand
+	BrandNames map[string]string
+	// Dates types timestamps in RFC 3339 and dates in the layout 2006-01-02 as a Date instead of the string of
+	// their JSON encoding, and generates functions decodeType and encodeType for the types holding them, which
+	// convert values after JSON.parse and before JSON.stringify
+	Dates bool
+	// CamelCase names properties in camelCase, converting field names such as created_at to createdAt, or the
+	// $codename of a field, and generates functions decodeType and encodeType for the types holding them, which
+	// translate the names of JSON documents after JSON.parse and before JSON.stringify
+	CamelCase bool
+	// EncodedBytes converts bytes, a Uint8Array, from and to the strings of their JSON encoding in the decodeType
+	// and encodeType functions, in base64 or the base64url or hex encoding their format declares. Without it,
+	// the JSON documents of types holding bytes are not values of their types.
+	EncodedBytes bool
+	// NullableOptionals types optional fields as T | null as well, as JSON documents may hold null for them
+	NullableOptionals bool
+	// ExactOptionals types fields that may be missing as T | undefined as well, for projects enabling
+	// exactOptionalPropertyTypes, where the decoders of Dates set missing fields to undefined
+	ExactOptionals bool
+
+	// renamedBrands are the BrandNames in use
+	renamedBrands map[string]bool
+	// styledStructs are the StructStyles in use
+	styledStructs map[string]bool
+	// types collects the top-level types in the order they are generated, see ToTypeScriptFiles
+	types *[]typeStart
+}
+
+// StructStyle is the way structs are declared, see Options.Structs
+type StructStyle string
+
+const (
+	// Interfaces declares structs as interfaces, which can be extended and merged
+	Interfaces StructStyle = "interface"
+	// TypeAliases declares structs as type aliases of object types
+	TypeAliases StructStyle = "alias"
+)
+
+// checkStructStyles reports styles that are not supported
+func checkStructStyles(opts Options) error {
+	styles := []StructStyle{opts.Structs}
+	for _, name := range slices.Sorted(maps.Keys(opts.StructStyles)) {
+		styles = append(styles, opts.StructStyles[name])
+	}
+	for _, style := range styles {
+		if style != "" && style != Interfaces && style != TypeAliases {
+			return fmt.Errorf("unsupported struct style %q, expected %s or %s", style, Interfaces, TypeAliases)
+		}
+	}
+	return nil
+}
+
+// structStyle returns the way the struct typeName is declared
+func structStyle(typeName string, opts Options) StructStyle {
+	style, ok := opts.StructStyles[typeName]
+	if ok {
+		opts.styledStructs[typeName] = true
+	} else {
+		style = opts.Structs
+	}
+	if style == "" {
+		return Interfaces
+	}
+	return style
+}
+
+// checkStructNames reports StructStyles of types that are not generated structs
+func checkStructNames(opts Options) error {
+	var unmatched []string
+	for name := range opts.StructStyles {
+		if !opts.styledStructs[name] {
+			unmatched = append(unmatched, name)
+		}
+	}
+	if len(unmatched) > 0 {
+		sort.Strings(unmatched)
+		return fmt.Errorf("struct styles of %s match no generated struct", strings.Join(unmatched, ", "))
+	}
+	return nil
 }
 
 // ToTypeScriptWithOptions converts a yema.Type to TypeScript definitions with custom options
@@ -26,30 +141,136 @@ func ToTypeScript(t *yema.Type, opts Options) ([]byte, error) {
 		return nil, fmt.Errorf("nil type provided")
 	}
 
-	if t.Kind != yema.Struct {
-		return nil, fmt.Errorf("expected root type to be Struct, got %v", t.Kind)
-	}
-
 	// Use default values if not provided
 	if opts.RootType == "" {
 		opts.RootType = "Root"
 	}
-	if !opts.UseInterfaces {
-		opts.UseInterfaces = true // Default to interfaces
+	if err := checkEnumStyle(opts.Enums); err != nil {
+		return nil, err
+	}
+	if err := resolveFileStyle(&opts); err != nil {
+		return nil, err
+	}
+	if err := checkStructStyles(opts); err != nil {
+		return nil, err
 	}
+	opts.renamedBrands = make(map[string]bool)
+	opts.styledStructs = make(map[string]bool)
 
 	var buf bytes.Buffer
+	if opts.Zod {
+		buf.WriteString(zodImport)
+	}
 
-	// Write namespace if provided
+	// Write namespace if provided, declaration files declare it ambiently
 	if opts.Namespace != "" {
+		if opts.Style == Declarations {
+			buf.WriteString("declare ")
+		}
 		buf.WriteString(fmt.Sprintf("namespace %s {\n\n", opts.Namespace))
 	}
 
-	// Process the root struct
-	err := generateInterfaces(t, opts.RootType, &buf, make(map[string]bool), opts)
-	if err != nil {
+	// The root types are registered first, nested types must not be named like them
+	generatedTypes := map[string]string{opts.RootType: "the root type"}
+
+	// A root array is a list of its element type, which is named RootType
+	rootName := opts.RootType
+	elem, depth := t, 0
+	for elem.Kind == yema.Array && elem.Array != nil {
+		elem = elem.Array
+		depth++
+	}
+	if depth > 0 {
+		rootName = opts.RootType + "List"
+		generatedTypes[rootName] = "the root list"
+		fmt.Fprintf(&buf, "/**\n * %s is a list of %s\n */\n", rootName, opts.RootType)
+		fmt.Fprintf(&buf, "%stype %s = %s%s;\n\n", exportIf(true, opts), rootName, opts.RootType, strings.Repeat("[]", depth))
+	}
+
+	// The values of a root map are named like a field Value
+	valuesName := opts.RootType
+	if elem.Kind == yema.Map {
+		valuesName += "Value"
+	}
+
+	if elem.Kind == yema.Struct {
+		// Process the root struct
+		err := generateInterfaces(elem, opts.RootType, &buf, generatedTypes, opts)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		scalar := *elem
+		scalar.Optional = false
+		if isEnum(&scalar, opts) {
+			if err := writeEnum(&buf, opts.RootType, &scalar, exportIf(true, opts), opts); err != nil {
+				return nil, err
+			}
+		} else if isBrand(&scalar, opts) {
+			writeBrand(&buf, opts.RootType, &scalar, exportIf(true, opts))
+		} else if scalar.Kind == yema.Union {
+			if err := generateUnion(&scalar, opts.RootType, &buf, generatedTypes, opts); err != nil {
+				return nil, err
+			}
+		} else {
+			tsType, nestedName, err := typeToTypeScriptType(&scalar, valuesName, "", opts)
+			if err != nil {
+				return nil, err
+			}
+			writeDoc(&buf, &scalar, fmt.Sprintf("%s represents a generated type", opts.RootType))
+			fmt.Fprintf(&buf, "%stype %s = %s;\n\n", exportIf(true, opts), opts.RootType, tsType)
+			if nestedName != "" {
+				if err := generateNested(nestedType{nestedName, nestedElem(&scalar), "the values of the root"}, &buf, generatedTypes, opts); err != nil {
+					return nil, err
+				}
+			}
+		}
+		startType(&buf, opts.RootType, opts)
+		if opts.Guards && scalar.Kind != yema.Union {
+			if err := writeGuard(&buf, &scalar, opts.RootType, valuesName, opts); err != nil {
+				return nil, err
+			}
+		}
+		if needsMapping(&scalar, opts) {
+			writeMappers(&buf, &scalar, opts.RootType, valuesName, opts)
+		}
+	}
+	startType(&buf, opts.RootType, opts)
+	if opts.Guards && depth > 0 {
+		if err := writeGuard(&buf, t, rootName, valuesName, opts); err != nil {
+			return nil, err
+		}
+	}
+	if depth > 0 && needsMapping(t, opts) {
+		writeMappers(&buf, t, rootName, valuesName, opts)
+	}
+
+	if err := checkBrandNames(opts); err != nil {
 		return nil, err
 	}
+	if err := checkStructNames(opts); err != nil {
+		return nil, err
+	}
+
+	// The bytes of all types share the functions of their encodings
+	usedEncodings := make(map[string]bool)
+	encodings(t, opts, usedEncodings)
+	if len(usedEncodings) > 0 {
+		startType(&buf, "Encodings", opts)
+		writeEncodings(&buf, usedEncodings, opts)
+	}
+
+	if opts.Zod {
+		if err := generateZod(elem, opts.RootType, depth, &buf, opts.ProtoJSON); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Validators {
+		if err := generateValidator(t, rootName, &buf, opts.ProtoJSON); err != nil {
+			return nil, err
+		}
+	}
 
 	// Close namespace if needed
 	if opts.Namespace != "" {
@@ -59,78 +280,116 @@ func ToTypeScript(t *yema.Type, opts Options) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
-// generateInterfaces recursively generates TypeScript interface definitions
-func generateInterfaces(t *yema.Type, typeName string, buf *bytes.Buffer, generatedTypes map[string]bool, opts Options) error {
+// nestedType is a nested type that still needs to be generated
+type nestedType struct {
+	name string
+	t    *yema.Type
+	// origin describes the field the type is generated for in errors
+	origin string
+}
+
+// brandOrigin is the origin of brands, which fields may share
+const brandOrigin = "a brand"
+
+// generateInterfaces recursively generates TypeScript interface definitions. Fields are written in declaration
+// order, or sorted by name for schemas without one, and nested types follow their parent in the order of its
+// fields, so the output is the same on every run. generatedTypes maps the names of generated types to their origin.
+func generateInterfaces(t *yema.Type, typeName string, buf *bytes.Buffer, generatedTypes map[string]string, opts Options) error {
 	if t.Kind != yema.Struct {
 		return fmt.Errorf("expected Struct type, got %v", t.Kind)
 	}
 
-	// Don't regenerate types we've already processed
-	if generatedTypes[typeName] {
-		return nil
-	}
-
-	// Mark this type as generated
-	generatedTypes[typeName] = true
-
 	// Start type definition
-	fmt.Fprintf(buf, "/**\n * %s represents a generated type\n */\n", typeName)
+	writeDoc(buf, t, fmt.Sprintf("%s represents a generated type", typeName))
 
 	// Determine export keyword
-	exportKeyword := ""
-	if opts.ExportAll || typeName == opts.RootType {
-		exportKeyword = "export "
-	}
+	exportKeyword := exportIf(opts.ExportAll || typeName == opts.RootType, opts)
 
 	// Use interface or type alias based on options
-	if opts.UseInterfaces {
+	interfaces := structStyle(typeName, opts) == Interfaces
+	if interfaces {
 		fmt.Fprintf(buf, "%sinterface %s {\n", exportKeyword, typeName)
 	} else {
 		fmt.Fprintf(buf, "%stype %s = {\n", exportKeyword, typeName)
 	}
 
-	// Track any nested types we need to generate
-	nestedTypes := make(map[string]*yema.Type)
+	// Track any nested types we need to generate, and the fields they are named after
+	var nestedTypes []nestedType
+	nestedFields := make(map[string]string)
+
+	propNames, err := propertyNames(t, opts)
+	if err != nil {
+		return fmt.Errorf("type %s: %w", typeName, err)
+	}
 
 	// Process all fields in the struct
-	for fieldName, fieldType := range *t.Struct {
+	for _, fieldName := range t.FieldNames() {
+		fieldType := (*t.Struct)[fieldName]
+		// protojson omits fields holding their default value, which only structs do not have
 		var tsSuffix string
-		if fieldType.Optional {
+		if fieldType.Optional || opts.ProtoJSON && fieldType.Kind != yema.Struct {
 			tsSuffix = "?"
 		}
-		tsFieldType, nestedName, err := typeToTypeScriptType(&fieldType, typeName, fieldName)
+		typeIdent := ident.Camel(ident.Source(fieldName, fieldType.CodeName, opts.Transliterate))
+		tsFieldType, nestedName, err := typeToTypeScriptType(&fieldType, typeName, typeIdent, opts)
 		if err != nil {
 			return err
 		}
+		// Fields may share a renamed brand
+		if nestedName != "" && !isBrand(nestedElem(&fieldType), opts) {
+			if other, ok := nestedFields[nestedName]; ok {
+				return fmt.Errorf("type %s: fields %q and %q both map to the nested type %s, declare a $codename for one of them", typeName, other, fieldName, nestedName)
+			}
+			nestedFields[nestedName] = fieldName
+		}
 
 		// Check if this field requires a nested type to be generated
-		if nestedName != "" && fieldType.Kind == yema.Struct {
-			nestedTypes[nestedName] = &yema.Type{
-				Kind:   yema.Struct,
-				Struct: fieldType.Struct,
-			}
-		} else if nestedName != "" && fieldType.Kind == yema.Array && fieldType.Array.Kind == yema.Struct {
-			nestedTypes[nestedName] = &yema.Type{
-				Kind:   yema.Struct,
-				Struct: fieldType.Array.Struct,
-			}
+		if nestedName != "" {
+			nestedTypes = append(nestedTypes, nestedType{nestedName, nestedElem(&fieldType), fmt.Sprintf("field %q of %s", fieldName, typeName)})
+		}
+
+		// Quote property names that are not valid identifiers
+		propName := propertyKey(propNames[fieldName])
+
+		// Read-only fields are never sent by clients, write-only fields are documented since TypeScript cannot express them
+		var modifier string
+		if fieldType.ReadOnly {
+			modifier = "readonly "
+		}
+		writeFieldDoc(buf, "  ", &fieldType)
+
+		if fieldType.Optional && opts.NullableOptionals {
+			tsFieldType += " | null"
+		}
+		if tsSuffix != "" && opts.ExactOptionals {
+			tsFieldType += " | undefined"
 		}
 
 		// Write field definition
-		fmt.Fprintf(buf, "  %s%s: %s;\n", fieldName, tsSuffix, tsFieldType)
+		fmt.Fprintf(buf, "  %s%s%s: %s;\n", modifier, propName, tsSuffix, tsFieldType)
 	}
 
 	// Close type definition
-	if opts.UseInterfaces {
+	if interfaces {
 		fmt.Fprintf(buf, "}\n\n")
 	} else {
 		fmt.Fprintf(buf, "};\n\n")
 	}
 
+	if opts.Guards {
+		if err := writeStructGuard(buf, t, typeName, exportKeyword, opts); err != nil {
+			return err
+		}
+	}
+	if needsMapping(t, opts) {
+		if err := writeStructMappers(buf, t, typeName, exportKeyword, opts); err != nil {
+			return err
+		}
+	}
+
 	// Generate any nested type definitions
-	for nestedName, nestedStruct := range nestedTypes {
-		err := generateInterfaces(nestedStruct, nestedName, buf, generatedTypes, opts)
-		if err != nil {
+	for _, nested := range nestedTypes {
+		if err := generateNested(nested, buf, generatedTypes, opts); err != nil {
 			return err
 		}
 	}
@@ -138,35 +397,119 @@ func generateInterfaces(t *yema.Type, typeName string, buf *bytes.Buffer, genera
 	return nil
 }
 
-// typeToTypeScriptType converts a yema.Type to a TypeScript type string
-func typeToTypeScriptType(t *yema.Type, parentName, fieldName string) (string, string, error) {
+// nestedElem returns the nested struct, union, enum or brand of a field of type t, the item of arrays and the value of maps
+func nestedElem(t *yema.Type) *yema.Type {
+	for t.Kind == yema.Array || t.Kind == yema.Map {
+		if t.Kind == yema.Array {
+			t = t.Array
+		} else {
+			t = t.Map
+		}
+	}
+	if t.Kind == yema.Struct {
+		return &yema.Type{
+			Kind:        yema.Struct,
+			Struct:      t.Struct,
+			Order:       t.Order,
+			Description: t.Description,
+			Example:     t.Example,
+		}
+	}
+	return t
+}
+
+// generateNested generates the interface of a nested struct or the type of a nested union, enum or brand. Types of
+// different origins named alike, such as those of the fields fooBar and foo.bar, are reported.
+func generateNested(nested nestedType, buf *bytes.Buffer, generatedTypes map[string]string, opts Options) error {
+	origin := nested.origin
+	if isBrand(nested.t, opts) {
+		origin = brandOrigin
+	}
+	if other, ok := generatedTypes[nested.name]; ok {
+		if other == brandOrigin && origin == brandOrigin {
+			return nil
+		}
+		return fmt.Errorf("%s and %s both map to the type %s, declare a $codename for one of them", other, origin, nested.name)
+	}
+	generatedTypes[nested.name] = origin
+	startType(buf, nested.name, opts)
+
+	if nested.t.Kind == yema.Struct {
+		return generateInterfaces(nested.t, nested.name, buf, generatedTypes, opts)
+	}
+	if nested.t.Kind == yema.Union {
+		return generateUnion(nested.t, nested.name, buf, generatedTypes, opts)
+	}
+	if isBrand(nested.t, opts) {
+		writeBrand(buf, nested.name, nested.t, exportIf(opts.ExportAll, opts))
+		return nil
+	}
+	return writeEnum(buf, nested.name, nested.t, exportIf(opts.ExportAll, opts), opts)
+}
+
+// typeToTypeScriptType converts a yema.Type to a TypeScript type string, nested types and enums are named after the
+// parent and typeIdent. With ProtoJSON, 64-bit integers and bytes are the strings protojson encodes them as.
+func typeToTypeScriptType(t *yema.Type, parentName, typeIdent string, opts Options) (string, string, error) {
 	var tsType string
 	var nestedStructName string
+	protoJSON := opts.ProtoJSON
+
+	if isEnum(t, opts) {
+		return parentName + typeIdent, parentName + typeIdent, nil
+	}
+	if isBrand(t, opts) {
+		name := brandName(parentName+typeIdent, opts)
+		return name, name, nil
+	}
 
 	switch t.Kind {
 	case yema.Bool:
 		tsType = "boolean"
-	case yema.Int, yema.Int8, yema.Int16, yema.Int32, yema.Int64,
-		yema.Uint, yema.Uint8, yema.Uint16, yema.Uint32, yema.Uint64,
+	case yema.Int, yema.Int64, yema.Uint, yema.Uint64:
+		tsType = "number"
+		if protoJSON {
+			tsType = "string"
+		}
+	case yema.Int8, yema.Int16, yema.Int32,
+		yema.Uint8, yema.Uint16, yema.Uint32,
 		yema.Float32, yema.Float64:
 		tsType = "number"
 	case yema.String:
 		tsType = "string"
+		if isDate(t, opts) {
+			tsType = "Date"
+		}
 	case yema.Bytes:
 		tsType = "Uint8Array"
+		if protoJSON {
+			tsType = "string"
+		}
 	case yema.Array:
 		if t.Array == nil {
 			return "", "", fmt.Errorf("array type with nil Array field")
 		}
-		elemType, elemNestedName, err := typeToTypeScriptType(t.Array, parentName, fieldName)
+		elemType, elemNestedName, err := typeToTypeScriptType(t.Array, parentName, typeIdent, opts)
 		if err != nil {
 			return "", "", err
 		}
 		tsType = elemType + "[]"
 		nestedStructName = elemNestedName
-	case yema.Struct:
+	case yema.Map:
+		if t.Map == nil {
+			return "", "", fmt.Errorf("map type with nil Map field")
+		}
+		valueType, valueNestedName, err := typeToTypeScriptType(t.Map, parentName, typeIdent, opts)
+		if err != nil {
+			return "", "", err
+		}
+		tsType = "Record<string, " + valueType + ">"
+		if opts.UseMaps {
+			tsType = "Map<string, " + valueType + ">"
+		}
+		nestedStructName = valueNestedName
+	case yema.Struct, yema.Union:
 		// Create a name for the nested type
-		nestedStructName = parentName + toCamelCase(fieldName)
+		nestedStructName = parentName + typeIdent
 		tsType = nestedStructName
 	default:
 		return "", "", fmt.Errorf("unexpected type kind: %v", t.Kind)
@@ -174,26 +517,3 @@ func typeToTypeScriptType(t *yema.Type, parentName, fieldName string) (string, s
 
 	return tsType, nestedStructName, nil
 }
-
-// toCamelCase converts a string to CamelCase
-func toCamelCase(s string) string {
-	var result string
-	nextUpper := true
-
-	for _, char := range s {
-		if char == '_' || char == '-' || char == ' ' {
-			nextUpper = true
-			continue
-		}
-
-		if nextUpper {
-			result += string(unicode.ToUpper(char))
-			nextUpper = false
-		} else {
-			result += string(char)
-		}
-	}
-
-	return result
-}
-
@@ -1,9 +1,12 @@
 package typescript
 
 import (
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/aep/yema"
+	"github.com/aep/yema/validator"
 )
 
 func TestToTypeScript(t *testing.T) {
@@ -33,7 +36,7 @@ func TestToTypeScript(t *testing.T) {
 	}
 
 	// Generate TypeScript
-	ts, err := ToTypeScript(userType)
+	ts, err := ToTypeScript(userType, Options{})
 	if err != nil {
 		t.Fatalf("Failed to generate TypeScript: %v", err)
 	}
@@ -43,17 +46,832 @@ func TestToTypeScript(t *testing.T) {
 
 	// With custom options
 	customOpts := Options{
-		Namespace:    "API",
-		RootType:     "User",
-		UseInterfaces: true,
-		ExportAll:    false,
+		Namespace: "API",
+		RootType:  "User",
+		Structs:   Interfaces,
+		ExportAll: false,
 	}
 
-	tsWithOpts, err := ToTypeScriptWithOptions(userType, customOpts)
+	tsWithOpts, err := ToTypeScript(userType, customOpts)
 	if err != nil {
 		t.Fatalf("Failed to generate TypeScript with options: %v", err)
 	}
 
 	// Print the generated TypeScript with custom options for inspection
 	t.Logf("Generated TypeScript with custom options:\n%s", string(tsWithOpts))
-}
\ No newline at end of file
+}
+
+func TestToTypeScriptValidators(t *testing.T) {
+	schema := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"name": {Kind: yema.String},
+			"age":  {Kind: yema.Int8, Optional: true},
+			"tags": {Kind: yema.Array, Array: &yema.Type{Kind: yema.String}},
+		},
+	}
+
+	ts, err := ToTypeScript(schema, Options{RootType: "User", Validators: true})
+	if err != nil {
+		t.Fatalf("Failed to generate TypeScript: %v", err)
+	}
+
+	out := string(ts)
+	for _, want := range []string{
+		"export function validateUser(v: unknown): string[] {",
+		"errors.push(`required field 'name' is missing`);",
+		"errors.push(`field 'age' must be at most 127`);",
+		"errors.push(`field 'tags[${i",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("generated TypeScript does not contain %q:\n%s", want, out)
+		}
+	}
+
+	// Values without checks beyond their type have no else branch
+	if want := "if (typeof v2 !== \"string\") {\n        errors.push(`field 'name' must be a string`);\n      }\n    }\n"; !strings.Contains(out, want) {
+		t.Errorf("generated TypeScript does not contain %q:\n%s", want, out)
+	}
+	if regexp.MustCompile(`else \{\s*\}`).MatchString(out) {
+		t.Errorf("unexpected empty else branch in:\n%s", out)
+	}
+}
+
+func TestToTypeScriptRoots(t *testing.T) {
+	list := &yema.Type{Kind: yema.Array, Array: &yema.Type{
+		Kind:   yema.Struct,
+		Struct: &map[string]yema.Type{"name": {Kind: yema.String}},
+	}}
+
+	ts, err := ToTypeScript(list, Options{RootType: "User", Validators: true})
+	if err != nil {
+		t.Fatalf("Failed to generate TypeScript: %v", err)
+	}
+
+	out := string(ts)
+	for _, want := range []string{
+		"export type UserList = User[];",
+		"export interface User {",
+		"export function validateUserList(v: unknown): string[] {",
+		"errors.push(`document must be an array`);",
+		"errors.push(`required field '[${i1}].name' is missing`);",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestToTypeScriptAccess(t *testing.T) {
+	schema := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"id":       {Kind: yema.String, ReadOnly: true},
+			"password": {Kind: yema.String, WriteOnly: true},
+		},
+	}
+
+	ts, err := ToTypeScript(schema, Options{})
+	if err != nil {
+		t.Fatalf("Failed to generate TypeScript: %v", err)
+	}
+
+	out := string(ts)
+	for _, want := range []string{
+		"  readonly id: string;\n",
+		"  /** Write-only, never sent in responses */\n  password: string;\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestToTypeScriptProtoJSON(t *testing.T) {
+	schema := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"id":    {Kind: yema.Int64},
+			"data":  {Kind: yema.Bytes},
+			"owner": {Kind: yema.Struct, Struct: &map[string]yema.Type{"name": {Kind: yema.String}}},
+		},
+		Order: []string{"id", "data", "owner"},
+	}
+
+	ts, err := ToTypeScript(schema, Options{ProtoJSON: true, Validators: true})
+	if err != nil {
+		t.Fatalf("Failed to generate TypeScript: %v", err)
+	}
+
+	out := string(ts)
+	for _, want := range []string{
+		"  id?: string;\n",
+		"  data?: string;\n",
+		"  owner: RootOwner;\n",
+		"if (typeof v1 !== \"string\") {",
+		"errors.push(`required field 'owner' is missing`);",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "required field 'id'") {
+		t.Errorf("id must not be required under protojson:\n%s", out)
+	}
+}
+
+func TestToTypeScriptZod(t *testing.T) {
+	userType := &yema.Type{
+		Kind:  yema.Struct,
+		Order: []string{"id", "age", "env", "site", "x-tags", "address"},
+		Struct: &map[string]yema.Type{
+			"id":     {Kind: yema.String, Format: validator.UUIDFormat},
+			"age":    {Kind: yema.Uint8, Optional: true},
+			"env":    {Kind: yema.String, Enum: []interface{}{"dev", "prod"}},
+			"site":   {Kind: yema.String, Format: validator.URIFormat, Optional: true},
+			"x-tags": {Kind: yema.Array, Array: &yema.Type{Kind: yema.Int, Enum: []interface{}{1, 2}}},
+			"address": {Kind: yema.Struct, Struct: &map[string]yema.Type{
+				"street": {Kind: yema.String},
+			}},
+		},
+	}
+
+	ts, err := ToTypeScript(&yema.Type{Kind: yema.Array, Array: userType}, Options{RootType: "User", Zod: true})
+	if err != nil {
+		t.Fatalf("Failed to generate TypeScript: %v", err)
+	}
+	out := string(ts)
+	for _, want := range []string{
+		"import { z } from \"zod\";\n\n",
+		"export const UserSchema = z.object({\n" +
+			"  id: z.string().uuid(),\n" +
+			"  age: z.number().int().min(0).max(255).nullish(),\n" +
+			"  env: z.enum([\"dev\", \"prod\"]),\n" +
+			"  site: z.string().url().nullish(),\n" +
+			"  \"x-tags\": z.array(z.union([z.literal(1), z.literal(2)])),\n" +
+			"  address: z.object({\n" +
+			"    street: z.string(),\n" +
+			"  }),\n" +
+			"});\n",
+		"export const UserListSchema = z.array(UserSchema);",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+
+	// Bytes and 64-bit integers are strings in protojson
+	protoType := &yema.Type{Kind: yema.Struct, Struct: &map[string]yema.Type{
+		"count": {Kind: yema.Int64},
+		"data":  {Kind: yema.Bytes},
+	}}
+	ts, err = ToTypeScript(protoType, Options{Zod: true, ProtoJSON: true})
+	if err != nil {
+		t.Fatalf("Failed to generate TypeScript: %v", err)
+	}
+	if out := string(ts); !strings.Contains(out, "  count: z.string().nullish(),\n  data: z.string().nullish(),\n") {
+		t.Errorf("expected protojson strings in:\n%s", out)
+	}
+
+	if _, err := ToTypeScript(protoType, Options{Zod: true, Namespace: "API"}); err == nil {
+		t.Error("expected an error for zod schemas in a namespace")
+	}
+}
+
+func TestToTypeScriptGuards(t *testing.T) {
+	list := &yema.Type{Kind: yema.Array, Array: &yema.Type{
+		Kind:  yema.Struct,
+		Order: []string{"name", "age", "tags", "address"},
+		Struct: &map[string]yema.Type{
+			"name": {Kind: yema.String},
+			"age":  {Kind: yema.Int8, Optional: true},
+			"tags": {Kind: yema.Array, Array: &yema.Type{Kind: yema.String}},
+			"address": {Kind: yema.Struct, Struct: &map[string]yema.Type{
+				"street": {Kind: yema.String},
+			}},
+		},
+	}}
+
+	ts, err := ToTypeScript(list, Options{RootType: "User", ExportAll: true, Guards: true})
+	if err != nil {
+		t.Fatalf("Failed to generate TypeScript: %v", err)
+	}
+	out := string(ts)
+	for _, want := range []string{
+		"export function isUser(v: unknown): v is User {\n  if (typeof v !== \"object\" || v === null || Array.isArray(v)) {\n    return false;\n  }",
+		"  if (!(typeof o[\"name\"] === \"string\")) {\n    return false;\n  }",
+		"  if (!(o[\"age\"] === undefined || typeof o[\"age\"] === \"number\" && Number.isInteger(o[\"age\"]))) {",
+		"  if (!(Array.isArray(o[\"tags\"]) && o[\"tags\"].every((e1) => typeof e1 === \"string\"))) {",
+		"  if (!(isUserAddress(o[\"address\"]))) {",
+		"export function isUserAddress(v: unknown): v is UserAddress {",
+		"export function isUserList(v: unknown): v is UserList {\n  return Array.isArray(v) && v.every((e1) => isUser(e1));\n}",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+
+	ts, err = ToTypeScript(&yema.Type{Kind: yema.Bytes}, Options{Guards: true})
+	if err != nil {
+		t.Fatalf("Failed to generate TypeScript: %v", err)
+	}
+	if out := string(ts); !strings.Contains(out, "export function isRoot(v: unknown): v is Root {\n  return v instanceof Uint8Array;\n}") {
+		t.Errorf("missing scalar guard in:\n%s", out)
+	}
+}
+
+func TestToTypeScriptEnums(t *testing.T) {
+	root := &yema.Type{
+		Kind:  yema.Struct,
+		Order: []string{"status", "levels"},
+		Struct: &map[string]yema.Type{
+			"status": {Kind: yema.String, Enum: []interface{}{"in-progress", "done"}},
+			"levels": {Kind: yema.Array, Array: &yema.Type{Kind: yema.Int32, Enum: []interface{}{1, 2}}},
+		},
+	}
+
+	for style, wants := range map[EnumStyle][]string{
+		LiteralUnions: {
+			"  status: RootStatus;",
+			"  levels: RootLevels[];",
+			"export type RootStatus = \"in-progress\" | \"done\";",
+			"export type RootLevels = 1 | 2;",
+		},
+		ConstObjects: {
+			"export const RootStatus = {\n  InProgress: \"in-progress\",\n  Done: \"done\",\n} as const;\n" +
+				"export type RootStatus = (typeof RootStatus)[keyof typeof RootStatus];",
+		},
+		TSEnums: {
+			"export enum RootStatus {\n  InProgress = \"in-progress\",\n  Done = \"done\",\n}",
+			"  if (!(o[\"status\"] === \"in-progress\" || o[\"status\"] === \"done\")) {",
+		},
+	} {
+		ts, err := ToTypeScript(root, Options{RootType: "Root", ExportAll: true, Guards: true, Enums: style})
+		if err != nil {
+			t.Fatalf("Failed to generate TypeScript with %s: %v", style, err)
+		}
+		out := string(ts)
+		for _, want := range wants {
+			if !strings.Contains(out, want) {
+				t.Errorf("missing %q with %s in:\n%s", want, style, out)
+			}
+		}
+	}
+
+	if _, err := ToTypeScript(root, Options{Enums: "flags"}); err == nil {
+		t.Errorf("expected an error for an unsupported enum style")
+	}
+}
+
+func TestToTypeScriptMaps(t *testing.T) {
+	root := &yema.Type{
+		Kind:  yema.Struct,
+		Order: []string{"labels", "servers"},
+		Struct: &map[string]yema.Type{
+			"labels": {Kind: yema.Map, Map: &yema.Type{Kind: yema.String}},
+			"servers": {Kind: yema.Map, Map: &yema.Type{Kind: yema.Struct, Struct: &map[string]yema.Type{
+				"host": {Kind: yema.String},
+			}}},
+		},
+	}
+
+	ts, err := ToTypeScript(root, Options{RootType: "Root", ExportAll: true, Guards: true})
+	if err != nil {
+		t.Fatalf("Failed to generate TypeScript: %v", err)
+	}
+	out := string(ts)
+	for _, want := range []string{
+		"  labels: Record<string, string>;",
+		"  servers: Record<string, RootServers>;",
+		"export interface RootServers {\n  host: string;\n}",
+		"Object.values(o[\"servers\"]).every((e2) => isRootServers(e2))",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+
+	ts, err = ToTypeScript(root, Options{RootType: "Root", ExportAll: true, Guards: true, UseMaps: true})
+	if err != nil {
+		t.Fatalf("Failed to generate TypeScript: %v", err)
+	}
+	out = string(ts)
+	for _, want := range []string{
+		"  servers: Map<string, RootServers>;",
+		"o[\"labels\"] instanceof Map && Array.from(o[\"labels\"].values()).every((e1) => typeof e1 === \"string\")",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+
+	// The values of a root map are named like a field Value
+	ts, err = ToTypeScript(&yema.Type{Kind: yema.Map, Map: (*root.Struct)["servers"].Map}, Options{RootType: "Root", ExportAll: true})
+	if err != nil {
+		t.Fatalf("Failed to generate TypeScript: %v", err)
+	}
+	out = string(ts)
+	for _, want := range []string{
+		"export type Root = Record<string, RootValue>;",
+		"export interface RootValue {\n  host: string;\n}",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestToTypeScriptUnions(t *testing.T) {
+	root := &yema.Type{
+		Kind:  yema.Struct,
+		Order: []string{"target"},
+		Struct: &map[string]yema.Type{
+			"target": {Kind: yema.Union, Union: []yema.Type{
+				{Kind: yema.Struct, Struct: &map[string]yema.Type{"host": {Kind: yema.String}}},
+				{Kind: yema.String},
+				{Kind: yema.Struct, Struct: &map[string]yema.Type{"path": {Kind: yema.String}}},
+			}},
+		},
+	}
+
+	ts, err := ToTypeScript(root, Options{RootType: "Root", ExportAll: true, Guards: true, Zod: true, Validators: true})
+	if err != nil {
+		t.Fatalf("Failed to generate TypeScript: %v", err)
+	}
+	out := string(ts)
+	for _, want := range []string{
+		"  target: RootTarget;",
+		"export type RootTarget = RootTargetStruct1 | string | RootTargetStruct3;",
+		"export interface RootTargetStruct1 {\n  host: string;\n}",
+		"export interface RootTargetStruct3 {\n  path: string;\n}",
+		"isRootTarget(o[\"target\"])",
+		"return (isRootTargetStruct1(v)) || (typeof v === \"string\") || (isRootTargetStruct3(v));",
+		"target: z.union([z.object({",
+		"errors.push(`field 'target' matches no variant of the union`);",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+
+	// A root union is the root type
+	ts, err = ToTypeScript(&yema.Type{Kind: yema.Union, Union: []yema.Type{{Kind: yema.String}, {Kind: yema.Int32}}}, Options{RootType: "Root", Guards: true})
+	if err != nil {
+		t.Fatalf("Failed to generate TypeScript: %v", err)
+	}
+	out = string(ts)
+	for _, want := range []string{
+		"export type Root = string | number;",
+		"export function isRoot(v: unknown): v is Root {",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+
+	// The decode functions cannot tell which variant holds a Date
+	dated := &yema.Type{Kind: yema.Union, Union: []yema.Type{{Kind: yema.String, Format: validator.DateTimeFormat}, {Kind: yema.Int}}}
+	_, err = ToTypeScript(dated, Options{RootType: "Root", Dates: true})
+	if err == nil || !strings.Contains(err.Error(), "variant 1 of Root holds values converted") {
+		t.Errorf("expected the Date variant to be reported, got %v", err)
+	}
+}
+
+func TestToTypeScriptDocs(t *testing.T) {
+	root := &yema.Type{
+		Kind:  yema.Struct,
+		Order: []string{"name", "nick", "address"},
+		Struct: &map[string]yema.Type{
+			"name": {Kind: yema.String, Description: "name of the user", Example: "Ada"},
+			"nick": {Kind: yema.String, Optional: true, Description: "an old nickname\n\nDeprecated: use name instead"},
+			"address": {Kind: yema.Struct, Description: "where the user lives", Struct: &map[string]yema.Type{
+				"street": {Kind: yema.String},
+			}},
+		},
+	}
+
+	ts, err := ToTypeScript(root, Options{RootType: "User", ExportAll: true})
+	if err != nil {
+		t.Fatalf("Failed to generate TypeScript: %v", err)
+	}
+	out := string(ts)
+	for _, want := range []string{
+		"/**\n * User represents a generated type\n */\nexport interface User {",
+		"  /**\n   * name of the user\n   *\n   * @example\n   * ```json\n   * \"Ada\"\n   * ```\n   */\n  name: string;",
+		"  /**\n   * an old nickname\n   *\n   * @deprecated use name instead\n   */\n  nick?: string;",
+		"  /** where the user lives */\n  address: UserAddress;",
+		"/**\n * where the user lives\n */\nexport interface UserAddress {",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestToTypeScriptFileStyles(t *testing.T) {
+	root := &yema.Type{Kind: yema.Struct, Struct: &map[string]yema.Type{
+		"address": {Kind: yema.Struct, Struct: &map[string]yema.Type{
+			"street": {Kind: yema.String},
+		}},
+	}}
+
+	ts, err := ToTypeScript(root, Options{RootType: "User", ExportAll: true, Style: Declarations})
+	if err != nil {
+		t.Fatalf("Failed to generate TypeScript: %v", err)
+	}
+	if out := string(ts); strings.Contains(out, "export ") || !strings.Contains(out, "\ninterface UserAddress {") {
+		t.Errorf("expected global declarations in:\n%s", out)
+	}
+
+	ts, err = ToTypeScript(root, Options{RootType: "User", ExportAll: true, Style: Declarations, Namespace: "Api"})
+	if err != nil {
+		t.Fatalf("Failed to generate TypeScript: %v", err)
+	}
+	if out := string(ts); !strings.HasPrefix(out, "declare namespace Api {\n") || !strings.Contains(out, "export interface UserAddress {") {
+		t.Errorf("expected an ambient namespace in:\n%s", out)
+	}
+
+	for _, opts := range []Options{
+		{Style: ESModules, Namespace: "Api"},
+		{Style: Namespaces},
+		{Style: Declarations, Guards: true},
+		{Style: Declarations, Enums: TSEnums},
+		{Namespace: "Api", Zod: true},
+		{Style: "commonjs"},
+	} {
+		if _, err := ToTypeScript(root, opts); err == nil {
+			t.Errorf("expected an error for %+v", opts)
+		}
+	}
+}
+
+func TestToTypeScriptBrands(t *testing.T) {
+	uuid := yema.Type{Kind: yema.String, Format: validator.UUIDFormat}
+	root := &yema.Type{
+		Kind:  yema.Struct,
+		Order: []string{"id", "owner", "members"},
+		Struct: &map[string]yema.Type{
+			"id":      uuid,
+			"owner":   uuid,
+			"members": {Kind: yema.Array, Array: &uuid},
+		},
+	}
+
+	ts, err := ToTypeScript(root, Options{RootType: "Team", ExportAll: true, Brands: true, BrandNames: map[string]string{
+		"TeamOwner":   "UserId",
+		"TeamMembers": "UserId",
+	}})
+	if err != nil {
+		t.Fatalf("Failed to generate TypeScript: %v", err)
+	}
+	out := string(ts)
+	for _, want := range []string{
+		"  id: TeamId;\n  owner: UserId;\n  members: UserId[];",
+		"export type TeamId = string & { readonly __brand: \"TeamId\" };",
+		"export type UserId = string & { readonly __brand: \"UserId\" };",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	if strings.Count(out, "type UserId =") != 1 {
+		t.Errorf("expected a single shared brand in:\n%s", out)
+	}
+
+	if _, err := ToTypeScript(root, Options{Brands: true, BrandNames: map[string]string{"RootOwnerId": "UserId"}}); err == nil {
+		t.Errorf("expected an error for a brand name matching no field")
+	}
+}
+
+func TestToTypeScriptDates(t *testing.T) {
+	root := &yema.Type{
+		Kind:  yema.Struct,
+		Order: []string{"created", "birthday", "layout", "events"},
+		Struct: &map[string]yema.Type{
+			"created":  {Kind: yema.String, Format: validator.DateTimeFormat},
+			"birthday": {Kind: yema.String, Format: validator.DateTimeFormat, FormatArg: "2006-01-02", Optional: true},
+			"layout":   {Kind: yema.String, Format: validator.DateTimeFormat, FormatArg: "02.01.2006"},
+			"events": {Kind: yema.Array, Array: &yema.Type{Kind: yema.Struct, Struct: &map[string]yema.Type{
+				"at": {Kind: yema.String, Format: validator.DateTimeFormat},
+			}}},
+		},
+	}
+
+	ts, err := ToTypeScript(root, Options{RootType: "User", ExportAll: true, Dates: true})
+	if err != nil {
+		t.Fatalf("Failed to generate TypeScript: %v", err)
+	}
+	out := string(ts)
+	for _, want := range []string{
+		"  created: Date;\n  birthday?: Date;\n  layout: string;\n  events: UserEvents[];",
+		"export function decodeUser(v: any): User {\n  return {\n    ...v,\n    created: new Date(v[\"created\"]),\n" +
+			"    birthday: v[\"birthday\"] == null ? undefined : new Date(v[\"birthday\"]),\n" +
+			"    events: v[\"events\"].map((e1: any) => decodeUserEvents(e1)),\n  };\n}",
+		"    birthday: v[\"birthday\"] == null ? undefined : v[\"birthday\"].toISOString().slice(0, 10),",
+		"export function encodeUserEvents(v: UserEvents): any {",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+
+	ts, err = ToTypeScript(&yema.Type{Kind: yema.Array, Array: &yema.Type{Kind: yema.String, Format: validator.DateTimeFormat}}, Options{Dates: true})
+	if err != nil {
+		t.Fatalf("Failed to generate TypeScript: %v", err)
+	}
+	if out := string(ts); !strings.Contains(out, "export function decodeRootList(v: any): RootList {\n  return v.map((e1: any) => new Date(e1));\n}") {
+		t.Errorf("missing list decoder in:\n%s", out)
+	}
+}
+
+func TestToTypeScriptOptionals(t *testing.T) {
+	root := &yema.Type{
+		Kind:  yema.Struct,
+		Order: []string{"name", "nick"},
+		Struct: &map[string]yema.Type{
+			"name": {Kind: yema.String},
+			"nick": {Kind: yema.String, Optional: true},
+		},
+	}
+
+	ts, err := ToTypeScript(root, Options{RootType: "User", Guards: true, NullableOptionals: true, ExactOptionals: true})
+	if err != nil {
+		t.Fatalf("Failed to generate TypeScript: %v", err)
+	}
+	out := string(ts)
+	for _, want := range []string{
+		"  name: string;\n  nick?: string | null | undefined;",
+		"  if (!(o[\"nick\"] === null || o[\"nick\"] === undefined || typeof o[\"nick\"] === \"string\")) {",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestToTypeScriptStructStyles(t *testing.T) {
+	root := &yema.Type{Kind: yema.Struct, Struct: &map[string]yema.Type{
+		"address": {Kind: yema.Struct, Struct: &map[string]yema.Type{
+			"street": {Kind: yema.String},
+		}},
+	}}
+
+	ts, err := ToTypeScript(root, Options{RootType: "User", ExportAll: true, Structs: TypeAliases})
+	if err != nil {
+		t.Fatalf("Failed to generate TypeScript: %v", err)
+	}
+	if out := string(ts); !strings.Contains(out, "export type User = {\n") || !strings.Contains(out, "export type UserAddress = {\n  street: string;\n};") {
+		t.Errorf("expected type aliases in:\n%s", out)
+	}
+
+	ts, err = ToTypeScript(root, Options{RootType: "User", ExportAll: true, StructStyles: map[string]StructStyle{"UserAddress": TypeAliases}})
+	if err != nil {
+		t.Fatalf("Failed to generate TypeScript: %v", err)
+	}
+	if out := string(ts); !strings.Contains(out, "export interface User {\n") || !strings.Contains(out, "export type UserAddress = {\n") {
+		t.Errorf("expected an alias of UserAddress only in:\n%s", out)
+	}
+
+	for _, opts := range []Options{
+		{Structs: "class"},
+		{StructStyles: map[string]StructStyle{"RootStreet": TypeAliases}},
+	} {
+		if _, err := ToTypeScript(root, opts); err == nil {
+			t.Errorf("expected an error for %+v", opts)
+		}
+	}
+}
+
+func TestToTypeScriptCamelCase(t *testing.T) {
+	root := &yema.Type{
+		Kind:  yema.Struct,
+		Order: []string{"user_id", "home_address"},
+		Struct: &map[string]yema.Type{
+			"user_id": {Kind: yema.String},
+			"home_address": {Kind: yema.Struct, Optional: true, Struct: &map[string]yema.Type{
+				"street_name": {Kind: yema.String},
+			}},
+		},
+	}
+
+	ts, err := ToTypeScript(root, Options{RootType: "User", ExportAll: true, CamelCase: true, Guards: true})
+	if err != nil {
+		t.Fatalf("Failed to generate TypeScript: %v", err)
+	}
+	out := string(ts)
+	for _, want := range []string{
+		"  userId: string;\n  homeAddress?: UserHomeAddress;",
+		"  if (!(typeof o[\"userId\"] === \"string\")) {",
+		"export function decodeUser(v: any): User {\n  return {\n    userId: v[\"user_id\"],\n" +
+			"    homeAddress: v[\"home_address\"] == null ? undefined : decodeUserHomeAddress(v[\"home_address\"]),\n  };\n}",
+		"export function encodeUser(v: User): any {\n  return {\n    user_id: v[\"userId\"],\n",
+		"export function decodeUserHomeAddress(v: any): UserHomeAddress {\n  return {\n    streetName: v[\"street_name\"],\n  };\n}",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+
+	clash := &yema.Type{Kind: yema.Struct, Struct: &map[string]yema.Type{
+		"user_id": {Kind: yema.String},
+		"userId":  {Kind: yema.String},
+	}}
+	if _, err := ToTypeScript(clash, Options{CamelCase: true}); err == nil {
+		t.Errorf("expected an error for fields with the same property name")
+	}
+}
+
+func TestToTypeScriptDeterministic(t *testing.T) {
+	// Schemas built in code have no declaration order, their fields are sorted by name
+	schema := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"zeta": {Kind: yema.String},
+			"beta": {Kind: yema.Struct, Struct: &map[string]yema.Type{
+				"y": {Kind: yema.Int},
+				"x": {Kind: yema.Struct, Struct: &map[string]yema.Type{"deep": {Kind: yema.Bool}}},
+			}},
+			"alpha": {Kind: yema.Array, Array: &yema.Type{Kind: yema.Array, Array: &yema.Type{
+				Kind: yema.Struct, Struct: &map[string]yema.Type{"cell": {Kind: yema.Int}},
+			}}},
+		},
+	}
+	opts := Options{ExportAll: true, Guards: true, Zod: true, Validators: true}
+
+	first, err := ToTypeScript(schema, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(first)
+	last := -1
+	for _, want := range []string{"  alpha: RootAlpha[][];", "  beta: RootBeta;", "  zeta: string;",
+		"interface RootAlpha {", "interface RootBeta {", "  x: RootBetaX;", "  y: number;", "interface RootBetaX {"} {
+		i := strings.Index(out, want)
+		if i <= last {
+			t.Fatalf("expected %q after the previous declarations in:\n%s", want, out)
+		}
+		last = i
+	}
+
+	for i := 0; i < 20; i++ {
+		result, err := ToTypeScript(schema, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(result) != string(first) {
+			t.Fatalf("output differs between runs:\n%s\n---\n%s", first, result)
+		}
+	}
+
+	// The fields fooBar and foo.bar both name a type RootFooBar
+	clash := &yema.Type{Kind: yema.Struct, Order: []string{"fooBar", "foo"}, Struct: &map[string]yema.Type{
+		"fooBar": {Kind: yema.Struct, Struct: &map[string]yema.Type{"a": {Kind: yema.Int}}},
+		"foo": {Kind: yema.Struct, Struct: &map[string]yema.Type{
+			"bar": {Kind: yema.Struct, Struct: &map[string]yema.Type{"b": {Kind: yema.Int}}},
+		}},
+	}}
+	_, err = ToTypeScript(clash, Options{})
+	if err == nil || !strings.Contains(err.Error(), "field \"fooBar\" of Root and field \"bar\" of RootFoo both map to the type RootFooBar") {
+		t.Errorf("expected a collision of RootFooBar, got %v", err)
+	}
+}
+
+func TestToTypeScriptFiles(t *testing.T) {
+	schema := &yema.Type{Kind: yema.Struct, Order: []string{"status", "address"}, Struct: &map[string]yema.Type{
+		"status": {Kind: yema.String, Enum: []any{"active", "inactive"}},
+		"address": {Kind: yema.Struct, Order: []string{"street", "geo"}, Struct: &map[string]yema.Type{
+			"street": {Kind: yema.String},
+			"geo":    {Kind: yema.Struct, Optional: true, Struct: &map[string]yema.Type{"lat": {Kind: yema.Float64}}},
+		}},
+	}}
+
+	files, err := ToTypeScriptFiles(schema, Options{Guards: true, Enums: ConstObjects, Zod: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 5 {
+		t.Errorf("expected root, rootstatus, rootaddress, rootaddressgeo and index, got %d files", len(files))
+	}
+
+	// Modules import what they use from others, values and types alike
+	wants := map[string][]string{
+		"root.ts": {
+			"import { RootStatus } from \"./rootstatus\";\n",
+			"import { type RootAddress, isRootAddress } from \"./rootaddress\";\n",
+			"import { z } from \"zod\";",
+			"export interface Root {",
+			"export const RootSchema = z.object({",
+		},
+		"rootaddress.ts": {
+			"import { type RootAddressGeo, isRootAddressGeo } from \"./rootaddressgeo\";\n",
+			"export interface RootAddress {",
+			"export function isRootAddress(v: unknown): v is RootAddress {",
+		},
+		"rootaddressgeo.ts": {"export interface RootAddressGeo {"},
+		"index.ts":          {"export * from \"./root\";\n", "export * from \"./rootaddressgeo\";\n"},
+	}
+	for name, want := range wants {
+		out := string(files[name])
+		for _, w := range want {
+			if !strings.Contains(out, w) {
+				t.Errorf("missing %q in %s:\n%s", w, name, out)
+			}
+		}
+	}
+	if out := string(files["rootaddressgeo.ts"]); strings.Contains(out, "import") {
+		t.Errorf("unexpected import in rootaddressgeo.ts:\n%s", out)
+	}
+
+	if _, err := ToTypeScriptFiles(schema, Options{Namespace: "API"}); err == nil {
+		t.Error("expected an error for files in a namespace")
+	}
+	index := &yema.Type{Kind: yema.Struct, Struct: &map[string]yema.Type{"x": {Kind: yema.Int}}}
+	if _, err := ToTypeScriptFiles(index, Options{RootType: "Index"}); err == nil || !strings.Contains(err.Error(), "both map to the file index.ts") {
+		t.Errorf("expected a collision with the index, got %v", err)
+	}
+}
+
+func TestEncodedBytes(t *testing.T) {
+	schema := &yema.Type{Kind: yema.Struct, Order: []string{"data", "hex", "list"}, Struct: &map[string]yema.Type{
+		"data": {Kind: yema.Bytes, Optional: true},
+		"hex":  {Kind: yema.Bytes, Format: validator.HexEncoding},
+		"list": {Kind: yema.Array, Array: &yema.Type{Kind: yema.Bytes}},
+	}}
+
+	ts, err := ToTypeScript(schema, Options{EncodedBytes: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(ts)
+	for _, want := range []string{
+		"  data?: Uint8Array;",
+		"    data: v[\"data\"] == null ? undefined : decodeBase64(v[\"data\"]),\n",
+		"    hex: decodeHex(v[\"hex\"]),\n",
+		"    list: v[\"list\"].map((e1: any) => decodeBase64(e1)),\n",
+		"    hex: encodeHex(v[\"hex\"]),\n",
+		"function decodeBase64(s: string): Uint8Array {",
+		"function encodeBase64(b: Uint8Array): string {",
+		"function decodeHex(s: string): Uint8Array {",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Base64URL") {
+		t.Errorf("unexpected functions of base64url in:\n%s", out)
+	}
+
+	// protojson bytes are strings, which need no conversion
+	ts, err = ToTypeScript(schema, Options{EncodedBytes: true, ProtoJSON: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out := string(ts); strings.Contains(out, "decodeRoot") || strings.Contains(out, "Base64") {
+		t.Errorf("unexpected conversion of protojson bytes in:\n%s", out)
+	}
+
+	// Modules of other types import the functions of encodings
+	files, err := ToTypeScriptFiles(&yema.Type{Kind: yema.Array, Array: schema}, Options{EncodedBytes: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "import { decodeBase64, decodeHex, encodeBase64, encodeHex } from \"./encodings\";\n"
+	if out := string(files["root.ts"]); !strings.Contains(out, want) {
+		t.Errorf("missing %q in:\n%s", want, out)
+	}
+}
+
+func TestToTypeScriptBytesLength(t *testing.T) {
+	schema := &yema.Type{Kind: yema.Struct, Order: []string{"sum", "nonce", "plain"}, Struct: &map[string]yema.Type{
+		"sum":   {Kind: yema.Bytes, Format: validator.HexEncoding, Length: &yema.Length{Min: 4, Max: 4}},
+		"nonce": {Kind: yema.Bytes, Format: validator.Base64Encoding, Length: &yema.Length{Min: 2, Max: -1}},
+		"plain": {Kind: yema.Bytes, Length: &yema.Length{Min: 0, Max: 3}},
+	}}
+
+	ts, err := ToTypeScript(schema, Options{Validators: true, Zod: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(ts)
+	for _, want := range []string{
+		"sum: z.string().refine((s) => s.length / 2 >= 4, \"must hold at least 4 bytes\").refine((s) => s.length / 2 <= 4, \"must hold at most 4 bytes\"),",
+		"if (v1.length / 2 < 4) {",
+		"errors.push(`field 'sum' must hold at least 4 bytes`);",
+		"if (Math.floor(v2.replace(/=+$/, \"\").length * 3 / 4) < 2) {",
+		"if (new TextEncoder().encode(v3).length > 3) {",
+		"errors.push(`field 'plain' must hold at most 3 bytes`);",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+
+	// protojson bytes are base64 whatever their format
+	ts, err = ToTypeScript(schema, Options{Validators: true, ProtoJSON: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "if (Math.floor(v1.replace(/=+$/, \"\").length * 3 / 4) < 4) {"; !strings.Contains(string(ts), want) {
+		t.Errorf("missing %q in:\n%s", want, ts)
+	}
+}
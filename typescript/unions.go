@@ -0,0 +1,91 @@
+package typescript
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/aep/yema"
+	"github.com/aep/yema/internal/ident"
+)
+
+// unionIdents returns the identifiers the variants of the union t are named after, their kind numbered by
+// position if several have the same kind, as the variants of Go unions are
+func unionIdents(t *yema.Type) []string {
+	kinds := make(map[yema.Kind]int, len(t.Union))
+	for _, variant := range t.Union {
+		kinds[variant.Kind]++
+	}
+	idents := make([]string, len(t.Union))
+	for i, variant := range t.Union {
+		idents[i] = ident.Camel(variant.Kind.String())
+		if kinds[variant.Kind] > 1 {
+			idents[i] += fmt.Sprint(i + 1)
+		}
+	}
+	return idents
+}
+
+// generateUnion writes the union t as a type alias named name of the types of its variants, whatever the struct
+// style, as interfaces cannot declare one. Nested types of the variants are named after the union and the
+// variant, such as RootTargetStruct. The decode and encode functions cannot tell the variants apart, so
+// variants holding values they convert are reported.
+func generateUnion(t *yema.Type, name string, buf *bytes.Buffer, generatedTypes map[string]string, opts Options) error {
+	if len(t.Union) == 0 {
+		return fmt.Errorf("union type %s without variants", name)
+	}
+
+	idents := unionIdents(t)
+	types := make([]string, len(t.Union))
+	var nested []nestedType
+	for i := range t.Union {
+		variant := t.Union[i]
+		variant.Optional = false
+		if needsMapping(&variant, opts) {
+			return fmt.Errorf("variant %d of %s holds values converted by the decode and encode functions, which cannot tell the variants of a union apart", i+1, name)
+		}
+		tsType, nestedName, err := typeToTypeScriptType(&variant, name, idents[i], opts)
+		if err != nil {
+			return err
+		}
+		types[i] = tsType
+		if nestedName != "" {
+			nested = append(nested, nestedType{nestedName, nestedElem(&variant), fmt.Sprintf("variant %d of %s", i+1, name)})
+		}
+	}
+
+	exportKeyword := exportIf(opts.ExportAll || name == opts.RootType, opts)
+	writeDoc(buf, t, fmt.Sprintf("%s holds a value of one of the variants of a union", name))
+	fmt.Fprintf(buf, "%stype %s = %s;\n\n", exportKeyword, name, strings.Join(types, " | "))
+
+	if opts.Guards {
+		if err := writeUnionGuard(buf, t, name, exportKeyword, opts); err != nil {
+			return err
+		}
+	}
+
+	for _, n := range nested {
+		if err := generateNested(n, buf, generatedTypes, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeUnionGuard writes the type guard of the union typeName, which holds a value matching any of its variants
+func writeUnionGuard(buf *bytes.Buffer, t *yema.Type, typeName, exportKeyword string, opts Options) error {
+	idents := unionIdents(t)
+	conds := make([]string, len(t.Union))
+	vars := 0
+	for i := range t.Union {
+		cond, err := guardCondition(&t.Union[i], "v", typeName, idents[i], opts, &vars)
+		if err != nil {
+			return err
+		}
+		conds[i] = "(" + cond + ")"
+	}
+	fmt.Fprintf(buf, "/**\n * is%s reports whether v is a %s\n */\n", typeName, typeName)
+	fmt.Fprintf(buf, "%sfunction is%s(v: unknown): v is %s {\n", exportKeyword, typeName, typeName)
+	fmt.Fprintf(buf, "  return %s;\n}\n\n", strings.Join(conds, " || "))
+	return nil
+}
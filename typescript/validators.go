@@ -0,0 +1,195 @@
+package typescript
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aep/yema"
+	"github.com/aep/yema/internal/checks"
+	"github.com/aep/yema/validator"
+)
+
+// generateValidator generates a runtime validation function for the root type from the shared validation IR
+func generateValidator(t *yema.Type, typeName string, buf *bytes.Buffer, protoJSON bool) error {
+	root, err := checks.BuildWithOptions(t, checks.Options{ProtoJSON: protoJSON})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(buf, "/**\n * validate%s checks that v is a valid %s and returns all violations found\n */\n", typeName, typeName)
+	fmt.Fprintf(buf, "export function validate%s(v: unknown): string[] {\n", typeName)
+	buf.WriteString("  const errors: string[] = [];\n")
+
+	e := &validatorEmitter{buf: buf}
+	e.node(root, "v", "", 1)
+
+	buf.WriteString("  return errors;\n}\n\n")
+	return nil
+}
+
+// validatorEmitter writes the TypeScript statements checking a node of the validation IR
+type validatorEmitter struct {
+	buf  *bytes.Buffer
+	vars int
+}
+
+func (e *validatorEmitter) newVar(prefix string) string {
+	e.vars++
+	return prefix + strconv.Itoa(e.vars)
+}
+
+func (e *validatorEmitter) line(depth int, format string, args ...interface{}) {
+	e.buf.WriteString(strings.Repeat("  ", depth))
+	fmt.Fprintf(e.buf, format, args...)
+	e.buf.WriteString("\n")
+}
+
+// node emits the checks for value expression expr. path is the content of a template literal evaluating to the path of the value.
+func (e *validatorEmitter) node(n *checks.Node, expr, path string, depth int) {
+	if len(n.Variants) != 0 {
+		e.union(n, expr, path, depth)
+		return
+	}
+
+	typeCheck := n.Checks[0]
+	e.line(depth, "if (%s) {", typeCondition(typeCheck.Type, expr))
+	e.line(depth+1, "errors.push(`%s must be %s`);", subject(path), article(typeCheck.Type))
+	// The other checks apply to a value of the right type, the else branch is left out if there are none
+	if body := e.capture(func() { e.contents(n, expr, path, depth) }); len(body) != 0 {
+		e.line(depth, "} else {")
+		e.buf.Write(body)
+	}
+	e.line(depth, "}")
+}
+
+// contents emits the checks of a value of the type of n, its fields and its items, indented below depth
+func (e *validatorEmitter) contents(n *checks.Node, expr, path string, depth int) {
+	for _, c := range n.Checks[1:] {
+		switch c.Op {
+		case checks.OpMin:
+			e.line(depth+1, "if (%s < %d) {", expr, c.Bound)
+			e.line(depth+2, "errors.push(`%s must be at least %d`);", subject(path), c.Bound)
+			e.line(depth+1, "}")
+		case checks.OpMax:
+			e.line(depth+1, "if (%s > %d) {", expr, c.Bound)
+			e.line(depth+2, "errors.push(`%s must be at most %d`);", subject(path), c.Bound)
+			e.line(depth+1, "}")
+		case checks.OpMinLength:
+			e.line(depth+1, "if (%s < %d) {", byteLength(n, expr), c.Bound)
+			e.line(depth+2, "errors.push(`%s must hold at least %d bytes`);", subject(path), c.Bound)
+			e.line(depth+1, "}")
+		case checks.OpMaxLength:
+			e.line(depth+1, "if (%s > %d) {", byteLength(n, expr), c.Bound)
+			e.line(depth+2, "errors.push(`%s must hold at most %d bytes`);", subject(path), c.Bound)
+			e.line(depth+1, "}")
+		}
+	}
+
+	for _, f := range n.Fields {
+		v := e.newVar("v")
+		fieldPath := escapeTemplate(f.Name)
+		if path != "" {
+			fieldPath = path + "." + fieldPath
+		}
+
+		e.line(depth+1, "const %s = (%s as Record<string, unknown>)[%s];", v, expr, strconv.Quote(f.Name))
+		if f.Required {
+			e.line(depth+1, "if (%s === undefined || %s === null) {", v, v)
+			e.line(depth+2, "errors.push(`required field '%s' is missing`);", fieldPath)
+			e.line(depth+1, "} else {")
+		} else {
+			e.line(depth+1, "if (%s !== undefined && %s !== null) {", v, v)
+		}
+		e.node(f.Node, v, fieldPath, depth+2)
+		e.line(depth+1, "}")
+	}
+
+	if n.Items != nil {
+		i := e.newVar("i")
+		v := e.newVar("v")
+		e.line(depth+1, "for (let %s = 0; %s < %s.length; %s++) {", i, i, expr, i)
+		e.line(depth+2, "const %s: unknown = %s[%s];", v, expr, i)
+		e.node(n.Items, v, path+"[${"+i+"}]", depth+2)
+		e.line(depth+1, "}")
+	}
+}
+
+// capture returns the statements emit writes, so a branch holding none can be left out
+func (e *validatorEmitter) capture(emit func()) []byte {
+	outer := e.buf
+	e.buf = new(bytes.Buffer)
+	emit()
+	body := e.buf.Bytes()
+	e.buf = outer
+	return body
+}
+
+// union emits the checks of every variant of the union n into errors of their own, reporting the value if
+// none of them passes
+func (e *validatorEmitter) union(n *checks.Node, expr, path string, depth int) {
+	u := e.newVar("u")
+	e.line(depth, "const %s: string[][] = [];", u)
+	for _, variant := range n.Variants {
+		e.line(depth, "{")
+		e.line(depth+1, "const errors: string[] = [];")
+		e.node(variant, expr, path, depth+1)
+		e.line(depth+1, "%s.push(errors);", u)
+		e.line(depth, "}")
+	}
+	e.line(depth, "if (%s.every((errs) => errs.length > 0)) {", u)
+	e.line(depth+1, "errors.push(`%s matches no variant of the union`);", subject(path))
+	e.line(depth, "}")
+}
+
+// byteLength returns the number of bytes held by the string expr, decoded from the encoding of n
+// or in UTF-8 if it has none
+func byteLength(n *checks.Node, expr string) string {
+	switch n.Encoding {
+	case validator.Base64Encoding, validator.Base64URLEncoding:
+		return fmt.Sprintf("Math.floor(%s.replace(/=+$/, \"\").length * 3 / 4)", expr)
+	case validator.HexEncoding:
+		return fmt.Sprintf("%s.length / 2", expr)
+	}
+	return fmt.Sprintf("new TextEncoder().encode(%s).length", expr)
+}
+
+func typeCondition(t checks.JSONType, expr string) string {
+	switch t {
+	case checks.Boolean:
+		return fmt.Sprintf(`typeof %s !== "boolean"`, expr)
+	case checks.Integer:
+		return fmt.Sprintf(`typeof %s !== "number" || !Number.isInteger(%s)`, expr, expr)
+	case checks.Number:
+		return fmt.Sprintf(`typeof %s !== "number"`, expr)
+	case checks.String:
+		return fmt.Sprintf(`typeof %s !== "string"`, expr)
+	case checks.Array:
+		return fmt.Sprintf(`!Array.isArray(%s)`, expr)
+	default:
+		return fmt.Sprintf(`typeof %s !== "object" || %s === null || Array.isArray(%s)`, expr, expr, expr)
+	}
+}
+
+// subject describes the value at path in error messages
+func subject(path string) string {
+	if path == "" {
+		return "document"
+	}
+	return "field '" + path + "'"
+}
+
+func article(t checks.JSONType) string {
+	switch t {
+	case checks.Integer, checks.Array, checks.Object:
+		return "an " + t.String()
+	default:
+		return "a " + t.String()
+	}
+}
+
+// escapeTemplate escapes a string for use inside a template literal
+func escapeTemplate(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "`", "\\`", "${", "\\${").Replace(s)
+}
@@ -0,0 +1,154 @@
+package typescript
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aep/yema"
+	"github.com/aep/yema/internal/checks"
+	"github.com/aep/yema/internal/ident"
+	"github.com/aep/yema/validator"
+)
+
+// zodImport imports zod, which makes the generated file a module
+const zodImport = "import { z } from \"zod\";\n\n"
+
+// generateZod generates the zod schema of the element t of the root, named after typeName, and the schema of the
+// list of depth arrays of it if the root is an array. The schemas accept the JSON documents the validators accept,
+// from the shared validation IR, with the values of optional fields null or missing.
+func generateZod(t *yema.Type, typeName string, depth int, buf *bytes.Buffer, protoJSON bool) error {
+	root, err := checks.BuildWithOptions(t, checks.Options{ProtoJSON: protoJSON})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(buf, "/**\n * %sSchema parses a %s with zod\n */\n", typeName, typeName)
+	fmt.Fprintf(buf, "export const %sSchema = %s;\n\n", typeName, zodSchema(root, 0))
+	if depth > 0 {
+		fmt.Fprintf(buf, "/**\n * %sListSchema parses a %sList with zod\n */\n", typeName, typeName)
+		fmt.Fprintf(buf, "export const %sListSchema = %s%sSchema%s;\n\n", typeName, strings.Repeat("z.array(", depth), typeName, strings.Repeat(")", depth))
+	}
+	return nil
+}
+
+// zodSchema returns the zod schema of the values of n, with nested objects indented by depth
+func zodSchema(n *checks.Node, depth int) string {
+	if len(n.Variants) == 1 {
+		return zodSchema(n.Variants[0], depth)
+	}
+	if len(n.Variants) > 1 {
+		variants := make([]string, len(n.Variants))
+		for i, variant := range n.Variants {
+			variants[i] = zodSchema(variant, depth)
+		}
+		return "z.union([" + strings.Join(variants, ", ") + "])"
+	}
+	if len(n.Enum) > 0 {
+		return zodEnum(n.Enum)
+	}
+
+	var schema string
+	switch n.Checks[0].Type {
+	case checks.Boolean:
+		schema = "z.boolean()"
+	case checks.Integer:
+		schema = "z.number().int()"
+	case checks.Number:
+		schema = "z.number()"
+	case checks.String:
+		schema = "z.string()"
+		// Strings holding bytes or 64-bit integers in protojson have no format
+		if n.Kind == yema.String {
+			schema += zodFormat(n.Format)
+		}
+	case checks.Array:
+		schema = "z.array(" + zodSchema(n.Items, depth) + ")"
+	case checks.Object:
+		if n.Values != nil {
+			return "z.record(z.string(), " + zodSchema(n.Values, depth) + ")"
+		}
+		return zodObject(n.Fields, depth)
+	}
+
+	for _, c := range n.Checks[1:] {
+		switch c.Op {
+		case checks.OpMin:
+			schema += fmt.Sprintf(".min(%d)", c.Bound)
+		case checks.OpMax:
+			schema += fmt.Sprintf(".max(%d)", c.Bound)
+		case checks.OpMinLength:
+			schema += fmt.Sprintf(".refine((s) => %s >= %d, \"must hold at least %d bytes\")", byteLength(n, "s"), c.Bound, c.Bound)
+		case checks.OpMaxLength:
+			schema += fmt.Sprintf(".refine((s) => %s <= %d, \"must hold at most %d bytes\")", byteLength(n, "s"), c.Bound, c.Bound)
+		}
+	}
+	return schema
+}
+
+// zodObject returns the zod schema of an object with fields, indented by depth
+func zodObject(fields []*checks.Field, depth int) string {
+	if len(fields) == 0 {
+		return "z.object({})"
+	}
+
+	var b strings.Builder
+	indent := strings.Repeat("  ", depth)
+	b.WriteString("z.object({\n")
+	for _, f := range fields {
+		// Quote property names that are not valid identifiers
+		propName := f.Name
+		if !ident.IsIdentifier(propName) {
+			propName = strconv.Quote(propName)
+		}
+		schema := zodSchema(f.Node, depth+1)
+		// The validators accept null for optional fields as well
+		if !f.Required {
+			schema += ".nullish()"
+		}
+		fmt.Fprintf(&b, "%s  %s: %s,\n", indent, propName, schema)
+	}
+	b.WriteString(indent + "})")
+	return b.String()
+}
+
+// zodEnum returns the zod schema of the values of an enum
+func zodEnum(values []interface{}) string {
+	literals := make([]string, len(values))
+	strs := true
+	for i, value := range values {
+		literals[i] = tsLiteral(value)
+		_, ok := value.(string)
+		strs = strs && ok
+	}
+	switch {
+	case strs:
+		return "z.enum([" + strings.Join(literals, ", ") + "])"
+	case len(literals) == 1:
+		return "z.literal(" + literals[0] + ")"
+	}
+	for i := range literals {
+		literals[i] = "z.literal(" + literals[i] + ")"
+	}
+	return "z.union([" + strings.Join(literals, ", ") + "])"
+}
+
+// zodFormat returns the zod refinement of strings in format, "" for formats zod does not check
+func zodFormat(format string) string {
+	switch format {
+	case validator.UUIDFormat:
+		return ".uuid()"
+	case validator.URIFormat:
+		return ".url()"
+	}
+	return ""
+}
+
+// tsLiteral returns the TypeScript literal of an enum value
+func tsLiteral(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return strconv.Quote(s)
+	}
+	return fmt.Sprint(value)
+}
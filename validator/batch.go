@@ -0,0 +1,62 @@
+package validator
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/aep/yema"
+)
+
+// ValidateAll checks many decoded documents against a schema across parallelism goroutines,
+// 0 or less uses one goroutine per CPU. The schema is compiled once, see Compile.
+// The result holds the errors of each document at the index of the document, nil for valid documents.
+// A document whose validation panics gets an error wrapping ErrPanic instead, as SafeValidate reports it.
+func ValidateAll(docs []interface{}, schema *yema.Type, parallelism int) [][]error {
+	return ValidateAllWithOptions(docs, schema, parallelism, Options{})
+}
+
+// ValidateAllWithOptions is like ValidateAll with custom options, MaxErrors limits the errors reported per document
+func ValidateAllWithOptions(docs []interface{}, schema *yema.Type, parallelism int, opts Options) [][]error {
+	c, err := CompileWithOptions(schema, opts)
+	if err != nil {
+		results := make([][]error, len(docs))
+		for i := range results {
+			results[i] = []error{err}
+		}
+		return results
+	}
+	return c.ValidateAll(docs, parallelism)
+}
+
+// ValidateAll checks many decoded documents across parallelism goroutines, see ValidateAll
+func (c *CompiledValidator) ValidateAll(docs []interface{}, parallelism int) [][]error {
+	if parallelism <= 0 {
+		parallelism = runtime.GOMAXPROCS(0)
+	}
+	if parallelism > len(docs) {
+		parallelism = len(docs)
+	}
+
+	results := make([][]error, len(docs))
+	// Workers claim documents one at a time, so a few slow documents do not hold up a whole share
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for w := 0; w < parallelism; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1)) - 1
+				if i >= len(docs) {
+					return
+				}
+				// A panic in a worker would crash the program, as callers cannot recover it
+				results[i] = c.safeValidate(docs[i])
+			}
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
@@ -0,0 +1,83 @@
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aep/yema"
+)
+
+func TestValidateAll(t *testing.T) {
+	schema := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"id": {Kind: yema.Int64},
+		},
+	}
+
+	docs := make([]interface{}, 100)
+	for i := range docs {
+		if i%7 == 0 {
+			docs[i] = map[string]interface{}{"id": fmt.Sprint(i)}
+		} else {
+			docs[i] = map[string]interface{}{"id": i}
+		}
+	}
+
+	for _, parallelism := range []int{0, 1, 3, 1000} {
+		results := ValidateAll(docs, schema, parallelism)
+		if len(results) != len(docs) {
+			t.Fatalf("parallelism %d: got %d results, want %d", parallelism, len(results), len(docs))
+		}
+		for i, errs := range results {
+			if i%7 == 0 {
+				assertErrors(t, errs, []string{"field 'id' must be an integer"})
+			} else if errs != nil {
+				t.Errorf("parallelism %d: document %d: unexpected errors %v", parallelism, i, errs)
+			}
+		}
+	}
+
+	if results := ValidateAll(nil, schema, 4); len(results) != 0 {
+		t.Errorf("unexpected results %v for no documents", results)
+	}
+
+	results := ValidateAll([]interface{}{1, 2}, &yema.Type{Kind: yema.Array}, 2)
+	if len(results) != 2 || len(results[0]) != 1 || len(results[1]) != 1 {
+		t.Errorf("expected the schema error for every document, got %v", results)
+	}
+}
+
+func TestValidateAllPanic(t *testing.T) {
+	registry := NewRegistry()
+	registry.RegisterFormat("explode", func(value interface{}) error {
+		if value == "boom" {
+			panic("boom")
+		}
+		return nil
+	})
+	schema := &yema.Type{Kind: yema.String, Format: "explode"}
+
+	results := ValidateAllWithOptions([]interface{}{"ok", "boom", "ok"}, schema, 2, Options{Registry: registry})
+	if results[0] != nil || results[2] != nil {
+		t.Errorf("unexpected errors %v", results)
+	}
+	var verr *Error
+	if len(results[1]) != 1 || !errors.As(results[1][0], &verr) || !errors.Is(verr, ErrPanic) || CodeOf(verr) != CodePanic {
+		t.Errorf("expected the panic to be reported as an *Error, got %v", results[1])
+	}
+}
+
+func BenchmarkValidateAll(b *testing.B) {
+	personSchema, data := benchmarkPerson()
+	docs := make([]interface{}, 1000)
+	for i := range docs {
+		docs[i] = data
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ValidateAll(docs, personSchema, 0)
+	}
+}
@@ -0,0 +1,61 @@
+package validator
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/aep/yema"
+)
+
+func TestBigIntegers(t *testing.T) {
+	tests := []struct {
+		name  string
+		kind  yema.Kind
+		value interface{}
+		want  []string
+	}{
+		{"int64 above 2^53 as json.Number", yema.Int64, json.Number("9007199254740993"), nil},
+		{"int64 max", yema.Int64, json.Number("9223372036854775807"), nil},
+		{"int64 min", yema.Int64, json.Number("-9223372036854775808"), nil},
+		{"int64 overflow", yema.Int64, json.Number("9223372036854775808"), []string{"document value out of range for int64"}},
+		{"uint64 max", yema.Uint64, json.Number("18446744073709551615"), nil},
+		{"uint64 overflow", yema.Uint64, json.Number("18446744073709551616"), []string{"document value out of range for uint64"}},
+		{"uint64 negative", yema.Uint64, json.Number("-1"), []string{"document must be a non-negative integer"}},
+		{"int64 at 2^53 as float64", yema.Int64, float64(1 << 53), nil},
+		{"int64 above 2^53 as float64", yema.Int64, float64(1<<53 + 2), []string{"document is too large to be exact as a float64, decode numbers as json.Number"}},
+		{"uint64 above 2^53 as float64", yema.Uint64, float64(1e19), []string{"document is too large to be exact as a float64, decode numbers as json.Number"}},
+		{"int64 as uint64 Go value", yema.Int64, uint64(1 << 63), []string{"document value out of range for a signed integer"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := &yema.Type{Kind: tt.kind}
+			assertErrors(t, Validate(tt.value, schema), tt.want)
+
+			compiled, err := Compile(schema)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertErrors(t, compiled.Validate(tt.value), tt.want)
+		})
+	}
+}
+
+func TestBigIntegersFromDocuments(t *testing.T) {
+	schema := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"id":   {Kind: yema.Int64},
+			"size": {Kind: yema.Uint64},
+		},
+		Order: []string{"id", "size"},
+	}
+
+	assertErrors(t, ValidateJSON(strings.NewReader(`{"id": 9007199254740993, "size": 18446744073709551615}`), schema), nil)
+	assertErrors(t, ValidateYAML(strings.NewReader("id: 9007199254740993\nsize: 18446744073709551615\n"), schema), nil)
+	assertErrors(t, ValidateYAML(strings.NewReader("id: 9223372036854775808\nsize: 18446744073709551616\n"), schema), []string{
+		"field 'id' value out of range for a signed integer",
+		"field 'size' value out of range for uint64",
+	})
+}
@@ -0,0 +1,68 @@
+package validator
+
+import (
+	"fmt"
+
+	"github.com/aep/yema"
+)
+
+// builtinFormat is a format checked without registering a validator for it. Formats registered under
+// the same name replace it.
+type builtinFormat struct {
+	// kind is the kind of values the format applies to
+	kind yema.Kind
+	// check validates a value of the kind, arg is the argument of the format, nil if the schema gives none.
+	// The error is reported at the path of the value.
+	check func(value, arg interface{}) *Error
+	// checkArg validates the argument of the format, nil if the format takes none
+	checkArg func(arg interface{}) error
+}
+
+// builtinFormats are the built-in formats by name
+var builtinFormats = map[string]builtinFormat{
+	DateTimeFormat: {kind: yema.String, check: checkDateTime, checkArg: checkLayout},
+	DurationFormat: {kind: yema.String, check: checkDuration, checkArg: checkDialect},
+	UUIDFormat:     {kind: yema.String, check: checkUUID, checkArg: checkUUIDVersions},
+	URIFormat:      {kind: yema.String, check: checkURI, checkArg: checkURISchemes},
+	IPv4Format:     {kind: yema.String, check: checkIPv4, checkArg: checkIPRange},
+	IPv6Format:     {kind: yema.String, check: checkIPv6, checkArg: checkIPRange},
+	CIDRFormat:     {kind: yema.String, check: checkCIDR, checkArg: checkIPRange},
+	SemverFormat:   {kind: yema.String, check: checkSemver, checkArg: checkSemverRange},
+}
+
+// CheckFormat checks the declaration of a format on a type of the given kind. Built-in formats, such as date-time,
+// only apply to their kind and take the arguments they document, other formats take no argument.
+func CheckFormat(name string, arg interface{}, kind yema.Kind) error {
+	builtin, ok := builtinFormats[name]
+	if !ok {
+		if arg != nil {
+			return fmt.Errorf("format %s takes no argument", name)
+		}
+		return nil
+	}
+	if kind != builtin.kind {
+		return fmt.Errorf("format %s is only supported for %v", name, builtin.kind)
+	}
+	if arg == nil {
+		return nil
+	}
+	if builtin.checkArg == nil {
+		return fmt.Errorf("format %s takes no argument", name)
+	}
+	if err := builtin.checkArg(arg); err != nil {
+		return fmt.Errorf("invalid argument of format %s: %w", name, err)
+	}
+	return nil
+}
+
+// checkBuiltin checks a value against a built-in format, unless a validator was registered for its name
+func (v *validation) checkBuiltin(value interface{}, format string, arg interface{}, path func() string) {
+	builtin, ok := builtinFormats[format]
+	if !ok {
+		return
+	}
+	if err := builtin.check(value, arg); err != nil {
+		err.Path = path()
+		v.report(err)
+	}
+}
@@ -0,0 +1,73 @@
+package validator
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+
+	"github.com/aep/yema"
+)
+
+// Encodings of bytes given as strings, declared as the format of a bytes type.
+// Strings of bytes declaring one of them must decode, other strings are taken as they are,
+// also when counting their bytes against yema.Type.Length.
+const (
+	Base64Encoding    = "base64"
+	Base64URLEncoding = "base64url"
+	HexEncoding       = "hex"
+)
+
+// decodeBytes decodes a string holding bytes in the encoding named by format, false if format is not an encoding.
+// Base64 may be padded or not.
+func decodeBytes(s, format string) ([]byte, bool, error) {
+	var b []byte
+	var err error
+	switch format {
+	case Base64Encoding:
+		if b, err = base64.StdEncoding.DecodeString(s); err != nil {
+			b, err = base64.RawStdEncoding.DecodeString(s)
+		}
+	case Base64URLEncoding:
+		if b, err = base64.URLEncoding.DecodeString(s); err != nil {
+			b, err = base64.RawURLEncoding.DecodeString(s)
+		}
+	case HexEncoding:
+		b, err = hex.DecodeString(s)
+	default:
+		return nil, false, nil
+	}
+	return b, true, err
+}
+
+// checkBytes reports a value that is neither bytes nor a string, a string that does not decode in the encoding
+// declared by format, and bytes whose number once decoded is out of the bounds of length
+func (v *validation) checkBytes(value interface{}, format string, length *yema.Length, path func() string) {
+	var n int
+	switch b := value.(type) {
+	case []byte:
+		n = len(b)
+	case string:
+		decoded, ok, err := decodeBytes(b, format)
+		if ok && err != nil {
+			v.report(wrapError(err, path(), "encoding", "encoding", format))
+			return
+		}
+		n = len(b)
+		if ok {
+			n = len(decoded)
+		}
+	default:
+		v.report(newError(path(), "type.bytes"))
+		return
+	}
+
+	switch {
+	case length == nil:
+	case length.Min == length.Max && n != length.Min:
+		v.report(newError(path(), "length", "length", strconv.Itoa(length.Min), "actual", strconv.Itoa(n)))
+	case n < length.Min:
+		v.report(newError(path(), "length.min", "min", strconv.Itoa(length.Min), "actual", strconv.Itoa(n)))
+	case length.Max >= 0 && n > length.Max:
+		v.report(newError(path(), "length.max", "max", strconv.Itoa(length.Max), "actual", strconv.Itoa(n)))
+	}
+}
@@ -0,0 +1,124 @@
+package validator
+
+import "errors"
+
+// Code classifies a validation failure. Codes are stable across releases, unlike the wording of messages,
+// so programs can branch on them and they can be shown to users as a reference.
+type Code string
+
+const (
+	// CodeRequiredMissing is a required field missing from a struct
+	CodeRequiredMissing Code = "E_REQUIRED_MISSING"
+	// CodeNull is a null value that is not optional
+	CodeNull Code = "E_NULL"
+	// CodeUnknownField is a field not declared in the schema, see Options.DenyUnknownFields
+	CodeUnknownField Code = "E_UNKNOWN_FIELD"
+	// CodeRequires is a field present without a sibling field it requires
+	CodeRequires Code = "E_REQUIRES"
+	// CodeConflict is a field present along with a sibling field it conflicts with
+	CodeConflict Code = "E_CONFLICT"
+	// CodeDuplicate is an item of an array equal to an earlier one, if items must be unique
+	CodeDuplicate Code = "E_DUPLICATE"
+	// CodeTypeMismatch is a value of another kind than the declared one
+	CodeTypeMismatch Code = "E_TYPE_MISMATCH"
+	// CodeRange is a number out of the range of its declared kind
+	CodeRange Code = "E_RANGE"
+	// CodeEnum is a value that is not one of the values of an enum
+	CodeEnum Code = "E_ENUM"
+	// CodeKeyPattern is a map key not matching the key pattern of the map
+	CodeKeyPattern Code = "E_KEY_PATTERN"
+	// CodeFormat is a value not matching its declared format
+	CodeFormat Code = "E_FORMAT"
+	// CodeEncoding is a string of bytes that does not decode in its declared encoding
+	CodeEncoding Code = "E_ENCODING"
+	// CodeLength is bytes whose number is out of the bounds of their declared length
+	CodeLength Code = "E_LENGTH"
+	// CodeInvalid is a value rejected by a custom validator registered for its path
+	CodeInvalid Code = "E_INVALID"
+	// CodeNoVariant is a value matching no variant of a union, see UnionError
+	CodeNoVariant Code = "E_NO_VARIANT"
+	// CodeLimitExceeded is a document exceeding a resource limit, see Options
+	CodeLimitExceeded Code = "E_LIMIT_EXCEEDED"
+	// CodeCanceled is a validation stopped by its context, see ValidateContext
+	CodeCanceled Code = "E_CANCELED"
+	// CodePanic is a validation that panicked, see SafeValidate
+	CodePanic Code = "E_PANIC"
+	// CodeSyntax is a document that cannot be decoded
+	CodeSyntax Code = "E_SYNTAX"
+)
+
+// messageCodes classifies the messages of the English catalog
+var messageCodes = map[string]Code{
+	"required":      CodeRequiredMissing,
+	"null":          CodeNull,
+	"unknown_field": CodeUnknownField,
+	"requires":      CodeRequires,
+	"conflicts":     CodeConflict,
+	"unique":        CodeDuplicate,
+
+	"type.boolean":              CodeTypeMismatch,
+	"type.string":               CodeTypeMismatch,
+	"type.integer":              CodeTypeMismatch,
+	"type.non_negative_integer": CodeTypeMismatch,
+	"type.number":               CodeTypeMismatch,
+	"type.bytes":                CodeTypeMismatch,
+	"type.array":                CodeTypeMismatch,
+	"type.map":                  CodeTypeMismatch,
+	"type.struct":               CodeTypeMismatch,
+	"type.protojson_integer":    CodeTypeMismatch,
+	"type.protojson_bytes":      CodeTypeMismatch,
+
+	"range":           CodeRange,
+	"range.signed":    CodeRange,
+	"range.imprecise": CodeRange,
+
+	"enum":                CodeEnum,
+	"enum.suggestion":     CodeEnum,
+	"key_pattern":         CodeKeyPattern,
+	"format":              CodeFormat,
+	"format.layout":       CodeFormat,
+	"format.duration":     CodeFormat,
+	"format.uuid":         CodeFormat,
+	"format.uuid_version": CodeFormat,
+	"format.uri":          CodeFormat,
+	"format.uri_scheme":   CodeFormat,
+	"format.ip":           CodeFormat,
+	"format.ip_range":     CodeFormat,
+	"format.cidr":         CodeFormat,
+	"format.cidr_range":   CodeFormat,
+	"format.semver":       CodeFormat,
+	"format.semver_range": CodeFormat,
+	"encoding":            CodeEncoding,
+	"length":              CodeLength,
+	"length.min":          CodeLength,
+	"length.max":          CodeLength,
+	"invalid":             CodeInvalid,
+
+	"limit.depth":   CodeLimitExceeded,
+	"limit.length":  CodeLimitExceeded,
+	"limit.aliases": CodeLimitExceeded,
+	"canceled":      CodeCanceled,
+	"panic":         CodePanic,
+
+	"syntax.json":          CodeSyntax,
+	"syntax.trailing":      CodeSyntax,
+	"syntax.yaml":          CodeSyntax,
+	"syntax.alias":         CodeSyntax,
+	"syntax.duplicate_key": CodeSyntax,
+	"syntax.merge":         CodeSyntax,
+	"syntax.key":           CodeSyntax,
+}
+
+// CodeOf returns the code of a validation failure, empty for errors that are not validation failures,
+// such as an invalid schema
+func CodeOf(err error) Code {
+	var unionErr *UnionError
+	if errors.As(err, &unionErr) {
+		return CodeNoVariant
+	}
+	var verr *Error
+	if errors.As(err, &verr) {
+		return verr.Code
+	}
+	return ""
+}
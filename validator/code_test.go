@@ -0,0 +1,75 @@
+package validator
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aep/yema"
+)
+
+func TestCodeOf(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema *yema.Type
+		data   interface{}
+		opts   Options
+		want   Code
+	}{
+		{"required", &yema.Type{Kind: yema.Struct, Struct: &map[string]yema.Type{"a": {Kind: yema.String}}}, map[string]interface{}{}, Options{}, CodeRequiredMissing},
+		{"null", &yema.Type{Kind: yema.String}, nil, Options{}, CodeNull},
+		{"unknown field", &yema.Type{Kind: yema.Struct, Struct: &map[string]yema.Type{}}, map[string]interface{}{"a": 1}, Options{DenyUnknownFields: true}, CodeUnknownField},
+		{"type", &yema.Type{Kind: yema.Bool}, "yes", Options{}, CodeTypeMismatch},
+		{"range", &yema.Type{Kind: yema.Int8}, 1000, Options{}, CodeRange},
+		{"enum", &yema.Type{Kind: yema.String, Enum: []interface{}{"a"}}, "b", Options{}, CodeEnum},
+		{"key pattern", &yema.Type{Kind: yema.Map, Map: &yema.Type{Kind: yema.Int}, KeyPattern: "^[a-z]+$"}, map[string]interface{}{"A": 1}, Options{}, CodeKeyPattern},
+		{"union", &yema.Type{Kind: yema.Union, Union: []yema.Type{{Kind: yema.String}, {Kind: yema.Int}}}, true, Options{}, CodeNoVariant},
+		{"limit", &yema.Type{Kind: yema.Array, Array: &yema.Type{Kind: yema.Int}}, []interface{}{1, 2}, Options{MaxArrayLength: 1}, CodeLimitExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := ValidateWithOptions(tt.data, tt.schema, tt.opts)
+			if len(errs) != 1 {
+				t.Fatalf("expected 1 error, got %v", errs)
+			}
+			if got := CodeOf(errs[0]); got != tt.want {
+				t.Errorf("expected code %s, got %s for %v", tt.want, got, errs[0])
+			}
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	errs := ValidateContext(ctx, []interface{}{1}, &yema.Type{Kind: yema.Array, Array: &yema.Type{Kind: yema.Int}})
+	if len(errs) != 1 || CodeOf(errs[0]) != CodeCanceled {
+		t.Errorf("expected a canceled error, got %v", errs)
+	}
+
+	errs = ValidateJSON(strings.NewReader("{"), &yema.Type{Kind: yema.Struct, Struct: &map[string]yema.Type{}})
+	if len(errs) != 1 || CodeOf(errs[0]) != CodeSyntax {
+		t.Errorf("expected a syntax error, got %v", errs)
+	}
+
+	wrapped := &RecordError{Record: 1, Err: newError("a", "null")}
+	if got := CodeOf(wrapped); got != CodeNull {
+		t.Errorf("expected the code of a wrapped error, got %s", got)
+	}
+	if got := CodeOf(errors.New("invalid schema")); got != "" {
+		t.Errorf("expected no code for other errors, got %s", got)
+	}
+}
+
+// Every message of the validator must be classified, otherwise its errors carry no code
+func TestMessageCodes(t *testing.T) {
+	for message := range English {
+		// Subjects and wrappers of other messages are not failures of their own
+		if strings.HasPrefix(message, "subject.") || strings.HasPrefix(message, "union") || message == "position" {
+			continue
+		}
+		if messageCodes[message] == "" {
+			t.Errorf("message %q has no code", message)
+		}
+	}
+}
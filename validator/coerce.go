@@ -0,0 +1,143 @@
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/aep/yema"
+)
+
+// Coerce converts values of a decoded document to the kinds declared by the schema where the representation
+// is compatible, then validates the result. It returns the normalized document, the input is left unchanged.
+// This is meant for data where every value arrives as a string, such as environment variables or query strings:
+//
+//   - strings holding a number become int64, uint64 or float64 for integer and float types
+//   - strings holding a boolean, as accepted by strconv.ParseBool, become bool
+//   - integers become float64 for float types
+//
+// Values that cannot be converted are left as they are and reported by validation.
+func Coerce(data interface{}, schema *yema.Type) (interface{}, []error) {
+	return CoerceWithOptions(data, schema, Options{})
+}
+
+// CoerceWithOptions is like Coerce with custom validation options
+func CoerceWithOptions(data interface{}, schema *yema.Type, opts Options) (interface{}, []error) {
+	if schema == nil {
+		return data, []error{fmt.Errorf("invalid schema")}
+	}
+
+	coerced := coerce(data, schema)
+	return coerced, ValidateWithOptions(coerced, schema, opts)
+}
+
+// coerce returns value converted to the kind of schema where possible
+func coerce(value interface{}, schema *yema.Type) interface{} {
+	switch schema.Kind {
+	case yema.Bool:
+		if s, ok := value.(string); ok {
+			if b, err := strconv.ParseBool(s); err == nil {
+				return b
+			}
+		}
+
+	case yema.Int, yema.Int8, yema.Int16, yema.Int32, yema.Int64:
+		if s, ok := value.(string); ok {
+			if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+				return i
+			}
+		}
+
+	case yema.Uint, yema.Uint8, yema.Uint16, yema.Uint32, yema.Uint64:
+		if s, ok := value.(string); ok {
+			if u, err := strconv.ParseUint(s, 10, 64); err == nil {
+				return u
+			}
+		}
+
+	case yema.Float32, yema.Float64:
+		switch v := value.(type) {
+		case string:
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				return f
+			}
+		case json.Number:
+			if f, err := v.Float64(); err == nil {
+				return f
+			}
+		case int:
+			return float64(v)
+		case int8:
+			return float64(v)
+		case int16:
+			return float64(v)
+		case int32:
+			return float64(v)
+		case int64:
+			return float64(v)
+		case uint:
+			return float64(v)
+		case uint8:
+			return float64(v)
+		case uint16:
+			return float64(v)
+		case uint32:
+			return float64(v)
+		case uint64:
+			return float64(v)
+		}
+
+	case yema.Array:
+		arr, ok := value.([]interface{})
+		if !ok || schema.Array == nil {
+			return value
+		}
+		out := make([]interface{}, len(arr))
+		for i, elem := range arr {
+			out[i] = coerce(elem, schema.Array)
+		}
+		return out
+
+	case yema.Union:
+		// The value takes the form of the first variant it matches once converted
+		for i := range schema.Union {
+			converted := coerce(value, &schema.Union[i])
+			if len(Validate(converted, &schema.Union[i])) == 0 {
+				return converted
+			}
+		}
+
+	case yema.Map:
+		m, ok := value.(map[string]interface{})
+		if !ok || schema.Map == nil {
+			return value
+		}
+		out := make(map[string]interface{}, len(m))
+		for key, elem := range m {
+			out[key] = coerce(elem, schema.Map)
+		}
+		return out
+
+	case yema.Struct:
+		m, ok := value.(map[string]interface{})
+		if !ok || schema.Struct == nil {
+			return value
+		}
+		out := make(map[string]interface{}, len(m))
+		for key, fieldValue := range m {
+			if fieldType, ok := (*schema.Struct)[key]; ok {
+				fieldValue = coerce(fieldValue, &fieldType)
+			}
+			out[key] = fieldValue
+		}
+		// Conditional fields are coerced again once the values of the fields they depend on are coerced
+		for key, fieldValue := range m {
+			if fieldType, ok := (*schema.Struct)[key]; ok && fieldType.If != nil {
+				out[key] = coerce(fieldValue, selectType(&fieldType, out))
+			}
+		}
+		return out
+	}
+
+	return value
+}
@@ -0,0 +1,100 @@
+package validator
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aep/yema"
+)
+
+func TestCoerce(t *testing.T) {
+	schema := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"port":    {Kind: yema.Uint16},
+			"offset":  {Kind: yema.Int32},
+			"ratio":   {Kind: yema.Float64},
+			"scale":   {Kind: yema.Float32},
+			"debug":   {Kind: yema.Bool},
+			"name":    {Kind: yema.String},
+			"retries": {Kind: yema.Array, Array: &yema.Type{Kind: yema.Int}},
+		},
+	}
+
+	data := map[string]interface{}{
+		"port":    "8080",
+		"offset":  "-3",
+		"ratio":   "0.5",
+		"scale":   2,
+		"debug":   "true",
+		"name":    "42",
+		"retries": []interface{}{"1", 2},
+		"extra":   "1",
+	}
+
+	got, errs := Coerce(data, schema)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors %v", errs)
+	}
+
+	want := map[string]interface{}{
+		"port":    uint64(8080),
+		"offset":  int64(-3),
+		"ratio":   0.5,
+		"scale":   2.0,
+		"debug":   true,
+		"name":    "42",
+		"retries": []interface{}{int64(1), 2},
+		"extra":   "1",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Coerce() = %#v, want %#v", got, want)
+	}
+	if data["port"] != "8080" {
+		t.Errorf("input was modified: %v", data)
+	}
+}
+
+func TestCoerceInvalid(t *testing.T) {
+	schema := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"port":  {Kind: yema.Uint8},
+			"debug": {Kind: yema.Bool},
+			"count": {Kind: yema.Int},
+		},
+	}
+
+	_, errs := Coerce(map[string]interface{}{"port": "8080", "debug": "maybe", "count": "1.5"}, schema)
+	assertErrors(t, errs, []string{
+		"field 'count' must be an integer",
+		"field 'debug' must be a boolean",
+		"field 'port' value out of range for uint8",
+	})
+}
+
+func TestCoerceOption(t *testing.T) {
+	schema := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"port":  {Kind: yema.Uint16},
+			"debug": {Kind: yema.Bool},
+		},
+		Order: []string{"port", "debug"},
+	}
+	data := map[string]interface{}{"port": "8080", "debug": "maybe"}
+	opts := Options{Coerce: true}
+
+	compiled, err := CompileWithOptions(schema, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"field 'debug' must be a boolean"}
+	assertErrors(t, ValidateWithOptions(data, schema, opts), want)
+	assertErrors(t, compiled.Validate(data), want)
+
+	assertErrors(t, Validate(data, schema), []string{
+		"field 'port' must be a non-negative integer",
+		"field 'debug' must be a boolean",
+	})
+}
@@ -0,0 +1,387 @@
+package validator
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/aep/yema"
+	"github.com/aep/yema/internal/fieldpath"
+)
+
+// CompiledValidator checks documents against a schema prepared ahead of time, see Compile.
+// It is safe for concurrent use.
+type CompiledValidator struct {
+	root *plan
+	opts Options
+	// schema is the compiled schema, to coerce documents if Options.Coerce is set
+	schema *yema.Type
+}
+
+// plan is the compiled form of a yema.Type
+type plan struct {
+	kind     yema.Kind
+	optional bool
+	// fields of a struct in declaration order
+	fields []fieldPlan
+	// schema of a struct, to look up unknown fields
+	schema *yema.Type
+	// item of an array
+	item *plan
+	// variants of a union
+	variants []*plan
+	// values of a map and the pattern its keys must match, nil if keys are unconstrained
+	values     *plan
+	keyPattern *regexp.Regexp
+	// requires and conflicts list the sibling fields a struct field requires or conflicts with
+	requires  []string
+	conflicts []string
+	// unique requires the items of an array to differ from each other
+	unique bool
+	// when selects another plan of a struct field by the values of its sibling fields, nil if there is none
+	when *condition
+	// enum lists the allowed values, nil if any value of the kind is allowed
+	enum []interface{}
+	// format is the name of the declared format and formatFunc its validator, nil if it is not registered,
+	// formatArg is the argument of a built-in format
+	format     string
+	formatFunc FormatFunc
+	formatArg  interface{}
+	// length bounds the number of bytes of bytes, nil if it is unconstrained
+	length *yema.Length
+	// validators are the custom validators registered for the path of the value
+	validators []FormatFunc
+}
+
+type fieldPlan struct {
+	name string
+	plan *plan
+}
+
+// pathSegment is a link of the path to a value, only rendered to a string when an error is reported
+type pathSegment struct {
+	parent *pathSegment
+	// name of a struct field, empty for array elements and map values
+	name  string
+	index int
+	// key of a map value, valid if isKey is set
+	key   string
+	isKey bool
+}
+
+func (p *pathSegment) String() string {
+	if p == nil {
+		return ""
+	}
+	if p.isKey {
+		return keyPath(p.parent.String(), p.key)
+	}
+	if p.name == "" {
+		return p.parent.String() + "[" + strconv.Itoa(p.index) + "]"
+	}
+	return fieldpath.Join(p.parent.String(), p.name)
+}
+
+// Compile prepares a schema for repeated validation. The field order and nested types are resolved once,
+// so validating a document does not walk the schema again and only builds field paths for errors.
+// A compiled validator reports the same errors as Validate. Custom validators are looked up once as well,
+// so those registered after compiling are not run.
+func Compile(schema *yema.Type) (*CompiledValidator, error) {
+	return CompileWithOptions(schema, Options{})
+}
+
+// CompileWithOptions is like Compile with custom options
+func CompileWithOptions(schema *yema.Type, opts Options) (*CompiledValidator, error) {
+	if schema == nil {
+		return nil, fmt.Errorf("invalid schema")
+	}
+
+	root, err := compile(schema, "", opts.Registry)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CompiledValidator{root: root, opts: opts, schema: schema}, nil
+}
+
+func compile(t *yema.Type, path string, registry *Registry) (*plan, error) {
+	p := &plan{
+		kind:       t.Kind,
+		optional:   t.Optional,
+		enum:       t.Enum,
+		requires:   t.Requires,
+		conflicts:  t.Conflicts,
+		unique:     t.Unique,
+		format:     t.Format,
+		formatArg:  t.FormatArg,
+		length:     t.Length,
+		validators: registry.path(path),
+	}
+	if t.Format != "" {
+		p.formatFunc = registry.format(t.Format)
+	}
+	if t.If != nil {
+		when, err := compileCondition(t.If, path, registry)
+		if err != nil {
+			return nil, err
+		}
+		p.when = when
+	}
+
+	switch t.Kind {
+	case yema.Bool, yema.String, yema.Bytes,
+		yema.Int, yema.Int8, yema.Int16, yema.Int32, yema.Int64,
+		yema.Uint, yema.Uint8, yema.Uint16, yema.Uint32, yema.Uint64,
+		yema.Float32, yema.Float64:
+
+	case yema.Array:
+		if t.Array == nil {
+			return nil, fmt.Errorf("array type definition for %s is nil", subject(path))
+		}
+		item, err := compile(t.Array, path+"[]", registry)
+		if err != nil {
+			return nil, err
+		}
+		p.item = item
+
+	case yema.Map:
+		if t.Map == nil {
+			return nil, fmt.Errorf("map type definition for %s is nil", subject(path))
+		}
+		pattern, err := keyPattern(t.KeyPattern)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", subject(path), err)
+		}
+		values, err := compile(t.Map, path+"[]", registry)
+		if err != nil {
+			return nil, err
+		}
+		p.values = values
+		p.keyPattern = pattern
+
+	case yema.Union:
+		if len(t.Union) == 0 {
+			return nil, fmt.Errorf("union type definition for %s has no variants", subject(path))
+		}
+		for i := range t.Union {
+			variant, err := compile(&t.Union[i], path, registry)
+			if err != nil {
+				return nil, err
+			}
+			p.variants = append(p.variants, variant)
+		}
+
+	case yema.Struct:
+		if t.Struct == nil {
+			return nil, fmt.Errorf("struct type definition for %s is nil", subject(path))
+		}
+		p.schema = t
+		for _, name := range t.FieldNames() {
+			fieldType := (*t.Struct)[name]
+			field, err := compile(&fieldType, fieldpath.Join(path, name), registry)
+			if err != nil {
+				return nil, err
+			}
+			p.fields = append(p.fields, fieldPlan{name: name, plan: field})
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported type %v for %s", t.Kind, subject(path))
+	}
+
+	return p, nil
+}
+
+// Validate checks a decoded document against the compiled schema, see Validate
+func (c *CompiledValidator) Validate(data interface{}) []error {
+	return c.validate(nil, data)
+}
+
+// validate checks a document until ctx is done, ctx is nil if the validation cannot be canceled
+func (c *CompiledValidator) validate(ctx context.Context, data interface{}) []error {
+	v := &validation{opts: c.opts, ctx: ctx}
+	start := v.now()
+
+	if c.opts.Coerce {
+		data = coerce(data, c.schema)
+	}
+	v.validatePlan(data, c.root, nil)
+	v.finish(start)
+	return v.errors
+}
+
+// validatePlan checks a single value against its compiled type and reports all violations
+func (v *validation) validatePlan(value interface{}, p *plan, path *pathSegment) {
+	v.values++
+	if value == nil {
+		if v.required(p.kind, p.optional) {
+			v.report(newError(path.String(), "null"))
+		}
+		return
+	}
+
+	if v.opts.ProtoJSON {
+		converted, err := fromProtoJSON(value, p.kind, "")
+		if err != nil {
+			_, err = fromProtoJSON(value, p.kind, path.String())
+			v.report(err)
+			return
+		}
+		value = converted
+	}
+
+	before := len(v.errors)
+
+	switch p.kind {
+	case yema.Bool:
+		if _, ok := value.(bool); !ok {
+			v.report(newError(path.String(), "type.boolean"))
+		}
+
+	case yema.String:
+		if _, ok := value.(string); !ok {
+			v.report(newError(path.String(), "type.string"))
+		}
+
+	case yema.Int, yema.Int8, yema.Int16, yema.Int32, yema.Int64:
+		// Only render the path once the value is known to be invalid
+		if validateIntValue(value, p.kind, "") != nil {
+			v.report(validateIntValue(value, p.kind, path.String()))
+		}
+
+	case yema.Uint, yema.Uint8, yema.Uint16, yema.Uint32, yema.Uint64:
+		if validateUintValue(value, p.kind, "") != nil {
+			v.report(validateUintValue(value, p.kind, path.String()))
+		}
+
+	case yema.Float32, yema.Float64:
+		if validateFloatValue(value, p.kind, "") != nil {
+			v.report(validateFloatValue(value, p.kind, path.String()))
+		}
+
+	case yema.Bytes:
+		v.checkBytes(value, p.format, p.length, path.String)
+
+	case yema.Array:
+		arr, ok := value.([]interface{})
+		if !ok {
+			v.report(newError(path.String(), "type.array"))
+			return
+		}
+		if !v.checkLength(len(arr), path.String) || !v.enter(path.String) {
+			return
+		}
+		defer v.leave()
+
+		for i, elem := range arr {
+			elemPath := &pathSegment{parent: path, index: i}
+			if v.done() || v.canceled(elemPath.String) {
+				return
+			}
+			v.validatePlan(elem, p.item, elemPath)
+		}
+		if p.unique && !v.done() {
+			v.checkUnique(arr, func(i int) string { return (&pathSegment{parent: path, index: i}).String() })
+		}
+
+	case yema.Map:
+		data, ok := value.(map[string]interface{})
+		if !ok {
+			v.report(newError(path.String(), "type.map"))
+			return
+		}
+		if !v.enter(path.String) {
+			return
+		}
+		defer v.leave()
+
+		for _, key := range sortedKeys(data) {
+			elemPath := &pathSegment{parent: path, key: key, isKey: true}
+			if v.done() || v.canceled(elemPath.String) {
+				return
+			}
+			v.checkKey(key, p.keyPattern, elemPath.String)
+			v.validatePlan(data[key], p.values, elemPath)
+		}
+
+	case yema.Union:
+		unionErr := &UnionError{}
+		matched := false
+		for _, variant := range p.variants {
+			sub := v.variant()
+			sub.validatePlan(value, variant, path)
+			if len(sub.errors) == 0 {
+				matched = true
+				break
+			}
+			unionErr.Variants = append(unionErr.Variants, VariantError{Kind: variant.kind, Errors: sub.errors})
+		}
+		if !matched {
+			unionErr.Path = path.String()
+			v.report(unionErr)
+		}
+
+	case yema.Struct:
+		data, ok := value.(map[string]interface{})
+		if !ok {
+			v.report(newError(path.String(), "type.struct"))
+			return
+		}
+		if !v.enter(path.String) {
+			return
+		}
+		defer v.leave()
+
+		present := 0
+		for _, field := range p.fields {
+			if v.done() {
+				return
+			}
+
+			selected := field.plan.selectPlan(data)
+			fieldValue, exists := data[field.name]
+			if !exists {
+				if v.required(selected.kind, selected.optional) {
+					v.report(newError(fieldpath.Join(path.String(), field.name), "required"))
+				}
+				continue
+			}
+
+			present++
+			fieldPath := &pathSegment{parent: path, name: field.name}
+			start, before := v.now(), len(v.errors)
+			v.validatePlan(fieldValue, selected, fieldPath)
+			v.fieldDone(fieldPath.String, start, before)
+			v.checkRules(data, field.name, field.plan.requires, field.plan.conflicts, path.String)
+		}
+
+		// Unknown fields exist only if the data holds more keys than declared fields it matched
+		if v.opts.DenyUnknownFields && present < len(data) {
+			for _, fieldName := range unknownFields(data, p.schema) {
+				v.report(newError(fieldpath.Join(path.String(), fieldName), "unknown_field"))
+			}
+		}
+	}
+
+	// Enums and custom validators only run on values matching their kind
+	if len(v.errors) != before {
+		return
+	}
+	v.checkEnum(value, p.enum, path.String)
+	if len(v.errors) != before {
+		return
+	}
+	if p.formatFunc != nil {
+		if err := p.formatFunc(value); err != nil {
+			v.report(wrapError(err, path.String(), "format", "format", p.format))
+		}
+	} else if p.format != "" {
+		v.checkBuiltin(value, p.format, p.formatArg, path.String)
+	}
+	for _, fn := range p.validators {
+		if err := fn(value); err != nil {
+			v.report(wrapError(err, path.String(), "invalid"))
+		}
+	}
+}
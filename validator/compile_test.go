@@ -0,0 +1,100 @@
+package validator
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/aep/yema"
+)
+
+func TestCompile(t *testing.T) {
+	schema := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"name":  {Kind: yema.String},
+			"age":   {Kind: yema.Uint8},
+			"score": {Kind: yema.Float32, Optional: true},
+			"raw":   {Kind: yema.Bytes, Optional: true},
+			"items": {Kind: yema.Array, Array: &yema.Type{
+				Kind: yema.Struct,
+				Struct: &map[string]yema.Type{
+					"id":     {Kind: yema.Int16},
+					"active": {Kind: yema.Bool, Optional: true},
+				},
+			}},
+		},
+		Order: []string{"name", "age", "score", "raw", "items", "labels"},
+	}
+	(*schema.Struct)["labels"] = yema.Type{Kind: yema.Map, Optional: true, KeyPattern: "^[a-z]+$", Map: &yema.Type{Kind: yema.Uint8}}
+
+	documents := []string{
+		`{"name": "a", "age": 1, "items": []}`,
+		`{"name": "a", "age": 1, "score": 1.5, "raw": "AA==", "items": [{"id": 1}, {"id": 2, "active": true}]}`,
+		`{}`,
+		`{"name": 1, "age": -1, "score": "x", "raw": 1, "items": {}}`,
+		`{"name": "a", "age": 300, "items": [{"id": 40000}, {"active": 1, "extra": 1}, 3, null]}`,
+		`{"name": null, "age": 1, "items": [], "unknown": true}`,
+		`{"name": "a", "age": 1, "items": [], "labels": {"a": 1, "B": 2, "c": 300, "d": null}}`,
+		`{"name": "a", "age": 1, "items": [], "labels": []}`,
+		`[]`,
+		`null`,
+	}
+
+	for _, opts := range []Options{{}, {DenyUnknownFields: true}, {MaxErrors: 2}, {FailFast: true}} {
+		compiled, err := CompileWithOptions(schema, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, doc := range documents {
+			var data interface{}
+			if err := json.Unmarshal([]byte(doc), &data); err != nil {
+				t.Fatal(err)
+			}
+
+			want := ValidateWithOptions(data, schema, opts)
+			got := compiled.Validate(data)
+			if !reflect.DeepEqual(errorStrings(got), errorStrings(want)) {
+				t.Errorf("%+v %s: got %v, want %v", opts, doc, got, want)
+			}
+		}
+	}
+}
+
+func TestCompileInvalidSchema(t *testing.T) {
+	schemas := []*yema.Type{
+		nil,
+		{Kind: yema.Struct},
+		{Kind: yema.Array},
+		{Kind: yema.Map},
+		{Kind: yema.Map, Map: &yema.Type{Kind: yema.String}, KeyPattern: "("},
+		{Kind: yema.Struct, Struct: &map[string]yema.Type{"a": {Kind: yema.Kind(99)}}},
+	}
+	for _, schema := range schemas {
+		if _, err := Compile(schema); err == nil {
+			t.Errorf("expected error compiling %+v", schema)
+		}
+	}
+}
+
+func errorStrings(errs []error) []string {
+	var s []string
+	for _, e := range errs {
+		s = append(s, e.Error())
+	}
+	return s
+}
+
+func BenchmarkCompiledValidateMap(b *testing.B) {
+	personSchema, data := benchmarkPerson()
+	compiled, err := Compile(personSchema)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = compiled.Validate(data)
+	}
+}
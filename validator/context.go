@@ -0,0 +1,41 @@
+package validator
+
+import (
+	"context"
+
+	"github.com/aep/yema"
+)
+
+// ValidateContext is like Validate, but stops once ctx is done and reports the error of ctx along with
+// the errors found so far. The context is checked before every element of an array or map,
+// so deadlines are enforced on large documents.
+func ValidateContext(ctx context.Context, data interface{}, schema *yema.Type) []error {
+	return ValidateContextWithOptions(ctx, data, schema, Options{})
+}
+
+// ValidateContextWithOptions is like ValidateContext with custom options
+func ValidateContextWithOptions(ctx context.Context, data interface{}, schema *yema.Type, opts Options) []error {
+	return validateDocument(ctx, data, schema, "", opts)
+}
+
+// ValidateContext checks a decoded document against the compiled schema until ctx is done, see ValidateContext
+func (c *CompiledValidator) ValidateContext(ctx context.Context, data interface{}) []error {
+	return c.validate(ctx, data)
+}
+
+// canceled reports whether the validation must stop because its context is done.
+// The error of the context is reported once, with the path of the value the validation stopped at.
+func (v *validation) canceled(path func() string) bool {
+	if v.ctx == nil {
+		return false
+	}
+	if v.stopped {
+		return true
+	}
+	if err := v.ctx.Err(); err != nil {
+		v.report(wrapError(err, path(), "canceled"))
+		v.stopped = true
+		return true
+	}
+	return false
+}
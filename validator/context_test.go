@@ -0,0 +1,66 @@
+package validator
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aep/yema"
+)
+
+func TestValidateContext(t *testing.T) {
+	schema := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"name":  {Kind: yema.String},
+			"items": {Kind: yema.Array, Array: &yema.Type{Kind: yema.Int}},
+		},
+		Order: []string{"name", "items"},
+	}
+	data := map[string]interface{}{
+		"name":  1,
+		"items": []interface{}{1, "two", 3},
+	}
+
+	compiled, err := Compile(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"field 'name' must be a string", "field 'items[1]' must be an integer"}
+	assertErrors(t, ValidateContext(context.Background(), data, schema), want)
+	assertErrors(t, compiled.ValidateContext(context.Background(), data), want)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	want = []string{"field 'name' must be a string", "validation stopped at field 'items[0]': context canceled"}
+	for _, errs := range [][]error{ValidateContext(ctx, data, schema), compiled.ValidateContext(ctx, data)} {
+		assertErrors(t, errs, want)
+		if len(errs) == 2 && !errors.Is(errs[1], context.Canceled) {
+			t.Errorf("expected the error to wrap context.Canceled, got %v", errs[1])
+		}
+	}
+}
+
+func TestValidateContextCanceledMidway(t *testing.T) {
+	schema := &yema.Type{Kind: yema.Array, Array: &yema.Type{Kind: yema.Int}}
+	items := make([]interface{}, 10)
+	for i := range items {
+		items[i] = i
+	}
+
+	// A format validator cancels the context halfway through the array
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	registry := NewRegistry()
+	registry.RegisterPath("[]", func(value interface{}) error {
+		if value == 4 {
+			cancel()
+		}
+		return nil
+	})
+
+	errs := ValidateContextWithOptions(ctx, items, schema, Options{Registry: registry})
+	assertErrors(t, errs, []string{"validation stopped at field '[5]': context canceled"})
+}
@@ -0,0 +1,144 @@
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DurationFormat is the format of strings holding a duration, Normalize converts them to time.Duration.
+// The argument of the format selects the dialect, ISO 8601 if none is given.
+const DurationFormat = "duration"
+
+// Dialects of durations, the argument of DurationFormat
+const (
+	// ISO8601Duration durations look like PT1H30M. Years and months are rejected as their length varies,
+	// days and weeks are 24 hours and 7 days long.
+	ISO8601Duration = "iso8601"
+	// GoDuration durations look like 1h30m, as parsed by time.ParseDuration
+	GoDuration = "go"
+)
+
+// durationDialect returns the dialect of a duration format
+func durationDialect(arg interface{}) string {
+	if dialect, ok := arg.(string); ok {
+		return dialect
+	}
+	return ISO8601Duration
+}
+
+// parseDuration parses a duration in the dialect of a duration format
+func parseDuration(s string, arg interface{}) (time.Duration, error) {
+	if durationDialect(arg) == GoDuration {
+		return time.ParseDuration(s)
+	}
+	return parseISO8601Duration(s)
+}
+
+// checkDuration reports a string that is not a duration in the dialect of its format
+func checkDuration(value, arg interface{}) *Error {
+	s, ok := value.(string)
+	if !ok {
+		return nil
+	}
+	if _, err := parseDuration(s, arg); err != nil {
+		return durationError(err, arg)
+	}
+	return nil
+}
+
+// durationError describes a duration that does not parse, with an example of the dialect
+func durationError(err error, arg interface{}) *Error {
+	example := "PT1H30M"
+	if durationDialect(arg) == GoDuration {
+		example = "1h30m"
+	}
+	return wrapError(err, "", "format.duration", "example", example)
+}
+
+// checkDialect checks the dialect of a duration format
+func checkDialect(arg interface{}) error {
+	switch arg {
+	case ISO8601Duration, GoDuration:
+		return nil
+	}
+	return fmt.Errorf("expected %s or %s, got %v", ISO8601Duration, GoDuration, arg)
+}
+
+// isoDurationUnit is a unit of ISO 8601 durations
+type isoDurationUnit struct {
+	name byte
+	size time.Duration
+}
+
+// isoDurationUnits are the units of ISO 8601 durations before and after the T, in the order they must appear
+var isoDurationUnits = [2][]isoDurationUnit{
+	{{'W', 7 * 24 * time.Hour}, {'D', 24 * time.Hour}},
+	{{'H', time.Hour}, {'M', time.Minute}, {'S', time.Second}},
+}
+
+// parseISO8601Duration parses an ISO 8601 duration such as P1DT2H or PT0.5S. Only seconds may have a fraction.
+func parseISO8601Duration(s string) (time.Duration, error) {
+	rest, ok := strings.CutPrefix(s, "P")
+	if !ok {
+		return 0, errors.New("duration does not start with P")
+	}
+
+	var total time.Duration
+	// next is the index of the first unit of the part that may still follow
+	part, next, components := 0, 0, 0
+	for rest != "" {
+		if rest[0] == 'T' {
+			if part == 1 || len(rest) == 1 {
+				return 0, errors.New("duration has a misplaced T")
+			}
+			part, next, rest = 1, 0, rest[1:]
+			continue
+		}
+
+		end := strings.IndexFunc(rest, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+		switch {
+		case end < 0:
+			return 0, fmt.Errorf("duration has a number without a unit at %q", rest)
+		case end == 0:
+			return 0, fmt.Errorf("duration has a unit without a number at %q", rest)
+		}
+		number, unit := rest[:end], rest[end]
+		rest = rest[end+1:]
+
+		index := -1
+		for i, u := range isoDurationUnits[part] {
+			if u.name == unit {
+				index = i
+			}
+		}
+		switch {
+		case index < 0 && part == 0 && (unit == 'Y' || unit == 'M'):
+			return 0, errors.New("durations in years or months have no fixed length")
+		case index < next:
+			return 0, fmt.Errorf("duration has an unexpected unit %q", unit)
+		case strings.Contains(number, ".") && unit != 'S':
+			return 0, errors.New("only seconds of a duration may have a fraction")
+		}
+		size := isoDurationUnits[part][index].size
+		next = index + 1
+
+		n, err := strconv.ParseFloat(number, 64)
+		if err != nil {
+			return 0, fmt.Errorf("duration has an invalid number %q", number)
+		}
+		if n*float64(size) > math.MaxInt64-float64(total) {
+			return 0, errors.New("duration is too long")
+		}
+		total += time.Duration(n * float64(size))
+		components++
+	}
+
+	if components == 0 {
+		return 0, errors.New("duration has no components")
+	}
+	return total, nil
+}
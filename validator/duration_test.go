@@ -0,0 +1,65 @@
+package validator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aep/yema"
+)
+
+func TestDuration(t *testing.T) {
+	tests := []struct {
+		name  string
+		arg   interface{}
+		value interface{}
+		want  []string
+	}{
+		{"iso 8601", nil, "PT1H30M", nil},
+		{"iso 8601 days and fraction", ISO8601Duration, "P1DT0.5S", nil},
+		{"iso 8601 weeks", nil, "P2W", nil},
+		{"iso 8601 years", nil, "P1Y", []string{"document must be a duration such as PT1H30M: durations in years or months have no fixed length"}},
+		{"iso 8601 months", nil, "P1M", []string{"document must be a duration such as PT1H30M: durations in years or months have no fixed length"}},
+		{"iso 8601 minutes", nil, "PT1M", nil},
+		{"iso 8601 out of order", nil, "PT1M1H", []string{`document must be a duration such as PT1H30M: duration has an unexpected unit 'H'`}},
+		{"iso 8601 repeated unit", nil, "PT1H1H", []string{`document must be a duration such as PT1H30M: duration has an unexpected unit 'H'`}},
+		{"iso 8601 fraction of hours", nil, "PT1.5H", []string{"document must be a duration such as PT1H30M: only seconds of a duration may have a fraction"}},
+		{"iso 8601 empty", nil, "P", []string{"document must be a duration such as PT1H30M: duration has no components"}},
+		{"iso 8601 trailing T", nil, "P1DT", []string{"document must be a duration such as PT1H30M: duration has a misplaced T"}},
+		{"iso 8601 missing unit", nil, "PT15", []string{`document must be a duration such as PT1H30M: duration has a number without a unit at "15"`}},
+		{"go dialect in iso 8601", nil, "1h30m", []string{"document must be a duration such as PT1H30M: duration does not start with P"}},
+		{"go", GoDuration, "1h30m", nil},
+		{"go fraction", GoDuration, "1.5h", nil},
+		{"iso 8601 in go dialect", GoDuration, "PT1H", []string{`document must be a duration such as 1h30m: time: invalid duration "PT1H"`}},
+		{"not a string", nil, 42, []string{"document must be a string"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := &yema.Type{Kind: yema.String, Format: DurationFormat, FormatArg: tt.arg}
+			errs := Validate(tt.value, schema)
+			assertErrors(t, errs, tt.want)
+
+			compiled, err := Compile(schema)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertErrors(t, compiled.Validate(tt.value), tt.want)
+		})
+	}
+}
+
+func TestNormalizeDuration(t *testing.T) {
+	schema := &yema.Type{Kind: yema.String, Format: DurationFormat}
+	got, errs := Normalize("P1DT1H0.25S", schema)
+	if len(errs) != 0 {
+		t.Fatalf("Normalize() errors = %v", errs)
+	}
+	if want := 25*time.Hour + 250*time.Millisecond; got != want {
+		t.Errorf("Normalize() = %v, want %v", got, want)
+	}
+
+	schema.FormatArg = GoDuration
+	if got, _ := Normalize("90s", schema); got != 90*time.Second {
+		t.Errorf("Normalize() = %v, want 1m30s", got)
+	}
+}
@@ -0,0 +1,146 @@
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// checkEnum reports a value that is not one of the allowed values of an enum.
+// Strings close to an allowed value are reported with a suggestion, to point out typos.
+func (v *validation) checkEnum(value interface{}, enum []interface{}, path func() string) {
+	if len(enum) == 0 {
+		return
+	}
+	for _, allowed := range enum {
+		if enumEqual(value, allowed) {
+			return
+		}
+	}
+
+	if s, ok := value.(string); ok {
+		if hint, ok := suggest(s, enum); ok {
+			v.report(newError(path(), "enum.suggestion", "values", formatEnum(enum), "suggestion", strconv.Quote(hint)))
+			return
+		}
+	}
+	v.report(newError(path(), "enum", "values", formatEnum(enum)))
+}
+
+// enumEqual reports whether a value equals an allowed value of an enum, numbers are compared by value
+// regardless of their Go type
+func enumEqual(value, allowed interface{}) bool {
+	switch a := allowed.(type) {
+	case string:
+		s, ok := value.(string)
+		return ok && s == a
+	case bool:
+		b, ok := value.(bool)
+		return ok && b == a
+	}
+
+	x, ok := toRat(value)
+	if !ok {
+		return false
+	}
+	y, ok := toRat(allowed)
+	return ok && x.Cmp(y) == 0
+}
+
+// toRat returns the exact value of a number, false if value is not a finite number
+func toRat(value interface{}) (*big.Rat, bool) {
+	switch v := value.(type) {
+	case json.Number:
+		return new(big.Rat).SetString(string(v))
+	case int:
+		return new(big.Rat).SetInt64(int64(v)), true
+	case int8:
+		return new(big.Rat).SetInt64(int64(v)), true
+	case int16:
+		return new(big.Rat).SetInt64(int64(v)), true
+	case int32:
+		return new(big.Rat).SetInt64(int64(v)), true
+	case int64:
+		return new(big.Rat).SetInt64(v), true
+	case uint:
+		return new(big.Rat).SetUint64(uint64(v)), true
+	case uint8:
+		return new(big.Rat).SetUint64(uint64(v)), true
+	case uint16:
+		return new(big.Rat).SetUint64(uint64(v)), true
+	case uint32:
+		return new(big.Rat).SetUint64(uint64(v)), true
+	case uint64:
+		return new(big.Rat).SetUint64(v), true
+	case float32:
+		r := new(big.Rat).SetFloat64(float64(v))
+		return r, r != nil
+	case float64:
+		r := new(big.Rat).SetFloat64(v)
+		return r, r != nil
+	}
+	return nil, false
+}
+
+// formatEnum lists the allowed values of an enum for error messages
+func formatEnum(enum []interface{}) string {
+	values := make([]string, len(enum))
+	for i, allowed := range enum {
+		if s, ok := allowed.(string); ok {
+			values[i] = strconv.Quote(s)
+		} else {
+			values[i] = fmt.Sprint(allowed)
+		}
+	}
+	return strings.Join(values, ", ")
+}
+
+// suggest returns the allowed string closest to s by edit distance, if it is close enough to likely be a typo.
+// Of several equally close values the first one declared wins.
+func suggest(s string, enum []interface{}) (string, bool) {
+	best, bestDistance := "", -1
+	for _, allowed := range enum {
+		candidate, ok := allowed.(string)
+		if !ok {
+			continue
+		}
+		d := editDistance(strings.ToLower(s), strings.ToLower(candidate))
+		// Differences in case alone always count as a typo
+		if d == 0 {
+			return candidate, true
+		}
+		if bestDistance < 0 || d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+
+	if bestDistance < 0 || bestDistance > max(1, len([]rune(best))/3) {
+		return "", false
+	}
+	return best, true
+}
+
+// editDistance returns the Levenshtein distance between a and b in runes
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,129 @@
+package validator
+
+import (
+	"strings"
+	"sync"
+)
+
+// FormatFunc checks a value that already matches the kind of its type, returning an error describing the violation
+type FormatFunc func(value interface{}) error
+
+// Registry holds custom validators, either for a format declared with $format or for the values at a path.
+// It is safe for concurrent use.
+type Registry struct {
+	mu      sync.RWMutex
+	formats map[string]FormatFunc
+	paths   map[string][]FormatFunc
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		formats: make(map[string]FormatFunc),
+		paths:   make(map[string][]FormatFunc),
+	}
+}
+
+// defaultRegistry holds the formats registered with RegisterFormat
+var defaultRegistry = NewRegistry()
+
+// RegisterFormat registers fn as the validator of values declaring the format name in every validation,
+// replacing a format of the same name. Formats not registered anywhere are not checked.
+func RegisterFormat(name string, fn FormatFunc) {
+	defaultRegistry.RegisterFormat(name, fn)
+}
+
+// RegisterFormat registers fn as the validator of values declaring the format name, replacing a format of the same name
+func (r *Registry) RegisterFormat(name string, fn FormatFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.formats[name] = fn
+}
+
+// RegisterPath registers fn as an additional validator of the values at path.
+// Paths name fields separated by dots, with [] for the elements of an array, e.g. "items[].id".
+// The empty path is the document itself.
+func (r *Registry) RegisterPath(path string, fn FormatFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.paths[path] = append(r.paths[path], fn)
+}
+
+// format returns the validator of a format, looking in r before the formats registered with RegisterFormat
+func (r *Registry) format(name string) FormatFunc {
+	if r != nil {
+		r.mu.RLock()
+		fn, ok := r.formats[name]
+		r.mu.RUnlock()
+		if ok {
+			return fn
+		}
+	}
+
+	defaultRegistry.mu.RLock()
+	defer defaultRegistry.mu.RUnlock()
+	return defaultRegistry.formats[name]
+}
+
+// path returns the validators of the values at a schema path
+func (r *Registry) path(schemaPath string) []FormatFunc {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.paths[schemaPath]
+}
+
+// hasPaths reports whether any validators were registered by path
+func (r *Registry) hasPaths() bool {
+	if r == nil {
+		return false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.paths) != 0
+}
+
+// schemaPath strips the array indices from a data path, "items[3].id" becomes "items[].id"
+func schemaPath(path string) string {
+	if !strings.Contains(path, "[") {
+		return path
+	}
+
+	var b strings.Builder
+	inIndex := false
+	for _, c := range path {
+		switch {
+		case c == '[':
+			inIndex = true
+			b.WriteString("[]")
+		case c == ']':
+			inIndex = false
+		case !inIndex:
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// checkFormat runs the custom validators of a value that matches its kind
+func (v *validation) checkFormat(value interface{}, format string, arg interface{}, path string) {
+	if format != "" {
+		if fn := v.opts.Registry.format(format); fn != nil {
+			if err := fn(value); err != nil {
+				v.report(wrapError(err, path, "format", "format", format))
+			}
+		} else {
+			v.checkBuiltin(value, format, arg, func() string { return path })
+		}
+	}
+
+	if v.opts.Registry.hasPaths() {
+		for _, fn := range v.opts.Registry.path(schemaPath(path)) {
+			if err := fn(value); err != nil {
+				v.report(wrapError(err, path, "invalid"))
+			}
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package validator
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aep/yema"
+)
+
+func TestFormats(t *testing.T) {
+	RegisterFormat("test-upper", func(value interface{}) error {
+		if s := value.(string); s != strings.ToUpper(s) {
+			return errors.New("must be upper case")
+		}
+		return nil
+	})
+
+	registry := NewRegistry()
+	registry.RegisterFormat("even", func(value interface{}) error {
+		if n, ok := value.(int); ok && n%2 != 0 {
+			return errors.New("odd number")
+		}
+		return nil
+	})
+	registry.RegisterPath("items[].qty", func(value interface{}) error {
+		if value.(int) > 10 {
+			return errors.New("at most 10 may be ordered")
+		}
+		return nil
+	})
+
+	schema := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"code":  {Kind: yema.String, Format: "test-upper"},
+			"count": {Kind: yema.Int, Format: "even"},
+			"other": {Kind: yema.String, Format: "unregistered"},
+			"items": {Kind: yema.Array, Array: &yema.Type{
+				Kind:   yema.Struct,
+				Struct: &map[string]yema.Type{"qty": {Kind: yema.Int}},
+			}},
+		},
+		Order: []string{"code", "count", "other", "items"},
+	}
+
+	data := map[string]interface{}{
+		"code":  "abc",
+		"count": 3,
+		"other": "anything",
+		"items": []interface{}{
+			map[string]interface{}{"qty": 1},
+			map[string]interface{}{"qty": 12},
+		},
+	}
+
+	opts := Options{Registry: registry}
+	want := []string{
+		"field 'code' must be a valid test-upper: must be upper case",
+		"field 'count' must be a valid even: odd number",
+		"field 'items[1].qty' is invalid: at most 10 may be ordered",
+	}
+	errs := ValidateWithOptions(data, schema, opts)
+	assertErrors(t, errs, want)
+
+	compiled, err := CompileWithOptions(schema, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := compiled.Validate(data); !reflect.DeepEqual(errorStrings(got), want) {
+		t.Errorf("compiled validator reported %v, want %v", got, want)
+	}
+
+	// Without the registry only globally registered formats apply
+	assertErrors(t, Validate(data, schema), want[:1])
+
+	// Custom validators do not run on values of the wrong kind
+	data["code"] = 1
+	assertErrors(t, Validate(data, schema), []string{"field 'code' must be a string"})
+}
+
+func TestSchemaPath(t *testing.T) {
+	tests := map[string]string{
+		"":                  "",
+		"a.b":               "a.b",
+		"[0]":               "[]",
+		"items[12].tags[3]": "items[].tags[]",
+	}
+	for path, want := range tests {
+		if got := schemaPath(path); got != want {
+			t.Errorf("schemaPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestBytesEncoding(t *testing.T) {
+	schema := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"b64":   {Kind: yema.Bytes, Format: Base64Encoding},
+			"url":   {Kind: yema.Bytes, Format: Base64URLEncoding},
+			"hex":   {Kind: yema.Bytes, Format: HexEncoding},
+			"plain": {Kind: yema.Bytes},
+		},
+		Order: []string{"b64", "url", "hex", "plain"},
+	}
+	compiled, err := Compile(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		data map[string]interface{}
+		want []string
+	}{
+		{
+			name: "valid",
+			data: map[string]interface{}{"b64": "aGk/Pz8=", "url": "aGk_Pz8", "hex": "DEADbeef", "plain": "not encoded!"},
+		},
+		{
+			name: "raw bytes are not decoded",
+			data: map[string]interface{}{"b64": []byte("!"), "url": []byte("!"), "hex": []byte("!"), "plain": []byte("!")},
+		},
+		{
+			name: "invalid",
+			data: map[string]interface{}{"b64": "aGk_Pz8", "url": "a+b/", "hex": "abc", "plain": "x"},
+			want: []string{
+				"field 'b64' must be valid base64: illegal base64 data at input byte 3",
+				"field 'url' must be valid base64url: illegal base64 data at input byte 1",
+				"field 'hex' must be valid hex: encoding/hex: odd length hex string",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertErrors(t, Validate(tt.data, schema), tt.want)
+			assertErrors(t, compiled.Validate(tt.data), tt.want)
+		})
+	}
+
+	errs := Validate("zz", &yema.Type{Kind: yema.Bytes, Format: HexEncoding})
+	if len(errs) != 1 || CodeOf(errs[0]) != CodeEncoding {
+		t.Errorf("expected an encoding error, got %v", errs)
+	}
+
+	got, errs := Normalize("cafe", &yema.Type{Kind: yema.Bytes, Format: HexEncoding})
+	if len(errs) != 0 || !reflect.DeepEqual(got, []byte{0xca, 0xfe}) {
+		t.Errorf("expected hex to be decoded, got %v %v", got, errs)
+	}
+}
+
+func TestBytesLength(t *testing.T) {
+	schema := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"sum":   {Kind: yema.Bytes, Format: HexEncoding, Length: &yema.Length{Min: 4, Max: 4}},
+			"nonce": {Kind: yema.Bytes, Format: Base64Encoding, Length: &yema.Length{Min: 2, Max: -1}},
+			"plain": {Kind: yema.Bytes, Length: &yema.Length{Min: 0, Max: 3}},
+		},
+		Order: []string{"sum", "nonce", "plain"},
+	}
+	compiled, err := Compile(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		data map[string]interface{}
+		want []string
+	}{
+		{
+			name: "valid",
+			data: map[string]interface{}{"sum": "deadbeef", "nonce": "aGk=", "plain": "abc"},
+		},
+		{
+			name: "raw bytes",
+			data: map[string]interface{}{"sum": []byte{1, 2, 3, 4}, "nonce": []byte{1, 2}, "plain": []byte{}},
+		},
+		{
+			name: "out of bounds",
+			data: map[string]interface{}{"sum": "dead", "nonce": "aA==", "plain": "abcd"},
+			want: []string{
+				"field 'sum' must hold 4 bytes, got 2",
+				"field 'nonce' must hold at least 2 bytes, got 1",
+				"field 'plain' must hold at most 3 bytes, got 4",
+			},
+		},
+		{
+			name: "undecodable strings are not counted",
+			data: map[string]interface{}{"sum": "xx", "nonce": "aGk=", "plain": ""},
+			want: []string{"field 'sum' must be valid hex: encoding/hex: invalid byte: U+0078 'x'"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertErrors(t, Validate(tt.data, schema), tt.want)
+			assertErrors(t, compiled.Validate(tt.data), tt.want)
+		})
+	}
+
+	errs := Validate("cafe", &yema.Type{Kind: yema.Bytes, Format: HexEncoding, Length: &yema.Length{Min: 3, Max: 3}})
+	if len(errs) != 1 || CodeOf(errs[0]) != CodeLength {
+		t.Errorf("expected a length error, got %v", errs)
+	}
+
+	// protojson bytes are base64, their length is that of the decoded bytes
+	protoSchema := &yema.Type{Kind: yema.Bytes, Length: &yema.Length{Min: 0, Max: 2}}
+	assertErrors(t, ValidateWithOptions("AQI", protoSchema, Options{ProtoJSON: true}), nil)
+	assertErrors(t, ValidateWithOptions("AQID", protoSchema, Options{ProtoJSON: true}), []string{"document must hold at most 2 bytes, got 3"})
+}
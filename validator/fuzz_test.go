@@ -0,0 +1,108 @@
+package validator
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aep/yema"
+)
+
+// fuzzSchemas cover every kind, so arbitrary documents reach every branch of the validator
+var fuzzSchemas = []*yema.Type{
+	{Kind: yema.Struct, Struct: &map[string]yema.Type{
+		"s":  {Kind: yema.String, Enum: []interface{}{"a", "b"}},
+		"i":  {Kind: yema.Int8, Optional: true},
+		"u":  {Kind: yema.Uint64},
+		"f":  {Kind: yema.Float32},
+		"b":  {Kind: yema.Bytes, Format: HexEncoding},
+		"a":  {Kind: yema.Array, Array: &yema.Type{Kind: yema.Bool}},
+		"m":  {Kind: yema.Map, Map: &yema.Type{Kind: yema.Int}, KeyPattern: "^[a-z]+$"},
+		"un": {Kind: yema.Union, Union: []yema.Type{{Kind: yema.String}, {Kind: yema.Struct, Struct: &map[string]yema.Type{"x": {Kind: yema.Int}}}}},
+	}},
+	{Kind: yema.Array, Array: &yema.Type{Kind: yema.Array, Array: &yema.Type{Kind: yema.Uint8}}},
+	{Kind: yema.Map, Map: &yema.Type{Kind: yema.Struct, Struct: &map[string]yema.Type{}}},
+}
+
+// FuzzValidate checks that no document, however malformed, makes validation panic
+func FuzzValidate(f *testing.F) {
+	f.Add(`{"s": "a", "u": 18446744073709551615, "f": 1e39, "b": "zz", "a": [true, 1], "m": {"A": 1}, "un": {"x": "y"}}`)
+	f.Add(`[[1, 2], [300], "x"]`)
+	f.Add(`{"a": {"b": 1}}`)
+	f.Add("a: &a [*a]\n")
+	f.Add("<<: {s: a}\ns: 1\n")
+	f.Add(`null`)
+
+	f.Fuzz(func(t *testing.T, document string) {
+		for _, schema := range fuzzSchemas {
+			compiled, err := Compile(schema)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ValidateYAML(strings.NewReader(document), schema)
+			ValidateJSON(strings.NewReader(document), schema)
+			var data interface{}
+			dec := json.NewDecoder(strings.NewReader(document))
+			dec.UseNumber()
+			if dec.Decode(&data) != nil {
+				continue
+			}
+			ValidateWithOptions(data, schema, Options{ProtoJSON: true})
+			compiled.Validate(data)
+			Coerce(data, schema)
+			Normalize(data, schema)
+			ValidateAt(data, schema, document)
+		}
+	})
+}
+
+// Schemas built in Go may hold nil pointers the parser never produces, they are reported instead of panicking
+func TestMalformedSchemas(t *testing.T) {
+	schemas := []*yema.Type{
+		nil,
+		{Kind: yema.Struct},
+		{Kind: yema.Array},
+		{Kind: yema.Map},
+		{Kind: yema.Union},
+		{Kind: yema.Kind(99)},
+		{Kind: yema.Map, Map: &yema.Type{Kind: yema.String}, KeyPattern: "("},
+		{Kind: yema.Struct, Struct: &map[string]yema.Type{"a": {Kind: yema.Array}, "b": {Kind: yema.Struct}, "c": {Kind: yema.Map}}},
+		{Kind: yema.Union, Union: []yema.Type{{Kind: yema.Array}, {Kind: yema.Struct}}},
+	}
+	documents := []interface{}{
+		nil,
+		"x",
+		[]interface{}{1},
+		map[string]interface{}{"a": []interface{}{1}, "b": map[string]interface{}{}, "c": map[string]interface{}{"k": 1}},
+	}
+
+	for _, schema := range schemas {
+		for _, data := range documents {
+			if errs := SafeValidate(data, schema); len(errs) == 1 && errors.Is(errs[0], ErrPanic) {
+				t.Errorf("validating %#v against %+v panicked: %v", data, schema, errs[0])
+			}
+			if compiled, err := Compile(schema); err == nil {
+				compiled.Validate(data)
+			}
+			Coerce(data, schema)
+			Normalize(data, schema)
+			ValidateAt(data, schema, "a[0]")
+		}
+	}
+}
+
+func TestSafeValidate(t *testing.T) {
+	registry := NewRegistry()
+	registry.RegisterFormat("explode", func(value interface{}) error {
+		panic("boom")
+	})
+	schema := &yema.Type{Kind: yema.String, Format: "explode"}
+
+	errs := SafeValidateWithOptions("x", schema, Options{Registry: registry})
+	if len(errs) != 1 || !errors.Is(errs[0], ErrPanic) || !strings.Contains(errs[0].Error(), "boom") {
+		t.Errorf("expected the panic to be reported, got %v", errs)
+	}
+	assertErrors(t, SafeValidate("x", &yema.Type{Kind: yema.Int}), []string{"document must be an integer"})
+}
@@ -0,0 +1,55 @@
+package validator
+
+import "time"
+
+// Hooks observe validations, e.g. to export metrics, see Options.Hooks. Nil functions are not called.
+// Hooks are called synchronously and must be safe for concurrent use if validations run concurrently.
+type Hooks struct {
+	// Document is called once a document was validated
+	Document func(DocumentStats)
+	// Field is called once a field of a struct was validated, with the time spent on it including nested values
+	// and the number of errors it added. Timing every field has a cost, leave it nil unless it is needed.
+	Field func(path string, duration time.Duration, errors int)
+}
+
+// DocumentStats describe the validation of a document
+type DocumentStats struct {
+	// Duration is the time validation took
+	Duration time.Duration
+	// Errors is the number of errors reported
+	Errors int
+	// Values is the number of values checked, a measure of the size of the document
+	Values int
+	// Depth is the deepest nesting of arrays, maps and structs in the document
+	Depth int
+}
+
+// now returns the current time if a hook is set, reading the clock is skipped otherwise
+func (v *validation) now() time.Time {
+	if v.opts.Hooks == nil {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// fieldDone reports a field whose validation started at start, with before errors reported at the time,
+// to the Field hook if one is set
+func (v *validation) fieldDone(path func() string, start time.Time, before int) {
+	if v.opts.Hooks == nil || v.opts.Hooks.Field == nil {
+		return
+	}
+	v.opts.Hooks.Field(path(), time.Since(start), len(v.errors)-before)
+}
+
+// finish reports a validation that started at start to the Document hook, if one is set
+func (v *validation) finish(start time.Time) {
+	if v.opts.Hooks == nil || v.opts.Hooks.Document == nil {
+		return
+	}
+	v.opts.Hooks.Document(DocumentStats{
+		Duration: time.Since(start),
+		Errors:   len(v.errors),
+		Values:   v.values,
+		Depth:    v.maxDepth,
+	})
+}
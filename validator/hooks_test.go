@@ -0,0 +1,73 @@
+package validator
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aep/yema"
+)
+
+func TestHooks(t *testing.T) {
+	schema := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"name": {Kind: yema.String},
+			"tags": {Kind: yema.Array, Array: &yema.Type{Kind: yema.String}},
+			"id":   {Kind: yema.Union, Union: []yema.Type{{Kind: yema.Struct, Struct: &map[string]yema.Type{"x": {Kind: yema.Int}}}, {Kind: yema.Int}}},
+		},
+		Order: []string{"name", "tags", "id"},
+	}
+	data := map[string]interface{}{
+		"name": 1,
+		"tags": []interface{}{"a", 2, "c"},
+		"id":   7,
+	}
+
+	type field struct {
+		path   string
+		errors int
+	}
+	var mu sync.Mutex
+	var docs []DocumentStats
+	var fields []field
+	opts := Options{Hooks: &Hooks{
+		Document: func(stats DocumentStats) {
+			mu.Lock()
+			defer mu.Unlock()
+			if stats.Duration <= 0 {
+				t.Errorf("expected a duration, got %v", stats.Duration)
+			}
+			stats.Duration = 0
+			docs = append(docs, stats)
+		},
+		Field: func(path string, duration time.Duration, errors int) {
+			mu.Lock()
+			defer mu.Unlock()
+			fields = append(fields, field{path, errors})
+		},
+	}}
+
+	compiled, err := CompileWithOptions(schema, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, validate := range []func() []error{
+		func() []error { return ValidateWithOptions(data, schema, opts) },
+		func() []error { return compiled.Validate(data) },
+	} {
+		docs, fields = nil, nil
+		errs := validate()
+
+		// Fields of variants that do not match are not observed
+		wantFields := []field{{"name", 1}, {"tags", 1}, {"id", 0}}
+		if !reflect.DeepEqual(fields, wantFields) {
+			t.Errorf("expected fields %v, got %v", wantFields, fields)
+		}
+		wantDocs := []DocumentStats{{Errors: len(errs), Values: 7, Depth: 2}}
+		if !reflect.DeepEqual(docs, wantDocs) {
+			t.Errorf("expected documents %+v, got %+v", wantDocs, docs)
+		}
+	}
+}
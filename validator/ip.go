@@ -0,0 +1,142 @@
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+)
+
+// Formats of strings holding IP addresses and prefixes. The argument of the formats is the range
+// the address or prefix must lie in, PrivateRange or PublicRange, any range is accepted if none is given.
+const (
+	// IPv4Format strings hold an IPv4 address in dotted decimal form, such as 192.0.2.1
+	IPv4Format = "ipv4"
+	// IPv6Format strings hold an IPv6 address such as 2001:db8::1, without a zone
+	IPv6Format = "ipv6"
+	// CIDRFormat strings hold an IPv4 or IPv6 prefix without host bits, such as 10.0.0.0/8
+	CIDRFormat = "cidr"
+)
+
+// Ranges of IP addresses, the argument of the IP formats
+const (
+	// PrivateRange addresses are in the private ranges of RFC 1918 and RFC 4193,
+	// 10.0.0.0/8, 172.16.0.0/12, 192.168.0.0/16 and fc00::/7
+	PrivateRange = "private"
+	// PublicRange addresses are global unicast addresses outside the private ranges
+	PublicRange = "public"
+)
+
+// privatePrefixes are the private ranges, the same as of netip.Addr.IsPrivate
+var privatePrefixes = []netip.Prefix{
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("172.16.0.0/12"),
+	netip.MustParsePrefix("192.168.0.0/16"),
+	netip.MustParsePrefix("fc00::/7"),
+}
+
+// nonPublicPrefixes are the ranges a public prefix must not overlap, the private ranges and those
+// netip.Addr.IsGlobalUnicast excludes
+var nonPublicPrefixes = append([]netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("127.0.0.0/8"),
+	netip.MustParsePrefix("169.254.0.0/16"),
+	netip.MustParsePrefix("224.0.0.0/4"),
+	netip.MustParsePrefix("255.255.255.255/32"),
+	netip.MustParsePrefix("::/128"),
+	netip.MustParsePrefix("::1/128"),
+	netip.MustParsePrefix("fe80::/10"),
+	netip.MustParsePrefix("ff00::/8"),
+}, privatePrefixes...)
+
+// checkIPv4 reports a string that is not an IPv4 address in the range of its format
+func checkIPv4(value, arg interface{}) *Error {
+	return checkAddr(value, arg, "IPv4", func(addr netip.Addr) error {
+		if !addr.Is4() {
+			return errors.New("not an IPv4 address")
+		}
+		return nil
+	})
+}
+
+// checkIPv6 reports a string that is not an IPv6 address in the range of its format
+func checkIPv6(value, arg interface{}) *Error {
+	return checkAddr(value, arg, "IPv6", func(addr netip.Addr) error {
+		switch {
+		case !addr.Is6():
+			return errors.New("not an IPv6 address")
+		case addr.Zone() != "":
+			return errors.New("addresses with a zone are not allowed")
+		}
+		return nil
+	})
+}
+
+// checkAddr reports a string that is not an address of the version accepted by check, or not in the range of arg
+func checkAddr(value, arg interface{}, version string, check func(netip.Addr) error) *Error {
+	s, ok := value.(string)
+	if !ok {
+		return nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err == nil {
+		err = check(addr)
+	}
+	if err != nil {
+		return wrapError(err, "", "format.ip", "version", version)
+	}
+
+	// IPv4 addresses mapped to IPv6 are in the range of the IPv4 address
+	addr = addr.Unmap()
+	switch arg {
+	case PrivateRange:
+		if !addr.IsPrivate() {
+			return newError("", "format.ip_range", "range", PrivateRange)
+		}
+	case PublicRange:
+		if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+			return newError("", "format.ip_range", "range", PublicRange)
+		}
+	}
+	return nil
+}
+
+// checkCIDR reports a string that is not a prefix in the range of its format
+func checkCIDR(value, arg interface{}) *Error {
+	s, ok := value.(string)
+	if !ok {
+		return nil
+	}
+	prefix, err := netip.ParsePrefix(s)
+	if err == nil && prefix.Masked() != prefix {
+		err = fmt.Errorf("host bits are set, the prefix is %s", prefix.Masked())
+	}
+	if err != nil {
+		return wrapError(err, "", "format.cidr")
+	}
+
+	switch arg {
+	case PrivateRange:
+		for _, private := range privatePrefixes {
+			if private.Bits() <= prefix.Bits() && private.Contains(prefix.Addr()) {
+				return nil
+			}
+		}
+		return newError("", "format.cidr_range", "range", PrivateRange)
+	case PublicRange:
+		for _, other := range nonPublicPrefixes {
+			if other.Overlaps(prefix) {
+				return newError("", "format.cidr_range", "range", PublicRange)
+			}
+		}
+	}
+	return nil
+}
+
+// checkIPRange checks the range of an IP format
+func checkIPRange(arg interface{}) error {
+	switch arg {
+	case PrivateRange, PublicRange:
+		return nil
+	}
+	return fmt.Errorf("expected %s or %s, got %v", PrivateRange, PublicRange, arg)
+}
@@ -0,0 +1,53 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/aep/yema"
+)
+
+func TestIP(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		arg    interface{}
+		value  interface{}
+		want   []string
+	}{
+		{"ipv4", IPv4Format, nil, "192.0.2.1", nil},
+		{"ipv4 leading zero", IPv4Format, nil, "192.0.2.01", []string{`document must be an IPv4 address: ParseAddr("192.0.2.01"): IPv4 field has octet with leading zero`}},
+		{"ipv4 given ipv6", IPv4Format, nil, "2001:db8::1", []string{"document must be an IPv4 address: not an IPv4 address"}},
+		{"ipv4 private", IPv4Format, PrivateRange, "172.16.4.1", nil},
+		{"ipv4 not private", IPv4Format, PrivateRange, "192.0.2.1", []string{"document must be a private address"}},
+		{"ipv4 public", IPv4Format, PublicRange, "192.0.2.1", nil},
+		{"ipv4 loopback not public", IPv4Format, PublicRange, "127.0.0.1", []string{"document must be a public address"}},
+		{"ipv6", IPv6Format, nil, "2001:db8::1", nil},
+		{"ipv6 mapped ipv4", IPv6Format, PrivateRange, "::ffff:10.0.0.1", nil},
+		{"ipv6 zone", IPv6Format, nil, "fe80::1%eth0", []string{"document must be an IPv6 address: addresses with a zone are not allowed"}},
+		{"ipv6 given ipv4", IPv6Format, nil, "10.0.0.1", []string{"document must be an IPv6 address: not an IPv6 address"}},
+		{"ipv6 private", IPv6Format, PrivateRange, "fd12:3456::1", nil},
+		{"cidr", CIDRFormat, nil, "10.0.0.0/8", nil},
+		{"cidr ipv6", CIDRFormat, nil, "2001:db8::/32", nil},
+		{"cidr host bits", CIDRFormat, nil, "10.0.0.1/8", []string{"document must be a CIDR prefix such as 10.0.0.0/8: host bits are set, the prefix is 10.0.0.0/8"}},
+		{"cidr address", CIDRFormat, nil, "10.0.0.1", []string{`document must be a CIDR prefix such as 10.0.0.0/8: netip.ParsePrefix("10.0.0.1"): no '/'`}},
+		{"cidr private", CIDRFormat, PrivateRange, "192.168.1.0/24", nil},
+		{"cidr larger than private range", CIDRFormat, PrivateRange, "172.0.0.0/8", []string{"document must be a prefix of private addresses"}},
+		{"cidr public", CIDRFormat, PublicRange, "192.0.2.0/24", nil},
+		{"cidr overlapping private range", CIDRFormat, PublicRange, "8.0.0.0/5", []string{"document must be a prefix of public addresses"}},
+		{"not a string", IPv4Format, nil, 42, []string{"document must be a string"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := &yema.Type{Kind: yema.String, Format: tt.format, FormatArg: tt.arg}
+			errs := Validate(tt.value, schema)
+			assertErrors(t, errs, tt.want)
+
+			compiled, err := Compile(schema)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertErrors(t, compiled.Validate(tt.value), tt.want)
+		})
+	}
+}
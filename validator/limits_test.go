@@ -0,0 +1,82 @@
+package validator
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aep/yema"
+)
+
+func TestLimits(t *testing.T) {
+	// A list of lists nested 5 levels deep
+	schema := &yema.Type{Kind: yema.Int}
+	var data interface{} = 1
+	for i := 0; i < 5; i++ {
+		schema = &yema.Type{Kind: yema.Array, Array: schema}
+		data = []interface{}{data}
+	}
+
+	tests := []struct {
+		name string
+		data interface{}
+		opts Options
+		want string
+	}{
+		{
+			name: "within limits",
+			data: data,
+		},
+		{
+			name: "too deep",
+			data: data,
+			opts: Options{MaxDepth: 3},
+			want: "limit exceeded: field '[0][0][0]' nests deeper than 3 levels",
+		},
+		{
+			name: "depth limit disabled",
+			data: data,
+			opts: Options{MaxDepth: -1},
+		},
+		{
+			name: "too long",
+			data: []interface{}{[]interface{}{[]interface{}{[]interface{}{[]interface{}{1, 2, 3}}}}},
+			opts: Options{MaxArrayLength: 2},
+			want: "limit exceeded: field '[0][0][0][0]' has 3 elements, more than 2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compiled, err := CompileWithOptions(schema, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, errs := range [][]error{ValidateWithOptions(tt.data, schema, tt.opts), compiled.Validate(tt.data)} {
+				if tt.want == "" {
+					assertErrors(t, errs, nil)
+					continue
+				}
+				assertErrors(t, errs, []string{tt.want})
+				if !errors.Is(errs[0], yema.ErrLimitExceeded) {
+					t.Errorf("error %v does not wrap ErrLimitExceeded", errs[0])
+				}
+			}
+		})
+	}
+}
+
+func TestDefaultDepthLimit(t *testing.T) {
+	schema := &yema.Type{Kind: yema.Int}
+	var data interface{} = 1
+	for i := 0; i < DefaultMaxDepth+1; i++ {
+		schema = &yema.Type{Kind: yema.Array, Array: schema}
+		data = []interface{}{data}
+	}
+
+	errs := Validate(data, schema)
+	if len(errs) != 1 || !errors.Is(errs[0], yema.ErrLimitExceeded) || !strings.Contains(errs[0].Error(), "deeper than 1000 levels") {
+		t.Errorf("expected depth limit error, got %v", errs)
+	}
+}
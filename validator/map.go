@@ -0,0 +1,100 @@
+package validator
+
+import (
+	"container/list"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// maxKeyPatterns is the number of compiled key patterns kept by keyPatterns
+const maxKeyPatterns = 256
+
+// keyPatterns caches the compiled key patterns of map types, so repeated validations compile each pattern once.
+// The least recently used patterns are evicted, schemas built at runtime may declare any number of them.
+var keyPatterns = newPatternCache(maxKeyPatterns)
+
+// patternCache holds compiled regular expressions up to its size, evicting the least recently used one
+type patternCache struct {
+	mu   sync.Mutex
+	size int
+	// order holds the patterns from the most to the least recently used, entries their elements by source
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type cachedPattern struct {
+	pattern string
+	re      *regexp.Regexp
+}
+
+func newPatternCache(size int) *patternCache {
+	return &patternCache{size: size, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+func (c *patternCache) load(pattern string) (*regexp.Regexp, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[pattern]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*cachedPattern).re, true
+}
+
+func (c *patternCache) store(pattern string, re *regexp.Regexp) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[pattern]; ok {
+		c.order.MoveToFront(e)
+		return
+	}
+	c.entries[pattern] = c.order.PushFront(&cachedPattern{pattern, re})
+	if c.order.Len() > c.size {
+		oldest := c.order.Remove(c.order.Back()).(*cachedPattern)
+		delete(c.entries, oldest.pattern)
+	}
+}
+
+// keyPattern returns the compiled form of a map key pattern, nil if keys are unconstrained
+func keyPattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	if re, ok := keyPatterns.load(pattern); ok {
+		return re, nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key pattern %q: %w", pattern, err)
+	}
+	keyPatterns.store(pattern, re)
+	return re, nil
+}
+
+// keyPath returns the path of the value stored under key in the map at path.
+// Keys are written in brackets like array indices, so custom validators registered for "labels[]" apply to every value.
+func keyPath(path, key string) string {
+	return path + "[" + key + "]"
+}
+
+// sortedKeys returns the keys of a map in a stable order, so errors are reported deterministically
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// checkKey reports a key not matching the key pattern of its map
+func (v *validation) checkKey(key string, pattern *regexp.Regexp, path func() string) {
+	if pattern != nil && !pattern.MatchString(key) {
+		v.report(newError(path(), "key_pattern", "pattern", strconv.Quote(pattern.String())))
+	}
+}
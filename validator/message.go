@@ -0,0 +1,211 @@
+package validator
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Error is a validation failure of a value. It is rendered from a message ID and its parameters,
+// so applications can show it in other languages with their own Catalog.
+type Error struct {
+	// Path is the location of the value, empty for the document itself
+	Path string
+	// Code classifies the failure, it is stable while the wording of messages may change
+	Code Code
+	// Message identifies the message, it is the key of its template in a Catalog
+	Message string
+	// Params are substituted for the placeholders of the template, such as {limit}
+	Params map[string]string
+	// Err is the underlying cause, such as the error of a custom validator, nil if there is none
+	Err error
+}
+
+// Error renders the message in English
+func (e *Error) Error() string {
+	return English.Format(e)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// newError returns an Error for the value at path, params are pairs of names and values
+func newError(path, message string, params ...string) *Error {
+	e := &Error{Path: path, Code: messageCodes[message], Message: message}
+	if len(params) > 0 {
+		e.Params = make(map[string]string, len(params)/2)
+		for i := 0; i+1 < len(params); i += 2 {
+			e.Params[params[i]] = params[i+1]
+		}
+	}
+	return e
+}
+
+// wrapError is like newError with an underlying cause, rendered as {cause}
+func wrapError(cause error, path, message string, params ...string) *Error {
+	e := newError(path, message, params...)
+	e.Err = cause
+	return e
+}
+
+// Catalog maps message IDs to templates rendering them. Placeholders in braces are replaced by the parameters
+// of the error, and by {path}, {subject} and {cause}. Templates missing from a catalog fall back to English.
+type Catalog map[string]string
+
+// English is the catalog the errors of the validator render with. Copy it as a starting point for translations.
+var English = Catalog{
+	"subject.document": "document",
+	"subject.field":    "field '{path}'",
+
+	"required":      "required field '{path}' is missing",
+	"null":          "{subject} is nil but not optional",
+	"unknown_field": "unknown field '{path}'",
+	"requires":      "{subject} requires field '{field}'",
+	"conflicts":     "{subject} conflicts with field '{field}'",
+	"unique":        "{subject} duplicates '{first}', items must be unique",
+
+	"type.boolean":              "{subject} must be a boolean",
+	"type.string":               "{subject} must be a string",
+	"type.integer":              "{subject} must be an integer",
+	"type.non_negative_integer": "{subject} must be a non-negative integer",
+	"type.number":               "{subject} must be a number",
+	"type.bytes":                "{subject} must be bytes or string",
+	"type.array":                "{subject} must be an array",
+	"type.map":                  "{subject} must be a map",
+	"type.struct":               "{subject} must be a map[string]interface{}",
+	"type.protojson_integer":    "{subject} must be a string holding an integer, protojson encodes 64-bit integers as strings",
+	"type.protojson_bytes":      "{subject} must be a base64 encoded string",
+
+	"range":           "{subject} value out of range for {type}",
+	"range.signed":    "{subject} value out of range for a signed integer",
+	"range.imprecise": "{subject} is too large to be exact as a float64, decode numbers as json.Number",
+
+	"enum":                "{subject} must be one of {values}",
+	"enum.suggestion":     "{subject} must be one of {values}, did you mean {suggestion}?",
+	"key_pattern":         "key of {subject} must match {pattern}",
+	"encoding":            "{subject} must be valid {encoding}: {cause}",
+	"length":              "{subject} must hold {length} bytes, got {actual}",
+	"length.min":          "{subject} must hold at least {min} bytes, got {actual}",
+	"length.max":          "{subject} must hold at most {max} bytes, got {actual}",
+	"format":              "{subject} must be a valid {format}: {cause}",
+	"format.layout":       "{subject} must be a timestamp in the layout \"{layout}\"",
+	"format.duration":     "{subject} must be a duration such as {example}: {cause}",
+	"format.uuid":         "{subject} must be a UUID such as 123e4567-e89b-42d3-a456-426614174000",
+	"format.uuid_version": "{subject} must be a UUID of version {versions}",
+	"format.uri":          "{subject} must be an absolute URI: {cause}",
+	"format.uri_scheme":   "{subject} must be a URI with scheme {schemes}, got {scheme}",
+	"format.ip":           "{subject} must be an {version} address: {cause}",
+	"format.ip_range":     "{subject} must be a {range} address",
+	"format.cidr":         "{subject} must be a CIDR prefix such as 10.0.0.0/8: {cause}",
+	"format.cidr_range":   "{subject} must be a prefix of {range} addresses",
+	"format.semver":       "{subject} must be a semantic version such as 1.2.3: {cause}",
+	"format.semver_range": "{subject} must be a version in the range \"{range}\"",
+	"invalid":             "{subject} is invalid: {cause}",
+
+	"union":         "{subject} matches no variant of the union",
+	"union.variant": "variant {index} ({kind}): {errors}",
+
+	"limit.depth":   "{cause}: {subject} nests deeper than {limit} levels",
+	"limit.length":  "{cause}: {subject} has {length} elements, more than {limit}",
+	"limit.aliases": "{cause}: {subject} expands to too many nodes through aliases, the limit is {limit}",
+	"canceled":      "validation stopped at {subject}: {cause}",
+	"panic":         "{cause}",
+
+	"syntax.json":          "{subject} is not valid JSON: {cause}",
+	"syntax.trailing":      "{subject} has data after the JSON value",
+	"syntax.yaml":          "{subject} is not valid YAML: {cause}",
+	"syntax.alias":         "{subject} is a recursive alias of &{anchor}",
+	"syntax.duplicate_key": "duplicate key '{path}'",
+	"syntax.merge":         "merge key of {subject} must refer to a mapping or a list of mappings",
+	"syntax.key":           "{subject} has a key that is not a string or number",
+
+	"position": "line {line}, column {column}: {message}",
+}
+
+// Format renders an error with the templates of the catalog.
+// Errors other than Error, UnionError and PositionError, such as an invalid schema, are not localized.
+func (c Catalog) Format(err error) string {
+	switch e := err.(type) {
+	case *Error:
+		params := map[string]string{
+			"path":    e.Path,
+			"subject": c.subject(e.Path),
+		}
+		if e.Err != nil {
+			params["cause"] = e.Err.Error()
+		}
+		for name, value := range e.Params {
+			params[name] = value
+		}
+		return render(c.template(e.Message), params)
+
+	case *UnionError:
+		var b strings.Builder
+		b.WriteString(render(c.template("union"), map[string]string{"path": e.Path, "subject": c.subject(e.Path)}))
+		for i, variant := range e.Variants {
+			errs := make([]string, len(variant.Errors))
+			for j, err := range variant.Errors {
+				errs[j] = c.Format(err)
+			}
+			b.WriteString("; ")
+			b.WriteString(render(c.template("union.variant"), map[string]string{
+				"index":  strconv.Itoa(i + 1),
+				"kind":   variant.Kind.String(),
+				"errors": strings.Join(errs, ", "),
+			}))
+		}
+		return b.String()
+
+	case *PositionError:
+		return render(c.template("position"), map[string]string{
+			"line":    strconv.Itoa(e.Line),
+			"column":  strconv.Itoa(e.Column),
+			"message": c.Format(e.Err),
+		})
+	}
+	return err.Error()
+}
+
+// subject describes the value at path, like the package level subject
+func (c Catalog) subject(path string) string {
+	if path == "" {
+		return c.template("subject.document")
+	}
+	return render(c.template("subject.field"), map[string]string{"path": path})
+}
+
+// template returns the template of a message, falling back to English and then to the message ID
+func (c Catalog) template(message string) string {
+	if t, ok := c[message]; ok {
+		return t
+	}
+	if t, ok := English[message]; ok {
+		return t
+	}
+	return message
+}
+
+// render replaces the placeholders of a template, unknown placeholders are kept as they are
+func render(template string, params map[string]string) string {
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(template, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(template[start:], '}')
+		if end < 0 {
+			break
+		}
+		end += start
+		b.WriteString(template[:start])
+		if value, ok := params[template[start+1:end]]; ok {
+			b.WriteString(value)
+		} else {
+			b.WriteString(template[start : end+1])
+		}
+		template = template[end+1:]
+	}
+	b.WriteString(template)
+	return b.String()
+}
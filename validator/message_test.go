@@ -0,0 +1,85 @@
+package validator
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aep/yema"
+)
+
+func TestCatalog(t *testing.T) {
+	german := Catalog{
+		"subject.document": "Dokument",
+		"subject.field":    "Feld '{path}'",
+		"required":         "Pflichtfeld '{path}' fehlt",
+		"type.string":      "{subject} muss ein Text sein",
+		"range":            "{subject} liegt außerhalb des Bereichs von {type}",
+		"union":            "{subject} passt zu keiner Variante",
+		"union.variant":    "Variante {index} ({kind}): {errors}",
+	}
+	schema := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"name":  {Kind: yema.String},
+			"age":   {Kind: yema.Uint8},
+			"email": {Kind: yema.String},
+			"id":    {Kind: yema.Union, Union: []yema.Type{{Kind: yema.String}, {Kind: yema.Int}}},
+			"tags":  {Kind: yema.Array, Array: &yema.Type{Kind: yema.String}},
+		},
+		Order: []string{"name", "age", "email", "id", "tags"},
+	}
+	data := map[string]interface{}{
+		"name": 1,
+		"age":  300,
+		"id":   true,
+		"tags": "a",
+	}
+
+	errs := Validate(data, schema)
+	var got []string
+	for _, err := range errs {
+		got = append(got, german.Format(err))
+	}
+	want := []string{
+		"Feld 'name' muss ein Text sein",
+		"Feld 'age' liegt außerhalb des Bereichs von uint8",
+		"Pflichtfeld 'email' fehlt",
+		"Feld 'id' passt zu keiner Variante; Variante 1 (string): Feld 'id' muss ein Text sein; Variante 2 (int): Feld 'id' must be an integer",
+		// Messages missing from the catalog fall back to English
+		"Feld 'tags' must be an array",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d errors, got %q", len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("error %d: expected %q, got %q", i, want[i], got[i])
+		}
+	}
+
+	var verr *Error
+	if !errors.As(errs[1], &verr) || verr.Path != "age" || verr.Message != "range" || verr.Params["type"] != "uint8" {
+		t.Errorf("expected a structured range error for 'age', got %#v", errs[1])
+	}
+	if other := errors.New("document is not valid JSON"); german.Format(other) != other.Error() {
+		t.Errorf("expected other errors to render unchanged, got %q", german.Format(other))
+	}
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{"{subject} must be {kind}", "field 'a' must be string"},
+		{"{unknown} stays", "{unknown} stays"},
+		{"must be a map[string]interface{}", "must be a map[string]interface{}"},
+		{"unclosed {subject", "unclosed {subject"},
+	}
+	params := map[string]string{"subject": "field 'a'", "kind": "string"}
+	for _, tt := range tests {
+		if got := render(tt.template, params); got != tt.want {
+			t.Errorf("render(%q) = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package validator
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/aep/yema"
+	"github.com/aep/yema/internal/fieldpath"
+)
+
+// Normalize validates a decoded document and returns a copy holding the canonical Go type of every value,
+// so callers do not need a second conversion pass. The input is left unchanged.
+//
+//   - integers and floats become the Go type of their kind, such as int64 for int64 and uint8 for uint8
+//   - bytes become []byte, strings are decoded in their declared encoding and kept as they are if none is declared,
+//     except with Options.ProtoJSON where they are base64
+//   - strings declaring the date-time format become time.Time, the duration format time.Duration
+//
+// Invalid documents are not converted, the errors of validation are returned instead.
+// With Options.Coerce the coerced document is normalized.
+func Normalize(data interface{}, schema *yema.Type) (interface{}, []error) {
+	return NormalizeWithOptions(data, schema, Options{})
+}
+
+// NormalizeWithOptions is like Normalize with custom validation options
+func NormalizeWithOptions(data interface{}, schema *yema.Type, opts Options) (interface{}, []error) {
+	if schema != nil && opts.Coerce {
+		data = coerce(data, schema)
+	}
+	if errs := ValidateWithOptions(data, schema, opts); len(errs) != 0 {
+		return nil, errs
+	}
+	normalized, err := normalize(data, schema, "", opts)
+	if err != nil {
+		return nil, []error{err}
+	}
+	return normalized, nil
+}
+
+// normalize converts a valid value to the canonical Go type of its kind
+func normalize(value interface{}, schema *yema.Type, path string, opts Options) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	switch schema.Kind {
+	case yema.Int, yema.Int8, yema.Int16, yema.Int32, yema.Int64:
+		i, err := normalizeInt(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", subject(path), err)
+		}
+		switch schema.Kind {
+		case yema.Int:
+			return int(i), nil
+		case yema.Int8:
+			return int8(i), nil
+		case yema.Int16:
+			return int16(i), nil
+		case yema.Int32:
+			return int32(i), nil
+		}
+		return i, nil
+
+	case yema.Uint, yema.Uint8, yema.Uint16, yema.Uint32, yema.Uint64:
+		u, err := normalizeUint(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", subject(path), err)
+		}
+		switch schema.Kind {
+		case yema.Uint:
+			return uint(u), nil
+		case yema.Uint8:
+			return uint8(u), nil
+		case yema.Uint16:
+			return uint16(u), nil
+		case yema.Uint32:
+			return uint32(u), nil
+		}
+		return u, nil
+
+	case yema.Float32, yema.Float64:
+		f, err := normalizeFloat(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", subject(path), err)
+		}
+		if schema.Kind == yema.Float32 {
+			return float32(f), nil
+		}
+		return f, nil
+
+	case yema.Bytes:
+		switch v := value.(type) {
+		case []byte:
+			return append([]byte(nil), v...), nil
+		case string:
+			if b, ok, err := decodeBytes(v, schema.Format); ok {
+				if err != nil {
+					return nil, wrapError(err, path, "encoding", "encoding", schema.Format)
+				}
+				return b, nil
+			}
+			if !opts.ProtoJSON {
+				return []byte(v), nil
+			}
+			for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding} {
+				if b, err := enc.DecodeString(v); err == nil {
+					return b, nil
+				}
+			}
+			return nil, newError(path, "type.protojson_bytes")
+		}
+
+	case yema.String:
+		if s, ok := value.(string); ok && schema.Format == DateTimeFormat {
+			t, err := parseDateTime(s, schema.FormatArg)
+			if err != nil {
+				return nil, wrapError(err, path, "format.layout", "layout", dateTimeLayout(schema.FormatArg))
+			}
+			return t, nil
+		}
+		if s, ok := value.(string); ok && schema.Format == DurationFormat {
+			d, err := parseDuration(s, schema.FormatArg)
+			if err != nil {
+				verr := durationError(err, schema.FormatArg)
+				verr.Path = path
+				return nil, verr
+			}
+			return d, nil
+		}
+
+	case yema.Array:
+		arr := value.([]interface{})
+		out := make([]interface{}, len(arr))
+		for i, elem := range arr {
+			v, err := normalize(elem, schema.Array, path+"["+strconv.Itoa(i)+"]", opts)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = v
+		}
+		return out, nil
+
+	case yema.Map:
+		m := value.(map[string]interface{})
+		out := make(map[string]interface{}, len(m))
+		for key, elem := range m {
+			v, err := normalize(elem, schema.Map, keyPath(path, key), opts)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = v
+		}
+		return out, nil
+
+	case yema.Union:
+		// The value takes the form of the first variant it matches, as in validation
+		for i := range schema.Union {
+			if len(ValidateWithOptions(value, &schema.Union[i], opts)) == 0 {
+				return normalize(value, &schema.Union[i], path, opts)
+			}
+		}
+
+	case yema.Struct:
+		m := value.(map[string]interface{})
+		out := make(map[string]interface{}, len(m))
+		for key, fieldValue := range m {
+			if fieldType, ok := (*schema.Struct)[key]; ok {
+				v, err := normalize(fieldValue, selectType(&fieldType, m), fieldpath.Join(path, key), opts)
+				if err != nil {
+					return nil, err
+				}
+				fieldValue = v
+			}
+			out[key] = fieldValue
+		}
+		return out, nil
+	}
+
+	return value, nil
+}
+
+// normalizeInt returns a valid integer as int64, strings hold integers under protojson
+func normalizeInt(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	case json.Number:
+		return v.Int64()
+	case int:
+		return int64(v), nil
+	case int8:
+		return int64(v), nil
+	case int16:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case uint64:
+		return int64(v), nil
+	case float64:
+		return int64(v), nil
+	}
+	return 0, fmt.Errorf("unexpected integer of type %T", value)
+}
+
+// normalizeUint returns a valid unsigned integer as uint64
+func normalizeUint(value interface{}) (uint64, error) {
+	switch v := value.(type) {
+	case string:
+		return strconv.ParseUint(v, 10, 64)
+	case json.Number:
+		return strconv.ParseUint(string(v), 10, 64)
+	case uint:
+		return uint64(v), nil
+	case uint8:
+		return uint64(v), nil
+	case uint16:
+		return uint64(v), nil
+	case uint32:
+		return uint64(v), nil
+	case uint64:
+		return v, nil
+	case int:
+		return uint64(v), nil
+	case int8:
+		return uint64(v), nil
+	case int16:
+		return uint64(v), nil
+	case int32:
+		return uint64(v), nil
+	case int64:
+		return uint64(v), nil
+	case float64:
+		return uint64(v), nil
+	}
+	return 0, fmt.Errorf("unexpected unsigned integer of type %T", value)
+}
+
+// normalizeFloat returns a valid number as float64, including the special values of protojson
+func normalizeFloat(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case string:
+		switch v {
+		case "NaN":
+			return math.NaN(), nil
+		case "Infinity":
+			return math.Inf(1), nil
+		case "-Infinity":
+			return math.Inf(-1), nil
+		}
+		return strconv.ParseFloat(v, 64)
+	case json.Number:
+		return v.Float64()
+	case float32:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case int8:
+		return float64(v), nil
+	case int16:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case uint:
+		return float64(v), nil
+	case uint8:
+		return float64(v), nil
+	case uint16:
+		return float64(v), nil
+	case uint32:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	}
+	return 0, fmt.Errorf("unexpected number of type %T", value)
+}
@@ -0,0 +1,131 @@
+package validator
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aep/yema"
+)
+
+func TestNormalize(t *testing.T) {
+	schema := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"id":      {Kind: yema.Int64},
+			"port":    {Kind: yema.Uint16},
+			"ratio":   {Kind: yema.Float32},
+			"payload": {Kind: yema.Bytes, Format: Base64Encoding},
+			"created": {Kind: yema.String, Format: DateTimeFormat},
+			"sizes":   {Kind: yema.Array, Array: &yema.Type{Kind: yema.Int8}},
+			"limits":  {Kind: yema.Map, Map: &yema.Type{Kind: yema.Uint}},
+			"ref":     {Kind: yema.Union, Union: []yema.Type{{Kind: yema.String}, {Kind: yema.Int32}}},
+			"note":    {Kind: yema.String, Optional: true},
+		},
+	}
+	data := map[string]interface{}{
+		"id":      json.Number("9007199254740993"),
+		"port":    float64(8080),
+		"ratio":   json.Number("0.5"),
+		"payload": "aGVsbG8=",
+		"created": "2024-05-01T12:00:00Z",
+		"sizes":   []interface{}{1, json.Number("2")},
+		"limits":  map[string]interface{}{"cpu": json.Number("4")},
+		"ref":     json.Number("7"),
+		"note":    nil,
+		"extra":   "kept",
+	}
+
+	got, errs := Normalize(data, schema)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	want := map[string]interface{}{
+		"id":      int64(9007199254740993),
+		"port":    uint16(8080),
+		"ratio":   float32(0.5),
+		"payload": []byte("hello"),
+		"created": time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		"sizes":   []interface{}{int8(1), int8(2)},
+		"limits":  map[string]interface{}{"cpu": uint(4)},
+		"ref":     int32(7),
+		"note":    nil,
+		"extra":   "kept",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %#v, got %#v", want, got)
+	}
+	if data["payload"] != "aGVsbG8=" {
+		t.Errorf("expected the input to be left unchanged, got %v", data["payload"])
+	}
+}
+
+func TestNormalizeErrors(t *testing.T) {
+	_, errs := Normalize(map[string]interface{}{"id": "x"}, &yema.Type{
+		Kind:   yema.Struct,
+		Struct: &map[string]yema.Type{"id": {Kind: yema.Int64}},
+	})
+	assertErrors(t, errs, []string{"field 'id' must be an integer"})
+
+	_, errs = Normalize("not base64!", &yema.Type{Kind: yema.Bytes, Format: Base64Encoding})
+	assertErrors(t, errs, []string{"document must be valid base64: illegal base64 data at input byte 3"})
+
+	_, errs = Normalize("yesterday", &yema.Type{Kind: yema.String, Format: DateTimeFormat})
+	if len(errs) != 1 || CodeOf(errs[0]) != CodeFormat {
+		t.Errorf("expected a format error, got %v", errs)
+	}
+}
+
+func TestNormalizeUnencodedBytes(t *testing.T) {
+	schema := &yema.Type{
+		Kind:   yema.Struct,
+		Struct: &map[string]yema.Type{"raw": {Kind: yema.Bytes}, "packed": {Kind: yema.Bytes}},
+	}
+	data := map[string]interface{}{"raw": "hello world!", "packed": "aGVsbG8="}
+	if errs := Validate(data, schema); len(errs) != 0 {
+		t.Fatalf("unexpected validation errors: %v", errs)
+	}
+	got, errs := Normalize(data, schema)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	want := map[string]interface{}{"raw": []byte("hello world!"), "packed": []byte("aGVsbG8=")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %#v, got %#v", want, got)
+	}
+}
+
+func TestNormalizeCoerce(t *testing.T) {
+	schema := &yema.Type{
+		Kind:   yema.Struct,
+		Struct: &map[string]yema.Type{"flag": {Kind: yema.Bool}, "count": {Kind: yema.Int32}},
+	}
+	got, errs := NormalizeWithOptions(map[string]interface{}{"flag": "true", "count": "3"}, schema, Options{Coerce: true})
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	want := map[string]interface{}{"flag": true, "count": int32(3)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %#v, got %#v", want, got)
+	}
+}
+
+func TestNormalizeProtoJSON(t *testing.T) {
+	schema := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"id":   {Kind: yema.Uint64},
+			"data": {Kind: yema.Bytes},
+		},
+	}
+	got, errs := NormalizeWithOptions(map[string]interface{}{"id": "9007199254740993", "data": "AQI"}, schema, Options{ProtoJSON: true})
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	m := got.(map[string]interface{})
+	if m["id"] != uint64(9007199254740993) || !bytes.Equal(m["data"].([]byte), []byte{1, 2}) {
+		t.Errorf("unexpected result %#v", got)
+	}
+}
@@ -0,0 +1,61 @@
+package validator
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/aep/yema"
+)
+
+// required reports whether a value of the given kind must be present, under protojson only structs are,
+// since proto3 omits fields holding their default value
+func (v *validation) required(kind yema.Kind, optional bool) bool {
+	if optional {
+		return false
+	}
+	return !v.opts.ProtoJSON || kind == yema.Struct
+}
+
+// fromProtoJSON converts a value encoded following the protojson conventions to the value the kind checks expect.
+// 64-bit integers must be strings, other numbers may be strings, and bytes must be base64 encoded,
+// they are decoded so their length is that of the bytes they hold.
+func fromProtoJSON(value interface{}, kind yema.Kind, path string) (interface{}, error) {
+	s, isString := value.(string)
+
+	switch kind {
+	case yema.Int, yema.Int64, yema.Uint, yema.Uint64:
+		if !isString {
+			return nil, newError(path, "type.protojson_integer")
+		}
+		return json.Number(s), nil
+
+	case yema.Int8, yema.Int16, yema.Int32, yema.Uint8, yema.Uint16, yema.Uint32:
+		if isString {
+			return json.Number(s), nil
+		}
+
+	case yema.Float32, yema.Float64:
+		if !isString {
+			break
+		}
+		switch s {
+		case "NaN", "Infinity", "-Infinity":
+			// Special values are valid for every float type
+			return 0.0, nil
+		}
+		return json.Number(s), nil
+
+	case yema.Bytes:
+		if !isString {
+			return nil, newError(path, "type.protojson_bytes")
+		}
+		for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding} {
+			if b, err := enc.DecodeString(s); err == nil {
+				return b, nil
+			}
+		}
+		return nil, newError(path, "type.protojson_bytes")
+	}
+
+	return value, nil
+}
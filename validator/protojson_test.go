@@ -0,0 +1,75 @@
+package validator
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/aep/yema"
+)
+
+func TestValidateProtoJSON(t *testing.T) {
+	schema := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"id":     {Kind: yema.Int64},
+			"count":  {Kind: yema.Uint32},
+			"ratio":  {Kind: yema.Float32},
+			"data":   {Kind: yema.Bytes},
+			"tags":   {Kind: yema.Array, Array: &yema.Type{Kind: yema.Uint64}},
+			"nested": {Kind: yema.Struct, Struct: &map[string]yema.Type{"name": {Kind: yema.String}}},
+		},
+		Order: []string{"id", "count", "ratio", "data", "tags", "nested"},
+	}
+
+	tests := []struct {
+		name string
+		doc  string
+		want []string
+	}{
+		{
+			name: "valid",
+			doc:  `{"id": "-9007199254740993", "count": 3, "ratio": "NaN", "data": "aGk", "tags": ["18"], "nested": {}}`,
+		},
+		{
+			name: "numbers as strings",
+			doc:  `{"count": "3", "ratio": "1.5", "nested": {"name": null}}`,
+		},
+		{
+			name: "defaults may be omitted but messages may not",
+			doc:  `{"id": null}`,
+			want: []string{"required field 'nested' is missing"},
+		},
+		{
+			name: "violations",
+			doc:  `{"id": 1, "count": "x", "ratio": true, "data": "not base64!", "tags": [1], "nested": {}}`,
+			want: []string{
+				"field 'id' must be a string holding an integer, protojson encodes 64-bit integers as strings",
+				"field 'count' must be a non-negative integer",
+				"field 'ratio' must be a number",
+				"field 'data' must be a base64 encoded string",
+				"field 'tags[0]' must be a string holding an integer, protojson encodes 64-bit integers as strings",
+			},
+		},
+	}
+
+	opts := Options{ProtoJSON: true}
+	compiled, err := CompileWithOptions(schema, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data interface{}
+			if err := json.Unmarshal([]byte(tt.doc), &data); err != nil {
+				t.Fatal(err)
+			}
+			errs := ValidateWithOptions(data, schema, opts)
+			assertErrors(t, errs, tt.want)
+			if got := compiled.Validate(data); !reflect.DeepEqual(errorStrings(got), errorStrings(errs)) {
+				t.Errorf("compiled validator reported %v, want %v", got, errs)
+			}
+		})
+	}
+}
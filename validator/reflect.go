@@ -0,0 +1,273 @@
+package validator
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/aep/yema"
+)
+
+// maxDepth bounds the nesting of Go values, so cyclic pointers fail instead of recursing forever
+const maxDepth = 1000
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// ValidateValue checks a Go value, such as a struct, map or slice, against a yema.Type without marshaling it to JSON.
+// Values are read the way encoding/json would encode them: struct fields honor json tags, omitempty and embedding,
+// and types implementing json.Marshaler or encoding.TextMarshaler are validated by their encoding.
+func ValidateValue(v interface{}, schema *yema.Type) []error {
+	return ValidateValueWithOptions(v, schema, Options{})
+}
+
+// ValidateValueWithOptions is like ValidateValue with custom options
+func ValidateValueWithOptions(v interface{}, schema *yema.Type, opts Options) []error {
+	data, err := toData(reflect.ValueOf(v), 0)
+	if err != nil {
+		return []error{err}
+	}
+	return ValidateWithOptions(data, schema, opts)
+}
+
+// toData converts a Go value to the generic representation of a decoded document
+func toData(rv reflect.Value, depth int) (interface{}, error) {
+	if !rv.IsValid() {
+		return nil, nil
+	}
+	if depth > maxDepth {
+		return nil, fmt.Errorf("value of type %s is nested too deeply, it may contain a cycle", rv.Type())
+	}
+
+	if data, ok, err := marshaled(rv); ok {
+		return data, err
+	}
+
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		return toData(rv.Elem(), depth+1)
+
+	case reflect.Bool:
+		return rv.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return rv.Uint(), nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	case reflect.String:
+		return rv.String(), nil
+
+	case reflect.Slice:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return rv.Bytes(), nil
+		}
+		fallthrough
+	case reflect.Array:
+		items := make([]interface{}, rv.Len())
+		for i := range items {
+			item, err := toData(rv.Index(i), depth+1)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+
+	case reflect.Map:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		m := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			key, err := mapKey(iter.Key())
+			if err != nil {
+				return nil, err
+			}
+			value, err := toData(iter.Value(), depth+1)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = value
+		}
+		return m, nil
+
+	case reflect.Struct:
+		m := make(map[string]interface{})
+		if err := structFields(rv, m, depth); err != nil {
+			return nil, err
+		}
+		return m, nil
+	}
+
+	return nil, fmt.Errorf("unsupported Go type %s", rv.Type())
+}
+
+// marshaled returns the encoding of values implementing json.Marshaler or encoding.TextMarshaler
+func marshaled(rv reflect.Value) (interface{}, bool, error) {
+	if rv.Kind() == reflect.Pointer && rv.IsNil() {
+		return nil, false, nil
+	}
+	if rv.Kind() != reflect.Pointer && rv.CanAddr() {
+		// Methods with pointer receivers are used by encoding/json for addressable values as well
+		if ptr := rv.Addr(); ptr.Type().Implements(jsonMarshalerType) || ptr.Type().Implements(textMarshalerType) {
+			rv = ptr
+		}
+	}
+	if !rv.CanInterface() {
+		return nil, false, nil
+	}
+
+	switch m := rv.Interface().(type) {
+	case json.Marshaler:
+		raw, err := m.MarshalJSON()
+		if err != nil {
+			return nil, true, fmt.Errorf("failed marshaling %s: %w", rv.Type(), err)
+		}
+		dec := json.NewDecoder(strings.NewReader(string(raw)))
+		dec.UseNumber()
+		var data interface{}
+		if err := dec.Decode(&data); err != nil {
+			return nil, true, fmt.Errorf("failed decoding json of %s: %w", rv.Type(), err)
+		}
+		return data, true, nil
+	case encoding.TextMarshaler:
+		text, err := m.MarshalText()
+		if err != nil {
+			return nil, true, fmt.Errorf("failed marshaling %s: %w", rv.Type(), err)
+		}
+		return string(text), true, nil
+	}
+
+	return nil, false, nil
+}
+
+// mapKey converts a map key to a string, like encoding/json does for object keys
+func mapKey(k reflect.Value) (string, error) {
+	if k.Kind() == reflect.String {
+		return k.String(), nil
+	}
+	if tm, ok := k.Interface().(encoding.TextMarshaler); ok {
+		text, err := tm.MarshalText()
+		return string(text), err
+	}
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10), nil
+	}
+	return "", fmt.Errorf("unsupported map key type %s", k.Type())
+}
+
+// structFields adds the fields of a struct to m under their json names.
+// Fields of embedded structs are promoted unless a field of the same name exists at a shallower level.
+func structFields(rv reflect.Value, m map[string]interface{}, depth int) error {
+	var embedded []reflect.Value
+
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, tagOpts, _ := strings.Cut(tag, ",")
+
+		fv := rv.Field(i)
+		if sf.Anonymous && name == "" {
+			ft := sf.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if fv.Kind() == reflect.Pointer {
+					if fv.IsNil() {
+						continue
+					}
+					fv = fv.Elem()
+				}
+				embedded = append(embedded, fv)
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = sf.Name
+		}
+		if hasOption(tagOpts, "omitempty") && isEmptyValue(fv) {
+			continue
+		}
+
+		value, err := toData(fv, depth+1)
+		if err != nil {
+			return err
+		}
+		if hasOption(tagOpts, "string") {
+			switch value.(type) {
+			case bool, int64, uint64, float64, string:
+				value = fmt.Sprint(value)
+			}
+		}
+		m[name] = value
+	}
+
+	for _, fv := range embedded {
+		inner := make(map[string]interface{})
+		if err := structFields(fv, inner, depth+1); err != nil {
+			return err
+		}
+		for name, value := range inner {
+			if _, ok := m[name]; !ok {
+				m[name] = value
+			}
+		}
+	}
+
+	return nil
+}
+
+func hasOption(opts, name string) bool {
+	for opts != "" {
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+		if opt == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isEmptyValue reports whether encoding/json omits the value of a field tagged omitempty
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Pointer:
+		return v.IsNil()
+	}
+	return false
+}
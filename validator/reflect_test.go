@@ -0,0 +1,93 @@
+package validator
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aep/yema"
+)
+
+type testBase struct {
+	ID uint64 `json:"id"`
+}
+
+type testUser struct {
+	testBase
+	Name     string            `json:"name"`
+	Nickname *string           `json:"nickname,omitempty"`
+	Age      int               `json:"age,omitempty"`
+	Tags     []string          `json:"tags"`
+	Labels   map[string]string `json:"labels"`
+	Created  time.Time         `json:"created"`
+	Secret   string            `json:"-"`
+	internal string
+}
+
+func TestValidateValue(t *testing.T) {
+	schema := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"id":       {Kind: yema.Int64},
+			"name":     {Kind: yema.String},
+			"nickname": {Kind: yema.String, Optional: true},
+			"age":      {Kind: yema.Uint8},
+			"tags":     {Kind: yema.Array, Array: &yema.Type{Kind: yema.String}},
+			"labels":   {Kind: yema.Struct, Struct: &map[string]yema.Type{"team": {Kind: yema.String}}},
+			"created":  {Kind: yema.String},
+		},
+	}
+
+	valid := testUser{
+		testBase: testBase{ID: 7},
+		Name:     "Bob",
+		Age:      30,
+		Tags:     []string{"a"},
+		Labels:   map[string]string{"team": "x"},
+		Created:  time.Now(),
+	}
+	if errs := ValidateValue(valid, schema); len(errs) != 0 {
+		t.Errorf("unexpected errors %v", errs)
+	}
+	if errs := ValidateValue(&valid, schema); len(errs) != 0 {
+		t.Errorf("unexpected errors for pointer %v", errs)
+	}
+
+	// Age 300 overflows uint8, nil slices are encoded as null
+	invalid := testUser{Name: "Bob", Age: 300, Labels: map[string]string{}}
+	assertErrors(t, ValidateValue(invalid, schema), []string{
+		"field 'age' value out of range for uint8",
+		"required field 'labels.team' is missing",
+		"field 'tags' is nil but not optional",
+	})
+
+	// Zero values of fields tagged omitempty are left out
+	valid.Age = 0
+	assertErrors(t, ValidateValue(valid, schema), []string{"required field 'age' is missing"})
+}
+
+func assertErrors(t *testing.T, errs []error, want []string) {
+	t.Helper()
+	if len(errs) != len(want) {
+		t.Fatalf("got errors %v, want %v", errs, want)
+	}
+	for i, err := range errs {
+		if err.Error() != want[i] {
+			t.Errorf("error %d = %q, want %q", i, err, want[i])
+		}
+	}
+}
+
+func TestValidateValueCycle(t *testing.T) {
+	type node struct {
+		Next *node `json:"next"`
+	}
+	n := &node{}
+	n.Next = n
+
+	schema := &yema.Type{Kind: yema.Struct, Struct: &map[string]yema.Type{}}
+	errs := ValidateValue(n, schema)
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "cycle") {
+		t.Errorf("expected cycle error, got %v", errs)
+	}
+}
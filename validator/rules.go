@@ -0,0 +1,94 @@
+package validator
+
+import (
+	"github.com/aep/yema"
+	"github.com/aep/yema/internal/fieldpath"
+)
+
+// checkRules reports a present field whose required sibling fields are missing or whose conflicting ones are present.
+// A field holding null counts as absent.
+func (v *validation) checkRules(data map[string]interface{}, name string, requires, conflicts []string, path func() string) {
+	if len(requires) == 0 && len(conflicts) == 0 || !present(data, name) {
+		return
+	}
+	for _, other := range requires {
+		if !present(data, other) {
+			v.report(newError(fieldpath.Join(path(), name), "requires", "field", fieldpath.Join(path(), other)))
+		}
+	}
+	for _, other := range conflicts {
+		if present(data, other) {
+			v.report(newError(fieldpath.Join(path(), name), "conflicts", "field", fieldpath.Join(path(), other)))
+		}
+	}
+}
+
+// present reports whether a struct holds a non-null value for a field
+func present(data map[string]interface{}, name string) bool {
+	value, ok := data[name]
+	return ok && value != nil
+}
+
+// condition is the compiled form of a yema.Condition
+type condition struct {
+	equals map[string]interface{}
+	then   *plan
+	els    *plan
+}
+
+func compileCondition(c *yema.Condition, path string, registry *Registry) (*condition, error) {
+	cond := &condition{equals: c.Equals}
+	var err error
+	if c.Then != nil {
+		if cond.then, err = compile(c.Then, path, registry); err != nil {
+			return nil, err
+		}
+	}
+	if c.Else != nil {
+		if cond.els, err = compile(c.Else, path, registry); err != nil {
+			return nil, err
+		}
+	}
+	return cond, nil
+}
+
+// holds reports whether the fields of a struct hold the values a condition compares them with,
+// compared like the values of an enum
+func holds(data map[string]interface{}, equals map[string]interface{}) bool {
+	for name, value := range equals {
+		if !enumEqual(data[name], value) {
+			return false
+		}
+	}
+	return true
+}
+
+// selectType returns the declaration of a struct field that applies to the values of its sibling fields
+func selectType(field *yema.Type, data map[string]interface{}) *yema.Type {
+	for field.If != nil {
+		next := field.If.Else
+		if holds(data, field.If.Equals) {
+			next = field.If.Then
+		}
+		if next == nil {
+			break
+		}
+		field = next
+	}
+	return field
+}
+
+// selectPlan is the compiled equivalent of selectType
+func (p *plan) selectPlan(data map[string]interface{}) *plan {
+	for p.when != nil {
+		next := p.when.els
+		if holds(data, p.when.equals) {
+			next = p.when.then
+		}
+		if next == nil {
+			break
+		}
+		p = next
+	}
+	return p
+}
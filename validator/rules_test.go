@@ -0,0 +1,128 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/aep/yema"
+)
+
+func TestRules(t *testing.T) {
+	schema := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"cert":     {Kind: yema.String, Optional: true, Requires: []string{"key"}},
+			"key":      {Kind: yema.String, Optional: true},
+			"token":    {Kind: yema.String, Optional: true, Conflicts: []string{"password"}},
+			"password": {Kind: yema.String, Optional: true},
+		},
+		Order: []string{"cert", "key", "token", "password"},
+	}
+
+	tests := []struct {
+		name string
+		data map[string]interface{}
+		want []string
+	}{
+		{"empty", map[string]interface{}{}, nil},
+		{"requirement met", map[string]interface{}{"cert": "c", "key": "k"}, nil},
+		{"required only", map[string]interface{}{"key": "k"}, nil},
+		{"requirement missing", map[string]interface{}{"cert": "c"}, []string{"field 'cert' requires field 'key'"}},
+		{"requirement null", map[string]interface{}{"cert": "c", "key": nil}, []string{"field 'cert' requires field 'key'"}},
+		{"null requires nothing", map[string]interface{}{"cert": nil}, nil},
+		{"one of conflicting", map[string]interface{}{"password": "p"}, nil},
+		{"conflict", map[string]interface{}{"token": "t", "password": "p"}, []string{"field 'token' conflicts with field 'password'"}},
+		{"conflict with null", map[string]interface{}{"token": "t", "password": nil}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := Validate(tt.data, schema)
+			assertErrors(t, errs, tt.want)
+
+			compiled, err := Compile(schema)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertErrors(t, compiled.Validate(tt.data), tt.want)
+
+			for _, err := range errs {
+				if code := CodeOf(err); code != CodeRequires && code != CodeConflict {
+					t.Errorf("unexpected code %s of %v", code, err)
+				}
+			}
+		})
+	}
+}
+
+func TestRulesNested(t *testing.T) {
+	schema := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"tls": {Kind: yema.Struct, Struct: &map[string]yema.Type{
+				"cert": {Kind: yema.String, Optional: true, Requires: []string{"key"}},
+				"key":  {Kind: yema.String, Optional: true},
+			}},
+		},
+	}
+	data := map[string]interface{}{"tls": map[string]interface{}{"cert": "c"}}
+	want := []string{"field 'tls.cert' requires field 'tls.key'"}
+
+	assertErrors(t, Validate(data, schema), want)
+	compiled, err := Compile(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertErrors(t, compiled.Validate(data), want)
+}
+
+func TestConditions(t *testing.T) {
+	port := yema.Type{Kind: yema.Uint16, Optional: true}
+	port.If = &yema.Condition{
+		Equals: map[string]interface{}{"protocol": "tcp"},
+		Then:   &yema.Type{Kind: yema.Uint16},
+	}
+	schema := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"protocol": {Kind: yema.String},
+			"port":     port,
+			"size": {Kind: yema.Uint8, Optional: true, If: &yema.Condition{
+				Equals: map[string]interface{}{"protocol": "udp", "large": true},
+				Then:   &yema.Type{Kind: yema.Uint32, Optional: true},
+				Else:   &yema.Type{Kind: yema.Uint8, Optional: true},
+			}},
+			"large": {Kind: yema.Bool, Optional: true},
+		},
+		Order: []string{"protocol", "port", "size", "large"},
+	}
+
+	tests := []struct {
+		name string
+		data map[string]interface{}
+		want []string
+	}{
+		{"tcp with port", map[string]interface{}{"protocol": "tcp", "port": 80}, nil},
+		{"tcp without port", map[string]interface{}{"protocol": "tcp"}, []string{"required field 'port' is missing"}},
+		{"udp without port", map[string]interface{}{"protocol": "udp"}, nil},
+		{"unconditional type", map[string]interface{}{"protocol": "udp", "port": -1}, []string{"field 'port' must be a non-negative integer"}},
+		{"then type", map[string]interface{}{"protocol": "udp", "large": true, "size": 1000}, nil},
+		{"else type", map[string]interface{}{"protocol": "udp", "size": 1000}, []string{"field 'size' value out of range for uint8"}},
+		{"partial condition", map[string]interface{}{"protocol": "tcp", "port": 1, "large": true, "size": 1000}, []string{"field 'size' value out of range for uint8"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertErrors(t, Validate(tt.data, schema), tt.want)
+
+			compiled, err := Compile(schema)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertErrors(t, compiled.Validate(tt.data), tt.want)
+		})
+	}
+
+	// The condition is checked against the coerced values of the fields it depends on
+	coerced := map[string]interface{}{"protocol": "udp", "large": "true", "size": "1000"}
+	assertErrors(t, ValidateWithOptions(coerced, schema, Options{Coerce: true}), nil)
+}
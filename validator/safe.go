@@ -0,0 +1,44 @@
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/aep/yema"
+)
+
+// ErrPanic is wrapped by the error SafeValidate reports for a validation that panicked
+var ErrPanic = errors.New("validation panicked")
+
+// SafeValidate is like Validate, but converts a panic during validation into an *Error wrapping ErrPanic,
+// for servers that must not crash on any input. Validation is not expected to panic, the error carries
+// the stack to report the bug with. Panics of custom validators are recovered the same way.
+func SafeValidate(data interface{}, schema *yema.Type) []error {
+	return SafeValidateWithOptions(data, schema, Options{})
+}
+
+// SafeValidateWithOptions is like SafeValidate with custom options
+func SafeValidateWithOptions(data interface{}, schema *yema.Type, opts Options) (errs []error) {
+	defer func() {
+		if r := recover(); r != nil {
+			errs = []error{panicError(r)}
+		}
+	}()
+	return ValidateWithOptions(data, schema, opts)
+}
+
+// safeValidate is like Validate, converting a panic like SafeValidate does
+func (c *CompiledValidator) safeValidate(data interface{}) (errs []error) {
+	defer func() {
+		if r := recover(); r != nil {
+			errs = []error{panicError(r)}
+		}
+	}()
+	return c.Validate(data)
+}
+
+// panicError returns the error reported for a validation that panicked with r, holding the stack of the panic
+func panicError(r interface{}) *Error {
+	return wrapError(fmt.Errorf("%w: %v\n%s", ErrPanic, r, debug.Stack()), "", "panic")
+}
@@ -0,0 +1,323 @@
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SemverFormat is the format of strings holding a semantic version, such as 1.2.3-rc.1+build.5.
+// The argument of the format is a range the version must lie in, such as ">=1.2.0 <2", any version is accepted
+// if none is given.
+//
+// A range holds comparators separated by spaces, all of which the version must satisfy, and alternatives
+// of them separated by ||. Comparators are a version preceded by =, >, >=, < or <=, by ^ for versions
+// compatible with it or by ~ for its patch versions. Versions of comparators may leave out the minor
+// and patch number, which are then 0, and ^ and ~ accept any value of the numbers left out.
+const SemverFormat = "semver"
+
+// semver is a parsed semantic version, build metadata is dropped as it does not affect precedence
+type semver struct {
+	major, minor, patch uint64
+	pre                 []string
+}
+
+// parseSemver parses a semantic version 2.0.0, without a leading v
+func parseSemver(s string) (semver, error) {
+	var v semver
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		if err := checkIdentifiers(s[i+1:], "build metadata", false); err != nil {
+			return v, err
+		}
+		s = s[:i]
+	}
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		if err := checkIdentifiers(s[i+1:], "pre-release", true); err != nil {
+			return v, err
+		}
+		v.pre = strings.Split(s[i+1:], ".")
+		s = s[:i]
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return v, errors.New("expected major, minor and patch numbers")
+	}
+	numbers := []*uint64{&v.major, &v.minor, &v.patch}
+	for i, part := range parts {
+		n, err := parseVersionNumber(part)
+		if err != nil {
+			return v, err
+		}
+		*numbers[i] = n
+	}
+	return v, nil
+}
+
+// parseVersionNumber parses a number of a version, which must not have leading zeros
+func parseVersionNumber(s string) (uint64, error) {
+	if len(s) > 1 && s[0] == '0' {
+		return 0, fmt.Errorf("number %s has a leading zero", s)
+	}
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	return n, nil
+}
+
+// checkIdentifiers checks the dot-separated identifiers of a pre-release or build metadata,
+// numeric identifiers of pre-releases must not have leading zeros
+func checkIdentifiers(s, name string, numeric bool) error {
+	for _, id := range strings.Split(s, ".") {
+		if id == "" {
+			return fmt.Errorf("%s has an empty identifier", name)
+		}
+		digits := true
+		for i := 0; i < len(id); i++ {
+			c := id[i]
+			switch {
+			case '0' <= c && c <= '9':
+			case 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == '-':
+				digits = false
+			default:
+				return fmt.Errorf("%s has an invalid character %q", name, c)
+			}
+		}
+		if numeric && digits && len(id) > 1 && id[0] == '0' {
+			return fmt.Errorf("%s number %s has a leading zero", name, id)
+		}
+	}
+	return nil
+}
+
+// compare returns -1, 0 or 1 as v precedes, equals or follows w
+func (v semver) compare(w semver) int {
+	for _, pair := range [][2]uint64{{v.major, w.major}, {v.minor, w.minor}, {v.patch, w.patch}} {
+		if pair[0] != pair[1] {
+			if pair[0] < pair[1] {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	// A pre-release precedes its release
+	switch {
+	case len(v.pre) == 0 && len(w.pre) == 0:
+		return 0
+	case len(v.pre) == 0:
+		return 1
+	case len(w.pre) == 0:
+		return -1
+	}
+	for i := 0; i < len(v.pre) && i < len(w.pre); i++ {
+		if c := compareIdentifier(v.pre[i], w.pre[i]); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(v.pre) < len(w.pre):
+		return -1
+	case len(v.pre) > len(w.pre):
+		return 1
+	}
+	return 0
+}
+
+// compareIdentifier compares identifiers of pre-releases, numbers by value and before any other identifier
+func compareIdentifier(a, b string) int {
+	x, errX := strconv.ParseUint(a, 10, 64)
+	y, errY := strconv.ParseUint(b, 10, 64)
+	switch {
+	case errX == nil && errY == nil:
+		if x == y {
+			return 0
+		}
+		if x < y {
+			return -1
+		}
+		return 1
+	case errX == nil:
+		return -1
+	case errY == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// comparator is a bound of a range, the version must compare to v with one of the results in accept
+type comparator struct {
+	v      semver
+	accept [3]bool
+}
+
+// holds reports whether v satisfies the comparator
+func (c comparator) holds(v semver) bool {
+	return c.accept[v.compare(c.v)+1]
+}
+
+// semverRange is a parsed range, a version lies in it if it satisfies all comparators of any alternative
+type semverRange [][]comparator
+
+// comparison operators of ranges, by the results of comparing to their version they accept
+var comparisons = map[string][3]bool{
+	"=":  {false, true, false},
+	">":  {false, false, true},
+	">=": {false, true, true},
+	"<":  {true, false, false},
+	"<=": {true, true, false},
+}
+
+// parseSemverRange parses a range such as ">=1.2.0 <2 || ^3.1"
+func parseSemverRange(s string) (semverRange, error) {
+	var r semverRange
+	for _, alternative := range strings.Split(s, "||") {
+		fields := strings.Fields(alternative)
+		if len(fields) == 0 {
+			return nil, errors.New("range has an empty alternative")
+		}
+		var all []comparator
+		for _, field := range fields {
+			comparators, err := parseComparator(field)
+			if err != nil {
+				return nil, err
+			}
+			all = append(all, comparators...)
+		}
+		r = append(r, all)
+	}
+	return r, nil
+}
+
+// parseComparator parses a comparator of a range, ^ and ~ stand for a lower and an upper bound
+func parseComparator(s string) ([]comparator, error) {
+	op := s[:len(s)-len(strings.TrimLeft(s, "<>=^~"))]
+	v, given, err := parsePartialSemver(s[len(op):])
+	if err != nil {
+		return nil, fmt.Errorf("invalid comparator %q: %w", s, err)
+	}
+
+	switch op {
+	case "", "=", ">", ">=", "<", "<=":
+		if op == "" {
+			op = "="
+		}
+		return []comparator{{v: v, accept: comparisons[op]}}, nil
+	case "^", "~":
+		// The upper bound increments the first number that is not zero for ^, the minor number for ~,
+		// or else the last number given
+		upper := semver{major: v.major + 1}
+		switch {
+		case op == "^" && v.major == 0 && given > 1 && (v.minor != 0 || given == 2):
+			upper = semver{minor: v.minor + 1}
+		case op == "^" && v.major == 0 && given > 2:
+			upper = semver{minor: v.minor, patch: v.patch + 1}
+		case op == "~" && given > 1:
+			upper = semver{major: v.major, minor: v.minor + 1}
+		}
+		// The lowest pre-release of the upper bound is excluded as well
+		upper.pre = []string{"0"}
+		return []comparator{{v: v, accept: comparisons[">="]}, {v: upper, accept: comparisons["<"]}}, nil
+	}
+	return nil, fmt.Errorf("invalid comparator %q: unknown operator %s", s, op)
+}
+
+// parsePartialSemver parses the version of a comparator, which may leave out the minor and patch number,
+// and returns how many numbers it gives
+func parsePartialSemver(s string) (semver, int, error) {
+	core := s
+	if i := strings.IndexAny(s, "-+"); i >= 0 {
+		core = s[:i]
+	}
+	given := strings.Count(core, ".") + 1
+	if given < 3 && core != s {
+		return semver{}, 0, errors.New("pre-releases require major, minor and patch numbers")
+	}
+	for i := given; i < 3; i++ {
+		s += ".0"
+	}
+	if given > 3 {
+		given = 3
+	}
+	v, err := parseSemver(s)
+	return v, given, err
+}
+
+// contains reports whether v lies in the range
+func (r semverRange) contains(v semver) bool {
+	for _, all := range r {
+		matched := true
+		for _, c := range all {
+			if !c.holds(v) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// checkSemver reports a string that is not a semantic version in the range of its format
+func checkSemver(value, arg interface{}) *Error {
+	s, ok := value.(string)
+	if !ok {
+		return nil
+	}
+	v, err := parseSemver(s)
+	if err != nil {
+		return wrapError(err, "", "format.semver")
+	}
+
+	rng, ok := arg.(string)
+	if !ok {
+		return nil
+	}
+	r, err := parseSemverRange(rng)
+	if err != nil || r.contains(v) {
+		return nil
+	}
+	return newError("", "format.semver_range", "range", rng)
+}
+
+// checkSemverRange checks the range of a semver format
+func checkSemverRange(arg interface{}) error {
+	rng, ok := arg.(string)
+	if !ok {
+		return fmt.Errorf("expected a range such as \">=1.2.0 <2\", got %v", arg)
+	}
+	_, err := parseSemverRange(rng)
+	return err
+}
+
+// SemverExample returns a version in the range of a semver format, 1.0.0 if it has none.
+// Candidates are the versions of the comparators and their next patch versions, empty if none of them lies in the range.
+func SemverExample(arg interface{}) string {
+	rng, ok := arg.(string)
+	if !ok {
+		return "1.0.0"
+	}
+	r, err := parseSemverRange(rng)
+	if err != nil {
+		return ""
+	}
+
+	candidates := []semver{{major: 1}}
+	for _, all := range r {
+		for _, c := range all {
+			if len(c.v.pre) == 0 {
+				candidates = append(candidates, c.v, semver{major: c.v.major, minor: c.v.minor, patch: c.v.patch + 1})
+			}
+		}
+	}
+	for _, v := range candidates {
+		if r.contains(v) {
+			return fmt.Sprintf("%d.%d.%d", v.major, v.minor, v.patch)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,90 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/aep/yema"
+)
+
+func TestSemver(t *testing.T) {
+	tests := []struct {
+		name  string
+		arg   interface{}
+		value interface{}
+		want  []string
+	}{
+		{"release", nil, "1.2.3", nil},
+		{"pre-release and build", nil, "1.2.3-rc.1+build.5", nil},
+		{"leading v", nil, "v1.2.3", []string{`document must be a semantic version such as 1.2.3: invalid number "v1"`}},
+		{"missing patch", nil, "1.2", []string{"document must be a semantic version such as 1.2.3: expected major, minor and patch numbers"}},
+		{"leading zero", nil, "1.02.3", []string{"document must be a semantic version such as 1.2.3: number 02 has a leading zero"}},
+		{"empty pre-release", nil, "1.2.3-", []string{"document must be a semantic version such as 1.2.3: pre-release has an empty identifier"}},
+		{"in range", ">=1.2.0 <2", "1.9.0", nil},
+		{"below range", ">=1.2.0 <2", "1.1.9", []string{`document must be a version in the range ">=1.2.0 <2"`}},
+		{"above range", ">=1.2.0 <2", "2.0.0", []string{`document must be a version in the range ">=1.2.0 <2"`}},
+		{"pre-release precedes release", "<2", "2.0.0-rc.1", nil},
+		{"alternative", "^1.2 || ^3", "3.4.5", nil},
+		{"caret", "^1.2.3", "1.99.0", nil},
+		{"caret major", "^1.2.3", "2.0.0", []string{`document must be a version in the range "^1.2.3"`}},
+		{"caret pre-release of next major", "^1.2.3", "2.0.0-alpha", []string{`document must be a version in the range "^1.2.3"`}},
+		{"caret zero minor", "^0.2.3", "0.3.0", []string{`document must be a version in the range "^0.2.3"`}},
+		{"caret zero patch", "^0.0.3", "0.0.4", []string{`document must be a version in the range "^0.0.3"`}},
+		{"tilde", "~1.2.3", "1.2.9", nil},
+		{"tilde minor", "~1.2.3", "1.3.0", []string{`document must be a version in the range "~1.2.3"`}},
+		{"tilde major only", "~1", "1.9.0", nil},
+		{"exact", "1.2.3", "1.2.3+build", nil},
+		{"not a string", nil, 42, []string{"document must be a string"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := &yema.Type{Kind: yema.String, Format: SemverFormat, FormatArg: tt.arg}
+			errs := Validate(tt.value, schema)
+			assertErrors(t, errs, tt.want)
+
+			compiled, err := Compile(schema)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertErrors(t, compiled.Validate(tt.value), tt.want)
+		})
+	}
+}
+
+func TestSemverPrecedence(t *testing.T) {
+	// The example of precedence of the specification, in ascending order
+	versions := []string{"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta", "1.0.0-beta.2",
+		"1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "2.0.0", "2.1.0", "2.1.1"}
+	for i := 1; i < len(versions); i++ {
+		a, err := parseSemver(versions[i-1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := parseSemver(versions[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a.compare(b) != -1 || b.compare(a) != 1 {
+			t.Errorf("%s does not precede %s", versions[i-1], versions[i])
+		}
+	}
+}
+
+func TestSemverExample(t *testing.T) {
+	tests := []struct {
+		arg  interface{}
+		want string
+	}{
+		{nil, "1.0.0"},
+		{">=1.2.0 <2", "1.2.0"},
+		{">2.3.4", "2.3.5"},
+		{"^0.3", "0.3.0"},
+		{"<1 >2", ""},
+	}
+
+	for _, tt := range tests {
+		if got := SemverExample(tt.arg); got != tt.want {
+			t.Errorf("SemverExample(%v) = %q, want %q", tt.arg, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package validator
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"unicode"
+
+	"github.com/aep/yema"
+)
+
+// RecordError is a violation found in a single record of a stream
+type RecordError struct {
+	// Record is the zero-based index of the record in the stream
+	Record int
+	Err    error
+}
+
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("record %d: %s", e.Record, e.Err)
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+// StreamValidator validates a stream of JSON records one at a time, without reading the whole stream into memory.
+// The stream is either newline-delimited JSON or a single top-level JSON array, each element being a record.
+type StreamValidator struct {
+	schema *yema.Type
+	opts   Options
+}
+
+// NewStreamValidator returns a StreamValidator checking every record against schema
+func NewStreamValidator(schema *yema.Type) *StreamValidator {
+	return NewStreamValidatorWithOptions(schema, Options{})
+}
+
+// NewStreamValidatorWithOptions is like NewStreamValidator with custom options.
+// MaxErrors limits the errors reported per record.
+func NewStreamValidatorWithOptions(schema *yema.Type, opts Options) *StreamValidator {
+	return &StreamValidator{schema: schema, opts: opts}
+}
+
+// Validate reads records from r and calls report with the errors of every invalid record.
+// It returns the number of records read, and an error if the stream is not valid JSON,
+// in which case the remaining records cannot be read, or if report is nil.
+//
+// A stream starting with [ is read as a top-level array, unless the schema itself is an array,
+// in which case every record of newline-delimited JSON is an array.
+func (s *StreamValidator) Validate(r io.Reader, report func(errs []*RecordError)) (int, error) {
+	if s.schema == nil || (s.schema.Kind == yema.Struct && s.schema.Struct == nil) {
+		return 0, fmt.Errorf("invalid schema")
+	}
+	if report == nil {
+		return 0, fmt.Errorf("no report function provided")
+	}
+
+	br := bufio.NewReader(r)
+	array, err := startsArray(br)
+	if err != nil {
+		return 0, err
+	}
+	array = array && s.schema.Kind != yema.Array
+
+	dec := json.NewDecoder(br)
+	dec.UseNumber()
+	if array {
+		// Consume the opening bracket
+		if _, err := dec.Token(); err != nil {
+			return 0, err
+		}
+	}
+
+	records := 0
+	for {
+		if array && !dec.More() {
+			break
+		}
+
+		var data interface{}
+		if err := dec.Decode(&data); err != nil {
+			if err == io.EOF && !array {
+				return records, nil
+			}
+			return records, &RecordError{Record: records, Err: fmt.Errorf("record is not valid JSON: %w", err)}
+		}
+
+		if errs := ValidateWithOptions(data, s.schema, s.opts); len(errs) != 0 {
+			recordErrs := make([]*RecordError, len(errs))
+			for i, e := range errs {
+				recordErrs[i] = &RecordError{Record: records, Err: e}
+			}
+			report(recordErrs)
+		}
+		records++
+	}
+
+	// Consume the closing bracket and make sure nothing follows the array
+	if _, err := dec.Token(); err != nil {
+		return records, fmt.Errorf("array is not valid JSON: %w", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return records, fmt.Errorf("stream has data after the JSON array")
+	}
+
+	return records, nil
+}
+
+// startsArray skips leading whitespace and reports whether the stream starts with a JSON array
+func startsArray(r *bufio.Reader) (bool, error) {
+	for {
+		c, _, err := r.ReadRune()
+		if err == io.EOF {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if !unicode.IsSpace(c) {
+			return c == '[', r.UnreadRune()
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package validator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aep/yema"
+)
+
+func TestStreamValidator(t *testing.T) {
+	schema := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"id":   {Kind: yema.Int64},
+			"name": {Kind: yema.String},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		records int
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:    "ndjson",
+			input:   "{\"id\": 1, \"name\": \"a\"}\n{\"id\": \"2\"}\n\n{\"id\": 3, \"name\": \"c\"}\n",
+			records: 3,
+			want: []string{
+				"record 1: field 'id' must be an integer",
+				"record 1: required field 'name' is missing",
+			},
+		},
+		{
+			name:    "array",
+			input:   " [{\"id\": 1, \"name\": \"a\"}, {\"id\": 2, \"name\": 2}]",
+			records: 2,
+			want:    []string{"record 1: field 'name' must be a string"},
+		},
+		{
+			name:    "empty",
+			input:   "",
+			records: 0,
+		},
+		{
+			name:    "empty array",
+			input:   "[]",
+			records: 0,
+		},
+		{
+			name:    "invalid record",
+			input:   "{\"id\": 1, \"name\": \"a\"}\n{\"id\": \n",
+			records: 1,
+			wantErr: true,
+		},
+		{
+			name:    "data after array",
+			input:   "[{\"id\": 1, \"name\": \"a\"}] {}",
+			records: 1,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var errs []error
+			records, err := NewStreamValidator(schema).Validate(strings.NewReader(tt.input), func(recordErrs []*RecordError) {
+				for _, e := range recordErrs {
+					errs = append(errs, e)
+				}
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if records != tt.records {
+				t.Errorf("Validate() read %d records, want %d", records, tt.records)
+			}
+			assertErrors(t, errs, tt.want)
+		})
+	}
+}
+
+func TestStreamValidatorArraySchema(t *testing.T) {
+	schema := &yema.Type{Kind: yema.Array, Array: &yema.Type{Kind: yema.Int}}
+
+	var errs []error
+	records, err := NewStreamValidator(schema).Validate(strings.NewReader("[1, 2]\n[3, \"x\"]\n"), func(recordErrs []*RecordError) {
+		for _, e := range recordErrs {
+			errs = append(errs, e)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if records != 2 {
+		t.Errorf("read %d records, want 2", records)
+	}
+	assertErrors(t, errs, []string{"record 1: field '[1]' must be an integer"})
+}
+
+func TestStreamValidatorNilReport(t *testing.T) {
+	schema := &yema.Type{Kind: yema.Int}
+
+	records, err := NewStreamValidator(schema).Validate(strings.NewReader("\"x\"\n"), nil)
+	if err == nil || records != 0 {
+		t.Errorf("expected a nil report function to be rejected, got %d records and %v", records, err)
+	}
+}
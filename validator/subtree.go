@@ -0,0 +1,193 @@
+package validator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aep/yema"
+	"github.com/aep/yema/internal/fieldpath"
+)
+
+// ValidateAt checks only the value at path in a decoded document against the matching part of the schema,
+// for re-checking a single field of a form or a part of a document that changed.
+// The path uses the syntax of error messages, such as spec.containers[0] or labels[app],
+// and errors name the full path of values.
+func ValidateAt(data interface{}, schema *yema.Type, path string) []error {
+	return ValidateAtWithOptions(data, schema, path, Options{})
+}
+
+// ValidateAtWithOptions is like ValidateAt with custom options
+func ValidateAtWithOptions(data interface{}, schema *yema.Type, path string, opts Options) []error {
+	if schema == nil || (schema.Kind == yema.Struct && schema.Struct == nil) {
+		return []error{fmt.Errorf("invalid schema")}
+	}
+
+	segments, err := splitPath(path)
+	if err != nil {
+		return []error{err}
+	}
+
+	value, exists := data, true
+	current := ""
+	for _, seg := range segments {
+		for schema.Kind == yema.Union {
+			if schema = unionVariantAt(value, schema, seg, opts); schema == nil {
+				return []error{fmt.Errorf("no variant of the union at %s has %s", subject(current), seg.describe())}
+			}
+		}
+
+		switch schema.Kind {
+		case yema.Struct:
+			if seg.bracket || schema.Struct == nil {
+				return []error{fmt.Errorf("%s is a struct, it has no %s", subject(current), seg.describe())}
+			}
+			fieldType, ok := (*schema.Struct)[seg.name]
+			if !ok {
+				return []error{fmt.Errorf("schema has no field '%s'", fieldpath.Join(current, seg.name))}
+			}
+			schema = &fieldType
+			if siblings, ok := value.(map[string]interface{}); ok {
+				schema = selectType(schema, siblings)
+			}
+			current = fieldpath.Join(current, seg.name)
+
+		case yema.Array:
+			index, err := strconv.Atoi(seg.name)
+			if !seg.bracket || err != nil || index < 0 || schema.Array == nil {
+				return []error{fmt.Errorf("%s is an array, it has no %s", subject(current), seg.describe())}
+			}
+			schema = schema.Array
+			current = current + "[" + seg.name + "]"
+
+		case yema.Map:
+			if !seg.bracket || schema.Map == nil {
+				return []error{fmt.Errorf("%s is a map, it has no %s", subject(current), seg.describe())}
+			}
+			schema = schema.Map
+			current = keyPath(current, seg.name)
+
+		default:
+			return []error{fmt.Errorf("%s is a %s, it has no %s", subject(current), schema.Kind, seg.describe())}
+		}
+
+		value, exists = child(value, seg)
+	}
+
+	if !exists {
+		// A value missing from the document is only an error if it is required, like a missing field
+		v := &validation{opts: opts}
+		start := v.now()
+		if v.required(schema.Kind, schema.Optional) {
+			v.report(newError(current, "required"))
+		}
+		v.finish(start)
+		return v.errors
+	}
+	return validateDocument(nil, value, schema, current, opts)
+}
+
+// step is a segment of a path given to ValidateAt, a field name or the index or key in brackets
+type step struct {
+	name    string
+	bracket bool
+}
+
+func (s step) describe() string {
+	if s.bracket {
+		return "element [" + s.name + "]"
+	}
+	return "field '" + s.name + "'"
+}
+
+// splitPath splits a path such as spec.containers[0] into its segments
+func splitPath(path string) ([]step, error) {
+	var segments []step
+	rest := path
+	for rest != "" {
+		switch {
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("path %q has an unclosed bracket", path)
+			}
+			segments = append(segments, step{name: rest[1:end], bracket: true})
+			rest = rest[end+1:]
+			if strings.HasPrefix(rest, ".") {
+				rest = rest[1:]
+				if rest == "" || rest[0] == '[' || rest[0] == '.' {
+					return nil, fmt.Errorf("path %q has an empty field name", path)
+				}
+			}
+
+		default:
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("path %q has an empty field name", path)
+			}
+			segments = append(segments, step{name: rest[:end]})
+			rest = rest[end:]
+			if strings.HasPrefix(rest, ".") {
+				rest = rest[1:]
+				if rest == "" {
+					return nil, fmt.Errorf("path %q has an empty field name", path)
+				}
+			}
+		}
+	}
+	return segments, nil
+}
+
+// child returns the value of a segment in value, false if value does not hold it
+func child(value interface{}, seg step) (interface{}, bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		elem, ok := v[seg.name]
+		return elem, ok
+	case []interface{}:
+		index, err := strconv.Atoi(seg.name)
+		if !seg.bracket || err != nil || index < 0 || index >= len(v) {
+			return nil, false
+		}
+		return v[index], true
+	}
+	return nil, false
+}
+
+// unionVariantAt returns the first variant of a union the segment leads into, preferring variants
+// the value matches with opts, nil if no variant has the segment
+func unionVariantAt(value interface{}, union *yema.Type, seg step, opts Options) *yema.Type {
+	var fallback *yema.Type
+	for i := range union.Union {
+		variant := &union.Union[i]
+		switch {
+		case variant.Kind == yema.Struct && !seg.bracket && variant.Struct != nil:
+			if _, ok := (*variant.Struct)[seg.name]; !ok {
+				continue
+			}
+		case variant.Kind == yema.Array && seg.bracket:
+			if _, err := strconv.Atoi(seg.name); err != nil {
+				continue
+			}
+		case variant.Kind == yema.Map && seg.bracket:
+		default:
+			continue
+		}
+		probe := (&validation{opts: opts}).variant()
+		if opts.Coerce {
+			probe.validateValue(coerce(value, variant), variant, "")
+		} else {
+			probe.validateValue(value, variant, "")
+		}
+		if len(probe.errors) == 0 {
+			return variant
+		}
+		if fallback == nil {
+			fallback = variant
+		}
+	}
+	return fallback
+}
@@ -0,0 +1,98 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/aep/yema"
+)
+
+func TestValidateAt(t *testing.T) {
+	container := yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"image": {Kind: yema.String},
+			"port":  {Kind: yema.Uint16, Optional: true},
+		},
+		Order: []string{"image", "port"},
+	}
+	schema := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"name": {Kind: yema.String},
+			"spec": {Kind: yema.Struct, Struct: &map[string]yema.Type{
+				"containers": {Kind: yema.Array, Array: &container},
+			}},
+			"labels": {Kind: yema.Map, Map: &yema.Type{Kind: yema.String}},
+			"owner": {Kind: yema.Union, Union: []yema.Type{
+				{Kind: yema.String},
+				{Kind: yema.Struct, Struct: &map[string]yema.Type{"email": {Kind: yema.String}}},
+			}},
+		},
+	}
+	data := map[string]interface{}{
+		"name": 1,
+		"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{"image": "nginx", "port": 70000},
+				map[string]interface{}{"image": "redis"},
+			},
+		},
+		"labels": map[string]interface{}{"team": 7},
+		"owner":  map[string]interface{}{"email": 1},
+	}
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"spec.containers[0]", []string{"field 'spec.containers[0].port' value out of range for uint16"}},
+		{"spec.containers[1]", nil},
+		{"spec.containers[0].image", nil},
+		{"spec.containers[2]", []string{"required field 'spec.containers[2]' is missing"}},
+		{"spec.containers[2].port", nil},
+		{"labels[team]", []string{"field 'labels[team]' must be a string"}},
+		{"owner.email", []string{"field 'owner.email' must be a string"}},
+		{"", []string{
+			"field 'labels[team]' must be a string",
+			"field 'name' must be a string",
+			"field 'owner' matches no variant of the union; variant 1 (string): field 'owner' must be a string; variant 2 (struct): field 'owner.email' must be a string",
+			"field 'spec.containers[0].port' value out of range for uint16",
+		}},
+		{"spec.volumes", []string{"schema has no field 'spec.volumes'"}},
+		{"spec.containers.image", []string{"field 'spec.containers' is an array, it has no field 'image'"}},
+		{"name[0]", []string{"field 'name' is a string, it has no element [0]"}},
+		{"spec..containers", []string{`path "spec..containers" has an empty field name`}},
+		{"labels[team", []string{`path "labels[team" has an unclosed bracket`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assertErrors(t, ValidateAt(data, schema, tt.path), tt.want)
+		})
+	}
+}
+
+func TestValidateAtOptions(t *testing.T) {
+	schema := &yema.Type{
+		Kind: yema.Union,
+		Union: []yema.Type{
+			{Kind: yema.Struct, Struct: &map[string]yema.Type{"id": {Kind: yema.Bool}, "tag": {Kind: yema.String}}},
+			{Kind: yema.Struct, Struct: &map[string]yema.Type{"id": {Kind: yema.Int64}}},
+		},
+	}
+	data := map[string]interface{}{"id": "5"}
+
+	// Only protojson makes the string a 64-bit integer, which selects the second variant
+	assertErrors(t, ValidateAt(data, schema, "id"), []string{"field 'id' must be a boolean"})
+
+	var docs []DocumentStats
+	opts := Options{ProtoJSON: true, Hooks: &Hooks{Document: func(stats DocumentStats) { docs = append(docs, stats) }}}
+	assertErrors(t, ValidateAtWithOptions(data, schema, "id", opts), nil)
+	assertErrors(t, ValidateAtWithOptions(data, schema, "missing", opts), []string{"no variant of the union at document has field 'missing'"})
+	assertErrors(t, ValidateAtWithOptions(map[string]interface{}{}, schema, "id", opts), nil)
+
+	// Selecting a variant is not observed, the value at the path is
+	if len(docs) != 2 || docs[0].Values != 1 || docs[0].Errors != 0 {
+		t.Errorf("expected a document for every validated value, got %+v", docs)
+	}
+}
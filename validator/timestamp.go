@@ -0,0 +1,48 @@
+package validator
+
+import (
+	"fmt"
+	"time"
+)
+
+// DateTimeFormat is the format of strings holding a timestamp, Normalize converts them to time.Time.
+// The argument of the format is the layout of timestamps, RFC 3339 if none is given.
+const DateTimeFormat = "date-time"
+
+// dateTimeLayout returns the layout of a date-time format, RFC 3339 unless the schema declares another one
+func dateTimeLayout(arg interface{}) string {
+	if layout, ok := arg.(string); ok {
+		return layout
+	}
+	return time.RFC3339
+}
+
+// parseDateTime parses a timestamp in the layout of a date-time format
+func parseDateTime(s string, arg interface{}) (time.Time, error) {
+	return time.Parse(dateTimeLayout(arg), s)
+}
+
+// checkDateTime reports a string that is not a timestamp in the layout of its date-time format
+func checkDateTime(value, arg interface{}) *Error {
+	s, ok := value.(string)
+	if !ok {
+		return nil
+	}
+	if _, err := parseDateTime(s, arg); err != nil {
+		return wrapError(err, "", "format.layout", "layout", dateTimeLayout(arg))
+	}
+	return nil
+}
+
+// checkLayout checks the layout of a date-time format, in the notation of package time such as 2006-01-02
+func checkLayout(arg interface{}) error {
+	layout, ok := arg.(string)
+	if !ok || layout == "" {
+		return fmt.Errorf("expected a layout string such as \"2006-01-02\", quoted in YAML, got %v", arg)
+	}
+	// A layout without any element of the reference time only matches itself
+	if time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC).Format(layout) == layout {
+		return fmt.Errorf("layout %q holds no element of the reference time Mon Jan 2 15:04:05 MST 2006", layout)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package validator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aep/yema"
+)
+
+func TestDateTime(t *testing.T) {
+	tests := []struct {
+		name  string
+		arg   interface{}
+		value interface{}
+		want  []string
+	}{
+		{"rfc 3339", nil, "2024-05-01T10:00:00Z", nil},
+		{"rfc 3339 with fraction and offset", nil, "2024-05-01T10:00:00.123+02:00", nil},
+		{"rfc 3339 date only", nil, "2024-05-01", []string{`document must be a timestamp in the layout "2006-01-02T15:04:05Z07:00"`}},
+		{"layout", time.DateOnly, "2024-05-01", nil},
+		{"layout invalid date", time.DateOnly, "2024-13-01", []string{`document must be a timestamp in the layout "2006-01-02"`}},
+		{"layout full timestamp", time.DateOnly, "2024-05-01T10:00:00Z", []string{`document must be a timestamp in the layout "2006-01-02"`}},
+		{"not a string", nil, 42, []string{"document must be a string"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := &yema.Type{Kind: yema.String, Format: DateTimeFormat, FormatArg: tt.arg}
+			errs := Validate(tt.value, schema)
+			assertErrors(t, errs, tt.want)
+
+			compiled, err := Compile(schema)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertErrors(t, compiled.Validate(tt.value), tt.want)
+		})
+	}
+}
+
+func TestDateTimeRegistered(t *testing.T) {
+	// A registered validator replaces the built-in format
+	registry := NewRegistry()
+	registry.RegisterFormat(DateTimeFormat, func(value interface{}) error { return nil })
+	schema := &yema.Type{Kind: yema.String, Format: DateTimeFormat}
+	assertErrors(t, ValidateWithOptions("yesterday", schema, Options{Registry: registry}), nil)
+}
+
+func TestCheckFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		arg     interface{}
+		kind    yema.Kind
+		wantErr bool
+	}{
+		{"custom format", "ulid", nil, yema.String, false},
+		{"custom format with argument", "ulid", "x", yema.String, true},
+		{"date-time", DateTimeFormat, nil, yema.String, false},
+		{"date-time layout", DateTimeFormat, "02.01.2006", yema.String, false},
+		{"date-time on integer", DateTimeFormat, nil, yema.Int64, true},
+		{"layout without elements", DateTimeFormat, "today", yema.String, true},
+		{"layout of another type", DateTimeFormat, 2006, yema.String, true},
+		{"duration", DurationFormat, nil, yema.String, false},
+		{"duration dialect", DurationFormat, GoDuration, yema.String, false},
+		{"unknown duration dialect", DurationFormat, "rfc", yema.String, true},
+		{"ip range", CIDRFormat, PrivateRange, yema.String, false},
+		{"unknown ip range", IPv4Format, "internal", yema.String, true},
+		{"semver range", SemverFormat, ">=1.2.0 <2 || ^3", yema.String, false},
+		{"semver range with empty alternative", SemverFormat, "^1 ||", yema.String, true},
+		{"semver range with unknown operator", SemverFormat, "=>1.2", yema.String, true},
+		{"semver range of another type", SemverFormat, 1, yema.String, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckFormat(tt.format, tt.arg, tt.kind); (err != nil) != tt.wantErr {
+				t.Errorf("CheckFormat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package validator
+
+import (
+	"fmt"
+
+	"github.com/aep/yema"
+)
+
+// UnionError is reported for a value that matches no variant of a union.
+// It holds the errors of every variant, so callers can tell how close the value came to each of them.
+type UnionError struct {
+	// Path is the location of the value, empty for the document itself
+	Path string
+	// Variants are the outcomes of the variants of the union, in declaration order
+	Variants []VariantError
+}
+
+// VariantError holds the errors of a single variant of a union
+type VariantError struct {
+	Kind   yema.Kind
+	Errors []error
+}
+
+// Error renders the message in English
+func (e *UnionError) Error() string {
+	return English.Format(e)
+}
+
+// Unwrap returns the errors of all variants, so errors.Is finds errors such as yema.ErrLimitExceeded
+func (e *UnionError) Unwrap() []error {
+	var errs []error
+	for _, variant := range e.Variants {
+		errs = append(errs, variant.Errors...)
+	}
+	return errs
+}
+
+// variant returns a validation for trying a variant of a union at the current depth.
+// Its errors are only reported if no variant matches, so the error cap does not apply to it,
+// though under FailFast it stops at its first error.
+func (v *validation) variant() *validation {
+	sub := &validation{opts: v.opts, depth: v.depth, ctx: v.ctx}
+	sub.opts.MaxErrors = 0
+	// Variants that do not match are not part of the document, so they are not observed
+	sub.opts.Hooks = nil
+	return sub
+}
+
+// validateUnion checks a value against the variants of a union in order, stopping at the first match
+func (v *validation) validateUnion(value interface{}, schema *yema.Type, path string) {
+	if len(schema.Union) == 0 {
+		v.report(fmt.Errorf("union type definition for '%s' has no variants", path))
+		return
+	}
+
+	unionErr := &UnionError{Path: path}
+	for i := range schema.Union {
+		sub := v.variant()
+		sub.validateValue(value, &schema.Union[i], path)
+		if len(sub.errors) == 0 {
+			return
+		}
+		unionErr.Variants = append(unionErr.Variants, VariantError{Kind: schema.Union[i].Kind, Errors: sub.errors})
+	}
+	v.report(unionErr)
+}
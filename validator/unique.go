@@ -0,0 +1,187 @@
+package validator
+
+import (
+	"bytes"
+	"encoding/json"
+	"hash/maphash"
+	"math"
+	"reflect"
+	"strconv"
+)
+
+// checkUnique reports elements of an array that equal an earlier element. Elements are compared by value,
+// so structs and maps are equal regardless of the order of their keys and numbers regardless of their Go type.
+// Elements are hashed first, only elements with the same hash are compared, to stay linear for large arrays.
+func (v *validation) checkUnique(arr []interface{}, path func(i int) string) {
+	if len(arr) < 2 {
+		return
+	}
+
+	var h maphash.Hash
+	// first holds the index of the first element of each hash, more the other distinct elements
+	// of a hash, which only exist if hashes collide
+	first := make(map[uint64]int, len(arr))
+	var more map[uint64][]int
+	for i, elem := range arr {
+		h.Reset()
+		hashValue(&h, elem)
+		sum := h.Sum64()
+
+		j, ok := first[sum]
+		if !ok {
+			first[sum] = i
+			continue
+		}
+		if equalValues(arr[j], elem) {
+			v.report(newError(path(i), "unique", "first", path(j)))
+			continue
+		}
+		duplicate := false
+		for _, j := range more[sum] {
+			if equalValues(arr[j], elem) {
+				v.report(newError(path(i), "unique", "first", path(j)))
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			if more == nil {
+				more = make(map[uint64][]int)
+			}
+			more[sum] = append(more[sum], i)
+		}
+	}
+}
+
+// hashValue writes a value to h such that values equal by equalValues hash the same
+func hashValue(h *maphash.Hash, value interface{}) {
+	switch v := value.(type) {
+	case nil:
+		h.WriteByte('n')
+	case bool:
+		if v {
+			h.WriteByte('t')
+		} else {
+			h.WriteByte('f')
+		}
+	case string:
+		writeString(h, v)
+	case []byte:
+		h.WriteByte('b')
+		writeInt(h, int64(len(v)))
+		h.Write(v)
+	case []interface{}:
+		h.WriteByte('a')
+		writeInt(h, int64(len(v)))
+		for _, elem := range v {
+			hashValue(h, elem)
+		}
+	case map[string]interface{}:
+		// Entries are hashed on their own and summed, so the hash does not depend on the order of keys
+		var sum uint64
+		var entry maphash.Hash
+		entry.SetSeed(h.Seed())
+		for key, elem := range v {
+			entry.Reset()
+			writeString(&entry, key)
+			hashValue(&entry, elem)
+			sum += entry.Sum64()
+		}
+		h.WriteByte('m')
+		writeInt(h, int64(len(v)))
+		writeInt(h, int64(sum))
+	default:
+		// Numbers hash by their exact value, other values only by their type and are told apart by equalValues
+		h.WriteByte('d')
+		if !hashNumber(h, value) {
+			h.WriteString(reflect.TypeOf(value).String())
+		}
+	}
+}
+
+// hashNumber writes a number to h such that numbers of equal value hash the same, false if value is not a finite number.
+// Integers, the common case, are written without the cost of an exact fraction.
+func hashNumber(h *maphash.Hash, value interface{}) bool {
+	switch n := value.(type) {
+	case int:
+		writeInt(h, int64(n))
+		return true
+	case int64:
+		writeInt(h, n)
+		return true
+	case float64:
+		if n == math.Trunc(n) && math.Abs(n) < maxExactFloat {
+			writeInt(h, int64(n))
+			return true
+		}
+	case json.Number:
+		if i, err := strconv.ParseInt(string(n), 10, 64); err == nil {
+			writeInt(h, i)
+			return true
+		}
+	}
+	r, ok := toRat(value)
+	if !ok {
+		return false
+	}
+	if r.IsInt() && r.Num().IsInt64() {
+		writeInt(h, r.Num().Int64())
+	} else {
+		h.WriteString(r.RatString())
+	}
+	return true
+}
+
+// writeString writes a string to h, prefixed by its length to keep consecutive strings apart
+func writeString(h *maphash.Hash, s string) {
+	h.WriteByte('s')
+	writeInt(h, int64(len(s)))
+	h.WriteString(s)
+}
+
+// writeInt writes an integer to h without allocating
+func writeInt(h *maphash.Hash, i int64) {
+	maphash.WriteComparable(h, i)
+}
+
+// equalValues reports whether two decoded values are deeply equal, numbers are compared by value
+func equalValues(a, b interface{}) bool {
+	switch x := a.(type) {
+	case nil:
+		return b == nil
+	case []byte:
+		y, ok := b.([]byte)
+		return ok && bytes.Equal(x, y)
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !equalValues(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for key, xv := range x {
+			yv, ok := y[key]
+			if !ok || !equalValues(xv, yv) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if enumEqual(a, b) {
+		return true
+	}
+	if _, ok := toRat(a); ok {
+		return false
+	}
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,88 @@
+package validator
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"github.com/aep/yema"
+)
+
+func TestUnique(t *testing.T) {
+	point := &yema.Type{Kind: yema.Struct, Struct: &map[string]yema.Type{
+		"x":    {Kind: yema.Float64},
+		"tags": {Kind: yema.Map, Optional: true, Map: &yema.Type{Kind: yema.String}},
+	}}
+
+	tests := []struct {
+		name  string
+		items *yema.Type
+		value []interface{}
+		want  []string
+	}{
+		{"distinct strings", &yema.Type{Kind: yema.String}, []interface{}{"a", "b", "c"}, nil},
+		{"duplicate strings", &yema.Type{Kind: yema.String}, []interface{}{"a", "b", "a", "a"}, []string{
+			"field 'items[2]' duplicates 'items[0]', items must be unique",
+			"field 'items[3]' duplicates 'items[0]', items must be unique",
+		}},
+		{"numbers by value", &yema.Type{Kind: yema.Float64}, []interface{}{json.Number("1.0"), 2, 1}, []string{
+			"field 'items[2]' duplicates 'items[0]', items must be unique",
+		}},
+		{"structs regardless of key order", point, []interface{}{
+			map[string]interface{}{"x": 1.5, "tags": map[string]interface{}{"a": "1", "b": "2"}},
+			map[string]interface{}{"tags": map[string]interface{}{"b": "2", "a": "1"}, "x": json.Number("1.5")},
+		}, []string{"field 'items[1]' duplicates 'items[0]', items must be unique"}},
+		{"structs differing deeply", point, []interface{}{
+			map[string]interface{}{"x": 1.5, "tags": map[string]interface{}{"a": "1"}},
+			map[string]interface{}{"x": 1.5, "tags": map[string]interface{}{"a": "2"}},
+			map[string]interface{}{"x": 1.5},
+		}, nil},
+		{"nested arrays", &yema.Type{Kind: yema.Array, Array: &yema.Type{Kind: yema.Int}}, []interface{}{
+			[]interface{}{1, 2}, []interface{}{2, 1}, []interface{}{int64(1), 2},
+		}, []string{"field 'items[2]' duplicates 'items[0]', items must be unique"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := &yema.Type{Kind: yema.Struct, Struct: &map[string]yema.Type{
+				"items": {Kind: yema.Array, Unique: true, Array: tt.items},
+			}}
+			data := map[string]interface{}{"items": tt.value}
+			assertErrors(t, Validate(data, schema), tt.want)
+
+			compiled, err := Compile(schema)
+			if err != nil {
+				t.Fatal(err)
+			}
+			errs := compiled.Validate(data)
+			assertErrors(t, errs, tt.want)
+			for _, err := range errs {
+				if code := CodeOf(err); code != CodeDuplicate {
+					t.Errorf("unexpected code %s of %v", code, err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkUnique(b *testing.B) {
+	schema := &yema.Type{Kind: yema.Array, Unique: true, Array: &yema.Type{Kind: yema.Struct, Struct: &map[string]yema.Type{
+		"id":   {Kind: yema.Int64},
+		"name": {Kind: yema.String},
+	}}}
+	items := make([]interface{}, 10000)
+	for i := range items {
+		items[i] = map[string]interface{}{"id": json.Number(strconv.Itoa(i)), "name": "item"}
+	}
+	compiled, err := Compile(schema)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if errs := compiled.Validate(items); len(errs) != 0 {
+			b.Fatal(errs)
+		}
+	}
+}
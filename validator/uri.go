@@ -0,0 +1,91 @@
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// URIFormat is the format of strings holding an absolute URI, such as https://example.com/path.
+// The argument of the format is a scheme or a list of schemes the URI must have, any scheme is accepted if none is given.
+const URIFormat = "uri"
+
+// URISchemes returns the schemes allowed by the argument of a uri format in lower case, nil if any scheme is allowed
+func URISchemes(arg interface{}) ([]string, error) {
+	if arg == nil {
+		return nil, nil
+	}
+	list, ok := arg.([]interface{})
+	if !ok {
+		list = []interface{}{arg}
+	}
+	if len(list) == 0 {
+		return nil, fmt.Errorf("expected at least one scheme")
+	}
+
+	schemes := make([]string, 0, len(list))
+	for _, elem := range list {
+		scheme, ok := elem.(string)
+		if !ok || !isScheme(scheme) {
+			return nil, fmt.Errorf("expected a scheme such as https, got %v", elem)
+		}
+		schemes = append(schemes, strings.ToLower(scheme))
+	}
+	return schemes, nil
+}
+
+// isScheme reports whether s is a URI scheme, a letter followed by letters, digits, +, - or .
+func isScheme(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z':
+		case i > 0 && ('0' <= c && c <= '9' || c == '+' || c == '-' || c == '.'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// checkURI reports a string that is not an absolute URI with a scheme of its format
+func checkURI(value, arg interface{}) *Error {
+	s, ok := value.(string)
+	if !ok {
+		return nil
+	}
+	// Parse lower cases the scheme
+	u, err := url.Parse(s)
+	if err != nil {
+		// Errors of url.Parse repeat the whole string, the cause is enough
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return wrapError(err, "", "format.uri")
+	}
+	if u.Scheme == "" {
+		return wrapError(errors.New("missing scheme"), "", "format.uri")
+	}
+
+	schemes, _ := URISchemes(arg)
+	if schemes == nil {
+		return nil
+	}
+	for _, scheme := range schemes {
+		if u.Scheme == scheme {
+			return nil
+		}
+	}
+	return newError("", "format.uri_scheme", "scheme", u.Scheme, "schemes", strings.Join(schemes, " or "))
+}
+
+// checkURISchemes checks the schemes of a uri format
+func checkURISchemes(arg interface{}) error {
+	_, err := URISchemes(arg)
+	return err
+}
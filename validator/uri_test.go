@@ -0,0 +1,70 @@
+package validator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aep/yema"
+)
+
+func TestURI(t *testing.T) {
+	tests := []struct {
+		name  string
+		arg   interface{}
+		value interface{}
+		want  []string
+	}{
+		{"any scheme", nil, "http://example.com/path?q=1", nil},
+		{"urn", nil, "urn:isbn:0451450523", nil},
+		{"relative", nil, "/path", []string{"document must be an absolute URI: missing scheme"}},
+		{"invalid", nil, "http://exa mple.com/", []string{`document must be an absolute URI: invalid character " " in host name`}},
+		{"allowed scheme", []interface{}{"https", "s3"}, "s3://bucket/key", nil},
+		{"scheme in upper case", []interface{}{"https"}, "HTTPS://example.com", nil},
+		{"single scheme", "https", "https://example.com", nil},
+		{"rejected scheme", []interface{}{"https", "s3"}, "http://example.com", []string{"document must be a URI with scheme https or s3, got http"}},
+		{"not a string", nil, 42, []string{"document must be a string"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := &yema.Type{Kind: yema.String, Format: URIFormat, FormatArg: tt.arg}
+			errs := Validate(tt.value, schema)
+			assertErrors(t, errs, tt.want)
+
+			compiled, err := Compile(schema)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertErrors(t, compiled.Validate(tt.value), tt.want)
+		})
+	}
+}
+
+func TestURISchemes(t *testing.T) {
+	tests := []struct {
+		name    string
+		arg     interface{}
+		want    []string
+		wantErr bool
+	}{
+		{"none", nil, nil, false},
+		{"single", "HTTPS", []string{"https"}, false},
+		{"list", []interface{}{"https", "git+ssh"}, []string{"https", "git+ssh"}, false},
+		{"empty list", []interface{}{}, nil, true},
+		{"with separator", "https://", nil, true},
+		{"starts with digit", "3s", nil, true},
+		{"number", 443, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := URISchemes(tt.arg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("URISchemes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("URISchemes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
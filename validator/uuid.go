@@ -0,0 +1,94 @@
+package validator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// UUIDFormat is the format of strings holding a UUID in its canonical form, such as
+// 123e4567-e89b-42d3-a456-426614174000. The argument of the format is a version or a list of versions
+// the UUID must have, any well-formed UUID is accepted if none is given.
+const UUIDFormat = "uuid"
+
+// UUIDVersions returns the versions allowed by the argument of a uuid format, nil if any version is allowed
+func UUIDVersions(arg interface{}) ([]int, error) {
+	if arg == nil {
+		return nil, nil
+	}
+	list, ok := arg.([]interface{})
+	if !ok {
+		list = []interface{}{arg}
+	}
+	if len(list) == 0 {
+		return nil, fmt.Errorf("expected at least one version")
+	}
+
+	versions := make([]int, 0, len(list))
+	for _, elem := range list {
+		r, ok := toRat(elem)
+		if !ok || !r.IsInt() || !r.Num().IsInt64() || r.Num().Int64() < 1 || r.Num().Int64() > 8 {
+			return nil, fmt.Errorf("expected a version from 1 to 8, got %v", elem)
+		}
+		versions = append(versions, int(r.Num().Int64()))
+	}
+	return versions, nil
+}
+
+// checkUUID reports a string that is not a UUID of the versions of its format
+func checkUUID(value, arg interface{}) *Error {
+	s, ok := value.(string)
+	if !ok {
+		return nil
+	}
+	if !isUUID(s) {
+		return newError("", "format.uuid")
+	}
+
+	versions, _ := UUIDVersions(arg)
+	if versions == nil {
+		return nil
+	}
+	// Versioned UUIDs carry the variant of RFC 9562, 10 in the high bits of the clock sequence
+	if strings.IndexByte("89abAB", s[19]) >= 0 {
+		version := int(s[14] - '0')
+		for _, v := range versions {
+			if v == version {
+				return nil
+			}
+		}
+	}
+
+	names := make([]string, len(versions))
+	for i, v := range versions {
+		names[i] = strconv.Itoa(v)
+	}
+	return newError("", "format.uuid_version", "versions", strings.Join(names, " or "))
+}
+
+// isUUID reports whether s is a UUID in hexadecimal digits grouped 8-4-4-4-12
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		default:
+			if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// checkUUIDVersions checks the versions of a uuid format
+func checkUUIDVersions(arg interface{}) error {
+	_, err := UUIDVersions(arg)
+	return err
+}
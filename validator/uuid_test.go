@@ -0,0 +1,78 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/aep/yema"
+)
+
+func TestUUID(t *testing.T) {
+	tests := []struct {
+		name  string
+		arg   interface{}
+		value interface{}
+		want  []string
+	}{
+		{"any version", nil, "123e4567-e89b-12d3-a456-426614174000", nil},
+		{"upper case", nil, "123E4567-E89B-12D3-A456-426614174000", nil},
+		{"nil uuid", nil, "00000000-0000-0000-0000-000000000000", nil},
+		{"without hyphens", nil, "123e4567e89b12d3a456426614174000", []string{"document must be a UUID such as 123e4567-e89b-42d3-a456-426614174000"}},
+		{"braces", nil, "{123e4567-e89b-12d3-a456-426614174000}", []string{"document must be a UUID such as 123e4567-e89b-42d3-a456-426614174000"}},
+		{"not hex", nil, "123e4567-e89b-12d3-a456-42661417400g", []string{"document must be a UUID such as 123e4567-e89b-42d3-a456-426614174000"}},
+		{"version", 4, "123e4567-e89b-42d3-a456-426614174000", nil},
+		{"versions", []interface{}{4, 7}, "0190a3b4-5c6d-7e8f-9a0b-1c2d3e4f5a6b", nil},
+		{"other version", []interface{}{4, 7}, "123e4567-e89b-12d3-a456-426614174000", []string{"document must be a UUID of version 4 or 7"}},
+		{"version without variant", 4, "123e4567-e89b-42d3-c456-426614174000", []string{"document must be a UUID of version 4"}},
+		{"nil uuid with version", 4, "00000000-0000-0000-0000-000000000000", []string{"document must be a UUID of version 4"}},
+		{"not a string", nil, 42, []string{"document must be a string"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := &yema.Type{Kind: yema.String, Format: UUIDFormat, FormatArg: tt.arg}
+			errs := Validate(tt.value, schema)
+			assertErrors(t, errs, tt.want)
+
+			compiled, err := Compile(schema)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertErrors(t, compiled.Validate(tt.value), tt.want)
+		})
+	}
+}
+
+func TestUUIDVersions(t *testing.T) {
+	tests := []struct {
+		name    string
+		arg     interface{}
+		want    []int
+		wantErr bool
+	}{
+		{"none", nil, nil, false},
+		{"single", 7, []int{7}, false},
+		{"list", []interface{}{4, uint64(7)}, []int{4, 7}, false},
+		{"decoded json", []interface{}{float64(4)}, []int{4}, false},
+		{"empty list", []interface{}{}, nil, true},
+		{"out of range", 9, nil, true},
+		{"fraction", 4.5, nil, true},
+		{"string", "v4", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := UUIDVersions(tt.arg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UUIDVersions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("UUIDVersions() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("UUIDVersions() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
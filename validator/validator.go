@@ -2,137 +2,374 @@
 package validator
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/aep/yema"
+	"github.com/aep/yema/internal/fieldpath"
+	"io"
+	"math"
+	"sort"
 	"strconv"
 )
 
-// Validate checks if a map[string]interface{} matches a given yema.Type
-func Validate(data map[string]interface{}, schema *yema.Type) []error {
-	if schema == nil || schema.Struct == nil {
+// Options holds configuration options for validation
+type Options struct {
+	// DenyUnknownFields reports fields in the data that are not declared in the schema,
+	// instead of ignoring them
+	DenyUnknownFields bool
+	// MaxErrors stops validation once this many errors were found, 0 reports all errors
+	MaxErrors int
+	// FailFast stops validation at the first error, also within the variants of unions,
+	// for callers that only need to know whether a document is valid
+	FailFast bool
+	// MaxDepth bounds the nesting of arrays, maps and structs in a document, 0 uses DefaultMaxDepth and a negative value disables the limit
+	MaxDepth int
+	// MaxArrayLength bounds the number of elements of every array, 0 uses DefaultMaxArrayLength and a negative value disables the limit
+	MaxArrayLength int
+	// ProtoJSON enforces the conventions of the protobuf JSON mapping: 64-bit integers are strings,
+	// other numbers may be strings, bytes are base64 encoded, and fields that are neither optional nor structs
+	// may be missing or null, as proto3 omits fields holding their default value
+	ProtoJSON bool
+	// Registry holds custom validators by format and path, formats are also looked up among those
+	// registered with RegisterFormat
+	Registry *Registry
+	// Hooks observe validations, e.g. to export metrics, nil if there are none
+	Hooks *Hooks
+	// Coerce accepts values whose representation converts to the declared kind, such as "42" for an integer,
+	// see Coerce to also obtain the converted document
+	Coerce bool
+	// MaxAliasNodes bounds the nodes a YAML document may expand to through aliases, see ValidateYAML.
+	// 0 uses DefaultMaxAliasNodes.
+	MaxAliasNodes int
+}
+
+// Default resource limits of a validation, see Options.
+// Exceeding a limit is reported with an error wrapping yema.ErrLimitExceeded.
+const (
+	DefaultMaxDepth       = 1000
+	DefaultMaxArrayLength = 1000000
+)
+
+// validation holds the state of a single validation run
+type validation struct {
+	opts   Options
+	errors []error
+	// depth is the nesting of the value being validated
+	depth int
+	// ctx stops the validation once done, nil if it cannot be canceled
+	ctx context.Context
+	// stopped is set once ctx is done
+	stopped bool
+	// values counts the values checked and maxDepth is the deepest nesting entered, see DocumentStats
+	values   int
+	maxDepth int
+}
+
+// enter descends into an array, map or struct, reporting an error instead once MaxDepth is exceeded.
+// Every successful enter must be followed by leave.
+func (v *validation) enter(path func() string) bool {
+	limit := limit(v.opts.MaxDepth, DefaultMaxDepth)
+	if limit > 0 && v.depth >= limit {
+		v.report(wrapError(yema.ErrLimitExceeded, path(), "limit.depth", "limit", strconv.Itoa(limit)))
+		return false
+	}
+	v.depth++
+	v.maxDepth = max(v.maxDepth, v.depth)
+	return true
+}
+
+func (v *validation) leave() {
+	v.depth--
+}
+
+// checkLength reports an error if an array has more elements than MaxArrayLength
+func (v *validation) checkLength(length int, path func() string) bool {
+	limit := limit(v.opts.MaxArrayLength, DefaultMaxArrayLength)
+	if limit > 0 && length > limit {
+		v.report(wrapError(yema.ErrLimitExceeded, path(), "limit.length", "length", strconv.Itoa(length), "limit", strconv.Itoa(limit)))
+		return false
+	}
+	return true
+}
+
+// limit resolves a configured limit, 0 selects the default and a negative value disables it
+func limit(configured, defaultLimit int) int {
+	if configured == 0 {
+		return defaultLimit
+	}
+	return configured
+}
+
+// report records err unless it is nil or the error cap was reached
+func (v *validation) report(err error) {
+	if err == nil || v.done() {
+		return
+	}
+	v.errors = append(v.errors, err)
+}
+
+// done returns true once MaxErrors errors were reported, any error under FailFast, or the validation was canceled
+func (v *validation) done() bool {
+	if v.stopped || v.opts.FailFast && len(v.errors) > 0 {
+		return true
+	}
+	return v.opts.MaxErrors > 0 && len(v.errors) >= v.opts.MaxErrors
+}
+
+// Validate checks if a decoded document matches a given yema.Type.
+// The document is usually a map[string]interface{}, unless the root of the schema is not a struct.
+func Validate(data interface{}, schema *yema.Type) []error {
+	return ValidateWithOptions(data, schema, Options{})
+}
+
+// ValidateWithOptions checks if a decoded document matches a given yema.Type with custom options.
+// It is the entry point all modes of validation hang off, see Options; Validate uses the zero Options.
+func ValidateWithOptions(data interface{}, schema *yema.Type, opts Options) []error {
+	return validateDocument(nil, data, schema, "", opts)
+}
+
+// validateDocument checks a document, or the value at path in one, until ctx is done,
+// ctx is nil if the validation cannot be canceled
+func validateDocument(ctx context.Context, data interface{}, schema *yema.Type, path string, opts Options) []error {
+	if schema == nil || (schema.Kind == yema.Struct && schema.Struct == nil) {
 		return []error{fmt.Errorf("invalid schema")}
 	}
+	v := &validation{opts: opts, ctx: ctx}
+	start := v.now()
 
-	var errors []error
+	if opts.Coerce {
+		data = coerce(data, schema)
+	}
+	v.validateValue(data, schema, path)
+	v.finish(start)
+	return v.errors
+}
 
+// ValidateJSON decodes a JSON document from r and checks it against a yema.Type.
+// Numbers are decoded as json.Number, so integers keep their exact value instead of passing through float64.
+func ValidateJSON(r io.Reader, schema *yema.Type) []error {
+	return ValidateJSONWithOptions(r, schema, Options{})
+}
+
+// ValidateJSONWithOptions decodes a JSON document from r and checks it against a yema.Type with custom options
+func ValidateJSONWithOptions(r io.Reader, schema *yema.Type, opts Options) []error {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+
+	var data interface{}
+	if err := dec.Decode(&data); err != nil {
+		return []error{wrapError(err, "", "syntax.json")}
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return []error{newError("", "syntax.trailing")}
+	}
+
+	return ValidateWithOptions(data, schema, opts)
+}
+
+// validateStruct checks the fields of data against a struct schema, path is empty for the root
+func (v *validation) validateStruct(data map[string]interface{}, schema *yema.Type, path string) {
 	// For each field in the schema, validate the corresponding field in the data
-	for fieldName, fieldType := range *schema.Struct {
+	for _, fieldName := range schema.FieldNames() {
+		if v.done() {
+			return
+		}
+
+		fieldType := (*schema.Struct)[fieldName]
+		declared := selectType(&fieldType, data)
+		fieldPath := fieldpath.Join(path, fieldName)
 		value, exists := data[fieldName]
 
 		// If the field doesn't exist in the data
 		if !exists {
 			// Check if it's optional
-			if !fieldType.Optional {
-				errors = append(errors, fmt.Errorf("required field '%s' is missing", fieldName))
+			if v.required(declared.Kind, declared.Optional) {
+				v.report(newError(fieldPath, "required"))
 			}
 			// Skip validation for optional fields that don't exist
 			continue
 		}
 
 		// Field exists, validate it against the field type
-		if err := validateValue(value, &fieldType, fieldName); err != nil {
-			errors = append(errors, err)
+		start, before := v.now(), len(v.errors)
+		v.validateValue(value, declared, fieldPath)
+		v.fieldDone(func() string { return fieldPath }, start, before)
+		v.checkRules(data, fieldName, fieldType.Requires, fieldType.Conflicts, func() string { return path })
+	}
+
+	if v.opts.DenyUnknownFields {
+		for _, fieldName := range unknownFields(data, schema) {
+			v.report(newError(fieldpath.Join(path, fieldName), "unknown_field"))
+		}
+	}
+}
+
+// unknownFields returns the sorted keys of data not declared in the struct schema
+func unknownFields(data map[string]interface{}, schema *yema.Type) []string {
+	var unknown []string
+	for key := range data {
+		if _, ok := (*schema.Struct)[key]; !ok {
+			unknown = append(unknown, key)
 		}
 	}
+	sort.Strings(unknown)
+	return unknown
+}
 
-	return errors
+// subject describes the value at path in error messages
+func subject(path string) string {
+	if path == "" {
+		return "document"
+	}
+	return "field '" + path + "'"
 }
 
-// validateValue checks if a single value matches a yema.Type specification
-func validateValue(value interface{}, schema *yema.Type, path string) error {
+// validateValue checks if a single value matches a yema.Type specification and reports all violations
+func (v *validation) validateValue(value interface{}, schema *yema.Type, path string) {
+	v.values++
 	// Handle nil values
 	if value == nil {
-		if schema.Optional {
-			return nil
+		if v.required(schema.Kind, schema.Optional) {
+			v.report(newError(path, "null"))
 		}
-		return fmt.Errorf("field '%s' is nil but not optional", path)
+		return
 	}
 
+	if v.opts.ProtoJSON {
+		var err error
+		if value, err = fromProtoJSON(value, schema.Kind, path); err != nil {
+			v.report(err)
+			return
+		}
+	}
+
+	before := len(v.errors)
+
 	switch schema.Kind {
 	case yema.Bool:
 		if _, ok := value.(bool); !ok {
-			return fmt.Errorf("field '%s' must be a boolean", path)
+			v.report(newError(path, "type.boolean"))
 		}
 
 	case yema.String:
 		if _, ok := value.(string); !ok {
-			return fmt.Errorf("field '%s' must be a string", path)
+			v.report(newError(path, "type.string"))
 		}
 
 	case yema.Int, yema.Int8, yema.Int16, yema.Int32, yema.Int64:
-		return validateIntValue(value, schema.Kind, path)
+		v.report(validateIntValue(value, schema.Kind, path))
 
 	case yema.Uint, yema.Uint8, yema.Uint16, yema.Uint32, yema.Uint64:
-		return validateUintValue(value, schema.Kind, path)
+		v.report(validateUintValue(value, schema.Kind, path))
 
 	case yema.Float32, yema.Float64:
-		return validateFloatValue(value, schema.Kind, path)
+		v.report(validateFloatValue(value, schema.Kind, path))
 
 	case yema.Array:
 		if schema.Array == nil {
-			return fmt.Errorf("array type definition for '%s' is nil", path)
+			v.report(fmt.Errorf("array type definition for '%s' is nil", path))
+			return
 		}
 
 		arr, ok := value.([]interface{})
 		if !ok {
-			return fmt.Errorf("field '%s' must be an array", path)
+			v.report(newError(path, "type.array"))
+			return
 		}
+		if !v.checkLength(len(arr), func() string { return path }) || !v.enter(func() string { return path }) {
+			return
+		}
+		defer v.leave()
 
 		// Validate each element in the array
 		for i, elem := range arr {
 			elemPath := path + "[" + strconv.Itoa(i) + "]"
-			if err := validateValue(elem, schema.Array, elemPath); err != nil {
-				return err
+			if v.done() || v.canceled(func() string { return elemPath }) {
+				return
 			}
+			v.validateValue(elem, schema.Array, elemPath)
+		}
+		if schema.Unique && !v.done() {
+			v.checkUnique(arr, func(i int) string { return path + "[" + strconv.Itoa(i) + "]" })
 		}
 
 	case yema.Struct:
 		if schema.Struct == nil {
-			return fmt.Errorf("struct type definition for '%s' is nil", path)
+			v.report(fmt.Errorf("struct type definition for '%s' is nil", path))
+			return
 		}
 
 		mapValue, ok := value.(map[string]interface{})
 		if !ok {
-			return fmt.Errorf("field '%s' must be a map[string]interface{}", path)
+			v.report(newError(path, "type.struct"))
+			return
 		}
+		if !v.enter(func() string { return path }) {
+			return
+		}
+		defer v.leave()
 
-		// For each field in the schema, validate the corresponding field in the data
-		for fieldName, fieldType := range *schema.Struct {
-			nestedValue, exists := mapValue[fieldName]
+		v.validateStruct(mapValue, schema, path)
 
-			// If the field doesn't exist in the data
-			if !exists {
-				// Check if it's optional
-				if !fieldType.Optional {
-					return fmt.Errorf("required field '%s.%s' is missing", path, fieldName)
-				}
-				// Skip validation for optional fields that don't exist
-				continue
-			}
+	case yema.Map:
+		if schema.Map == nil {
+			v.report(fmt.Errorf("map type definition for '%s' is nil", path))
+			return
+		}
 
-			// Field exists, validate it against the field type
-			nestedPath := path + "." + fieldName
-			if err := validateValue(nestedValue, &fieldType, nestedPath); err != nil {
-				return err
-			}
+		mapValue, ok := value.(map[string]interface{})
+		if !ok {
+			v.report(newError(path, "type.map"))
+			return
+		}
+		pattern, err := keyPattern(schema.KeyPattern)
+		if err != nil {
+			v.report(fmt.Errorf("%s: %w", subject(path), err))
+			return
 		}
+		if !v.enter(func() string { return path }) {
+			return
+		}
+		defer v.leave()
 
-	case yema.Bytes:
-		// Accept both []byte and string for bytes type
-		if _, ok := value.([]byte); !ok {
-			if _, ok := value.(string); !ok {
-				return fmt.Errorf("field '%s' must be bytes or string", path)
+		// Validate every key and value, the key is part of the path of its value
+		for _, key := range sortedKeys(mapValue) {
+			elemPath := keyPath(path, key)
+			if v.done() || v.canceled(func() string { return elemPath }) {
+				return
 			}
+			v.checkKey(key, pattern, func() string { return elemPath })
+			v.validateValue(mapValue[key], schema.Map, elemPath)
 		}
 
+	case yema.Union:
+		v.validateUnion(value, schema, path)
+
+	case yema.Bytes:
+		// Accept both []byte and string for bytes type, strings must decode if an encoding is declared
+		v.checkBytes(value, schema.Format, schema.Length, func() string { return path })
+
 	default:
-		return fmt.Errorf("unsupported type %v for field '%s'", schema.Kind, path)
+		v.report(fmt.Errorf("unsupported type %v for field '%s'", schema.Kind, path))
 	}
 
-	return nil
+	// Enums and custom validators only run on values matching their kind
+	if len(v.errors) == before {
+		v.checkEnum(value, schema.Enum, func() string { return path })
+	}
+	if len(v.errors) == before {
+		v.checkFormat(value, schema.Format, schema.FormatArg, path)
+	}
 }
 
+// maxExactFloat is the largest magnitude up to which a float64 holds every integer exactly, 2^53.
+// Larger integers decoded as float64 may have been rounded, so they are rejected instead of validated
+// with a value that differs from the document. Decode numbers as json.Number to validate them exactly,
+// as ValidateJSON does.
+const maxExactFloat = 1 << 53
+
 // validateIntValue handles validation of integer types with proper range checking
 func validateIntValue(value interface{}, kind yema.Kind, path string) error {
 	// Check for various numeric types from JSON unmarshaling
@@ -145,6 +382,9 @@ func validateIntValue(value interface{}, kind yema.Kind, path string) error {
 			var err error
 			intVal, err = v.Int64()
 			isInt = err == nil
+			if errors.Is(err, strconv.ErrRange) {
+				return newError(path, "range", "type", "int64")
+			}
 		}
 	case int:
 		intVal, isInt = int64(v), true
@@ -156,29 +396,37 @@ func validateIntValue(value interface{}, kind yema.Kind, path string) error {
 		intVal, isInt = int64(v), true
 	case int64:
 		intVal, isInt = v, true
+	case uint64: // Unsigned Go values, see ValidateValue
+		if v > math.MaxInt64 {
+			return newError(path, "range.signed")
+		}
+		intVal, isInt = int64(v), true
 	case float64: // JSON numbers typically come as float64
-		if v == float64(int64(v)) { // Check if it's a whole number
+		if v == math.Trunc(v) && math.Abs(v) > maxExactFloat {
+			return newError(path, "range.imprecise")
+		}
+		if v == math.Trunc(v) { // Check if it's a whole number
 			intVal, isInt = int64(v), true
 		}
 	}
 
 	if !isInt {
-		return fmt.Errorf("field '%s' must be an integer", path)
+		return newError(path, "type.integer")
 	}
 
 	// Range validation
 	switch kind {
 	case yema.Int8:
 		if intVal < -128 || intVal > 127 {
-			return fmt.Errorf("field '%s' value out of range for int8", path)
+			return newError(path, "range", "type", "int8")
 		}
 	case yema.Int16:
 		if intVal < -32768 || intVal > 32767 {
-			return fmt.Errorf("field '%s' value out of range for int16", path)
+			return newError(path, "range", "type", "int16")
 		}
 	case yema.Int32:
 		if intVal < -2147483648 || intVal > 2147483647 {
-			return fmt.Errorf("field '%s' value out of range for int32", path)
+			return newError(path, "range", "type", "int32")
 		}
 	case yema.Int64, yema.Int:
 		// No range check needed for int64 (handled by conversion)
@@ -197,10 +445,12 @@ func validateUintValue(value interface{}, kind yema.Kind, path string) error {
 	switch v := value.(type) {
 	case json.Number:
 		{
-			intVal, err := v.Int64()
-			if intVal >= 0 {
-				uintVal = uint64(intVal)
-				isUint = err == nil
+			// Parsed as unsigned, so values above math.MaxInt64 keep their exact value
+			var err error
+			uintVal, err = strconv.ParseUint(string(v), 10, 64)
+			isUint = err == nil
+			if errors.Is(err, strconv.ErrRange) {
+				return newError(path, "range", "type", "uint64")
 			}
 		}
 	case uint:
@@ -234,28 +484,31 @@ func validateUintValue(value interface{}, kind yema.Kind, path string) error {
 			uintVal, isUint = uint64(v), true
 		}
 	case float64: // JSON numbers typically come as float64
-		if v >= 0 && v == float64(uint64(v)) { // Check if it's a non-negative whole number
+		if v == math.Trunc(v) && v > maxExactFloat {
+			return newError(path, "range.imprecise")
+		}
+		if v >= 0 && v == math.Trunc(v) { // Check if it's a non-negative whole number
 			uintVal, isUint = uint64(v), true
 		}
 	}
 
 	if !isUint {
-		return fmt.Errorf("field '%s' must be a non-negative integer", path)
+		return newError(path, "type.non_negative_integer")
 	}
 
 	// Range validation
 	switch kind {
 	case yema.Uint8:
 		if uintVal > 255 {
-			return fmt.Errorf("field '%s' value out of range for uint8", path)
+			return newError(path, "range", "type", "uint8")
 		}
 	case yema.Uint16:
 		if uintVal > 65535 {
-			return fmt.Errorf("field '%s' value out of range for uint16", path)
+			return newError(path, "range", "type", "uint16")
 		}
 	case yema.Uint32:
 		if uintVal > 4294967295 {
-			return fmt.Errorf("field '%s' value out of range for uint32", path)
+			return newError(path, "range", "type", "uint32")
 		}
 	case yema.Uint64, yema.Uint:
 		// No range check needed for uint64 (handled by conversion)
@@ -304,13 +557,13 @@ func validateFloatValue(value interface{}, kind yema.Kind, path string) error {
 	}
 
 	if !isFloat {
-		return fmt.Errorf("field '%s' must be a number", path)
+		return newError(path, "type.number")
 	}
 
 	// Float32 range check (approximation)
 	if kind == yema.Float32 {
 		if floatVal > 3.4e38 || floatVal < -3.4e38 {
-			return fmt.Errorf("field '%s' value out of range for float32", path)
+			return newError(path, "range", "type", "float32")
 		}
 	}
 
@@ -1,6 +1,11 @@
 package validator
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/aep/yema"
@@ -224,8 +229,8 @@ func TestValidateUnsignedIntegerRanges(t *testing.T) {
 	}
 }
 
-func BenchmarkValidateMap(b *testing.B) {
-	// Define a complex schema for benchmarking
+// benchmarkPerson returns a complex schema and a valid document for benchmarking
+func benchmarkPerson() (*yema.Type, map[string]interface{}) {
 	addressSchema := map[string]yema.Type{
 		"street":     {Kind: yema.String},
 		"city":       {Kind: yema.String},
@@ -277,6 +282,12 @@ func BenchmarkValidateMap(b *testing.B) {
 		"extraField3": true,
 	}
 
+	return personSchema, data
+}
+
+func BenchmarkValidateMap(b *testing.B) {
+	personSchema, data := benchmarkPerson()
+
 	// Run the benchmark
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
@@ -284,3 +295,409 @@ func BenchmarkValidateMap(b *testing.B) {
 	}
 }
 
+func TestValidateDenyUnknownFields(t *testing.T) {
+	schema := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"name": {Kind: yema.String},
+			"address": {Kind: yema.Struct, Struct: &map[string]yema.Type{
+				"city": {Kind: yema.String},
+			}},
+		},
+	}
+
+	data := map[string]interface{}{
+		"name":     "Bob",
+		"nickname": "B",
+		"age":      3,
+		"address": map[string]interface{}{
+			"city": "Springfield",
+			"zip":  "12345",
+		},
+	}
+
+	if errs := Validate(data, schema); len(errs) != 0 {
+		t.Errorf("unknown fields must be ignored by default, got %v", errs)
+	}
+
+	errs := ValidateWithOptions(data, schema, Options{DenyUnknownFields: true})
+	want := []string{"unknown field 'address.zip'", "unknown field 'age'", "unknown field 'nickname'"}
+	if len(errs) != len(want) {
+		t.Fatalf("got errors %v, want %v", errs, want)
+	}
+	for i, err := range errs {
+		if err.Error() != want[i] {
+			t.Errorf("error %d = %q, want %q", i, err, want[i])
+		}
+	}
+}
+
+func TestValidateCollectsAllErrors(t *testing.T) {
+	schema := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"tags": {Kind: yema.Array, Array: &yema.Type{Kind: yema.String}},
+			"owner": {Kind: yema.Struct, Struct: &map[string]yema.Type{
+				"name": {Kind: yema.String},
+				"age":  {Kind: yema.Uint8},
+			}},
+		},
+		Order: []string{"tags", "owner"},
+	}
+
+	data := map[string]interface{}{
+		"tags":  []interface{}{"a", 1, "b", true},
+		"owner": map[string]interface{}{"age": 300},
+	}
+
+	want := []string{
+		"field 'tags[1]' must be a string",
+		"field 'tags[3]' must be a string",
+		"field 'owner.age' value out of range for uint8",
+		"required field 'owner.name' is missing",
+	}
+
+	errs := Validate(data, schema)
+	if len(errs) != len(want) {
+		t.Fatalf("got errors %v, want %v", errs, want)
+	}
+	for i, err := range errs {
+		if err.Error() != want[i] {
+			t.Errorf("error %d = %q, want %q", i, err, want[i])
+		}
+	}
+
+	errs = ValidateWithOptions(data, schema, Options{MaxErrors: 2})
+	if len(errs) != 2 {
+		t.Fatalf("MaxErrors: got errors %v, want the first 2", errs)
+	}
+	for i, err := range errs {
+		if err.Error() != want[i] {
+			t.Errorf("MaxErrors: error %d = %q, want %q", i, err, want[i])
+		}
+	}
+}
+
+func TestFailFast(t *testing.T) {
+	schema := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"id": {Kind: yema.Union, Union: []yema.Type{
+				{Kind: yema.Struct, Struct: &map[string]yema.Type{"a": {Kind: yema.String}, "b": {Kind: yema.String}}, Order: []string{"a", "b"}},
+				{Kind: yema.Int},
+			}},
+			"tags": {Kind: yema.Array, Array: &yema.Type{Kind: yema.String}},
+		},
+		Order: []string{"id", "tags"},
+	}
+	data := map[string]interface{}{
+		"id":   map[string]interface{}{},
+		"tags": []interface{}{1, 2},
+	}
+
+	// Variants stop at their first error as well, so the union holds one error per variant
+	assertErrors(t, ValidateWithOptions(data, schema, Options{FailFast: true}), []string{
+		"field 'id' matches no variant of the union; variant 1 (struct): required field 'id.a' is missing; variant 2 (int): field 'id' must be an integer",
+	})
+	assertErrors(t, ValidateWithOptions(map[string]interface{}{"id": 1, "tags": []interface{}{"a"}}, schema, Options{FailFast: true}), nil)
+}
+
+func TestValidateJSON(t *testing.T) {
+	schema := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"id":   {Kind: yema.Int64},
+			"name": {Kind: yema.String},
+		},
+	}
+
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		// 2^63-1 is not representable as float64 and would fail after rounding
+		{`{"id": 9223372036854775807, "name": "max"}`, nil},
+		{`{"id": 1.5, "name": "x"}`, []string{"field 'id' must be an integer"}},
+		{`{"id": 1}`, []string{"required field 'name' is missing"}},
+		{`[1, 2]`, []string{"document must be a map[string]interface{}"}},
+		{`{"id": 1, "name": "x"`, []string{"document is not valid JSON"}},
+		{`{"id": 1, "name": "x"} {}`, []string{"document has data after the JSON value"}},
+	}
+
+	for _, tt := range tests {
+		errs := ValidateJSON(strings.NewReader(tt.input), schema)
+		if len(errs) != len(tt.want) {
+			t.Errorf("ValidateJSON(%s) = %v, want %v", tt.input, errs, tt.want)
+			continue
+		}
+		for i, err := range errs {
+			if !strings.HasPrefix(err.Error(), tt.want[i]) {
+				t.Errorf("ValidateJSON(%s) error %d = %q, want %q", tt.input, i, err, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestValidateRoots(t *testing.T) {
+	users := &yema.Type{Kind: yema.Array, Array: &yema.Type{
+		Kind:   yema.Struct,
+		Struct: &map[string]yema.Type{"name": {Kind: yema.String}},
+	}}
+
+	errs := Validate([]interface{}{
+		map[string]interface{}{"name": "Bob"},
+		map[string]interface{}{},
+	}, users)
+	if len(errs) != 1 || errs[0].Error() != "required field '[1].name' is missing" {
+		t.Errorf("unexpected errors %v", errs)
+	}
+
+	errs = Validate(map[string]interface{}{}, users)
+	if len(errs) != 1 || errs[0].Error() != "document must be an array" {
+		t.Errorf("unexpected errors %v", errs)
+	}
+
+	errs = Validate(json.Number("300"), &yema.Type{Kind: yema.Uint8})
+	if len(errs) != 1 || errs[0].Error() != "document value out of range for uint8" {
+		t.Errorf("unexpected errors %v", errs)
+	}
+}
+
+func TestValidateMap(t *testing.T) {
+	schema := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"labels": {Kind: yema.Map, KeyPattern: "^[a-z]+$", Map: &yema.Type{Kind: yema.String}},
+			"scores": {Kind: yema.Map, Optional: true, Map: &yema.Type{
+				Kind:   yema.Struct,
+				Struct: &map[string]yema.Type{"value": {Kind: yema.Int8}},
+			}},
+		},
+	}
+
+	tests := []struct {
+		name string
+		data map[string]interface{}
+		want []string
+	}{
+		{
+			name: "valid",
+			data: map[string]interface{}{
+				"labels": map[string]interface{}{"app": "web", "tier": "db"},
+				"scores": map[string]interface{}{"Any Key": map[string]interface{}{"value": 1}},
+			},
+		},
+		{
+			name: "empty map",
+			data: map[string]interface{}{"labels": map[string]interface{}{}},
+		},
+		{
+			name: "not a map",
+			data: map[string]interface{}{"labels": []interface{}{"web"}},
+			want: []string{"field 'labels' must be a map"},
+		},
+		{
+			name: "key and value errors in key order",
+			data: map[string]interface{}{
+				"labels": map[string]interface{}{"b": 2, "App": "web", "a": nil},
+			},
+			want: []string{
+				`key of field 'labels[App]' must match "^[a-z]+$"`,
+				"field 'labels[a]' is nil but not optional",
+				"field 'labels[b]' must be a string",
+			},
+		},
+		{
+			name: "nested struct values",
+			data: map[string]interface{}{
+				"labels": map[string]interface{}{},
+				"scores": map[string]interface{}{"x": map[string]interface{}{"value": 200}, "y": map[string]interface{}{}},
+			},
+			want: []string{
+				"field 'scores[x].value' value out of range for int8",
+				"required field 'scores[y].value' is missing",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertErrors(t, Validate(tt.data, schema), tt.want)
+		})
+	}
+
+	errs := Validate(map[string]interface{}{"a": "x"}, &yema.Type{Kind: yema.Map, KeyPattern: "(", Map: &yema.Type{Kind: yema.String}})
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "invalid key pattern") {
+		t.Errorf("unexpected errors for an invalid key pattern %v", errs)
+	}
+}
+
+func TestKeyPatternCache(t *testing.T) {
+	cache := newPatternCache(2)
+	for _, pattern := range []string{"a", "b", "c"} {
+		cache.store(pattern, regexp.MustCompile(pattern))
+		if pattern == "b" {
+			// Using a keeps it over b
+			cache.load("a")
+		}
+	}
+	if _, ok := cache.load("b"); ok {
+		t.Error("expected the least recently used pattern to be evicted")
+	}
+	for _, pattern := range []string{"a", "c"} {
+		if re, ok := cache.load(pattern); !ok || re.String() != pattern {
+			t.Errorf("expected pattern %q to be cached, got %v", pattern, re)
+		}
+	}
+	if cache.order.Len() != 2 || len(cache.entries) != 2 {
+		t.Errorf("expected 2 cached patterns, got %d", cache.order.Len())
+	}
+
+	// Validations with more distinct patterns than the cache holds keep it bounded
+	for i := 0; i < maxKeyPatterns+10; i++ {
+		schema := &yema.Type{Kind: yema.Map, KeyPattern: fmt.Sprintf("^k%d$", i), Map: &yema.Type{Kind: yema.String}}
+		if errs := Validate(map[string]interface{}{fmt.Sprintf("k%d", i): "v"}, schema); len(errs) != 0 {
+			t.Fatalf("unexpected errors %v", errs)
+		}
+	}
+	if n := len(keyPatterns.entries); n > maxKeyPatterns {
+		t.Errorf("expected at most %d cached key patterns, got %d", maxKeyPatterns, n)
+	}
+}
+
+func TestValidateEnum(t *testing.T) {
+	schema := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"env":   {Kind: yema.String, Enum: []interface{}{"development", "staging", "production"}},
+			"level": {Kind: yema.Uint8, Optional: true, Enum: []interface{}{1, 2, 3}},
+		},
+	}
+
+	tests := []struct {
+		name string
+		data map[string]interface{}
+		want []string
+	}{
+		{
+			name: "valid",
+			data: map[string]interface{}{"env": "staging", "level": json.Number("2")},
+		},
+		{
+			name: "numbers compare by value",
+			data: map[string]interface{}{"env": "production", "level": 3.0},
+		},
+		{
+			name: "typo",
+			data: map[string]interface{}{"env": "prodution"},
+			want: []string{`field 'env' must be one of "development", "staging", "production", did you mean "production"?`},
+		},
+		{
+			name: "case",
+			data: map[string]interface{}{"env": "Staging"},
+			want: []string{`field 'env' must be one of "development", "staging", "production", did you mean "staging"?`},
+		},
+		{
+			name: "no close value",
+			data: map[string]interface{}{"env": "test", "level": 4},
+			want: []string{
+				`field 'env' must be one of "development", "staging", "production"`,
+				"field 'level' must be one of 1, 2, 3",
+			},
+		},
+		{
+			name: "kind errors take precedence",
+			data: map[string]interface{}{"env": 1},
+			want: []string{"field 'env' must be a string"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertErrors(t, Validate(tt.data, schema), tt.want)
+
+			compiled, err := Compile(schema)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertErrors(t, compiled.Validate(tt.data), tt.want)
+		})
+	}
+}
+
+func TestValidateUnion(t *testing.T) {
+	schema := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"id": {Kind: yema.Union, Union: []yema.Type{
+				{Kind: yema.String},
+				{Kind: yema.Struct, Struct: &map[string]yema.Type{"value": {Kind: yema.Int8}}},
+			}},
+		},
+	}
+
+	tests := []struct {
+		name string
+		data map[string]interface{}
+		want []string
+	}{
+		{
+			name: "first variant",
+			data: map[string]interface{}{"id": "abc"},
+		},
+		{
+			name: "second variant",
+			data: map[string]interface{}{"id": map[string]interface{}{"value": 1}},
+		},
+		{
+			name: "no variant",
+			data: map[string]interface{}{"id": map[string]interface{}{"value": 300}},
+			want: []string{"field 'id' matches no variant of the union; variant 1 (string): field 'id' must be a string; " +
+				"variant 2 (struct): field 'id.value' value out of range for int8"},
+		},
+		{
+			name: "missing",
+			data: map[string]interface{}{},
+			want: []string{"required field 'id' is missing"},
+		},
+	}
+
+	compiled, err := Compile(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertErrors(t, Validate(tt.data, schema), tt.want)
+			assertErrors(t, compiled.Validate(tt.data), tt.want)
+		})
+	}
+
+	errs := Validate(map[string]interface{}{"id": 1}, schema)
+	var unionErr *UnionError
+	if len(errs) != 1 || !errors.As(errs[0], &unionErr) {
+		t.Fatalf("expected a UnionError, got %v", errs)
+	}
+	if unionErr.Path != "id" || len(unionErr.Variants) != 2 || unionErr.Variants[1].Kind != yema.Struct {
+		t.Errorf("unexpected union error %+v", unionErr)
+	}
+
+	nested := map[string]interface{}{"id": map[string]interface{}{"value": 1}}
+	errs = ValidateWithOptions(nested, schema, Options{MaxDepth: 1})
+	if len(errs) != 1 || !errors.Is(errs[0], yema.ErrLimitExceeded) {
+		t.Errorf("expected a limit error within the union, got %v", errs)
+	}
+}
+
+func BenchmarkValidateFailFast(b *testing.B) {
+	schema := &yema.Type{Kind: yema.Array, Array: &yema.Type{Kind: yema.String}}
+	data := make([]interface{}, 10000)
+	for i := range data {
+		data[i] = i
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = ValidateWithOptions(data, schema, Options{FailFast: true})
+	}
+}
@@ -0,0 +1,291 @@
+package validator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/aep/yema"
+	"github.com/aep/yema/internal/fieldpath"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultMaxAliasNodes is the number of nodes a YAML document may expand to through aliases, unless Options.MaxAliasNodes is set
+const DefaultMaxAliasNodes = 100000
+
+// mergeTag is the tag yaml.v3 resolves the merge key << to
+const mergeTag = "!!merge"
+
+// ValidateYAML decodes a YAML document from r and checks it against a yema.Type.
+// Aliases and merge keys (<<) are resolved before validation, so a value is checked and reported
+// at every location it is used. Mapping keys are read as strings, like the keys of a JSON object.
+func ValidateYAML(r io.Reader, schema *yema.Type) []error {
+	return ValidateYAMLWithOptions(r, schema, Options{})
+}
+
+// ValidateYAMLWithOptions decodes a YAML document from r and checks it against a yema.Type with custom options
+func ValidateYAMLWithOptions(r io.Reader, schema *yema.Type, opts Options) []error {
+	var node yaml.Node
+	if err := yaml.NewDecoder(r).Decode(&node); err == io.EOF {
+		return ValidateWithOptions(nil, schema, opts)
+	} else if err != nil {
+		return []error{wrapError(err, "", "syntax.yaml")}
+	}
+
+	return validateYAMLNode(&node, schema, opts, nil)
+}
+
+// ValidateYAMLNode checks a parsed YAML document against a yema.Type, like ValidateYAML.
+// Errors are wrapped in a PositionError holding the line and column of the offending value,
+// or of the closest enclosing value, such as the mapping missing a required field.
+func ValidateYAMLNode(node *yaml.Node, schema *yema.Type) []error {
+	return ValidateYAMLNodeWithOptions(node, schema, Options{})
+}
+
+// ValidateYAMLNodeWithOptions is like ValidateYAMLNode with custom options
+func ValidateYAMLNodeWithOptions(node *yaml.Node, schema *yema.Type, opts Options) []error {
+	if node == nil || node.Kind == 0 {
+		// An empty document, as decoded from empty input
+		return ValidateWithOptions(nil, schema, opts)
+	}
+
+	positions := make(map[string]*yaml.Node)
+	errs := validateYAMLNode(node, schema, opts, positions)
+	for i, err := range errs {
+		path, ok := errorPath(err)
+		if !ok {
+			continue
+		}
+		if n := closestNode(positions, path); n != nil {
+			errs[i] = &PositionError{Line: n.Line, Column: n.Column, Err: err}
+		}
+	}
+	return errs
+}
+
+// validateYAMLNode decodes and checks a document, recording the node of every path in positions unless it is nil
+func validateYAMLNode(node *yaml.Node, schema *yema.Type, opts Options, positions map[string]*yaml.Node) []error {
+	limit := opts.MaxAliasNodes
+	if limit <= 0 {
+		limit = DefaultMaxAliasNodes
+	}
+
+	d := &yamlDecoder{limit: limit, positions: positions}
+	data, err := d.value(node, "")
+	if err != nil {
+		return []error{err}
+	}
+
+	return ValidateWithOptions(data, schema, opts)
+}
+
+// PositionError is a violation found in a YAML document, located by the line and column of the value
+type PositionError struct {
+	// Line and Column are 1-based, as reported by yaml.Node
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *PositionError) Error() string {
+	return English.Format(e)
+}
+
+func (e *PositionError) Unwrap() error {
+	return e.Err
+}
+
+// errorPath returns the path of the value an error is about, false if the error has none
+func errorPath(err error) (string, bool) {
+	var unionErr *UnionError
+	if errors.As(err, &unionErr) {
+		return unionErr.Path, true
+	}
+	var verr *Error
+	if errors.As(err, &verr) {
+		return verr.Path, true
+	}
+	return "", false
+}
+
+// closestNode returns the node at path, or at its closest ancestor if the value does not exist in the document
+func closestNode(positions map[string]*yaml.Node, path string) *yaml.Node {
+	for {
+		if n, ok := positions[path]; ok {
+			return n
+		}
+		if path == "" {
+			return nil
+		}
+		path = fieldpath.Parent(path)
+	}
+}
+
+// yamlDecoder converts yaml nodes to the values Validate expects, resolving aliases and merge keys
+type yamlDecoder struct {
+	limit int
+	// expanded counts the nodes decoded through aliases
+	expanded int
+	// aliased are the anchored nodes currently being expanded, to reject recursive aliases
+	aliased []*yaml.Node
+	// positions maps paths to the nodes decoded there, nil if they are not recorded
+	positions map[string]*yaml.Node
+}
+
+// record remembers the node decoded at path. The first node wins,
+// so keys declared in a mapping are located there rather than in a merged mapping.
+func (d *yamlDecoder) record(path string, n *yaml.Node) {
+	if d.positions == nil {
+		return
+	}
+	if _, ok := d.positions[path]; !ok {
+		d.positions[path] = n
+	}
+}
+
+// value converts the node at path to a plain value
+func (d *yamlDecoder) value(n *yaml.Node, path string) (interface{}, error) {
+	if len(d.aliased) > 0 {
+		d.expanded++
+		if d.expanded > d.limit {
+			return nil, wrapError(yema.ErrLimitExceeded, path, "limit.aliases", "limit", strconv.Itoa(d.limit))
+		}
+	}
+
+	switch n.Kind {
+	case yaml.DocumentNode:
+		if len(n.Content) == 0 {
+			return nil, nil
+		}
+		return d.value(n.Content[0], path)
+
+	case yaml.AliasNode:
+		for _, anchored := range d.aliased {
+			if anchored == n.Alias {
+				return nil, newError(path, "syntax.alias", "anchor", n.Value)
+			}
+		}
+		d.aliased = append(d.aliased, n.Alias)
+		v, err := d.value(n.Alias, path)
+		d.aliased = d.aliased[:len(d.aliased)-1]
+		return v, err
+
+	case yaml.ScalarNode:
+		d.record(path, n)
+		var v interface{}
+		if err := n.Decode(&v); err != nil {
+			return nil, wrapError(err, path, "syntax.yaml")
+		}
+		// Integers beyond uint64 resolve to floats, keep their digits so they are reported as out of range
+		if _, ok := v.(float64); ok && isDecimalInteger(n.Value) {
+			return json.Number(strings.TrimPrefix(n.Value, "+")), nil
+		}
+		return v, nil
+
+	case yaml.SequenceNode:
+		d.record(path, n)
+		arr := make([]interface{}, len(n.Content))
+		for i, item := range n.Content {
+			v, err := d.value(item, path+"["+strconv.Itoa(i)+"]")
+			if err != nil {
+				return nil, err
+			}
+			arr[i] = v
+		}
+		return arr, nil
+
+	case yaml.MappingNode:
+		d.record(path, n)
+		return d.mapping(n, path)
+	}
+
+	return nil, wrapError(fmt.Errorf("unexpected node kind %v", n.Kind), path, "syntax.yaml")
+}
+
+// mapping converts a mapping node. Keys declared in the mapping take precedence over merged keys,
+// and of several merged mappings the first one declaring a key wins.
+func (d *yamlDecoder) mapping(n *yaml.Node, path string) (map[string]interface{}, error) {
+	m := make(map[string]interface{}, len(n.Content)/2)
+	var merges []*yaml.Node
+
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		keyNode, valueNode := n.Content[i], n.Content[i+1]
+		if keyNode.Tag == mergeTag {
+			merges = append(merges, valueNode)
+			continue
+		}
+
+		key, err := d.key(keyNode, path)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := m[key]; ok {
+			return nil, newError(fieldpath.Join(path, key), "syntax.duplicate_key")
+		}
+
+		v, err := d.value(valueNode, fieldpath.Join(path, key))
+		if err != nil {
+			return nil, err
+		}
+		// Values of maps are reported with the key in brackets, see keyPath
+		if n, ok := d.positions[fieldpath.Join(path, key)]; ok {
+			d.record(keyPath(path, key), n)
+		}
+		m[key] = v
+	}
+
+	for _, merge := range merges {
+		sources := []*yaml.Node{merge}
+		if merge.Kind == yaml.SequenceNode {
+			sources = merge.Content
+		}
+
+		for _, source := range sources {
+			v, err := d.value(source, path)
+			if err != nil {
+				return nil, err
+			}
+			merged, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, newError(path, "syntax.merge")
+			}
+			for key, value := range merged {
+				if _, ok := m[key]; !ok {
+					m[key] = value
+				}
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// isDecimalInteger reports whether s is a plain decimal integer literal with an optional sign
+func isDecimalInteger(s string) bool {
+	if strings.HasPrefix(s, "+") || strings.HasPrefix(s, "-") {
+		s = s[1:]
+	}
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// key returns the string form of a mapping key, keys that are not scalars are rejected
+func (d *yamlDecoder) key(n *yaml.Node, path string) (string, error) {
+	for n.Kind == yaml.AliasNode && n.Alias != nil {
+		n = n.Alias
+	}
+	if n.Kind != yaml.ScalarNode {
+		return "", newError(path, "syntax.key")
+	}
+	return n.Value, nil
+}
@@ -0,0 +1,213 @@
+package validator
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aep/yema"
+	"gopkg.in/yaml.v3"
+)
+
+func TestValidateYAML(t *testing.T) {
+	server := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"host": {Kind: yema.String},
+			"port": {Kind: yema.Uint16},
+			"tls":  {Kind: yema.Bool},
+		},
+		Order: []string{"host", "port", "tls"},
+	}
+	schema := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"primary": *server,
+			"backup":  *server,
+		},
+		Order: []string{"primary", "backup"},
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name: "merge keys",
+			input: `
+defaults: &defaults
+  port: 80
+  tls: false
+primary:
+  <<: *defaults
+  host: a
+backup:
+  <<: [{port: 8080}, *defaults]
+  host: b
+`,
+		},
+		{
+			name: "declared keys override merged keys",
+			input: `
+defaults: &defaults
+  host: a
+  port: 80
+  tls: yes
+primary:
+  <<: *defaults
+  tls: false
+backup:
+  <<: *defaults
+  port: 70000
+`,
+			want: []string{
+				"field 'backup.port' value out of range for uint16",
+				"field 'backup.tls' must be a boolean",
+			},
+		},
+		{
+			name: "aliases are reported where they are used",
+			input: `
+primary: &server
+  host: a
+  port: -1
+  tls: true
+backup: *server
+`,
+			want: []string{
+				"field 'primary.port' must be a non-negative integer",
+				"field 'backup.port' must be a non-negative integer",
+			},
+		},
+		{
+			name:  "merge of a scalar",
+			input: "primary:\n  <<: 1\n",
+			want:  []string{"merge key of field 'primary' must refer to a mapping or a list of mappings"},
+		},
+		{
+			name:  "duplicate key",
+			input: "primary: {}\nprimary: {}\n",
+			want:  []string{"duplicate key 'primary'"},
+		},
+		{
+			name:  "key that is not a scalar",
+			input: "? [a]\n: 1\n",
+			want:  []string{"document has a key that is not a string or number"},
+		},
+		{
+			name:  "empty document",
+			input: "",
+			want:  []string{"document is nil but not optional"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertErrors(t, ValidateYAML(strings.NewReader(tt.input), schema), tt.want)
+		})
+	}
+}
+
+func TestValidateYAMLSyntax(t *testing.T) {
+	schema := &yema.Type{Kind: yema.Struct, Struct: &map[string]yema.Type{}}
+
+	tests := []struct {
+		input   string
+		message string
+		path    string
+		want    string
+	}{
+		{"a: &x\n  b: *x\n", "syntax.alias", "a.b.b", "field 'a.b.b' is a recursive alias of &x"},
+		{"a: 1\na: 2\n", "syntax.duplicate_key", "a", "duplicate key 'a'"},
+		{"a:\n  <<: [1]\n", "syntax.merge", "a", "merge key of field 'a' must refer to a mapping or a list of mappings"},
+		{"a:\n  ? {b: 1}\n  : 1\n", "syntax.key", "a", "field 'a' has a key that is not a string or number"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			errs := ValidateYAML(strings.NewReader(tt.input), schema)
+			var verr *Error
+			if len(errs) != 1 || !errors.As(errs[0], &verr) {
+				t.Fatalf("expected a single *Error, got %v", errs)
+			}
+			if verr.Message != tt.message || verr.Path != tt.path || verr.Code != CodeSyntax || verr.Error() != tt.want {
+				t.Errorf("got %s %q at %q with code %s, want %s %q at %q", verr.Message, verr.Error(), verr.Path, verr.Code, tt.message, tt.want, tt.path)
+			}
+		})
+	}
+}
+
+func TestValidateYAMLAliasLimit(t *testing.T) {
+	// Each level multiplies the nodes of the previous one by ten
+	input := `
+a: &a [x, x, x, x, x, x, x, x, x, x]
+b: &b [*a, *a, *a, *a, *a, *a, *a, *a, *a, *a]
+c: &c [*b, *b, *b, *b, *b, *b, *b, *b, *b, *b]
+d: &d [*c, *c, *c, *c, *c, *c, *c, *c, *c, *c]
+`
+	schema := &yema.Type{Kind: yema.Struct, Struct: &map[string]yema.Type{}}
+
+	errs := ValidateYAMLWithOptions(strings.NewReader(input), schema, Options{MaxAliasNodes: 1000})
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "too many nodes through aliases") {
+		t.Fatalf("expected alias limit error, got %v", errs)
+	}
+	if !errors.Is(errs[0], yema.ErrLimitExceeded) || CodeOf(errs[0]) != CodeLimitExceeded {
+		t.Errorf("expected the alias limit error to wrap yema.ErrLimitExceeded, got %#v", errs[0])
+	}
+
+	if errs := ValidateYAML(strings.NewReader(input), schema); len(errs) != 0 {
+		t.Errorf("unexpected errors within the default limit: %v", errs)
+	}
+}
+
+func TestValidateYAMLNode(t *testing.T) {
+	schema := &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"name":   {Kind: yema.String},
+			"port":   {Kind: yema.Uint16},
+			"labels": {Kind: yema.Map, Map: &yema.Type{Kind: yema.String}},
+			"server": {Kind: yema.Struct, Struct: &map[string]yema.Type{
+				"host": {Kind: yema.String},
+			}},
+			"hosts": {Kind: yema.Map, Map: &yema.Type{Kind: yema.Struct, Struct: &map[string]yema.Type{
+				"addr": {Kind: yema.String},
+			}}},
+		},
+		Order: []string{"name", "port", "labels", "server", "hosts"},
+	}
+	input := `name: app
+port: 70000
+labels:
+  team: 7
+server:
+  tls: true
+hosts:
+  db.internal:
+    port: 5432
+`
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(input), &node); err != nil {
+		t.Fatal(err)
+	}
+
+	errs := ValidateYAMLNode(&node, schema)
+	assertErrors(t, errs, []string{
+		"line 2, column 7: field 'port' value out of range for uint16",
+		"line 4, column 9: field 'labels[team]' must be a string",
+		// Missing fields are located at the mapping that should hold them
+		"line 6, column 3: required field 'server.host' is missing",
+		// Keys holding dots are located as a whole
+		"line 9, column 5: required field 'hosts[db.internal].addr' is missing",
+	})
+
+	var posErr *PositionError
+	if len(errs) != 4 || !errors.As(errs[0], &posErr) || posErr.Line != 2 || CodeOf(errs[0]) != CodeRange {
+		t.Errorf("expected a position error wrapping a range error, got %#v", errs)
+	}
+
+	if errs := ValidateYAMLNode(&yaml.Node{}, &yema.Type{Kind: yema.String, Optional: true}); len(errs) != 0 {
+		t.Errorf("expected an empty document to validate as null, got %v", errs)
+	}
+}
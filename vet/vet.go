@@ -0,0 +1,216 @@
+// Package vet checks a schema for semantic problems, declarations that are contradictory or cannot
+// be satisfied by any document. Unlike package lint it does not judge style or target languages.
+package vet
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/aep/yema"
+	"github.com/aep/yema/internal/fieldpath"
+	"github.com/aep/yema/parser"
+	"github.com/aep/yema/validator"
+	"gopkg.in/yaml.v3"
+)
+
+// Problem is a single semantic problem in a schema
+type Problem struct {
+	// Path is the location of the offending type, e.g. "addresses[].type"
+	Path string
+	// Message describes the problem
+	Message string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: %s", fieldpath.Display(p.Path), p.Message)
+}
+
+// Vet checks every type in the schema and returns the problems found, in declaration order
+func Vet(t *yema.Type) []Problem {
+	if t == nil {
+		return []Problem{{Message: "schema is nil"}}
+	}
+
+	var problems []Problem
+	vet(t, "", "", &problems)
+	return problems
+}
+
+// Defs checks the yaml or json schema document a type was parsed from for entries of $defs that are never
+// referenced, as parsing expands references and drops the entries. Documents that fail to decode have none.
+func Defs(data []byte) []Problem {
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil
+	}
+
+	var problems []Problem
+	for _, name := range parser.UnusedDefs(doc) {
+		problems = append(problems, Problem{Path: parser.DefsKey + "." + name, Message: "type is never referenced"})
+	}
+	return problems
+}
+
+func vet(t *yema.Type, path, name string, problems *[]Problem) {
+	report := func(format string, args ...interface{}) {
+		*problems = append(*problems, Problem{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch t.Kind {
+	case yema.Array:
+		if t.Array == nil {
+			report("array does not declare the type of its items")
+			return
+		}
+		vet(t.Array, path+"[]", "", problems)
+
+	case yema.Union:
+		if len(t.Union) < 2 {
+			report("union declares fewer than two variants")
+		}
+		for i := range t.Union {
+			vet(&t.Union[i], path, "", problems)
+		}
+
+	case yema.Map:
+		if t.Map == nil {
+			report("map does not declare the type of its values")
+			return
+		}
+		if _, err := regexp.Compile(t.KeyPattern); err != nil {
+			report("invalid key pattern: %v", err)
+		}
+		vet(t.Map, path+"[]", "", problems)
+
+	case yema.Struct:
+		if t.Struct == nil || len(*t.Struct) == 0 {
+			report("struct declares no fields")
+			return
+		}
+		if t.Order != nil && !sameFields(t.Order, *t.Struct) {
+			report("field order %q does not match the declared fields", t.Order)
+		}
+		for _, fieldName := range t.FieldNames() {
+			fieldType := (*t.Struct)[fieldName]
+			vet(&fieldType, fieldpath.Join(path, fieldName), fieldName, problems)
+			vetRules(*t.Struct, fieldName, fieldpath.Join(path, fieldName), problems)
+		}
+
+	case yema.Bool, yema.String, yema.Bytes, yema.Float32, yema.Float64,
+		yema.Int, yema.Int8, yema.Int16, yema.Int32, yema.Int64,
+		yema.Uint, yema.Uint8, yema.Uint16, yema.Uint32, yema.Uint64:
+		vetEnum(t, report)
+
+	default:
+		report("invalid type kind %v", t.Kind)
+		return
+	}
+
+	if t.Example != nil {
+		if err := checkExample(t, name); err != nil {
+			report("example is not a valid value of the type: %v", err)
+		}
+	}
+}
+
+// vetEnum reports enum values that are not valid for the type or listed more than once
+func vetEnum(t *yema.Type, report func(format string, args ...interface{})) {
+	if t.Enum == nil {
+		return
+	}
+	if t.Kind == yema.Bool || t.Kind == yema.Bytes {
+		report("enums are only supported for strings and numbers")
+		return
+	}
+
+	valueType := yema.Type{Kind: t.Kind}
+	seen := make(map[string]bool, len(t.Enum))
+	for _, value := range t.Enum {
+		if errs := validator.Validate(value, &valueType); len(errs) != 0 {
+			report("enum value %v is not a valid value of the type: %v", value, errors.Join(errs...))
+			continue
+		}
+		key := fmt.Sprint(value)
+		if seen[key] {
+			report("enum lists %v more than once", value)
+		}
+		seen[key] = true
+	}
+}
+
+// vetRules reports requires and conflicts rules of a struct field that refer to unknown fields,
+// are redundant or contradict each other
+func vetRules(fields map[string]yema.Type, name, path string, problems *[]Problem) {
+	report := func(format string, args ...interface{}) {
+		*problems = append(*problems, Problem{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	field := fields[name]
+	for _, other := range field.Requires {
+		otherType, ok := fields[other]
+		switch {
+		case !ok || other == name:
+			report("requires unknown field '%s'", other)
+		case containsName(field.Conflicts, other):
+			report("both requires and conflicts with field '%s', it can never be present", other)
+		case containsName(otherType.Conflicts, name):
+			report("requires field '%s', which conflicts with it, it can never be present", other)
+		case !otherType.Optional:
+			report("requires field '%s', which is always present", other)
+		}
+	}
+	for _, other := range field.Conflicts {
+		otherType, ok := fields[other]
+		switch {
+		case !ok || other == name:
+			report("conflicts with unknown field '%s'", other)
+		case !otherType.Optional:
+			report("conflicts with field '%s', which is always present, it can never be present", other)
+		}
+	}
+}
+
+// containsName reports whether names lists name
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// sameFields returns true if order lists every field of fields exactly once
+func sameFields(order []string, fields map[string]yema.Type) bool {
+	if len(order) != len(fields) {
+		return false
+	}
+	seen := make(map[string]bool, len(order))
+	for _, name := range order {
+		if _, ok := fields[name]; !ok || seen[name] {
+			return false
+		}
+		seen[name] = true
+	}
+	return true
+}
+
+// checkExample validates the example of a type against the type itself
+func checkExample(t *yema.Type, name string) error {
+	if name == "" {
+		name = "example"
+	}
+
+	typ := *t
+	typ.Optional = false
+	schema := &yema.Type{
+		Kind:   yema.Struct,
+		Struct: &map[string]yema.Type{name: typ},
+	}
+
+	if errs := validator.Validate(map[string]interface{}{name: t.Example}, schema); len(errs) != 0 {
+		return errors.Join(errs...)
+	}
+	return nil
+}
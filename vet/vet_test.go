@@ -0,0 +1,148 @@
+package vet
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aep/yema"
+	"github.com/aep/yema/parser"
+)
+
+func TestVet(t *testing.T) {
+	schema, err := parser.FromYAML([]byte(`
+name: string
+empty: {}
+tags: [string]
+settings:
+  nested: {}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// examples are checked by the parser, so only types built in code can carry invalid ones
+	(*schema.Struct)["name"] = yema.Type{Kind: yema.String, Example: 42}
+	(*schema.Struct)["color"] = yema.Type{Kind: yema.String, Enum: []interface{}{"red", 1, "red"}}
+	// an inconsistent order falls back to sorted field names
+	schema.Order = append(schema.Order, "missing")
+
+	want := []string{
+		"root: field order",
+		"color: enum value 1 is not a valid value of the type",
+		"color: enum lists red more than once",
+		"empty: struct declares no fields",
+		"name: example is not a valid value of the type",
+		"settings.nested: struct declares no fields",
+	}
+
+	problems := Vet(schema)
+	if len(problems) != len(want) {
+		t.Fatalf("got problems %v, want %v", problems, want)
+	}
+	for i, p := range problems {
+		if got := p.String(); len(got) < len(want[i]) || got[:len(want[i])] != want[i] {
+			t.Errorf("problem %d = %q, want prefix %q", i, got, want[i])
+		}
+	}
+}
+
+func TestVetValid(t *testing.T) {
+	schema, err := parser.FromYAML([]byte(`
+name:
+  $type: string
+  $example: Bob
+tags: [string]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if problems := Vet(schema); len(problems) != 0 {
+		t.Errorf("got problems %v for a valid schema", problems)
+	}
+}
+
+func TestVetRules(t *testing.T) {
+	schema, err := parser.FromYAML([]byte(`
+name: string
+nick?:
+  $type: string
+  $requires: [name]
+email?:
+  $type: string
+  $conflicts: [name]
+phone?:
+  $type: string
+  $requires: [fax]
+  $conflicts: [fax]
+fax?: string
+pager?:
+  $type: string
+  $requires: [token]
+token?:
+  $type: string
+  $conflicts: [pager]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// unknown fields are rejected by the parser, so only types built in code can refer to them
+	fax := (*schema.Struct)["fax"]
+	fax.Requires = []string{"missing"}
+	(*schema.Struct)["fax"] = fax
+
+	want := []string{
+		"nick: requires field 'name', which is always present",
+		"email: conflicts with field 'name', which is always present",
+		"phone: both requires and conflicts with field 'fax'",
+		"fax: requires unknown field 'missing'",
+		"pager: requires field 'token', which conflicts with it",
+	}
+
+	problems := Vet(schema)
+	if len(problems) != len(want) {
+		t.Fatalf("got problems %v, want %v", problems, want)
+	}
+	for i, p := range problems {
+		if got := p.String(); !strings.HasPrefix(got, want[i]) {
+			t.Errorf("problem %d = %q, want prefix %q", i, got, want[i])
+		}
+	}
+}
+
+func TestDefs(t *testing.T) {
+	data := []byte(`
+$defs:
+  address:
+    street: string
+    geo: {$ref: "#/$defs/geo"}
+  geo:
+    lat: float64
+  unused:
+    point: {$ref: "#/$defs/point"}
+  point:
+    x: int
+  sample:
+    id: string
+home: {$ref: "#/$defs/address"}
+note:
+  $type: string
+  $example: {$ref: "#/$defs/sample"}
+`)
+
+	// Entries only referenced by unused ones or from examples are unused as well
+	want := []string{
+		"$defs.point: type is never referenced",
+		"$defs.sample: type is never referenced",
+		"$defs.unused: type is never referenced",
+	}
+	problems := Defs(data)
+	if len(problems) != len(want) {
+		t.Fatalf("got problems %v, want %v", problems, want)
+	}
+	for i, p := range problems {
+		if got := p.String(); got != want[i] {
+			t.Errorf("problem %d = %q, want %q", i, got, want[i])
+		}
+	}
+}
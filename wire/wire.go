@@ -0,0 +1,397 @@
+// Package wire defines the stable, versioned JSON encoding of yema.Type.
+//
+// External tooling such as exec plugins, registries and the serve API should exchange
+// schemas in this format instead of depending on the layout of the Go structs.
+// Fields of a struct are encoded as a list in declaration order.
+//
+// Version 1 looks like this:
+//
+//	{
+//	  "version": 1,
+//	  "type": {
+//	    "kind": "struct",
+//	    "fields": [
+//	      {"name": "name", "type": {"kind": "string"}},
+//	      {"name": "tags", "type": {"kind": "array", "optional": true, "items": {"kind": "string"}}}
+//	    ]
+//	  }
+//	}
+package wire
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/aep/yema"
+)
+
+// Version is the current version of the encoding.
+// Decoders accept any version up to and including this one.
+// Adding optional attributes does not change the version, older decoders ignore them.
+// New kinds, such as map and union, do not change it either, but decoders that predate a kind
+// reject documents using it as an unknown kind.
+const Version = 1
+
+// Document is the top level envelope of an encoded type
+type Document struct {
+	Version int   `json:"version"`
+	Type    *Type `json:"type"`
+}
+
+// Type is the wire representation of yema.Type
+type Type struct {
+	Kind     string  `json:"kind"`
+	Optional bool    `json:"optional,omitempty"`
+	Items    *Type   `json:"items,omitempty"`
+	Fields   []Field `json:"fields,omitempty"`
+	// Values is the type of the values of a map
+	Values *Type `json:"values,omitempty"`
+	// Variants are the types of a union, in order
+	Variants []*Type `json:"variants,omitempty"`
+	// KeyPattern is a regular expression every key of a map must match
+	KeyPattern string `json:"keyPattern,omitempty"`
+	// Example is an example value of the type
+	Example interface{} `json:"example,omitempty"`
+	// Description documents the type
+	Description string `json:"description,omitempty"`
+	// CodeName is the name generators derive identifiers from instead of the field name
+	CodeName string `json:"codeName,omitempty"`
+	// Enum lists the allowed values of a string or number
+	Enum []interface{} `json:"enum,omitempty"`
+	// Format names a custom validator of the value
+	Format string `json:"format,omitempty"`
+	// FormatArg is the argument of a built-in format, such as the layout of a date-time
+	FormatArg interface{} `json:"formatArg,omitempty"`
+	// Length bounds the number of bytes of bytes once decoded
+	Length *Length `json:"length,omitempty"`
+	// ReadOnly and WriteOnly restrict a field to responses or requests
+	ReadOnly  bool `json:"readOnly,omitempty"`
+	WriteOnly bool `json:"writeOnly,omitempty"`
+	// Ref names the named type the type was expanded from
+	Ref string `json:"ref,omitempty"`
+	// Requires and Conflicts list the sibling fields a struct field requires or conflicts with
+	Requires  []string `json:"requires,omitempty"`
+	Conflicts []string `json:"conflicts,omitempty"`
+	// Unique requires the items of an array to differ from each other
+	Unique bool `json:"unique,omitempty"`
+	// If makes the declaration of a struct field depend on the values of its sibling fields
+	If *Condition `json:"if,omitempty"`
+}
+
+// Condition selects the declaration of a struct field by the values of its sibling fields
+type Condition struct {
+	// Equals maps the names of sibling fields to the value each must hold
+	Equals map[string]interface{} `json:"equals"`
+	// Then and Else replace the declaration of the field while the condition holds or does not
+	Then *Type `json:"then,omitempty"`
+	Else *Type `json:"else,omitempty"`
+}
+
+// Length bounds the number of bytes of a value, inclusively. Max is -1 if there is no upper bound.
+type Length struct {
+	Min int `json:"min"`
+	Max int `json:"max"`
+}
+
+// Field is a single named field of a struct type
+type Field struct {
+	Name string `json:"name"`
+	Type *Type  `json:"type"`
+}
+
+// kindNames are the wire names of each kind. They are part of the format and must never change.
+var kindNames = map[yema.Kind]string{
+	yema.Bool:    "bool",
+	yema.Int:     "int",
+	yema.Int8:    "int8",
+	yema.Int16:   "int16",
+	yema.Int32:   "int32",
+	yema.Int64:   "int64",
+	yema.Uint:    "uint",
+	yema.Uint8:   "uint8",
+	yema.Uint16:  "uint16",
+	yema.Uint32:  "uint32",
+	yema.Uint64:  "uint64",
+	yema.Float32: "float32",
+	yema.Float64: "float64",
+	yema.Array:   "array",
+	yema.Struct:  "struct",
+	yema.String:  "string",
+	yema.Bytes:   "bytes",
+	yema.Map:     "map",
+	yema.Union:   "union",
+}
+
+var kindsByName = func() map[string]yema.Kind {
+	m := make(map[string]yema.Kind, len(kindNames))
+	for kind, name := range kindNames {
+		m[name] = kind
+	}
+	return m
+}()
+
+// Encode converts a yema.Type to a versioned wire document
+func Encode(t *yema.Type) ([]byte, error) {
+	wt, err := FromType(t)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&Document{Version: Version, Type: wt})
+}
+
+// Decode parses a versioned wire document into a yema.Type.
+// Numbers in values such as enums keep their exact value, see ToType.
+func Decode(data []byte) (*yema.Type, error) {
+	var doc Document
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("document has data after its end")
+	}
+	if doc.Version < 1 || doc.Version > Version {
+		return nil, fmt.Errorf("unsupported wire version %d", doc.Version)
+	}
+	if doc.Type == nil {
+		return nil, fmt.Errorf("document has no type")
+	}
+	return doc.Type.ToType()
+}
+
+// FromType converts a yema.Type to its wire representation
+func FromType(t *yema.Type) (*Type, error) {
+	if t == nil {
+		return nil, fmt.Errorf("nil type provided")
+	}
+
+	name, ok := kindNames[t.Kind]
+	if !ok {
+		return nil, fmt.Errorf("unexpected type kind: %v", t.Kind)
+	}
+
+	wt := &Type{
+		Kind:        name,
+		Optional:    t.Optional,
+		Example:     t.Example,
+		Description: t.Description,
+		CodeName:    t.CodeName,
+		Enum:        t.Enum,
+		Format:      t.Format,
+		FormatArg:   t.FormatArg,
+		ReadOnly:    t.ReadOnly,
+		WriteOnly:   t.WriteOnly,
+		Ref:         t.Ref,
+		Requires:    t.Requires,
+		Conflicts:   t.Conflicts,
+		Unique:      t.Unique,
+	}
+	if t.Length != nil {
+		wt.Length = &Length{Min: t.Length.Min, Max: t.Length.Max}
+	}
+
+	if t.If != nil {
+		cond := &Condition{Equals: t.If.Equals}
+		var err error
+		if t.If.Then != nil {
+			if cond.Then, err = FromType(t.If.Then); err != nil {
+				return nil, err
+			}
+		}
+		if t.If.Else != nil {
+			if cond.Else, err = FromType(t.If.Else); err != nil {
+				return nil, err
+			}
+		}
+		wt.If = cond
+	}
+
+	switch t.Kind {
+	case yema.Array:
+		items, err := FromType(t.Array)
+		if err != nil {
+			return nil, err
+		}
+		wt.Items = items
+	case yema.Map:
+		values, err := FromType(t.Map)
+		if err != nil {
+			return nil, err
+		}
+		wt.Values = values
+		wt.KeyPattern = t.KeyPattern
+	case yema.Union:
+		for i := range t.Union {
+			variant, err := FromType(&t.Union[i])
+			if err != nil {
+				return nil, err
+			}
+			wt.Variants = append(wt.Variants, variant)
+		}
+	case yema.Struct:
+		if t.Struct == nil {
+			return nil, fmt.Errorf("struct type with nil Struct field")
+		}
+
+		names := t.FieldNames()
+		wt.Fields = make([]Field, 0, len(names))
+		for _, fieldName := range names {
+			fieldType := (*t.Struct)[fieldName]
+			ft, err := FromType(&fieldType)
+			if err != nil {
+				return nil, err
+			}
+			wt.Fields = append(wt.Fields, Field{Name: fieldName, Type: ft})
+		}
+	}
+
+	return wt, nil
+}
+
+// ToType converts the wire representation back to a yema.Type.
+// Numbers decoded as json.Number in example, enum, formatArg and the values of conditions become int64,
+// or uint64 above its range, if they are integers and float64 otherwise.
+func (wt *Type) ToType() (*yema.Type, error) {
+	kind, ok := kindsByName[wt.Kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown kind %q", wt.Kind)
+	}
+
+	t := &yema.Type{
+		Kind:        kind,
+		Optional:    wt.Optional,
+		Example:     fromNumbers(wt.Example),
+		Description: wt.Description,
+		CodeName:    wt.CodeName,
+		Enum:        fromNumbersList(wt.Enum),
+		Format:      wt.Format,
+		FormatArg:   fromNumbers(wt.FormatArg),
+		ReadOnly:    wt.ReadOnly,
+		WriteOnly:   wt.WriteOnly,
+		Ref:         wt.Ref,
+		Requires:    wt.Requires,
+		Conflicts:   wt.Conflicts,
+		Unique:      wt.Unique,
+	}
+	if wt.Length != nil {
+		t.Length = &yema.Length{Min: wt.Length.Min, Max: wt.Length.Max}
+	}
+
+	if wt.If != nil {
+		cond := &yema.Condition{Equals: wt.If.Equals}
+		if cond.Equals != nil {
+			cond.Equals = fromNumbers(wt.If.Equals).(map[string]interface{})
+		}
+		var err error
+		if wt.If.Then != nil {
+			if cond.Then, err = wt.If.Then.ToType(); err != nil {
+				return nil, fmt.Errorf("then: %w", err)
+			}
+		}
+		if wt.If.Else != nil {
+			if cond.Else, err = wt.If.Else.ToType(); err != nil {
+				return nil, fmt.Errorf("else: %w", err)
+			}
+		}
+		t.If = cond
+	}
+
+	switch kind {
+	case yema.Array:
+		if wt.Items == nil {
+			return nil, fmt.Errorf("array type without items")
+		}
+		items, err := wt.Items.ToType()
+		if err != nil {
+			return nil, err
+		}
+		t.Array = items
+	case yema.Map:
+		if wt.Values == nil {
+			return nil, fmt.Errorf("map type without values")
+		}
+		values, err := wt.Values.ToType()
+		if err != nil {
+			return nil, err
+		}
+		t.Map = values
+		t.KeyPattern = wt.KeyPattern
+	case yema.Union:
+		if len(wt.Variants) == 0 {
+			return nil, fmt.Errorf("union type without variants")
+		}
+		for i, wv := range wt.Variants {
+			if wv == nil {
+				return nil, fmt.Errorf("variant %d has no type", i)
+			}
+			variant, err := wv.ToType()
+			if err != nil {
+				return nil, fmt.Errorf("variant %d: %w", i, err)
+			}
+			t.Union = append(t.Union, *variant)
+		}
+	case yema.Struct:
+		fields := make(map[string]yema.Type, len(wt.Fields))
+		order := make([]string, 0, len(wt.Fields))
+		for _, f := range wt.Fields {
+			if f.Type == nil {
+				return nil, fmt.Errorf("field %q has no type", f.Name)
+			}
+			if _, dup := fields[f.Name]; dup {
+				return nil, fmt.Errorf("duplicate field %q", f.Name)
+			}
+			ft, err := f.Type.ToType()
+			if err != nil {
+				return nil, fmt.Errorf("field %q: %w", f.Name, err)
+			}
+			fields[f.Name] = *ft
+			order = append(order, f.Name)
+		}
+		t.Struct = &fields
+		t.Order = order
+	}
+
+	return t, nil
+}
+
+// fromNumbers returns value with every json.Number in it converted to int64, uint64 or float64
+func fromNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+			return i
+		}
+		if u, err := strconv.ParseUint(string(v), 10, 64); err == nil {
+			return u
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v
+	case []interface{}:
+		return fromNumbersList(v)
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, elem := range v {
+			m[key] = fromNumbers(elem)
+		}
+		return m
+	}
+	return value
+}
+
+// fromNumbersList is like fromNumbers for a list, nil stays nil
+func fromNumbersList(list []interface{}) []interface{} {
+	if list == nil {
+		return nil
+	}
+	converted := make([]interface{}, len(list))
+	for i, elem := range list {
+		converted[i] = fromNumbers(elem)
+	}
+	return converted
+}
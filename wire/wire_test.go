@@ -0,0 +1,143 @@
+package wire
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/aep/yema"
+)
+
+func testType() *yema.Type {
+	return &yema.Type{
+		Kind: yema.Struct,
+		Struct: &map[string]yema.Type{
+			"name":   {Kind: yema.String},
+			"age":    {Kind: yema.Uint8},
+			"avatar": {Kind: yema.Bytes, Optional: true},
+			"scores": {Kind: yema.Array, Array: &yema.Type{Kind: yema.Float64}},
+			"address": {Kind: yema.Struct, Optional: true, Order: []string{"city", "street"}, Struct: &map[string]yema.Type{
+				"street": {Kind: yema.String},
+				"city":   {Kind: yema.String},
+			}},
+		},
+		Order: []string{"address", "age", "avatar", "name", "scores"},
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	data, err := Encode(testType())
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+
+	got, err := Decode(data)
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(got, testType()) {
+		t.Errorf("round trip mismatch:\n got %#v\nwant %#v", got, testType())
+	}
+}
+
+func TestRoundTripComposite(t *testing.T) {
+	want := &yema.Type{Kind: yema.Map, KeyPattern: "^[a-z]+$", Map: &yema.Type{
+		Kind:  yema.Union,
+		Union: []yema.Type{{Kind: yema.String}, {Kind: yema.Array, Array: &yema.Type{Kind: yema.Int}}},
+	}}
+
+	data, err := Encode(want)
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	got, err := Decode(data)
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip mismatch:\n got %#v\nwant %#v", got, want)
+	}
+}
+
+func TestRoundTripRules(t *testing.T) {
+	want := &yema.Type{Kind: yema.Struct, Order: []string{"protocol", "port"}, Struct: &map[string]yema.Type{
+		"protocol": {Kind: yema.String, Description: "Transport protocol"},
+		"port": {Kind: yema.Uint16, Optional: true, Requires: []string{"protocol"}, If: &yema.Condition{
+			Equals: map[string]interface{}{"protocol": "tcp"},
+			Then:   &yema.Type{Kind: yema.Uint16},
+			Else:   &yema.Type{Kind: yema.String, Optional: true},
+		}},
+	}}
+
+	data, err := Encode(want)
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	got, err := Decode(data)
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip mismatch:\n got %#v\nwant %#v", got, want)
+	}
+}
+
+func TestRoundTripNumbers(t *testing.T) {
+	// Integers above 2^53 lose their exact value through float64
+	want := &yema.Type{Kind: yema.Struct, Order: []string{"id", "limit"}, Struct: &map[string]yema.Type{
+		"id": {Kind: yema.Uint64, Enum: []interface{}{int64(9007199254740993), uint64(18446744073709551615), 1.5}, Example: int64(9007199254740993)},
+		"limit": {Kind: yema.Int64, If: &yema.Condition{
+			Equals: map[string]interface{}{"id": int64(9007199254740993)},
+			Else:   &yema.Type{Kind: yema.Int64, Optional: true},
+		}},
+	}}
+
+	data, err := Encode(want)
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	got, err := Decode(data)
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip mismatch:\n got %#v\nwant %#v", got, want)
+	}
+}
+
+// TestCompatibility guards against breaking changes: documents written by
+// older versions of the format must keep decoding to the same type.
+func TestCompatibility(t *testing.T) {
+	data, err := os.ReadFile("testdata/v1.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Decode(data)
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(got, testType()) {
+		t.Errorf("v1 document decoded to\n%#v\nwant\n%#v", got, testType())
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	tests := []string{
+		`{"version": 0, "type": {"kind": "string"}}`,
+		`{"version": 99, "type": {"kind": "string"}}`,
+		`{"version": 1}`,
+		`{"version": 1, "type": {"kind": "nope"}}`,
+		`{"version": 1, "type": {"kind": "array"}}`,
+		`{"version": 1, "type": {"kind": "struct", "fields": [{"name": "a", "type": {"kind": "int"}}, {"name": "a", "type": {"kind": "int"}}]}}`,
+		`{"version": 1, "type": {"kind": "string"}} {}`,
+	}
+
+	for _, src := range tests {
+		if _, err := Decode([]byte(src)); err == nil {
+			t.Errorf("expected error decoding %s", src)
+		}
+	}
+}
@@ -1,5 +1,15 @@
 package yema
 
+import (
+	"errors"
+	"sort"
+	"strconv"
+)
+
+// ErrLimitExceeded is wrapped by the errors reported when an input exceeds a configured resource limit,
+// such as the nesting depth of a document
+var ErrLimitExceeded = errors.New("limit exceeded")
+
 type Kind uint
 
 const (
@@ -21,11 +31,124 @@ const (
 	Struct
 	String
 	Bytes
+	Map
+	Union
 )
 
+var kindNames = [...]string{
+	Invalid: "invalid",
+	Bool:    "bool",
+	Int:     "int",
+	Int8:    "int8",
+	Int16:   "int16",
+	Int32:   "int32",
+	Int64:   "int64",
+	Uint:    "uint",
+	Uint8:   "uint8",
+	Uint16:  "uint16",
+	Uint32:  "uint32",
+	Uint64:  "uint64",
+	Float32: "float32",
+	Float64: "float64",
+	Array:   "array",
+	Struct:  "struct",
+	String:  "string",
+	Bytes:   "bytes",
+	Map:     "map",
+	Union:   "union",
+}
+
+// String returns the name of a kind as used in schemas
+func (k Kind) String() string {
+	if int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return "Kind(" + strconv.Itoa(int(k)) + ")"
+}
+
 type Type struct {
 	Kind     Kind
 	Optional bool
 	Struct   *map[string]Type
 	Array    *Type
+	// Map is the type of the values of a map, whose keys are strings
+	Map *Type
+	// Union lists the variants of a union, a value matches the union if it matches any variant
+	Union []Type
+	// KeyPattern is a regular expression every key of a map must match, empty if keys are unconstrained
+	KeyPattern string
+	// Order lists the field names of Struct in declaration order
+	Order []string
+	// Example is an example value of the type, nil if none was declared
+	Example interface{}
+	// Description documents the type in generated code and JSON Schema, empty if none was declared
+	Description string
+	// CodeName replaces the field name as the source of identifiers in generated code, empty if none was declared
+	CodeName string
+	// Enum lists the values allowed for a string or number, nil if any value of the kind is allowed
+	Enum []interface{}
+	// Requires lists the sibling fields that must be present whenever this field is
+	Requires []string
+	// Conflicts lists the sibling fields that must be absent whenever this field is present
+	Conflicts []string
+	// Unique requires the items of an array to differ from each other, compared by value
+	Unique bool
+	// If makes the declaration of a struct field depend on the values of its sibling fields, nil if it does not
+	If *Condition
+	// Format names a custom validator of the value, such as "ulid", empty if none was declared
+	Format string
+	// FormatArg is the argument of a built-in format, such as the layout of a date-time, nil if none was declared
+	FormatArg interface{}
+	// Length bounds the number of bytes of a bytes type once decoded, nil if it is unconstrained
+	Length *Length
+	// ReadOnly marks a field that is only sent in responses and never accepted in requests
+	ReadOnly bool
+	// WriteOnly marks a field that is only accepted in requests and never sent in responses, such as a password
+	WriteOnly bool
+	// Ref names the entry of $defs the type was expanded from, empty if it was declared in place
+	Ref string
+}
+
+// Condition selects the declaration of a struct field by the values of its sibling fields
+type Condition struct {
+	// Equals maps the names of sibling fields to the value each must hold, the condition holds if all of them do
+	Equals map[string]interface{}
+	// Then and Else replace the declaration of the field while the condition holds or does not, nil keeps it
+	Then *Type
+	Else *Type
+}
+
+// Length bounds the number of bytes of a value, inclusively
+type Length struct {
+	Min int
+	// Max is -1 if the length has no upper bound
+	Max int
+}
+
+// FieldNames returns the field names of a struct type in declaration order.
+// If Order does not describe the fields of Struct, the names are returned sorted instead.
+func (t *Type) FieldNames() []string {
+	if t.Struct == nil {
+		return nil
+	}
+
+	if len(t.Order) == len(*t.Struct) {
+		ordered := true
+		for _, name := range t.Order {
+			if _, ok := (*t.Struct)[name]; !ok {
+				ordered = false
+				break
+			}
+		}
+		if ordered {
+			return t.Order
+		}
+	}
+
+	names := make([]string, 0, len(*t.Struct))
+	for name := range *t.Struct {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }